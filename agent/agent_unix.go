@@ -11,15 +11,19 @@ import (
 )
 
 func main() {
-	config, _ := appconfig.Config(false)
-	// will use default when the value is less than one
-	runtime.GOMAXPROCS(config.Agent.GoMaxProcForAgentWorker)
-
 	// initialize logger
 	log := logger.SSMLogger(true)
 	defer log.Close()
 	defer log.Flush()
 
+	config, err := appconfig.Config(false)
+	if err != nil {
+		log.Errorf("error occurred when loading the app config of ssm-agent-worker: %v", err)
+		return
+	}
+	// will use default when the value is less than one
+	runtime.GOMAXPROCS(config.Agent.GoMaxProcForAgentWorker)
+
 	// parse input parameters
 	parseFlags(log)
 
@@ -15,21 +15,25 @@ const serviceName = "AmazonSSMAgent"
 const imageStateComplete = "IMAGE_STATE_COMPLETE"
 
 func main() {
-	config, _ := appconfig.Config(false)
-	// will use default when the value is less than one
-	runtime.GOMAXPROCS(config.Agent.GoMaxProcForAgentWorker)
-
 	// initialize logger
 	log := ssmlog.SSMLogger(true)
 	defer log.Close()
 	defer log.Flush()
 
+	config, err := appconfig.Config(false)
+	if err != nil {
+		log.Errorf("error occurred when loading the app config of ssm-agent-worker: %v", err)
+		return
+	}
+	// will use default when the value is less than one
+	runtime.GOMAXPROCS(config.Agent.GoMaxProcForAgentWorker)
+
 	// parse input parameters
 	parseFlags(log)
 
 	//Check if there's cloudwatch json config file, and skip hibernation check if configure CW is enabled
 	shouldCheckHibernation := true
-	err := cloudwatch.Instance().Update(log)
+	err = cloudwatch.Instance().Update(log)
 	if err == nil && cloudwatch.Instance().GetIsEnabled() {
 		shouldCheckHibernation = false
 	}
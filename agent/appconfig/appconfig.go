@@ -53,7 +53,10 @@ func Config(reload bool) (SsmagentConfig, error) {
 			fmt.Println("Failed to unmarshal config override. Fall back to default.")
 			return agentConfig, err
 		}
-		parser(&agentConfig)
+		if err := parser(&agentConfig); err != nil {
+			fmt.Printf("Invalid config override: %v\n", err)
+			return agentConfig, err
+		}
 		cache(agentConfig)
 	}
 	return getCached(), nil
@@ -117,8 +120,18 @@ func DefaultConfig() SsmagentConfig {
 		RunCommandLogsRetentionDurationHours:  DefaultRunCommandLogsRetentionDurationHours,
 		SessionLogsRetentionDurationHours:     DefaultSessionLogsRetentionDurationHours,
 		SessionLogsDestination:                SessionLogsDestinationNone,
+		SessionTranscriptFormat:               DefaultSessionTranscriptFormat,
 		PluginLocalOutputCleanup:              DefaultPluginOutputRetention,
 		OrchestrationDirectoryCleanup:         DefaultOrchestrationDirCleanup,
+		OrchestrationDirectoryRetentionDays:   DefaultOrchestrationDirRetentionDays,
+		LocalSecretsDirectory:                 DefaultLocalSecretsFolder,
+		AssociationConcurrencyLimit:           DefaultSsmAssociationConcurrencyLimit,
+		PluginOutputMaxSizeBytes:              DefaultPluginOutputMaxSizeBytes,
+		DocumentMaxStepCount:                  DefaultDocumentMaxStepCount,
+		RunDocumentMaxDepth:                   DefaultRunDocumentMaxDepth,
+		RunDocumentSigningKeysDirectory:       DefaultDocumentSigningKeysFolder,
+		RunDocumentRequireSignature:           false,
+		DisallowedPluginAction:                DisallowedPluginActionFail,
 	}
 	var agent = AgentInfo{
 		Name:                                    "amazon-ssm-agent",
@@ -132,7 +145,15 @@ func DefaultConfig() SsmagentConfig {
 		LongRunningWorkerMonitorIntervalSeconds: defaultLongRunningWorkerMonitorIntervalSeconds,
 		ShouldPurgeInstanceProfileRoleCreds:     false,
 		ForceFileIPC:                            false,
+		WaitForIPCMessageVisibility:             false,
 		GoMaxProcForAgentWorker:                 0,
+		DocumentWorkerHeartbeatTimeoutSeconds:   DefaultDocumentWorkerHeartbeatTimeoutSeconds,
+		DocumentWorkerRespawnLimit:              DefaultDocumentWorkerRespawnLimit,
+		DocumentWorkersLimit:                    DefaultDocumentWorkersLimit,
+		UpdateFreeze:                            false,
+		CompressRotatedLogs:                     true,
+		RotatedLogRetentionCount:                DefaultRotatedLogRetentionCount,
+		MinimumTLSVersion:                       DefaultMinimumTLSVersion,
 	}
 
 	var os = OsInfo{
@@ -16,12 +16,15 @@
 package appconfig
 
 import (
+	"crypto/tls"
+	"fmt"
 	"log"
 	"runtime"
 )
 
-// func parser(config *T) {
-func parser(config *SsmagentConfig) {
+// parser replaces the invalid config values with their defaults. It returns an error for the invalid values
+// that have no safe default, the agent does not start with them.
+func parser(config *SsmagentConfig) error {
 	log.Printf("processing appconfig overrides")
 
 	booleanStringOptions := []string{
@@ -47,6 +50,26 @@ func parser(config *SsmagentConfig) {
 		defaultLongRunningWorkerMonitorIntervalSecondsMin,
 		defaultLongRunningWorkerMonitorIntervalSecondsMax,
 		defaultLongRunningWorkerMonitorIntervalSeconds)
+	config.Agent.DocumentWorkerHeartbeatTimeoutSeconds = getNumericValue(
+		config.Agent.DocumentWorkerHeartbeatTimeoutSeconds,
+		DefaultDocumentWorkerHeartbeatTimeoutSecondsMin,
+		DefaultDocumentWorkerHeartbeatTimeoutSecondsMax,
+		DefaultDocumentWorkerHeartbeatTimeoutSeconds)
+	config.Agent.DocumentWorkerRespawnLimit = getNumericValue(
+		config.Agent.DocumentWorkerRespawnLimit,
+		DefaultDocumentWorkerRespawnLimitMin,
+		DefaultDocumentWorkerRespawnLimitMax,
+		DefaultDocumentWorkerRespawnLimit)
+	config.Agent.DocumentWorkersLimit = getNumericValue(
+		config.Agent.DocumentWorkersLimit,
+		DefaultDocumentWorkersLimitMin,
+		DefaultDocumentWorkersLimitMax,
+		DefaultDocumentWorkersLimit)
+	config.Agent.RotatedLogRetentionCount = getNumericValue(
+		config.Agent.RotatedLogRetentionCount,
+		DefaultRotatedLogRetentionCountMin,
+		DefaultRotatedLogRetentionCountMax,
+		DefaultRotatedLogRetentionCount)
 	config.Agent.SelfUpdateScheduleDay = getNumericValue(
 		config.Agent.SelfUpdateScheduleDay,
 		DefaultSsmSelfUpdateFrequencyDaysMin,
@@ -62,6 +85,9 @@ func parser(config *SsmagentConfig) {
 		DefaultAuditExpirationDayMin,
 		DefaultAuditExpirationDayMax,
 		DefaultAuditExpirationDay)
+	var tlsVersionErr, tlsCipherSuitesErr error
+	config.Agent.MinimumTLSVersion, tlsVersionErr = getMinimumTLSVersion(config.Agent.MinimumTLSVersion)
+	config.Agent.TLSCipherSuites, tlsCipherSuitesErr = getTLSCipherSuites(config.Agent.TLSCipherSuites)
 
 	// MDS config
 	config.Mds.CommandWorkersLimit = getNumericValue(
@@ -106,6 +132,24 @@ func parser(config *SsmagentConfig) {
 		DefaultSsmAssociationFrequencyMinutesMin,
 		DefaultSsmAssociationFrequencyMinutesMax,
 		DefaultSsmAssociationFrequencyMinutes)
+	config.Ssm.AssociationConcurrencyLimit = getNumericValue(
+		config.Ssm.AssociationConcurrencyLimit,
+		DefaultSsmAssociationConcurrencyLimitMin,
+		DefaultSsmAssociationConcurrencyLimitMax,
+		DefaultSsmAssociationConcurrencyLimit)
+	config.Ssm.PluginOutputMaxSizeBytes = getNumericValue(
+		config.Ssm.PluginOutputMaxSizeBytes,
+		DefaultPluginOutputMaxSizeBytesMin,
+		DefaultPluginOutputMaxSizeBytesMax,
+		DefaultPluginOutputMaxSizeBytes)
+	config.Ssm.DocumentMaxStepCount = getNumericValueAboveMin(
+		config.Ssm.DocumentMaxStepCount,
+		DefaultDocumentMaxStepCountMin,
+		DefaultDocumentMaxStepCount)
+	config.Ssm.RunDocumentMaxDepth = getNumericValueAboveMin(
+		config.Ssm.RunDocumentMaxDepth,
+		DefaultRunDocumentMaxDepthMin,
+		DefaultRunDocumentMaxDepth)
 	config.Ssm.AssociationLogsRetentionDurationHours = getNumericValueAboveMin(
 		config.Ssm.AssociationLogsRetentionDurationHours,
 		DefaultStateOrchestrationLogsRetentionDurationHoursMin,
@@ -118,6 +162,10 @@ func parser(config *SsmagentConfig) {
 	config.Ssm.SessionLogsDestination = getStringEnum(config.Ssm.SessionLogsDestination,
 		sessionLogsDestinationOptions,
 		SessionLogsDestinationNone)
+	sessionTranscriptFormatOptions := []string{SessionTranscriptFormatRaw, SessionTranscriptFormatJSONLines}
+	config.Ssm.SessionTranscriptFormat = getStringEnum(config.Ssm.SessionTranscriptFormat,
+		sessionTranscriptFormatOptions,
+		DefaultSessionTranscriptFormat)
 	pluginLocalOutputCleanupOptions := []string{PluginLocalOutputCleanupAfterExecution,
 		PluginLocalOutputCleanupAfterUpload,
 		DefaultPluginOutputRetention}
@@ -133,6 +181,17 @@ func parser(config *SsmagentConfig) {
 	config.Ssm.OrchestrationDirectoryCleanup = getStringEnum(config.Ssm.OrchestrationDirectoryCleanup,
 		OrchestartionDirCleanupOtions,
 		DefaultOrchestrationDirCleanup)
+	config.Ssm.OrchestrationDirectoryRetentionDays = getNumericValueAboveMin(
+		config.Ssm.OrchestrationDirectoryRetentionDays,
+		DefaultOrchestrationDirRetentionDaysMin,
+		DefaultOrchestrationDirRetentionDays)
+
+	config.Ssm.DisallowedPluginAction = getStringEnum(config.Ssm.DisallowedPluginAction,
+		[]string{DisallowedPluginActionFail, DisallowedPluginActionSkip},
+		DisallowedPluginActionFail)
+
+	config.Ssm.LocalSecretsDirectory = getStringValue(config.Ssm.LocalSecretsDirectory, DefaultLocalSecretsFolder)
+	config.Ssm.RunDocumentSigningKeysDirectory = getStringValue(config.Ssm.RunDocumentSigningKeysDirectory, DefaultDocumentSigningKeysFolder)
 
 	config.Identity.Ec2SystemInfoDetectionResponse = getStringEnum(config.Identity.Ec2SystemInfoDetectionResponse, booleanStringOptions, "")
 	IdentityConsumptionOrderOptions := map[string]bool{
@@ -151,6 +210,10 @@ func parser(config *SsmagentConfig) {
 	for _, customIdentity := range config.Identity.CustomIdentities {
 		customIdentity.CredentialsProvider = getStringEnumMap(customIdentity.CredentialsProvider, CredentialsProviderOptions, DefaultCustomIdentityCredentialsProvider)
 	}
+	if tlsVersionErr != nil {
+		return tlsVersionErr
+	}
+	return tlsCipherSuitesErr
 }
 
 // getStringValue returns the default value if config is empty, else the config value
@@ -224,3 +287,42 @@ func getStringEnumMap(configValue string, possibleValues map[string]bool, defaul
 	}
 	return defaultValue
 }
+
+// getMinimumTLSVersion returns the configured minimum TLS version, raising the versions below TLS 1.2 to TLS 1.2.
+// It returns an error for an unknown version rather than guessing which version was meant.
+func getMinimumTLSVersion(configValue string) (string, error) {
+	switch configValue {
+	case MinimumTLSVersion12, MinimumTLSVersion13:
+		return configValue, nil
+	case "":
+		return DefaultMinimumTLSVersion, nil
+	case "1.0", "1.1":
+		log.Printf("MinimumTLSVersion %v is not allowed, using TLS %v", configValue, MinimumTLSVersion12)
+		return MinimumTLSVersion12, nil
+	}
+	return "", fmt.Errorf("MinimumTLSVersion %v is not supported, the supported values are %v and %v",
+		configValue, MinimumTLSVersion12, MinimumTLSVersion13)
+}
+
+// getTLSCipherSuites removes the unknown and insecure cipher suites from the configured cipher suites.
+// It returns an error when none of the configured cipher suites is left, rather than widening the restricted
+// cipher suites to the default ones.
+func getTLSCipherSuites(configValue []string) ([]string, error) {
+	secureCipherSuites := make(map[string]bool)
+	for _, cipherSuite := range tls.CipherSuites() {
+		secureCipherSuites[cipherSuite.Name] = true
+	}
+
+	var result []string
+	for _, cipherSuite := range configValue {
+		if !secureCipherSuites[cipherSuite] {
+			log.Printf("TLSCipherSuites %v is not a known secure cipher suite, ignoring it", cipherSuite)
+			continue
+		}
+		result = append(result, cipherSuite)
+	}
+	if len(configValue) > 0 && len(result) == 0 {
+		return nil, fmt.Errorf("none of the TLSCipherSuites %v is a known secure cipher suite", configValue)
+	}
+	return result, nil
+}
@@ -161,3 +161,86 @@ func TestIdentityCredentialsValue_InvalidCredentialsProviderToDefault(t *testing
 	parser(&agentConfig)
 	assert.Equal(t, agentConfig.Identity.CustomIdentities[0].CredentialsProvider, DefaultCustomIdentityCredentialsProvider)
 }
+
+func TestPluginOutputMaxSizeBytes(t *testing.T) {
+	for configValue, expected := range map[int]int{
+		0:       DefaultPluginOutputMaxSizeBytes,
+		2499:    DefaultPluginOutputMaxSizeBytes,
+		2500:    2500,
+		48000:   48000,
+		1000000: DefaultPluginOutputMaxSizeBytes,
+	} {
+		agentConfig := DefaultConfig()
+		agentConfig.Ssm.PluginOutputMaxSizeBytes = configValue
+		parser(&agentConfig)
+		assert.Equal(t, expected, agentConfig.Ssm.PluginOutputMaxSizeBytes, configValue)
+	}
+}
+
+func TestAssociationConcurrencyLimit(t *testing.T) {
+	for configValue, expected := range map[int]int{
+		-1: DefaultSsmAssociationConcurrencyLimit,
+		0:  0,
+		3:  3,
+		10: 10,
+		11: DefaultSsmAssociationConcurrencyLimit,
+	} {
+		agentConfig := DefaultConfig()
+		agentConfig.Ssm.AssociationConcurrencyLimit = configValue
+		parser(&agentConfig)
+		assert.Equal(t, expected, agentConfig.Ssm.AssociationConcurrencyLimit, configValue)
+	}
+	assert.Equal(t, 0, DefaultConfig().Ssm.AssociationConcurrencyLimit)
+}
+
+func TestMinimumTLSVersion(t *testing.T) {
+	for configValue, expected := range map[string]string{
+		"":    MinimumTLSVersion12,
+		"1.0": MinimumTLSVersion12,
+		"1.1": MinimumTLSVersion12,
+		"1.2": MinimumTLSVersion12,
+		"1.3": MinimumTLSVersion13,
+	} {
+		agentConfig := DefaultConfig()
+		agentConfig.Agent.MinimumTLSVersion = configValue
+		assert.NoError(t, parser(&agentConfig), configValue)
+		assert.Equal(t, expected, agentConfig.Agent.MinimumTLSVersion, configValue)
+	}
+}
+
+func TestMinimumTLSVersion_UnknownVersionIsAnError(t *testing.T) {
+	for _, configValue := range []string{"2.0", "1.4", "TLS1.2"} {
+		agentConfig := DefaultConfig()
+		agentConfig.Agent.MinimumTLSVersion = configValue
+
+		err := parser(&agentConfig)
+
+		assert.Error(t, err, configValue)
+		assert.Contains(t, err.Error(), configValue)
+	}
+}
+
+func TestTLSCipherSuites_RemovesUnknownAndInsecureCipherSuites(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Agent.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_RC4_128_SHA", "InvalidCipherSuite"}
+	assert.NoError(t, parser(&agentConfig))
+
+	assert.Equal(t, []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, agentConfig.Agent.TLSCipherSuites)
+}
+
+func TestTLSCipherSuites_NoAllowedCipherSuiteIsAnError(t *testing.T) {
+	agentConfig := DefaultConfig()
+	agentConfig.Agent.TLSCipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA", "InvalidCipherSuite"}
+
+	err := parser(&agentConfig)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "InvalidCipherSuite")
+}
+
+func TestTLSCipherSuites_NotConfiguredUsesDefaults(t *testing.T) {
+	agentConfig := DefaultConfig()
+
+	assert.NoError(t, parser(&agentConfig))
+	assert.Empty(t, agentConfig.Agent.TLSCipherSuites)
+}
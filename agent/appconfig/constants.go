@@ -61,6 +61,26 @@ const (
 	DefaultSsmAssociationFrequencyMinutesMin = 5
 	DefaultSsmAssociationFrequencyMinutesMax = 60
 
+	// a concurrency limit of 0 does not limit the executions of an association
+	DefaultSsmAssociationConcurrencyLimit    = 0
+	DefaultSsmAssociationConcurrencyLimitMin = 0
+	DefaultSsmAssociationConcurrencyLimitMax = 10
+
+	// the max keeps the output of every step within the size of a single reply to the service
+	DefaultPluginOutputMaxSizeBytes    = 24000
+	DefaultPluginOutputMaxSizeBytesMin = 2500
+	DefaultPluginOutputMaxSizeBytesMax = 48000
+
+	DefaultDocumentMaxStepCount    = 1000
+	DefaultDocumentMaxStepCountMin = 1
+
+	DefaultRunDocumentMaxDepth    = 5
+	DefaultRunDocumentMaxDepthMin = 1
+
+	// 0 disables the age based sweep of the orchestration directories
+	DefaultOrchestrationDirRetentionDays    = 0
+	DefaultOrchestrationDirRetentionDaysMin = 0
+
 	DefaultSsmSelfUpdateFrequencyDays    = 7
 	DefaultSsmSelfUpdateFrequencyDaysMin = 1 //Minimum frequency is 1 day
 	DefaultSsmSelfUpdateFrequencyDaysMax = 7 //Maximum frequency is 7 day
@@ -87,6 +107,12 @@ const (
 	// Don't delete orchestration folder after execution
 	DefaultOrchestrationDirCleanup = "default"
 
+	// DisallowedPluginAction
+	// Fail the steps whose plugin is not in AllowedPlugins
+	DisallowedPluginActionFail = "fail"
+	// Skip the steps whose plugin is not in AllowedPlugins
+	DisallowedPluginActionSkip = "skip"
+
 	// Don't delete logs immediately after execution. Fall back to AssociationLogsRetentionDurationHours,
 	// RunCommandLogsRetentionDurationHours, and SessionLogsRetentionDurationHours
 	DefaultPluginOutputRetention = "default"
@@ -105,6 +131,16 @@ const (
 	SessionLogsDestinationDisk = "disk"
 	SessionLogsDestinationNone = "none"
 
+	// persisted session transcript formats
+	SessionTranscriptFormatRaw       = "raw"
+	SessionTranscriptFormatJSONLines = "jsonl"
+	DefaultSessionTranscriptFormat   = SessionTranscriptFormatRaw
+
+	// minimum TLS versions of the agent HTTP clients
+	MinimumTLSVersion12      = "1.2"
+	MinimumTLSVersion13      = "1.3"
+	DefaultMinimumTLSVersion = MinimumTLSVersion12
+
 	//aws-ssm-agent bookkeeping constants for long running plugins
 	LongRunningPluginsLocation         = "longrunningplugins"
 	LongRunningPluginsHealthCheck      = "healthcheck"
@@ -149,6 +185,22 @@ const (
 	defaultLongRunningWorkerMonitorIntervalSecondsMin = 30
 	defaultLongRunningWorkerMonitorIntervalSecondsMax = 1800
 
+	DefaultDocumentWorkerHeartbeatTimeoutSeconds    = 600
+	DefaultDocumentWorkerHeartbeatTimeoutSecondsMin = 120
+	DefaultDocumentWorkerHeartbeatTimeoutSecondsMax = 172800
+
+	DefaultDocumentWorkerRespawnLimit    = 0
+	DefaultDocumentWorkerRespawnLimitMin = 0
+	DefaultDocumentWorkerRespawnLimitMax = 5
+
+	DefaultDocumentWorkersLimit    = 0
+	DefaultDocumentWorkersLimitMin = 0
+	DefaultDocumentWorkersLimitMax = 100
+
+	DefaultRotatedLogRetentionCount    = 5
+	DefaultRotatedLogRetentionCountMin = 1
+	DefaultRotatedLogRetentionCountMax = 100
+
 	defaultProfileKeyAutoRotateDays    = 0
 	defaultProfileKeyAutoRotateDaysMin = 0
 	defaultProfileKeyAutoRotateDaysMax = 365
@@ -220,6 +272,15 @@ const (
 	// PluginNameAwsApplications is the name of the Applications plugin
 	PluginNameAwsApplications = "aws:applications"
 
+	// PluginNameAwsCheckCertificateExpiry is the name of the certificate expiry check plugin
+	PluginNameAwsCheckCertificateExpiry = "aws:checkCertificateExpiry"
+
+	// PluginNameAwsConfigureRegistry is the name of the registry configuration plugin
+	PluginNameAwsConfigureRegistry = "aws:configureRegistry"
+
+	// PluginNameAwsConfigureKernelModules is the name of the kernel module configuration plugin
+	PluginNameAwsConfigureKernelModules = "aws:configureKernelModules"
+
 	AppConfigFileName = "amazon-ssm-agent.json"
 
 	SeelogConfigFileName = "seelog.xml"
@@ -80,6 +80,12 @@ var (
 	// Default Custom Inventory Inventory Folder
 	DefaultCustomInventoryFolder = DefaultDataStorePath + "inventory/custom"
 
+	// Default folder for secrets resolved by {{ localsecret:name }} references
+	DefaultLocalSecretsFolder = DefaultDataStorePath + "localsecrets"
+
+	// Default folder for the public keys verifying the signature of aws:runDocument sub-documents
+	DefaultDocumentSigningKeysFolder = DefaultDataStorePath + "documentsigningkeys"
+
 	// Default Session files Folder
 	SessionFilesPath = DefaultDataStorePath + "session"
 
@@ -87,6 +87,12 @@ var (
 	// Default Custom Inventory Inventory Folder
 	DefaultCustomInventoryFolder = AgentData + "inventory/custom"
 
+	// Default folder for secrets resolved by {{ localsecret:name }} references
+	DefaultLocalSecretsFolder = AgentData + "localsecrets"
+
+	// Default folder for the public keys verifying the signature of aws:runDocument sub-documents
+	DefaultDocumentSigningKeysFolder = AgentData + "documentsigningkeys"
+
 	// Default Session files Folder
 	SessionFilesPath = AgentData + "session"
 
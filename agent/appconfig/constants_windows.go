@@ -171,6 +171,12 @@ var EnvWinDir string
 // Default Custom Inventory Data Folder
 var DefaultCustomInventoryFolder string
 
+// Default folder for secrets resolved by {{ localsecret:name }} references
+var DefaultLocalSecretsFolder string
+
+// Default folder for the public keys verifying the signature of aws:runDocument sub-documents
+var DefaultDocumentSigningKeysFolder string
+
 // SSM Agent Update download legacy path
 var LegacyUpdateDownloadFolder string
 
@@ -218,6 +224,8 @@ func init() {
 	LegacyUpdateDownloadFolder = DownloadRoot
 
 	DefaultCustomInventoryFolder = filepath.Join(SSMDataPath, "Inventory", "Custom")
+	DefaultLocalSecretsFolder = filepath.Join(SSMDataPath, "LocalSecrets")
+	DefaultDocumentSigningKeysFolder = filepath.Join(SSMDataPath, "DocumentSigningKeys")
 	EC2UpdateArtifactsRoot = filepath.Join(programData, EC2ConfigAppDataFolder, "Updater")
 	EC2UpdaterDownloadRoot = filepath.Join(programData, EC2ConfigAppDataFolder, "Downloads")
 	EC2ConfigDataStorePath = filepath.Join(programData, EC2ConfigAppDataFolder, "InstanceData")
@@ -46,10 +46,34 @@ type SsmCfg struct {
 	SessionLogsRetentionDurationHours int
 	// Configure where you want Session Manager to write session data
 	SessionLogsDestination string
+	// Format of the persisted session transcript, "raw" byte stream or "jsonl" for one timestamped JSON object per output chunk
+	SessionTranscriptFormat string
+	// Strip operating system commands, such as OSC 8 hyperlinks, from the persisted session transcript, the live session is unchanged
+	SessionTranscriptStripOSC bool
 	// Configure when after execution it is safe to delete local plugin output files in orchestration folder
 	PluginLocalOutputCleanup string
 	// Configure only when it is safe to delete orchestration folder after document execution. This config overrides PluginLocalOutputCleanup when set.
 	OrchestrationDirectoryCleanup string
+	// Days after which orchestration directories are deleted by a periodic sweep whatever the outcome of their document, 0 disables the sweep
+	OrchestrationDirectoryRetentionDays int
+	// Directory holding the files resolved by {{ localsecret:name }} document references
+	LocalSecretsDirectory string
+	// Maximum number of executions of a single association allowed to run on the instance at once
+	AssociationConcurrencyLimit int
+	// Maximum number of bytes of stdout or stderr a plugin keeps in memory while its output is captured
+	PluginOutputMaxSizeBytes int
+	// Maximum number of steps a document may define, larger documents are rejected when they are parsed
+	DocumentMaxStepCount int
+	// Maximum number of nested aws:runDocument executions, deeper sub-documents fail instead of running
+	RunDocumentMaxDepth int
+	// Directory holding the PEM encoded public keys, named <keyId>.pem, that verify the signature of aws:runDocument sub-documents
+	RunDocumentSigningKeysDirectory string
+	// Reject the aws:runDocument sub-documents that are not signed by one of the keys of RunDocumentSigningKeysDirectory
+	RunDocumentRequireSignature bool
+	// Names of the only plugins documents are allowed to run, all plugins are allowed when empty
+	AllowedPlugins []string
+	// Whether the steps of plugins missing from AllowedPlugins are failed or skipped
+	DisallowedPluginAction string
 }
 
 // AgentInfo represents metadata for amazon-ssm-agent
@@ -71,8 +95,33 @@ type AgentInfo struct {
 	ShouldPurgeInstanceProfileRoleCreds bool
 	AuditExpirationDay                  int
 	ForceFileIPC                        bool
+	// Waits for IPC message files seen missing or empty, for orchestration directories on slow syncing file systems
+	WaitForIPCMessageVisibility bool
 	// denotes GOMAXPROCS value for legacy agent worker
 	GoMaxProcForAgentWorker int
+	// Seconds without heartbeat after which a document worker process is considered crashed or disconnected
+	DocumentWorkerHeartbeatTimeoutSeconds int
+	// Times a crashed document worker is re-spawned to resume its document, 0 fails the document on the first crash
+	DocumentWorkerRespawnLimit int
+	// Documents run at the same time by the command and association processors, the others are queued, 0 does not limit them
+	DocumentWorkersLimit int
+	// Defers agent updates while set, optionally only between the RFC3339 start and end times
+	UpdateFreeze          bool
+	UpdateFreezeStartTime string
+	UpdateFreezeEndTime   string
+	// Hosts the agent and its plugins may send requests to, as host names or patterns such as *.example.com.
+	// Empty allows every host. The SSM, S3, instance metadata and configured service endpoints are always allowed.
+	AllowedEndpoints []string
+	// Rotated agent log files are compressed with gzip when set
+	CompressRotatedLogs bool
+	// Rotated files kept for each agent log file, the oldest ones are deleted on rotation
+	RotatedLogRetentionCount int
+	// Minimum TLS version of the agent HTTP clients, 1.2 or 1.3. Lower versions are raised to 1.2.
+	MinimumTLSVersion string
+	// TLS 1.2 cipher suites the agent HTTP clients may use, by their standard names such as TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	// Empty uses the Go defaults, unknown and insecure cipher suites are ignored, and the agent does not start when none
+	// of the configured cipher suites is left. TLS 1.3 cipher suites are not configurable.
+	TLSCipherSuites []string
 }
 
 // MgsConfig represents configuration for Message Gateway service
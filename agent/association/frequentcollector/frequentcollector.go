@@ -42,6 +42,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 
 	AssociationModel "github.com/aws/amazon-ssm-agent/agent/association/model"
+	"github.com/aws/amazon-ssm-agent/agent/association/throttle"
 	InventoryModel "github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
 )
 
@@ -49,6 +50,8 @@ const (
 	minIntervalInSeconds          float64 = 300
 	paramChangeDetectionFrequency         = "changeDetectionFrequency"
 	paramChangeDetectionTypes             = "changeDetectionTypes"
+	// frequentCollectionExecutionID holds the execution slot of the collections, which run one at a time
+	frequentCollectionExecutionID = "frequentCollection"
 )
 
 type FrequentCollector struct {
@@ -95,7 +98,8 @@ func (collector *FrequentCollector) resetTicker(d time.Duration) *time.Ticker {
 }
 
 // StartFrequentCollector starts the frequent collector per association configuration.
-func (collector *FrequentCollector) StartFrequentCollector(context context.T, docState *contracts.DocumentState, scheduledAssociation *AssociationModel.InstanceAssociation) {
+// A collection is skipped when the association has no execution slot left in associationThrottle.
+func (collector *FrequentCollector) StartFrequentCollector(context context.T, docState *contracts.DocumentState, scheduledAssociation *AssociationModel.InstanceAssociation, associationThrottle *throttle.Throttle) {
 	collector.mutex.RLock()
 	defer collector.mutex.RUnlock()
 
@@ -122,7 +126,7 @@ func (collector *FrequentCollector) StartFrequentCollector(context context.T, do
 		}()
 		for t := range ticker.C {
 			log.Infof("Frequent collector, tick at %s, ticker address : %p", t.Format(time.UnixDate), collector.tickerForFrequentCollector)
-			collector.collect(context, docState)
+			collector.collectWithinLimit(context, docState, associationThrottle)
 		}
 	}()
 }
@@ -236,6 +240,18 @@ func (collector *FrequentCollector) IsSoftwareInventoryAssociation(docState *con
 	return collector.getInventoryPluginState(docState) != nil
 }
 
+// collectWithinLimit runs collect only when the association has an execution slot left
+func (collector *FrequentCollector) collectWithinLimit(context context.T, docState *contracts.DocumentState, associationThrottle *throttle.Throttle) {
+	associationID := docState.DocumentInformation.AssociationID
+	if !associationThrottle.TryAcquire(associationID, frequentCollectionExecutionID) {
+		context.Log().Infof("Frequent collector, association %v reached its concurrency limit, skipping collection", associationID)
+		return
+	}
+	defer associationThrottle.Release(associationID, frequentCollectionExecutionID)
+
+	collector.collect(context, docState)
+}
+
 // collect collects the dirty inventory types and report to SSM if there's any
 func (collector *FrequentCollector) collect(context context.T, docState *contracts.DocumentState) {
 	log := context.Log()
@@ -39,6 +39,7 @@ func getPluginAssociationInstances() map[string]AssocList {
 // bookkeepingService represents the dependency for docmanager
 type bookkeepingService interface {
 	DeleteOldOrchestrationDirectories(log log.T, instanceID, orchestrationRootDirName string, retentionDurationHours int, associationRetentionDurationHours int)
+	DeleteExpiredOrchestrationDirectories(log log.T, instanceID, orchestrationRootDirName string, retentionDays int)
 }
 
 type assocBookkeepingService struct{}
@@ -47,6 +48,10 @@ func (assocBookkeepingService) DeleteOldOrchestrationDirectories(log log.T, inst
 	docmanager.DeleteOldOrchestrationDirectories(log, instanceID, orchestrationRootDirName, retentionDurationHours, associationRetentionDurationHours)
 }
 
+func (assocBookkeepingService) DeleteExpiredOrchestrationDirectories(log log.T, instanceID, orchestrationRootDirName string, retentionDays int) {
+	docmanager.DeleteExpiredOrchestrationDirectories(log, instanceID, orchestrationRootDirName, retentionDays)
+}
+
 // parserService represents the dependency for association parser
 type parserService interface {
 	ParseDocumentForPayload(log log.T, rawData *model.InstanceAssociation) (*messageContract.SendCommandPayload, error)
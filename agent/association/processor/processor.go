@@ -31,6 +31,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager/signal"
 	assocScheduler "github.com/aws/amazon-ssm-agent/agent/association/scheduler"
 	"github.com/aws/amazon-ssm-agent/agent/association/service"
+	"github.com/aws/amazon-ssm-agent/agent/association/throttle"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor"
@@ -48,6 +49,7 @@ const (
 	documentLevelTimeOutDurationHour        = 2
 	outputMessageTemplate            string = "%v out of %v plugin%v processed, %v success, %v failed, %v timedout, %v skipped. %v"
 	defaultRetryWaitOnBootInSeconds         = 30
+	throttledRetryWaitInSeconds             = 60
 )
 
 // Processor contains the logic for processing association
@@ -60,6 +62,7 @@ type Processor struct {
 	proc               processor.Processor
 	resChan            chan contracts.DocumentResult
 	onBoot             bool
+	throttle           *throttle.Throttle
 }
 
 var lock sync.RWMutex
@@ -93,6 +96,7 @@ func NewAssociationProcessor(context context.T) *Processor {
 		agentInfo:          &agentInfo,
 		proc:               proc,
 		onBoot:             true,
+		throttle:           throttle.NewThrottle(config.Ssm.AssociationConcurrencyLimit),
 	}
 }
 
@@ -365,6 +369,16 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		return
 	}
 
+	// scheduled runs of an association do not overlap, the slot is only held by frequent inventory collections here,
+	// the run stays scheduled and is retried once they had time to complete
+	associationID := docState.DocumentInformation.AssociationID
+	documentID := docState.DocumentInformation.DocumentID
+	if !p.throttle.TryAcquire(associationID, documentID) {
+		log.Infof("Association %v reached its concurrency limit, retrying in %v seconds", associationID, throttledRetryWaitInSeconds)
+		signal.ResetWaitTimerForNextScheduledAssociation(log, time.Now().Add(throttledRetryWaitInSeconds*time.Second))
+		return
+	}
+
 	updatePluginAssociationInstances(*scheduledAssociation.Association.AssociationId, docState)
 	log = p.context.With("[associationId=" + docState.DocumentInformation.AssociationID + "]").Log()
 	instanceID, _ := p.context.Identity().InstanceID()
@@ -381,7 +395,12 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 
 	log.Debug("runScheduledAssociation submitting document")
 
-	p.proc.Submit(*docState)
+	if errorCode := p.proc.Submit(*docState); errorCode != "" {
+		// the document will not report a result, so its execution slot has to be released here
+		log.Warnf("Association %v was not submitted, %v", associationID, errorCode)
+		p.throttle.Release(associationID, documentID)
+		return
+	}
 
 	log.Debug("runScheduledAssociation submitted document")
 
@@ -391,7 +410,7 @@ func (p *Processor) runScheduledAssociation(log log.T) {
 		frequentCollector.ClearTicker()
 		if frequentCollector.IsFrequentCollectorEnabled(p.context, docState, scheduledAssociation) {
 			log.Infof("This software inventory association enabled frequent collector")
-			frequentCollector.StartFrequentCollector(p.context, docState, scheduledAssociation)
+			frequentCollector.StartFrequentCollector(p.context, docState, scheduledAssociation, p.throttle)
 		}
 	}
 }
@@ -536,6 +555,22 @@ func (r *Processor) listenToResponses() {
 		}
 	}()
 	for res := range r.resChan {
+		if res.Status == contracts.ResultStatusQueued {
+			// a queued association stays InProgress, Pending would schedule it to run again
+			log.Infof("Association %v is queued", res.AssociationID)
+			instanceID, _ := r.context.Identity().InstanceID()
+			r.assocSvc.UpdateInstanceAssociationStatus(
+				log,
+				res.AssociationID,
+				res.DocumentName,
+				instanceID,
+				contracts.AssociationStatusInProgress,
+				contracts.AssociationErrorCodeNoError,
+				times.ToIso8601UTC(time.Now()),
+				contracts.AssociationQueuedMessage,
+				service.NoOutputUrl)
+			continue
+		}
 		if res.LastPlugin != "" {
 			log.Infof("update association status upon plugin $v completion", res.LastPlugin)
 			r.pluginExecutionReport(log, res.AssociationID, res.LastPlugin, res.PluginResults, res.NPlugins)
@@ -546,6 +581,7 @@ func (r *Processor) listenToResponses() {
 		}
 		//send asociation completion response
 		if res.LastPlugin == "" {
+			r.throttle.Release(res.AssociationID, res.DocumentID)
 			log.Debug("Association execution completion: ", res.AssociationID)
 			log.Debug("Association execution status is ", res.Status)
 			if res.Status == contracts.ResultStatusFailed {
@@ -573,6 +609,8 @@ func (r *Processor) listenToResponses() {
 					contracts.AssociationErrorCodeNoError,
 					string(res.Status))
 			} else if res.Status == contracts.ResultStatusInProgress {
+				log.Warnf("Association %v completed InProgress, step %v started at %v did not complete",
+					res.AssociationID, res.LastInProgressPluginID, res.LastInProgressTime)
 				// reset the association to pending if it's still in progress after the command finish
 				r.associationExecutionReport(
 					log,
@@ -593,6 +631,10 @@ func (r *Processor) listenToResponses() {
 				r.context.AppConfig().Agent.OrchestrationRootDir,
 				r.context.AppConfig().Ssm.RunCommandLogsRetentionDurationHours,
 				r.context.AppConfig().Ssm.AssociationLogsRetentionDurationHours)
+			go assocBookkeeping.DeleteExpiredOrchestrationDirectories(log,
+				instanceID,
+				r.context.AppConfig().Agent.OrchestrationRootDir,
+				r.context.AppConfig().Ssm.OrchestrationDirectoryRetentionDays)
 			//TODO move this part to service
 			schedulemanager.UpdateNextScheduledDate(log, res.AssociationID)
 			signal.ExecuteAssociation(log)
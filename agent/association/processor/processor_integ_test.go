@@ -186,8 +186,8 @@ func TestParseAssociationWithAssociationVersion2_0(t *testing.T) {
 	assert.Equal(t, action0, pluginInfo1.Name)
 	assert.Equal(t, action1, pluginInfo2.Name)
 
-	expectProp1 := map[string]interface{}{"id": "0.aws:psModule", "runCommand": *source0[0]}
-	expectProp2 := map[string]interface{}{"id": "1.aws:psModule", "runCommand": *source1[0]}
+	expectProp1 := map[string]interface{}{"id": "0.aws:psModule", "runCommand": []interface{}{*source0[0]}}
+	expectProp2 := map[string]interface{}{"id": "1.aws:psModule", "runCommand": []interface{}{*source1[0]}}
 
 	assert.Equal(t, expectProp1, pluginInfo1.Configuration.Properties)
 	assert.Equal(t, expectProp2, pluginInfo2.Configuration.Properties)
@@ -273,7 +273,7 @@ func TestParseAssociationWithAssociationVersion2_0_StringMapParams(t *testing.T)
 	assert.Equal(t, action1, pluginInfo2.Name)
 
 	expectProp1 := map[string]interface{}{"sourceType": "SSMDocument", "sourceInfo": *source0[0], "id": "0.aws.downloadContent"}
-	expectProp2 := map[string]interface{}{"id": "1.aws:psModule", "runCommand": *source1[0]}
+	expectProp2 := map[string]interface{}{"id": "1.aws:psModule", "runCommand": []interface{}{*source1[0]}}
 
 	assert.Equal(t, expectProp1, pluginInfo1.Configuration.Properties)
 	assert.Equal(t, expectProp2, pluginInfo2.Configuration.Properties)
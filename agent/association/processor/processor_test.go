@@ -24,7 +24,9 @@ import (
 	complianceUploader "github.com/aws/amazon-ssm-agent/agent/association/mocks/uploader"
 	"github.com/aws/amazon-ssm-agent/agent/association/model"
 	"github.com/aws/amazon-ssm-agent/agent/association/schedulemanager"
+	"github.com/aws/amazon-ssm-agent/agent/association/throttle"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	frameworkprocessor "github.com/aws/amazon-ssm-agent/agent/framework/processor"
 	processormock "github.com/aws/amazon-ssm-agent/agent/framework/processor/mock"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -289,9 +291,112 @@ func mockParser(parserMock *processor2.ParserMock, payload *messageContracts.Sen
 		mock.AnythingOfType("*model.InstanceAssociation")).Return(docState)
 }
 
+func scheduleAssociationToRunNow(t *testing.T) []*model.InstanceAssociation {
+	assocRawData := createAssociationRawData()
+	assocRawData[0].Association.DetailedStatus = aws.String(contracts.AssociationStatusPending)
+	schedulemanager.Refresh(log.NewMockLog(), assocRawData)
+	t.Cleanup(func() {
+		schedulemanager.Refresh(log.NewMockLog(), []*model.InstanceAssociation{})
+	})
+	return assocRawData
+}
+
+func TestRunScheduledAssociationDeferredAtConcurrencyLimit(t *testing.T) {
+	processor := createProcessor()
+	svcMock := service.NewMockDefault()
+	processorMock := &processormock.MockedProcessor{}
+	parserMock := processor2.ParserMock{}
+	processor.assocSvc = svcMock
+	processor.proc = processorMock
+	assocParser = &parserMock
+	assocRawData := scheduleAssociationToRunNow(t)
+	associationID := *assocRawData[0].Association.AssociationId
+
+	docState := contracts.DocumentState{}
+	docState.DocumentInformation.AssociationID = associationID
+	docState.DocumentInformation.DocumentID = associationID + ".run"
+	svcMock.On("UpdateInstanceAssociationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	parserMock.On("ParseDocumentForPayload", mock.Anything, mock.Anything).Return(&messageContracts.SendCommandPayload{}, nil)
+	parserMock.On("InitializeDocumentState", mock.Anything, mock.Anything, mock.Anything).Return(docState, nil)
+	processorMock.On("Submit", mock.Anything).Return(frameworkprocessor.ErrorCode(""))
+
+	// an inventory collection of the same association holds the only slot
+	assert.True(t, processor.throttle.TryAcquire(associationID, "collection"))
+
+	processor.runScheduledAssociation(log.NewMockLog())
+
+	processorMock.AssertNotCalled(t, "Submit", mock.Anything)
+	assert.Equal(t, 1, processor.throttle.Running(associationID))
+
+	// the run is still scheduled and is submitted once the collection released its slot
+	processor.throttle.Release(associationID, "collection")
+	processor.runScheduledAssociation(log.NewMockLog())
+
+	processorMock.AssertNumberOfCalls(t, "Submit", 1)
+	assert.Equal(t, 1, processor.throttle.Running(associationID))
+	assert.False(t, processor.throttle.TryAcquire(associationID, "collection"))
+
+	processor.throttle.Release(associationID, docState.DocumentInformation.DocumentID)
+	assert.Equal(t, 0, processor.throttle.Running(associationID))
+}
+
+func TestRunScheduledAssociationHoldsNoSlotWhenParsingFails(t *testing.T) {
+	processor := createProcessor()
+	svcMock := service.NewMockDefault()
+	processorMock := &processormock.MockedProcessor{}
+	complianceUploader := complianceUploader.NewMockDefault()
+	parserMock := processor2.ParserMock{}
+	processor.assocSvc = svcMock
+	processor.proc = processorMock
+	processor.complianceUploader = complianceUploader
+	assocParser = &parserMock
+	assocRawData := scheduleAssociationToRunNow(t)
+	associationID := *assocRawData[0].Association.AssociationId
+
+	svcMock.On("UpdateInstanceAssociationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	complianceUploader.On("UpdateAssociationCompliance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	parserMock.On("ParseDocumentForPayload", mock.Anything, mock.Anything).Return(&messageContracts.SendCommandPayload{}, errors.New("invalid document"))
+
+	processor.runScheduledAssociation(log.NewMockLog())
+
+	parserMock.AssertExpectations(t)
+	processorMock.AssertNotCalled(t, "Submit", mock.Anything)
+	assert.Equal(t, 0, processor.throttle.Running(associationID))
+}
+
+func TestRunScheduledAssociationReleasesSlotWhenSubmissionFails(t *testing.T) {
+	for _, errorCode := range []frameworkprocessor.ErrorCode{frameworkprocessor.DuplicateCommand, frameworkprocessor.ClosedProcessor} {
+		t.Run(string(errorCode), func(t *testing.T) {
+			processor := createProcessor()
+			svcMock := service.NewMockDefault()
+			processorMock := &processormock.MockedProcessor{}
+			parserMock := processor2.ParserMock{}
+			processor.assocSvc = svcMock
+			processor.proc = processorMock
+			assocParser = &parserMock
+			assocRawData := scheduleAssociationToRunNow(t)
+			associationID := *assocRawData[0].Association.AssociationId
+
+			docState := contracts.DocumentState{}
+			docState.DocumentInformation.AssociationID = associationID
+			docState.DocumentInformation.DocumentID = associationID + ".run"
+			svcMock.On("UpdateInstanceAssociationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			parserMock.On("ParseDocumentForPayload", mock.Anything, mock.Anything).Return(&messageContracts.SendCommandPayload{}, nil)
+			parserMock.On("InitializeDocumentState", mock.Anything, mock.Anything, mock.Anything).Return(docState, nil)
+			processorMock.On("Submit", mock.Anything).Return(errorCode)
+
+			processor.runScheduledAssociation(log.NewMockLog())
+
+			processorMock.AssertExpectations(t)
+			assert.Equal(t, 0, processor.throttle.Running(associationID))
+		})
+	}
+}
+
 func createProcessor() *Processor {
 	processor := Processor{}
 	processor.context = context.NewMockDefault()
+	processor.throttle = throttle.NewThrottle(1)
 	return &processor
 }
 
@@ -0,0 +1,79 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package throttle bounds how many executions of a single association run on the instance at once.
+package throttle
+
+import "sync"
+
+// Throttle counts the in-flight executions of each association, its scheduled runs and frequent inventory
+// collections, and refuses new ones once an association reaches its limit. It does not bound the steps of an
+// execution. It is independent of the worker limit of the association processor, which bounds the documents of
+// all associations together.
+// Each slot is held by a named execution, so releasing an execution that never acquired a slot
+// cannot free the slot of another execution.
+type Throttle struct {
+	mutex   sync.Mutex
+	limit   int
+	running map[string]map[string]struct{}
+}
+
+// NewThrottle returns a Throttle allowing limit concurrent executions per association, a limit below 1 does not limit them.
+func NewThrottle(limit int) *Throttle {
+	return &Throttle{
+		limit:   limit,
+		running: make(map[string]map[string]struct{}),
+	}
+}
+
+// TryAcquire reserves an execution slot of the association for executionID, it returns false when the association is at its limit.
+// It returns true without taking another slot when executionID already holds one.
+func (t *Throttle) TryAcquire(associationID string, executionID string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	executions := t.running[associationID]
+	if _, held := executions[executionID]; held {
+		return true
+	}
+	if t.limit > 0 && len(executions) >= t.limit {
+		return false
+	}
+	if executions == nil {
+		executions = make(map[string]struct{})
+		t.running[associationID] = executions
+	}
+	executions[executionID] = struct{}{}
+	return true
+}
+
+// Release frees the execution slot held by executionID, it does nothing when executionID holds no slot,
+// as for documents resumed after an agent restart.
+func (t *Throttle) Release(associationID string, executionID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	executions := t.running[associationID]
+	delete(executions, executionID)
+	if len(executions) == 0 {
+		delete(t.running, associationID)
+	}
+}
+
+// Running returns the number of executions of the association currently holding a slot.
+func (t *Throttle) Running(associationID string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return len(t.running[associationID])
+}
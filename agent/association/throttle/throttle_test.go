@@ -0,0 +1,101 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package throttle
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottleHonorsPerAssociationLimit(t *testing.T) {
+	throttle := NewThrottle(2)
+
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-1"))
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-2"))
+	assert.False(t, throttle.TryAcquire("assoc-1", "run-3"))
+	assert.Equal(t, 2, throttle.Running("assoc-1"))
+
+	// other associations are not affected by a busy one
+	assert.True(t, throttle.TryAcquire("assoc-2", "run-1"))
+
+	throttle.Release("assoc-1", "run-1")
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-3"))
+}
+
+func TestThrottleAcquireAgainKeepsSingleSlot(t *testing.T) {
+	throttle := NewThrottle(2)
+
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-1"))
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-1"))
+	assert.Equal(t, 1, throttle.Running("assoc-1"))
+}
+
+func TestThrottleWithoutLimit(t *testing.T) {
+	throttle := NewThrottle(0)
+
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-1"))
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-2"))
+	assert.Equal(t, 2, throttle.Running("assoc-1"))
+
+	throttle.Release("assoc-1", "run-1")
+	assert.Equal(t, 1, throttle.Running("assoc-1"))
+}
+
+func TestThrottleReleaseWithoutAcquire(t *testing.T) {
+	throttle := NewThrottle(1)
+
+	throttle.Release("assoc-1", "run-1")
+	assert.Equal(t, 0, throttle.Running("assoc-1"))
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-1"))
+}
+
+func TestThrottleReleaseOfOtherExecutionKeepsSlot(t *testing.T) {
+	throttle := NewThrottle(1)
+
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-1"))
+
+	// a document resumed after a restart completes without holding a slot
+	throttle.Release("assoc-1", "resumed-run")
+	assert.Equal(t, 1, throttle.Running("assoc-1"))
+	assert.False(t, throttle.TryAcquire("assoc-1", "run-2"))
+
+	throttle.Release("assoc-1", "run-1")
+	assert.True(t, throttle.TryAcquire("assoc-1", "run-2"))
+}
+
+func TestThrottleConcurrentAcquire(t *testing.T) {
+	throttle := NewThrottle(3)
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	acquired := 0
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if throttle.TryAcquire("assoc-1", fmt.Sprintf("run-%d", i)) {
+				mutex.Lock()
+				acquired++
+				mutex.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, acquired)
+	assert.Equal(t, 3, throttle.Running("assoc-1"))
+}
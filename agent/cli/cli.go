@@ -48,7 +48,12 @@ func RunCommand(args []string, out io.Writer) (exitCode int) {
 		} else {
 			cmdErr, result := cmd.Execute(subcommands, parameters)
 			if cmdErr != nil {
-				displayUsage(out)
+				// a command failing with a result, such as failed checks, shows the result rather than the usage
+				if result != "" {
+					fmt.Fprintln(out, result)
+				} else {
+					displayUsage(out)
+				}
 				fmt.Fprintln(out, "\nerror: "+cmdErr.Error())
 				// Exit 255 if command failed
 				return cliutil.CLI_COMMAND_FAIL_EXITCODE
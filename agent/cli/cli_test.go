@@ -16,6 +16,7 @@ package cli
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/cli/clicommand"
@@ -68,3 +69,19 @@ func TestCliCmdExecSuccess(t *testing.T) {
 	assert.Equal(t, cliutil.CLI_SUCCESS_EXITCODE, exitCode, "command execution success return exit code 0")
 	cliCmdMock.AssertExpectations(t)
 }
+
+func TestCliCmdExecErrorWithResult(t *testing.T) {
+	var buffer bytes.Buffer
+	cliCmdMock := &CliCommandMock.CliCommand{}
+	cliCmdMock.On("Name").Return("cli-command-result-mock").Once()
+	cliCmdMock.On("Execute", mock.AnythingOfType("[]string"), mock.AnythingOfType("map[string][]string")).Return(errors.New("1 of 2 checks failed"), "[FAIL] check").Once()
+	cliutil.Register(cliCmdMock)
+
+	args := []string{"ssm-cli", "cli-command-result-mock"}
+	exitCode := RunCommand(args, &buffer)
+	assert.Equal(t, cliutil.CLI_COMMAND_FAIL_EXITCODE, exitCode, "command execution error return exit code 255")
+	assert.Contains(t, buffer.String(), "[FAIL] check")
+	assert.Contains(t, buffer.String(), "error: 1 of 2 checks failed")
+	assert.NotContains(t, buffer.String(), "usage")
+	cliCmdMock.AssertExpectations(t)
+}
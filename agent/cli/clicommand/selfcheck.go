@@ -0,0 +1,260 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package clicommand contains the implementation of all commands for the ssm agent cli
+package clicommand
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/cliutil"
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+)
+
+const (
+	selfCheckCommand = "self-check"
+
+	selfCheckConfig      = "Agent configuration"
+	selfCheckIdentity    = "Agent identity"
+	selfCheckIPC         = "IPC channel"
+	selfCheckSSMEndpoint = "SSM endpoint"
+	selfCheckPassed      = "PASS"
+	selfCheckFailed      = "FAIL"
+
+	selfCheckIPCPing = "self-check ping"
+	selfCheckIPCPong = "self-check pong"
+
+	// ssmConnectivityCheckName is the name of the get-diagnostics query checking the connectivity to the ssm endpoint
+	ssmConnectivityCheckName = "Connectivity to ssm endpoint"
+)
+
+const selfCheckCommandHelp = `NAME:
+    {{.SelfCheckCommandName}}
+DESCRIPTION
+    Verifies that the subsystems the agent relies on work on this instance: the agent configuration
+    loads, the agent identity resolves, a file watcher IPC channel exchanges messages and the ssm
+    endpoint is reachable. Exits with a non-zero code when any of the checks fails.
+SYNOPSIS
+    {{.SelfCheckCommandName}}
+EXAMPLES
+    Command:
+
+      {{.SsmCliName}} {{.SelfCheckCommandName}}
+
+    Output:
+      [PASS] Agent configuration: configuration loaded
+      [PASS] Agent identity: instance i-0123456789abcdefa in region us-east-1
+      [PASS] IPC channel: message exchanged through /tmp/ssm-self-check123456789/channel
+      [PASS] SSM endpoint: ssm.us-east-1.amazonaws.com is reachable
+
+OUTPUT
+    One line per check with its result and a note
+`
+
+type selfCheckHelpParams struct {
+	SsmCliName           string
+	SelfCheckCommandName string
+}
+
+var (
+	// selfCheckIPCTimeout is how long a message sent on the IPC channel is waited for
+	selfCheckIPCTimeout = 5 * time.Second
+
+	loadSelfCheckConfig   = appconfig.Config
+	loadSelfCheckIdentity = proc.LoadAgentIdentity
+	newSelfCheckChannel   = func(log log.T, mode filewatcherbasedipc.Mode, path string) (filewatcherbasedipc.IPCChannel, error) {
+		return filewatcherbasedipc.NewFileWatcherChannel(log, mode, path, false)
+	}
+	checkSSMEndpointConnectivity = func() diagnosticsutil.DiagnosticOutput {
+		diagnosticsutil.AssumeAgentEnvironmentProxy()
+		for _, query := range diagnosticsutil.DiagnosticQueries {
+			if query.GetName() == ssmConnectivityCheckName {
+				return query.Execute()
+			}
+		}
+		return diagnosticsutil.DiagnosticOutput{Status: diagnosticsutil.DiagnosticsStatusFailed, Note: "connectivity check is not available"}
+	}
+)
+
+func init() {
+	cliutil.Register(&SelfCheckCommand{})
+}
+
+// SelfCheckCommand checks the agent configuration, identity, IPC and ssm endpoint connectivity
+type SelfCheckCommand struct {
+	helpText string
+}
+
+// selfCheckResult is the outcome of one check
+type selfCheckResult struct {
+	check string
+	err   error
+	note  string
+}
+
+// Execute validates and executes the self-check cli command
+func (c *SelfCheckCommand) Execute(subcommands []string, parameters map[string][]string) (error, string) {
+	if len(subcommands) > 0 {
+		return fmt.Errorf("%v does not support subcommand %v", selfCheckCommand, subcommands), ""
+	}
+	if len(parameters) > 0 {
+		var unknownParameters []string
+		for key := range parameters {
+			unknownParameters = append(unknownParameters, cliutil.FormatFlag(key))
+		}
+		sort.Strings(unknownParameters)
+		return fmt.Errorf("unknown parameters %v", unknownParameters), ""
+	}
+
+	results := runSelfChecks(logger.NewSilentLogger())
+
+	var lines []string
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			lines = append(lines, fmt.Sprintf("[%v] %v: %v", selfCheckFailed, result.check, result.err))
+		} else {
+			lines = append(lines, fmt.Sprintf("[%v] %v: %v", selfCheckPassed, result.check, result.note))
+		}
+	}
+	output := strings.Join(lines, "\n")
+	if failed > 0 {
+		return fmt.Errorf("%v of %v checks failed", failed, len(results)), output
+	}
+	return nil, output
+}
+
+// runSelfChecks runs the checks in order, the identity is only checked once the configuration is loaded
+func runSelfChecks(log log.T) (results []selfCheckResult) {
+	config, err := loadSelfCheckConfig(true)
+	if err != nil {
+		results = append(results, selfCheckResult{check: selfCheckConfig, err: fmt.Errorf("failed to parse the configuration: %v", err)})
+	} else {
+		results = append(results, selfCheckResult{check: selfCheckConfig, note: "configuration loaded"})
+	}
+
+	if err != nil {
+		results = append(results, selfCheckResult{check: selfCheckIdentity, err: errors.New("not checked as the configuration did not load")})
+	} else {
+		note, identityErr := checkAgentIdentity(log, &config)
+		results = append(results, selfCheckResult{check: selfCheckIdentity, note: note, err: identityErr})
+	}
+
+	note, err := checkIPCChannel(log)
+	results = append(results, selfCheckResult{check: selfCheckIPC, note: note, err: err})
+
+	connectivity := checkSSMEndpointConnectivity()
+	if connectivity.Status != diagnosticsutil.DiagnosticsStatusSuccess {
+		results = append(results, selfCheckResult{check: selfCheckSSMEndpoint, err: errors.New(connectivity.Note)})
+	} else {
+		results = append(results, selfCheckResult{check: selfCheckSSMEndpoint, note: connectivity.Note})
+	}
+	return results
+}
+
+// checkAgentIdentity resolves the agent identity and the instance and region it provides
+func checkAgentIdentity(log log.T, config *appconfig.SsmagentConfig) (note string, err error) {
+	var agentIdentity identity.IAgentIdentity
+	if agentIdentity, err = loadSelfCheckIdentity(log, config); err != nil {
+		return "", fmt.Errorf("failed to get identity: %v", err)
+	}
+	instanceID, err := agentIdentity.InstanceID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get instance id: %v", err)
+	}
+	region, err := agentIdentity.Region()
+	if err != nil {
+		return "", fmt.Errorf("failed to get region: %v", err)
+	}
+	return fmt.Sprintf("instance %v in region %v", instanceID, region), nil
+}
+
+// checkIPCChannel creates a master and a worker channel in a temporary directory and sends a message each way
+func checkIPCChannel(log log.T) (note string, err error) {
+	dir, err := os.MkdirTemp("", "ssm-self-check")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "channel")
+
+	master, err := newSelfCheckChannel(log, filewatcherbasedipc.ModeMaster, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create master channel: %v", err)
+	}
+	defer master.Destroy()
+	worker, err := newSelfCheckChannel(log, filewatcherbasedipc.ModeWorker, path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create worker channel: %v", err)
+	}
+	defer worker.Close()
+
+	if err = exchangeSelfCheckMessage(master, worker, selfCheckIPCPing); err != nil {
+		return "", fmt.Errorf("master to worker: %v", err)
+	}
+	if err = exchangeSelfCheckMessage(worker, master, selfCheckIPCPong); err != nil {
+		return "", fmt.Errorf("worker to master: %v", err)
+	}
+	return fmt.Sprintf("message exchanged through %v", path), nil
+}
+
+// exchangeSelfCheckMessage sends the message on one channel and waits for it on the other
+func exchangeSelfCheckMessage(sender filewatcherbasedipc.IPCChannel, receiver filewatcherbasedipc.IPCChannel, message string) error {
+	if err := sender.Send(message); err != nil {
+		return fmt.Errorf("failed to send message: %v", err)
+	}
+	select {
+	case received, ok := <-receiver.GetMessage():
+		if !ok {
+			return errors.New("channel closed before the message was received")
+		}
+		if received != message {
+			return fmt.Errorf("received unexpected message %q", received)
+		}
+		return nil
+	case <-time.After(selfCheckIPCTimeout):
+		return fmt.Errorf("message not received within %v", selfCheckIPCTimeout)
+	}
+}
+
+// Help prints help for the self-check cli command
+func (c *SelfCheckCommand) Help() string {
+	if len(c.helpText) == 0 {
+		t, _ := template.New("SelfCheckCommandHelp").Parse(selfCheckCommandHelp)
+		params := selfCheckHelpParams{cliutil.SsmCliName, selfCheckCommand}
+		buf := new(bytes.Buffer)
+		t.Execute(buf, params)
+		c.helpText = buf.String()
+	}
+	return c.helpText
+}
+
+// Name is the command name used in the cli
+func (SelfCheckCommand) Name() string {
+	return selfCheckCommand
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clicommand
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/cli/diagnosticsutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	identitymocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// useSelfCheckDependencies replaces the config, identity and connectivity dependencies of the checks for a test,
+// the IPC channel check runs against the file system unless the test replaces newSelfCheckChannel
+func useSelfCheckDependencies(t *testing.T, configErr error, identityErr error, connectivityStatus string) {
+	oldLoadConfig, oldLoadIdentity, oldNewChannel, oldConnectivity := loadSelfCheckConfig, loadSelfCheckIdentity, newSelfCheckChannel, checkSSMEndpointConnectivity
+	t.Cleanup(func() {
+		loadSelfCheckConfig, loadSelfCheckIdentity, newSelfCheckChannel, checkSSMEndpointConnectivity = oldLoadConfig, oldLoadIdentity, oldNewChannel, oldConnectivity
+	})
+	loadSelfCheckConfig = func(reload bool) (appconfig.SsmagentConfig, error) {
+		return appconfig.DefaultConfig(), configErr
+	}
+	loadSelfCheckIdentity = func(log log.T, config *appconfig.SsmagentConfig) (identity.IAgentIdentity, error) {
+		if identityErr != nil {
+			return nil, identityErr
+		}
+		return identitymocks.NewDefaultMockAgentIdentity(), nil
+	}
+	checkSSMEndpointConnectivity = func() diagnosticsutil.DiagnosticOutput {
+		return diagnosticsutil.DiagnosticOutput{Status: connectivityStatus, Note: fmt.Sprintf("ssm endpoint check %v", connectivityStatus)}
+	}
+}
+
+// silentChannel is a channel whose messages never reach the other end
+type silentChannel struct {
+	filewatcherbasedipc.IPCChannel
+	messages chan string
+}
+
+func (c *silentChannel) Send(string) error         { return nil }
+func (c *silentChannel) GetMessage() <-chan string { return c.messages }
+func (c *silentChannel) Close()                    {}
+func (c *silentChannel) Destroy()                  {}
+
+func TestSelfCheckAllChecksPass(t *testing.T) {
+	useSelfCheckDependencies(t, nil, nil, diagnosticsutil.DiagnosticsStatusSuccess)
+
+	err, output := (&SelfCheckCommand{}).Execute(nil, nil)
+
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "[PASS] Agent configuration: configuration loaded", lines[0])
+	assert.Equal(t, fmt.Sprintf("[PASS] Agent identity: instance %v in region %v", identitymocks.MockInstanceID, identitymocks.MockRegion), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "[PASS] IPC channel: message exchanged through "), lines[2])
+	assert.Equal(t, "[PASS] SSM endpoint: ssm endpoint check Success", lines[3])
+}
+
+func TestSelfCheckConfigFailure(t *testing.T) {
+	useSelfCheckDependencies(t, errors.New("invalid character"), nil, diagnosticsutil.DiagnosticsStatusSuccess)
+
+	err, output := (&SelfCheckCommand{}).Execute(nil, nil)
+
+	assert.EqualError(t, err, "2 of 4 checks failed")
+	assert.Contains(t, output, "[FAIL] Agent configuration: failed to parse the configuration: invalid character")
+	assert.Contains(t, output, "[FAIL] Agent identity: not checked as the configuration did not load")
+	assert.Contains(t, output, "[PASS] IPC channel")
+}
+
+func TestSelfCheckIdentityFailure(t *testing.T) {
+	useSelfCheckDependencies(t, nil, errors.New("no identity available"), diagnosticsutil.DiagnosticsStatusSuccess)
+
+	err, output := (&SelfCheckCommand{}).Execute(nil, nil)
+
+	assert.EqualError(t, err, "1 of 4 checks failed")
+	assert.Contains(t, output, "[FAIL] Agent identity: failed to get identity: no identity available")
+}
+
+func TestSelfCheckSSMEndpointFailure(t *testing.T) {
+	useSelfCheckDependencies(t, nil, nil, diagnosticsutil.DiagnosticsStatusFailed)
+
+	err, output := (&SelfCheckCommand{}).Execute(nil, nil)
+
+	assert.EqualError(t, err, "1 of 4 checks failed")
+	assert.Contains(t, output, "[FAIL] SSM endpoint: ssm endpoint check Failed")
+}
+
+func TestSelfCheckIPCChannelCreationFailure(t *testing.T) {
+	useSelfCheckDependencies(t, nil, nil, diagnosticsutil.DiagnosticsStatusSuccess)
+	newSelfCheckChannel = func(log log.T, mode filewatcherbasedipc.Mode, path string) (filewatcherbasedipc.IPCChannel, error) {
+		return nil, errors.New("too many open files")
+	}
+
+	err, output := (&SelfCheckCommand{}).Execute(nil, nil)
+
+	assert.EqualError(t, err, "1 of 4 checks failed")
+	assert.Contains(t, output, "[FAIL] IPC channel: failed to create master channel: too many open files")
+}
+
+func TestSelfCheckIPCMessageNotReceived(t *testing.T) {
+	useSelfCheckDependencies(t, nil, nil, diagnosticsutil.DiagnosticsStatusSuccess)
+	newSelfCheckChannel = func(log log.T, mode filewatcherbasedipc.Mode, path string) (filewatcherbasedipc.IPCChannel, error) {
+		return &silentChannel{messages: make(chan string)}, nil
+	}
+	oldTimeout := selfCheckIPCTimeout
+	defer func() { selfCheckIPCTimeout = oldTimeout }()
+	selfCheckIPCTimeout = 10 * time.Millisecond
+
+	note, err := checkIPCChannel(logmocks.NewMockLog())
+
+	assert.Empty(t, note)
+	assert.EqualError(t, err, "master to worker: message not received within 10ms")
+}
+
+func TestSelfCheckRejectsParameters(t *testing.T) {
+	err, output := (&SelfCheckCommand{}).Execute(nil, map[string][]string{"output": {"table"}})
+
+	assert.EqualError(t, err, "unknown parameters [--output]")
+	assert.Empty(t, output)
+}
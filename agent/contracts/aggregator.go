@@ -16,6 +16,7 @@ package contracts
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/times"
@@ -59,6 +60,24 @@ func prepareRuntimeStatus(log log.T, pluginResult PluginResult) PluginRuntimeSta
 	return runtimeStatus
 }
 
+// SetLastInProgressPlugin records in an InProgress docResult the InProgress plugin that started last and its
+// start time, which is the step that has not completed.
+func SetLastInProgressPlugin(docResult *DocumentResult) {
+	if docResult.Status != ResultStatusInProgress {
+		return
+	}
+	var startDateTime time.Time
+	for pluginID, pluginResult := range docResult.PluginResults {
+		if pluginResult != nil && pluginResult.Status == ResultStatusInProgress && pluginResult.StartDateTime.After(startDateTime) {
+			docResult.LastInProgressPluginID = pluginID
+			startDateTime = pluginResult.StartDateTime
+		}
+	}
+	if !startDateTime.IsZero() {
+		docResult.LastInProgressTime = times.ToIso8601UTC(startDateTime)
+	}
+}
+
 // DocumentResultAggregator aggregates the result from the plugins to construct the agent response
 func DocumentResultAggregator(log log.T,
 	pluginID string,
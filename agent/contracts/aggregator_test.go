@@ -3,6 +3,7 @@ package contracts
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/agent/times"
@@ -160,3 +161,36 @@ func TestDocumentStatusCount(t *testing.T) {
 	_, statusCount, _, _ := DocumentResultAggregator(logger, "", input)
 	assert.Equal(t, statusCount, output)
 }
+
+func TestSetLastInProgressPlugin(t *testing.T) {
+	startTime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	docResult := DocumentResult{
+		Status: ResultStatusInProgress,
+		PluginResults: map[string]*PluginResult{
+			"first":      {Status: ResultStatusSuccess, StartDateTime: startTime},
+			"second":     {Status: ResultStatusInProgress, StartDateTime: startTime.Add(time.Minute)},
+			"notStarted": {Status: ResultStatusNotStarted},
+			// a step started later that already completed is not the step left InProgress
+			"skipped": {Status: ResultStatusSkipped, StartDateTime: startTime.Add(2 * time.Minute)},
+		},
+	}
+
+	SetLastInProgressPlugin(&docResult)
+
+	assert.Equal(t, "second", docResult.LastInProgressPluginID)
+	assert.Equal(t, times.ToIso8601UTC(startTime.Add(time.Minute)), docResult.LastInProgressTime)
+}
+
+func TestSetLastInProgressPluginIgnoresCompletedDocuments(t *testing.T) {
+	docResult := DocumentResult{
+		Status: ResultStatusSuccess,
+		PluginResults: map[string]*PluginResult{
+			"first": {Status: ResultStatusSuccess, StartDateTime: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	SetLastInProgressPlugin(&docResult)
+
+	assert.Empty(t, docResult.LastInProgressPluginID)
+	assert.Empty(t, docResult.LastInProgressTime)
+}
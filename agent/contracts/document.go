@@ -101,6 +101,27 @@ type IOConfiguration struct {
 	OutputS3BucketName     string
 	OutputS3KeyPrefix      string
 	CloudWatchConfig       CloudWatchConfiguration
+	// CompressS3Output gzips stdout/stderr before they are uploaded to S3
+	CompressS3Output bool
+	// CommandTimeoutSeconds, set from the commandTimeoutSeconds of the document, bounds how long the document worker
+	// lets the document run before cancelling it, up to a maximum of two days. 0 leaves the document without a command timeout.
+	CommandTimeoutSeconds int `json:",omitempty"`
+	// IncrementalUploadIntervalSeconds, set from the incrementalUploadIntervalSeconds of the document,
+	// uploads the stdout/stderr written so far to S3 at this interval while a plugin runs,
+	// so that the progress of long steps can be inspected. 0 only uploads the output once the plugin completes.
+	IncrementalUploadIntervalSeconds int `json:",omitempty"`
+	// OnFailure is the onFailure value of the document, "abort" skips the steps following a failed step
+	OnFailure string `json:",omitempty"`
+	// StepHooks are the commands the document runs around each step, nil when it has none
+	StepHooks *StepHooks `json:",omitempty"`
+}
+
+// StepHooks represents the commands run before and after each step of a document
+type StepHooks struct {
+	PreStep  string `json:",omitempty"`
+	PostStep string `json:",omitempty"`
+	// OnFailure is the onStepHookFailure value of the document, "fail" fails the step when one of its commands fails
+	OnFailure string `json:",omitempty"`
 }
 
 // DocumentState represents information relevant to a command that gets executed by agent
@@ -112,6 +133,20 @@ type DocumentState struct {
 	CancelInformation          CancelCommandInfo
 	IOConfig                   IOConfiguration
 	UpstreamServiceName        UpstreamServiceName
+	Provenance                 *ExecutionProvenance `json:",omitempty"`
+}
+
+// ExecutionProvenance records what a document execution ran with, so that a past execution can be reconstructed
+type ExecutionProvenance struct {
+	// ContentHash is the sha256 of the steps of the document after parameter substitution
+	ContentHash string
+	// Parameters are the resolved document parameters, sensitive values are redacted
+	Parameters      map[string]interface{}
+	AgentVersion    string
+	PlatformName    string
+	PlatformVersion string
+	// PluginVersions maps each plugin of the document to its version, plugins are built into the agent
+	PluginVersions map[string]string
 }
 
 // IsRebootRequired returns if reboot is needed
@@ -15,7 +15,10 @@
 // necessary for communication and sharing within the agent.
 package contracts
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // ResultStatus provides the granular status of a plugin.
 // These are internal states maintained by agent during the execution of a command/config
@@ -44,6 +47,29 @@ const (
 	ResultStatusTestFailure ResultStatus = "TestFailure"
 	// ResultStatusTestPass represents test passing
 	ResultStatusTestPass ResultStatus = "TestPass"
+	// ResultStatusWouldRun represents a step that a dry run found would be executed
+	ResultStatusWouldRun ResultStatus = "WouldRun"
+	// ResultStatusQueued represents a document waiting for a free document worker, it is reported as a status update
+	// and is never the final status of a document
+	ResultStatusQueued ResultStatus = "Queued"
+)
+
+// SkipReason represents the machine-readable reason for a step being skipped, or failed without running
+type SkipReason string
+
+const (
+	// SkipReasonPreconditionFailed represents a step skipped because its preconditions are not satisfied
+	SkipReasonPreconditionFailed SkipReason = "PreconditionFailed"
+	// SkipReasonUnsupportedPlugin represents a step skipped because its plugin is not supported on this platform
+	SkipReasonUnsupportedPlugin SkipReason = "UnsupportedPlugin"
+	// SkipReasonPriorStepExit represents a step skipped because a prior step requested to exit the document
+	SkipReasonPriorStepExit SkipReason = "PriorStepExit"
+	// SkipReasonAgentTooOld represents a step skipped or failed because its plugin is unknown to this agent version
+	SkipReasonAgentTooOld SkipReason = "AgentTooOld"
+	// SkipReasonPluginNotPermitted represents a step skipped because its plugin is not in the allowed plugins of the agent
+	SkipReasonPluginNotPermitted SkipReason = "PluginNotPermitted"
+	// SkipReasonPriorStepFailed represents a step skipped because a prior step failed in a document that aborts on failure
+	SkipReasonPriorStepFailed SkipReason = "PriorStepFailed"
 )
 
 const (
@@ -61,6 +87,15 @@ const (
 	ModifierValueExit           string = "exit"
 	ModifierValueSuccessAndExit string = "successAndExit"
 	ModifierValueTrue           string = "true"
+	// ModifierValueAbort is the document onFailure value that stops running the steps after the first failed step
+	ModifierValueAbort string = "abort"
+)
+
+const (
+	// StepHookFailureIgnore is the default onStepHookFailure value, a failed preStep or postStep command does not fail the step
+	StepHookFailureIgnore string = "ignore"
+	// StepHookFailureFail is the onStepHookFailure value that fails the step when its preStep or postStep command fails
+	StepHookFailureFail string = "fail"
 )
 
 // IsSuccess checks whether the result is success or not
@@ -87,6 +122,7 @@ func (rs ResultStatus) IsReboot() bool {
 func MergeResultStatus(current ResultStatus, new ResultStatus) (merged ResultStatus) {
 	orderedResultStatus := [...]ResultStatus{
 		ResultStatusSkipped,
+		ResultStatusWouldRun,
 		ResultStatusSuccess,
 		ResultStatusSuccessAndReboot,
 		ResultStatusPassedAndReboot,
@@ -160,6 +196,8 @@ const (
 	AssociationPendingMessage string = "Association is pending"
 	// DocumentInProgressMessage represents the summary message for inprogress association
 	AssociationInProgressMessage string = "Executing association"
+	// AssociationQueuedMessage represents the summary message for an association waiting for a document worker
+	AssociationQueuedMessage string = "Association is queued, waiting for a document worker"
 )
 
 const (
@@ -193,6 +231,8 @@ type Parameter struct {
 	MaxChars       json.Number `json:"maxChars,omitempty" yaml:"maxChars,omitempty"`
 	MinItems       json.Number `json:"minItems,omitempty" yaml:"minItems,omitempty"`
 	MaxItems       json.Number `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	// PlatformDefaults are the default values by platform type (linux, windows or macos), they take precedence over DefaultVal
+	PlatformDefaults map[string]interface{} `json:"platformDefaults,omitempty" yaml:"platformDefaults,omitempty"`
 }
 
 // PluginConfig stores plugin configuration
@@ -200,18 +240,99 @@ type PluginConfig struct {
 	Settings    interface{} `json:"settings" yaml:"settings"`
 	Properties  interface{} `json:"properties" yaml:"properties"`
 	Description string      `json:"description" yaml:"description"`
+	Timeout     int         `json:"timeoutSeconds" yaml:"timeoutSeconds"`
 }
 
 // InstancePluginConfig stores plugin configuration
 type InstancePluginConfig struct {
-	Action        string              `json:"action" yaml:"action"` // plugin name
-	Inputs        interface{}         `json:"inputs" yaml:"inputs"` // Properties
-	MaxAttempts   int                 `json:"maxAttempts" yaml:"maxAttempts"`
-	Name          string              `json:"name" yaml:"name"` // unique identifier
-	OnFailure     string              `json:"onFailure" yaml:"onFailure"`
-	Settings      interface{}         `json:"settings" yaml:"settings"`
-	Timeout       int                 `json:"timeoutSeconds" yaml:"timeoutSeconds"`
-	Preconditions map[string][]string `json:"precondition" yaml:"precondition"`
+	Action        string           `json:"action" yaml:"action"` // plugin name
+	Inputs        interface{}      `json:"inputs" yaml:"inputs"` // Properties
+	MaxAttempts   int              `json:"maxAttempts" yaml:"maxAttempts"`
+	Name          string           `json:"name" yaml:"name"` // unique identifier
+	OnFailure     string           `json:"onFailure" yaml:"onFailure"`
+	Settings      interface{}      `json:"settings" yaml:"settings"`
+	Timeout       int              `json:"timeoutSeconds" yaml:"timeoutSeconds"`
+	Preconditions StepPrecondition `json:"precondition" yaml:"precondition"`
+	Priority      ProcessPriority  `json:"priority" yaml:"priority"`
+	// SuccessCriteria fails a step that completed successfully based on its output
+	SuccessCriteria SuccessCriteria `json:"successCriteria" yaml:"successCriteria"`
+	// StripAnsi removes ANSI escape sequences, such as colors, from the output captured for the step
+	StripAnsi bool `json:"stripAnsi" yaml:"stripAnsi"`
+	// SensitiveOutput keeps the output of the step out of S3, CloudWatch and the step result
+	SensitiveOutput bool `json:"sensitiveOutput" yaml:"sensitiveOutput"`
+}
+
+// PreconditionAllOf is the key of the precondition group whose preconditions must all be satisfied
+const PreconditionAllOf = "allOf"
+
+// StepPrecondition is the precondition of a step. Its operators, such as {"StringEquals": ["platformType", "Linux"]},
+// are combined with AND. An operator appears once in a precondition, the allOf group lists preconditions which
+// must all be satisfied too, so that an operator can be used more than once:
+// {"allOf": [{"StringEquals": ["platformType", "Linux"]}, {"StringEquals": ["{{ environment }}", "prod"]}]}
+type StepPrecondition struct {
+	Operators map[string][]string
+	AllOf     []map[string][]string
+}
+
+// UnmarshalJSON reads the operators of the precondition, and the preconditions of its allOf group
+func (precondition *StepPrecondition) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*precondition = StepPrecondition{}
+	for key, value := range fields {
+		if key == PreconditionAllOf {
+			if err := json.Unmarshal(value, &precondition.AllOf); err != nil {
+				return fmt.Errorf("invalid %v precondition: %v", PreconditionAllOf, err)
+			}
+			continue
+		}
+		var args []string
+		if err := json.Unmarshal(value, &args); err != nil {
+			return fmt.Errorf("invalid %v precondition: %v", key, err)
+		}
+		if precondition.Operators == nil {
+			precondition.Operators = make(map[string][]string)
+		}
+		precondition.Operators[key] = args
+	}
+	return nil
+}
+
+// MarshalJSON writes the precondition in the format read by UnmarshalJSON
+func (precondition StepPrecondition) MarshalJSON() ([]byte, error) {
+	if precondition.Operators == nil && precondition.AllOf == nil {
+		return []byte("null"), nil
+	}
+	fields := make(map[string]interface{}, len(precondition.Operators)+1)
+	for operator, args := range precondition.Operators {
+		fields[operator] = args
+	}
+	if precondition.AllOf != nil {
+		fields[PreconditionAllOf] = precondition.AllOf
+	}
+	return json.Marshal(fields)
+}
+
+// SuccessCriteria declares regular expressions matched against each line of the standard output and error of a step
+// that completed successfully. Empty patterns are ignored.
+type SuccessCriteria struct {
+	// FailurePattern marks the step as Failed when a line of its output matches
+	FailurePattern string `json:"failurePattern" yaml:"failurePattern"`
+	// SuccessPattern marks the step as Failed when no line of its output matches
+	SuccessPattern string `json:"successPattern" yaml:"successPattern"`
+}
+
+// ProcessPriority stores the CPU and IO scheduling priority of the processes started by a step.
+// Zero values leave the inherited priority unchanged.
+type ProcessPriority struct {
+	// Nice is the CPU nice value, from 0 to 19 (lowest priority)
+	Nice int `json:"nice" yaml:"nice"`
+	// IONiceClass is the IO scheduling class as accepted by ionice, 2 (best-effort) or 3 (idle)
+	IONiceClass int `json:"ioniceClass" yaml:"ioniceClass"`
+	// IONiceLevel is the priority within the best-effort class, from 0 to 7 (lowest priority)
+	IONiceLevel int `json:"ioniceLevel" yaml:"ioniceLevel"`
 }
 
 // DocumentContent object which represents ssm document content.
@@ -221,6 +342,19 @@ type DocumentContent struct {
 	RuntimeConfig map[string]*PluginConfig `json:"runtimeConfig" yaml:"runtimeConfig"`
 	MainSteps     []*InstancePluginConfig  `json:"mainSteps" yaml:"mainSteps"`
 	Parameters    map[string]*Parameter    `json:"parameters" yaml:"parameters"`
+	// Command is the shell command of a shorthand document, which only has this field
+	Command string `json:"command,omitempty" yaml:"command,omitempty"`
+	// OnFailure set to "abort" stops the document at its first failed step, the remaining steps are skipped
+	OnFailure string `json:"onFailure,omitempty" yaml:"onFailure,omitempty"`
+	// PreStep and PostStep are shell commands run before and after each step that runs, with their output kept apart from the step output
+	PreStep  string `json:"preStep,omitempty" yaml:"preStep,omitempty"`
+	PostStep string `json:"postStep,omitempty" yaml:"postStep,omitempty"`
+	// OnStepHookFailure set to "fail" fails the step when its preStep or postStep command fails, "ignore" only logs the failure
+	OnStepHookFailure string `json:"onStepHookFailure,omitempty" yaml:"onStepHookFailure,omitempty"`
+	// CommandTimeoutSeconds cancels the document when it runs longer, up to two days. 0 leaves the document without a command timeout
+	CommandTimeoutSeconds int `json:"commandTimeoutSeconds,omitempty" yaml:"commandTimeoutSeconds,omitempty"`
+	// IncrementalUploadIntervalSeconds uploads the output of the running step to S3 at this interval. 0 uploads it once the step completes
+	IncrementalUploadIntervalSeconds int `json:"incrementalUploadIntervalSeconds,omitempty" yaml:"incrementalUploadIntervalSeconds,omitempty"`
 
 	// InvokedPlugin field is set when document is invoked from any other plugin.
 	// Currently, InvokedPlugin is set only in runDocument Plugin
@@ -239,6 +373,7 @@ type SessionInputs struct {
 	RunAsEnabled                interface{}        `json:"runAsEnabled" yaml:"runAsEnabled"`
 	RunAsDefaultUser            string             `json:"runAsDefaultUser" yaml:"runAsDefaultUser"`
 	ShellProfile                ShellProfileConfig `json:"shellProfile" yaml:"shellProfile"`
+	InitialCommands             ShellProfileConfig `json:"initialCommands" yaml:"initialCommands"`
 }
 
 // ShellProfileConfig stores shell profile config
@@ -302,6 +437,7 @@ type DocumentResult struct {
 	DocumentVersion     string
 	MessageID           string
 	AssociationID       string
+	DocumentID          string `json:",omitempty"`
 	PluginResults       map[string]*PluginResult
 	Status              ResultStatus
 	LastPlugin          string
@@ -309,6 +445,10 @@ type DocumentResult struct {
 	UpstreamServiceName UpstreamServiceName
 	ResultType          ResultType
 	RelatedDocumentType DocumentType
+	Provenance          *ExecutionProvenance `json:",omitempty"`
+	// LastInProgressPluginID and LastInProgressTime identify the step that entered InProgress last
+	LastInProgressPluginID string `json:",omitempty"`
+	LastInProgressTime     string `json:",omitempty"`
 }
 
 // ResultType represents document Result types
@@ -28,19 +28,61 @@ const (
 
 // PluginResult represents a plugin execution result.
 type PluginResult struct {
-	PluginID           string       `json:"pluginID"`
-	PluginName         string       `json:"pluginName"`
-	Status             ResultStatus `json:"status"`
-	Code               int          `json:"code"`
-	Output             interface{}  `json:"output"`
-	StartDateTime      time.Time    `json:"startDateTime"`
-	EndDateTime        time.Time    `json:"endDateTime"`
-	OutputS3BucketName string       `json:"outputS3BucketName"`
-	OutputS3KeyPrefix  string       `json:"outputS3KeyPrefix"`
-	StepName           string       `json:"stepName"`
-	Error              string       `json:"error"`
-	StandardOutput     string       `json:"standardOutput"`
-	StandardError      string       `json:"standardError"`
+	PluginID           string         `json:"pluginID"`
+	PluginName         string         `json:"pluginName"`
+	Status             ResultStatus   `json:"status"`
+	Code               int            `json:"code"`
+	Output             interface{}    `json:"output"`
+	StartDateTime      time.Time      `json:"startDateTime"`
+	EndDateTime        time.Time      `json:"endDateTime"`
+	OutputS3BucketName string         `json:"outputS3BucketName"`
+	OutputS3KeyPrefix  string         `json:"outputS3KeyPrefix"`
+	StepName           string         `json:"stepName"`
+	Error              string         `json:"error"`
+	StandardOutput     string         `json:"standardOutput"`
+	StandardError      string         `json:"standardError"`
+	SkipReason         SkipReason     `json:"skipReason,omitempty"`
+	ResourceUsage      *ResourceUsage `json:"resourceUsage,omitempty"`
+	// ResolvedInput is the command run by the step after parameter substitution, with secret values masked
+	ResolvedInput string `json:"resolvedInput,omitempty"`
+	// ProcessID is the OS process ID of the last process started by the step, 0 when the step did not start a process
+	ProcessID int `json:"processID,omitempty"`
+	// PreStep and PostStep are the results of the preStep and postStep commands of the document run around the step
+	PreStep  *StepHookResult `json:"preStep,omitempty"`
+	PostStep *StepHookResult `json:"postStep,omitempty"`
+	// Outputs are the named outputs set by the step with ::set-output, the later steps reference them with {{ stepName.outputs.key }}
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// SecureOutputs are the named outputs set with ::set-secure-output, they are never reported and are masked in the
+	// output of the steps referencing them
+	SecureOutputs map[string]string `json:"-"`
+}
+
+// StepHookResult represents the result of a command run before or after a step, its output is kept apart from the step output
+type StepHookResult struct {
+	Status         ResultStatus `json:"status"`
+	Code           int          `json:"code"`
+	Error          string       `json:"error,omitempty"`
+	StandardOutput string       `json:"standardOutput"`
+	StandardError  string       `json:"standardError"`
+}
+
+// ResourceUsage represents the resources consumed by the processes started by a plugin
+type ResourceUsage struct {
+	// PeakMemoryBytes is the largest resident set size of the processes, 0 when unavailable
+	PeakMemoryBytes int64 `json:"peakMemoryBytes"`
+	// UserCPUTimeMillis is the CPU time spent in user mode
+	UserCPUTimeMillis int64 `json:"userCpuTimeMillis"`
+	// SystemCPUTimeMillis is the CPU time spent in kernel mode
+	SystemCPUTimeMillis int64 `json:"systemCpuTimeMillis"`
+}
+
+// Add accumulates the usage of another process, CPU times are summed and the peak memory is the largest of both
+func (usage *ResourceUsage) Add(other ResourceUsage) {
+	if other.PeakMemoryBytes > usage.PeakMemoryBytes {
+		usage.PeakMemoryBytes = other.PeakMemoryBytes
+	}
+	usage.UserCPUTimeMillis += other.UserCPUTimeMillis
+	usage.SystemCPUTimeMillis += other.SystemCPUTimeMillis
 }
 
 // IPlugin is interface for authoring a functionality of work.
@@ -80,6 +122,14 @@ type PreconditionArgument struct {
 	ResolvedArgumentValue string
 }
 
+// PreconditionClause is one precondition operator along with its arguments.
+// The clauses of the allOf precondition group of a step are kept in order in Configuration.PreconditionsAllOf,
+// they must all be satisfied along with the operators of Configuration.Preconditions for the step to run.
+type PreconditionClause struct {
+	Operator  string
+	Arguments []PreconditionArgument
+}
+
 // Configuration represents a plugin configuration as in the json format.
 type Configuration struct {
 	Settings                    interface{}
@@ -97,6 +147,7 @@ type Configuration struct {
 	PluginID                    string
 	DefaultWorkingDirectory     string
 	Preconditions               map[string][]PreconditionArgument
+	PreconditionsAllOf          []PreconditionClause
 	IsPreconditionEnabled       bool
 	CurrentAssociations         []string
 	SessionId                   string
@@ -105,8 +156,18 @@ type Configuration struct {
 	RunAsEnabled                bool
 	RunAsUser                   string
 	ShellProfile                ShellProfileConfig
+	InitialCommands             ShellProfileConfig
 	SessionOwner                string
 	UpstreamServiceName         UpstreamServiceName
+	TimeoutSeconds              int
+	ProcessPriority             ProcessPriority
+	ResolveStepOutputReferences bool
+	SuccessCriteria             SuccessCriteria
+	ExecutionDepth              int
+	StripAnsi                   bool
+	// RedactedValues are the secret values masked by the step, they are only known when the step runs and never persisted
+	RedactedValues  []string `json:"-"`
+	SensitiveOutput bool
 }
 
 // Plugin wraps the plugin configuration and plugin result.
@@ -29,6 +29,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
@@ -41,6 +42,10 @@ const (
 	envVarRegionName      = "AWS_SSM_REGION_NAME"
 	envVarPlatformName    = "AWS_SSM_PLATFORM_NAME"
 	envVarPlatformVersion = "AWS_SSM_PLATFORM_VERSION"
+
+	// ioniceClass* constants are the IO scheduling classes accepted in contracts.ProcessPriority
+	ioniceClassBestEffort = 2
+	ioniceClassIdle       = 3
 )
 
 // T is the interface type for ShellCommandExecuter.
@@ -53,6 +58,35 @@ type T interface {
 
 // ShellCommandExecuter is specially added for testing purposes
 type ShellCommandExecuter struct {
+	// Priority is applied to the processes started by Execute and NewExecute, where supported
+	Priority contracts.ProcessPriority
+	// UsageRecorder receives the resources consumed by the processes started by Execute and NewExecute
+	UsageRecorder ResourceUsageRecorder
+	// Credential is the user the processes started by Execute and NewExecute run as, nil runs them as the agent user
+	Credential *Credential
+	// Stdin is streamed to the standard input of the processes started by Execute and NewExecute, nil leaves it empty
+	Stdin io.Reader
+	// ProcessIDRecorder receives the ID of the processes started by Execute and NewExecute as soon as they start
+	ProcessIDRecorder ProcessIDRecorder
+	// CombineOutput sends the standard error of the processes started by Execute and NewExecute to their standard output
+	CombineOutput bool
+}
+
+// Credential identifies the user and groups a process runs as.
+type Credential struct {
+	Uid    uint32
+	Gid    uint32
+	Groups []uint32
+}
+
+// ResourceUsageRecorder receives the resource usage of completed processes.
+type ResourceUsageRecorder interface {
+	AddResourceUsage(usage contracts.ResourceUsage)
+}
+
+// ProcessIDRecorder receives the ID of started processes.
+type ProcessIDRecorder interface {
+	SetProcessID(pid int)
 }
 
 type timeoutSignal struct {
@@ -75,7 +109,7 @@ type timeoutSignal struct {
 // For byte buffer output, the reader will be a reader over the buffer, which will accumulate the entire output.  Be careful
 // not to use the byte buffer approach for extremely large output (or unknown output) because it could take up a large amount
 // of memory.
-func (ShellCommandExecuter) Execute(
+func (executer ShellCommandExecuter) Execute(
 	context context.T,
 	workingDir string,
 	stdoutFilePath string,
@@ -128,7 +162,7 @@ func (ShellCommandExecuter) Execute(
 	// writers as long as it is after the process starts.
 
 	var err error
-	exitCode, err = ExecuteCommand(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars)
+	exitCode, err = executer.executeCommand(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars)
 	if err != nil {
 		errs = append(errs, err)
 	}
@@ -165,7 +199,7 @@ func (ShellCommandExecuter) Execute(
 }
 
 // NewExecute executes a list of shell commands in the given working directory and provides the stdout and stderr writers.
-func (ShellCommandExecuter) NewExecute(
+func (executer ShellCommandExecuter) NewExecute(
 	context context.T,
 	workingDir string,
 	stdoutWriter io.Writer,
@@ -176,10 +210,91 @@ func (ShellCommandExecuter) NewExecute(
 	commandArguments []string,
 	envVars map[string]string,
 ) (exitCode int, err error) {
-	exitCode, err = ExecuteCommand(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars)
+	exitCode, err = executer.executeCommand(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars)
 	return
 }
 
+// WithPriority returns a copy of the executer that applies the given priority to the processes it starts.
+// Executers that do not support priorities are returned unchanged.
+func WithPriority(executer T, priority contracts.ProcessPriority) T {
+	if shellExecuter, ok := executer.(ShellCommandExecuter); ok {
+		shellExecuter.Priority = priority
+		return shellExecuter
+	}
+	return executer
+}
+
+// WithResourceUsageRecorder returns a copy of the executer that reports the resource usage of the processes it starts to the recorder.
+// Executers that do not support resource usage reporting are returned unchanged.
+func WithResourceUsageRecorder(executer T, recorder ResourceUsageRecorder) T {
+	if shellExecuter, ok := executer.(ShellCommandExecuter); ok {
+		shellExecuter.UsageRecorder = recorder
+		return shellExecuter
+	}
+	return executer
+}
+
+// WithProcessIDRecorder returns a copy of the executer that reports the ID of the processes it starts to the recorder.
+// Executers that do not support process ID reporting are returned unchanged.
+func WithProcessIDRecorder(executer T, recorder ProcessIDRecorder) T {
+	if shellExecuter, ok := executer.(ShellCommandExecuter); ok {
+		shellExecuter.ProcessIDRecorder = recorder
+		return shellExecuter
+	}
+	return executer
+}
+
+// WithCredential returns a copy of the executer that starts its processes as the user identified by the credential.
+// It returns an error for the executers that do not support switching users, which would run the processes as the agent user.
+func WithCredential(executer T, credential *Credential) (T, error) {
+	if shellExecuter, ok := executer.(ShellCommandExecuter); ok {
+		shellExecuter.Credential = credential
+		return shellExecuter, nil
+	}
+	return executer, fmt.Errorf("the command executer %T does not support running commands as another user", executer)
+}
+
+// WithStdin returns a copy of the executer that streams the reader to the standard input of the processes it starts.
+// It returns an error for the executers that do not support standard input, which would run the processes without it.
+func WithStdin(executer T, stdin io.Reader) (T, error) {
+	if shellExecuter, ok := executer.(ShellCommandExecuter); ok {
+		shellExecuter.Stdin = stdin
+		return shellExecuter, nil
+	}
+	return executer, fmt.Errorf("the command executer %T does not support streaming the standard input of commands", executer)
+}
+
+// WithCombinedOutput returns a copy of the executer that sends the standard error of the processes it starts
+// to their standard output, so both streams are captured in order by the standard output writer.
+// It returns an error for the executers that do not support combining output, which would keep the streams separate.
+func WithCombinedOutput(executer T) (T, error) {
+	if shellExecuter, ok := executer.(ShellCommandExecuter); ok {
+		shellExecuter.CombineOutput = true
+		return shellExecuter, nil
+	}
+	return executer, fmt.Errorf("the command executer %T does not support combining the output of commands", executer)
+}
+
+// ValidateProcessPriority checks that the priority only contains values supported by nice and ionice.
+// Raising the priority above the agent default is not allowed.
+func ValidateProcessPriority(priority contracts.ProcessPriority) error {
+	if priority.Nice < 0 || priority.Nice > 19 {
+		return fmt.Errorf("invalid nice value %v, it must be between 0 and 19", priority.Nice)
+	}
+	switch priority.IONiceClass {
+	case 0, ioniceClassBestEffort, ioniceClassIdle:
+	default:
+		return fmt.Errorf("invalid ionice class %v, it must be %v (best-effort) or %v (idle)", priority.IONiceClass, ioniceClassBestEffort, ioniceClassIdle)
+	}
+	if priority.IONiceLevel < 0 || priority.IONiceLevel > 7 {
+		return fmt.Errorf("invalid ionice level %v, it must be between 0 and 7", priority.IONiceLevel)
+	}
+	if priority.IONiceLevel != 0 && priority.IONiceClass != ioniceClassBestEffort {
+		return fmt.Errorf("ionice level is only supported with the best-effort class (%v)", ioniceClassBestEffort)
+	}
+	return nil
+}
+
 // StartExe starts a list of shell commands in the given working directory.
 // Returns process started, an exit code (0 if successfully launch, 1 if error launching process), and a set of errors.
 // The errors need not be fatal - the output streams may still have data
@@ -262,6 +377,22 @@ func ExecuteCommand(
 	commandName string,
 	commandArguments []string,
 	envVars map[string]string,
+) (exitCode int, err error) {
+	return ShellCommandExecuter{}.executeCommand(context, cancelFlag, workingDir, stdoutWriter, stderrWriter, executionTimeout, commandName, commandArguments, envVars)
+}
+
+// executeCommand executes the given commands with the executer priority
+// and reports the resource usage of the completed process to the executer usage recorder.
+func (executer ShellCommandExecuter) executeCommand(
+	context context.T,
+	cancelFlag task.CancelFlag,
+	workingDir string,
+	stdoutWriter io.Writer,
+	stderrWriter io.Writer,
+	executionTimeout int,
+	commandName string,
+	commandArguments []string,
+	envVars map[string]string,
 ) (exitCode int, err error) {
 	log := context.Log()
 
@@ -276,6 +407,13 @@ func ExecuteCommand(
 	// However, if we run goroutines to copy from the StdoutPipe and StderrPipe we may lose the last write.
 	command.Stdout = stdoutInterruptable
 	command.Stderr = stderrInterruptable
+	if executer.CombineOutput {
+		// the process gets a single pipe for both streams when they are the same writer, which keeps their writes in order
+		command.Stderr = stdoutInterruptable
+	}
+	if executer.Stdin != nil {
+		command.Stdin = executer.Stdin
+	}
 	/*
 		stdoutPipe, err := command.StdoutPipe()
 		if err != nil {
@@ -291,19 +429,42 @@ func ExecuteCommand(
 
 	// configure OS-specific process settings
 	prepareProcess(command)
+	if executer.Credential != nil {
+		setProcessCredential(command, executer.Credential)
+	}
 
 	// configure environment variables
 	prepareEnvironment(context, command, envVars)
 
 	log.Debugf("Running in directory %v, command: %v %v", workingDir, commandName, commandArguments)
 
+	if executer.UsageRecorder != nil {
+		prepareResourceAccounting(command)
+	}
+
 	quiesce()
-	if err = command.Start(); err != nil {
+	if err = startProcess(log, command, executer.Priority); err != nil {
 		log.Error("error occurred starting the command", err)
 		exitCode = 1
 		return
 	}
 
+	var accounting *resourceAccounting
+	if executer.UsageRecorder != nil {
+		if accounting, err = startResourceAccounting(log, command.Process); err != nil {
+			log.Error("error occurred starting the command", err)
+			command.Process.Kill()
+			command.Wait()
+			exitCode = 1
+			return
+		}
+		defer accounting.close()
+	}
+
+	if executer.ProcessIDRecorder != nil {
+		executer.ProcessIDRecorder.SetProcessID(command.Process.Pid)
+	}
+
 	signal := timeoutSignal{}
 
 	cancelled := make(chan bool, 1)
@@ -350,6 +511,9 @@ func ExecuteCommand(
 		}
 	case err = <-done:
 		log.Debug("Process completed.")
+		if accounting != nil && command.ProcessState != nil {
+			executer.UsageRecorder.AddResourceUsage(accounting.usage(command.ProcessState))
+		}
 		if err != nil {
 			exitCode = 1
 			log.Debugf("command returned error %v", err)
@@ -461,6 +625,16 @@ func killProcessOnCancel(log log.T, command *exec.Cmd, cancelStdout chan bool, c
 	}
 }
 
+// KillProcessTree kills a process started by an executer along with the processes in its process group,
+// for instance when the plugin that started it does not return once its cancel flag is set
+func KillProcessTree(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return killProcess(process, &timeoutSignal{})
+}
+
 // prepareEnvironment adds ssm agent standard environment variables or environment variables defined by customer/other plugins to the command
 func prepareEnvironment(context context.T, command *exec.Cmd, envVars map[string]string) {
 	log := context.Log()
@@ -0,0 +1,44 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package executers
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExecute_RunsAsCredential(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+	executer, err := WithCredential(ShellCommandExecuter{}, &Credential{Uid: 65534, Gid: 65534, Groups: []uint32{65534}})
+	assert.NoError(t, err)
+	var stdout, stderr bytes.Buffer
+
+	exitCode, err := executer.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+		"sh", []string{"-c", "id -u; id -g"}, make(map[string]string))
+
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, []string{"65534", "65534"}, strings.Split(strings.TrimSpace(stdout.String()), "\n"))
+}
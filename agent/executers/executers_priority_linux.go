@@ -0,0 +1,68 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package executers
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// ioprioWhoProcess targets a single thread in ioprio_set, see ioprio_set(2)
+	ioprioWhoProcess = 1
+	// ioprioClassShift is the position of the scheduling class in an ioprio value
+	ioprioClassShift = 13
+)
+
+// startProcess starts the command with the priority, from an OS thread dedicated to the start.
+// The priority is set on that thread before the command is forked, so the process and everything it spawns
+// inherit it from their creation. The thread exits locked once the command started, so the runtime discards it
+// instead of running other goroutines with the priority.
+func startProcess(log log.T, command *exec.Cmd, priority contracts.ProcessPriority) error {
+	if priority == (contracts.ProcessPriority{}) {
+		return command.Start()
+	}
+
+	started := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		setThreadPriority(log, priority)
+		started <- command.Start()
+	}()
+	return <-started
+}
+
+// setThreadPriority applies the priority to the calling thread
+func setThreadPriority(log log.T, priority contracts.ProcessPriority) {
+	if priority.Nice != 0 {
+		// who 0 is the calling thread on linux
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, priority.Nice); err != nil {
+			log.Warnf("Failed to set nice value %v: %v", priority.Nice, err)
+		}
+	}
+
+	if priority.IONiceClass != 0 {
+		ioprio := priority.IONiceClass<<ioprioClassShift | priority.IONiceLevel
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(ioprio)); errno != 0 {
+			log.Warnf("Failed to set ionice class %v level %v: %v", priority.IONiceClass, priority.IONiceLevel, errno)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package executers
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewExecute_AppliesProcessPriority(t *testing.T) {
+	if _, err := exec.LookPath("ionice"); err != nil {
+		t.Skip("ionice is not available")
+	}
+	executer := WithPriority(ShellCommandExecuter{}, contracts.ProcessPriority{Nice: 10, IONiceClass: ioniceClassIdle})
+	var stdout, stderr bytes.Buffer
+
+	// nice and ionice are spawned right away, they inherit the priority the shell was created with
+	exitCode, err := executer.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+		"sh", []string{"-c", "nice; ionice"}, make(map[string]string))
+
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	assert.Equal(t, []string{"10", "idle"}, lines)
+}
+
+func TestNewExecute_ProcessPriorityDoesNotAffectAgent(t *testing.T) {
+	executer := WithPriority(ShellCommandExecuter{}, contracts.ProcessPriority{Nice: 10})
+	var stdout, stderr bytes.Buffer
+
+	for i := 0; i < 5; i++ {
+		_, err := executer.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+			"true", []string{}, make(map[string]string))
+		assert.NoError(t, err, stderr.String())
+	}
+
+	// commands started afterwards without a priority run with the default nice value
+	stdout.Reset()
+	exitCode, err := ShellCommandExecuter{}.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+		"nice", []string{}, make(map[string]string))
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "0", strings.TrimSpace(stdout.String()))
+}
+
+func TestNewExecute_DefaultProcessPriority(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	exitCode, err := ShellCommandExecuter{}.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+		"nice", []string{}, make(map[string]string))
+
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "0", strings.TrimSpace(stdout.String()))
+}
@@ -20,6 +20,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	mockIdentity "github.com/aws/amazon-ssm-agent/common/identity/mocks"
 	"github.com/stretchr/testify/assert"
@@ -117,3 +118,70 @@ func TestQuotePsStringWithQuotes(t *testing.T) {
 	result = QuotePsString("`abc`")
 	assert.Equal(t, "\"``abc``\"", result)
 }
+
+func TestValidateProcessPriority(t *testing.T) {
+	valid := []contracts.ProcessPriority{
+		{},
+		{Nice: 19},
+		{IONiceClass: ioniceClassIdle},
+		{Nice: 5, IONiceClass: ioniceClassBestEffort, IONiceLevel: 7},
+	}
+	for _, priority := range valid {
+		assert.NoError(t, ValidateProcessPriority(priority), "%+v", priority)
+	}
+
+	invalid := []contracts.ProcessPriority{
+		{Nice: -5},
+		{Nice: 20},
+		{IONiceClass: 1},
+		{IONiceClass: ioniceClassBestEffort, IONiceLevel: 8},
+		{IONiceClass: ioniceClassIdle, IONiceLevel: 4},
+	}
+	for _, priority := range invalid {
+		assert.Error(t, ValidateProcessPriority(priority), "%+v", priority)
+	}
+}
+
+func TestWithPriority_UnsupportedExecuterIsUnchanged(t *testing.T) {
+	var executer T = &ShellCommandExecuter{}
+
+	assert.Equal(t, executer, WithPriority(executer, contracts.ProcessPriority{Nice: 10}))
+}
+
+func TestWithCredential_UnsupportedExecuterFails(t *testing.T) {
+	var executer T = &ShellCommandExecuter{}
+
+	unchanged, err := WithCredential(executer, &Credential{Uid: 65534, Gid: 65534})
+
+	assert.Error(t, err)
+	assert.Equal(t, executer, unchanged)
+}
+
+func TestWithStdin_UnsupportedExecuterFails(t *testing.T) {
+	var executer T = &ShellCommandExecuter{}
+
+	unchanged, err := WithStdin(executer, strings.NewReader("input"))
+
+	assert.Error(t, err)
+	assert.Equal(t, executer, unchanged)
+}
+
+func TestWithCombinedOutput_UnsupportedExecuterFails(t *testing.T) {
+	var executer T = &ShellCommandExecuter{}
+
+	unchanged, err := WithCombinedOutput(executer)
+
+	assert.Error(t, err)
+	assert.Equal(t, executer, unchanged)
+}
+
+func TestWithStdinAndCombinedOutput(t *testing.T) {
+	executer, err := WithStdin(ShellCommandExecuter{}, strings.NewReader("input"))
+	assert.NoError(t, err)
+	executer, err = WithCombinedOutput(executer)
+	assert.NoError(t, err)
+
+	shellExecuter := executer.(ShellCommandExecuter)
+	assert.NotNil(t, shellExecuter.Stdin)
+	assert.True(t, shellExecuter.CombineOutput)
+}
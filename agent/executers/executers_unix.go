@@ -24,6 +24,8 @@ import (
 	"syscall"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
 func prepareProcess(command *exec.Cmd) {
@@ -32,6 +34,11 @@ func prepareProcess(command *exec.Cmd) {
 	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 }
 
+// setProcessCredential makes the process run as the user and groups of the credential
+func setProcessCredential(command *exec.Cmd, credential *Credential) {
+	command.SysProcAttr.Credential = &syscall.Credential{Uid: credential.Uid, Gid: credential.Gid, Groups: credential.Groups}
+}
+
 func quiesce() {
 	if runtime.GOOS != "darwin" {
 		return
@@ -52,6 +59,34 @@ func killProcess(process *os.Process, signal *timeoutSignal) error {
 	return syscall.Kill(-process.Pid, syscall.SIGKILL) // note the minus sign
 }
 
+// resourceAccounting reads the resource usage of a process from the rusage returned by wait4
+type resourceAccounting struct{}
+
+func prepareResourceAccounting(command *exec.Cmd) {}
+
+func startResourceAccounting(log log.T, process *os.Process) (*resourceAccounting, error) {
+	return &resourceAccounting{}, nil
+}
+
+// usage returns the CPU times and the peak resident set size of a completed process,
+// including the descendants it waited for
+func (accounting *resourceAccounting) usage(state *os.ProcessState) contracts.ResourceUsage {
+	usage := contracts.ResourceUsage{
+		UserCPUTimeMillis:   state.UserTime().Milliseconds(),
+		SystemCPUTimeMillis: state.SystemTime().Milliseconds(),
+	}
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		// ru_maxrss is reported in bytes on darwin and in kilobytes on the other platforms
+		usage.PeakMemoryBytes = int64(rusage.Maxrss)
+		if runtime.GOOS != "darwin" {
+			usage.PeakMemoryBytes *= 1024
+		}
+	}
+	return usage
+}
+
+func (accounting *resourceAccounting) close() {}
+
 // Running powershell on linux erquired the HOME env variable to be set and to remove the TERM env variable
 func validateEnvironmentVariables(command *exec.Cmd) {
 
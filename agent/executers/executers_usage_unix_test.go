@@ -0,0 +1,91 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package executers
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+type usageRecorderStub struct {
+	usages []contracts.ResourceUsage
+}
+
+func (recorder *usageRecorderStub) AddResourceUsage(usage contracts.ResourceUsage) {
+	recorder.usages = append(recorder.usages, usage)
+}
+
+func TestNewExecute_RecordsResourceUsage(t *testing.T) {
+	recorder := &usageRecorderStub{}
+	executer := WithResourceUsageRecorder(ShellCommandExecuter{}, recorder)
+	var stdout, stderr bytes.Buffer
+
+	// busy loop in the shell to consume a measurable amount of CPU time
+	exitCode, err := executer.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 60,
+		"/bin/sh", []string{"-c", "i=0; while [ $i -lt 500000 ]; do i=$((i+1)); done"}, make(map[string]string))
+
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	assert.Len(t, recorder.usages, 1)
+	usage := recorder.usages[0]
+	assert.True(t, usage.UserCPUTimeMillis+usage.SystemCPUTimeMillis > 0, "cpu time %+v", usage)
+	assert.True(t, usage.UserCPUTimeMillis+usage.SystemCPUTimeMillis < 60000, "cpu time %+v", usage)
+	assert.True(t, usage.PeakMemoryBytes > 100*1024, "peak memory %+v", usage)
+	assert.True(t, usage.PeakMemoryBytes < 1024*1024*1024, "peak memory %+v", usage)
+}
+
+func TestNewExecute_WithoutRecorderDoesNotRecordUsage(t *testing.T) {
+	recorder := &usageRecorderStub{}
+	var stdout, stderr bytes.Buffer
+
+	exitCode, err := ShellCommandExecuter{}.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+		"/bin/sh", []string{"-c", "exit 0"}, make(map[string]string))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	assert.Empty(t, recorder.usages)
+}
+
+type processIDRecorderStub struct {
+	processIDs []int
+}
+
+func (recorder *processIDRecorderStub) SetProcessID(pid int) {
+	recorder.processIDs = append(recorder.processIDs, pid)
+}
+
+func TestNewExecute_RecordsProcessID(t *testing.T) {
+	recorder := &processIDRecorderStub{}
+	executer := WithProcessIDRecorder(ShellCommandExecuter{}, recorder)
+	var stdout, stderr bytes.Buffer
+
+	exitCode, err := executer.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 10,
+		"/bin/sh", []string{"-c", "echo $$"}, make(map[string]string))
+
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	if assert.Len(t, recorder.processIDs, 1) {
+		assert.NotZero(t, recorder.processIDs[0])
+		assert.Equal(t, fmt.Sprintf("%d\n", recorder.processIDs[0]), stdout.String())
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package executers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+type usageRecorderStub struct {
+	usages []contracts.ResourceUsage
+}
+
+func (recorder *usageRecorderStub) AddResourceUsage(usage contracts.ResourceUsage) {
+	recorder.usages = append(recorder.usages, usage)
+}
+
+// TestNewExecute_RecordsResourceUsageOfChildren runs a command started suspended for its job object,
+// which must be resumed and account for the child process it spawns
+func TestNewExecute_RecordsResourceUsageOfChildren(t *testing.T) {
+	recorder := &usageRecorderStub{}
+	executer := WithResourceUsageRecorder(ShellCommandExecuter{}, recorder)
+	var stdout, stderr bytes.Buffer
+
+	exitCode, err := executer.NewExecute(context.NewMockDefault(), "", &stdout, &stderr, task.NewChanneledCancelFlag(), 60,
+		"cmd", []string{"/c", "cmd /c echo child"}, make(map[string]string))
+
+	assert.NoError(t, err, stderr.String())
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, "child", strings.TrimSpace(stdout.String()))
+	assert.Len(t, recorder.usages, 1)
+	assert.True(t, recorder.usages[0].PeakMemoryBytes > 0, "peak memory %+v", recorder.usages[0])
+}
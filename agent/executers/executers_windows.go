@@ -17,8 +17,15 @@
 package executers
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows"
 )
 
 const (
@@ -29,6 +36,10 @@ func prepareProcess(command *exec.Cmd) {
 	// nothing to do on windows
 }
 
+func setProcessCredential(command *exec.Cmd, credential *Credential) {
+	// switching users is not supported on windows
+}
+
 func quiesce() {
 	// not needed for Darwin workaround
 }
@@ -40,6 +51,88 @@ func killProcess(process *os.Process, signal *timeoutSignal) error {
 	return process.Kill()
 }
 
+// jobObjectBasicAccountingInformation mirrors JOBOBJECT_BASIC_ACCOUNTING_INFORMATION, times are in 100 nanosecond units
+type jobObjectBasicAccountingInformation struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// resourceAccounting reads the resource usage of a process and the children it spawns from a dedicated job object
+type resourceAccounting struct {
+	job windows.Handle
+}
+
+// ntResumeProcess resumes all the threads of a process started suspended
+var ntResumeProcess = windows.NewLazySystemDLL("ntdll.dll").NewProc("NtResumeProcess")
+
+// prepareResourceAccounting creates the process suspended, so that it is assigned to its job object
+// before it can spawn children that would escape the accounting
+func prepareResourceAccounting(command *exec.Cmd) {
+	if command.SysProcAttr == nil {
+		command.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	command.SysProcAttr.CreationFlags |= windows.CREATE_SUSPENDED
+}
+
+// startResourceAccounting assigns the suspended process to a new job object, then resumes it.
+// When the job object cannot be set up the usage falls back to the CPU times of the process itself.
+// An error is returned when the process cannot be resumed.
+func startResourceAccounting(log log.T, process *os.Process) (*resourceAccounting, error) {
+	accounting := &resourceAccounting{}
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE|windows.PROCESS_SUSPEND_RESUME, false, uint32(process.Pid))
+	if err != nil {
+		return accounting, fmt.Errorf("failed to open process %v: %v", process.Pid, err)
+	}
+	defer windows.CloseHandle(handle)
+
+	if job, err := windows.CreateJobObject(nil, nil); err != nil {
+		log.Debugf("failed to create job object for resource accounting: %v", err)
+	} else if err = windows.AssignProcessToJobObject(job, handle); err != nil {
+		log.Debugf("failed to assign process %v to job object for resource accounting: %v", process.Pid, err)
+		windows.CloseHandle(job)
+	} else {
+		accounting.job = job
+	}
+
+	if status, _, _ := ntResumeProcess.Call(uintptr(handle)); status != 0 {
+		return accounting, fmt.Errorf("failed to resume process %v: NTSTATUS 0x%x", process.Pid, status)
+	}
+	return accounting, nil
+}
+
+// usage returns the CPU times and the peak memory of the job, or the CPU times of the process without a job
+func (accounting *resourceAccounting) usage(state *os.ProcessState) contracts.ResourceUsage {
+	usage := contracts.ResourceUsage{
+		UserCPUTimeMillis:   state.UserTime().Milliseconds(),
+		SystemCPUTimeMillis: state.SystemTime().Milliseconds(),
+	}
+	if accounting.job == 0 {
+		return usage
+	}
+	var basic jobObjectBasicAccountingInformation
+	if err := windows.QueryInformationJobObject(accounting.job, windows.JobObjectBasicAccountingInformation, uintptr(unsafe.Pointer(&basic)), uint32(unsafe.Sizeof(basic)), nil); err == nil {
+		usage.UserCPUTimeMillis = basic.TotalUserTime / 10000
+		usage.SystemCPUTimeMillis = basic.TotalKernelTime / 10000
+	}
+	var extended windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	if err := windows.QueryInformationJobObject(accounting.job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&extended)), uint32(unsafe.Sizeof(extended)), nil); err == nil {
+		usage.PeakMemoryBytes = int64(extended.PeakProcessMemoryUsed)
+	}
+	return usage
+}
+
+func (accounting *resourceAccounting) close() {
+	if accounting.job != 0 {
+		windows.CloseHandle(accounting.job)
+	}
+}
+
 // Running powershell on linux required the HOME env variable to be set and to remove the TERM env variable
 func validateEnvironmentVariables(command *exec.Cmd) {
 }
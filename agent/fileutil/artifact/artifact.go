@@ -41,6 +41,10 @@ import (
 	"github.com/cenkalti/backoff/v4"
 )
 
+// PartialDownloadSuffix is appended to the destination path of a download while its content is being written.
+// The file is renamed to the destination path only once the download has completed.
+const PartialDownloadSuffix = ".partial"
+
 // DownloadOutput holds the result of file download operation.
 type DownloadOutput struct {
 	LocalFilePath string
@@ -116,6 +120,13 @@ func httpDownload(ctx context.T, fileURL string, destFile string) (output Downlo
 		}
 
 		defer resp.Body.Close()
+		_, err = FileCopy(log, destFile, resp.Body)
+		if err != nil {
+			_ = log.Errorf("failed to write destFile %v, %v ", destFile, err)
+			return
+		}
+
+		// the etag is only recorded once the content it describes is in place
 		eTagValue := resp.Header.Get("Etag")
 		if eTagValue != "" {
 			log.Debug("file eTagValue is ", eTagValue)
@@ -125,13 +136,8 @@ func httpDownload(ctx context.T, fileURL string, destFile string) (output Downlo
 				return
 			}
 		}
-		_, err = FileCopy(log, destFile, resp.Body)
-		if err == nil {
-			output.LocalFilePath = destFile
-			output.IsUpdated = true
-		} else {
-			_ = log.Errorf("failed to write destFile %v, %v ", destFile, err)
-		}
+		output.LocalFilePath = destFile
+		output.IsUpdated = true
 
 		return
 	}
@@ -291,7 +297,14 @@ func s3Download(context context.T, amazonS3URL s3util.AmazonS3URL, destFile stri
 		return output, nil
 	}
 
-	if *resp.ETag != "" {
+	defer resp.Body.Close()
+	_, err = FileCopy(log, destFile, resp.Body)
+	if err != nil {
+		log.Errorf("failed to write destFile %v, %v ", destFile, err)
+		return
+	}
+
+	if resp.ETag != nil && *resp.ETag != "" {
 		log.Debug("files etag is ", *resp.ETag)
 		err = fileutil.WriteAllText(eTagFile, *resp.ETag)
 		if err != nil {
@@ -299,15 +312,8 @@ func s3Download(context context.T, amazonS3URL s3util.AmazonS3URL, destFile stri
 			return
 		}
 	}
-
-	defer resp.Body.Close()
-	_, err = FileCopy(log, destFile, resp.Body)
-	if err == nil {
-		output.LocalFilePath = destFile
-		output.IsUpdated = true
-	} else {
-		log.Errorf("failed to write destFile %v, %v ", destFile, err)
-	}
+	output.LocalFilePath = destFile
+	output.IsUpdated = true
 	return
 }
 
@@ -345,19 +351,40 @@ func S3FileRead(context context.T, s3FullPath string) (output []byte, err error)
 	return content, nil
 }
 
-// FileCopy copies the content from reader to destinationPath file
+// FileCopy copies the content from reader to destinationPath file.
+// The content is written to a partial file next to the destination which is renamed to destinationPath
+// once the copy has completed, an interrupted copy removes the partial file and leaves destinationPath untouched.
 func FileCopy(log log.T, destinationPath string, src io.Reader) (written int64, err error) {
+	partialPath := destinationPath + PartialDownloadSuffix
 
 	var file *os.File
-	file, err = os.Create(destinationPath)
+	file, err = os.Create(partialPath)
 	if err != nil {
 		log.Errorf("failed to create file. %v", err)
 		return
 	}
-	defer file.Close()
-	var size int64
-	size, err = io.Copy(file, src)
-	log.Infof("%s with %v bytes downloaded", destinationPath, size)
+	defer func() {
+		if err != nil {
+			if deleteErr := fileutil.DeleteFile(partialPath); deleteErr != nil && !os.IsNotExist(deleteErr) {
+				log.Warnf("failed to delete partial download %v, %v", partialPath, deleteErr)
+			}
+		}
+	}()
+
+	written, err = io.Copy(file, src)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Errorf("download of %s interrupted after %v bytes. %v", destinationPath, written, err)
+		return
+	}
+
+	if err = os.Rename(partialPath, destinationPath); err != nil {
+		log.Errorf("failed to move partial download to %v. %v", destinationPath, err)
+		return
+	}
+	log.Infof("%s with %v bytes downloaded", destinationPath, written)
 	return
 }
 
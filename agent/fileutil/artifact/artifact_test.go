@@ -0,0 +1,89 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package artifact
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// interruptedReader returns its content followed by an error, as a connection dropped mid-transfer would
+type interruptedReader struct {
+	content io.Reader
+}
+
+func (reader *interruptedReader) Read(p []byte) (int, error) {
+	n, err := reader.content.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+func TestFileCopy(t *testing.T) {
+	destinationPath := filepath.Join(t.TempDir(), "download")
+
+	written, err := FileCopy(log.NewMockLog(), destinationPath, strings.NewReader("file content"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("file content")), written)
+	content, err := os.ReadFile(destinationPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "file content", string(content))
+	assert.NoFileExists(t, destinationPath+PartialDownloadSuffix)
+}
+
+func TestFileCopy_InterruptedLeavesNoPartialFile(t *testing.T) {
+	destinationPath := filepath.Join(t.TempDir(), "download")
+
+	_, err := FileCopy(log.NewMockLog(), destinationPath, &interruptedReader{content: strings.NewReader("partial content")})
+
+	assert.Error(t, err)
+	assert.NoFileExists(t, destinationPath)
+	assert.NoFileExists(t, destinationPath+PartialDownloadSuffix)
+}
+
+func TestFileCopy_InterruptedKeepsPreviousDownload(t *testing.T) {
+	destinationPath := filepath.Join(t.TempDir(), "download")
+	assert.NoError(t, os.WriteFile(destinationPath, []byte("previous content"), 0600))
+
+	_, err := FileCopy(log.NewMockLog(), destinationPath, &interruptedReader{content: strings.NewReader("partial content")})
+
+	assert.Error(t, err)
+	content, err := os.ReadFile(destinationPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "previous content", string(content))
+	assert.NoFileExists(t, destinationPath+PartialDownloadSuffix)
+}
+
+func TestFileCopy_ReplacesPreviousDownloadAndStalePartialFile(t *testing.T) {
+	destinationPath := filepath.Join(t.TempDir(), "download")
+	assert.NoError(t, os.WriteFile(destinationPath, []byte("previous content"), 0600))
+	assert.NoError(t, os.WriteFile(destinationPath+PartialDownloadSuffix, []byte("stale partial content from a crash"), 0600))
+
+	_, err := FileCopy(log.NewMockLog(), destinationPath, strings.NewReader("new"))
+
+	assert.NoError(t, err)
+	content, err := os.ReadFile(destinationPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+	assert.NoFileExists(t, destinationPath+PartialDownloadSuffix)
+}
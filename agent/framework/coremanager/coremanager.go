@@ -29,6 +29,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	logger "github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/rebooter"
+	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
 )
 
 const (
@@ -75,6 +76,7 @@ func NewCoreManager(context context.T, mr coremodules.ModuleRegistry, cwp *cloud
 	cwp.Init()
 	context = context.With("[instanceID=" + shortInstanceId + "]")
 	runpluginutil.SSMPluginRegistry = plugin.RegisteredWorkerPlugins(context)
+	filewatcherbasedipc.WaitForMessageVisibility = context.AppConfig().Agent.WaitForIPCMessageVisibility
 
 	return &CoreManager{
 		context:             context,
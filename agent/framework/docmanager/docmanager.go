@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,6 +35,12 @@ import (
 
 const (
 	maxOrchestrationDirectoryDeletions int = 1000
+
+	// orchestrationRetentionSweepInterval is the minimum time between two age based sweeps of the orchestration directories
+	orchestrationRetentionSweepInterval = time.Hour
+
+	// orchestrationRetentionLockSuffix distinguishes the lock of the age based sweep from the one of the regular clean up
+	orchestrationRetentionLockSuffix = "-retention"
 )
 
 type validString func(string) bool
@@ -460,6 +467,103 @@ func DeleteSessionOrchestrationDirectories(log log.T, instanceID, orchestrationR
 	log.Debugf("Completed session orchestration directory clean up of %v items", deletedCount)
 }
 
+// DeleteExpiredOrchestrationDirectories deletes the orchestration directories not modified for retentionDays, whatever
+// the outcome of their document. It is called by the agent core when documents complete, runs at most once per
+// orchestrationRetentionSweepInterval, and does nothing when retentionDays is not positive.
+func DeleteExpiredOrchestrationDirectories(log log.T, instanceID, orchestrationRootDirName string, retentionDays int) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Delete expired orchestration directories panic: %v", r)
+			log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+
+	if retentionDays <= 0 {
+		return
+	}
+
+	retentionLockName := orchestrationRootDirName + orchestrationRetentionLockSuffix
+	if !getLock(retentionLockName) {
+		return
+	}
+	defer releaseLock(retentionLockName)
+
+	deleteExpiredOrchestrationDirs(log,
+		orchestrationDir(instanceID, orchestrationRootDirName, appconfig.DefaultDocumentRootDirName),
+		DocumentStateDir(instanceID, appconfig.DefaultLocationOfCurrent),
+		retentionDays)
+
+	cleanupLock.Lock()
+	defer cleanupLock.Unlock()
+	nextCleanup[retentionLockName] = time.Now().Add(orchestrationRetentionSweepInterval)
+}
+
+// deleteExpiredOrchestrationDirs deletes the directories of orchestrationRootDir that were not modified for
+// retentionDays. The directories of documents still in progress, which have a state file in currentStateDir,
+// are kept whatever their age.
+func deleteExpiredOrchestrationDirs(log log.T, orchestrationRootDir, currentStateDir string, retentionDays int) {
+	if !fileutil.Exists(orchestrationRootDir) {
+		log.Debugf("Orchestration root directory doesn't exist: %v", orchestrationRootDir)
+		return
+	}
+	dirNames, err := fileutil.GetDirectoryNames(orchestrationRootDir)
+	if err != nil {
+		log.Warnf("failed to list the orchestration directories under %v: %v", orchestrationRootDir, err)
+		return
+	}
+
+	// state files of the documents in progress are named after their document id,
+	// a command id for Run Command and "<association id>.<run id>" for associations
+	var inProgressDocumentIDs []string
+	if fileutil.Exists(currentStateDir) {
+		if inProgressDocumentIDs, err = fileutil.GetFileNames(currentStateDir); err != nil {
+			// without the in progress documents nothing can be safely deleted
+			log.Warnf("failed to list the documents in progress under %v: %v", currentStateDir, err)
+			return
+		}
+	}
+
+	expiry := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+	deletedCount := 0
+	for _, dirName := range dirNames {
+		if deletedCount >= maxOrchestrationDirectoryDeletions {
+			log.Warnf("Reached max number of deletions for orchestration directories: %v", deletedCount)
+			break
+		}
+		if isOrchestrationDirInProgress(dirName, inProgressDocumentIDs) {
+			continue
+		}
+
+		dirPath := filepath.Join(orchestrationRootDir, dirName)
+		modificationTime, err := fileutil.GetFileModificationTime(dirPath)
+		if err != nil {
+			log.Debugf("Failed to get modification time of %v: %v", dirPath, err)
+			continue
+		}
+		if !modificationTime.Before(expiry) {
+			continue
+		}
+
+		log.Debugf("deleting orchestration directory %v older than %v days", dirPath, retentionDays)
+		if err := fileutil.DeleteDirectory(dirPath); err != nil {
+			log.Warnf("error deleting the directory %v: %v", dirPath, err)
+			continue
+		}
+		deletedCount++
+	}
+	log.Debugf("Completed orchestration directory retention sweep of %v items", deletedCount)
+}
+
+// isOrchestrationDirInProgress returns true if the orchestration directory belongs to one of the documents in progress
+func isOrchestrationDirInProgress(dirName string, inProgressDocumentIDs []string) bool {
+	for _, documentID := range inProgressDocumentIDs {
+		if documentID == dirName || strings.HasPrefix(documentID, dirName+".") {
+			return true
+		}
+	}
+	return false
+}
+
 // isOlderThan checks whether the file is older than the retention duration
 func isOlderThan(log log.T, fileFullPath string, retentionDurationHours int) bool {
 	modificationTime, err := fileutil.GetFileModificationTime(fileFullPath)
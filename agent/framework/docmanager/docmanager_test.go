@@ -13,9 +13,14 @@
 package docmanager
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -65,3 +70,70 @@ func TestDocManagerLockMultipleFolders(t *testing.T) {
 	assert.False(t, getLock(TEST_DOC_DIR))
 	assert.False(t, getLock(TEST_SEC_DIR))
 }
+
+func TestDeleteExpiredOrchestrationDirs(t *testing.T) {
+	orchestrationRootDir := t.TempDir()
+	currentStateDir := t.TempDir()
+	now := time.Now()
+
+	dirAges := map[string]time.Duration{
+		"expired-command":                 10 * 24 * time.Hour,
+		"recent-command":                  24 * time.Hour,
+		"expired-in-progress-command":     10 * 24 * time.Hour,
+		"expired-association":             10 * 24 * time.Hour,
+		"expired-in-progress-association": 10 * 24 * time.Hour,
+	}
+	for name, age := range dirAges {
+		dirPath := filepath.Join(orchestrationRootDir, name)
+		require.NoError(t, os.Mkdir(dirPath, 0700))
+		require.NoError(t, os.Chtimes(dirPath, now.Add(-age), now.Add(-age)))
+	}
+	// in progress markers are the state files of the documents in the current folder
+	for _, documentID := range []string{"expired-in-progress-command", "expired-in-progress-association.2024-01-01T00-00-00.000Z"} {
+		require.NoError(t, os.WriteFile(filepath.Join(currentStateDir, documentID), []byte("{}"), 0600))
+	}
+
+	deleteExpiredOrchestrationDirs(log.NewMockLog(), orchestrationRootDir, currentStateDir, 7)
+
+	entries, err := os.ReadDir(orchestrationRootDir)
+	require.NoError(t, err)
+	var remainingDirs []string
+	for _, entry := range entries {
+		remainingDirs = append(remainingDirs, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"recent-command", "expired-in-progress-command", "expired-in-progress-association"}, remainingDirs)
+}
+
+func TestDeleteExpiredOrchestrationDirsWithoutInProgressDocuments(t *testing.T) {
+	orchestrationRootDir := t.TempDir()
+	expiredDir := filepath.Join(orchestrationRootDir, "expired-command")
+	require.NoError(t, os.Mkdir(expiredDir, 0700))
+	modificationTime := time.Now().Add(-2 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(expiredDir, modificationTime, modificationTime))
+
+	deleteExpiredOrchestrationDirs(log.NewMockLog(), orchestrationRootDir, filepath.Join(orchestrationRootDir, "missing"), 1)
+
+	assert.NoDirExists(t, expiredDir)
+}
+
+func TestDeleteExpiredOrchestrationDirectoriesDisabledByDefault(t *testing.T) {
+	retentionLockName := TEST_ORC_DIR + "-disabled" + orchestrationRetentionLockSuffix
+
+	DeleteExpiredOrchestrationDirectories(log.NewMockLog(), "i-1234567890", TEST_ORC_DIR+"-disabled", 0)
+
+	assert.True(t, nextCleanup[retentionLockName].IsZero())
+}
+
+func TestDeleteExpiredOrchestrationDirectoriesRunsOncePerInterval(t *testing.T) {
+	orchestrationRootDirName := TEST_ORC_DIR + "-" + t.Name()
+	retentionLockName := orchestrationRootDirName + orchestrationRetentionLockSuffix
+
+	DeleteExpiredOrchestrationDirectories(log.NewMockLog(), "i-1234567890", orchestrationRootDirName, 30)
+
+	nextSweep := nextCleanup[retentionLockName]
+	assert.True(t, nextSweep.After(time.Now().Add(orchestrationRetentionSweepInterval/2)))
+	// the next sweep within the interval is skipped
+	assert.False(t, getLock(retentionLockName))
+	assert.True(t, getLock(orchestrationRootDirName))
+	releaseLock(orchestrationRootDirName)
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+)
+
+// DocumentDescription describes the parameters and steps of a command document
+type DocumentDescription struct {
+	SchemaVersion string
+	Description   string
+	// Parameters are sorted by name
+	Parameters []ParameterSpec
+	// Steps are in the order they run, the steps of schema 1.x documents are sorted by name
+	Steps []StepSpec
+}
+
+// ParameterSpec describes a parameter of a document
+type ParameterSpec struct {
+	Name        string
+	Type        string
+	Description string
+	// Default is the default value on this platform, nil when the parameter has none
+	Default        interface{}
+	AllowedValues  []string
+	AllowedPattern string
+	// Required is set for the parameters without a default value
+	Required bool
+}
+
+// StepSpec describes a step of a document
+type StepSpec struct {
+	Name string
+	// Action is the name of the plugin running the step, such as aws:runShellScript
+	Action         string
+	TimeoutSeconds int
+}
+
+// DescribeDocument returns the parameters and steps of a raw JSON or YAML command document, with the steps parsed
+// the way ParseDocument parses them. It needs neither an agent context nor parameter values, so parameter and
+// SSM parameter store references in the steps are not resolved.
+func DescribeDocument(raw []byte) (description DocumentDescription, err error) {
+	var docContent DocContent
+	// the error is already a SyntaxError locating the error in the document
+	if err = UnmarshalDocumentContent(raw, &docContent); err != nil {
+		return description, err
+	}
+	if docContent.isShorthand() {
+		docContent.expandShorthand()
+	}
+	if err = validateSchema(docContent.SchemaVersion); err != nil {
+		return description, newParseError(SchemaError, err)
+	}
+
+	log := logger.NewSilentLogger()
+	pluginsInfo, err := parseDocumentContent(docContent, DocumentParserInfo{}, log, nil)
+	if err != nil {
+		return description, newParseError(SchemaError, err)
+	}
+
+	description.SchemaVersion = docContent.SchemaVersion
+	description.Description = docContent.Description
+	for _, pluginInfo := range pluginsInfo {
+		description.Steps = append(description.Steps, StepSpec{
+			Name:           pluginInfo.Id,
+			Action:         pluginInfo.Name,
+			TimeoutSeconds: pluginInfo.Configuration.TimeoutSeconds,
+		})
+	}
+	// the runtime config of schema 1.x documents is a map, its plugins have no order
+	if len(docContent.MainSteps) == 0 {
+		sort.Slice(description.Steps, func(i, j int) bool { return description.Steps[i].Name < description.Steps[j].Name })
+	}
+
+	for name, definition := range docContent.Parameters {
+		if definition == nil {
+			continue
+		}
+		defaultValue := parameterDefault(log, definition)
+		description.Parameters = append(description.Parameters, ParameterSpec{
+			Name:           name,
+			Type:           definition.ParamType,
+			Description:    definition.Description,
+			Default:        defaultValue,
+			AllowedValues:  definition.AllowedVal,
+			AllowedPattern: definition.AllowedPattern,
+			Required:       defaultValue == nil,
+		})
+	}
+	sort.Slice(description.Parameters, func(i, j int) bool { return description.Parameters[i].Name < description.Parameters[j].Name })
+	return description, nil
+}
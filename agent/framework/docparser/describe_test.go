@@ -0,0 +1,121 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const describeTestDocument = `
+schemaVersion: "2.2"
+description: Configure the web server
+parameters:
+  packages:
+    type: StringList
+    description: Packages to install
+  restart:
+    type: String
+    default: "yes"
+    allowedValues: ["yes", "no"]
+  shell:
+    type: String
+    default: sh
+    platformDefaults:
+      windows: powershell
+mainSteps:
+  - action: aws:runShellScript
+    name: install
+    timeoutSeconds: 600
+    inputs:
+      runCommand: ["yum install -y {{ packages }}"]
+  - action: aws:runPowerShellScript
+    name: restart
+    inputs:
+      runCommand: ["Restart-Service W3SVC"]
+  - action: aws:runShellScript
+    name: check
+    inputs:
+      runCommand: ["curl localhost"]
+`
+
+func TestDescribeDocument(t *testing.T) {
+	getPlatformTypeOrig := getPlatformType
+	defer func() { getPlatformType = getPlatformTypeOrig }()
+	getPlatformType = func(log log.T) (string, error) { return "windows", nil }
+
+	description, err := DescribeDocument([]byte(describeTestDocument))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2.2", description.SchemaVersion)
+	assert.Equal(t, "Configure the web server", description.Description)
+	assert.Equal(t, []ParameterSpec{
+		{Name: "packages", Type: "StringList", Description: "Packages to install", Required: true},
+		{Name: "restart", Type: "String", Default: "yes", AllowedValues: []string{"yes", "no"}},
+		{Name: "shell", Type: "String", Default: "powershell"},
+	}, description.Parameters)
+	assert.Equal(t, []StepSpec{
+		{Name: "install", Action: "aws:runShellScript", TimeoutSeconds: 600},
+		{Name: "restart", Action: "aws:runPowerShellScript"},
+		{Name: "check", Action: "aws:runShellScript"},
+	}, description.Steps)
+}
+
+func TestDescribeDocument_RuntimeConfigStepsSortedByName(t *testing.T) {
+	description, err := DescribeDocument([]byte(`{
+		"schemaVersion": "1.2",
+		"runtimeConfig": {
+			"aws:runShellScript": {"properties": [{"runCommand": ["uptime"]}]},
+			"aws:applications": {"properties": [{"action": "Install", "source": "https://example.com/app.msi"}]}
+		}
+	}`))
+
+	assert.NoError(t, err)
+	assert.Empty(t, description.Parameters)
+	assert.Equal(t, []StepSpec{
+		{Name: "aws:applications", Action: "aws:applications"},
+		{Name: "aws:runShellScript", Action: "aws:runShellScript"},
+	}, description.Steps)
+}
+
+func TestDescribeDocument_Shorthand(t *testing.T) {
+	description, err := DescribeDocument([]byte(`{"command": "uptime"}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []StepSpec{{Name: shorthandStepName, Action: "aws:runShellScript"}}, description.Steps)
+}
+
+func TestDescribeDocument_InvalidDocument(t *testing.T) {
+	for _, raw := range []string{`{"schemaVersion": "9999"}`, `{"schemaVersion": "2.2"}`} {
+		_, err := DescribeDocument([]byte(raw))
+
+		var parseError *ParseError
+		assert.True(t, errors.As(err, &parseError), raw)
+		assert.Equal(t, SchemaError, parseError.Kind, raw)
+	}
+}
+
+func TestDescribeDocument_SyntaxError(t *testing.T) {
+	_, err := DescribeDocument([]byte("{\n  \"schemaVersion\": \"2.2\",\n  \"mainSteps\": [}\n}"))
+
+	var parseError *ParseError
+	if assert.True(t, errors.As(err, &parseError)) {
+		assert.Equal(t, SyntaxError, parseError.Kind)
+		assert.Equal(t, 3, parseError.Line)
+		assert.Equal(t, 17, parseError.Column)
+	}
+}
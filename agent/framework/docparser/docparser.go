@@ -16,9 +16,13 @@
 package docparser
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -26,13 +30,21 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/parameters"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/parameterstore"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/stepoutput"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/versionutil"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	preconditionSchemaVersion string = "2.2"
+
+	// shorthandSchemaVersion and shorthandStepName are used for the step generated from a shorthand document
+	shorthandSchemaVersion = "2.2"
+	shorthandStepName      = "runShellScript"
 )
 
 // DocumentParserInfo represents the parsed information from the request
@@ -44,6 +56,8 @@ type DocumentParserInfo struct {
 	DocumentId        string
 	DefaultWorkingDir string
 	CloudWatchConfig  contracts.CloudWatchConfiguration
+	// ExecutionDepth is the number of aws:runDocument levels the document is nested under
+	ExecutionDepth int
 }
 
 // InitializeDocState is a method to obtain the state of the document.
@@ -66,6 +80,7 @@ func InitializeDocState(
 		return
 	}
 	docState.InstancePluginsInformation = pluginInfo
+	docState.Provenance = newExecutionProvenance(context.Log(), docContent, pluginInfo, params)
 	return docState, nil
 }
 
@@ -79,19 +94,100 @@ type IDocumentContent interface {
 type DocContent contracts.DocumentContent
 type SessionDocContent contracts.SessionDocumentContent
 
+// utf8ByteOrderMark is prepended to documents by some Windows editors and export tools
+var utf8ByteOrderMark = []byte{0xEF, 0xBB, 0xBF}
+
+// NormalizeDocumentRaw strips a leading UTF-8 byte order mark and the trailing whitespace of a raw document,
+// neither of which the JSON and YAML decoders accept reliably. It returns whether the document was changed,
+// not counting the line ending most documents end with.
+func NormalizeDocumentRaw(documentRaw []byte) (normalized []byte, changed bool) {
+	normalized = bytes.TrimPrefix(documentRaw, utf8ByteOrderMark)
+	changed = len(normalized) != len(documentRaw)
+	trimmed := bytes.TrimRightFunc(normalized, unicode.IsSpace)
+	switch string(normalized[len(trimmed):]) {
+	case "", "\n", "\r\n":
+	default:
+		changed = true
+	}
+	return trimmed, changed
+}
+
+// UnmarshalDocumentContent unmarshals a raw JSON or YAML document into docContent.
+// The document is normalized with NormalizeDocumentRaw first.
+// YAML documents are first decoded into generic values, which fully resolves anchors and merge keys,
+// and are then converted to the same types json.Unmarshal produces before being decoded into docContent.
+// This guarantees both formats yield identical plugin inputs for validation and parameter substitution.
+// Errors are returned as a SyntaxError ParseError with the location of the error in the normalized document,
+// the JSON error is reported for documents starting like a JSON object or array and the YAML error otherwise.
+func UnmarshalDocumentContent(documentRaw []byte, docContent *DocContent) error {
+	documentRaw, _ = NormalizeDocumentRaw(documentRaw)
+	jsonErr := json.Unmarshal(documentRaw, docContent)
+	if jsonErr == nil {
+		return nil
+	}
+
+	var rawDocument interface{}
+	if err := yaml.Unmarshal(documentRaw, &rawDocument); err != nil {
+		if trimmed := bytes.TrimLeftFunc(documentRaw, unicode.IsSpace); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return newSyntaxError(documentRaw, jsonErr)
+		}
+		return newSyntaxError(documentRaw, err)
+	}
+	jsonDocument, err := json.Marshal(normalizeYAMLValue(rawDocument))
+	if err != nil {
+		return newParseError(SyntaxError, err)
+	}
+	return newParseError(SyntaxError, json.Unmarshal(jsonDocument, docContent))
+}
+
+// normalizeYAMLValue recursively converts the map[interface{}]interface{} values produced by yaml.v2
+// into map[string]interface{} so the value can be marshaled to JSON
+func normalizeYAMLValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			out[fmt.Sprint(k)] = normalizeYAMLValue(v)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, v := range value {
+			out[i] = normalizeYAMLValue(v)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
 // GetSchemaVersion is a method used to get document schema version
 func (docContent *DocContent) GetSchemaVersion() string {
+	if docContent.isShorthand() {
+		return shorthandSchemaVersion
+	}
 	return docContent.SchemaVersion
 }
 
 // GetIOConfiguration is a method used to get IO config from the document
 func (docContent *DocContent) GetIOConfiguration(parserInfo DocumentParserInfo) contracts.IOConfiguration {
-	return contracts.IOConfiguration{
-		OrchestrationDirectory: parserInfo.OrchestrationDir,
-		OutputS3BucketName:     parserInfo.S3Bucket,
-		OutputS3KeyPrefix:      parserInfo.S3Prefix,
-		CloudWatchConfig:       parserInfo.CloudWatchConfig,
+	ioConfig := contracts.IOConfiguration{
+		OrchestrationDirectory:           parserInfo.OrchestrationDir,
+		OutputS3BucketName:               parserInfo.S3Bucket,
+		OutputS3KeyPrefix:                parserInfo.S3Prefix,
+		CloudWatchConfig:                 parserInfo.CloudWatchConfig,
+		OnFailure:                        docContent.OnFailure,
+		CommandTimeoutSeconds:            docContent.CommandTimeoutSeconds,
+		IncrementalUploadIntervalSeconds: docContent.IncrementalUploadIntervalSeconds,
+	}
+	if docContent.PreStep != "" || docContent.PostStep != "" {
+		ioConfig.StepHooks = &contracts.StepHooks{
+			PreStep:   docContent.PreStep,
+			PostStep:  docContent.PostStep,
+			OnFailure: docContent.OnStepHookFailure,
+		}
 	}
+	return ioConfig
 }
 
 // ParseDocument is a method used to parse documents that are not received by any service (MDS or State manager)
@@ -100,14 +196,91 @@ func (docContent *DocContent) ParseDocument(context context.T,
 	parserInfo DocumentParserInfo,
 	params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 
+	if docContent.isShorthand() {
+		docContent.expandShorthand()
+	}
 	if err = validateSchema(docContent.SchemaVersion); err != nil {
-		return
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	if err = validateStepCount(docContent, context.AppConfig().Ssm.DocumentMaxStepCount); err != nil {
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	if err = validateOnFailure(docContent.OnFailure); err != nil {
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	if err = validateOnStepHookFailure(docContent.OnStepHookFailure); err != nil {
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	if docContent.CommandTimeoutSeconds < 0 {
+		return pluginsInfo, newParseError(SchemaError, fmt.Errorf("document commandTimeoutSeconds %v must not be negative", docContent.CommandTimeoutSeconds))
+	}
+	if docContent.IncrementalUploadIntervalSeconds < 0 {
+		return pluginsInfo, newParseError(SchemaError, fmt.Errorf("document incrementalUploadIntervalSeconds %v must not be negative", docContent.IncrementalUploadIntervalSeconds))
 	}
 	if err = getValidatedParameters(context, params, docContent); err != nil {
-		return
+		return pluginsInfo, newParseError(ParameterError, err)
 	}
 
-	return parseDocumentContent(*docContent, parserInfo, context.Log(), params)
+	if pluginsInfo, err = parseDocumentContent(*docContent, parserInfo, context.Log(), params); err != nil {
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	if err = validatePluginInputs(pluginsInfo); err != nil {
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	if err = validateSuccessCriteria(pluginsInfo); err != nil {
+		return pluginsInfo, newParseError(SchemaError, err)
+	}
+	for i := range pluginsInfo {
+		pluginsInfo[i].Configuration.ExecutionDepth = parserInfo.ExecutionDepth
+	}
+	return
+}
+
+// validatePluginInputs checks the inputs of every step against the schema of its plugin, once the parameters are replaced.
+// The inputs referencing the output of other steps are only known when the step runs, and are validated then.
+func validatePluginInputs(pluginsInfo []contracts.PluginState) error {
+	for _, pluginInfo := range pluginsInfo {
+		properties := pluginInfo.Configuration.Properties
+		if stepoutput.ContainsStepOutputs(properties) || stepoutput.ContainsNamedOutputs(properties) {
+			continue
+		}
+		if err := runpluginutil.ValidatePluginInputs(pluginInfo.Name, properties); err != nil {
+			return fmt.Errorf("step %v: %v", pluginInfo.Id, err)
+		}
+	}
+	return nil
+}
+
+// validateSuccessCriteria checks that the success criteria of every step are valid regular expressions
+func validateSuccessCriteria(pluginsInfo []contracts.PluginState) error {
+	for _, pluginInfo := range pluginsInfo {
+		if err := runpluginutil.ValidateSuccessCriteria(pluginInfo.Configuration.SuccessCriteria); err != nil {
+			return fmt.Errorf("step %v: invalid successCriteria: %v", pluginInfo.Id, err)
+		}
+	}
+	return nil
+}
+
+// isShorthand returns true for the documents only made of a command, such as {"command": "uptime"}.
+// Documents declaring a schema version or steps are never treated as shorthand.
+func (docContent *DocContent) isShorthand() bool {
+	return strings.TrimSpace(docContent.Command) != "" &&
+		docContent.SchemaVersion == "" &&
+		len(docContent.MainSteps) == 0 &&
+		len(docContent.RuntimeConfig) == 0
+}
+
+// expandShorthand replaces the command of a shorthand document with the equivalent single aws:runShellScript step
+func (docContent *DocContent) expandShorthand() {
+	docContent.SchemaVersion = shorthandSchemaVersion
+	docContent.MainSteps = []*contracts.InstancePluginConfig{{
+		Action: appconfig.PluginNameAwsRunShellScript,
+		Name:   shorthandStepName,
+		Inputs: map[string]interface{}{
+			"runCommand": []interface{}{docContent.Command},
+		},
+	}}
+	docContent.Command = ""
 }
 
 // GetSchemaVersion is a method used to get document schema version
@@ -132,10 +305,10 @@ func (sessionDocContent *SessionDocContent) ParseDocument(context context.T,
 	log := context.Log()
 
 	if err = validateSessionDocumentSchema(sessionDocContent.SchemaVersion); err != nil {
-		return
+		return pluginsInfo, newParseError(SchemaError, err)
 	}
 	if err = validateAndReplaceSessionDocumentParameters(context, params, sessionDocContent); err != nil {
-		return
+		return pluginsInfo, newParseError(ParameterError, err)
 	}
 
 	resolvedDocContent, _ := jsonutil.MarshalIndent(*sessionDocContent)
@@ -151,11 +324,7 @@ func validateAndReplaceSessionDocumentParameters(context context.T, params map[s
 	validParameters := parameters.ValidParameters(log, params)
 
 	// add default values for missing parameters
-	for k, v := range docContent.Parameters {
-		if _, ok := validParameters[k]; !ok {
-			validParameters[k] = v.DefaultVal
-		}
-	}
+	addParameterDefaults(log, validParameters, docContent.Parameters)
 
 	log.Debug("Validating SSM parameters")
 	// Validates SSM parameters
@@ -236,6 +405,50 @@ func ParseParameters(log log.T, params map[string][]*string, paramsDef map[strin
 	return result
 }
 
+// getPlatformType returns the platform type the platform-specific parameter defaults are selected for
+var getPlatformType = platform.PlatformType
+
+// parameterDefault returns the default value of the parameter for the platform type of the instance,
+// or its generic default when the parameter has no default for the platform type.
+func parameterDefault(log log.T, definition *contracts.Parameter) interface{} {
+	if definition == nil {
+		return nil
+	}
+	if len(definition.PlatformDefaults) > 0 {
+		platformType, err := getPlatformType(log)
+		if err != nil {
+			log.Warnf("Failed to get the platform type, using the generic parameter default: %v", err)
+		} else if value, ok := definition.PlatformDefaults[platformType]; ok {
+			return value
+		}
+	}
+	return definition.DefaultVal
+}
+
+// addParameterDefaults adds the default value of each parameter missing from validParameters
+func addParameterDefaults(log log.T, validParameters map[string]interface{}, definitions map[string]*contracts.Parameter) {
+	for name, definition := range definitions {
+		if _, ok := validParameters[name]; !ok {
+			validParameters[name] = parameterDefault(log, definition)
+		}
+	}
+}
+
+// validateRequiredParameters checks that every parameter defined by the document was supplied or has a default value
+func validateRequiredParameters(validParameters map[string]interface{}, definitions map[string]*contracts.Parameter) error {
+	var missingParameters []string
+	for name := range definitions {
+		if validParameters[name] == nil {
+			missingParameters = append(missingParameters, name)
+		}
+	}
+	if len(missingParameters) == 0 {
+		return nil
+	}
+	sort.Strings(missingParameters)
+	return fmt.Errorf("missing required parameters: %v", strings.Join(missingParameters, ", "))
+}
+
 // parseDocumentContent parses an SSM Document and returns the plugin information
 func parseDocumentContent(docContent DocContent, parserInfo DocumentParserInfo, log log.T, params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
 
@@ -276,6 +489,7 @@ func parsePluginStateForV10Schema(
 			PluginName:              pluginName,
 			PluginID:                pluginName,
 			DefaultWorkingDirectory: defaultWorkingDir,
+			TimeoutSeconds:          pluginConfig.Timeout,
 		}
 		pluginConfigurations = append(pluginConfigurations, &config)
 	}
@@ -317,9 +531,20 @@ func parsePluginStateForV20Schema(
 			BookKeepingFileName:     documentID,
 			PluginName:              pluginName,
 			PluginID:                instancePluginConfig.Name,
-			Preconditions:           parsePluginParametersInPreconditions(&docContent, instancePluginConfig.Preconditions, params, log),
+			Preconditions:           parsePluginParametersInPreconditions(&docContent, instancePluginConfig.Preconditions.Operators, params, log),
+			PreconditionsAllOf:      parsePreconditionGroup(&docContent, instancePluginConfig.Preconditions.AllOf, params, log),
 			IsPreconditionEnabled:   isPreconditionEnabled,
 			DefaultWorkingDirectory: defaultWorkingDir,
+			TimeoutSeconds:          instancePluginConfig.Timeout,
+			ProcessPriority:         instancePluginConfig.Priority,
+			SuccessCriteria:         instancePluginConfig.SuccessCriteria,
+			StripAnsi:               instancePluginConfig.StripAnsi,
+			SensitiveOutput:         instancePluginConfig.SensitiveOutput,
+		}
+		// plugins uploading output themselves do not get the bucket of a sensitive step
+		if config.SensitiveOutput {
+			config.OutputS3BucketName = ""
+			config.OutputS3KeyPrefix = ""
 		}
 
 		var plugin contracts.PluginState
@@ -341,6 +566,24 @@ func parsePluginParametersInPreconditions(docContent *DocContent, precondition m
 	return parsedPreconditions
 }
 
+// parsePreconditionGroup lists the clauses of the preconditions of an allOf group, in order
+func parsePreconditionGroup(docContent *DocContent, group []map[string][]string, params map[string]interface{}, log log.T) (clauses []contracts.PreconditionClause) {
+	for _, precondition := range group {
+		operators := make([]string, 0, len(precondition))
+		for operator := range precondition {
+			operators = append(operators, operator)
+		}
+		sort.Strings(operators)
+		for _, operator := range operators {
+			clauses = append(clauses, contracts.PreconditionClause{
+				Operator:  operator,
+				Arguments: validateAndReplaceParametersInPreconditionArguments(docContent, precondition[operator], params, log),
+			})
+		}
+	}
+	return clauses
+}
+
 // validateAndReplaceParametersInPreconditionArguments validates document parameters and modifies plugin preconditions
 // by replacing document parameters with their values
 // TODO: return a list of invalid parameters and expose it to the user in the document execution output
@@ -352,11 +595,7 @@ func validateAndReplaceParametersInPreconditionArguments(docContent *DocContent,
 	validParameters := parameters.ValidParameters(log, params)
 
 	// add default values for missing parameters
-	for k, v := range docContent.Parameters {
-		if _, ok := validParameters[k]; !ok {
-			validParameters[k] = v.DefaultVal
-		}
-	}
+	addParameterDefaults(log, validParameters, docContent.Parameters)
 
 	// replace document parameters in each of the arguments
 	parsedArguments := make([]contracts.PreconditionArgument, len(args))
@@ -416,6 +655,7 @@ func (sessionDocContent *SessionDocContent) parsePluginStateForStartSession(
 		RunAsEnabled:                runAsEnabled,
 		RunAsUser:                   runAsUser,
 		ShellProfile:                sessionDocContent.Inputs.ShellProfile,
+		InitialCommands:             sessionDocContent.Inputs.InitialCommands,
 		SessionOwner:                docInfo.SessionOwner,
 	}
 
@@ -440,6 +680,39 @@ func validateSessionDocumentSchema(documentSchemaVersion string) error {
 	return nil
 }
 
+// validateStepCount rejects documents that define more steps than the agent is configured to run
+func validateStepCount(docContent *DocContent, maxStepCount int) error {
+	if maxStepCount <= 0 {
+		maxStepCount = appconfig.DefaultDocumentMaxStepCount
+	}
+	stepCount := len(docContent.MainSteps)
+	if docContent.SchemaVersion == "1.0" || docContent.SchemaVersion == "1.2" {
+		stepCount = len(docContent.RuntimeConfig)
+	}
+	if stepCount > maxStepCount {
+		return fmt.Errorf("document has %v steps, which exceeds the maximum of %v steps set by DocumentMaxStepCount", stepCount, maxStepCount)
+	}
+	return nil
+}
+
+// validateOnFailure checks the document level onFailure value, abort being the only supported one
+func validateOnFailure(onFailure string) error {
+	if onFailure != "" && onFailure != contracts.ModifierValueAbort {
+		return fmt.Errorf("document onFailure value %v is not supported, the supported value is %v", onFailure, contracts.ModifierValueAbort)
+	}
+	return nil
+}
+
+// validateOnStepHookFailure checks the document onStepHookFailure value
+func validateOnStepHookFailure(onStepHookFailure string) error {
+	switch onStepHookFailure {
+	case "", contracts.StepHookFailureIgnore, contracts.StepHookFailureFail:
+		return nil
+	}
+	return fmt.Errorf("document onStepHookFailure value %v is not supported, the supported values are %v and %v",
+		onStepHookFailure, contracts.StepHookFailureIgnore, contracts.StepHookFailureFail)
+}
+
 // validateSchema checks if the document schema version is supported by this agent version
 func validateSchema(documentSchemaVersion string) error {
 	// Check if the document version is supported by this agent version
@@ -460,10 +733,9 @@ func getValidatedParameters(context context.T, params map[string]interface{}, do
 	validParameters := parameters.ValidParameters(log, params)
 
 	// add default values for missing parameters
-	for k, v := range docContent.Parameters {
-		if _, ok := validParameters[k]; !ok {
-			validParameters[k] = v.DefaultVal
-		}
+	addParameterDefaults(log, validParameters, docContent.Parameters)
+	if err := validateRequiredParameters(validParameters, docContent.Parameters); err != nil {
+		return err
 	}
 
 	log.Debug("Validating SSM parameters")
@@ -472,8 +744,7 @@ func getValidatedParameters(context context.T, params map[string]interface{}, do
 		return err
 	}
 
-	err := replaceValidatedPluginParameters(context, docContent, validParameters)
-	return err
+	return replaceValidatedPluginParameters(context, docContent, validParameters)
 }
 
 // replaceValidatedPluginParameters replaces parameters with their values, within the plugin Properties.
@@ -481,7 +752,6 @@ func replaceValidatedPluginParameters(
 	context context.T,
 	docContent *DocContent,
 	params map[string]interface{}) error {
-	logger := context.Log()
 	var err error
 
 	//TODO: Refactor this to not not reparse the docContent
@@ -491,17 +761,10 @@ func replaceValidatedPluginParameters(
 		updatedRuntimeConfig := make(map[string]*contracts.PluginConfig)
 		for pluginName, pluginConfig := range runtimeConfig {
 			updatedRuntimeConfig[pluginName] = pluginConfig
-			updatedRuntimeConfig[pluginName].Settings = parameters.ReplaceParameters(pluginConfig.Settings, params, logger)
-			updatedRuntimeConfig[pluginName].Properties = parameters.ReplaceParameters(pluginConfig.Properties, params, logger)
-
-			logger.Debug("Resolving SSM parameters")
-			// Resolves SSM parameters
-			if updatedRuntimeConfig[pluginName].Settings, err = parameterstore.Resolve(context, updatedRuntimeConfig[pluginName].Settings); err != nil {
+			if updatedRuntimeConfig[pluginName].Settings, err = replaceStepParameters(context, pluginConfig.Settings, params); err != nil {
 				return err
 			}
-
-			// Resolves SSM parameters
-			if updatedRuntimeConfig[pluginName].Properties, err = parameterstore.Resolve(context, updatedRuntimeConfig[pluginName].Properties); err != nil {
+			if updatedRuntimeConfig[pluginName].Properties, err = replaceStepParameters(context, pluginConfig.Properties, params); err != nil {
 				return err
 			}
 		}
@@ -514,17 +777,10 @@ func replaceValidatedPluginParameters(
 		updatedMainSteps := make([]*contracts.InstancePluginConfig, len(mainSteps))
 		for index, instancePluginConfig := range mainSteps {
 			updatedMainSteps[index] = instancePluginConfig
-			updatedMainSteps[index].Settings = parameters.ReplaceParameters(instancePluginConfig.Settings, params, logger)
-			updatedMainSteps[index].Inputs = parameters.ReplaceParameters(instancePluginConfig.Inputs, params, logger)
-
-			logger.Debug("Resolving SSM parameters")
-			// Resolves SSM parameters
-			if updatedMainSteps[index].Settings, err = parameterstore.Resolve(context, updatedMainSteps[index].Settings); err != nil {
+			if updatedMainSteps[index].Settings, err = replaceStepParameters(context, instancePluginConfig.Settings, params); err != nil {
 				return err
 			}
-
-			// Resolves SSM parameters
-			if updatedMainSteps[index].Inputs, err = parameterstore.Resolve(context, updatedMainSteps[index].Inputs); err != nil {
+			if updatedMainSteps[index].Inputs, err = replaceStepParameters(context, instancePluginConfig.Inputs, params); err != nil {
 				return err
 			}
 		}
@@ -534,6 +790,22 @@ func replaceValidatedPluginParameters(
 	return nil
 }
 
+// replaceStepParameters replaces the parameter references of a step input, then resolves its SSM parameters.
+// The parameter-resolution functions such as {{ base64decode:paramName }} are bound to the parameter value last,
+// so that no decoded value is substituted again, and are only decoded right before the step runs.
+func replaceStepParameters(context context.T, input interface{}, params map[string]interface{}) (interface{}, error) {
+	logger := context.Log()
+	input = parameters.ReplaceParameters(input, params, logger)
+
+	logger.Debug("Resolving SSM parameters")
+	// Resolves SSM parameters
+	input, err := parameterstore.Resolve(context, input)
+	if err != nil {
+		return input, err
+	}
+	return parameters.BindDecodedParameters(input, params)
+}
+
 // isPreConditionEnabled checks if precondition support is enabled by checking document schema version
 func isPreconditionEnabled(schemaVersion string) (response bool) {
 	response = false
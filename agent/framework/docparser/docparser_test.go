@@ -16,6 +16,7 @@ package docparser
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"path"
@@ -26,6 +27,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/stretchr/testify/assert"
 )
@@ -132,6 +134,379 @@ func TestParseDocument_ValidMainSteps(t *testing.T) {
 	assert.Equal(t, testWorkingDir, pluginInfoTest.Configuration.DefaultWorkingDirectory)
 }
 
+func TestParseDocument_ShorthandCommand(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir:  testOrchDir,
+		S3Bucket:          testS3Bucket,
+		S3Prefix:          testS3Prefix,
+		MessageId:         testMessageID,
+		DocumentId:        testDocumentID,
+		DefaultWorkingDir: testWorkingDir,
+	}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{"command": "uptime"}`), &testDocContent))
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2.2", docState.SchemaVersion)
+	if assert.Len(t, docState.InstancePluginsInformation, 1) {
+		pluginInfo := docState.InstancePluginsInformation[0]
+		assert.Equal(t, appconfig.PluginNameAwsRunShellScript, pluginInfo.Name)
+		assert.Equal(t, shorthandStepName, pluginInfo.Id)
+		assert.Equal(t, filepath.Join(testOrchDir, shorthandStepName), pluginInfo.Configuration.OrchestrationDirectory)
+		assert.Equal(t, map[string]interface{}{"runCommand": []interface{}{"uptime"}}, pluginInfo.Configuration.Properties)
+	}
+}
+
+func TestParseDocument_SensitiveOutputStepHasNoS3Bucket(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		S3Bucket:         testS3Bucket,
+		S3Prefix:         testS3Prefix,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"mainSteps": [
+			{"action": "aws:runShellScript", "name": "secret", "sensitiveOutput": true, "inputs": {"runCommand": ["cat secret"]}},
+			{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}
+		]
+	}`), &testDocContent))
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	if assert.Len(t, docState.InstancePluginsInformation, 2) {
+		sensitiveStep := docState.InstancePluginsInformation[0].Configuration
+		assert.True(t, sensitiveStep.SensitiveOutput)
+		assert.Empty(t, sensitiveStep.OutputS3BucketName)
+		assert.Empty(t, sensitiveStep.OutputS3KeyPrefix)
+		step := docState.InstancePluginsInformation[1].Configuration
+		assert.False(t, step.SensitiveOutput)
+		assert.Equal(t, testS3Bucket, step.OutputS3BucketName)
+	}
+}
+
+func TestParseDocument_CommandIgnoredInFullDocument(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	validdocumentmainsteps := loadFile(t, filepath.Join("..", "..", "runcommand", "mds", "testdata", "validcommand20.json"))
+	assert.NoError(t, json.Unmarshal(validdocumentmainsteps, &testDocContent))
+	expectedDocContent := testDocContent
+	testDocContent.Command = "uptime"
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedDocContent.SchemaVersion, testDocContent.SchemaVersion)
+	assert.Equal(t, expectedDocContent.MainSteps, testDocContent.MainSteps)
+	assert.Equal(t, "uptime", testDocContent.Command)
+	if assert.Len(t, pluginsInfo, 1) {
+		assert.Equal(t, "test", pluginsInfo[0].Id)
+	}
+}
+
+func TestInitializeDocState_OnFailureAbort(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"onFailure": "abort",
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, contracts.ModifierValueAbort, docState.IOConfig.OnFailure)
+}
+
+func TestParseDocument_UnsupportedOnFailure(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"onFailure": "exit",
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+}
+
+func TestInitializeDocState_StepHooks(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"preStep": "echo before",
+		"postStep": "echo after",
+		"onStepHookFailure": "fail",
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &contracts.StepHooks{PreStep: "echo before", PostStep: "echo after", OnFailure: contracts.StepHookFailureFail}, docState.IOConfig.StepHooks)
+}
+
+func TestInitializeDocState_CommandTimeout(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"commandTimeoutSeconds": 1800,
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1800, docState.IOConfig.CommandTimeoutSeconds)
+}
+
+func TestParseDocument_NegativeCommandTimeout(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"commandTimeoutSeconds": -1,
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+}
+
+func TestInitializeDocState_IncrementalUploadInterval(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`
+schemaVersion: "2.2"
+incrementalUploadIntervalSeconds: 60
+mainSteps:
+- action: aws:runShellScript
+  name: build
+  inputs:
+    runCommand:
+    - make
+`), &testDocContent))
+
+	docState, err := InitializeDocState(context, contracts.SendCommand, &testDocContent, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 60, docState.IOConfig.IncrementalUploadIntervalSeconds)
+}
+
+func TestParseDocument_NegativeIncrementalUploadInterval(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"incrementalUploadIntervalSeconds": -5,
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+}
+
+func TestParseDocument_UnknownPlugin(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"mainSteps": [{"action": "aws:unknownPlugin", "name": "unknown", "inputs": {}}]
+	}`), &testDocContent))
+
+	// unknown plugins are reported per step when the document runs, as the step may be skipped by its preconditions
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, pluginsInfo, 1)
+	assert.Equal(t, "aws:unknownPlugin", pluginsInfo[0].Name)
+}
+
+func TestParseDocument_MissingRequiredParameters(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"parameters": {
+			"message": {"type": "String"},
+			"target": {"type": "String"},
+			"greeting": {"type": "String", "default": "hello"}
+		},
+		"mainSteps": [{"action": "aws:runShellScript", "name": "echo", "inputs": {"runCommand": ["echo {{ greeting }} {{ message }} {{ target }}"]}}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, map[string]interface{}{"target": "world"})
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, ParameterError, parseError.Kind)
+	assert.EqualError(t, err, "missing required parameters: message")
+}
+
+func TestParseDocument_UnsupportedOnStepHookFailure(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"preStep": "echo before",
+		"onStepHookFailure": "retry",
+		"mainSteps": [{"action": "aws:runShellScript", "name": "uptime", "inputs": {"runCommand": ["uptime"]}}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+}
+
+func TestParseDocument_InvalidSuccessCriteria(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"mainSteps": [{
+			"action": "aws:runShellScript", "name": "install", "inputs": {"runCommand": ["install.sh"]},
+			"successCriteria": {"failurePattern": "ERROR", "successPattern": "Installed ("}
+		}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+	assert.Contains(t, err.Error(), `step install: invalid successCriteria: successPattern "Installed ("`)
+}
+
+func TestParseDocument_BindsDecodedParameters(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"parameters": {"password": {"type": "String"}, "token": {"type": "String", "default": "733363723374"}},
+		"mainSteps": [{
+			"action": "aws:runShellScript",
+			"name": "login",
+			"inputs": {"runCommand": ["login {{ base64decode:password }} {{ hexdecode:token }}"]}
+		}]
+	}`), &testDocContent))
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, map[string]interface{}{"password": "cGFzc3dvcmQ="})
+
+	assert.NoError(t, err)
+	if assert.Len(t, pluginsInfo, 1) {
+		// the values are only decoded right before the step runs
+		assert.Equal(t, map[string]interface{}{"runCommand": []interface{}{"login {{ base64decode=cGFzc3dvcmQ= }} {{ hexdecode=733363723374 }}"}}, pluginsInfo[0].Configuration.Properties)
+		assert.Empty(t, pluginsInfo[0].Configuration.RedactedValues)
+	}
+}
+
+func TestParseDocument_DoesNotResolveReferencesInDecodedParameters(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"parameters": {"script": {"type": "String"}, "user": {"type": "String", "default": "admin"}},
+		"mainSteps": [{"action": "aws:runShellScript", "name": "run", "inputs": {"runCommand": ["{{ base64decode:script }}"]}}]
+	}`), &testDocContent))
+	// the script decodes to "echo {{ user }} {{ssm:/secret}}"
+	params := map[string]interface{}{"script": "ZWNobyB7eyB1c2VyIH19IHt7c3NtOi9zZWNyZXR9fQ=="}
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, params)
+
+	assert.NoError(t, err)
+	if assert.Len(t, pluginsInfo, 1) {
+		assert.Equal(t, map[string]interface{}{"runCommand": []interface{}{"{{ base64decode=ZWNobyB7eyB1c2VyIH19IHt7c3NtOi9zZWNyZXR9fQ== }}"}}, pluginsInfo[0].Configuration.Properties)
+	}
+}
+
+func TestParseDocument_PlatformParameterDefaults(t *testing.T) {
+	getPlatformTypeOrig := getPlatformType
+	defer func() { getPlatformType = getPlatformTypeOrig }()
+
+	testCases := []struct {
+		name            string
+		platformType    string
+		expectedCommand string
+	}{
+		{"PlatformDefault", "linux", "cd /tmp"},
+		{"GenericDefault", "macos", "cd /var/tmp"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			getPlatformType = func(log log.T) (string, error) { return testCase.platformType, nil }
+			context := context.NewMockDefault()
+			testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+			var testDocContent DocContent
+			assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+				"schemaVersion": "2.2",
+				"parameters": {"tempDir": {"type": "String", "default": "/var/tmp", "platformDefaults": {"linux": "/tmp", "windows": "C:\\Temp"}}},
+				"mainSteps": [{"action": "aws:runShellScript", "name": "cd", "inputs": {"runCommand": ["cd {{ tempDir }}"]}}]
+			}`), &testDocContent))
+
+			pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+			assert.NoError(t, err)
+			if assert.Len(t, pluginsInfo, 1) {
+				assert.Equal(t, map[string]interface{}{"runCommand": []interface{}{testCase.expectedCommand}}, pluginsInfo[0].Configuration.Properties)
+			}
+		})
+	}
+}
+
+func TestParseDocument_MalformedEncodedParameter(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{OrchestrationDir: testOrchDir, MessageId: testMessageID, DocumentId: testDocumentID}
+	var testDocContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent([]byte(`{
+		"schemaVersion": "2.2",
+		"parameters": {"password": {"type": "String"}},
+		"mainSteps": [{"action": "aws:runShellScript", "name": "login", "inputs": {"runCommand": ["login {{ base64decode:password }}"]}}]
+	}`), &testDocContent))
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, map[string]interface{}{"password": "not base64!"})
+
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, ParameterError, parseError.Kind)
+	assert.NotContains(t, err.Error(), "not base64!")
+}
+
 func TestInitializeDocState_Valid(t *testing.T) {
 	context := context.NewMockDefault()
 
@@ -195,6 +570,9 @@ func TestInitializeDocStateForStartSessionDocument_Valid(t *testing.T) {
 		KmsKeyId:               testKmsKeyId,
 		CloudWatchLogGroupName: testLogGroupName,
 		ShellProfile:           shellProfile,
+		InitialCommands: contracts.ShellProfileConfig{
+			Linux: "cd /tmp\nls",
+		},
 	}
 
 	sessionDocContent := &SessionDocContent{
@@ -231,6 +609,7 @@ func TestInitializeDocStateForStartSessionDocument_Valid(t *testing.T) {
 	assert.Equal(t, testKmsKeyId, pluginInfo[0].Configuration.KmsKeyId)
 	assert.Equal(t, testWindowsCmd, pluginInfo[0].Configuration.ShellProfile.Windows)
 	assert.Equal(t, testLinuxCmd, pluginInfo[0].Configuration.ShellProfile.Linux)
+	assert.Equal(t, "cd /tmp\nls", pluginInfo[0].Configuration.InitialCommands.Linux)
 }
 
 func TestInitializeDocStateForStartSessionDocumentWithoutSessionCommands_Valid(t *testing.T) {
@@ -431,6 +810,86 @@ func TestParseDocument_EmptyDocContent(t *testing.T) {
 	assert.Contains(t, err.Error(), "Unsupported schema format")
 }
 
+// documentWithSteps builds a schema 2.2 document with the given number of shell script steps
+func documentWithSteps(count int) DocContent {
+	docContent := DocContent{SchemaVersion: "2.2"}
+	for i := 0; i < count; i++ {
+		docContent.MainSteps = append(docContent.MainSteps, &contracts.InstancePluginConfig{
+			Action: appconfig.PluginNameAwsRunShellScript,
+			Name:   fmt.Sprintf("step%v", i),
+			Inputs: map[string]interface{}{"runCommand": []interface{}{"echo hello"}},
+		})
+	}
+	return docContent
+}
+
+func TestParseDocument_StepCountAtLimit(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.DocumentMaxStepCount = 3
+	context := context.NewMockDefaultWithConfig(config)
+	testDocContent := documentWithSteps(3)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, DocumentParserInfo{OrchestrationDir: testOrchDir}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(pluginsInfo))
+}
+
+func TestParseDocument_SetsExecutionDepthOnEveryStep(t *testing.T) {
+	context := context.NewMockDefault()
+	testDocContent := documentWithSteps(2)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, DocumentParserInfo{OrchestrationDir: testOrchDir, ExecutionDepth: 2}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pluginsInfo))
+	for _, pluginState := range pluginsInfo {
+		assert.Equal(t, 2, pluginState.Configuration.ExecutionDepth)
+	}
+}
+
+func TestParseDocument_StepCountExceedsLimit(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.DocumentMaxStepCount = 3
+	context := context.NewMockDefaultWithConfig(config)
+	testDocContent := documentWithSteps(4)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, DocumentParserInfo{OrchestrationDir: testOrchDir}, nil)
+
+	assert.Error(t, err)
+	assert.Equal(t, "document has 4 steps, which exceeds the maximum of 3 steps set by DocumentMaxStepCount", err.Error())
+	assert.Empty(t, pluginsInfo)
+}
+
+func TestParseDocument_StepCountExceedsLimitForRuntimeConfig(t *testing.T) {
+	config := appconfig.DefaultConfig()
+	config.Ssm.DocumentMaxStepCount = 1
+	context := context.NewMockDefaultWithConfig(config)
+	testDocContent := DocContent{
+		SchemaVersion: "1.2",
+		RuntimeConfig: map[string]*contracts.PluginConfig{
+			appconfig.PluginNameAwsRunShellScript:      {},
+			appconfig.PluginNameAwsRunPowerShellScript: {},
+		},
+	}
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, DocumentParserInfo{OrchestrationDir: testOrchDir}, nil)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum of 1 steps")
+}
+
+func TestParseDocument_RuntimeConfigTimeout(t *testing.T) {
+	var testDocContent DocContent
+	err := json.Unmarshal([]byte(`{"schemaVersion":"1.2","runtimeConfig":{"aws:runShellScript":{"timeoutSeconds":30,"properties":[{"id":"0.aws:runShellScript","runCommand":["date"]}]}}}`), &testDocContent)
+	assert.NoError(t, err)
+
+	pluginsInfo, err := testDocContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{OrchestrationDir: testOrchDir}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 30, pluginsInfo[0].Configuration.TimeoutSeconds)
+}
+
 func TestParseDocument_Invalid(t *testing.T) {
 	context := context.NewMockDefault()
 	testParserInfo := DocumentParserInfo{
@@ -474,6 +933,72 @@ func TestParseDocument_InvalidSchema(t *testing.T) {
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Document with schema version 9999.0 is not supported by this version of ssm agent")
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+}
+
+func TestParseDocument_InvalidPluginInputs(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+	testDocContent := DocContent{
+		SchemaVersion: "2.2",
+		Parameters: map[string]*contracts.Parameter{
+			"timeout": {ParamType: "String", DefaultVal: "later"},
+		},
+		MainSteps: []*contracts.InstancePluginConfig{{
+			Action: appconfig.PluginNameAwsRunShellScript,
+			Name:   "runScript",
+			Inputs: map[string]interface{}{
+				"runCommand":     []interface{}{"uptime"},
+				"timeoutSeconds": "{{ timeout }}",
+			},
+		}},
+	}
+
+	_, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.EqualError(t, err, `step runScript: invalid inputs for plugin aws:runShellScript: input "timeoutSeconds" must be Integer, got string`)
+	var parseError *ParseError
+	assert.True(t, errors.As(err, &parseError))
+	assert.Equal(t, SchemaError, parseError.Kind)
+}
+
+func TestParseDocument_PluginInputsReferencingStepOutputs(t *testing.T) {
+	context := context.NewMockDefault()
+	testParserInfo := DocumentParserInfo{
+		OrchestrationDir: testOrchDir,
+		MessageId:        testMessageID,
+		DocumentId:       testDocumentID,
+	}
+	testDocContent := DocContent{
+		SchemaVersion: "2.2",
+		MainSteps: []*contracts.InstancePluginConfig{
+			{
+				Action: appconfig.PluginNameAwsRunShellScript,
+				Name:   "getTimeout",
+				Inputs: map[string]interface{}{"runCommand": []interface{}{"echo 60"}},
+			},
+			{
+				Action: appconfig.PluginNameAwsRunShellScript,
+				Name:   "runScript",
+				Inputs: map[string]interface{}{
+					"runCommand":     []interface{}{"uptime"},
+					"timeoutSeconds": "{{ getTimeout.output }} seconds",
+				},
+			},
+		},
+	}
+
+	// the reference is resolved, and the input validated, when the step runs
+	pluginsInfo, err := testDocContent.ParseDocument(context, contracts.DocumentInfo{}, testParserInfo, nil)
+
+	assert.NoError(t, err)
+	assert.Len(t, pluginsInfo, 2)
 }
 
 func TestParseDocument_ValidParameters(t *testing.T) {
@@ -644,6 +1169,64 @@ func TestParsingDocNameForVersion_InvalidVersion(t *testing.T) {
 	assert.Equal(t, docName, "AWS-RunShellScript")
 }
 
+func TestParseDocument_PreconditionGroup(t *testing.T) {
+	document := []byte(`
+schemaVersion: "2.2"
+parameters:
+  environment:
+    type: String
+    default: prod
+mainSteps:
+  - action: aws:runShellScript
+    name: linuxProduction
+    precondition:
+      StringNotEquals: ["{{ environment }}", "test"]
+      allOf:
+        - StringEquals: [platformType, Linux]
+        - StringEquals: ["{{ environment }}", prod]
+    inputs:
+      runCommand: [uptime]
+`)
+	var docContent DocContent
+	assert.NoError(t, UnmarshalDocumentContent(document, &docContent))
+	assert.Equal(t, contracts.StepPrecondition{
+		Operators: map[string][]string{"StringNotEquals": {"{{ environment }}", "test"}},
+		AllOf: []map[string][]string{
+			{"StringEquals": {"platformType", "Linux"}},
+			{"StringEquals": {"{{ environment }}", "prod"}},
+		},
+	}, docContent.MainSteps[0].Preconditions)
+
+	pluginsInfo, err := docContent.ParseDocument(context.NewMockDefault(), contracts.DocumentInfo{}, DocumentParserInfo{OrchestrationDir: testOrchDir}, nil)
+
+	assert.NoError(t, err)
+	configuration := pluginsInfo[0].Configuration
+	assert.Equal(t, map[string][]contracts.PreconditionArgument{
+		"StringNotEquals": preconditionArguments("{{ environment }}", "prod", "test", "test"),
+	}, configuration.Preconditions)
+	assert.Equal(t, []contracts.PreconditionClause{
+		{Operator: "StringEquals", Arguments: preconditionArguments("platformType", "platformType", "Linux", "Linux")},
+		{Operator: "StringEquals", Arguments: preconditionArguments("{{ environment }}", "prod", "prod", "prod")},
+	}, configuration.PreconditionsAllOf)
+}
+
+// preconditionArguments returns the precondition arguments given as pairs of initial and resolved values
+func preconditionArguments(values ...string) (arguments []contracts.PreconditionArgument) {
+	for i := 0; i+1 < len(values); i += 2 {
+		arguments = append(arguments, contracts.PreconditionArgument{InitialArgumentValue: values[i], ResolvedArgumentValue: values[i+1]})
+	}
+	return arguments
+}
+
+func TestUnmarshalDocumentContent_InvalidPreconditionGroup(t *testing.T) {
+	var docContent DocContent
+	err := UnmarshalDocumentContent([]byte(`{"schemaVersion": "2.2", "mainSteps": [{"action": "aws:runShellScript", "name": "step",
+		"precondition": {"allOf": {"StringEquals": ["platformType", "Linux"]}}}]}`), &docContent)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid allOf precondition")
+}
+
 func TestParseMessageWithPreconditions(t *testing.T) {
 	type testCase struct {
 		Input                 string
@@ -723,3 +1306,111 @@ func loadPreconditionsFromFile(t *testing.T) (preconditions map[string]map[strin
 	}
 	return preconditions
 }
+
+func TestUnmarshalDocumentContent_YAMLAnchorsAndMergeKeys(t *testing.T) {
+	yamlDocument := `
+schemaVersion: '2.2'
+mainSteps:
+- action: aws:runShellScript
+  name: first
+  inputs: &shared
+    timeoutSeconds: 60
+    runCommand: [date]
+- action: aws:runShellScript
+  name: second
+  inputs:
+    <<: *shared
+    workingDirectory: /tmp
+`
+	var docContent DocContent
+	err := UnmarshalDocumentContent([]byte(yamlDocument), &docContent)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(docContent.MainSteps))
+	assert.Equal(t, map[string]interface{}{
+		"timeoutSeconds": float64(60),
+		"runCommand":     []interface{}{"date"},
+	}, docContent.MainSteps[0].Inputs)
+	assert.Equal(t, map[string]interface{}{
+		"timeoutSeconds":   float64(60),
+		"runCommand":       []interface{}{"date"},
+		"workingDirectory": "/tmp",
+	}, docContent.MainSteps[1].Inputs)
+}
+
+func TestUnmarshalDocumentContent_JSON(t *testing.T) {
+	var docContent DocContent
+	err := UnmarshalDocumentContent([]byte(parameterdocument), &docContent)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2", docContent.SchemaVersion)
+	assert.Equal(t, 1, len(docContent.RuntimeConfig))
+}
+
+func TestUnmarshalDocumentContent_ByteOrderMark(t *testing.T) {
+	yamlDocument := "schemaVersion: '2.2'\r\nmainSteps:\r\n- action: aws:runShellScript\r\n  name: run\r\n  inputs:\r\n    runCommand: [date]\r\n"
+	for _, document := range []string{parameterdocument, yamlDocument} {
+		var docContent DocContent
+		err := UnmarshalDocumentContent([]byte("\xEF\xBB\xBF"+document+"  \t\r\n"), &docContent)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, docContent.SchemaVersion)
+	}
+}
+
+func TestNormalizeDocumentRaw(t *testing.T) {
+	normalized, changed := NormalizeDocumentRaw([]byte("\xEF\xBB\xBF{}"))
+	assert.Equal(t, "{}", string(normalized))
+	assert.True(t, changed)
+
+	normalized, changed = NormalizeDocumentRaw([]byte("{} \t\n\n"))
+	assert.Equal(t, "{}", string(normalized))
+	assert.True(t, changed)
+
+	// a single final line ending is expected and not reported
+	normalized, changed = NormalizeDocumentRaw([]byte("{}\r\n"))
+	assert.Equal(t, "{}", string(normalized))
+	assert.False(t, changed)
+}
+
+func TestUnmarshalDocumentContent_InvalidDocument(t *testing.T) {
+	var docContent DocContent
+	err := UnmarshalDocumentContent([]byte("schemaVersion: [2.2"), &docContent)
+
+	assert.Error(t, err)
+}
+
+func TestUnmarshalDocumentContent_YAMLErrorLine(t *testing.T) {
+	yamlDocument := "schemaVersion: '2.2'\n" +
+		"mainSteps:\n" +
+		"- action: aws:runShellScript\n" +
+		"  name: run\n" +
+		"   inputs:\n" +
+		"    runCommand: [date]\n"
+	var docContent DocContent
+	err := UnmarshalDocumentContent([]byte(yamlDocument), &docContent)
+
+	var parseError *ParseError
+	if assert.True(t, errors.As(err, &parseError)) {
+		assert.Equal(t, SyntaxError, parseError.Kind)
+		assert.Equal(t, 5, parseError.Line)
+		assert.Contains(t, err.Error(), "line 5")
+	}
+}
+
+func TestUnmarshalDocumentContent_JSONErrorLineAndColumn(t *testing.T) {
+	jsonDocument := "{\n" +
+		"  \"schemaVersion\": \"2.2\",\n" +
+		"  \"mainSteps\": [}\n" +
+		"}"
+	var docContent DocContent
+	err := UnmarshalDocumentContent([]byte(jsonDocument), &docContent)
+
+	var parseError *ParseError
+	if assert.True(t, errors.As(err, &parseError)) {
+		assert.Equal(t, SyntaxError, parseError.Kind)
+		assert.Equal(t, 3, parseError.Line)
+		assert.Equal(t, 17, parseError.Column)
+		assert.Contains(t, err.Error(), "line 3, column 17")
+	}
+}
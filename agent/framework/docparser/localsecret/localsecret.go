@@ -0,0 +1,152 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package localsecret resolves {{ localsecret:name }} references in documents from a
+// permission-restricted directory on the instance, for hosts that cannot reach Parameter Store.
+package localsecret
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const (
+	// redactedValue replaces resolved secret values in step output
+	redactedValue = "****"
+)
+
+// localSecretReferencePattern matches {{ localsecret:name }}. The name charset excludes path separators and
+// the name starts with a word character, so that a reference can never point to the secrets directory itself
+// (".") or outside of it ("..").
+var localSecretReferencePattern = regexp.MustCompile("{{\\s*localsecret:(\\w[\\w.-]*)\\s*}}")
+
+var readFile = os.ReadFile
+
+// ContainsLocalSecrets returns true if the input contains at least one local secret reference
+func ContainsLocalSecrets(input interface{}) bool {
+	found := false
+	walk(input, func(text string) string {
+		if localSecretReferencePattern.MatchString(text) {
+			found = true
+		}
+		return text
+	})
+	return found
+}
+
+// Resolve replaces all local secret references found in input with the content of the matching file
+// in secretsDir. It returns the resolved input along with the secret values so that callers can redact them.
+func Resolve(log log.T, secretsDir string, input interface{}) (interface{}, []string, error) {
+	if !ContainsLocalSecrets(input) {
+		return input, nil, nil
+	}
+
+	if err := checkPermissions(secretsDir); err != nil {
+		return input, nil, err
+	}
+
+	secrets := make(map[string]string)
+	var resolveErr error
+	resolved := walk(input, func(text string) string {
+		return localSecretReferencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+			name := localSecretReferencePattern.FindStringSubmatch(reference)[1]
+			if value, ok := secrets[name]; ok {
+				return value
+			}
+			value, err := readSecret(secretsDir, name)
+			if err != nil {
+				if resolveErr == nil {
+					resolveErr = err
+				}
+				return reference
+			}
+			secrets[name] = value
+			return value
+		})
+	})
+	if resolveErr != nil {
+		return input, nil, resolveErr
+	}
+
+	// NOTE: Do not log the secret values
+	log.Debugf("Resolved %v local secret(s) from %v", len(secrets), secretsDir)
+	values := make([]string, 0, len(secrets))
+	for _, value := range secrets {
+		values = append(values, value)
+	}
+	return resolved, values, nil
+}
+
+// Redact masks every occurrence of the given secret values in text
+func Redact(text string, secretValues []string) string {
+	return RedactWith(text, secretValues, redactedValue)
+}
+
+// RedactWith replaces every occurrence of the given secret values in text with mask
+func RedactWith(text string, secretValues []string, mask string) string {
+	for _, value := range secretValues {
+		if value != "" {
+			text = strings.Replace(text, value, mask, -1)
+		}
+	}
+	return text
+}
+
+// readSecret reads a single secret file, rejecting files readable by other users
+func readSecret(secretsDir string, name string) (string, error) {
+	path := filepath.Join(secretsDir, name)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", fmt.Errorf("local secret %v not found in %v", name, secretsDir)
+	}
+	if err := checkPermissions(path); err != nil {
+		return "", err
+	}
+	content, err := readFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read local secret %v: %v", name, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// walk applies replace to every string found in input, descending into lists and maps
+func walk(input interface{}, replace func(string) string) interface{} {
+	switch value := input.(type) {
+	case string:
+		return replace(value)
+	case []string:
+		out := make([]string, len(value))
+		for i, item := range value {
+			out[i] = replace(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = walk(item, replace)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			out[key] = walk(item, replace)
+		}
+		return out
+	default:
+		return input
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package localsecret
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSecretsDir(t *testing.T, secrets map[string]string) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.Chmod(dir, 0700))
+	for name, value := range secrets {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(value), 0600))
+	}
+	return dir
+}
+
+func TestResolve_PresentSecrets(t *testing.T) {
+	dir := newSecretsDir(t, map[string]string{
+		"db-password": "s3cr3t\n",
+		"api.token":   "tok-123",
+	})
+	input := map[string]interface{}{
+		"runCommand": []interface{}{
+			"connect --password {{ localsecret:db-password }}",
+			"curl -H 'Authorization: {{localsecret:api.token}}'",
+		},
+		"timeoutSeconds": 60,
+	}
+
+	resolved, secretValues, err := Resolve(log.NewMockLog(), dir, input)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"s3cr3t", "tok-123"}, secretValues)
+	assert.Equal(t, map[string]interface{}{
+		"runCommand": []interface{}{
+			"connect --password s3cr3t",
+			"curl -H 'Authorization: tok-123'",
+		},
+		"timeoutSeconds": 60,
+	}, resolved)
+	// the original input keeps the references
+	assert.Equal(t, "connect --password {{ localsecret:db-password }}", input["runCommand"].([]interface{})[0])
+	assert.Equal(t, "password=**** token=****", Redact("password=s3cr3t token=tok-123", secretValues))
+}
+
+func TestResolve_MissingSecret(t *testing.T) {
+	dir := newSecretsDir(t, map[string]string{"present": "value"})
+
+	input := "{{ localsecret:present }} {{ localsecret:absent }}"
+	resolved, secretValues, err := Resolve(log.NewMockLog(), dir, input)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "local secret absent not found")
+	assert.Nil(t, secretValues)
+	assert.Equal(t, input, resolved)
+}
+
+func TestResolve_NoReferences(t *testing.T) {
+	input := map[string]interface{}{"commands": "echo {{ ssm:param }}"}
+
+	resolved, secretValues, err := Resolve(log.NewMockLog(), filepath.Join(t.TempDir(), "missing"), input)
+
+	assert.NoError(t, err)
+	assert.Nil(t, secretValues)
+	assert.Equal(t, input, resolved)
+}
+
+func TestResolve_PermissiveSecretFile(t *testing.T) {
+	dir := newSecretsDir(t, map[string]string{"shared": "value"})
+	assert.NoError(t, os.Chmod(filepath.Join(dir, "shared"), 0644))
+
+	_, _, err := Resolve(log.NewMockLog(), dir, "{{ localsecret:shared }}")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must not be accessible by group or other users")
+}
+
+func TestResolve_PathTraversalIsNotAReference(t *testing.T) {
+	dir := newSecretsDir(t, nil)
+	input := "{{ localsecret:../../etc/shadow }}"
+
+	resolved, _, err := Resolve(log.NewMockLog(), dir, input)
+
+	assert.NoError(t, err)
+	assert.Equal(t, input, resolved)
+}
+
+func TestResolve_RelativeDirectoryNamesAreNotReferences(t *testing.T) {
+	dir := newSecretsDir(t, map[string]string{".hidden": "value"})
+	for _, input := range []string{"{{ localsecret:. }}", "{{ localsecret:.. }}", "{{ localsecret:.hidden }}"} {
+		assert.False(t, ContainsLocalSecrets(input), input)
+
+		resolved, _, err := Resolve(log.NewMockLog(), dir, input)
+
+		assert.NoError(t, err, input)
+		assert.Equal(t, input, resolved, input)
+	}
+}
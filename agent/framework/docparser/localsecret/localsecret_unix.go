@@ -0,0 +1,34 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package localsecret
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkPermissions fails if path is missing or accessible by group or other users
+func checkPermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("local secrets location %v is not accessible: %v", path, err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("local secrets location %v has permissions %v, it must not be accessible by group or other users", path, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parameters
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Base64DecodeFunction decodes a standard base64 encoded parameter value
+	Base64DecodeFunction = "base64decode"
+	// HexDecodeFunction decodes a hex encoded parameter value
+	HexDecodeFunction = "hexdecode"
+)
+
+// functionReferencePattern matches {{ function:paramName }} where function is one of the decoding functions
+var functionReferencePattern = regexp.MustCompile(fmt.Sprintf(`{{\s*(%v|%v):([a-zA-Z0-9]+)\s*}}`, Base64DecodeFunction, HexDecodeFunction))
+
+// boundFunctionPattern matches {{ function=encodedValue }}, a function reference bound to the value of its parameter
+var boundFunctionPattern = regexp.MustCompile(fmt.Sprintf(`{{\s*(%v|%v)=([a-zA-Z0-9+/=]*)\s*}}`, Base64DecodeFunction, HexDecodeFunction))
+
+// decoders maps each parameter-resolution function to the decoder it applies to the parameter value
+var decoders = map[string]func(string) ([]byte, error){
+	Base64DecodeFunction: base64.StdEncoding.DecodeString,
+	HexDecodeFunction:    hex.DecodeString,
+}
+
+// BindDecodedParameters replaces every {{ base64decode:paramName }} and {{ hexdecode:paramName }} found in input
+// with {{ base64decode=encodedValue }} or {{ hexdecode=encodedValue }}, the encoded value of the parameter.
+// The values are only decoded by ResolveDecodedParameters right before the step runs, so that the decoded values
+// are neither persisted nor logged with the document. An error is returned if a referenced parameter is undefined,
+// is not a string or is not correctly encoded; the error never contains the parameter value.
+func BindDecodedParameters(input interface{}, parameters map[string]interface{}) (interface{}, error) {
+	var bindErr error
+	bound := walk(input, func(text string) string {
+		return functionReferencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+			matches := functionReferencePattern.FindStringSubmatch(reference)
+			encoded, err := encodedParameter(matches[1], matches[2], parameters)
+			if err != nil {
+				if bindErr == nil {
+					bindErr = err
+				}
+				return reference
+			}
+			return fmt.Sprintf("{{ %v=%v }}", matches[1], encoded)
+		})
+	})
+	if bindErr != nil {
+		return input, bindErr
+	}
+	return bound, nil
+}
+
+// ResolveDecodedParameters replaces every {{ base64decode=encodedValue }} and {{ hexdecode=encodedValue }} bound by
+// BindDecodedParameters with the decoded value. It returns the replaced input along with the decoded values so that
+// callers can redact them.
+func ResolveDecodedParameters(input interface{}) (interface{}, []string, error) {
+	decoded := make(map[string]string)
+	var decodeErr error
+	replaced := walk(input, func(text string) string {
+		return boundFunctionPattern.ReplaceAllStringFunc(text, func(reference string) string {
+			if value, ok := decoded[reference]; ok {
+				return value
+			}
+			matches := boundFunctionPattern.FindStringSubmatch(reference)
+			value, err := decoders[matches[1]](matches[2])
+			if err != nil {
+				if decodeErr == nil {
+					decodeErr = fmt.Errorf("%v failed to decode a value: value is malformed", matches[1])
+				}
+				return reference
+			}
+			decoded[reference] = string(value)
+			return string(value)
+		})
+	})
+	if decodeErr != nil {
+		return input, nil, decodeErr
+	}
+
+	values := make([]string, 0, len(decoded))
+	for _, value := range decoded {
+		values = append(values, value)
+	}
+	return replaced, values, nil
+}
+
+// encodedParameter returns the value of the parameter once checked that the named decoding function accepts it
+func encodedParameter(function string, paramName string, parameters map[string]interface{}) (string, error) {
+	paramValue, ok := parameters[paramName]
+	if !ok {
+		return "", fmt.Errorf("%v references undefined parameter %v", function, paramName)
+	}
+	encoded, ok := paramValue.(string)
+	if !ok {
+		return "", fmt.Errorf("%v cannot decode parameter %v of type %T, a string is expected", function, paramName, paramValue)
+	}
+	encoded = strings.TrimSpace(encoded)
+	if _, err := decoders[function](encoded); err != nil {
+		return "", fmt.Errorf("%v failed to decode parameter %v: value is malformed", function, paramName)
+	}
+	return encoded, nil
+}
+
+// walk applies replace to every string found in input, descending into lists and maps
+func walk(input interface{}, replace func(string) string) interface{} {
+	switch value := input.(type) {
+	case string:
+		return replace(value)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = walk(item, replace)
+		}
+		return out
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(value))
+		for i, item := range value {
+			out[i] = walk(item, replace).(map[string]interface{})
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			out[key] = walk(item, replace)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{})
+		for key, item := range value {
+			if key, ok := key.(string); ok {
+				out[key] = walk(item, replace)
+			}
+		}
+		return out
+	default:
+		return input
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parameters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindDecodedParameters_Base64(t *testing.T) {
+	input := map[string]interface{}{
+		"commands": []interface{}{"login {{ base64decode:password }}", "echo {{ user }}"},
+	}
+	params := map[string]interface{}{"password": "czNjcjN0", "user": "admin"}
+
+	bound, err := BindDecodedParameters(input, params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"login {{ base64decode=czNjcjN0 }}", "echo {{ user }}"}, bound.(map[string]interface{})["commands"])
+
+	resolved, decodedValues, err := ResolveDecodedParameters(bound)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"login s3cr3t", "echo {{ user }}"}, resolved.(map[string]interface{})["commands"])
+	assert.Equal(t, []string{"s3cr3t"}, decodedValues)
+}
+
+func TestBindDecodedParameters_Hex(t *testing.T) {
+	params := map[string]interface{}{"token": "733363723374\n"}
+
+	bound, err := BindDecodedParameters("{{hexdecode:token}}", params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "{{ hexdecode=733363723374 }}", bound)
+
+	resolved, decodedValues, err := ResolveDecodedParameters(bound)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", resolved)
+	assert.Equal(t, []string{"s3cr3t"}, decodedValues)
+}
+
+func TestResolveDecodedParameters_SameReferenceDecodedOnce(t *testing.T) {
+	resolved, decodedValues, err := ResolveDecodedParameters("{{ base64decode=czNjcjN0 }} {{base64decode=czNjcjN0}}")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t s3cr3t", resolved)
+	assert.Len(t, decodedValues, 2)
+}
+
+func TestResolveDecodedParameters_LeavesParameterReferences(t *testing.T) {
+	resolved, decodedValues, err := ResolveDecodedParameters("login {{ base64decode:password }}")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "login {{ base64decode:password }}", resolved)
+	assert.Empty(t, decodedValues)
+}
+
+func TestBindDecodedParameters_Malformed(t *testing.T) {
+	input := map[string]interface{}{"commands": "login {{ base64decode:password }} {{ hexdecode:token }}"}
+	testCases := map[string]map[string]interface{}{
+		"base64": {"password": "not base64!", "token": "74"},
+		"hex":    {"password": "czNjcjN0", "token": "7g"},
+	}
+
+	for name, params := range testCases {
+		t.Run(name, func(t *testing.T) {
+			bound, err := BindDecodedParameters(input, params)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "malformed")
+			assert.NotContains(t, err.Error(), params["password"])
+			assert.Equal(t, input, bound)
+		})
+	}
+}
+
+func TestResolveDecodedParameters_Malformed(t *testing.T) {
+	input := "login {{ hexdecode=7 }}"
+
+	resolved, decodedValues, err := ResolveDecodedParameters(input)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "malformed")
+	assert.Equal(t, input, resolved)
+	assert.Nil(t, decodedValues)
+}
+
+func TestBindDecodedParameters_InvalidParameter(t *testing.T) {
+	params := map[string]interface{}{"passwords": []interface{}{"czNjcjN0"}}
+
+	_, err := BindDecodedParameters("{{ base64decode:passwords }}", params)
+	assert.Error(t, err)
+
+	_, err = BindDecodedParameters("{{ base64decode:missing }}", params)
+	assert.Error(t, err)
+}
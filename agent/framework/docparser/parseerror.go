@@ -0,0 +1,104 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ParseErrorKind classifies the cause of a document parsing failure
+type ParseErrorKind int
+
+const (
+	// SyntaxError is returned for documents that are not valid JSON or YAML
+	SyntaxError ParseErrorKind = iota + 1
+	// SchemaError is returned for documents with an unsupported schema version or invalid steps, such as unknown plugins
+	SchemaError
+	// ParameterError is returned when the parameters are missing, invalid or cannot be resolved
+	ParameterError
+)
+
+// String returns the name of the kind
+func (kind ParseErrorKind) String() string {
+	switch kind {
+	case SyntaxError:
+		return "SyntaxError"
+	case SchemaError:
+		return "SchemaError"
+	case ParameterError:
+		return "ParameterError"
+	}
+	return "UnknownError"
+}
+
+// ParseError is returned when a document cannot be parsed, its message is the message of the underlying error
+type ParseError struct {
+	Kind ParseErrorKind
+	Err  error
+	// Line and Column locate a SyntaxError in the raw document, starting at 1. They are 0 when unknown,
+	// yaml.v2 only reports the line of an error.
+	Line   int
+	Column int
+}
+
+// yamlErrorLinePattern matches the line yaml.v2 reports in its error messages, e.g. "yaml: line 3: mapping values are not allowed"
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+):`)
+
+// newParseError wraps err in a ParseError of the given kind, a nil err stays nil
+func newParseError(kind ParseErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ParseError{Kind: kind, Err: err}
+}
+
+func (parseError *ParseError) Error() string {
+	return parseError.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (parseError *ParseError) Unwrap() error {
+	return parseError.Err
+}
+
+// newSyntaxError wraps the error of the JSON or YAML decoder of documentRaw in a SyntaxError carrying its location.
+// JSON errors only report a byte offset, which is converted to a line and column and added to the message.
+func newSyntaxError(documentRaw []byte, err error) error {
+	parseError := &ParseError{Kind: SyntaxError, Err: err}
+	var offset int64 = -1
+	var jsonSyntaxError *json.SyntaxError
+	var jsonTypeError *json.UnmarshalTypeError
+	if errors.As(err, &jsonSyntaxError) {
+		offset = jsonSyntaxError.Offset
+	} else if errors.As(err, &jsonTypeError) {
+		offset = jsonTypeError.Offset
+	}
+	if offset >= 0 && offset <= int64(len(documentRaw)) {
+		read := documentRaw[:offset]
+		parseError.Line = bytes.Count(read, []byte("\n")) + 1
+		parseError.Column = len(read) - bytes.LastIndexByte(read, '\n') - 1
+		if parseError.Column == 0 {
+			parseError.Column = 1
+		}
+		parseError.Err = fmt.Errorf("line %d, column %d: %w", parseError.Line, parseError.Column, err)
+	} else if match := yamlErrorLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		parseError.Line, _ = strconv.Atoi(match[1])
+	}
+	return parseError
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/platform"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+)
+
+const redactedParameterValue = "****"
+
+// sensitiveParameterPattern matches the names of parameters whose values are not recorded in the provenance
+var sensitiveParameterPattern = regexp.MustCompile("(?i)(password|passwd|secret|token|credential|privatekey|apikey)")
+
+var (
+	getPlatformName    = platform.PlatformName
+	getPlatformVersion = platform.PlatformVersion
+)
+
+// provenanceStep is the part of a parsed step that determines what it runs
+type provenanceStep struct {
+	Name       string
+	Id         string
+	Settings   interface{}
+	Properties interface{}
+}
+
+// newExecutionProvenance builds the provenance record of a parsed document
+func newExecutionProvenance(log log.T, docContent IDocumentContent, pluginsInfo []contracts.PluginState, params map[string]interface{}) *contracts.ExecutionProvenance {
+	provenance := &contracts.ExecutionProvenance{
+		Parameters:     make(map[string]interface{}),
+		AgentVersion:   version.Version,
+		PluginVersions: make(map[string]string),
+	}
+	provenance.PlatformName, _ = getPlatformName(log)
+	provenance.PlatformVersion, _ = getPlatformVersion(log)
+
+	steps := make([]provenanceStep, 0, len(pluginsInfo))
+	for _, pluginState := range pluginsInfo {
+		steps = append(steps, provenanceStep{
+			Name:       pluginState.Name,
+			Id:         pluginState.Id,
+			Settings:   pluginState.Configuration.Settings,
+			Properties: pluginState.Configuration.Properties,
+		})
+		provenance.PluginVersions[pluginState.Name] = version.Version
+	}
+	// map keys are sorted when marshaled, so the hash does not depend on the parameter order
+	if content, err := jsonutil.Marshal(steps); err == nil {
+		hash := sha256.Sum256([]byte(content))
+		provenance.ContentHash = hex.EncodeToString(hash[:])
+	} else {
+		log.Warnf("Failed to compute the document content hash: %v", err)
+	}
+
+	if doc, ok := docContent.(*DocContent); ok {
+		for name, parameter := range doc.Parameters {
+			provenance.Parameters[name] = parameterDefault(log, parameter)
+		}
+	}
+	for name, value := range params {
+		provenance.Parameters[name] = value
+	}
+	for name := range provenance.Parameters {
+		if sensitiveParameterPattern.MatchString(name) {
+			provenance.Parameters[name] = redactedParameterValue
+		}
+	}
+	return provenance
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	"github.com/stretchr/testify/assert"
+)
+
+const provenanceDocument = `{
+	"schemaVersion": "2.2",
+	"parameters": {
+		"commands": {"type": "StringList"},
+		"dbPassword": {"type": "String", "default": "changeme"},
+		"workingDirectory": {"type": "String", "default": "/tmp"}
+	},
+	"mainSteps": [{
+		"action": "aws:runShellScript",
+		"name": "run",
+		"inputs": {"runCommand": "{{ commands }}", "workingDirectory": "{{ workingDirectory }}"}
+	}]
+}`
+
+func initializeProvenance(t *testing.T, params map[string]interface{}) *contracts.ExecutionProvenance {
+	var docContent DocContent
+	assert.NoError(t, json.Unmarshal([]byte(provenanceDocument), &docContent))
+
+	docState, err := InitializeDocState(context.NewMockDefault(), contracts.SendCommand, &docContent, contracts.DocumentInfo{}, DocumentParserInfo{}, params)
+
+	assert.NoError(t, err)
+	return docState.Provenance
+}
+
+func TestInitializeDocState_Provenance(t *testing.T) {
+	platformNameOrig, platformVersionOrig := getPlatformName, getPlatformVersion
+	getPlatformName = func(log log.T) (string, error) { return "Amazon Linux", nil }
+	getPlatformVersion = func(log log.T) (string, error) { return "2023", nil }
+	defer func() {
+		getPlatformName = platformNameOrig
+		getPlatformVersion = platformVersionOrig
+	}()
+
+	provenance := initializeProvenance(t, map[string]interface{}{
+		"commands":   []interface{}{"date"},
+		"dbPassword": "hunter2",
+	})
+
+	assert.Len(t, provenance.ContentHash, 64)
+	assert.Equal(t, map[string]interface{}{
+		"commands":         []interface{}{"date"},
+		"dbPassword":       redactedParameterValue,
+		"workingDirectory": "/tmp",
+	}, provenance.Parameters)
+	assert.Equal(t, version.Version, provenance.AgentVersion)
+	assert.Equal(t, "Amazon Linux", provenance.PlatformName)
+	assert.Equal(t, "2023", provenance.PlatformVersion)
+	assert.Equal(t, map[string]string{"aws:runShellScript": version.Version}, provenance.PluginVersions)
+}
+
+func TestInitializeDocState_ProvenanceContentHash(t *testing.T) {
+	first := initializeProvenance(t, map[string]interface{}{"commands": []interface{}{"date"}})
+	same := initializeProvenance(t, map[string]interface{}{"commands": []interface{}{"date"}})
+	different := initializeProvenance(t, map[string]interface{}{"commands": []interface{}{"uptime"}})
+	// dbPassword is not referenced by the steps, so its value does not change the hash
+	otherSecret := initializeProvenance(t, map[string]interface{}{"commands": []interface{}{"date"}, "dbPassword": "other"})
+
+	assert.Equal(t, first.ContentHash, same.ContentHash)
+	assert.NotEqual(t, first.ContentHash, different.ContentHash)
+	assert.Equal(t, first.ContentHash, otherSecret.ContentHash)
+}
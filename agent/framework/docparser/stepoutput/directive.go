@@ -0,0 +1,74 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stepoutput
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// outputDirectivePattern matches the lines ::set-output name=key::value and ::set-secure-output name=key::value
+var outputDirectivePattern = regexp.MustCompile("^::set-(secure-)?output name=([\\w-]+)::(.*)$")
+
+// DirectiveCollector collects the named outputs set by the output directives printed on their own line by a step.
+// Its Filter is registered on the standard output of the step so the directive lines are removed before the output
+// is written to the files and uploaded to S3 and CloudWatch.
+type DirectiveCollector struct {
+	lock          sync.Mutex
+	outputs       map[string]string
+	secureOutputs map[string]string
+}
+
+// NewDirectiveCollector returns a collector without outputs
+func NewDirectiveCollector() *DirectiveCollector {
+	return &DirectiveCollector{}
+}
+
+// Filter records the output set by a directive line and drops the line, other lines are kept unchanged.
+// Values set with ::set-secure-output are kept apart; when a name is set more than once the last value wins.
+func (collector *DirectiveCollector) Filter(line string) (string, bool) {
+	if !strings.HasPrefix(line, "::set-") {
+		return line, true
+	}
+	match := outputDirectivePattern.FindStringSubmatch(strings.TrimRight(line, "\r"))
+	if match == nil {
+		return line, true
+	}
+
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	name, value := match[2], match[3]
+	if match[1] != "" {
+		if collector.secureOutputs == nil {
+			collector.secureOutputs = make(map[string]string)
+		}
+		collector.secureOutputs[name] = value
+		delete(collector.outputs, name)
+	} else {
+		if collector.outputs == nil {
+			collector.outputs = make(map[string]string)
+		}
+		collector.outputs[name] = value
+		delete(collector.secureOutputs, name)
+	}
+	return "", false
+}
+
+// Outputs returns the outputs and the secure outputs collected so far
+func (collector *DirectiveCollector) Outputs() (outputs map[string]string, secureOutputs map[string]string) {
+	collector.lock.Lock()
+	defer collector.lock.Unlock()
+	return collector.outputs, collector.secureOutputs
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stepoutput
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// filterLines passes the lines of text through the collector and returns the lines it keeps
+func filterLines(collector *DirectiveCollector, text string) string {
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		if filtered, keep := collector.Filter(line); keep {
+			kept = append(kept, filtered)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func TestDirectiveCollector(t *testing.T) {
+	text := "starting\n::set-output name=host::host-1\r\n::set-secure-output name=token::abc::def\n" +
+		"::set-output name=host::host-2\n  ::set-output name=indented::kept\ndone"
+	collector := NewDirectiveCollector()
+
+	remaining := filterLines(collector, text)
+
+	outputs, secureOutputs := collector.Outputs()
+	assert.Equal(t, "starting\n  ::set-output name=indented::kept\ndone", remaining)
+	assert.Equal(t, map[string]string{"host": "host-2"}, outputs)
+	assert.Equal(t, map[string]string{"token": "abc::def"}, secureOutputs)
+}
+
+func TestDirectiveCollector_SecureOverridesOutput(t *testing.T) {
+	collector := NewDirectiveCollector()
+
+	remaining := filterLines(collector, "::set-output name=key::plain\n::set-secure-output name=key::secret")
+
+	outputs, secureOutputs := collector.Outputs()
+	assert.Empty(t, remaining)
+	assert.Empty(t, outputs)
+	assert.Equal(t, map[string]string{"key": "secret"}, secureOutputs)
+}
+
+func TestDirectiveCollector_NoDirectives(t *testing.T) {
+	text := "::set-output without a name\n"
+	collector := NewDirectiveCollector()
+
+	remaining := filterLines(collector, text)
+
+	outputs, secureOutputs := collector.Outputs()
+	assert.Equal(t, text, remaining)
+	assert.Nil(t, outputs)
+	assert.Nil(t, secureOutputs)
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package stepoutput resolves {{ stepName.output }} and {{ stepName.outputs.key }} references between the
+// steps of a document. Unlike document parameters these references can only be resolved at execution time,
+// once the referenced step has run.
+package stepoutput
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// stepOutputReferencePattern matches {{ stepName.output }}
+var stepOutputReferencePattern = regexp.MustCompile("{{\\s*([\\w.-]+)\\.output\\s*}}")
+
+// namedOutputReferencePattern matches {{ stepName.outputs.key }}
+var namedOutputReferencePattern = regexp.MustCompile("{{\\s*([\\w.-]+)\\.outputs\\.([\\w-]+)\\s*}}")
+
+// ContainsStepOutputs returns true if the input contains at least one step output reference
+func ContainsStepOutputs(input interface{}) bool {
+	found := false
+	walk(input, func(text string) string {
+		if stepOutputReferencePattern.MatchString(text) {
+			found = true
+		}
+		return text
+	})
+	return found
+}
+
+// ContainsNamedOutputs returns true if the input contains at least one named output reference
+func ContainsNamedOutputs(input interface{}) bool {
+	found := false
+	walk(input, func(text string) string {
+		if namedOutputReferencePattern.MatchString(text) {
+			found = true
+		}
+		return text
+	})
+	return found
+}
+
+// Resolve replaces all step output references found in input with the output of the matching step.
+// outputs holds the output of every step that completed successfully, keyed by step name.
+func Resolve(input interface{}, outputs map[string]string) (interface{}, error) {
+	if !ContainsStepOutputs(input) {
+		return input, nil
+	}
+
+	var resolveErr error
+	resolved := walk(input, func(text string) string {
+		return stepOutputReferencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+			stepName := stepOutputReferencePattern.FindStringSubmatch(reference)[1]
+			value, ok := outputs[stepName]
+			if !ok {
+				if resolveErr == nil {
+					resolveErr = fmt.Errorf("step %v has no output, it must run successfully before the steps referencing it", stepName)
+				}
+				return reference
+			}
+			return strings.TrimRight(value, "\r\n")
+		})
+	})
+	if resolveErr != nil {
+		return input, resolveErr
+	}
+	return resolved, nil
+}
+
+// ResolveNamedOutputs replaces the named output references found in input with the value set by the matching step.
+// outputs holds the named outputs of every step of the document, keyed by step name, with nil outputs for the steps
+// which did not complete successfully. References to a step missing from outputs are not step outputs and are kept.
+func ResolveNamedOutputs(input interface{}, outputs map[string]map[string]string) (interface{}, error) {
+	var resolveErr error
+	resolved := walk(input, func(text string) string {
+		return namedOutputReferencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+			match := namedOutputReferencePattern.FindStringSubmatch(reference)
+			stepName, key := match[1], match[2]
+			stepOutputs, isStep := outputs[stepName]
+			if !isStep {
+				return reference
+			}
+			value, ok := stepOutputs[key]
+			if !ok {
+				if resolveErr == nil {
+					resolveErr = fmt.Errorf("step %v has no output %v, it must run successfully and set it before the steps referencing it", stepName, key)
+				}
+				return reference
+			}
+			return value
+		})
+	})
+	if resolveErr != nil {
+		return input, resolveErr
+	}
+	return resolved, nil
+}
+
+// walk applies replace to every string found in input, descending into lists and maps
+func walk(input interface{}, replace func(string) string) interface{} {
+	switch value := input.(type) {
+	case string:
+		return replace(value)
+	case []string:
+		out := make([]string, len(value))
+		for i, item := range value {
+			out[i] = replace(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = walk(item, replace)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			out[key] = walk(item, replace)
+		}
+		return out
+	default:
+		return input
+	}
+}
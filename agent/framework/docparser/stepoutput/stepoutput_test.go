@@ -0,0 +1,83 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stepoutput
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve_ReplacesReferences(t *testing.T) {
+	input := map[string]interface{}{
+		"runCommand":       []interface{}{"echo {{ getHost.output }}", "echo {{getHost.output}}-{{ step.two.output }}"},
+		"workingDirectory": "/tmp",
+	}
+	outputs := map[string]string{"getHost": "host-1\n", "step.two": "two"}
+
+	resolved, err := Resolve(input, outputs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"runCommand":       []interface{}{"echo host-1", "echo host-1-two"},
+		"workingDirectory": "/tmp",
+	}, resolved)
+}
+
+func TestResolve_MissingStepOutput(t *testing.T) {
+	input := []interface{}{"echo {{ notRunYet.output }}"}
+
+	resolved, err := Resolve(input, map[string]string{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "notRunYet")
+	assert.Equal(t, input, resolved)
+}
+
+func TestResolve_NoReferences(t *testing.T) {
+	input := "echo {{ localsecret:token }} {{ ssm:param }}"
+
+	resolved, err := Resolve(input, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, input, resolved)
+	assert.False(t, ContainsStepOutputs(input))
+}
+
+func TestResolveNamedOutputs_ReplacesReferences(t *testing.T) {
+	input := map[string]interface{}{
+		"runCommand": []interface{}{"ping {{ getHost.outputs.host }}", "echo {{getHost.outputs.port}} {{ steps.build.outputs.tag }}"},
+	}
+	outputs := map[string]map[string]string{"getHost": {"host": "host-1", "port": "22"}}
+
+	resolved, err := ResolveNamedOutputs(input, outputs)
+
+	assert.NoError(t, err)
+	// the references to a step missing from the document are kept
+	assert.Equal(t, map[string]interface{}{
+		"runCommand": []interface{}{"ping host-1", "echo 22 {{ steps.build.outputs.tag }}"},
+	}, resolved)
+}
+
+func TestResolveNamedOutputs_MissingOutput(t *testing.T) {
+	input := []interface{}{"echo {{ getHost.outputs.host }}"}
+
+	for _, outputs := range []map[string]map[string]string{{"getHost": nil}, {"getHost": {"port": "22"}}} {
+		resolved, err := ResolveNamedOutputs(input, outputs)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "getHost has no output host")
+		assert.Equal(t, input, resolved)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/paramvalidator"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/log/logger"
+)
+
+// ssmParameterReference matches {{ssm:name}} references, which can only be resolved on an instance
+var ssmParameterReference = regexp.MustCompile(`\{\{ *ssm:[/\w.:-]+ *\}\}`)
+
+// ValidationError describes one problem found in a document by ValidateDocument
+type ValidationError struct {
+	// Field locates the problem in the document, e.g. "schemaVersion", "parameters.commands" or "mainSteps[1].action"
+	Field   string
+	Message string
+}
+
+func (validationError ValidationError) Error() string {
+	return fmt.Sprintf("%v: %v", validationError.Field, validationError.Message)
+}
+
+// ValidateDocument checks a raw JSON or YAML command document and its parameters the way the agent does before executing it:
+// the schema version, the step definitions and plugin names, required and unknown parameters and the allowed pattern of parameter values.
+// It needs neither an agent context nor a filesystem, and SSM parameter store references are not resolved.
+// The returned error is only set when the document cannot be parsed at all.
+func ValidateDocument(raw []byte, params map[string]interface{}) (validationErrors []ValidationError, err error) {
+	var docContent DocContent
+	if err = UnmarshalDocumentContent(raw, &docContent); err != nil {
+		return nil, fmt.Errorf("unable to parse document: %w", err)
+	}
+
+	if err := validateSchema(docContent.SchemaVersion); err != nil {
+		validationErrors = append(validationErrors, ValidationError{Field: "schemaVersion", Message: err.Error()})
+	}
+	validationErrors = append(validationErrors, validatePlugins(&docContent)...)
+	validationErrors = append(validationErrors, validateParameters(&docContent, params)...)
+	return validationErrors, nil
+}
+
+// validatePlugins checks the steps of the document for the schema version it declares
+func validatePlugins(docContent *DocContent) (validationErrors []ValidationError) {
+	switch docContent.SchemaVersion {
+	case "1.0", "1.2":
+		if len(docContent.RuntimeConfig) == 0 {
+			return []ValidationError{{Field: "runtimeConfig", Message: "document must define at least one plugin"}}
+		}
+		pluginNames := make([]string, 0, len(docContent.RuntimeConfig))
+		for pluginName := range docContent.RuntimeConfig {
+			pluginNames = append(pluginNames, pluginName)
+		}
+		sort.Strings(pluginNames)
+		for _, pluginName := range pluginNames {
+			if !runpluginutil.IsKnownPlugin(pluginName) {
+				validationErrors = append(validationErrors, ValidationError{
+					Field:   "runtimeConfig." + pluginName,
+					Message: fmt.Sprintf("unknown plugin %v", pluginName),
+				})
+			}
+		}
+	case "2.0", "2.0.1", "2.0.2", "2.0.3", "2.2":
+		if len(docContent.MainSteps) == 0 {
+			return []ValidationError{{Field: "mainSteps", Message: "document must define at least one step"}}
+		}
+		stepNames := make(map[string]bool)
+		for index, step := range docContent.MainSteps {
+			field := fmt.Sprintf("mainSteps[%d]", index)
+			if step == nil {
+				validationErrors = append(validationErrors, ValidationError{Field: field, Message: "step is empty"})
+				continue
+			}
+			if step.Name == "" {
+				validationErrors = append(validationErrors, ValidationError{Field: field + ".name", Message: "step name is required"})
+			} else if stepNames[step.Name] {
+				validationErrors = append(validationErrors, ValidationError{Field: field + ".name", Message: fmt.Sprintf("step name %v is not unique", step.Name)})
+			}
+			stepNames[step.Name] = true
+
+			if step.Action == "" {
+				validationErrors = append(validationErrors, ValidationError{Field: field + ".action", Message: "step action is required"})
+			} else if !runpluginutil.IsKnownPlugin(step.Action) {
+				validationErrors = append(validationErrors, ValidationError{Field: field + ".action", Message: fmt.Sprintf("unknown plugin %v", step.Action)})
+			}
+		}
+	}
+	return
+}
+
+// validateParameters checks the supplied parameters against the parameter definitions of the document
+func validateParameters(docContent *DocContent, params map[string]interface{}) (validationErrors []ValidationError) {
+	log := logger.NewSilentLogger()
+
+	suppliedNames := make([]string, 0, len(params))
+	for paramName := range params {
+		suppliedNames = append(suppliedNames, paramName)
+	}
+	sort.Strings(suppliedNames)
+	for _, paramName := range suppliedNames {
+		if _, defined := docContent.Parameters[paramName]; !defined {
+			validationErrors = append(validationErrors, ValidationError{Field: "parameters." + paramName, Message: "parameter is not defined in the document"})
+		}
+	}
+
+	definedNames := make([]string, 0, len(docContent.Parameters))
+	for paramName := range docContent.Parameters {
+		definedNames = append(definedNames, paramName)
+	}
+	sort.Strings(definedNames)
+	for _, paramName := range definedNames {
+		field := "parameters." + paramName
+		definition := docContent.Parameters[paramName]
+		if definition == nil {
+			validationErrors = append(validationErrors, ValidationError{Field: field, Message: "parameter definition is empty"})
+			continue
+		}
+		value, supplied := params[paramName]
+		if !supplied {
+			if value = parameterDefault(log, definition); value == nil {
+				validationErrors = append(validationErrors, ValidationError{Field: field, Message: "required parameter is missing"})
+				continue
+			}
+		}
+		// values taken from parameter store are only known once resolved on the instance
+		if referencesSSMParameter(value) {
+			continue
+		}
+		for _, paramValidator := range paramvalidator.GetMandatoryValidators() {
+			if err := paramValidator.Validate(log, value, definition); err != nil {
+				validationErrors = append(validationErrors, ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("error thrown in '%v': %v", paramValidator.GetName(), err),
+				})
+			}
+		}
+	}
+	return
+}
+
+func referencesSSMParameter(value interface{}) bool {
+	raw, err := json.Marshal(value)
+	return err == nil && ssmParameterReference.Match(raw)
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package docparser
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const validateTestDocument = `{
+	"schemaVersion": "2.2",
+	"description": "Run a script",
+	"parameters": {
+		"commands": {"type": "StringList", "description": "Commands to run"},
+		"workingDirectory": {"type": "String", "default": "", "allowedPattern": "^[a-zA-Z0-9/_-]*$"}
+	},
+	"mainSteps": [
+		{"action": "aws:runShellScript", "name": "runShellScript", "inputs": {"runCommand": "{{ commands }}", "workingDirectory": "{{ workingDirectory }}"}}
+	]
+}`
+
+func TestValidateDocumentValid(t *testing.T) {
+	validationErrors, err := ValidateDocument([]byte(validateTestDocument), map[string]interface{}{
+		"commands":         []interface{}{"echo hello"},
+		"workingDirectory": "/tmp",
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, validationErrors)
+}
+
+func TestValidateDocumentYAML(t *testing.T) {
+	document := `
+schemaVersion: "2.2"
+description: Run a script
+parameters:
+  commands:
+    type: StringList
+    default: ["echo hello"]
+mainSteps:
+  - action: aws:runShellScript
+    name: runShellScript
+    inputs:
+      runCommand: "{{ commands }}"
+`
+	validationErrors, err := ValidateDocument([]byte(document), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, validationErrors)
+}
+
+func TestValidateDocumentMissingRequiredParameter(t *testing.T) {
+	validationErrors, err := ValidateDocument([]byte(validateTestDocument), map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ValidationError{
+		{Field: "parameters.commands", Message: "required parameter is missing"},
+	}, validationErrors)
+}
+
+func TestValidateDocumentPlatformParameterDefaults(t *testing.T) {
+	getPlatformTypeOrig := getPlatformType
+	defer func() { getPlatformType = getPlatformTypeOrig }()
+	getPlatformType = func(log log.T) (string, error) { return "linux", nil }
+	document := `{
+		"schemaVersion": "2.2",
+		"parameters": {
+			"linuxDir": {"type": "String", "platformDefaults": {"linux": "/tmp"}},
+			"windowsDir": {"type": "String", "platformDefaults": {"windows": "C:\\Temp"}}
+		},
+		"mainSteps": [{"action": "aws:runShellScript", "name": "cd", "inputs": {"runCommand": ["cd {{ linuxDir }} {{ windowsDir }}"]}}]
+	}`
+
+	validationErrors, err := ValidateDocument([]byte(document), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []ValidationError{
+		{Field: "parameters.windowsDir", Message: "required parameter is missing"},
+	}, validationErrors)
+}
+
+func TestValidateDocumentUnknownParameter(t *testing.T) {
+	validationErrors, err := ValidateDocument([]byte(validateTestDocument), map[string]interface{}{
+		"commands": []interface{}{"echo hello"},
+		"timeout":  "3600",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []ValidationError{
+		{Field: "parameters.timeout", Message: "parameter is not defined in the document"},
+	}, validationErrors)
+}
+
+func TestValidateDocumentParameterNotMatchingAllowedPattern(t *testing.T) {
+	validationErrors, err := ValidateDocument([]byte(validateTestDocument), map[string]interface{}{
+		"commands":         []interface{}{"echo hello"},
+		"workingDirectory": "/tmp; rm -rf /",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(validationErrors))
+	assert.Equal(t, "parameters.workingDirectory", validationErrors[0].Field)
+}
+
+func TestValidateDocumentSkipsParameterStoreReferences(t *testing.T) {
+	validationErrors, err := ValidateDocument([]byte(validateTestDocument), map[string]interface{}{
+		"commands":         []interface{}{"echo hello"},
+		"workingDirectory": "{{ssm:/build/workingDirectory}}",
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, validationErrors)
+}
+
+func TestValidateDocumentUnknownPlugin(t *testing.T) {
+	document := `{
+		"schemaVersion": "2.2",
+		"mainSteps": [
+			{"action": "aws:runShellScript", "name": "first", "inputs": {"runCommand": ["echo"]}},
+			{"action": "aws:runShelScript", "name": "second", "inputs": {"runCommand": ["echo"]}},
+			{"action": "", "name": "first"}
+		]
+	}`
+	validationErrors, err := ValidateDocument([]byte(document), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []ValidationError{
+		{Field: "mainSteps[1].action", Message: "unknown plugin aws:runShelScript"},
+		{Field: "mainSteps[2].name", Message: "step name first is not unique"},
+		{Field: "mainSteps[2].action", Message: "step action is required"},
+	}, validationErrors)
+}
+
+func TestValidateDocumentUnknownPluginInRuntimeConfig(t *testing.T) {
+	document := `{
+		"schemaVersion": "1.2",
+		"runtimeConfig": {
+			"aws:runScript": {"properties": [{"runCommand": ["echo"]}]},
+			"aws:runPowerShellScript": {"properties": [{"runCommand": ["echo"]}]}
+		}
+	}`
+	validationErrors, err := ValidateDocument([]byte(document), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []ValidationError{
+		{Field: "runtimeConfig.aws:runScript", Message: "unknown plugin aws:runScript"},
+	}, validationErrors)
+}
+
+func TestValidateDocumentUnsupportedSchema(t *testing.T) {
+	document := `{"schemaVersion": "0.3", "mainSteps": [{"action": "aws:runShellScript", "name": "run"}]}`
+	validationErrors, err := ValidateDocument([]byte(document), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(validationErrors))
+	assert.Equal(t, "schemaVersion", validationErrors[0].Field)
+}
+
+func TestValidateDocumentUnparseable(t *testing.T) {
+	validationErrors, err := ValidateDocument([]byte("schemaVersion: [unclosed"), nil)
+	assert.Error(t, err)
+	assert.Nil(t, validationErrors)
+}
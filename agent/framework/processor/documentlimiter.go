@@ -0,0 +1,95 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"sync"
+)
+
+// documentLimiter bounds the number of documents running at the same time,
+// the documents waiting for a free slot get it in the order they were queued
+type documentLimiter struct {
+	lock    sync.Mutex
+	limit   int
+	running int
+	queue   []chan struct{}
+}
+
+var (
+	sharedDocumentLimiter     *documentLimiter
+	sharedDocumentLimiterOnce sync.Once
+)
+
+// getSharedDocumentLimiter returns the limiter shared by the processors of the agent, nil when the limit is not positive
+func getSharedDocumentLimiter(limit int) *documentLimiter {
+	sharedDocumentLimiterOnce.Do(func() {
+		sharedDocumentLimiter = newDocumentLimiter(limit)
+	})
+	return sharedDocumentLimiter
+}
+
+// newDocumentLimiter returns a limiter running at most limit documents at the same time, nil when the limit is not positive
+func newDocumentLimiter(limit int) *documentLimiter {
+	if limit < 1 {
+		return nil
+	}
+	return &documentLimiter{limit: limit}
+}
+
+// enqueue takes a free slot and returns nil, or queues the caller when all the slots are taken
+// and returns a channel closed once a slot is handed over to it
+func (l *documentLimiter) enqueue() <-chan struct{} {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.running < l.limit && len(l.queue) == 0 {
+		l.running++
+		return nil
+	}
+	granted := make(chan struct{})
+	l.queue = append(l.queue, granted)
+	return granted
+}
+
+// leave removes a caller from the queue, it returns false when the slot was handed over to the caller already,
+// in which case the caller must release it
+func (l *documentLimiter) leave(granted <-chan struct{}) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for i, queued := range l.queue {
+		if queued == granted {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// release frees a slot, it is handed over to the first queued caller if any
+func (l *documentLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.queue) > 0 {
+		close(l.queue[0])
+		l.queue = l.queue[1:]
+		return
+	}
+	l.running--
+}
+
+// queued returns the number of callers waiting for a slot
+func (l *documentLimiter) queued() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.queue)
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDocumentLimiter_NoLimit(t *testing.T) {
+	assert.Nil(t, newDocumentLimiter(0))
+	assert.Nil(t, newDocumentLimiter(-1))
+}
+
+func TestDocumentLimiter_HandsOverSlotsInQueueOrder(t *testing.T) {
+	limiter := newDocumentLimiter(2)
+	assert.Nil(t, limiter.enqueue())
+	assert.Nil(t, limiter.enqueue())
+	first, second := limiter.enqueue(), limiter.enqueue()
+	assert.NotNil(t, first)
+	assert.NotNil(t, second)
+	assert.Equal(t, 2, limiter.queued())
+
+	limiter.release()
+	assertGranted(t, first)
+	assertNotGranted(t, second)
+
+	limiter.release()
+	assertGranted(t, second)
+	assert.Equal(t, 0, limiter.queued())
+
+	// the released slots are free once nobody is queued
+	limiter.release()
+	assert.Nil(t, limiter.enqueue())
+}
+
+func TestDocumentLimiter_Leave(t *testing.T) {
+	limiter := newDocumentLimiter(1)
+	assert.Nil(t, limiter.enqueue())
+	left, next := limiter.enqueue(), limiter.enqueue()
+
+	assert.True(t, limiter.leave(left))
+	limiter.release()
+	assertGranted(t, next)
+	// the slot of a caller that left after being granted one is not freed by leave
+	assert.False(t, limiter.leave(next))
+	assert.NotNil(t, limiter.enqueue())
+}
+
+func assertGranted(t *testing.T, granted <-chan struct{}) {
+	select {
+	case <-granted:
+	default:
+		assert.Fail(t, "slot was not handed over")
+	}
+}
+
+func assertNotGranted(t *testing.T, granted <-chan struct{}) {
+	select {
+	case <-granted:
+		assert.Fail(t, "slot was handed over")
+	default:
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
@@ -55,11 +56,14 @@ func run(context context.T,
 	//document information summary
 	messageID := docState.DocumentInformation.MessageID
 	associationID := docState.DocumentInformation.AssociationID
+	documentID := docState.DocumentInformation.DocumentID
 	nPlugins := len(docState.InstancePluginsInformation)
 	documentName := docState.DocumentInformation.DocumentName
 	documentVersion := docState.DocumentInformation.DocumentVersion
 	//status channel for plugins update
 	statusChan := make(chan contracts.PluginResult)
+	//results delivered by the plugins, only read by this function once the listener is done
+	results := make(map[string]*contracts.PluginResult)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	//The go-routine to listen to individual plugin update
@@ -71,9 +75,9 @@ func run(context context.T,
 			}
 			wg.Done()
 		}()
-		results := make(map[string]*contracts.PluginResult)
 		for res := range statusChan {
-			results[res.PluginID] = &res
+			result := res
+			results[res.PluginID] = &result
 			//TODO decompose this function to return only Status
 			status, _, _, _ := contracts.DocumentResultAggregator(context.Log(), res.PluginID, results)
 			docResult := contracts.DocumentResult{
@@ -81,6 +85,7 @@ func run(context context.T,
 				PluginResults:   results,
 				LastPlugin:      res.PluginID,
 				AssociationID:   associationID,
+				DocumentID:      documentID,
 				MessageID:       messageID,
 				NPlugins:        nPlugins,
 				DocumentName:    documentName,
@@ -88,6 +93,8 @@ func run(context context.T,
 			}
 			resChan <- docResult
 			contracts.UpdateDocState(&docResult, state)
+			// checkpoint the completed step, a restarted document resumes at its next unstarted step
+			docStore.Save(*state)
 		}
 	}(&docState)
 
@@ -95,6 +102,7 @@ func run(context context.T,
 	close(statusChan)
 	//make sure the launched go routine has finshed before sending the final response
 	wg.Wait()
+	mergeDeliveredResults(context.Log(), outputs, results)
 	pluginOutputContent, _ := jsonutil.Marshal(outputs)
 	context.Log().Debugf("Plugin outputs %v", jsonutil.Indent(pluginOutputContent))
 	//send DocLevel response
@@ -105,9 +113,15 @@ func run(context context.T,
 		LastPlugin:      "",
 		MessageID:       messageID,
 		AssociationID:   associationID,
+		DocumentID:      documentID,
 		NPlugins:        nPlugins,
 		DocumentName:    documentName,
 		DocumentVersion: documentVersion,
+		Provenance:      docState.Provenance,
+	}
+	contracts.SetLastInProgressPlugin(&result)
+	if status == contracts.ResultStatusInProgress {
+		context.Log().Warnf("Document completed InProgress, step %v started at %v did not complete", result.LastInProgressPluginID, result.LastInProgressTime)
 	}
 	resChan <- result
 	docState.DocumentInformation.DocumentStatus = status
@@ -117,6 +131,22 @@ func run(context context.T,
 	close(resChan)
 }
 
+// mergeDeliveredResults replaces the outputs still pending with the final results delivered on the status channel,
+// so that a step reporting its result late does not leave the document InProgress
+func mergeDeliveredResults(log log.T, outputs map[string]*contracts.PluginResult, delivered map[string]*contracts.PluginResult) {
+	for pluginID, result := range delivered {
+		output, found := outputs[pluginID]
+		if found && output.Status != contracts.ResultStatusInProgress && output.Status != contracts.ResultStatusNotStarted && output.Status != "" {
+			continue
+		}
+		if result.Status == contracts.ResultStatusInProgress || result.Status == contracts.ResultStatusNotStarted {
+			continue
+		}
+		log.Debugf("Using the %v result delivered by step %v", result.Status, pluginID)
+		outputs[pluginID] = result
+	}
+}
+
 // NewBasicExecuter returns a pointer that impl the Executer interface
 // using a pointer so that it can be shared among multiple threads(go-routines)
 func NewBasicExecuter(context context.T) *BasicExecuter {
@@ -135,7 +165,12 @@ func (e *BasicExecuter) Run(
 	// we're creating a buffered channel according to the number of plugins the document has
 	e.resChan = make(chan contracts.DocumentResult, nPlugins)
 
+	documentID := docState.DocumentInformation.DocumentID
+	executer.RegisterRunningDocument(documentID, cancelFlag)
 	log.Debug("Running plugins...")
-	go run(e.ctx, docStore, e.resChan, cancelFlag)
+	go func() {
+		defer executer.UnregisterRunningDocument(documentID, cancelFlag)
+		run(e.ctx, docStore, e.resChan, cancelFlag)
+	}()
 	return e.resChan
 }
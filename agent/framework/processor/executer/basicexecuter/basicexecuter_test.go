@@ -15,15 +15,23 @@
 package basicexecuter
 
 import (
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/executertest"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	executermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
+	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var logger = log.NewMockLog()
@@ -63,6 +71,7 @@ func TestBasicExecuter(t *testing.T) {
 		DocumentInformation:        docInfo,
 		DocumentType:               "SendCommand",
 		InstancePluginsInformation: []contracts.PluginState{pluginState},
+		Provenance:                 &contracts.ExecutionProvenance{ContentHash: "hash", AgentVersion: "3.3.0.0"},
 	}
 
 	result := contracts.PluginResult{
@@ -126,6 +135,7 @@ func testBasicExecuter(t *testing.T, testCase TestCase) {
 			assert.Equal(t, res.Status, testCase.ResultStatus)
 			assert.Equal(t, res.PluginResults, testCase.PluginResults)
 			assert.Equal(t, "MessageID", res.MessageID)
+			assert.Equal(t, testCase.DocState.Provenance, res.Provenance)
 			//assert channel close last
 			done = true
 			continue
@@ -146,3 +156,182 @@ func testBasicExecuter(t *testing.T, testCase TestCase) {
 	dataStoreMock.AssertExpectations(t)
 
 }
+
+// TestBasicExecuterLateSuccessResult tests that a step whose Success result is only delivered on the status channel
+// does not leave the document InProgress
+func TestBasicExecuterLateSuccessResult(t *testing.T) {
+	startTime := time.Now()
+	docState := contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{MessageID: "MessageID", AssociationID: "AssociationID"},
+		DocumentType:        contracts.Association,
+		InstancePluginsInformation: []contracts.PluginState{
+			{Name: "aws:softwareInventory", Id: "collectSoftwareInventoryItems"},
+		},
+	}
+	dataStoreMock := new(executermock.MockDocumentStore)
+	dataStoreMock.On("Load").Return(docState)
+	dataStoreMock.On("Save", mock.Anything).Return()
+	pluginRunner = func(context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag) map[string]*contracts.PluginResult {
+		pluginID := docState.InstancePluginsInformation[0].Id
+		resChan <- contracts.PluginResult{PluginID: pluginID, Status: contracts.ResultStatusSuccess, StartDateTime: startTime}
+		// the runner returns before recording the result of the step
+		return map[string]*contracts.PluginResult{
+			pluginID: {PluginID: pluginID, Status: contracts.ResultStatusInProgress, StartDateTime: startTime},
+		}
+	}
+
+	var final contracts.DocumentResult
+	for res := range NewBasicExecuter(contextmocks.NewMockDefault()).Run(task.NewChanneledCancelFlag(), dataStoreMock) {
+		if res.LastPlugin == "" {
+			final = res
+		}
+	}
+
+	assert.Equal(t, contracts.ResultStatusSuccess, final.Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, final.PluginResults["collectSoftwareInventoryItems"].Status)
+	assert.Empty(t, final.LastInProgressPluginID)
+}
+
+// blockingPlugin runs until its step is cancelled
+type blockingPlugin struct {
+	started chan bool
+}
+
+func (p *blockingPlugin) Create(context context.T) (runpluginutil.T, error) {
+	return p, nil
+}
+
+func (p *blockingPlugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	p.started <- true
+	cancelFlag.Wait()
+	output.MarkAsCancelled()
+}
+
+// TestBasicExecuterCancelDocument tests that cancelling a running document by its id cancels the running step
+// and does not run the remaining steps
+func TestBasicExecuterCancelDocument(t *testing.T) {
+	plugin := &blockingPlugin{started: make(chan bool, 1)}
+	pluginIDs := []string{"blockingStep", "secondStep", "thirdStep"}
+	docState := contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{DocumentID: "cancelDocumentID", MessageID: "MessageID"},
+		DocumentType:        contracts.SendCommand,
+		IOConfig:            contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()},
+	}
+	for _, pluginID := range pluginIDs {
+		docState.InstancePluginsInformation = append(docState.InstancePluginsInformation, contracts.PluginState{
+			Name:          appconfig.PluginNameAwsRunShellScript,
+			Id:            pluginID,
+			Configuration: contracts.Configuration{PluginName: appconfig.PluginNameAwsRunShellScript, PluginID: pluginID},
+		})
+	}
+	dataStoreMock := new(executermock.MockDocumentStore)
+	dataStoreMock.On("Load").Return(docState)
+	dataStoreMock.On("Save", mock.Anything).Return()
+	pluginRunner = func(context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag) map[string]*contracts.PluginResult {
+		registry := runpluginutil.PluginRegistry{appconfig.PluginNameAwsRunShellScript: plugin}
+		return runpluginutil.RunPlugins(context, docState.InstancePluginsInformation, docState.IOConfig, docState.UpstreamServiceName, registry, resChan, cancelFlag)
+	}
+
+	resChan := NewBasicExecuter(contextmocks.NewMockDefault()).Run(task.NewChanneledCancelFlag(), dataStoreMock)
+	<-plugin.started
+	assert.NoError(t, executer.CancelDocument("cancelDocumentID"))
+
+	var final contracts.DocumentResult
+	for res := range resChan {
+		if res.LastPlugin == "" {
+			final = res
+		}
+	}
+
+	assert.Equal(t, contracts.ResultStatusCancelled, final.Status)
+	for _, pluginID := range pluginIDs {
+		assert.Equal(t, contracts.ResultStatusCancelled, final.PluginResults[pluginID].Status, pluginID)
+	}
+	assert.Contains(t, final.PluginResults["secondStep"].Output, "not run because the document was cancelled")
+	assert.Empty(t, plugin.started)
+	assert.Eventually(t, func() bool {
+		return executer.CancelDocument("cancelDocumentID") != nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+// recordingPlugin records the steps it runs, calling beforeExecute first when set
+type recordingPlugin struct {
+	lock          sync.Mutex
+	steps         []string
+	beforeExecute func(stepID string)
+}
+
+func (p *recordingPlugin) Create(context context.T) (runpluginutil.T, error) {
+	return p, nil
+}
+
+func (p *recordingPlugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	if p.beforeExecute != nil {
+		p.beforeExecute(config.PluginID)
+	}
+	p.lock.Lock()
+	p.steps = append(p.steps, config.PluginID)
+	p.lock.Unlock()
+	output.MarkAsSucceeded()
+}
+
+func runRecordedDocument(plugin *recordingPlugin, docStore executer.DocumentStore) contracts.DocumentResult {
+	pluginRunner = func(context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag) map[string]*contracts.PluginResult {
+		registry := runpluginutil.PluginRegistry{appconfig.PluginNameAwsRunShellScript: plugin}
+		return runpluginutil.RunPlugins(context, docState.InstancePluginsInformation, docState.IOConfig, docState.UpstreamServiceName, registry, resChan, cancelFlag)
+	}
+	var final contracts.DocumentResult
+	for res := range NewBasicExecuter(contextmocks.NewMockDefault()).Run(task.NewChanneledCancelFlag(), docStore) {
+		if res.LastPlugin == "" {
+			final = res
+		}
+	}
+	return final
+}
+
+// TestBasicExecuterResumesFromCheckpoint tests that the state saved after the first step lets a restarted document
+// run the second step only
+func TestBasicExecuterResumesFromCheckpoint(t *testing.T) {
+	docState := contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{DocumentID: "checkpointDocumentID", MessageID: "MessageID"},
+		DocumentType:        contracts.SendCommand,
+		IOConfig:            contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()},
+	}
+	for _, pluginID := range []string{"firstStep", "secondStep"} {
+		docState.InstancePluginsInformation = append(docState.InstancePluginsInformation, contracts.PluginState{
+			Name:          appconfig.PluginNameAwsRunShellScript,
+			Id:            pluginID,
+			Configuration: contracts.Configuration{PluginName: appconfig.PluginNameAwsRunShellScript, PluginID: pluginID},
+		})
+	}
+
+	// the agent stops while the second step runs, leaving the state saved by then
+	docStore := executertest.NewFakeDocumentStore(docState)
+	var checkpoint contracts.DocumentState
+	runRecordedDocument(&recordingPlugin{beforeExecute: func(stepID string) {
+		if stepID == "secondStep" {
+			assert.Eventually(t, func() bool { return docStore.SaveCount() > 0 }, time.Second, 10*time.Millisecond)
+			// copy the steps as persisting them would, the executer keeps updating its own state
+			checkpoint = docStore.Load()
+			checkpoint.InstancePluginsInformation = append([]contracts.PluginState(nil), checkpoint.InstancePluginsInformation...)
+		}
+	}}, docStore)
+	assert.Equal(t, contracts.ResultStatusSuccess, checkpoint.InstancePluginsInformation[0].Result.Status)
+	assert.Empty(t, checkpoint.InstancePluginsInformation[1].Result.Status)
+
+	restartedPlugin := &recordingPlugin{}
+	final := runRecordedDocument(restartedPlugin, executertest.NewFakeDocumentStore(checkpoint))
+
+	assert.Equal(t, []string{"secondStep"}, restartedPlugin.steps)
+	assert.Equal(t, contracts.ResultStatusSuccess, final.Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, final.PluginResults["firstStep"].Status)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package executertest provides fakes of the executer interfaces for testing code built on top of the executer.
+//
+// FakeExecuter replays the document results it is seeded with instead of running the document:
+//
+//	fakeExecuter := executertest.NewFakeExecuter(inProgressResult, completedResult)
+//	docStore := executertest.NewFakeDocumentStore(docState)
+//	for result := range fakeExecuter.Run(task.NewChanneledCancelFlag(), docStore) {
+//		// handle the result as the results of a real executer
+//	}
+//
+// Unlike the testify based mocks of the executer/mock package, the fakes need no expectations to be set up.
+package executertest
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// FakeExecuter is an executer.Executer whose Run sends a pre-seeded sequence of document results
+type FakeExecuter struct {
+	lock       sync.Mutex
+	results    []contracts.DocumentResult
+	docStores  []executer.DocumentStore
+	cancelFlag task.CancelFlag
+}
+
+// NewFakeExecuter returns a FakeExecuter sending the given results, in order, on every run
+func NewFakeExecuter(results ...contracts.DocumentResult) *FakeExecuter {
+	return &FakeExecuter{results: results}
+}
+
+// Run records the document store and returns a channel holding the seeded results, which is closed after the last one
+func (e *FakeExecuter) Run(cancelFlag task.CancelFlag, docStore executer.DocumentStore) chan contracts.DocumentResult {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.docStores = append(e.docStores, docStore)
+	e.cancelFlag = cancelFlag
+
+	resChan := make(chan contracts.DocumentResult, len(e.results))
+	for _, result := range e.results {
+		resChan <- result
+	}
+	close(resChan)
+	return resChan
+}
+
+// DocumentStores returns the document stores given to Run, one per run
+func (e *FakeExecuter) DocumentStores() []executer.DocumentStore {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]executer.DocumentStore(nil), e.docStores...)
+}
+
+// CancelFlag returns the cancel flag given to the last run, nil if Run was not called
+func (e *FakeExecuter) CancelFlag() task.CancelFlag {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.cancelFlag
+}
+
+// FakeDocumentStore is an executer.DocumentStore keeping the document state in memory
+type FakeDocumentStore struct {
+	lock  sync.Mutex
+	state contracts.DocumentState
+	saves int
+}
+
+// NewFakeDocumentStore returns a FakeDocumentStore loading the given state
+func NewFakeDocumentStore(state contracts.DocumentState) *FakeDocumentStore {
+	return &FakeDocumentStore{state: state}
+}
+
+// Save replaces the stored document state
+func (s *FakeDocumentStore) Save(state contracts.DocumentState) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.state = state
+	s.saves++
+}
+
+// Load returns the stored document state
+func (s *FakeDocumentStore) Load() contracts.DocumentState {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state
+}
+
+// SaveCount returns how many times Save was called
+func (s *FakeDocumentStore) SaveCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.saves
+}
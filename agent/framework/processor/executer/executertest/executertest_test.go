@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executertest
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// the fakes must keep implementing the executer interfaces
+var (
+	_ executer.Executer      = (*FakeExecuter)(nil)
+	_ executer.DocumentStore = (*FakeDocumentStore)(nil)
+)
+
+func TestFakeExecuterDeliversSeededResultsAndClosesChannel(t *testing.T) {
+	inProgress := contracts.DocumentResult{MessageID: "message", Status: contracts.ResultStatusInProgress, LastPlugin: "step1"}
+	completed := contracts.DocumentResult{MessageID: "message", Status: contracts.ResultStatusSuccess}
+	fakeExecuter := NewFakeExecuter(inProgress, completed)
+	docStore := NewFakeDocumentStore(contracts.DocumentState{})
+	cancelFlag := task.NewChanneledCancelFlag()
+
+	var received []contracts.DocumentResult
+	for result := range fakeExecuter.Run(cancelFlag, docStore) {
+		received = append(received, result)
+	}
+
+	assert.Equal(t, []contracts.DocumentResult{inProgress, completed}, received)
+	assert.Equal(t, []executer.DocumentStore{docStore}, fakeExecuter.DocumentStores())
+	assert.Equal(t, cancelFlag, fakeExecuter.CancelFlag())
+}
+
+func TestFakeExecuterReplaysResultsOnEveryRun(t *testing.T) {
+	completed := contracts.DocumentResult{MessageID: "message", Status: contracts.ResultStatusSuccess}
+	fakeExecuter := NewFakeExecuter(completed)
+
+	for i := 0; i < 2; i++ {
+		resChan := fakeExecuter.Run(task.NewChanneledCancelFlag(), NewFakeDocumentStore(contracts.DocumentState{}))
+		assert.Equal(t, completed, <-resChan)
+		_, open := <-resChan
+		assert.False(t, open)
+	}
+	assert.Len(t, fakeExecuter.DocumentStores(), 2)
+}
+
+func TestFakeExecuterWithoutResultsClosesChannel(t *testing.T) {
+	_, open := <-NewFakeExecuter().Run(task.NewChanneledCancelFlag(), NewFakeDocumentStore(contracts.DocumentState{}))
+
+	assert.False(t, open)
+}
+
+func TestFakeDocumentStore(t *testing.T) {
+	initial := contracts.DocumentState{DocumentInformation: contracts.DocumentInfo{DocumentID: "initial"}}
+	saved := contracts.DocumentState{DocumentInformation: contracts.DocumentInfo{DocumentID: "saved"}}
+	docStore := NewFakeDocumentStore(initial)
+
+	assert.Equal(t, initial, docStore.Load())
+	docStore.Save(saved)
+	assert.Equal(t, saved, docStore.Load())
+	assert.Equal(t, 1, docStore.SaveCount())
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"runtime/debug"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -95,6 +96,13 @@ type IOHandler interface {
 	SetOutput(interface{})
 	SetStdout(string)
 	SetStderr(string)
+
+	AddResourceUsage(contracts.ResourceUsage)
+	GetResourceUsage() *contracts.ResourceUsage
+	SetResolvedInput(string)
+	GetResolvedInput() string
+	SetProcessID(int)
+	GetProcessID() int
 }
 
 // DefaultIOHandler is used for writing output by the plugins
@@ -108,6 +116,16 @@ type DefaultIOHandler struct {
 	ioConfig contracts.IOConfiguration
 	//refreshassociation and invoker write a different output rather than merging stdout and stderr
 	output interface{}
+	// resources consumed by the processes started by the plugin, nil if no process was started
+	resourceUsage *contracts.ResourceUsage
+	// command run by the plugin after parameter substitution
+	resolvedInput string
+	// ID of the last process started by the plugin, 0 if no process was started
+	processID int
+	// filters applied to the lines written to stdout and stderr before they reach the output modules
+	outputFilters []multiwriter.LineFilter
+	// filters applied to the lines written to stdout before the output filters
+	stdoutFilters []multiwriter.LineFilter
 
 	// List of Writers attached to the IOHandler instance
 	StdoutWriter multiwriter.DocumentIOMultiWriter
@@ -152,12 +170,14 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 
 	// Initialize file output module
 	stdoutFile := iomodule.File{
-		FileName:               pluginConfig.StdoutFileName,
-		OrchestrationDirectory: fullPath,
-		OutputS3BucketName:     out.ioConfig.OutputS3BucketName,
-		OutputS3KeyPrefix:      s3KeyPrefix,
-		LogGroupName:           out.ioConfig.CloudWatchConfig.LogGroupName,
-		LogStreamName:          stdOutLogStreamName,
+		FileName:                  pluginConfig.StdoutFileName,
+		OrchestrationDirectory:    fullPath,
+		OutputS3BucketName:        out.ioConfig.OutputS3BucketName,
+		OutputS3KeyPrefix:         s3KeyPrefix,
+		CompressS3Output:          out.ioConfig.CompressS3Output,
+		LogGroupName:              out.ioConfig.CloudWatchConfig.LogGroupName,
+		IncrementalUploadInterval: time.Duration(out.ioConfig.IncrementalUploadIntervalSeconds) * time.Second,
+		LogStreamName:             stdOutLogStreamName,
 	}
 
 	// Initialize console output module
@@ -165,21 +185,25 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 		OutputString:           &out.stdout,
 		FileName:               pluginConfig.StdoutConsoleFileName,
 		OrchestrationDirectory: fullPath,
+		MaxOutputSize:          out.context.AppConfig().Ssm.PluginOutputMaxSizeBytes,
 	}
 
 	log.Debug("Initializing the Stdout Multi-writer with file and console listeners")
 	// Get a multi-writer for standard output
-	out.StdoutWriter = multiwriter.NewDocumentIOMultiWriter()
+	stdoutFilters := append(append([]multiwriter.LineFilter{}, out.stdoutFilters...), out.outputFilters...)
+	out.StdoutWriter = multiwriter.NewDocumentIOMultiWriter(stdoutFilters...)
 	out.RegisterOutputSource(out.StdoutWriter, stdoutFile, stdoutConsole)
 
 	// Initialize file error module
 	stderrFile := iomodule.File{
-		FileName:               pluginConfig.StderrFileName,
-		OrchestrationDirectory: fullPath,
-		OutputS3BucketName:     out.ioConfig.OutputS3BucketName,
-		OutputS3KeyPrefix:      s3KeyPrefix,
-		LogGroupName:           out.ioConfig.CloudWatchConfig.LogGroupName,
-		LogStreamName:          stdErrLogStreamName,
+		FileName:                  pluginConfig.StderrFileName,
+		OrchestrationDirectory:    fullPath,
+		OutputS3BucketName:        out.ioConfig.OutputS3BucketName,
+		OutputS3KeyPrefix:         s3KeyPrefix,
+		CompressS3Output:          out.ioConfig.CompressS3Output,
+		LogGroupName:              out.ioConfig.CloudWatchConfig.LogGroupName,
+		IncrementalUploadInterval: time.Duration(out.ioConfig.IncrementalUploadIntervalSeconds) * time.Second,
+		LogStreamName:             stdErrLogStreamName,
 	}
 
 	// Initialize console error module
@@ -187,14 +211,27 @@ func (out *DefaultIOHandler) Init(filePath ...string) {
 		OutputString:           &out.stderr,
 		FileName:               pluginConfig.StderrConsoleFileName,
 		OrchestrationDirectory: fullPath,
+		MaxOutputSize:          out.context.AppConfig().Ssm.PluginOutputMaxSizeBytes,
 	}
 
 	log.Debug("Initializing the Stderr Multi-writer with file and console listeners")
 	// Get a multi-writer for standard error
-	out.StderrWriter = multiwriter.NewDocumentIOMultiWriter()
+	out.StderrWriter = multiwriter.NewDocumentIOMultiWriter(out.outputFilters...)
 	out.RegisterOutputSource(out.StderrWriter, stderrFile, stderrConsole)
 }
 
+// AddOutputFilter adds a filter applied to the lines written to stdout and stderr before they reach the files,
+// the S3 and CloudWatch uploads and the step output. Filters must be added before Init.
+func (out *DefaultIOHandler) AddOutputFilter(filter multiwriter.LineFilter) {
+	out.outputFilters = append(out.outputFilters, filter)
+}
+
+// AddStdoutFilter adds a filter applied to the lines written to stdout before the output filters.
+// Filters must be added before Init.
+func (out *DefaultIOHandler) AddStdoutFilter(filter multiwriter.LineFilter) {
+	out.stdoutFilters = append(out.stdoutFilters, filter)
+}
+
 // RegisterOutputSource returns a new output source by creating a multiwriter for the output modules.
 func (out *DefaultIOHandler) RegisterOutputSource(multiWriter multiwriter.DocumentIOMultiWriter, IOModules ...iomodule.IOModule) {
 	if len(IOModules) == 0 {
@@ -203,6 +240,7 @@ func (out *DefaultIOHandler) RegisterOutputSource(multiWriter multiwriter.Docume
 
 	log := out.context.Log()
 	wg := multiWriter.GetWaitGroup()
+	exitCode := out.ExitCode
 	// Create a Pipe for each IO Module and add it to the multi-writer.
 	for _, module := range IOModules {
 		r, w := io.Pipe()
@@ -217,7 +255,7 @@ func (out *DefaultIOHandler) RegisterOutputSource(multiWriter multiwriter.Docume
 				}
 			}()
 			defer wg.Done()
-			module.Read(out.context, r, out.ExitCode)
+			module.Read(out.context, r, exitCode)
 		}(module, r)
 	}
 
@@ -316,6 +354,39 @@ func (out *DefaultIOHandler) SetOutput(output interface{}) {
 	out.output = output
 }
 
+// AddResourceUsage accumulates the resource usage of a process started by the plugin
+func (out *DefaultIOHandler) AddResourceUsage(usage contracts.ResourceUsage) {
+	if out.resourceUsage == nil {
+		out.resourceUsage = &contracts.ResourceUsage{}
+	}
+	out.resourceUsage.Add(usage)
+}
+
+// GetResourceUsage returns the accumulated resource usage, nil if no process was started
+func (out DefaultIOHandler) GetResourceUsage() *contracts.ResourceUsage {
+	return out.resourceUsage
+}
+
+// SetResolvedInput sets the command run by the plugin after parameter substitution
+func (out *DefaultIOHandler) SetResolvedInput(resolvedInput string) {
+	out.resolvedInput = resolvedInput
+}
+
+// GetResolvedInput returns the command run by the plugin after parameter substitution
+func (out DefaultIOHandler) GetResolvedInput() string {
+	return out.resolvedInput
+}
+
+// SetProcessID sets the ID of the process started by the plugin
+func (out *DefaultIOHandler) SetProcessID(pid int) {
+	out.processID = pid
+}
+
+// GetProcessID returns the ID of the last process started by the plugin, 0 if no process was started
+func (out DefaultIOHandler) GetProcessID() int {
+	return out.processID
+}
+
 // Merge plugin output objects
 func (out *DefaultIOHandler) Merge(mergeOutput *DefaultIOHandler) {
 
@@ -339,6 +410,18 @@ func (out *DefaultIOHandler) Merge(mergeOutput *DefaultIOHandler) {
 		out.ExitCode = mergeOutput.GetExitCode()
 	}
 	out.Status = contracts.MergeResultStatus(out.Status, mergeOutput.GetStatus())
+	if mergeOutput.GetResourceUsage() != nil {
+		out.AddResourceUsage(*mergeOutput.GetResourceUsage())
+	}
+	if resolvedInput := mergeOutput.GetResolvedInput(); resolvedInput != "" {
+		if out.resolvedInput != "" {
+			out.resolvedInput += "\n"
+		}
+		out.resolvedInput += resolvedInput
+	}
+	if processID := mergeOutput.GetProcessID(); processID != 0 {
+		out.processID = processID
+	}
 }
 
 // MarkAsFailed Failed marks plugin as Failed
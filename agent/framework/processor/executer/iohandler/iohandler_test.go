@@ -15,6 +15,8 @@ package iohandler
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -147,6 +149,39 @@ func TestMarkAsSuccessWithReboot(t *testing.T) {
 	assert.True(t, output.Status.IsReboot())
 }
 
+func TestAddResourceUsage(t *testing.T) {
+	output := DefaultIOHandler{}
+	assert.Nil(t, output.GetResourceUsage())
+
+	output.AddResourceUsage(contracts.ResourceUsage{PeakMemoryBytes: 2048, UserCPUTimeMillis: 10, SystemCPUTimeMillis: 1})
+	output.AddResourceUsage(contracts.ResourceUsage{PeakMemoryBytes: 1024, UserCPUTimeMillis: 5, SystemCPUTimeMillis: 2})
+
+	assert.Equal(t, &contracts.ResourceUsage{PeakMemoryBytes: 2048, UserCPUTimeMillis: 15, SystemCPUTimeMillis: 3}, output.GetResourceUsage())
+}
+
+func TestMergeResourceUsage(t *testing.T) {
+	output := DefaultIOHandler{}
+	mergeOutput := DefaultIOHandler{}
+	mergeOutput.AddResourceUsage(contracts.ResourceUsage{PeakMemoryBytes: 4096, UserCPUTimeMillis: 7})
+
+	output.Merge(&mergeOutput)
+
+	assert.Equal(t, &contracts.ResourceUsage{PeakMemoryBytes: 4096, UserCPUTimeMillis: 7}, output.GetResourceUsage())
+}
+
+func TestMergeProcessID(t *testing.T) {
+	output := DefaultIOHandler{}
+	output.SetProcessID(100)
+
+	output.Merge(&DefaultIOHandler{})
+	assert.Equal(t, 100, output.GetProcessID())
+
+	mergeOutput := DefaultIOHandler{}
+	mergeOutput.SetProcessID(200)
+	output.Merge(&mergeOutput)
+	assert.Equal(t, 200, output.GetProcessID())
+}
+
 func TestAppendInfo(t *testing.T) {
 	output := DefaultIOHandler{}
 
@@ -157,6 +192,44 @@ func TestAppendInfo(t *testing.T) {
 	assert.Contains(t, output.GetStdout(), "Second entry")
 }
 
+func TestOutputFiltersApplyToOutputFiles(t *testing.T) {
+	orchestrationDir := t.TempDir()
+	output := NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.AddOutputFilter(func(line string) (string, bool) { return strings.Replace(line, "secret", "****", -1), true })
+	output.Init()
+
+	output.AppendInfo("stdout secret")
+	output.AppendError("stderr secret")
+	output.Close()
+
+	assert.Equal(t, "stdout ****", output.GetStdout())
+	assert.Equal(t, "stderr ****", output.GetStderr())
+	for _, fileName := range []string{"stdout", "stderr"} {
+		content, err := os.ReadFile(filepath.Join(orchestrationDir, fileName))
+		assert.NoError(t, err)
+		assert.NotContains(t, string(content), "secret")
+		assert.Contains(t, string(content), "****")
+	}
+}
+
+func TestStdoutFiltersApplyBeforeOutputFilters(t *testing.T) {
+	orchestrationDir := t.TempDir()
+	output := NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.AddOutputFilter(func(line string) (string, bool) { return strings.Replace(line, "secret", "****", -1), true })
+	output.AddStdoutFilter(func(line string) (string, bool) { return line, !strings.HasPrefix(line, "drop") })
+	output.Init()
+
+	output.GetStdoutWriter().WriteString("drop secret\nkeep secret\n")
+	output.GetStderrWriter().WriteString("drop secret\n")
+	output.Close()
+
+	assert.Equal(t, "keep ****\n", output.GetStdout())
+	assert.Equal(t, "drop ****\n", output.GetStderr())
+	content, err := os.ReadFile(filepath.Join(orchestrationDir, "stdout"))
+	assert.NoError(t, err)
+	assert.Equal(t, "keep ****\n", string(content))
+}
+
 func TestAppendSpecialChars(t *testing.T) {
 	output := DefaultIOHandler{}
 
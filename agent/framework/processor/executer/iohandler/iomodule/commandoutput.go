@@ -15,7 +15,9 @@ package iomodule
 
 import (
 	"bufio"
+	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 
@@ -24,11 +26,29 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 )
 
+// outputTruncatedMarkerFormat is appended to the captured output when it exceeds MaxOutputSize
+const outputTruncatedMarkerFormat = "\n---output truncated (%d bytes omitted)---"
+
 // CommandOutput handles writing output to a string.
 type CommandOutput struct {
 	OutputString           *string
 	FileName               string
 	OrchestrationDirectory string
+	// MaxOutputSize caps the size of OutputString, including the truncation marker. Zero means no limit.
+	MaxOutputSize int
+}
+
+// capturedOutputLimit returns how many bytes of the stream are kept so that the output
+// and its truncation marker fit within MaxOutputSize, or -1 when the output is not limited.
+func (c CommandOutput) capturedOutputLimit() int {
+	if c.MaxOutputSize <= 0 {
+		return -1
+	}
+	limit := c.MaxOutputSize - len(fmt.Sprintf(outputTruncatedMarkerFormat, math.MaxInt64))
+	if limit < 0 {
+		return 0
+	}
+	return limit
 }
 
 // CleanUp cleans up local files according to PluginLocalOutputCleanup app config
@@ -59,13 +79,20 @@ func (c CommandOutput) Read(context context.T, reader *io.PipeReader, exitCode i
 
 	defer fileWriter.Close()
 
-	// Read byte by byte and write to file
+	// Read byte by byte and write to file, dropping the bytes beyond the limit
+	limit := c.capturedOutputLimit()
+	var captured, omitted int64
 	scanner := bufio.NewScanner(reader)
 	scanner.Split(bufio.ScanBytes)
 	for scanner.Scan() {
-		if _, err = fileWriter.Write([]byte(scanner.Text())); err != nil {
+		if limit >= 0 && captured >= int64(limit) {
+			omitted++
+			continue
+		}
+		if _, err = fileWriter.Write(scanner.Bytes()); err != nil {
 			log.Errorf("Failed to write the message to stdoutConsoleFile: %v", err)
 		}
+		captured++
 	}
 
 	// Check if scanner exited because of an error
@@ -86,4 +113,9 @@ func (c CommandOutput) Read(context context.T, reader *io.PipeReader, exitCode i
 			log.Errorf("Error reading %v at path %v", c.FileName, filePath)
 		}
 	}
+
+	if omitted > 0 {
+		log.Warnf("%v exceeded %v bytes, %v bytes were omitted", c.FileName, c.MaxOutputSize, omitted)
+		*c.OutputString += fmt.Sprintf(outputTruncatedMarkerFormat, omitted)
+	}
 }
@@ -1,8 +1,10 @@
 package iomodule
 
 import (
+	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 
@@ -63,3 +65,59 @@ func testFileCommandOutput(context context.T, pipeTestCase string, i int) string
 	return stdout
 
 }
+
+// TestCommandOutputTruncation tests that the CommandOutput module caps the captured output
+func TestCommandOutputTruncation(t *testing.T) {
+	context := contextmocks.NewMockDefault()
+	maxOutputSize := 2500
+	input := strings.Repeat("0123456789", 1000)
+
+	var stdout string
+	r, w := io.Pipe()
+	wg := new(sync.WaitGroup)
+	stdoutConsole := CommandOutput{
+		OutputString:           &stdout,
+		FileName:               "truncated",
+		OrchestrationDirectory: t.TempDir(),
+		MaxOutputSize:          maxOutputSize,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stdoutConsole.Read(context, r, appconfig.SuccessExitCode)
+	}()
+	w.Write([]byte(input))
+	w.Close()
+	wg.Wait()
+
+	kept := stdoutConsole.capturedOutputLimit()
+	assert.True(t, len(stdout) <= maxOutputSize)
+	assert.Equal(t, input[:kept], stdout[:kept])
+	assert.Equal(t, fmt.Sprintf("\n---output truncated (%d bytes omitted)---", len(input)-kept), stdout[kept:])
+}
+
+// TestCommandOutputUnderLimit tests that output within the limit is not changed
+func TestCommandOutputUnderLimit(t *testing.T) {
+	context := contextmocks.NewMockDefault()
+	input := strings.Repeat("0123456789", 100)
+
+	var stdout string
+	r, w := io.Pipe()
+	wg := new(sync.WaitGroup)
+	stdoutConsole := CommandOutput{
+		OutputString:           &stdout,
+		FileName:               "untruncated",
+		OrchestrationDirectory: t.TempDir(),
+		MaxOutputSize:          2500,
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stdoutConsole.Read(context, r, appconfig.SuccessExitCode)
+	}()
+	w.Write([]byte(input))
+	w.Close()
+	wg.Wait()
+
+	assert.Equal(t, input, stdout)
+}
@@ -15,6 +15,7 @@ package iomodule
 
 import (
 	"bufio"
+	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
@@ -23,10 +24,14 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
 )
 
 const (
 	maxCloudWatchUploadRetry = 60
+
+	gzipExtension       = ".gz"
+	gzipContentEncoding = "gzip"
 )
 
 // File handles writing to an output file and upload to s3 and cloudWatch
@@ -35,8 +40,12 @@ type File struct {
 	OrchestrationDirectory string
 	OutputS3BucketName     string
 	OutputS3KeyPrefix      string
+	CompressS3Output       bool
 	LogGroupName           string
 	LogStreamName          string
+	// IncrementalUploadInterval uploads the output written so far to S3 at this interval while the plugin runs.
+	// 0 only uploads the output once the plugin completes.
+	IncrementalUploadInterval time.Duration
 }
 
 // CleanUp cleans up local files according to PluginLocalOutputCleanup app config
@@ -62,6 +71,10 @@ func (file File) cleanUp(context context.T, uploadComplete bool, exitCode int) {
 // Read reads from the stream and writes to the output file, s3 and CloudWatchLogs.
 func (file File) Read(context context.T, reader *io.PipeReader, exitCode int) {
 	uploadComplete := false
+	if file.OutputS3BucketName != "" {
+		pendingUploads.begin()
+		defer pendingUploads.end()
+	}
 	log := context.Log()
 	defer func() { reader.Close() }()
 	defer func() { file.cleanUp(context, uploadComplete, exitCode) }()
@@ -99,6 +112,19 @@ func (file File) Read(context context.T, reader *io.PipeReader, exitCode int) {
 			false)
 	}
 
+	stopIncrementalUpload := func() {}
+	if file.OutputS3BucketName != "" && file.IncrementalUploadInterval > 0 {
+		done, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			file.uploadIncrementally(context, filePath, done)
+		}()
+		stopIncrementalUpload = func() {
+			close(done)
+			<-stopped
+		}
+	}
+
 	// Read byte by byte and write to file
 	scanner := bufio.NewScanner(reader)
 	scanner.Split(bufio.ScanBytes)
@@ -113,6 +139,9 @@ func (file File) Read(context context.T, reader *io.PipeReader, exitCode int) {
 		log.Error("Error with the scanner while reading the stream")
 	}
 
+	// the final upload below must not race with a partial one
+	stopIncrementalUpload()
+
 	fi, err := fileWriter.Stat()
 	if err != nil {
 		log.Errorf("Failed to get file stat: %v", err)
@@ -121,13 +150,10 @@ func (file File) Read(context context.T, reader *io.PipeReader, exitCode int) {
 
 	// Upload output file to S3
 	if file.OutputS3BucketName != "" && fi.Size() > 0 {
-		s3Key := fileutil.BuildS3Path(file.OutputS3KeyPrefix, file.FileName)
-		if s3, err := s3ServiceRetriever.NewAmazonS3Util(context, file.OutputS3BucketName); err == nil {
-			if err := s3.S3Upload(log, file.OutputS3BucketName, s3Key, filePath); err != nil {
-				log.Errorf("Failed to upload the output to s3: %v", err)
-			} else {
-				uploadComplete = true
-			}
+		if err = file.uploadToS3(context, filePath); err != nil {
+			log.Errorf("Failed to upload the output to s3: %v", err)
+		} else {
+			uploadComplete = true
 		}
 	}
 
@@ -144,3 +170,84 @@ func (file File) Read(context context.T, reader *io.PipeReader, exitCode int) {
 		uploadComplete = uploadComplete || cwl.GetIsUploadComplete()
 	}
 }
+
+// uploadToS3 uploads the output file to the S3 key of the file, overwriting any previous upload
+func (file File) uploadToS3(context context.T, filePath string) error {
+	s3, err := s3ServiceRetriever.NewAmazonS3Util(context, file.OutputS3BucketName)
+	if err != nil {
+		return err
+	}
+	s3Key := fileutil.BuildS3Path(file.OutputS3KeyPrefix, file.FileName)
+	if file.CompressS3Output {
+		return file.uploadCompressed(context.Log(), s3, s3Key, filePath)
+	}
+	return s3.S3Upload(context.Log(), file.OutputS3BucketName, s3Key, filePath)
+}
+
+// uploadIncrementally uploads the output file at every IncrementalUploadInterval until done is closed.
+// The upload is skipped when nothing was written since the previous one.
+func (file File) uploadIncrementally(context context.T, filePath string, done <-chan struct{}) {
+	log := context.Log()
+	ticker := time.NewTicker(file.IncrementalUploadInterval)
+	defer ticker.Stop()
+
+	var uploadedSize int64
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		fi, err := os.Stat(filePath)
+		if err != nil || fi.Size() == uploadedSize {
+			continue
+		}
+		if err = file.uploadToS3(context, filePath); err != nil {
+			log.Warnf("Failed to upload the partial output to s3: %v", err)
+			continue
+		}
+		uploadedSize = fi.Size()
+	}
+}
+
+// uploadCompressed gzips the output file next to the original and uploads it with a .gz suffix
+func (file File) uploadCompressed(log log.T, s3 IS3Util, s3Key string, filePath string) error {
+	compressedFilePath := filePath + gzipExtension
+	if err := compressFile(filePath, compressedFilePath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := fileutil.DeleteFile(compressedFilePath); err != nil {
+			log.Warnf("failed to delete compressed output file %s: %v", compressedFilePath, err)
+		}
+	}()
+
+	return s3.S3UploadWithContentEncoding(log, file.OutputS3BucketName, s3Key+gzipExtension, compressedFilePath, gzipContentEncoding)
+}
+
+// compressFile writes a gzip compressed copy of src to dest
+func compressFile(src string, dest string) (err error) {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	destFile, err := os.OpenFile(dest, appconfig.FileFlagsCreateOrTruncate, appconfig.ReadWriteAccess)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := destFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(destFile)
+	if _, err = io.Copy(gzipWriter, srcFile); err != nil {
+		gzipWriter.Close()
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -35,6 +35,7 @@ type ICloudWatchLoggingService interface {
 
 type IS3Util interface {
 	S3Upload(logger log.T, outputS3BucketName string, s3Key string, filePath string) error
+	S3UploadWithContentEncoding(logger log.T, outputS3BucketName string, s3Key string, filePath string, contentEncoding string) error
 }
 
 type cwServiceRetriever struct{}
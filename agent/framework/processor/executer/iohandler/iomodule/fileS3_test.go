@@ -1,6 +1,8 @@
 package iomodule
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +10,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
@@ -260,6 +263,143 @@ func TestFileS3DefaultPluginOutputRetention(t *testing.T) {
 	assert.True(t, outputFileExists)
 }
 
+func TestFileS3ReadCompressesOutputBeforeUpload(t *testing.T) {
+	file := File{
+		FileName:               "TestFileS3ReadCompressesOutputBeforeUpload",
+		OrchestrationDirectory: "testdata",
+		OutputS3BucketName:     "bucket-to-upload-to",
+		OutputS3KeyPrefix:      "s3KeyPrefix",
+		CompressS3Output:       true,
+	}
+	testOutput := "A sample \ninput text that should be compressed before upload.\n"
+
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.PluginLocalOutputCleanup = appconfig.DefaultPluginOutputRetention
+	var context = contextmocks.NewMockDefaultWithConfig(config)
+
+	r, w := io.Pipe()
+	wg := new(sync.WaitGroup)
+	filePath := filepath.Join(file.OrchestrationDirectory, file.FileName)
+	compressedFilePath := filePath + ".gz"
+	s3Key := fileutil.BuildS3Path(file.OutputS3KeyPrefix, file.FileName) + ".gz"
+
+	var uploadedBytes []byte
+	var mockS3Util = &s3UtilMock{}
+	mockS3Util.On("S3UploadWithContentEncoding", mock.AnythingOfType("*log.Mock"), file.OutputS3BucketName, s3Key, compressedFilePath, "gzip").
+		Run(func(args mock.Arguments) {
+			uploadedBytes, _ = os.ReadFile(args.String(3))
+		}).Return(nil)
+
+	var s3RetrieverMock = &s3LogsServiceRetrieverMock{}
+	s3RetrieverMock.On("NewAmazonS3Util", mock.AnythingOfType("*context.Mock"), file.OutputS3BucketName).Return(mockS3Util, nil)
+	s3ServiceRetriever = s3RetrieverMock
+
+	var cwRetrieverMock = &cloudWatchServiceRetrieverMock{}
+	cwRetrieverMock.On("NewCloudWatchLogsService", mock.AnythingOfType("*context.Mock")).Return(&cloudWatchLoggingServiceMock{})
+	cloudWatchServiceRetriever = cwRetrieverMock
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		file.Read(context, r, appconfig.SuccessExitCode)
+	}()
+
+	w.Write([]byte(testOutput))
+	w.Close()
+	wg.Wait()
+	defer os.Remove(filePath)
+
+	mockS3Util.AssertExpectations(t)
+	mockS3Util.AssertNotCalled(t, "S3Upload", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(uploadedBytes))
+	assert.NoError(t, err)
+	decompressed, err := io.ReadAll(gzipReader)
+	assert.NoError(t, err)
+	assert.Equal(t, testOutput, string(decompressed))
+
+	// the compressed copy is only needed for the upload
+	compressedFileExists, _ := fileutil.LocalFileExist(compressedFilePath)
+	assert.False(t, compressedFileExists)
+}
+
+func TestFileS3ReadDoesNotCompressOutputByDefault(t *testing.T) {
+	file := File{
+		FileName:               "TestFileS3ReadDoesNotCompressOutputByDefault",
+		OrchestrationDirectory: "testdata",
+		OutputS3BucketName:     "bucket-to-upload-to",
+		OutputS3KeyPrefix:      "s3KeyPrefix",
+	}
+
+	outputFileExists := testFileS3Read(appconfig.DefaultPluginOutputRetention, "Test input text.", file)
+	assert.True(t, outputFileExists)
+}
+
+func TestFileS3ReadUploadsOutputIncrementally(t *testing.T) {
+	file := File{
+		FileName:                  "TestFileS3ReadUploadsOutputIncrementally",
+		OrchestrationDirectory:    "testdata",
+		OutputS3BucketName:        "bucket-to-upload-to",
+		OutputS3KeyPrefix:         "s3KeyPrefix",
+		IncrementalUploadInterval: 20 * time.Millisecond,
+	}
+
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.PluginLocalOutputCleanup = appconfig.DefaultPluginOutputRetention
+	var context = contextmocks.NewMockDefaultWithConfig(config)
+
+	r, w := io.Pipe()
+	wg := new(sync.WaitGroup)
+	filePath := filepath.Join(file.OrchestrationDirectory, file.FileName)
+	s3Key := fileutil.BuildS3Path(file.OutputS3KeyPrefix, file.FileName)
+	defer os.Remove(filePath)
+
+	var uploadsLock sync.Mutex
+	var uploads []string
+	var mockS3Util = &s3UtilMock{}
+	mockS3Util.On("S3Upload", mock.AnythingOfType("*log.Mock"), file.OutputS3BucketName, s3Key, filePath).
+		Run(func(args mock.Arguments) {
+			content, _ := os.ReadFile(args.String(3))
+			uploadsLock.Lock()
+			defer uploadsLock.Unlock()
+			uploads = append(uploads, string(content))
+		}).Return(nil)
+
+	var s3RetrieverMock = &s3LogsServiceRetrieverMock{}
+	s3RetrieverMock.On("NewAmazonS3Util", mock.AnythingOfType("*context.Mock"), file.OutputS3BucketName).Return(mockS3Util, nil)
+	s3ServiceRetriever = s3RetrieverMock
+
+	var cwRetrieverMock = &cloudWatchServiceRetrieverMock{}
+	cwRetrieverMock.On("NewCloudWatchLogsService", mock.AnythingOfType("*context.Mock")).Return(&cloudWatchLoggingServiceMock{})
+	cloudWatchServiceRetriever = cwRetrieverMock
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		file.Read(context, r, appconfig.SuccessExitCode)
+	}()
+
+	// the plugin emits a line, then keeps running long enough for a partial upload
+	for _, line := range []string{"step 1\n", "step 2\n", "step 3\n"} {
+		w.Write([]byte(line))
+		time.Sleep(100 * time.Millisecond)
+	}
+	w.Close()
+	wg.Wait()
+
+	uploadsLock.Lock()
+	defer uploadsLock.Unlock()
+	assert.GreaterOrEqual(t, len(uploads), 3, "partial uploads happen while the plugin runs")
+	assert.Equal(t, "step 1\n", uploads[0])
+	assert.Contains(t, uploads, "step 1\nstep 2\n")
+	// the final upload contains the complete output
+	assert.Equal(t, "step 1\nstep 2\nstep 3\n", uploads[len(uploads)-1])
+	// partial uploads are skipped when no output was written since the previous one
+	for i := 1; i < len(uploads)-1; i++ {
+		assert.NotEqual(t, uploads[i-1], uploads[i])
+	}
+}
+
 func testFileS3Read(pluginLocalOutputCleanupPref string, pipeTestCase string, file File) bool {
 	config := appconfig.SsmagentConfig{}
 	config.Ssm.PluginLocalOutputCleanup = pluginLocalOutputCleanupPref
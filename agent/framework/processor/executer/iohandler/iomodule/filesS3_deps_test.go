@@ -60,3 +60,8 @@ func (m *s3UtilMock) S3Upload(log log.T, outputS3BucketName string, s3Key string
 	args := m.Called(log, outputS3BucketName, s3Key, filePath)
 	return args.Error(0)
 }
+
+func (m *s3UtilMock) S3UploadWithContentEncoding(log log.T, outputS3BucketName string, s3Key string, filePath string, contentEncoding string) error {
+	args := m.Called(log, outputS3BucketName, s3Key, filePath, contentEncoding)
+	return args.Error(0)
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iomodule
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// pendingUploads tracks the output files that are still being written or uploaded to S3
+var pendingUploads = newUploadTracker()
+
+// uploadTracker counts the in-flight uploads and signals when none are left
+type uploadTracker struct {
+	lock    sync.Mutex
+	pending int
+	// idle is closed whenever no upload is pending
+	idle chan struct{}
+}
+
+func newUploadTracker() *uploadTracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &uploadTracker{idle: idle}
+}
+
+func (t *uploadTracker) begin() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.pending == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.pending++
+}
+
+func (t *uploadTracker) end() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pending--
+	if t.pending == 0 {
+		close(t.idle)
+	}
+}
+
+func (t *uploadTracker) wait(timeout time.Duration) error {
+	t.lock.Lock()
+	idle := t.idle
+	t.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-time.After(timeout):
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		return fmt.Errorf("%v output uploads to S3 did not complete within %v", t.pending, timeout)
+	}
+}
+
+// WaitForPendingUploads blocks until every output file with an S3 destination has been uploaded,
+// or returns an error once the timeout expires.
+func WaitForPendingUploads(timeout time.Duration) error {
+	return pendingUploads.wait(timeout)
+}
@@ -0,0 +1,73 @@
+package iomodule
+
+import (
+	"io"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func startSlowS3Upload(t *testing.T, uploadDelay time.Duration) *int32 {
+	file := File{
+		FileName:               "slowUpload",
+		OrchestrationDirectory: t.TempDir(),
+		OutputS3BucketName:     "bucket-to-upload-to",
+		OutputS3KeyPrefix:      "s3KeyPrefix",
+	}
+	context := contextmocks.NewMockDefault()
+
+	var uploaded int32
+	mockS3Util := &s3UtilMock{}
+	s3Key := fileutil.BuildS3Path(file.OutputS3KeyPrefix, file.FileName)
+	filePath := filepath.Join(file.OrchestrationDirectory, file.FileName)
+	mockS3Util.On("S3Upload", mock.Anything, file.OutputS3BucketName, s3Key, filePath).
+		After(uploadDelay).
+		Run(func(mock.Arguments) { atomic.StoreInt32(&uploaded, 1) }).
+		Return(nil)
+	s3RetrieverMock := &s3LogsServiceRetrieverMock{}
+	s3RetrieverMock.On("NewAmazonS3Util", mock.Anything, file.OutputS3BucketName).Return(mockS3Util, nil)
+	s3ServiceRetriever = s3RetrieverMock
+	cwRetrieverMock := &cloudWatchServiceRetrieverMock{}
+	cwRetrieverMock.On("NewCloudWatchLogsService", mock.Anything).Return(&cloudWatchLoggingServiceMock{})
+	cloudWatchServiceRetriever = cwRetrieverMock
+
+	r, w := io.Pipe()
+	go file.Read(context, r, appconfig.SuccessExitCode)
+	w.Write([]byte("Test input text."))
+	w.Close()
+	return &uploaded
+}
+
+func TestWaitForPendingUploadsWaitsForSlowUpload(t *testing.T) {
+	uploaded := startSlowS3Upload(t, 500*time.Millisecond)
+	// let the reader register the upload before draining
+	time.Sleep(50 * time.Millisecond)
+
+	err := WaitForPendingUploads(5 * time.Second)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(uploaded))
+}
+
+func TestWaitForPendingUploadsTimesOut(t *testing.T) {
+	uploaded := startSlowS3Upload(t, 2*time.Second)
+	time.Sleep(50 * time.Millisecond)
+
+	err := WaitForPendingUploads(100 * time.Millisecond)
+
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(uploaded))
+	// drain before the temporary orchestration directory is removed
+	assert.NoError(t, WaitForPendingUploads(5*time.Second))
+}
+
+func TestWaitForPendingUploadsWithNothingPending(t *testing.T) {
+	assert.NoError(t, WaitForPendingUploads(time.Millisecond))
+}
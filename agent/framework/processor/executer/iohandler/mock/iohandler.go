@@ -163,3 +163,36 @@ func (m *MockIOHandler) SetStdout(stdout string) {
 func (m *MockIOHandler) SetStderr(stderr string) {
 	m.Called(stderr)
 }
+
+// AddResourceUsage is a mocked method that acknowledges that the function has been called.
+func (m *MockIOHandler) AddResourceUsage(usage contracts.ResourceUsage) {
+	m.Called(usage)
+}
+
+// GetResourceUsage is a mocked method that just returns what mock tells it to.
+func (m *MockIOHandler) GetResourceUsage() *contracts.ResourceUsage {
+	args := m.Called()
+	return args.Get(0).(*contracts.ResourceUsage)
+}
+
+// SetResolvedInput is a mocked method that acknowledges that the function has been called.
+func (m *MockIOHandler) SetResolvedInput(resolvedInput string) {
+	m.Called(resolvedInput)
+}
+
+// GetResolvedInput is a mocked method that just returns what mock tells it to.
+func (m *MockIOHandler) GetResolvedInput() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+// SetProcessID is a mocked method that acknowledges that the function has been called.
+func (m *MockIOHandler) SetProcessID(pid int) {
+	m.Called(pid)
+}
+
+// GetProcessID is a mocked method that just returns what mock tells it to.
+func (m *MockIOHandler) GetProcessID() int {
+	args := m.Called()
+	return args.Int(0)
+}
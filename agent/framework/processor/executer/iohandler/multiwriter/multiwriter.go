@@ -15,6 +15,7 @@
 package multiwriter
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"sync"
@@ -31,16 +32,24 @@ type DocumentIOMultiWriter interface {
 	Close() error
 }
 
+// LineFilter rewrites a line written to a multi-writer, without its line break, before it reaches the writers.
+// The line is dropped along with its line break when keep is false.
+type LineFilter func(line string) (filtered string, keep bool)
+
 // DefaultDocumentIOMultiWriter is the default implementation of multi-writer.
 type DefaultDocumentIOMultiWriter struct {
 	writers []*io.PipeWriter
 	wg      *sync.WaitGroup
+	filters []LineFilter
+	// pending holds the last line written while filtering, until its line break is written or the writer is closed
+	pending []byte
+	lock    sync.Mutex
 }
 
-// NewDocumentIOMultiWriter creates a new document multi-writer
-func NewDocumentIOMultiWriter() (b *DefaultDocumentIOMultiWriter) {
+// NewDocumentIOMultiWriter creates a new document multi-writer, the writes are passed through the filters line by line
+func NewDocumentIOMultiWriter(filters ...LineFilter) (b *DefaultDocumentIOMultiWriter) {
 	var w []*io.PipeWriter
-	b = &DefaultDocumentIOMultiWriter{w, new(sync.WaitGroup)}
+	b = &DefaultDocumentIOMultiWriter{writers: w, wg: new(sync.WaitGroup), filters: filters}
 	return
 }
 
@@ -60,7 +69,27 @@ func (b *DefaultDocumentIOMultiWriter) Write(p []byte) (n int, err error) {
 	if len(b.writers) == 0 {
 		return 0, fmt.Errorf("No writers present.")
 	}
+	if len(b.filters) == 0 {
+		return b.write(p)
+	}
 
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pending = append(b.pending, p...)
+	lastLineBreak := bytes.LastIndexByte(b.pending, '\n')
+	if lastLineBreak < 0 {
+		return len(p), nil
+	}
+	lines := b.filterLines(b.pending[:lastLineBreak+1])
+	b.pending = append([]byte(nil), b.pending[lastLineBreak+1:]...)
+	if len(lines) > 0 {
+		b.write(lines)
+	}
+	return len(p), nil
+}
+
+// write writes p to all the attached pipes
+func (b *DefaultDocumentIOMultiWriter) write(p []byte) (n int, err error) {
 	for i := 0; i < len(b.writers); i++ {
 		n, err = b.writers[i].Write(p)
 		// TODO: Handler other error types and close the writers after a fixed number of retries
@@ -81,6 +110,9 @@ func (b *DefaultDocumentIOMultiWriter) WriteString(message string) (n int, err e
 	if len(b.writers) == 0 {
 		return 0, fmt.Errorf("No writers present.")
 	}
+	if len(b.filters) > 0 {
+		return b.Write([]byte(message))
+	}
 
 	for _, w := range b.writers {
 		p := []byte(message)
@@ -89,8 +121,41 @@ func (b *DefaultDocumentIOMultiWriter) WriteString(message string) (n int, err e
 	return len(message), nil
 }
 
+// filterLines passes each line of text through the filters, text ends with a line break unless it is the last line
+func (b *DefaultDocumentIOMultiWriter) filterLines(text []byte) []byte {
+	var filtered bytes.Buffer
+	for len(text) > 0 {
+		line, lineBreak := text, []byte(nil)
+		if i := bytes.IndexByte(text, '\n'); i >= 0 {
+			line, lineBreak = text[:i], text[i:i+1]
+		}
+		text = text[len(line)+len(lineBreak):]
+
+		filteredLine, keep := string(line), true
+		for _, filter := range b.filters {
+			if filteredLine, keep = filter(filteredLine); !keep {
+				break
+			}
+		}
+		if keep {
+			filtered.WriteString(filteredLine)
+			filtered.Write(lineBreak)
+		}
+	}
+	return filtered.Bytes()
+}
+
 // Close waits for all the writers to be closed.
 func (b *DefaultDocumentIOMultiWriter) Close() (err error) {
+	b.lock.Lock()
+	if len(b.pending) > 0 && len(b.writers) > 0 {
+		if lastLine := b.filterLines(b.pending); len(lastLine) > 0 {
+			b.write(lastLine)
+		}
+	}
+	b.pending = nil
+	b.lock.Unlock()
+
 	for i := 0; i < len(b.writers); i++ {
 		err = b.writers[i].Close()
 	}
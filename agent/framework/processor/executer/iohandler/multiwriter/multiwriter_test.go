@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"io"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"sync"
@@ -153,3 +154,20 @@ func TestCloseWriter(t *testing.T) {
 	assert.Nil(t, err)
 
 }
+
+// TestWriteWithFilters runs tests to check that the filters rewrite or drop whole lines, even when a line is split across writes.
+func TestWriteWithFilters(t *testing.T) {
+	mask := func(line string) (string, bool) { return strings.Replace(line, "secret", "****", -1), true }
+	dropDirectives := func(line string) (string, bool) { return line, !strings.HasPrefix(line, "::") }
+	mw := NewDocumentIOMultiWriter(mask, dropDirectives)
+	r, w := io.Pipe()
+	mw.AddWriter(w)
+	go testReadBulk(t, r, "the sec****\nvalue\nlast ****", mw.wg)
+
+	for _, message := range []string{"the sec", "secret\n::set-output ", "name=key::secret\nval", "ue\nlast ", "secret"} {
+		bytesWritten, err := mw.WriteString(message)
+		assert.Equal(t, len(message), bytesWritten)
+		assert.Nil(t, err)
+	}
+	mw.Close()
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/messaging"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
@@ -35,6 +36,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/amazon-ssm-agent/core/executor"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var pluginRunner messaging.PluginRunner
@@ -355,6 +357,50 @@ func TestOutOfProcExecuter_Cancel(t *testing.T) {
 	teardown(t)
 }
 
+// TestOutOfProcExecuter_CancelByDocumentID tests that a document cancelled by its id is cancelled in the document worker,
+// where the blocking step is cancelled and the remaining step is not run
+func TestOutOfProcExecuter_CancelByDocumentID(t *testing.T) {
+	testCase := setup(t)
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return(nil)
+	stepStarted := make(chan bool, 1)
+	pluginRunner = func(
+		context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag,
+	) {
+		//the first step blocks until the cancel is received from the master
+		stepStarted <- true
+		cancelFlag.Wait()
+		for _, pluginState := range docState.InstancePluginsInformation {
+			resChan <- contracts.PluginResult{
+				PluginName: pluginState.Name,
+				PluginID:   pluginState.Id,
+				Status:     contracts.ResultStatusCancelled,
+				Code:       1,
+			}
+		}
+		close(resChan)
+	}
+	logger.Info("launching out-of-proc Executer...")
+	resChan := NewOutOfProcExecuter(testCase.context).Run(task.NewChanneledCancelFlag(), testCase.docStore)
+	<-stepStarted
+	assert.NoError(t, executer.CancelDocument(testDocumentID))
+
+	var final contracts.DocumentResult
+	for res := range resChan {
+		if res.LastPlugin == "" {
+			final = res
+		}
+	}
+	assert.Equal(t, contracts.ResultStatusCancelled, final.Status)
+	assert.Equal(t, contracts.ResultStatusCancelled, final.PluginResults["plugin1"].Status)
+	assert.Equal(t, contracts.ResultStatusCancelled, final.PluginResults["plugin2"].Status)
+	assert.Error(t, executer.CancelDocument(testDocumentID))
+	teardown(t)
+}
+
 type FakeProcess struct {
 	exitChan chan bool
 	live     bool
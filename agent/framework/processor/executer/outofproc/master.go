@@ -3,6 +3,7 @@ package outofproc
 import (
 	"fmt"
 	"runtime/debug"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -34,16 +35,21 @@ const (
 	defaultOrphanProcessTimeout = 172800 * time.Second
 )
 
+// zombieProcessTimeout is how long messaging keeps running after the worker process exited
+var zombieProcessTimeout = defaultZombieProcessTimeout
+
 type OutOfProcExecuter struct {
 	basicexecuter.BasicExecuter
 	docState   *contracts.DocumentState
 	ctx        context.T
 	cancelFlag task.CancelFlag
 	executor   executor.IExecutor
+	// set when the worker process launched last exited unsuccessfully
+	workerCrashed atomic.Bool
 }
 
 var channelCreator = func(log log.T, identity identity.IAgentIdentity, mode filewatcherbasedipc.Mode, documentID string) (filewatcherbasedipc.IPCChannel, error, bool) {
-	return filewatcherbasedipc.CreateFileWatcherChannel(log, identity, mode, documentID, false)
+	return filewatcherbasedipc.CreateFileWatcherChannelWithRetry(log, identity, mode, documentID, false)
 }
 
 var processFinder = func(log log.T, procinfo contracts.OSProcInfo, executor executor.IExecutor) bool {
@@ -104,6 +110,8 @@ func (e *OutOfProcExecuter) Run(
 	} else {
 		//create reply channel
 		resChan := make(chan contracts.DocumentResult, len(e.docState.InstancePluginsInformation)+1)
+		//the cancel flag is sent to the document worker by the messaging backend
+		executer.RegisterRunningDocument(documentID, cancelFlag)
 		//launch the messaging go-routine
 		go func(store executer.DocumentStore) {
 			defer func() {
@@ -113,10 +121,11 @@ func (e *OutOfProcExecuter) Run(
 				}
 				//save the overall result and signal called that Executer is done
 				store.Save(*e.docState)
+				executer.UnregisterRunningDocument(documentID, cancelFlag)
 				log.Debug("Executer closed")
 				close(resChan)
 			}()
-			e.messaging(log, ipc, resChan, cancelFlag, stopTimer)
+			e.messaging(log, ipc, resChan, cancelFlag, stopTimer, store)
 		}(docStore)
 
 		return resChan
@@ -126,31 +135,100 @@ func (e *OutOfProcExecuter) Run(
 // Executer spins up an ipc transmission worker, it creates a Data processing backend and hands off the backend to the ipc worker
 // ipc worker and data backend act as 2 threads exchange raw json messages, and messaging protocol happened in data backend, data backend is self-contained and exit when command finishes accordingly
 // Executer however does hold a timer to the worker to forcefully termniate both of them
-func (e *OutOfProcExecuter) messaging(log log.T, ipc filewatcherbasedipc.IPCChannel, resChan chan contracts.DocumentResult, cancelFlag task.CancelFlag, stopTimer chan bool) {
+// If the worker of a command document crashes before the document completes, a new worker is spawned to resume the document,
+// up to DocumentWorkerRespawnLimit times. Session workers are never re-spawned, their session cannot be resumed.
+func (e *OutOfProcExecuter) messaging(log log.T, ipc filewatcherbasedipc.IPCChannel, resChan chan contracts.DocumentResult, cancelFlag task.CancelFlag, stopTimer chan bool, docStore executer.DocumentStore) {
 
 	// backup current time in case outofproc execution failed and cannot correctly return PluginResult
 	backupStartTime := time.Now()
 
-	//handoff reply functionalities to data backend.
-	backend := messaging.NewExecuterBackend(log, resChan, e.docState, cancelFlag)
+	//stop the messaging worker if the document worker stops sending heartbeats
+	heartbeatTimeout := time.Duration(e.ctx.AppConfig().Agent.DocumentWorkerHeartbeatTimeoutSeconds) * time.Second
+	respawnLimit := e.ctx.AppConfig().Agent.DocumentWorkerRespawnLimit
+	if !isRespawnable(e.docState.DocumentType) {
+		respawnLimit = 0
+	}
 
-	//handoff the data backend to messaging worker
-	if err := messaging.Messaging(log, ipc, backend, stopTimer); err != nil {
+	for respawnCount := 0; ; respawnCount++ {
+		heartbeatMissed, err := e.exchangeMessages(log, ipc, resChan, cancelFlag, stopTimer, heartbeatTimeout, docStore)
+		if err == nil {
+			return
+		}
 		//the messaging worker encountered error, either ipc run into error or data backend throws error
 		log.Errorf("messaging worker encountered error: %v", err)
 		log.Errorf("document state during messaging worker error: %v", e.docState.DocumentInformation.DocumentStatus)
-		if e.docState.DocumentInformation.DocumentStatus == contracts.ResultStatusInProgress ||
-			e.docState.DocumentInformation.DocumentStatus == "" ||
-			e.docState.DocumentInformation.DocumentStatus == contracts.ResultStatusNotStarted ||
-			e.docState.DocumentInformation.DocumentStatus == contracts.ResultStatusSuccessAndReboot {
-			e.docState.DocumentInformation.DocumentStatus = contracts.ResultStatusFailed
-			log.Info("document failed half way, sending fail message...")
-			errMsg := fmt.Sprintf("document process failed unexpectedly: %s , check [ssm-document-worker]/[ssm-session-worker] log for crash reason", err)
-			resChan <- e.generateUnexpectedFailResult(errMsg, backupStartTime)
-		}
 		//destroy the channel
 		ipc.Destroy()
+		if e.docState.DocumentInformation.DocumentStatus != contracts.ResultStatusInProgress &&
+			e.docState.DocumentInformation.DocumentStatus != "" &&
+			e.docState.DocumentInformation.DocumentStatus != contracts.ResultStatusNotStarted &&
+			e.docState.DocumentInformation.DocumentStatus != contracts.ResultStatusSuccessAndReboot {
+			return
+		}
+
+		// a worker that hangs or is stopped on purpose is not re-spawned
+		crashed := e.workerCrashed.Load() && !heartbeatMissed && !cancelFlag.Canceled() && !cancelFlag.ShutDown()
+		if crashed && respawnCount < respawnLimit {
+			log.Warnf("document worker crashed, re-spawning it (%v of %v) to resume the document from its last completed step", respawnCount+1, respawnLimit)
+			var respawnErr error
+			if ipc, respawnErr = e.respawnWorker(stopTimer); respawnErr == nil {
+				docStore.Save(*e.docState)
+				continue
+			}
+			log.Errorf("failed to re-spawn document worker: %v", respawnErr)
+		}
+
+		e.docState.DocumentInformation.DocumentStatus = contracts.ResultStatusFailed
+		log.Info("document failed half way, sending fail message...")
+		errMsg := fmt.Sprintf("document process failed unexpectedly: %s , check [ssm-document-worker]/[ssm-session-worker] log for crash reason", err)
+		if heartbeatMissed {
+			errMsg = fmt.Sprintf("document worker stopped responding: no heartbeat received for %v, check [ssm-document-worker]/[ssm-session-worker] log for hang or crash reason", heartbeatTimeout)
+		} else if crashed && respawnLimit > 0 && respawnCount >= respawnLimit {
+			log.Errorf("document worker crashed %v times, giving up on the document", respawnCount+1)
+			errMsg = fmt.Sprintf("document worker crashed %v times, exceeding the re-spawn limit of %v: %s, check [ssm-document-worker]/[ssm-session-worker] log for crash reason", respawnCount+1, respawnLimit, err)
+		}
+		resChan <- e.generateUnexpectedFailResult(errMsg, backupStartTime)
+		return
+	}
+}
+
+// isRespawnable returns whether a crashed worker running a document of the given type can be re-spawned to resume the document
+func isRespawnable(documentType contracts.DocumentType) bool {
+	switch documentType {
+	case contracts.SendCommand, contracts.SendCommandOffline, contracts.Association:
+		return true
+	}
+	return false
+}
+
+// exchangeMessages runs the messaging worker between the master and one document worker until it stops
+func (e *OutOfProcExecuter) exchangeMessages(log log.T, ipc filewatcherbasedipc.IPCChannel, resChan chan contracts.DocumentResult, cancelFlag task.CancelFlag, stopTimer chan bool, heartbeatTimeout time.Duration, docStore executer.DocumentStore) (heartbeatMissed bool, err error) {
+	//handoff reply functionalities to data backend.
+	backend := messaging.NewExecuterBackend(log, resChan, e.docState, cancelFlag)
+	backend.SaveStepResultsTo(docStore)
+
+	missed := &atomic.Bool{}
+	messagingDone := make(chan struct{})
+	defer close(messagingDone)
+	go monitorHeartbeat(log, backend, heartbeatTimeout, stopTimer, messagingDone, missed)
+
+	//handoff the data backend to messaging worker
+	err = messaging.Messaging(log, ipc, backend, stopTimer)
+	return missed.Load(), err
+}
+
+// respawnWorker launches a new worker for the document on a new channel. The worker receives the document state
+// which holds the results of the plugins completed so far, so that only the remaining plugins are run.
+func (e *OutOfProcExecuter) respawnWorker(stopTimer chan bool) (ipc filewatcherbasedipc.IPCChannel, err error) {
+	log := e.ctx.Log()
+	e.workerCrashed.Store(false)
+	if ipc, err, _ = channelCreator(log, e.ctx.Identity(), filewatcherbasedipc.ModeMaster, e.docState.DocumentInformation.DocumentID); err != nil {
+		return nil, fmt.Errorf("failed to create ipc channel: %v", err)
+	}
+	if err = e.startWorker(ipc, stopTimer); err != nil {
+		return nil, err
 	}
+	return ipc, nil
 }
 
 func (e *OutOfProcExecuter) generateUnexpectedFailResult(errMsg string, startTime time.Time) contracts.DocumentResult {
@@ -158,9 +236,11 @@ func (e *OutOfProcExecuter) generateUnexpectedFailResult(errMsg string, startTim
 	var docResult contracts.DocumentResult
 	docResult.MessageID = e.docState.DocumentInformation.MessageID
 	docResult.AssociationID = e.docState.DocumentInformation.AssociationID
+	docResult.DocumentID = e.docState.DocumentInformation.DocumentID
 	docResult.DocumentName = e.docState.DocumentInformation.DocumentName
 	docResult.NPlugins = len(e.docState.InstancePluginsInformation)
 	docResult.DocumentVersion = e.docState.DocumentInformation.DocumentVersion
+	docResult.Provenance = e.docState.Provenance
 	docResult.Status = contracts.ResultStatusFailed
 	docResult.PluginResults = make(map[string]*contracts.PluginResult)
 	res := e.docState.InstancePluginsInformation[0].Result
@@ -205,38 +285,44 @@ func (e *OutOfProcExecuter) initialize(stopTimer chan bool) (ipc filewatcherbase
 			stopTime = defaultOrphanProcessTimeout
 		} else {
 			log.Infof("process: %v not found, treat as exited", procInfo.Pid)
-			stopTime = defaultZombieProcessTimeout
+			stopTime = zombieProcessTimeout
 		}
 		go timeoutOrCancel(stopTimer, stopTime, e.cancelFlag)
 	} else {
 		log.Debug("channel not found, starting a new process...")
-		var workerName string
-		if e.docState.DocumentType == contracts.StartSession {
-			workerName = appconfig.DefaultSessionWorker
-		} else {
-			workerName = appconfig.DefaultDocumentWorker
-		}
-		var process proc.OSProcess
-		if process, err = processCreator(workerName, []string{documentID}); err != nil {
-			log.Errorf("start process: %v error: %v", workerName, err)
-			//make sure close the channel
-			ipc.Destroy()
-			return
-		} else {
-			log.Debugf("successfully launched new process: %v", process.Pid())
-		}
-		e.docState.DocumentInformation.ProcInfo = contracts.OSProcInfo{
-			Pid:       process.Pid(),
-			StartTime: process.StartTime(),
-		}
-		//TODO add command timeout as well, in case process get stuck
-		go e.WaitForProcess(stopTimer, process)
-
+		err = e.startWorker(ipc, stopTimer)
 	}
 
 	return
 }
 
+// startWorker launches the worker process of the document and watches for its exit, the channel is destroyed if the launch fails
+func (e *OutOfProcExecuter) startWorker(ipc filewatcherbasedipc.IPCChannel, stopTimer chan bool) (err error) {
+	log := e.ctx.Log()
+	var workerName string
+	if e.docState.DocumentType == contracts.StartSession {
+		workerName = appconfig.DefaultSessionWorker
+	} else {
+		workerName = appconfig.DefaultDocumentWorker
+	}
+	var process proc.OSProcess
+	if process, err = processCreator(workerName, []string{e.docState.DocumentInformation.DocumentID}); err != nil {
+		log.Errorf("start process: %v error: %v", workerName, err)
+		//make sure close the channel
+		ipc.Destroy()
+		return
+	} else {
+		log.Debugf("successfully launched new process: %v", process.Pid())
+	}
+	e.docState.DocumentInformation.ProcInfo = contracts.OSProcInfo{
+		Pid:       process.Pid(),
+		StartTime: process.StartTime(),
+	}
+	//TODO add command timeout as well, in case process get stuck
+	go e.WaitForProcess(stopTimer, process)
+	return
+}
+
 func (e *OutOfProcExecuter) WaitForProcess(stopTimer chan bool, process proc.OSProcess) {
 	log := e.ctx.Log()
 	//TODO revisit this feature, it has done sides of killing the document worker too fast -- the worker might busy doing s3 upload
@@ -259,11 +345,12 @@ func (e *OutOfProcExecuter) WaitForProcess(stopTimer chan bool, process proc.OSP
 	}()
 	if err := process.Wait(); err != nil {
 		log.Errorf("process: %v exited unsuccessfully, error message: %v", process.Pid(), err)
+		e.workerCrashed.Store(true)
 	} else {
 		log.Debugf("process: %v exited successfully, trying to stop messaging worker", process.Pid())
 	}
 	//waitReturned = true
-	timeout(stopTimer, defaultZombieProcessTimeout)
+	timeout(stopTimer, zombieProcessTimeout)
 }
 
 func timeoutOrCancel(stopTimer chan bool, duration time.Duration, cancelFlag task.CancelFlag) {
@@ -281,6 +368,34 @@ func timeoutOrCancel(stopTimer chan bool, duration time.Duration, cancelFlag tas
 	}
 }
 
+// monitorHeartbeat signals the messaging worker to stop once the document worker has not sent a heartbeat for longer than timeout.
+// Workers that never sent a heartbeat are not monitored, they are still covered by the execution timeout.
+func monitorHeartbeat(log log.T, backend *messaging.ExecuterBackend, timeout time.Duration, stopTimer chan bool, done chan struct{}, heartbeatMissed *atomic.Bool) {
+	if timeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(messaging.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			lastHeartbeat := backend.LastHeartbeat()
+			if lastHeartbeat.IsZero() || time.Since(lastHeartbeat) <= timeout {
+				continue
+			}
+			log.Errorf("no heartbeat received from document worker since %v, stopping messaging", lastHeartbeat)
+			heartbeatMissed.Store(true)
+			select {
+			case stopTimer <- true:
+			default:
+			}
+			return
+		}
+	}
+}
+
 func timeout(stopTimer chan bool, duration time.Duration) {
 	<-time.After(duration)
 	stopTimer <- true
@@ -2,14 +2,18 @@ package outofproc
 
 import (
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/messaging"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
 	procmock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc/mock"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -19,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/amazon-ssm-agent/core/executor"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type TestCase struct {
@@ -327,6 +332,48 @@ func TestInitializeConnectOldOrphan(t *testing.T) {
 	channelMock.AssertExpectations(t)
 }
 
+func TestMonitorHeartbeatStopsMessagingWhenWorkerIsUnresponsive(t *testing.T) {
+	testCase := CreateTestCase()
+	defaultInterval := messaging.HeartbeatInterval
+	messaging.HeartbeatInterval = 5 * time.Millisecond
+	defer func() { messaging.HeartbeatInterval = defaultInterval }()
+	backend := messaging.NewExecuterBackend(logger, make(chan contracts.DocumentResult, 10), &testCase.docState, task.NewChanneledCancelFlag())
+	heartbeat, _ := messaging.CreateDatagram(messaging.MessageTypeHeartbeat, "heartbeat")
+	assert.NoError(t, backend.Process(heartbeat))
+	stopTimer := make(chan bool, 1)
+	done := make(chan struct{})
+	defer close(done)
+	heartbeatMissed := &atomic.Bool{}
+	go monitorHeartbeat(logger, backend, 20*time.Millisecond, stopTimer, done, heartbeatMissed)
+	select {
+	case <-stopTimer:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "messaging was not stopped after the worker stopped sending heartbeats")
+	}
+	assert.True(t, heartbeatMissed.Load())
+}
+
+func TestMonitorHeartbeatIgnoresWorkerWithoutHeartbeat(t *testing.T) {
+	testCase := CreateTestCase()
+	defaultInterval := messaging.HeartbeatInterval
+	messaging.HeartbeatInterval = 5 * time.Millisecond
+	defer func() { messaging.HeartbeatInterval = defaultInterval }()
+	backend := messaging.NewExecuterBackend(logger, make(chan contracts.DocumentResult, 10), &testCase.docState, task.NewChanneledCancelFlag())
+	stopTimer := make(chan bool, 1)
+	done := make(chan struct{})
+	heartbeatMissed := &atomic.Bool{}
+	monitorReturned := make(chan struct{})
+	go func() {
+		monitorHeartbeat(logger, backend, 20*time.Millisecond, stopTimer, done, heartbeatMissed)
+		close(monitorReturned)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(done)
+	<-monitorReturned
+	assert.Equal(t, 0, len(stopTimer))
+	assert.False(t, heartbeatMissed.Load())
+}
+
 //TODO add Run() unittest
 
 // this is needed, since after marshal-unmarshalling thru the data channel, the pointer value changed
@@ -336,3 +383,191 @@ func assertValueEqual(t *testing.T, a map[string]*contracts.PluginResult, b map[
 		assert.Equal(t, *val, *b[key])
 	}
 }
+
+// newWorkerChannel returns a mocked channel to a worker, whose messages to the master are written by the test to inbound
+// and whose messages from the master are forwarded to sent
+func newWorkerChannel() (channel *channelmock.MockedChannel, inbound chan string, sent chan string) {
+	channel = new(channelmock.MockedChannel)
+	inbound = make(chan string, 10)
+	sent = make(chan string, 10)
+	var once sync.Once
+	closeInbound := func(mock.Arguments) { once.Do(func() { close(inbound) }) }
+	channel.On("GetPath").Return("channel")
+	channel.On("GetMessage").Return(inbound)
+	channel.On("Send", mock.Anything).Run(func(args mock.Arguments) { sent <- args.String(0) }).Return(nil)
+	channel.On("Close").Run(closeInbound).Return()
+	channel.On("Destroy").Run(closeInbound).Return()
+	return
+}
+
+func newRespawnTestExecuter(t *testing.T, respawnLimit int, channels []*channelmock.MockedChannel, processes []*procmock.MockedOSProcess) (*OutOfProcExecuter, *int) {
+	config := appconfig.SsmagentConfig{}
+	config.Agent.DocumentWorkerRespawnLimit = respawnLimit
+	defaultTimeout := zombieProcessTimeout
+	zombieProcessTimeout = 10 * time.Millisecond
+	t.Cleanup(func() { zombieProcessTimeout = defaultTimeout })
+
+	channelCreator = func(log log.T, identity identity.IAgentIdentity, mode filewatcherbasedipc.Mode, documentID string) (filewatcherbasedipc.IPCChannel, error, bool) {
+		if len(channels) == 0 {
+			return nil, errors.New("no more channels"), false
+		}
+		channel := channels[0]
+		channels = channels[1:]
+		return channel, nil, false
+	}
+	started := 0
+	processCreator = func(name string, argv []string) (proc.OSProcess, error) {
+		if started == len(processes) {
+			return nil, errors.New("no more processes")
+		}
+		started++
+		return processes[started-1], nil
+	}
+	return NewOutOfProcExecuter(context.NewMockDefaultWithConfig(config)), &started
+}
+
+func receiveResult(t *testing.T, resChan chan contracts.DocumentResult) (res contracts.DocumentResult, more bool) {
+	select {
+	case res, more = <-resChan:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "no document result received")
+	}
+	return
+}
+
+func TestMessagingRespawnsCrashedWorkerToResumeDocument(t *testing.T) {
+	testCase := CreateTestCase()
+	channel1, inbound1, sent1 := newWorkerChannel()
+	channel2, inbound2, sent2 := newWorkerChannel()
+	crash := make(chan struct{})
+	// the second worker keeps running until the test ends
+	running := make(chan struct{})
+	process1 := new(procmock.MockedOSProcess)
+	process1.On("Pid").Return(testPid)
+	process1.On("StartTime").Return(testStartDateTime)
+	process1.On("Wait").Run(func(mock.Arguments) { <-crash }).Return(errors.New("exit status 2"))
+	process2 := new(procmock.MockedOSProcess)
+	process2.On("Pid").Return(testPid + 1)
+	process2.On("StartTime").Return(testStartDateTime)
+	process2.On("Wait").Run(func(mock.Arguments) { <-running }).Return(nil)
+	exe, started := newRespawnTestExecuter(t, 1, []*channelmock.MockedChannel{channel1, channel2}, []*procmock.MockedOSProcess{process1, process2})
+
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return()
+	cancelFlag := task.NewChanneledCancelFlag()
+	defer cancelFlag.Set(task.Completed)
+	resChan := exe.Run(cancelFlag, testCase.docStore)
+
+	// the first worker completes plugin1, then crashes
+	<-sent1
+	reply, _ := messaging.CreateDatagram(messaging.MessageTypeReply, contracts.DocumentResult{
+		LastPlugin:    "plugin1",
+		Status:        contracts.ResultStatusInProgress,
+		PluginResults: map[string]*contracts.PluginResult{"plugin1": testCase.results["plugin1"]},
+	})
+	inbound1 <- reply
+	res, _ := receiveResult(t, resChan)
+	assert.Equal(t, "plugin1", res.LastPlugin)
+	close(crash)
+
+	// the second worker is handed the state with plugin1 completed
+	var startDatagram string
+	select {
+	case startDatagram = <-sent2:
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "crashed worker was not re-spawned")
+	}
+	messageType, content := messaging.ParseDatagram(startDatagram)
+	assert.Equal(t, messaging.MessageType(messaging.MessageTypePluginConfig), messageType)
+	var resumedState contracts.DocumentState
+	assert.NoError(t, jsonutil.Unmarshal(content, &resumedState))
+	assert.Equal(t, contracts.ResultStatusSuccess, resumedState.InstancePluginsInformation[0].Result.Status)
+	assert.Equal(t, contracts.ResultStatus(""), resumedState.InstancePluginsInformation[1].Result.Status)
+	assert.Equal(t, testPid+1, resumedState.DocumentInformation.ProcInfo.Pid)
+
+	complete, _ := messaging.CreateDatagram(messaging.MessageTypeComplete, contracts.DocumentResult{
+		Status:        contracts.ResultStatusSuccess,
+		PluginResults: testCase.results,
+	})
+	inbound2 <- complete
+	res, _ = receiveResult(t, resChan)
+	assert.Equal(t, "", res.LastPlugin)
+	assert.Equal(t, contracts.ResultStatusSuccess, res.Status)
+	_, more := receiveResult(t, resChan)
+	assert.False(t, more)
+	assert.Equal(t, 2, *started)
+	channel1.AssertCalled(t, "Destroy")
+}
+
+func TestMessagingGivesUpOnWorkerCrashingRepeatedly(t *testing.T) {
+	testCase := CreateTestCase()
+	channel1, _, _ := newWorkerChannel()
+	channel2, _, _ := newWorkerChannel()
+	var processes []*procmock.MockedOSProcess
+	for i := 0; i < 3; i++ {
+		process := new(procmock.MockedOSProcess)
+		process.On("Pid").Return(testPid + i)
+		process.On("StartTime").Return(testStartDateTime)
+		process.On("Wait").Return(errors.New("exit status 2"))
+		processes = append(processes, process)
+	}
+	exe, started := newRespawnTestExecuter(t, 1, []*channelmock.MockedChannel{channel1, channel2}, processes)
+
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return()
+	cancelFlag := task.NewChanneledCancelFlag()
+	defer cancelFlag.Set(task.Completed)
+	resChan := exe.Run(cancelFlag, testCase.docStore)
+
+	res, _ := receiveResult(t, resChan)
+	assert.Equal(t, contracts.ResultStatusFailed, res.Status)
+	assert.Contains(t, res.PluginResults["plugin1"].Output, "document worker crashed 2 times, exceeding the re-spawn limit of 1")
+	_, more := receiveResult(t, resChan)
+	assert.False(t, more)
+	// the worker is re-spawned once, and not again after its second crash
+	assert.Equal(t, 2, *started)
+	assert.Equal(t, contracts.ResultStatusFailed, exe.docState.DocumentInformation.DocumentStatus)
+}
+
+func TestMessagingDoesNotRespawnWorkerByDefault(t *testing.T) {
+	testCase := CreateTestCase()
+	channel, _, _ := newWorkerChannel()
+	process := new(procmock.MockedOSProcess)
+	process.On("Pid").Return(testPid)
+	process.On("StartTime").Return(testStartDateTime)
+	process.On("Wait").Return(errors.New("exit status 2"))
+	exe, started := newRespawnTestExecuter(t, 0, []*channelmock.MockedChannel{channel}, []*procmock.MockedOSProcess{process})
+
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return()
+	cancelFlag := task.NewChanneledCancelFlag()
+	defer cancelFlag.Set(task.Completed)
+	resChan := exe.Run(cancelFlag, testCase.docStore)
+
+	res, _ := receiveResult(t, resChan)
+	assert.Equal(t, contracts.ResultStatusFailed, res.Status)
+	assert.Contains(t, res.PluginResults["plugin1"].Output, "document process failed unexpectedly")
+	assert.Equal(t, 1, *started)
+}
+
+func TestMessagingDoesNotRespawnSessionWorker(t *testing.T) {
+	testCase := CreateTestCase()
+	testCase.docState.DocumentType = contracts.StartSession
+	channel, _, _ := newWorkerChannel()
+	process := new(procmock.MockedOSProcess)
+	process.On("Pid").Return(testPid)
+	process.On("StartTime").Return(testStartDateTime)
+	process.On("Wait").Return(errors.New("exit status 2"))
+	exe, started := newRespawnTestExecuter(t, 1, []*channelmock.MockedChannel{channel}, []*procmock.MockedOSProcess{process})
+
+	testCase.docStore.On("Load").Return(testCase.docState)
+	testCase.docStore.On("Save", mock.Anything).Return()
+	cancelFlag := task.NewChanneledCancelFlag()
+	defer cancelFlag.Set(task.Completed)
+	resChan := exe.Run(cancelFlag, testCase.docStore)
+
+	res, _ := receiveResult(t, resChan)
+	assert.Equal(t, contracts.ResultStatusFailed, res.Status)
+	assert.Contains(t, res.PluginResults["plugin1"].Output, "document process failed unexpectedly")
+	assert.Equal(t, 1, *started)
+}
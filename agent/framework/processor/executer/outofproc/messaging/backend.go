@@ -2,13 +2,18 @@ package messaging
 
 import (
 	"errors"
+	"fmt"
 	"runtime/debug"
 	"sync/atomic"
+	"time"
 
 	"sync"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -21,6 +26,8 @@ const (
 	// Currently with Init and Processing statuses to avoid idle process leak.
 	BackendStateInit int32 = 0
 	BackendStateProc int32 = 1
+	// CommandTimeoutMax caps the command timeout configured for a document
+	CommandTimeoutMax = 172800 * time.Second
 )
 
 type PluginRunner func(
@@ -39,6 +46,14 @@ type WorkerBackend struct {
 	runner     PluginRunner
 	stopChan   chan int
 	state      atomic.Int32
+	// commandTimeout is the time the document is allowed to run, commandTimedOut is set once it is exceeded
+	commandTimeout  time.Duration
+	commandTimedOut atomic.Bool
+	// unix nano time the plugins were first asked to stop, 0 while they are allowed to run
+	stopRequested atomic.Int64
+	// docState is the document run by the worker, documentMgr persists it when a result cannot be sent to the master
+	docState    contracts.DocumentState
+	documentMgr docmanager.DocumentMgr
 }
 
 // Executer backend formulate the run request to the worker, and collect back the responses from worker
@@ -49,6 +64,10 @@ type ExecuterBackend struct {
 	cancelFlag task.CancelFlag
 	output     chan contracts.DocumentResult
 	stopChan   chan int
+	// unix nano time of the last message received from the worker, 0 until the worker sends its first heartbeat
+	lastHeartbeat atomic.Int64
+	// docStore checkpoints the document state each time the worker reports a step result, when set
+	docStore executer.DocumentStore
 }
 
 func NewExecuterBackend(log log.T, output chan contracts.DocumentResult, docState *contracts.DocumentState, cancelFlag task.CancelFlag) *ExecuterBackend {
@@ -65,6 +84,12 @@ func NewExecuterBackend(log log.T, output chan contracts.DocumentResult, docStat
 	return &p
 }
 
+// SaveStepResultsTo makes the backend save the document state to docStore after each step result received from the worker,
+// so that the document resumes at its next unstarted step if the agent restarts
+func (p *ExecuterBackend) SaveStepResultsTo(docStore executer.DocumentStore) {
+	p.docStore = docStore
+}
+
 func (p *ExecuterBackend) start(log log.T, docState contracts.DocumentState) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -74,6 +99,7 @@ func (p *ExecuterBackend) start(log log.T, docState contracts.DocumentState) {
 	}()
 	startDatagram, _ := CreateDatagram(MessageTypePluginConfig, docState)
 	p.input <- startDatagram
+	p.forwardPauses(log)
 	p.cancelFlag.Wait()
 	if p.cancelFlag.Canceled() {
 		cancelDatagram, _ := CreateDatagram(MessageTypeCancel, "cancel")
@@ -85,6 +111,24 @@ func (p *ExecuterBackend) start(log log.T, docState contracts.DocumentState) {
 	close(p.input)
 }
 
+// forwardPauses sends the pauses and resumes of the document to the worker, until the document is canceled, shut down or completed
+func (p *ExecuterBackend) forwardPauses(log log.T) {
+	for {
+		if p.cancelFlag.WaitWhileRunning() != task.Paused {
+			return
+		}
+		log.Info("document paused, sending pause message...")
+		pauseDatagram, _ := CreateDatagram(MessageTypePause, "pause")
+		p.input <- pauseDatagram
+		if p.cancelFlag.WaitWhilePaused() != task.Running {
+			return
+		}
+		log.Info("document resumed, sending resume message...")
+		resumeDatagram, _ := CreateDatagram(MessageTypeResume, "resume")
+		p.input <- resumeDatagram
+	}
+}
+
 func (p *ExecuterBackend) Accept() <-chan string {
 	return p.input
 }
@@ -117,10 +161,18 @@ func (p *ExecuterBackend) ForceQuit() {
 func (p *ExecuterBackend) Process(datagram string) error {
 	t, content := ParseDatagram(datagram)
 	switch t {
+	case MessageTypeHeartbeat:
+		p.lastHeartbeat.Store(time.Now().UnixNano())
 	case MessageTypeReply, MessageTypeComplete:
+		if p.lastHeartbeat.Load() != 0 {
+			p.lastHeartbeat.Store(time.Now().UnixNano())
+		}
 		var docResult contracts.DocumentResult
 		jsonutil.Unmarshal(content, &docResult)
 		p.formatDocResult(&docResult)
+		if t == MessageTypeReply && p.docStore != nil {
+			p.docStore.Save(*p.docState)
+		}
 		p.output <- docResult
 		if t == MessageTypeComplete {
 			//get document result, force termniate messaging worker
@@ -132,13 +184,27 @@ func (p *ExecuterBackend) Process(datagram string) error {
 	return nil
 }
 
+// LastHeartbeat returns when the worker was last known to be alive.
+// The zero time is returned if the worker has not sent any heartbeat, for instance when it predates heartbeats.
+func (p *ExecuterBackend) LastHeartbeat() time.Time {
+	lastHeartbeat := p.lastHeartbeat.Load()
+	if lastHeartbeat == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, lastHeartbeat)
+}
+
 func (p *ExecuterBackend) formatDocResult(docResult *contracts.DocumentResult) {
 	//fill doc level information that the sub-process wouldn't know
 	docResult.MessageID = p.docState.DocumentInformation.MessageID
 	docResult.AssociationID = p.docState.DocumentInformation.AssociationID
+	docResult.DocumentID = p.docState.DocumentInformation.DocumentID
 	docResult.DocumentName = p.docState.DocumentInformation.DocumentName
 	docResult.NPlugins = len(p.docState.InstancePluginsInformation)
 	docResult.DocumentVersion = p.docState.DocumentInformation.DocumentVersion
+	if docResult.LastPlugin == "" {
+		docResult.Provenance = p.docState.Provenance
+	}
 	//update current document status
 	contracts.UpdateDocState(docResult, p.docState)
 }
@@ -171,14 +237,29 @@ func (p *WorkerBackend) Process(datagram string) error {
 		}
 		log.Debugf("unmarshal plugin config: %+v", docState)
 		p.once.Do(func() {
+			p.docState = docState
 			statusChan := make(chan contracts.PluginResult)
-			go p.runner(p.ctx, docState, statusChan, p.cancelFlag)
+			if p.commandTimeout = getCommandTimeout(docState.IOConfig); p.commandTimeout > 0 {
+				commandTimer := time.AfterFunc(p.commandTimeout, p.timeOutCommand)
+				go func() {
+					defer commandTimer.Stop()
+					p.runner(p.ctx, docState, statusChan, p.cancelFlag)
+				}()
+			} else {
+				go p.runner(p.ctx, docState, statusChan, p.cancelFlag)
+			}
 			go p.pluginListener(statusChan)
 		})
 
 	case MessageTypeCancel:
 		log.Info("requested cancel the command, setting cancel flag...")
-		p.cancelFlag.Set(task.Canceled)
+		p.stopExecution(task.Canceled)
+	case MessageTypePause:
+		log.Info("requested pause of the command, pausing before the next step...")
+		p.cancelFlag.Set(task.Paused)
+	case MessageTypeResume:
+		log.Info("requested resume of the command")
+		p.cancelFlag.Set(task.Running)
 	default:
 		//TODO add extra logic to check whether plugin has started, if not, stop IPC, or add timeout
 		return errors.New("unsupported message type")
@@ -186,6 +267,75 @@ func (p *WorkerBackend) Process(datagram string) error {
 	return nil
 }
 
+// PersistUndeliveredResults makes the backend save the document state with the latest plugin results to the
+// current documents folder when they cannot be sent to the master, so that the master recovers them once it restarts
+func (p *WorkerBackend) PersistUndeliveredResults(documentMgr docmanager.DocumentMgr) {
+	p.documentMgr = documentMgr
+}
+
+// HandleUndeliveredDatagram persists the plugin results carried by a reply or complete message the master did not receive
+func (p *WorkerBackend) HandleUndeliveredDatagram(datagram string) {
+	log := p.ctx.Log()
+	t, content := ParseDatagram(datagram)
+	if p.documentMgr == nil || (t != MessageTypeReply && t != MessageTypeComplete) {
+		return
+	}
+	var docResult contracts.DocumentResult
+	if err := jsonutil.Unmarshal(content, &docResult); err != nil {
+		log.Errorf("failed to unmarshal undelivered document result: %v", err)
+		return
+	}
+	docState := p.docState
+	docState.InstancePluginsInformation = append([]contracts.PluginState(nil), p.docState.InstancePluginsInformation...)
+	docState.DocumentInformation.DocumentStatus = docResult.Status
+	for i, pluginState := range docState.InstancePluginsInformation {
+		if result, ok := docResult.PluginResults[pluginState.Id]; ok && result != nil {
+			docState.InstancePluginsInformation[i].Result = *result
+		}
+	}
+	documentID := docState.DocumentInformation.DocumentID
+	log.Warnf("persisting the undelivered results of document %v with status %v", documentID, docResult.Status)
+	p.documentMgr.PersistDocumentState(documentID, appconfig.DefaultLocationOfCurrent, docState)
+}
+
+// getCommandTimeout returns the command timeout configured for the document capped by CommandTimeoutMax,
+// or 0 if the document has no command timeout
+func getCommandTimeout(ioConfig contracts.IOConfiguration) time.Duration {
+	if ioConfig.CommandTimeoutSeconds <= 0 {
+		return 0
+	}
+	if timeout := time.Duration(ioConfig.CommandTimeoutSeconds) * time.Second; timeout < CommandTimeoutMax {
+		return timeout
+	}
+	return CommandTimeoutMax
+}
+
+// timeOutCommand cancels the plugins of a document that ran longer than its command timeout
+func (p *WorkerBackend) timeOutCommand() {
+	p.ctx.Log().Errorf("document did not complete within the command timeout of %v, setting cancel flag...", p.commandTimeout)
+	p.commandTimedOut.Store(true)
+	p.stopExecution(task.Canceled)
+}
+
+// Shutdown asks the running plugins to stop because the worker process is terminating. Once they have cleaned
+// up and returned, the document complete message is sent and messaging stops.
+func (p *WorkerBackend) Shutdown() {
+	p.ctx.Log().Info("requested shutdown of the worker, setting shutdown flag...")
+	p.stopExecution(task.ShutDown)
+}
+
+// stopExecution sets the cancel flag of the plugins and records when they were first asked to stop
+func (p *WorkerBackend) stopExecution(state task.State) {
+	p.stopRequested.CompareAndSwap(0, time.Now().UnixNano())
+	p.cancelFlag.Set(state)
+}
+
+// executionResponsive returns false once the plugins did not return within StopGracePeriod of being asked to stop
+func (p *WorkerBackend) executionResponsive() bool {
+	stopRequested := p.stopRequested.Load()
+	return stopRequested == 0 || time.Since(time.Unix(0, stopRequested)) <= StopGracePeriod
+}
+
 func (p *WorkerBackend) pluginListener(statusChan chan contracts.PluginResult) {
 	log := p.ctx.Log()
 	results := make(map[string]*contracts.PluginResult)
@@ -203,6 +353,7 @@ func (p *WorkerBackend) pluginListener(statusChan chan contracts.PluginResult) {
 			PluginResults: results,
 			LastPlugin:    "",
 		}
+		contracts.SetLastInProgressPlugin(&docResult)
 		log.Info("sending document complete response...")
 		completeMessage, _ := CreateDatagram(MessageTypeComplete, docResult)
 		p.input <- completeMessage
@@ -213,25 +364,71 @@ func (p *WorkerBackend) pluginListener(statusChan chan contracts.PluginResult) {
 		close(p.stopChan)
 	}()
 
-	for res := range statusChan {
-		var result = res
-		results[res.PluginID] = &result
-		//TODO move the aggregator under executer package and protect it, there's global lock in this package
-		status, _, _, _ := contracts.DocumentResultAggregator(log, res.PluginID, results)
-		docResult := contracts.DocumentResult{
-			Status:        status,
-			PluginResults: results,
-			LastPlugin:    res.PluginID,
+	//heartbeats let the master detect a worker process that crashed or stopped exchanging messages before the command times out,
+	//they are sent while the plugins run whatever their progress, and stop when the plugins do not return after being asked to stop
+	heartbeatTicker := time.NewTicker(HeartbeatInterval)
+	defer heartbeatTicker.Stop()
+	p.sendHeartbeat()
+	hung := false
+
+	for listening := true; listening; {
+		select {
+		case res, more := <-statusChan:
+			if !more {
+				listening = false
+				break
+			}
+			var result = res
+			results[res.PluginID] = &result
+			//TODO move the aggregator under executer package and protect it, there's global lock in this package
+			status, _, _, _ := contracts.DocumentResultAggregator(log, res.PluginID, results)
+			docResult := contracts.DocumentResult{
+				Status:        status,
+				PluginResults: results,
+				LastPlugin:    res.PluginID,
+			}
+			replyMessage, _ := CreateDatagram(MessageTypeReply, docResult)
+			log.Debugf("plugin: %v done, sending reply message...", res.PluginID)
+			p.input <- replyMessage
+		case <-heartbeatTicker.C:
+			if p.executionResponsive() {
+				p.sendHeartbeat()
+			} else if !hung {
+				hung = true
+				log.Errorf("plugins did not stop within %v of the stop request, no longer sending heartbeats", StopGracePeriod)
+			}
 		}
-		replyMessage, _ := CreateDatagram(MessageTypeReply, docResult)
-		log.Debugf("plugin: %v done, sending reply message...", res.PluginID)
-		p.input <- replyMessage
 	}
 	log.Info("document execution complete")
+	if p.commandTimedOut.Load() {
+		markTimedOutResults(results, p.commandTimeout)
+	}
 	finalStatus, _, _, _ = contracts.DocumentResultAggregator(log, "", results)
 
 }
 
+// markTimedOutResults reports the steps cancelled because the command timeout was exceeded as timed out
+func markTimedOutResults(results map[string]*contracts.PluginResult, commandTimeout time.Duration) {
+	for _, result := range results {
+		if result.Status != contracts.ResultStatusCancelled {
+			continue
+		}
+		result.Status = contracts.ResultStatusTimedOut
+		result.Code = 1
+		timeoutMessage := fmt.Sprintf("document did not complete within the command timeout of %v", commandTimeout)
+		if result.Error == "" {
+			result.Error = timeoutMessage
+		} else {
+			result.Error = timeoutMessage + ": " + result.Error
+		}
+	}
+}
+
+func (p *WorkerBackend) sendHeartbeat() {
+	heartbeatMessage, _ := CreateDatagram(MessageTypeHeartbeat, "heartbeat")
+	p.input <- heartbeatMessage
+}
+
 func (p *WorkerBackend) Accept() <-chan string {
 	return p.input
 }
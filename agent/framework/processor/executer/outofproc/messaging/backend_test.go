@@ -7,6 +7,8 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/executertest"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
@@ -269,8 +271,12 @@ func TestWorkerBackendPluginListener(t *testing.T) {
 		stopChan: stopChan,
 	}
 	go backend.pluginListener(statusChan)
-	statusChan <- *testCase.results["plugin1"]
+	//the worker reports its liveness as soon as it starts listening
 	data := <-inputChan
+	msgType, _ := ParseDatagram(data)
+	assert.EqualValues(t, MessageTypeHeartbeat, msgType)
+	statusChan <- *testCase.results["plugin1"]
+	data = <-inputChan
 	//cannot assume string equal, unmarshal sometimes switch map's order
 	assert.Equal(t, len(testPluginReplyRawJSON), len(data))
 	statusChan <- *testCase.results["plugin2"]
@@ -288,6 +294,183 @@ func TestWorkerBackendPluginListener(t *testing.T) {
 
 }
 
+func TestWorkerBackendPluginListenerSendsPeriodicHeartbeat(t *testing.T) {
+	defaultInterval := HeartbeatInterval
+	HeartbeatInterval = 10 * time.Millisecond
+	defer func() { HeartbeatInterval = defaultInterval }()
+	statusChan := make(chan contracts.PluginResult)
+	inputChan := make(chan string)
+	stopChan := make(chan int)
+	backend := WorkerBackend{
+		ctx:      contextMock,
+		input:    inputChan,
+		stopChan: stopChan,
+	}
+	go backend.pluginListener(statusChan)
+	for i := 0; i < 3; i++ {
+		msgType, _ := ParseDatagram(<-inputChan)
+		assert.EqualValues(t, MessageTypeHeartbeat, msgType)
+	}
+	close(statusChan)
+	for data := range inputChan {
+		if msgType, _ := ParseDatagram(data); msgType == MessageTypeComplete {
+			break
+		}
+	}
+	assert.Equal(t, stopTypeShutdown, <-stopChan)
+}
+
+func TestWorkerBackend_CommandTimeoutCancelsBlockingPlugin(t *testing.T) {
+	testCase := CreateTestCase()
+	testCase.docState.IOConfig.CommandTimeoutSeconds = 1
+	pluginRunner := func(
+		context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag,
+	) {
+		//the plugin blocks until it is cancelled
+		cancelFlag.Wait()
+		resChan <- contracts.PluginResult{
+			PluginName: "aws:runScript",
+			PluginID:   "plugin1",
+			Status:     contracts.ResultStatusCancelled,
+		}
+		close(resChan)
+	}
+	cancelFlag := task.NewChanneledCancelFlag()
+	backend := WorkerBackend{
+		ctx:        contextMock,
+		input:      make(chan string, 10),
+		cancelFlag: cancelFlag,
+		runner:     pluginRunner,
+		stopChan:   make(chan int, 1),
+	}
+	pluginConfig, _ := CreateDatagram(MessageTypePluginConfig, testCase.docState)
+
+	start := time.Now()
+	assert.NoError(t, backend.Process(pluginConfig))
+	var docResult contracts.DocumentResult
+	for data := range backend.Accept() {
+		if msgType, content := ParseDatagram(data); msgType == MessageTypeComplete {
+			assert.NoError(t, jsonutil.Unmarshal(content, &docResult))
+		}
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.True(t, cancelFlag.Canceled())
+	assert.Equal(t, stopTypeShutdown, <-backend.Stop())
+	assert.Equal(t, contracts.ResultStatusTimedOut, docResult.Status)
+	assert.Equal(t, contracts.ResultStatusTimedOut, docResult.PluginResults["plugin1"].Status)
+	assert.Equal(t, 1, docResult.PluginResults["plugin1"].Code)
+	assert.Equal(t, "document did not complete within the command timeout of 1s", docResult.PluginResults["plugin1"].Error)
+}
+
+func TestGetCommandTimeout(t *testing.T) {
+	testCases := map[int]time.Duration{
+		0:      0,
+		-1:     0,
+		1800:   30 * time.Minute,
+		172800: CommandTimeoutMax,
+		172801: CommandTimeoutMax,
+	}
+	for commandTimeoutSeconds, expected := range testCases {
+		assert.Equal(t, expected, getCommandTimeout(contracts.IOConfiguration{CommandTimeoutSeconds: commandTimeoutSeconds}), commandTimeoutSeconds)
+	}
+}
+
+func TestWorkerBackendPluginListenerStopsHeartbeatWhenPluginsIgnoreCancel(t *testing.T) {
+	defaultInterval, defaultGracePeriod := HeartbeatInterval, StopGracePeriod
+	HeartbeatInterval, StopGracePeriod = 10*time.Millisecond, 50*time.Millisecond
+	defer func() { HeartbeatInterval, StopGracePeriod = defaultInterval, defaultGracePeriod }()
+	statusChan := make(chan contracts.PluginResult)
+	inputChan := make(chan string)
+	stopChan := make(chan int)
+	backend := WorkerBackend{
+		ctx:        contextMock,
+		input:      inputChan,
+		stopChan:   stopChan,
+		cancelFlag: task.NewChanneledCancelFlag(),
+	}
+	go backend.pluginListener(statusChan)
+	msgType, _ := ParseDatagram(<-inputChan)
+	assert.EqualValues(t, MessageTypeHeartbeat, msgType)
+
+	//the plugins never return after the cancel request
+	cancel, _ := CreateDatagram(MessageTypeCancel, "cancel")
+	assert.NoError(t, backend.Process(cancel))
+	assert.True(t, backend.cancelFlag.Canceled())
+	deadline := time.After(StopGracePeriod + 5*HeartbeatInterval)
+	for draining := true; draining; {
+		select {
+		case <-inputChan:
+		case <-deadline:
+			draining = false
+		}
+	}
+	select {
+	case data := <-inputChan:
+		assert.Fail(t, "heartbeat sent after the stop grace period", data)
+	case <-time.After(10 * HeartbeatInterval):
+	}
+
+	close(statusChan)
+	for data := range inputChan {
+		if msgType, _ := ParseDatagram(data); msgType == MessageTypeComplete {
+			break
+		}
+	}
+	assert.Equal(t, stopTypeShutdown, <-stopChan)
+}
+
+func TestExecuterBackend_ProcessHeartbeat(t *testing.T) {
+	testCase := CreateTestCase()
+	outputChan := make(chan contracts.DocumentResult, 10)
+	backend := ExecuterBackend{
+		cancelFlag: taskmocks.NewMockDefault(),
+		output:     outputChan,
+		stopChan:   make(chan int, 1),
+		docState:   &testCase.docState,
+	}
+	//replies from a worker that never sent a heartbeat do not enable liveness tracking
+	err := backend.Process(testPluginReplyRawJSON)
+	assert.NoError(t, err)
+	<-outputChan
+	assert.True(t, backend.LastHeartbeat().IsZero())
+
+	heartbeat, _ := CreateDatagram(MessageTypeHeartbeat, "heartbeat")
+	before := time.Now()
+	err = backend.Process(heartbeat)
+	assert.NoError(t, err)
+	assert.False(t, backend.LastHeartbeat().Before(before))
+	assert.Equal(t, 0, len(outputChan))
+}
+
+func TestExecuterBackend_SavesStepResults(t *testing.T) {
+	testCase := CreateTestCase()
+	outputChan := make(chan contracts.DocumentResult, 10)
+	backend := ExecuterBackend{
+		cancelFlag: taskmocks.NewMockDefault(),
+		output:     outputChan,
+		stopChan:   make(chan int, 1),
+		docState:   &testCase.docState,
+	}
+	docStore := executertest.NewFakeDocumentStore(contracts.DocumentState{})
+	backend.SaveStepResultsTo(docStore)
+
+	assert.NoError(t, backend.Process(testPluginReplyRawJSON))
+	<-outputChan
+	assert.Equal(t, 1, docStore.SaveCount())
+	saved := docStore.Load()
+	assert.Equal(t, contracts.ResultStatusSuccess, saved.InstancePluginsInformation[0].Result.Status)
+	assert.Empty(t, saved.InstancePluginsInformation[1].Result.Status)
+
+	// the final state is saved by the executer once the worker is done
+	assert.NoError(t, backend.Process(testDocumentCompleteRawJSON))
+	<-outputChan
+	assert.Equal(t, 1, docStore.SaveCount())
+}
+
 // this is needed, since after marshal-unmarshalling thru the data channel, the pointer value changed
 func assertValueEqual(t *testing.T, a map[string]*contracts.PluginResult, b map[string]*contracts.PluginResult) {
 	assert.Equal(t, len(a), len(b))
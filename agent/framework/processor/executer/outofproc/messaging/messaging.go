@@ -2,6 +2,7 @@ package messaging
 
 import (
 	"errors"
+	"os"
 	"runtime/debug"
 	"time"
 
@@ -29,8 +30,20 @@ const (
 	MessageTypeComplete     = "complete"
 	MessageTypeReply        = "reply"
 	MessageTypeCancel       = "cancel"
+	MessageTypeHeartbeat    = "heartbeat"
+	MessageTypePause        = "pause"
+	MessageTypeResume       = "resume"
 )
 
+// HeartbeatInterval is the period at which the document worker process reports to the master that it is still running.
+// Heartbeats are not tied to the progress of the plugins: they detect a worker that crashed or stopped exchanging
+// messages, a plugin hanging in a running worker is only detected once it ignores a stop request.
+var HeartbeatInterval = 30 * time.Second
+
+// StopGracePeriod is the time the plugins have to return once the document is cancelled, shut down or timed out.
+// Past it the execution is considered hung and the worker stops sending heartbeats, so that the master fails the document.
+var StopGracePeriod = 2 * time.Minute
+
 var versions = []string{"1.0"}
 
 type Message struct {
@@ -55,6 +68,12 @@ type MessagingBackend interface {
 	ForceQuit()
 }
 
+// UndeliveredDatagramHandler is implemented by the backends that keep the datagrams which could not be sent,
+// so that the results they carry outlive a broken channel
+type UndeliveredDatagramHandler interface {
+	HandleUndeliveredDatagram(datagram string)
+}
+
 // GetLatestVersion retrieves the current latest message version of the agent build
 func GetLatestVersion() string {
 	return versions[len(versions)-1]
@@ -161,6 +180,9 @@ func Messaging(log log.T, ipc filewatcherbasedipc.IPCChannel, backend MessagingB
 			if err = ipc.Send(datagram); err != nil {
 				//this is fatal error, force return
 				log.Errorf("failed to send message to ipc channel: %v", err)
+				if handler, ok := backend.(UndeliveredDatagramHandler); ok {
+					handler.HandleUndeliveredDatagram(datagram)
+				}
 				return
 			}
 		case datagram, more := <-ipc.GetMessage():
@@ -179,3 +201,24 @@ func Messaging(log log.T, ipc filewatcherbasedipc.IPCChannel, backend MessagingB
 		}
 	}
 }
+
+// ShutdownOnSignal shuts the worker backend down on the first signal received and calls forceExit if messaging
+// has not completed within the grace period, so that a plugin that does not stop cannot keep the worker alive.
+// done is closed by the caller once messaging returns.
+func ShutdownOnSignal(log log.T, signals <-chan os.Signal, backend *WorkerBackend, done <-chan struct{}, gracePeriod time.Duration, forceExit func()) {
+	select {
+	case <-done:
+		return
+	case sig := <-signals:
+		log.Infof("received signal %v, shutting down the worker", sig)
+		backend.Shutdown()
+	}
+
+	select {
+	case <-done:
+		log.Info("worker shut down gracefully")
+	case <-time.After(gracePeriod):
+		log.Errorf("worker did not shut down within %v, forcing exit", gracePeriod)
+		forceExit()
+	}
+}
@@ -1,10 +1,24 @@
 package messaging
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
 	channelmock "github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc/mocks"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -74,6 +88,87 @@ func TestMessagingShutdown(t *testing.T) {
 	backendMock.AssertExpectations(t)
 }
 
+func TestShutdownOnSignalStopsPluginsGracefully(t *testing.T) {
+	_ = CreateTestCase()
+	var sawShutdown atomic.Bool
+	pluginRunner := func(
+		context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag,
+	) {
+		cancelFlag.Wait()
+		sawShutdown.Store(cancelFlag.ShutDown())
+		resChan <- contracts.PluginResult{PluginID: "plugin1", Status: contracts.ResultStatusCancelled}
+		close(resChan)
+	}
+	backend := NewWorkerBackend(contextMock, pluginRunner)
+	assert.NoError(t, backend.Process(testPluginsRawJSON))
+
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	returned := make(chan struct{})
+	var forced atomic.Bool
+	go func() {
+		ShutdownOnSignal(logger, signals, backend, done, time.Minute, func() { forced.Store(true) })
+		close(returned)
+	}()
+	signals <- syscall.SIGTERM
+
+	//the worker still reports the plugin results and completes the document before stopping
+	var completed bool
+	for data := range backend.Accept() {
+		if msgType, _ := ParseDatagram(data); msgType == MessageTypeComplete {
+			completed = true
+		}
+	}
+	assert.True(t, completed)
+	assert.Equal(t, stopTypeShutdown, <-backend.Stop())
+	close(done)
+	<-returned
+	assert.True(t, sawShutdown.Load())
+	assert.False(t, forced.Load())
+}
+
+func TestShutdownOnSignalForcesExitAfterGracePeriod(t *testing.T) {
+	_ = CreateTestCase()
+	release := make(chan struct{})
+	defer close(release)
+	//the plugin ignores the shutdown request
+	pluginRunner := func(
+		context context.T,
+		docState contracts.DocumentState,
+		resChan chan contracts.PluginResult,
+		cancelFlag task.CancelFlag,
+	) {
+		<-release
+	}
+	backend := NewWorkerBackend(contextMock, pluginRunner)
+	go func() {
+		for range backend.Accept() {
+		}
+	}()
+	assert.NoError(t, backend.Process(testPluginsRawJSON))
+
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGTERM
+	forced := make(chan struct{})
+	ShutdownOnSignal(logger, signals, backend, make(chan struct{}), 50*time.Millisecond, func() { close(forced) })
+	select {
+	case <-forced:
+	default:
+		assert.Fail(t, "worker exit was not forced")
+	}
+}
+
+func TestShutdownOnSignalReturnsWhenMessagingEnds(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+	ShutdownOnSignal(logger, make(chan os.Signal), nil, done, time.Millisecond, func() {
+		assert.Fail(t, "worker exit should not be forced")
+	})
+}
+
 type BackendMock struct {
 	mock.Mock
 }
@@ -108,3 +203,188 @@ func (m *BackendMock) ForceQuit() {
 func (m *BackendMock) GetBackendState() int32 {
 	return BackendStateProc
 }
+
+// the master is gone when the worker sends the result of the document, the result must still be persisted
+func TestMessagingPersistsResultsWhenSendFails(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	docState := contracts.DocumentState{
+		DocumentInformation:        contracts.DocumentInfo{DocumentID: "documentID", DocumentStatus: contracts.ResultStatusInProgress},
+		InstancePluginsInformation: []contracts.PluginState{{Id: "plugin1", Name: "aws:runShellScript"}},
+	}
+	runner := func(context context.T, docState contracts.DocumentState, resChan chan contracts.PluginResult, cancelFlag task.CancelFlag) {
+		resChan <- contracts.PluginResult{PluginID: "plugin1", PluginName: "aws:runShellScript", Status: contracts.ResultStatusSuccess, Output: "expensive output"}
+		close(resChan)
+	}
+	backend := NewWorkerBackend(ctx, runner)
+	dataStorePath := t.TempDir()
+	//the master creates the current folder when it saves the document before starting the worker
+	instanceID, _ := ctx.Identity().ShortInstanceID()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dataStorePath, instanceID, "document", "state", appconfig.DefaultLocationOfCurrent), 0700))
+	documentMgr := docmanager.NewDocumentFileMgr(ctx, dataStorePath, "document", "state")
+	backend.PersistUndeliveredResults(documentMgr)
+
+	recvChan := make(chan string, 1)
+	channelMock := new(channelmock.MockedChannel)
+	channelMock.On("GetMessage").Return(recvChan)
+	channelMock.On("GetPath").Return("/test/path")
+	channelMock.On("Send", mock.MatchedBy(func(datagram string) bool {
+		messageType, _ := ParseDatagram(datagram)
+		return messageType == MessageTypeHeartbeat
+	})).Return(nil)
+	channelMock.On("Send", mock.Anything).Return(errors.New("master is gone"))
+	configDatagram, _ := CreateDatagram(MessageTypePluginConfig, docState)
+	recvChan <- configDatagram
+
+	err := Messaging(logger, channelMock, backend, make(chan bool))
+
+	assert.Error(t, err)
+	persisted := documentMgr.GetDocumentState("documentID", appconfig.DefaultLocationOfCurrent)
+	if assert.Len(t, persisted.InstancePluginsInformation, 1) {
+		result := persisted.InstancePluginsInformation[0].Result
+		assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+		assert.Equal(t, "expensive output", result.Output)
+	}
+	assert.Equal(t, "documentID", persisted.DocumentInformation.DocumentID)
+}
+
+func TestWorkerBackendIgnoresUndeliveredHeartbeat(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	dataStorePath := t.TempDir()
+	backend := NewWorkerBackend(ctx, nil)
+	backend.PersistUndeliveredResults(docmanager.NewDocumentFileMgr(ctx, dataStorePath, "document", "state"))
+	heartbeat, _ := CreateDatagram(MessageTypeHeartbeat, "")
+
+	backend.HandleUndeliveredDatagram(heartbeat)
+
+	entries, err := os.ReadDir(dataStorePath)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// testIPCChannel is one end of an in-memory IPC channel between the master and the worker
+type testIPCChannel struct {
+	out       chan<- string
+	messages  chan string
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// newTestIPCChannels returns the master and worker ends of an in-memory IPC channel
+func newTestIPCChannels() (master *testIPCChannel, worker *testIPCChannel) {
+	masterToWorker := make(chan string, 100)
+	workerToMaster := make(chan string, 100)
+	return newTestIPCChannel(masterToWorker, workerToMaster), newTestIPCChannel(workerToMaster, masterToWorker)
+}
+
+func newTestIPCChannel(out chan<- string, in <-chan string) *testIPCChannel {
+	c := &testIPCChannel{out: out, messages: make(chan string), closed: make(chan struct{})}
+	go func() {
+		defer close(c.messages)
+		for {
+			select {
+			case message := <-in:
+				select {
+				case c.messages <- message:
+				case <-c.closed:
+					return
+				}
+			case <-c.closed:
+				return
+			}
+		}
+	}()
+	return c
+}
+
+func (c *testIPCChannel) Send(message string) error {
+	select {
+	case <-c.closed:
+		return errors.New("channel closed")
+	default:
+		c.out <- message
+		return nil
+	}
+}
+
+func (c *testIPCChannel) GetMessage() <-chan string {
+	return c.messages
+}
+
+func (c *testIPCChannel) Close() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+func (c *testIPCChannel) Destroy() {
+	c.Close()
+}
+
+func (c *testIPCChannel) CleanupOwnModeFiles() {}
+
+func (c *testIPCChannel) GetPath() string {
+	return "test"
+}
+
+func TestMessagingForwardsPauseAndResumeToWorker(t *testing.T) {
+	testCase := CreateTestCase()
+	docState := testCase.docState
+	started := make(chan string, len(docState.InstancePluginsInformation))
+	releaseFirstStep := make(chan struct{})
+	workerFlag := make(chan task.CancelFlag, 1)
+	// the runner pauses between steps like RunPlugins
+	runner := func(_ context.T, docState contracts.DocumentState, resChan chan contracts.PluginResult, cancelFlag task.CancelFlag) {
+		workerFlag <- cancelFlag
+		for i, plugin := range docState.InstancePluginsInformation {
+			cancelFlag.WaitWhilePaused()
+			started <- plugin.Id
+			if i == 0 {
+				<-releaseFirstStep
+			}
+			resChan <- contracts.PluginResult{PluginID: plugin.Id, PluginName: plugin.Name, Status: contracts.ResultStatusSuccess}
+		}
+		close(resChan)
+	}
+	masterChannel, workerChannel := newTestIPCChannels()
+	masterFlag := task.NewChanneledCancelFlag()
+	defer masterFlag.Set(task.Completed)
+	resChan := make(chan contracts.DocumentResult, 10)
+	masterBackend := NewExecuterBackend(logger, resChan, &docState, masterFlag)
+	workerBackend := NewWorkerBackend(contextmocks.NewMockDefault(), runner)
+	masterDone := make(chan struct{})
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(masterDone)
+		Messaging(logger, masterChannel, masterBackend, make(chan bool))
+	}()
+	go func() {
+		defer close(workerDone)
+		Messaging(logger, workerChannel, workerBackend, make(chan bool))
+	}()
+
+	assert.Equal(t, "plugin1", <-started)
+	flag := <-workerFlag
+	masterFlag.Set(task.Paused)
+	assert.Eventually(t, func() bool { return flag.State() == task.Paused }, 5*time.Second, 10*time.Millisecond)
+	close(releaseFirstStep)
+	select {
+	case pluginID := <-started:
+		assert.Fail(t, "step started while the document is paused", pluginID)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	masterFlag.Set(task.Running)
+	select {
+	case pluginID := <-started:
+		assert.Equal(t, "plugin2", pluginID)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "step did not start once the document was resumed")
+	}
+	<-masterDone
+	<-workerDone
+	close(resChan)
+	var lastResult contracts.DocumentResult
+	for result := range resChan {
+		lastResult = result
+	}
+	assert.Equal(t, contracts.ResultStatusSuccess, lastResult.Status)
+	assert.Len(t, lastResult.PluginResults, 2)
+}
@@ -115,8 +115,19 @@ func InitializeWorkerDependencies(log log.T, args []string) (*appconfig.Ssmagent
 	if err != nil {
 		return nil, nil, "", fmt.Errorf("failed to parse args: %v", err)
 	}
+	agentIdentity, err := LoadAgentIdentity(log, &config)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to get identity: %v", err)
+	}
+	probeProxySettings(log, config, agentIdentity)
+
+	return &config, agentIdentity, channelName, nil
+}
+
+// LoadAgentIdentity resolves the agent identity the way the workers do,
+// with the runtime config identity selector when the runtime config exists
+func LoadAgentIdentity(log log.T, config *appconfig.SsmagentConfig) (identity.IAgentIdentity, error) {
 	var selector identity2.IAgentIdentitySelector
-	var agentIdentity identity.IAgentIdentity
 	runtimeConfigClientHandler := runtimeConfigClientCreator()
 	if ok, err := runtimeConfigClientHandler.ConfigExists(); ok && err == nil {
 		log.Info("picking up runtime config identity selector")
@@ -125,10 +136,5 @@ func InitializeWorkerDependencies(log log.T, args []string) (*appconfig.Ssmagent
 		log.Info("picking up default identity selector")
 		selector = defaultAgentIdentitySelectorCreator(log)
 	}
-	agentIdentity, err = newAgentIdentity(log, &config, selector)
-	if err != nil {
-		return nil, nil, "", fmt.Errorf("failed to get identity: %v", err)
-	}
-
-	return &config, agentIdentity, channelName, nil
+	return newAgentIdentity(log, config, selector)
 }
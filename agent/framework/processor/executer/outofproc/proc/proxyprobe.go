@@ -0,0 +1,174 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proc
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/proxyconfig"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+)
+
+const redactedProxyCredentials = "redacted"
+
+var (
+	getProxyConfig    = proxyconfig.GetProxyConfig
+	proxyProbeTimeout = 5 * time.Second
+)
+
+// probeProxySettings logs the proxy settings the worker inherited from the agent and, when a proxy is configured,
+// checks that the SSM endpoint can be reached through it. A failed probe is only logged, so that a misconfigured
+// proxy shows up early in the worker log instead of as an obscure error from the first plugin calling out.
+// The check runs in the background so that it does not delay the worker, the returned channel is closed once
+// it completes.
+func probeProxySettings(log log.T, config appconfig.SsmagentConfig, agentIdentity identity.IAgentIdentity) <-chan struct{} {
+	done := make(chan struct{})
+	proxyValues := getProxyConfig()
+	if !logProxySettings(log, proxyValues) {
+		close(done)
+		return done
+	}
+
+	endpoint := config.Ssm.Endpoint
+	if endpoint == "" {
+		endpoint = agentIdentity.GetServiceEndpoint("ssm")
+	}
+	if endpoint == "" {
+		log.Warn("unable to determine the SSM endpoint, skipping the proxy reachability check")
+		close(done)
+		return done
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	go func() {
+		defer close(done)
+		if err := checkEndpointThroughProxy(log, config, proxyValues, endpoint); err != nil {
+			log.Warnf("SSM endpoint %s is not reachable through the configured proxy, requests from this worker are likely to fail: %v", endpoint, err)
+			return
+		}
+		log.Infof("SSM endpoint %s is reachable through the configured proxy", endpoint)
+	}()
+	return done
+}
+
+// logProxySettings logs the proxy environment with any credentials removed and reports whether a proxy is set
+func logProxySettings(log log.T, proxyValues map[string]string) bool {
+	proxyConfigured := proxyValues[proxyconfig.PROXY_VAR_HTTPS] != "" || proxyValues[proxyconfig.PROXY_VAR_HTTP] != ""
+	if !proxyConfigured {
+		log.Info("no proxy configured for the worker, connecting to AWS endpoints directly")
+		return false
+	}
+
+	keys := make([]string, 0, len(proxyValues))
+	for key := range proxyValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	log.Info("worker proxy settings:")
+	for _, key := range keys {
+		value := proxyValues[key]
+		if value != "" && key != proxyconfig.PROXY_VAR_BYPASS {
+			value = redactProxyURL(value)
+		}
+		log.Infof("%s: %s", key, value)
+	}
+	return true
+}
+
+// redactProxyURL hides the user info of a proxy url, the proxy may be given with or without a scheme
+func redactProxyURL(value string) string {
+	raw := value
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return "<invalid proxy url>"
+	}
+	if proxyURL.User != nil {
+		proxyURL.User = url.User(redactedProxyCredentials)
+	}
+	if raw != value {
+		return strings.TrimPrefix(proxyURL.String(), "http://")
+	}
+	return proxyURL.String()
+}
+
+// checkEndpointThroughProxy sends a single request to the endpoint through the proxy configured for its scheme,
+// any http response means the proxy let the request through
+func checkEndpointThroughProxy(log log.T, config appconfig.SsmagentConfig, proxyValues map[string]string, endpoint string) error {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %v", err)
+	}
+	proxyValue := proxyValues[proxyconfig.PROXY_VAR_HTTP]
+	if endpointURL.Scheme == "https" {
+		proxyValue = proxyValues[proxyconfig.PROXY_VAR_HTTPS]
+	}
+	if proxyValue == "" || bypassesProxy(endpointURL.Hostname(), proxyValues[proxyconfig.PROXY_VAR_BYPASS]) {
+		log.Infof("requests to %s do not go through the proxy", endpointURL.Host)
+		return nil
+	}
+	if !strings.Contains(proxyValue, "://") {
+		proxyValue = "http://" + proxyValue
+	}
+	proxyURL, err := url.Parse(proxyValue)
+	if err != nil {
+		return fmt.Errorf("invalid proxy url: %s", redactProxyURL(proxyValue))
+	}
+
+	transport := network.GetDefaultTransport(log, config)
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   proxyProbeTimeout,
+	}
+	defer client.CloseIdleConnections()
+
+	response, err := client.Head(endpointURL.String())
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	return nil
+}
+
+// bypassesProxy reports whether the host matches an entry of the comma separated no_proxy list
+func bypassesProxy(host string, bypassList string) bool {
+	for _, entry := range strings.Split(bypassList, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" {
+			return true
+		}
+		entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		if entry == "" {
+			continue
+		}
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
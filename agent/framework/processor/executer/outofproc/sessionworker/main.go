@@ -16,7 +16,10 @@ package main
 
 import (
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/agentlogstocloudwatch/cloudwatchlogspublisher"
@@ -28,6 +31,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/plugin"
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/log/ssmlog"
+	"github.com/aws/amazon-ssm-agent/agent/session/audit"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/aws/amazon-ssm-agent/common/filewatcherbasedipc"
@@ -35,9 +39,17 @@ import (
 
 const (
 	defaultSessionWorkerContextName = "[" + appconfig.SSMSessionWorkerName + "]"
+	// shutdownGracePeriod bounds the time the session plugin has to tear down after SIGTERM
+	shutdownGracePeriod = 30 * time.Second
 )
 
-var sessionPluginRunner = func(
+var (
+	sessionAuditFilePath = audit.FilePath()
+	// activeSessionAudit holds the *audit.Recorder of the running session so that a forced exit can still end it
+	activeSessionAudit atomic.Value
+)
+
+var runSessionPlugins = func(
 	context context.T,
 	docState contracts.DocumentState,
 	resChan chan contracts.PluginResult,
@@ -50,11 +62,32 @@ var sessionPluginRunner = func(
 		runpluginutil.SSMPluginRegistry,
 		resChan,
 		cancelFlag)
+}
+
+var sessionPluginRunner = func(
+	context context.T,
+	docState contracts.DocumentState,
+	resChan chan contracts.PluginResult,
+	cancelFlag task.CancelFlag,
+) {
+	sessionAudit := audit.NewRecorder(context, sessionAuditFilePath, docState)
+	activeSessionAudit.Store(sessionAudit)
+	sessionAudit.Start()
+
+	runSessionPlugins(context, docState, resChan, cancelFlag)
+	sessionAudit.End(audit.ExitReason(cancelFlag))
 
 	//make sure to signal the client that job complete
 	close(resChan)
 }
 
+// endActiveSessionAudit records the end of the running session, if any, when the worker exits before the session plugin returned
+func endActiveSessionAudit() {
+	if sessionAudit, ok := activeSessionAudit.Load().(*audit.Recorder); ok {
+		sessionAudit.End(audit.ExitReasonTerminated)
+	}
+}
+
 // SessionWorker runs as independent worker process when invoked by master agent process and is responsible for running session plugins
 func main() {
 	logger := ssmlog.SSMLogger(false)
@@ -100,7 +133,8 @@ func createFileChannelAndExecutePlugin(context context.T, channelName string) {
 	log := context.Log()
 	log.Infof("document: %v worker started", channelName)
 	//create channel from the given handle identifier by master
-	ipc, err, _ := filewatcherbasedipc.CreateFileWatcherChannel(log, context.Identity(), filewatcherbasedipc.ModeWorker, channelName, false)
+	filewatcherbasedipc.WaitForMessageVisibility = context.AppConfig().Agent.WaitForIPCMessageVisibility
+	ipc, err, _ := filewatcherbasedipc.CreateFileWatcherChannelWithRetry(log, context.Identity(), filewatcherbasedipc.ModeWorker, channelName, false)
 	if err != nil {
 		log.Errorf("failed to create channel: %v", err)
 		return
@@ -112,7 +146,19 @@ func createFileChannelAndExecutePlugin(context context.T, channelName string) {
 	//TODO add command timeout
 	stopTimer := make(chan bool)
 	pipeline := messaging.NewWorkerBackend(context, sessionPluginRunner)
-	//TODO wait for sigterm or send fail message to the channel?
+
+	// on SIGTERM let the session plugin close the session and report its result before the worker exits
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	messagingDone := make(chan struct{})
+	defer close(messagingDone)
+	go messaging.ShutdownOnSignal(log, signals, pipeline, messagingDone, shutdownGracePeriod, func() {
+		endActiveSessionAudit()
+		log.Flush()
+		os.Exit(1)
+	})
+
 	if err = messaging.Messaging(log, ipc, pipeline, stopTimer); err != nil {
 		log.Errorf("messaging worker encountered error: %v", err)
 		//If ipc messaging broke, there's nothing session worker process can do, exit immediately
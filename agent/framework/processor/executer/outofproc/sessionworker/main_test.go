@@ -0,0 +1,136 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/session/audit"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+var sessionDocState = contracts.DocumentState{
+	DocumentInformation: contracts.DocumentInfo{DocumentID: "session-id", DocumentName: "SSM-SessionManagerRunShell"},
+	InstancePluginsInformation: []contracts.PluginState{{
+		Name: "Standard_Stream",
+		Configuration: contracts.Configuration{
+			SessionId:    "session-id",
+			SessionOwner: "arn:aws:iam::123456789012:user/operator",
+		},
+	}},
+}
+
+// setupSessionAudit writes the session audit to a temporary file and replaces the session plugins with the given runner
+func setupSessionAudit(t *testing.T, runner func(cancelFlag task.CancelFlag, resChan chan contracts.PluginResult)) string {
+	oldFilePath, oldRunSessionPlugins := sessionAuditFilePath, runSessionPlugins
+	t.Cleanup(func() {
+		sessionAuditFilePath, runSessionPlugins = oldFilePath, oldRunSessionPlugins
+		activeSessionAudit = atomic.Value{}
+	})
+	sessionAuditFilePath = filepath.Join(t.TempDir(), "audit", "session_audit.log")
+	runSessionPlugins = func(_ context.T, _ contracts.DocumentState, resChan chan contracts.PluginResult, cancelFlag task.CancelFlag) {
+		runner(cancelFlag, resChan)
+	}
+	return sessionAuditFilePath
+}
+
+func readSessionAudit(t *testing.T, filePath string) []audit.Record {
+	file, err := os.Open(filePath)
+	assert.NoError(t, err)
+	defer file.Close()
+	var records []audit.Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record audit.Record
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func assertSessionAudit(t *testing.T, records []audit.Record, exitReason string) {
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, audit.EventStart, records[0].Event)
+		assert.Equal(t, "session-id", records[0].SessionId)
+		assert.Equal(t, "arn:aws:iam::123456789012:user/operator", records[0].Principal)
+		assert.Equal(t, audit.EventEnd, records[1].Event)
+		assert.Equal(t, exitReason, records[1].ExitReason)
+		assert.Equal(t, records[0].StartTime, records[1].StartTime)
+		assert.NotEmpty(t, records[1].StopTime)
+	}
+}
+
+func TestSessionPluginRunnerAuditsCompletedSession(t *testing.T) {
+	filePath := setupSessionAudit(t, func(cancelFlag task.CancelFlag, resChan chan contracts.PluginResult) {
+		resChan <- contracts.PluginResult{PluginID: "Standard_Stream", Status: contracts.ResultStatusSuccess}
+	})
+	resChan := make(chan contracts.PluginResult, 1)
+
+	sessionPluginRunner(contextmocks.NewMockDefault(), sessionDocState, resChan, task.NewChanneledCancelFlag())
+
+	assert.Equal(t, contracts.ResultStatusSuccess, (<-resChan).Status)
+	_, open := <-resChan
+	assert.False(t, open)
+	assertSessionAudit(t, readSessionAudit(t, filePath), audit.ExitReasonCompleted)
+}
+
+func TestSessionPluginRunnerAuditsShutDownSession(t *testing.T) {
+	filePath := setupSessionAudit(t, func(cancelFlag task.CancelFlag, resChan chan contracts.PluginResult) {
+		cancelFlag.Wait()
+	})
+	cancelFlag := task.NewChanneledCancelFlag()
+	done := make(chan struct{})
+
+	go func() {
+		sessionPluginRunner(contextmocks.NewMockDefault(), sessionDocState, make(chan contracts.PluginResult), cancelFlag)
+		close(done)
+	}()
+	// SIGTERM sets the shutdown flag of the worker backend
+	cancelFlag.Set(task.ShutDown)
+	<-done
+
+	assertSessionAudit(t, readSessionAudit(t, filePath), audit.ExitReasonShutdown)
+}
+
+func TestEndActiveSessionAuditOnForcedExit(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	filePath := setupSessionAudit(t, func(cancelFlag task.CancelFlag, resChan chan contracts.PluginResult) {
+		close(started)
+		<-release
+	})
+	done := make(chan struct{})
+
+	go func() {
+		sessionPluginRunner(contextmocks.NewMockDefault(), sessionDocState, make(chan contracts.PluginResult), task.NewChanneledCancelFlag())
+		close(done)
+	}()
+	<-started
+	// the session plugin did not return within the shutdown grace period
+	endActiveSessionAudit()
+	assertSessionAudit(t, readSessionAudit(t, filePath), audit.ExitReasonTerminated)
+
+	// the end record is only written once
+	close(release)
+	<-done
+	assertSessionAudit(t, readSessionAudit(t, filePath), audit.ExitReasonTerminated)
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docmanager"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/messaging"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/outofproc/proc"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/plugin"
@@ -20,8 +22,9 @@ import (
 )
 
 const (
-	defaultCommandTimeoutMax = 172800 * time.Second
 	defaultWorkerContextName = "[" + appconfig.SSMDocumentWorkerName + "]"
+	// outputUploadTimeout bounds how long the worker waits for plugin output to reach S3 before exiting
+	outputUploadTimeout = 2 * time.Minute
 )
 
 var pluginRunner = func(
@@ -68,7 +71,8 @@ func main() {
 
 	logger.Infof("document: %v worker started", channelName)
 	//create channel from the given handle identifier by master
-	ipc, err, _ := filewatcherbasedipc.CreateFileWatcherChannel(logger, agentIdentity, filewatcherbasedipc.ModeWorker, channelName, true)
+	filewatcherbasedipc.WaitForMessageVisibility = cfg.Agent.WaitForIPCMessageVisibility
+	ipc, err, _ := filewatcherbasedipc.CreateFileWatcherChannelWithRetry(logger, agentIdentity, filewatcherbasedipc.ModeWorker, channelName, true)
 	if err != nil {
 		logger.Errorf("failed to create channel: %v", err)
 		logger.Close()
@@ -77,9 +81,11 @@ func main() {
 	//initialize PluginRegistry
 	runpluginutil.SSMPluginRegistry = plugin.RegisteredWorkerPlugins(ctx)
 
-	//TODO add command timeout
+	//the command timeout of the document, if any, is enforced by the worker backend
 	stopTimer := make(chan bool)
 	pipeline := messaging.NewWorkerBackend(ctx, pluginRunner)
+	//keep the results if the master is gone by the time they are sent
+	pipeline.PersistUndeliveredResults(docmanager.NewDocumentFileMgr(ctx, appconfig.DefaultDataStorePath, appconfig.DefaultDocumentRootDirName, appconfig.DefaultLocationOfState))
 	//TODO wait for sigterm or send fail message to the channel?
 	if err = messaging.Messaging(logger, ipc, pipeline, stopTimer); err != nil {
 		logger.Errorf("messaging worker encountered error: %v", err)
@@ -87,8 +93,10 @@ func main() {
 		logger.Close()
 		return
 	}
+	if uploadErr := iomodule.WaitForPendingUploads(outputUploadTimeout); uploadErr != nil {
+		logger.Errorf("document worker exiting before its output was uploaded: %v", uploadErr)
+	}
 	logger.Info("document worker closed")
-	//TODO figure out s3 aync problem
 	//TODO figure out why defer main doesnt work on windows
 	if err != nil {
 		os.Exit(1)
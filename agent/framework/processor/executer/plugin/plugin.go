@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/certexpiry"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurecontainers"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/configurepackage"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/dockercontainer"
@@ -43,22 +44,25 @@ import (
 // This allows us to differentiate between the case where a document asks for a plugin that exists but isn't supported on this platform
 // and the case where a plugin name isn't known at all to this version of the agent (and the user should probably upgrade their agent)
 var allPlugins = map[string]struct{}{
-	appconfig.PluginNameAwsAgentUpdate:         {},
-	appconfig.PluginNameAwsApplications:        {},
-	appconfig.PluginNameAwsConfigureDaemon:     {},
-	appconfig.PluginNameAwsConfigurePackage:    {},
-	appconfig.PluginNameAwsPowerShellModule:    {},
-	appconfig.PluginNameAwsRunPowerShellScript: {},
-	appconfig.PluginNameAwsRunShellScript:      {},
-	appconfig.PluginNameAwsSoftwareInventory:   {},
-	appconfig.PluginNameCloudWatch:             {},
-	appconfig.PluginNameConfigureDocker:        {},
-	appconfig.PluginNameDockerContainer:        {},
-	appconfig.PluginNameDomainJoin:             {},
-	appconfig.PluginEC2ConfigUpdate:            {},
-	appconfig.PluginNameRefreshAssociation:     {},
-	appconfig.PluginDownloadContent:            {},
-	appconfig.PluginRunDocument:                {},
+	appconfig.PluginNameAwsAgentUpdate:            {},
+	appconfig.PluginNameAwsApplications:           {},
+	appconfig.PluginNameAwsCheckCertificateExpiry: {},
+	appconfig.PluginNameAwsConfigureDaemon:        {},
+	appconfig.PluginNameAwsConfigureKernelModules: {},
+	appconfig.PluginNameAwsConfigureRegistry:      {},
+	appconfig.PluginNameAwsConfigurePackage:       {},
+	appconfig.PluginNameAwsPowerShellModule:       {},
+	appconfig.PluginNameAwsRunPowerShellScript:    {},
+	appconfig.PluginNameAwsRunShellScript:         {},
+	appconfig.PluginNameAwsSoftwareInventory:      {},
+	appconfig.PluginNameCloudWatch:                {},
+	appconfig.PluginNameConfigureDocker:           {},
+	appconfig.PluginNameDockerContainer:           {},
+	appconfig.PluginNameDomainJoin:                {},
+	appconfig.PluginEC2ConfigUpdate:               {},
+	appconfig.PluginNameRefreshAssociation:        {},
+	appconfig.PluginDownloadContent:               {},
+	appconfig.PluginRunDocument:                   {},
 }
 
 var once sync.Once
@@ -136,6 +140,13 @@ func (r RunDocumentFactory) Create(context context.T) (runpluginutil.T, error) {
 	return rundocument.NewPlugin(context)
 }
 
+type CheckCertificateExpiryFactory struct {
+}
+
+func (f CheckCertificateExpiryFactory) Create(context context.T) (runpluginutil.T, error) {
+	return certexpiry.NewPlugin(context)
+}
+
 type SessionPluginFactory struct {
 	newPluginFunc sessionplugin.NewPluginFunc
 }
@@ -160,6 +171,12 @@ func RegisteredWorkerPlugins(context context.T) runpluginutil.PluginRegistry {
 	return *registeredPlugins
 }
 
+// WorkerPluginSupport returns the support status of the registered worker plugins and of the
+// additional plugin names on the current platform, sorted by plugin name.
+func WorkerPluginSupport(context context.T, pluginNames ...string) []runpluginutil.PluginSupport {
+	return runpluginutil.DescribePluginSupport(context.Log(), RegisteredWorkerPlugins(context), pluginNames...)
+}
+
 // RegisteredSessionWorkerPlugins returns all registered session plugins.
 func RegisteredSessionWorkerPlugins() runpluginutil.PluginRegistry {
 	once.Do(func() {
@@ -169,21 +186,27 @@ func RegisteredSessionWorkerPlugins() runpluginutil.PluginRegistry {
 }
 
 // loadWorkers loads all worker plugins that are invokers for interacting with long running plugins and
-// then all standard worker plugins (if there are any conflicting names, the standard worker plugin wins)
+// then all standard worker plugins (if there are any conflicting names, the plugin loaded first is kept)
 func loadWorkers(context context.T) {
 	plugins := runpluginutil.PluginRegistry{}
 
 	//Long running plugins are handled by lrpm. lrpminvoker is a worker plugin that can communicate with lrpm.
 	//that's why all long running plugins are first handled by lrpminvoker - which then hands off the work to lrpm.
-	plugins[appconfig.PluginNameCloudWatch] = CloudWatchFactory{}
+	plugins.MustRegister(appconfig.PluginNameCloudWatch, CloudWatchFactory{})
 
 	for key, value := range loadPlatformIndependentPlugins(context) {
-		plugins[key] = value
+		if err := plugins.Register(key, value); err != nil {
+			context.Log().Errorf("Failed to load platform independent plugin: %v", err)
+			continue
+		}
 		context.Log().Infof("Successfully loaded platform independent plugin %v", key)
 	}
 
 	for key, value := range loadPlatformDependentPlugins(context) {
-		plugins[key] = value
+		if err := plugins.Register(key, value); err != nil {
+			context.Log().Errorf("Failed to load platform dependent plugin: %v", err)
+			continue
+		}
 		context.Log().Infof("Successfully loaded platform dependent plugin %v", key)
 	}
 
@@ -195,16 +218,16 @@ func loadSessionPlugins() {
 	var sessionPlugins = runpluginutil.PluginRegistry{}
 
 	standardStreamPluginName := appconfig.PluginNameStandardStream
-	sessionPlugins[standardStreamPluginName] = SessionPluginFactory{standardstream.NewPlugin}
+	sessionPlugins.MustRegister(standardStreamPluginName, SessionPluginFactory{standardstream.NewPlugin})
 
 	interactiveCommandsPluginName := appconfig.PluginNameInteractiveCommands
-	sessionPlugins[interactiveCommandsPluginName] = SessionPluginFactory{interactivecommands.NewPlugin}
+	sessionPlugins.MustRegister(interactiveCommandsPluginName, SessionPluginFactory{interactivecommands.NewPlugin})
 
 	portPluginName := appconfig.PluginNamePort
-	sessionPlugins[portPluginName] = SessionPluginFactory{port.NewPlugin}
+	sessionPlugins.MustRegister(portPluginName, SessionPluginFactory{port.NewPlugin})
 
 	nonInteractiveCommandsPluginName := appconfig.PluginNameNonInteractiveCommands
-	sessionPlugins[nonInteractiveCommandsPluginName] = SessionPluginFactory{noninteractivecommands.NewPlugin}
+	sessionPlugins.MustRegister(nonInteractiveCommandsPluginName, SessionPluginFactory{noninteractivecommands.NewPlugin})
 
 	registeredPlugins = &sessionPlugins
 }
@@ -214,39 +237,43 @@ func loadPlatformIndependentPlugins(context context.T) runpluginutil.PluginRegis
 	var workerPlugins = runpluginutil.PluginRegistry{}
 
 	inventoryPluginName := inventory.Name()
-	workerPlugins[inventoryPluginName] = InventoryGathererFactory{}
+	workerPlugins.MustRegister(inventoryPluginName, InventoryGathererFactory{})
 
 	// registering aws:runPowerShellScript plugin
-	workerPlugins[appconfig.PluginNameAwsRunPowerShellScript] = RunPowerShellFactory{}
+	workerPlugins.MustRegister(appconfig.PluginNameAwsRunPowerShellScript, RunPowerShellFactory{})
 
 	// registering aws:updateSsmAgent plugin
 	updateAgentPluginName := updatessmagent.Name()
-	workerPlugins[updateAgentPluginName] = UpdateAgentFactory{}
+	workerPlugins.MustRegister(updateAgentPluginName, UpdateAgentFactory{})
 
 	// registering aws:configureContainers plugin
 	configureContainersPluginName := configurecontainers.Name()
 
-	workerPlugins[configureContainersPluginName] = ConfigureContainerFactory{}
+	workerPlugins.MustRegister(configureContainersPluginName, ConfigureContainerFactory{})
 
 	// registering aws:runDockerAction plugin
 	runDockerPluginName := dockercontainer.Name()
-	workerPlugins[runDockerPluginName] = RunDockerFactory{}
+	workerPlugins.MustRegister(runDockerPluginName, RunDockerFactory{})
 
 	// registering aws:refreshAssociation plugin
 	refreshAssociationPluginName := refreshassociation.Name()
-	workerPlugins[refreshAssociationPluginName] = RefreshAssociationFactory{}
+	workerPlugins.MustRegister(refreshAssociationPluginName, RefreshAssociationFactory{})
 
 	// registering aws:configurePackage
 	configurePackagePluginName := configurepackage.Name()
-	workerPlugins[configurePackagePluginName] = ConfigurePackageFactory{}
+	workerPlugins.MustRegister(configurePackagePluginName, ConfigurePackageFactory{})
 
 	//registering aws:downloadContent
 	downloadContentPluginName := downloadcontent.Name()
-	workerPlugins[downloadContentPluginName] = DownloadContentFactory{}
+	workerPlugins.MustRegister(downloadContentPluginName, DownloadContentFactory{})
 
 	//registering aws:runDocument
 	runDocumentPluginName := rundocument.Name()
-	workerPlugins[runDocumentPluginName] = RunDocumentFactory{}
+	workerPlugins.MustRegister(runDocumentPluginName, RunDocumentFactory{})
+
+	//registering aws:checkCertificateExpiry
+	checkCertificateExpiryPluginName := certexpiry.Name()
+	workerPlugins.MustRegister(checkCertificateExpiryPluginName, CheckCertificateExpiryFactory{})
 
 	return workerPlugins
 }
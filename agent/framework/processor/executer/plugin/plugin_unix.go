@@ -20,6 +20,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configurekernelmodules"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/domainjoin"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/runscript"
 )
@@ -38,12 +39,20 @@ func (f DomainJoinFactory) Create(context context.T) (runpluginutil.T, error) {
 	return domainjoin.NewPlugin(context)
 }
 
+type ConfigureKernelModulesFactory struct {
+}
+
+func (f ConfigureKernelModulesFactory) Create(context context.T) (runpluginutil.T, error) {
+	return configurekernelmodules.NewPlugin(context)
+}
+
 // loadPlatformDependentPlugins registers platform dependent plugins
 func loadPlatformDependentPlugins(context context.T) runpluginutil.PluginRegistry {
 	var workerPlugins = runpluginutil.PluginRegistry{}
 
-	workerPlugins[appconfig.PluginNameAwsRunShellScript] = RunShellScriptFactory{}
-	workerPlugins[appconfig.PluginNameDomainJoin] = DomainJoinFactory{}
+	workerPlugins.MustRegister(appconfig.PluginNameAwsRunShellScript, RunShellScriptFactory{})
+	workerPlugins.MustRegister(appconfig.PluginNameDomainJoin, DomainJoinFactory{})
+	workerPlugins.MustRegister(appconfig.PluginNameAwsConfigureKernelModules, ConfigureKernelModulesFactory{})
 
 	return workerPlugins
 }
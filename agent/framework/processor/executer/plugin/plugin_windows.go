@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/framework/runpluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/application"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/configureregistry"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/domainjoin"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/psmodule"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/updateec2config"
@@ -56,25 +57,36 @@ func (f UpdateEc2ConfigFactory) Create(context context.T) (runpluginutil.T, erro
 	return updateec2config.NewPlugin(context, updateec2config.GetUpdatePluginConfig(context))
 }
 
+type ConfigureRegistryFactory struct {
+}
+
+func (f ConfigureRegistryFactory) Create(context context.T) (runpluginutil.T, error) {
+	return configureregistry.NewPlugin(context)
+}
+
 // loadPlatformDependentPlugins registers platform dependent plugins
 func loadPlatformDependentPlugins(context context.T) runpluginutil.PluginRegistry {
 	var workerPlugins = runpluginutil.PluginRegistry{}
 
 	// registering aws:psModule plugin
 	psModulePluginName := psmodule.Name()
-	workerPlugins[psModulePluginName] = PsModuleFactory{}
+	workerPlugins.MustRegister(psModulePluginName, PsModuleFactory{})
 
 	// registering aws:applications plugin
 	applicationPluginName := application.Name()
-	workerPlugins[applicationPluginName] = ApplicationFactory{}
+	workerPlugins.MustRegister(applicationPluginName, ApplicationFactory{})
 
 	// registering aws:domainJoin plugin
 	domainJoinPluginName := domainjoin.Name()
-	workerPlugins[domainJoinPluginName] = DomainJoinFactory{}
+	workerPlugins.MustRegister(domainJoinPluginName, DomainJoinFactory{})
+
+	// registering aws:configureRegistry plugin
+	configureRegistryPluginName := configureregistry.Name()
+	workerPlugins.MustRegister(configureRegistryPluginName, ConfigureRegistryFactory{})
 
 	// registering aws:updateAgent plugin.
 	updateEC2AgentPluginName := updateec2config.Name()
-	workerPlugins[updateEC2AgentPluginName] = UpdateEc2ConfigFactory{}
+	workerPlugins.MustRegister(updateEC2AgentPluginName, UpdateEc2ConfigFactory{})
 
 	//// registering aws:configureDaemon
 	//configureDaemonPluginName := configuredaemon.Name()
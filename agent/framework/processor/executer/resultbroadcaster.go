@@ -0,0 +1,126 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executer
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// BackpressurePolicy decides what happens to a result when the buffer of a subscriber is full
+type BackpressurePolicy int
+
+const (
+	// BlockOnFullBuffer waits until the subscriber has room for the result, which holds back the other subscribers
+	// and the executer, so every subscriber receives every result
+	BlockOnFullBuffer BackpressurePolicy = iota
+	// DropOnFullBuffer skips the result for the subscriber, which then misses it
+	DropOnFullBuffer
+)
+
+// ResultSubscription receives the results forwarded by a ResultBroadcaster
+type ResultSubscription struct {
+	// Results is closed once the result channel of the executer is closed
+	Results <-chan contracts.DocumentResult
+	results chan contracts.DocumentResult
+	policy  BackpressurePolicy
+	dropped int64
+}
+
+// Dropped returns the number of results the subscription missed because its buffer was full
+func (subscription *ResultSubscription) Dropped() int {
+	return int(atomic.LoadInt64(&subscription.dropped))
+}
+
+// ResultBroadcaster forwards every result of the result channel returned by an Executer to each of its subscriptions,
+// so that several consumers receive the document and step results without competing for the single channel.
+// The subscriptions receive the results forwarded after they subscribed.
+type ResultBroadcaster struct {
+	m             sync.Mutex
+	subscriptions []*ResultSubscription
+	closed        bool
+}
+
+// NewResultBroadcaster creates a broadcaster without subscriptions
+func NewResultBroadcaster() *ResultBroadcaster {
+	return &ResultBroadcaster{}
+}
+
+// Subscribe registers a subscription buffering up to bufferSize results, the policy applies when the buffer is full.
+// The subscription is closed right away when the broadcaster is already done.
+func (broadcaster *ResultBroadcaster) Subscribe(bufferSize int, policy BackpressurePolicy) *ResultSubscription {
+	results := make(chan contracts.DocumentResult, bufferSize)
+	subscription := &ResultSubscription{Results: results, results: results, policy: policy}
+
+	broadcaster.m.Lock()
+	defer broadcaster.m.Unlock()
+	if broadcaster.closed {
+		close(results)
+		return subscription
+	}
+	broadcaster.subscriptions = append(broadcaster.subscriptions, subscription)
+	return subscription
+}
+
+// Run forwards the results of resChan to the subscriptions until resChan is closed, then closes the subscriptions.
+// Each subscription receives its own copy of the plugin results map, since the executer keeps updating the same map.
+func (broadcaster *ResultBroadcaster) Run(resChan chan contracts.DocumentResult) {
+	if resChan != nil {
+		for res := range resChan {
+			broadcaster.m.Lock()
+			subscriptions := broadcaster.subscriptions
+			broadcaster.m.Unlock()
+
+			for _, subscription := range subscriptions {
+				subscription.send(res)
+			}
+		}
+	}
+
+	broadcaster.m.Lock()
+	defer broadcaster.m.Unlock()
+	broadcaster.closed = true
+	for _, subscription := range broadcaster.subscriptions {
+		close(subscription.results)
+	}
+	broadcaster.subscriptions = nil
+}
+
+// send forwards a copy of the result to the subscription according to its policy
+func (subscription *ResultSubscription) send(res contracts.DocumentResult) {
+	res.PluginResults = copyPluginResults(res.PluginResults)
+	if subscription.policy == BlockOnFullBuffer {
+		subscription.results <- res
+		return
+	}
+	select {
+	case subscription.results <- res:
+	default:
+		atomic.AddInt64(&subscription.dropped, 1)
+	}
+}
+
+// FanOutResults forwards every result of resChan to count new channels, each buffering up to bufferSize results
+// with the given policy, and closes them once resChan is closed.
+func FanOutResults(resChan chan contracts.DocumentResult, count int, bufferSize int, policy BackpressurePolicy) []<-chan contracts.DocumentResult {
+	broadcaster := NewResultBroadcaster()
+	channels := make([]<-chan contracts.DocumentResult, count)
+	for i := range channels {
+		channels[i] = broadcaster.Subscribe(bufferSize, policy).Results
+	}
+	go broadcaster.Run(resChan)
+	return channels
+}
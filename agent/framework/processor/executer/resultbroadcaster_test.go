@@ -0,0 +1,99 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+// sendDocumentResults sends the results of a two step document
+func sendDocumentResults(resChan chan contracts.DocumentResult) {
+	results := map[string]*contracts.PluginResult{}
+	results["step1"] = &contracts.PluginResult{PluginID: "step1", Status: contracts.ResultStatusSuccess}
+	resChan <- contracts.DocumentResult{LastPlugin: "step1", Status: contracts.ResultStatusInProgress, PluginResults: results, NPlugins: 2}
+	results = copyPluginResults(results)
+	results["step2"] = &contracts.PluginResult{PluginID: "step2", Status: contracts.ResultStatusSuccess}
+	resChan <- contracts.DocumentResult{LastPlugin: "step2", Status: contracts.ResultStatusInProgress, PluginResults: results, NPlugins: 2}
+	resChan <- contracts.DocumentResult{LastPlugin: "", Status: contracts.ResultStatusSuccess, PluginResults: results, NPlugins: 2}
+	close(resChan)
+}
+
+func collectResults(results <-chan contracts.DocumentResult) (collected []contracts.DocumentResult) {
+	for res := range results {
+		collected = append(collected, res)
+	}
+	return collected
+}
+
+func TestFanOutResults(t *testing.T) {
+	resChan := make(chan contracts.DocumentResult)
+	channels := FanOutResults(resChan, 2, 0, BlockOnFullBuffer)
+	go sendDocumentResults(resChan)
+
+	var wg sync.WaitGroup
+	collected := make([][]contracts.DocumentResult, len(channels))
+	for i, results := range channels {
+		wg.Add(1)
+		go func(i int, results <-chan contracts.DocumentResult) {
+			defer wg.Done()
+			collected[i] = collectResults(results)
+		}(i, results)
+	}
+	wg.Wait()
+
+	for _, results := range collected {
+		if assert.Len(t, results, 3) {
+			assert.Equal(t, "step1", results[0].LastPlugin)
+			assert.Len(t, results[0].PluginResults, 1)
+			assert.Equal(t, "step2", results[1].LastPlugin)
+			assert.Len(t, results[1].PluginResults, 2)
+			assert.Equal(t, "", results[2].LastPlugin)
+			assert.Equal(t, contracts.ResultStatusSuccess, results[2].Status)
+		}
+	}
+}
+
+func TestResultBroadcasterDropsForFullSubscription(t *testing.T) {
+	broadcaster := NewResultBroadcaster()
+	blocking := broadcaster.Subscribe(3, BlockOnFullBuffer)
+	dropping := broadcaster.Subscribe(1, DropOnFullBuffer)
+	resChan := make(chan contracts.DocumentResult, 3)
+	sendDocumentResults(resChan)
+
+	broadcaster.Run(resChan)
+
+	assert.Len(t, collectResults(blocking.Results), 3)
+	assert.Equal(t, 0, blocking.Dropped())
+	droppedResults := collectResults(dropping.Results)
+	if assert.Len(t, droppedResults, 1) {
+		assert.Equal(t, "step1", droppedResults[0].LastPlugin)
+	}
+	assert.Equal(t, 2, dropping.Dropped())
+}
+
+func TestResultBroadcasterSubscribeAfterRun(t *testing.T) {
+	broadcaster := NewResultBroadcaster()
+	resChan := make(chan contracts.DocumentResult)
+	close(resChan)
+	broadcaster.Run(resChan)
+
+	subscription := broadcaster.Subscribe(1, BlockOnFullBuffer)
+
+	_, open := <-subscription.Results
+	assert.False(t, open)
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executer
+
+import (
+	"errors"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// DrainResults consumes the result channel returned by an Executer until the document level result arrives, which is
+// the one without LastPlugin. The step level updates received before it are returned in order as history, each with
+// a copy of the plugin results map taken when it was received, since the executer keeps updating the same map.
+// If the channel closes before the document level result, the returned result is marked Failed along with an error.
+func DrainResults(resChan chan contracts.DocumentResult) (final contracts.DocumentResult, history []contracts.DocumentResult, err error) {
+	if resChan == nil {
+		final.Status = contracts.ResultStatusFailed
+		return final, history, errors.New("no result channel to read document results from")
+	}
+	for res := range resChan {
+		if res.LastPlugin == "" {
+			return res, history, nil
+		}
+		res.PluginResults = copyPluginResults(res.PluginResults)
+		history = append(history, res)
+	}
+
+	// keep what is known about the document from the last update
+	if len(history) > 0 {
+		final = history[len(history)-1]
+		final.LastPlugin = ""
+	}
+	final.Status = contracts.ResultStatusFailed
+	return final, history, errors.New("result channel closed before the document completed")
+}
+
+func copyPluginResults(results map[string]*contracts.PluginResult) map[string]*contracts.PluginResult {
+	if results == nil {
+		return nil
+	}
+	copied := make(map[string]*contracts.PluginResult, len(results))
+	for pluginID, result := range results {
+		copied[pluginID] = result
+	}
+	return copied
+}
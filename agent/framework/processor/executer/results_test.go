@@ -0,0 +1,90 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executer
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainResults(t *testing.T) {
+	resChan := make(chan contracts.DocumentResult, 3)
+	results := map[string]*contracts.PluginResult{}
+
+	results["step1"] = &contracts.PluginResult{PluginID: "step1", Status: contracts.ResultStatusSuccess}
+	resChan <- contracts.DocumentResult{LastPlugin: "step1", Status: contracts.ResultStatusInProgress, PluginResults: results, NPlugins: 2}
+	results["step2"] = &contracts.PluginResult{PluginID: "step2", Status: contracts.ResultStatusFailed}
+	resChan <- contracts.DocumentResult{LastPlugin: "step2", Status: contracts.ResultStatusFailed, PluginResults: results, NPlugins: 2}
+	resChan <- contracts.DocumentResult{LastPlugin: "", Status: contracts.ResultStatusFailed, PluginResults: results, NPlugins: 2}
+	close(resChan)
+
+	final, history, err := DrainResults(resChan)
+	assert.Nil(t, err)
+	assert.Equal(t, contracts.ResultStatusFailed, final.Status)
+	assert.Equal(t, "", final.LastPlugin)
+	assert.Equal(t, 2, len(final.PluginResults))
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, "step1", history[0].LastPlugin)
+	assert.Equal(t, contracts.ResultStatusInProgress, history[0].Status)
+	assert.Equal(t, "step2", history[1].LastPlugin)
+
+	// the history is not affected by later updates of the executer's results map
+	results["step3"] = &contracts.PluginResult{PluginID: "step3"}
+	assert.Equal(t, 2, len(history[0].PluginResults))
+	assert.Equal(t, 2, len(history[1].PluginResults))
+}
+
+func TestDrainResultsStopsAtDocumentResult(t *testing.T) {
+	resChan := make(chan contracts.DocumentResult, 1)
+	resChan <- contracts.DocumentResult{Status: contracts.ResultStatusSuccess}
+
+	final, history, err := DrainResults(resChan)
+	assert.Nil(t, err)
+	assert.Equal(t, contracts.ResultStatusSuccess, final.Status)
+	assert.Empty(t, history)
+}
+
+func TestDrainResultsClosedWithoutDocumentResult(t *testing.T) {
+	resChan := make(chan contracts.DocumentResult, 1)
+	resChan <- contracts.DocumentResult{
+		DocumentName:  "doc",
+		LastPlugin:    "step1",
+		Status:        contracts.ResultStatusInProgress,
+		PluginResults: map[string]*contracts.PluginResult{"step1": {PluginID: "step1"}},
+	}
+	close(resChan)
+
+	final, history, err := DrainResults(resChan)
+	assert.NotNil(t, err)
+	assert.Equal(t, contracts.ResultStatusFailed, final.Status)
+	assert.Equal(t, "doc", final.DocumentName)
+	assert.Equal(t, "", final.LastPlugin)
+	assert.Equal(t, 1, len(history))
+	assert.Equal(t, contracts.ResultStatusInProgress, history[0].Status)
+}
+
+func TestDrainResultsWithoutChannel(t *testing.T) {
+	final, history, err := DrainResults(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, contracts.ResultStatusFailed, final.Status)
+	assert.Empty(t, history)
+
+	resChan := make(chan contracts.DocumentResult)
+	close(resChan)
+	final, _, err = DrainResults(resChan)
+	assert.NotNil(t, err)
+	assert.Equal(t, contracts.ResultStatusFailed, final.Status)
+}
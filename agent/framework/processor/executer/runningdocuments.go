@@ -0,0 +1,57 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// runningDocuments holds the cancel flags of the documents run by the executers, indexed by document id
+var runningDocuments = struct {
+	sync.Mutex
+	cancelFlags map[string]task.CancelFlag
+}{cancelFlags: make(map[string]task.CancelFlag)}
+
+// RegisterRunningDocument makes the document cancellable by CancelDocument while it runs
+func RegisterRunningDocument(documentID string, cancelFlag task.CancelFlag) {
+	runningDocuments.Lock()
+	defer runningDocuments.Unlock()
+	runningDocuments.cancelFlags[documentID] = cancelFlag
+}
+
+// UnregisterRunningDocument removes the document once its run is over,
+// unless the document id has been registered again by a later run
+func UnregisterRunningDocument(documentID string, cancelFlag task.CancelFlag) {
+	runningDocuments.Lock()
+	defer runningDocuments.Unlock()
+	if runningDocuments.cancelFlags[documentID] == cancelFlag {
+		delete(runningDocuments.cancelFlags, documentID)
+	}
+}
+
+// CancelDocument requests the cancellation of a running document. The steps not started yet are not run
+// and the running step is asked to cancel, in the document worker as well for out-of-process executions.
+func CancelDocument(documentID string) error {
+	runningDocuments.Lock()
+	defer runningDocuments.Unlock()
+	cancelFlag, found := runningDocuments.cancelFlags[documentID]
+	if !found {
+		return fmt.Errorf("document %v is not running", documentID)
+	}
+	cancelFlag.Set(task.Canceled)
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package executer
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelDocument(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	RegisterRunningDocument("documentID", cancelFlag)
+	defer UnregisterRunningDocument("documentID", cancelFlag)
+
+	assert.NoError(t, CancelDocument("documentID"))
+	assert.True(t, cancelFlag.Canceled())
+}
+
+func TestCancelDocumentNotRunning(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	RegisterRunningDocument("documentID", cancelFlag)
+	UnregisterRunningDocument("documentID", cancelFlag)
+
+	assert.EqualError(t, CancelDocument("documentID"), "document documentID is not running")
+	assert.EqualError(t, CancelDocument("unknownDocumentID"), "document unknownDocumentID is not running")
+	assert.False(t, cancelFlag.Canceled())
+}
+
+func TestUnregisterRunningDocumentKeepsLaterRun(t *testing.T) {
+	previousCancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag := task.NewChanneledCancelFlag()
+	RegisterRunningDocument("documentID", previousCancelFlag)
+	RegisterRunningDocument("documentID", cancelFlag)
+	defer UnregisterRunningDocument("documentID", cancelFlag)
+	UnregisterRunningDocument("documentID", previousCancelFlag)
+
+	assert.NoError(t, CancelDocument("documentID"))
+	assert.True(t, cancelFlag.Canceled())
+	assert.False(t, previousCancelFlag.Canceled())
+}
@@ -100,6 +100,9 @@ type EngineProcessor struct {
 	startWorker                 *workerProcessorSpec
 	cancelWorker                *workerProcessorSpec
 	poolToProcessorErrorCodeMap map[task.PoolErrorCode]ErrorCode
+	statusGuard                 *terminalStatusGuard
+	// documentLimiter bounds the documents run at the same time with the other processors, nil does not limit them
+	documentLimiter *documentLimiter
 }
 
 // WorkerProcessorSpec contains properties and methods to specify worker related specifications needed for the processor
@@ -175,6 +178,12 @@ func NewEngineProcessor(ctx context.T, startWorker *workerProcessorSpec, cancelW
 		startWorker:                 startWorker,
 		cancelWorker:                cancelWorker,
 		poolToProcessorErrorCodeMap: make(map[task.PoolErrorCode]ErrorCode),
+		statusGuard:                 newTerminalStatusGuard(),
+	}
+	// commands and associations share the document workers limit, sessions are not limited
+	switch startWorker.assignedDocType {
+	case contracts.SendCommand, contracts.SendCommandOffline, contracts.Association:
+		engineProcessor.documentLimiter = getSharedDocumentLimiter(ctx.AppConfig().Agent.DocumentWorkersLimit)
 	}
 	engineProcessor.loadProcessorPoolErrorCodes()
 	return engineProcessor
@@ -201,6 +210,9 @@ func (p *EngineProcessor) InitialProcessing(skipDocumentIfExpired bool) (err err
 
 	log.Debug("Initial processing")
 
+	// clean up the channels and workers left behind by a previous agent process before resuming its documents
+	reconcileWorkersOnce.Do(p.reconcileWorkers)
+
 	// preloading pending files is added here to handle the below case:
 	// In-progress documents starts submission by pushing it to the pending state.
 	// This may lead to load same documents again when calling function processPendingDocuments
@@ -306,13 +318,7 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState, isInProgress
 	}
 	//TODO this is a hack, in future jobID should be managed by Processing engine itself, instead of inferring from job's internal field
 	err := p.sendCommandPool.Submit(log, jobID, func(cancelFlag task.CancelFlag) {
-		processCommand(
-			p.context,
-			p.executerCreator,
-			cancelFlag,
-			p.resChan,
-			docState,
-			p.documentMgr)
+		p.runCommand(cancelFlag, docState)
 	})
 	if err != nil {
 		// currently, we have only Duplicate command error returned by the job pool
@@ -330,6 +336,114 @@ func (p *EngineProcessor) submit(docState *contracts.DocumentState, isInProgress
 	return "" // considered submission successful
 }
 
+// runCommand runs the document once a document worker is free
+func (p *EngineProcessor) runCommand(cancelFlag task.CancelFlag, docState *contracts.DocumentState) {
+	release, run := p.waitForDocumentWorker(cancelFlag, docState)
+	if !run {
+		return
+	}
+	defer release()
+	processCommand(
+		p.context,
+		p.executerCreator,
+		cancelFlag,
+		p.resChan,
+		p.statusGuard,
+		docState,
+		p.documentMgr)
+}
+
+// waitForDocumentWorker waits until the document can run without exceeding the document workers limit.
+// The Queued status is persisted and reported while the document waits. It returns false when the document does not
+// run: a document shut down while waiting is left pending to be resumed on restart, and a document canceled while
+// waiting reports its cancellation without taking a document worker.
+func (p *EngineProcessor) waitForDocumentWorker(cancelFlag task.CancelFlag, docState *contracts.DocumentState) (release func(), run bool) {
+	log := p.context.Log()
+	limiter := p.documentLimiter
+	noop := func() {}
+	if limiter == nil {
+		return noop, true
+	}
+	granted := limiter.enqueue()
+	if granted == nil {
+		return limiter.release, true
+	}
+
+	documentID := docState.DocumentInformation.DocumentID
+	status := docState.DocumentInformation.DocumentStatus
+	log.Infof("document %v is queued, %v documents are waiting for a document worker", documentID, limiter.queued())
+	docState.DocumentInformation.DocumentStatus = contracts.ResultStatusQueued
+	p.documentMgr.PersistDocumentState(documentID, appconfig.DefaultLocationOfPending, *docState)
+	p.resChan <- newDocumentResult(docState, contracts.ResultStatusQueued, nil)
+
+	// Wait returns once the job completes, the go routine does not outlive it
+	stopped := make(chan task.State, 1)
+	go func() {
+		stopped <- cancelFlag.Wait()
+	}()
+	select {
+	case <-granted:
+		log.Infof("document %v is dequeued", documentID)
+		docState.DocumentInformation.DocumentStatus = status
+		p.documentMgr.PersistDocumentState(documentID, appconfig.DefaultLocationOfPending, *docState)
+		return limiter.release, true
+	case state := <-stopped:
+		if !limiter.leave(granted) {
+			// the slot was handed over at the same time
+			limiter.release()
+		}
+		if state == task.ShutDown {
+			log.Infof("document %v was shut down while queued", documentID)
+			docState.DocumentInformation.DocumentStatus = status
+			p.documentMgr.PersistDocumentState(documentID, appconfig.DefaultLocationOfPending, *docState)
+			return noop, false
+		}
+		log.Infof("document %v was canceled while queued", documentID)
+		p.reportCanceledWhileQueued(docState)
+		return noop, false
+	}
+}
+
+// reportCanceledWhileQueued reports the steps of a document canceled before it ran as canceled and removes its state
+func (p *EngineProcessor) reportCanceledWhileQueued(docState *contracts.DocumentState) {
+	documentID := docState.DocumentInformation.DocumentID
+	now := time.Now()
+	pluginResults := make(map[string]*contracts.PluginResult)
+	for _, plugin := range docState.InstancePluginsInformation {
+		pluginResults[plugin.Id] = &contracts.PluginResult{
+			PluginID:      plugin.Id,
+			PluginName:    plugin.Name,
+			Status:        contracts.ResultStatusCancelled,
+			Code:          1,
+			Output:        fmt.Sprintf("Plugin with name %s and id %s not run because the document was cancelled while queued", plugin.Name, plugin.Id),
+			StartDateTime: now,
+			EndDateTime:   now,
+		}
+	}
+	res := newDocumentResult(docState, contracts.ResultStatusCancelled, pluginResults)
+	if p.statusGuard.allow(documentID, res) {
+		p.resChan <- res
+	}
+	p.statusGuard.release(documentID)
+	p.documentMgr.RemoveDocumentState(documentID, appconfig.DefaultLocationOfPending)
+}
+
+// newDocumentResult creates the document level result of a document which is not run by an executer
+func newDocumentResult(docState *contracts.DocumentState, status contracts.ResultStatus, pluginResults map[string]*contracts.PluginResult) contracts.DocumentResult {
+	return contracts.DocumentResult{
+		Status:              status,
+		PluginResults:       pluginResults,
+		MessageID:           docState.DocumentInformation.MessageID,
+		AssociationID:       docState.DocumentInformation.AssociationID,
+		DocumentID:          docState.DocumentInformation.DocumentID,
+		NPlugins:            len(docState.InstancePluginsInformation),
+		DocumentName:        docState.DocumentInformation.DocumentName,
+		DocumentVersion:     docState.DocumentInformation.DocumentVersion,
+		UpstreamServiceName: docState.UpstreamServiceName,
+		RelatedDocumentType: docState.DocumentType,
+	}
+}
+
 // checkProcessorSubmissionAllowed checks whether the processor submission is allowed or not
 func (p *EngineProcessor) checkProcessorSubmissionAllowed(doc *contracts.DocumentState) (error ErrorCode) {
 	if doc.DocumentType == p.startWorker.assignedDocType {
@@ -582,7 +696,7 @@ func (p *EngineProcessor) isSupportedDocumentType(documentType contracts.Documen
 	return false
 }
 
-func processCommand(context context.T, executerCreator ExecuterCreator, cancelFlag task.CancelFlag, resChan chan contracts.DocumentResult, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr) {
+func processCommand(context context.T, executerCreator ExecuterCreator, cancelFlag task.CancelFlag, resChan chan contracts.DocumentResult, statusGuard *terminalStatusGuard, docState *contracts.DocumentState, docMgr docmanager.DocumentMgr) {
 	log := context.Log()
 	//persist the current running document
 	docMgr.MoveDocumentState(
@@ -592,6 +706,7 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 	log.Debug("Running executer...")
 	documentID := docState.DocumentInformation.DocumentID
 	messageID := docState.DocumentInformation.MessageID
+	defer statusGuard.release(documentID)
 	e := executerCreator(context)
 	docStore := executer.NewDocumentFileStore(documentID, appconfig.DefaultLocationOfCurrent, docState, docMgr, true)
 	statusChan := e.Run(
@@ -609,6 +724,12 @@ func processCommand(context context.T, executerCreator ExecuterCreator, cancelFl
 				}
 			}()
 
+			if !statusGuard.allow(documentID, res) {
+				reportedStatus, _ := statusGuard.terminalStatus(documentID)
+				log.Warnf("dropping %v update for plugin %v of document %v, the document already reported its final status %v",
+					res.Status, res.LastPlugin, documentID, reportedStatus)
+				return
+			}
 			if res.LastPlugin == "" {
 				log.Infof("sending document: %v complete response", documentID)
 			} else {
@@ -17,6 +17,7 @@ package processor
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -146,7 +147,7 @@ func TestProcessCommand(t *testing.T) {
 	docMock := new(DocumentMgrMock)
 	docMock.On("MoveDocumentState", "documentID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
 	docMock.On("RemoveDocumentState", "documentID", appconfig.DefaultLocationOfCurrent)
-	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock)
+	processCommand(ctx, creator, cancelFlag, resChan, newTerminalStatusGuard(), &docState, docMock)
 	executerMock.AssertExpectations(t)
 	docMock.AssertExpectations(t)
 	close(resChan)
@@ -154,6 +155,54 @@ func TestProcessCommand(t *testing.T) {
 	assert.NotNil(t, resChan)
 }
 
+func TestProcessCommand_SingleTerminalStatus(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	docState := contracts.DocumentState{}
+	docState.DocumentInformation.MessageID = "messageID"
+	docState.DocumentInformation.DocumentID = "documentID"
+	executerMock := executermocks.NewMockExecuter()
+	cancelFlag := task.NewChanneledCancelFlag()
+	updates := []contracts.DocumentResult{
+		{LastPlugin: "plugin0", Status: contracts.ResultStatusInProgress},
+		{LastPlugin: "", Status: contracts.ResultStatusSuccess},
+		{LastPlugin: "", Status: contracts.ResultStatusInProgress},
+		{LastPlugin: "plugin1", Status: contracts.ResultStatusFailed},
+		{LastPlugin: "", Status: contracts.ResultStatusFailed},
+	}
+	statusChan := make(chan contracts.DocumentResult, len(updates))
+	for _, update := range updates {
+		statusChan <- update
+	}
+	close(statusChan)
+	resChan := make(chan contracts.DocumentResult, len(updates))
+	executerMock.On("Run", cancelFlag, mock.AnythingOfType("*executer.DocumentFileStore")).Return(statusChan)
+	creator := func(ctx context.T) executer.Executer {
+		return executerMock
+	}
+	docMock := new(DocumentMgrMock)
+	docMock.On("MoveDocumentState", "documentID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+	docMock.On("RemoveDocumentState", "documentID", appconfig.DefaultLocationOfCurrent)
+	statusGuard := newTerminalStatusGuard()
+
+	processCommand(ctx, creator, cancelFlag, resChan, statusGuard, &docState, docMock)
+	close(resChan)
+
+	var sent []contracts.DocumentResult
+	for res := range resChan {
+		sent = append(sent, res)
+	}
+	// only the updates up to the first terminal status are sent
+	assert.Len(t, sent, 2)
+	assert.Equal(t, "plugin0", sent[0].LastPlugin)
+	assert.Equal(t, "", sent[1].LastPlugin)
+	assert.Equal(t, contracts.ResultStatusSuccess, sent[1].Status)
+	executerMock.AssertExpectations(t)
+	docMock.AssertExpectations(t)
+	// the guard is released once the document is done processing
+	_, reported := statusGuard.terminalStatus("documentID")
+	assert.False(t, reported)
+}
+
 func TestCheckDocSubmissionAllowed(t *testing.T) {
 	sendCommandPoolMock := new(taskmocks.MockedPool)
 	ctx := contextmocks.NewMockDefault()
@@ -315,7 +364,7 @@ func TestProcessCommand_Shutdown(t *testing.T) {
 	}()
 	docMock := new(DocumentMgrMock)
 	docMock.On("MoveDocumentState", "documentID", appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
-	processCommand(ctx, creator, cancelFlag, resChan, &docState, docMock)
+	processCommand(ctx, creator, cancelFlag, resChan, newTerminalStatusGuard(), &docState, docMock)
 	executerMock.AssertExpectations(t)
 	docMock.AssertExpectations(t)
 	close(resChan)
@@ -341,6 +390,148 @@ func TestProcessCancelCommand_Success(t *testing.T) {
 
 }
 
+func TestRunCommand_DocumentWorkersLimit(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	executerMock := executermocks.NewMockExecuter()
+	firstFlag, secondFlag := task.NewChanneledCancelFlag(), task.NewChanneledCancelFlag()
+	firstStatus, secondStatus := make(chan contracts.DocumentResult), make(chan contracts.DocumentResult)
+	close(secondStatus)
+	executerMock.On("Run", firstFlag, mock.AnythingOfType("*executer.DocumentFileStore")).Return(firstStatus)
+	executerMock.On("Run", secondFlag, mock.AnythingOfType("*executer.DocumentFileStore")).Return(secondStatus)
+	docMock := new(DocumentMgrMock)
+	docMock.On("MoveDocumentState", mock.Anything, appconfig.DefaultLocationOfPending, appconfig.DefaultLocationOfCurrent)
+	docMock.On("PersistDocumentState", "second", appconfig.DefaultLocationOfPending, mock.Anything)
+	resChan := make(chan contracts.DocumentResult)
+	processor := EngineProcessor{
+		context:         ctx,
+		executerCreator: func(ctx context.T) executer.Executer { return executerMock },
+		resChan:         resChan,
+		documentMgr:     docMock,
+		statusGuard:     newTerminalStatusGuard(),
+		documentLimiter: newDocumentLimiter(1),
+	}
+	first, second := contracts.DocumentState{}, contracts.DocumentState{}
+	first.DocumentInformation.DocumentID = "first"
+	second.DocumentInformation.DocumentID = "second"
+	second.DocumentInformation.MessageID = "secondMessage"
+
+	firstDone, secondDone := make(chan bool), make(chan bool)
+	go func() {
+		processor.runCommand(firstFlag, &first)
+		close(firstDone)
+	}()
+	// the first document is running once its plugin update is sent
+	firstStatus <- contracts.DocumentResult{LastPlugin: "plugin1", Status: contracts.ResultStatusInProgress}
+	<-resChan
+	go func() {
+		processor.runCommand(secondFlag, &second)
+		close(secondDone)
+	}()
+
+	// the queued status is reported as a document level status update
+	queued := <-resChan
+	assert.Equal(t, contracts.ResultStatusQueued, queued.Status)
+	assert.Equal(t, "", queued.LastPlugin)
+	assert.Equal(t, "second", queued.DocumentID)
+	assert.Equal(t, "secondMessage", queued.MessageID)
+	assert.Equal(t, 1, processor.documentLimiter.queued())
+	executerMock.AssertNotCalled(t, "Run", secondFlag, mock.Anything)
+	docMock.AssertCalled(t, "PersistDocumentState", "second", appconfig.DefaultLocationOfPending, mock.MatchedBy(func(state contracts.DocumentState) bool {
+		return state.DocumentInformation.DocumentStatus == contracts.ResultStatusQueued
+	}))
+
+	close(firstStatus)
+	<-firstDone
+	<-secondDone
+	executerMock.AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatus(""), second.DocumentInformation.DocumentStatus)
+}
+
+func TestRunCommand_ShutDownWhileQueued(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	executerMock := executermocks.NewMockExecuter()
+	docMock := new(DocumentMgrMock)
+	docMock.On("PersistDocumentState", "documentID", appconfig.DefaultLocationOfPending, mock.Anything)
+	resChan := make(chan contracts.DocumentResult, 1)
+	processor := EngineProcessor{
+		context:         ctx,
+		executerCreator: func(ctx context.T) executer.Executer { return executerMock },
+		resChan:         resChan,
+		documentMgr:     docMock,
+		statusGuard:     newTerminalStatusGuard(),
+		documentLimiter: newDocumentLimiter(1),
+	}
+	// the only document worker is busy
+	assert.Nil(t, processor.documentLimiter.enqueue())
+	docState := contracts.DocumentState{}
+	docState.DocumentInformation.DocumentID = "documentID"
+	cancelFlag := task.NewChanneledCancelFlag()
+
+	done := make(chan bool)
+	go func() {
+		processor.runCommand(cancelFlag, &docState)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return processor.documentLimiter.queued() == 1 }, time.Second, 10*time.Millisecond)
+	cancelFlag.Set(task.ShutDown)
+	<-done
+
+	// the document is left pending without running
+	executerMock.AssertNotCalled(t, "Run", mock.Anything, mock.Anything)
+	docMock.AssertNotCalled(t, "MoveDocumentState", mock.Anything, mock.Anything, mock.Anything)
+	docMock.AssertNotCalled(t, "RemoveDocumentState", mock.Anything, mock.Anything)
+	assert.Equal(t, 0, processor.documentLimiter.queued())
+	assert.Equal(t, contracts.ResultStatusQueued, (<-resChan).Status)
+	assert.Empty(t, resChan)
+}
+
+func TestRunCommand_CanceledWhileQueued(t *testing.T) {
+	ctx := contextmocks.NewMockDefault()
+	executerMock := executermocks.NewMockExecuter()
+	docMock := new(DocumentMgrMock)
+	docMock.On("PersistDocumentState", "documentID", appconfig.DefaultLocationOfPending, mock.Anything)
+	docMock.On("RemoveDocumentState", "documentID", appconfig.DefaultLocationOfPending)
+	resChan := make(chan contracts.DocumentResult, 2)
+	processor := EngineProcessor{
+		context:         ctx,
+		executerCreator: func(ctx context.T) executer.Executer { return executerMock },
+		resChan:         resChan,
+		documentMgr:     docMock,
+		statusGuard:     newTerminalStatusGuard(),
+		documentLimiter: newDocumentLimiter(1),
+	}
+	// the only document worker is busy
+	assert.Nil(t, processor.documentLimiter.enqueue())
+	docState := contracts.DocumentState{
+		InstancePluginsInformation: []contracts.PluginState{{Id: "step1", Name: "aws:runShellScript"}},
+	}
+	docState.DocumentInformation.DocumentID = "documentID"
+	cancelFlag := task.NewChanneledCancelFlag()
+
+	done := make(chan bool)
+	go func() {
+		processor.runCommand(cancelFlag, &docState)
+		close(done)
+	}()
+	assert.Equal(t, contracts.ResultStatusQueued, (<-resChan).Status)
+	cancelFlag.Set(task.Canceled)
+	<-done
+
+	// the cancellation is reported without running the document
+	executerMock.AssertNotCalled(t, "Run", mock.Anything, mock.Anything)
+	docMock.AssertNotCalled(t, "MoveDocumentState", mock.Anything, mock.Anything, mock.Anything)
+	docMock.AssertExpectations(t)
+	canceled := <-resChan
+	assert.Equal(t, contracts.ResultStatusCancelled, canceled.Status)
+	assert.Equal(t, "", canceled.LastPlugin)
+	assert.Equal(t, contracts.ResultStatusCancelled, canceled.PluginResults["step1"].Status)
+	status, _, _, _ := contracts.DocumentResultAggregator(ctx.Log(), "", canceled.PluginResults)
+	assert.Equal(t, contracts.ResultStatusCancelled, status)
+	assert.Equal(t, 0, processor.documentLimiter.queued())
+	// the busy document worker is not handed to the canceled document
+	assert.NotNil(t, processor.documentLimiter.enqueue())
+}
+
 type DocumentMgrMock struct {
 	mock.Mock
 }
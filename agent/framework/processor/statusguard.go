@@ -0,0 +1,67 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+)
+
+// terminalStatusGuard makes sure each document reports a single terminal status,
+// updates received for a document after its terminal status are dropped
+type terminalStatusGuard struct {
+	lock     sync.Mutex
+	reported map[string]contracts.ResultStatus
+}
+
+func newTerminalStatusGuard() *terminalStatusGuard {
+	return &terminalStatusGuard{
+		reported: make(map[string]contracts.ResultStatus),
+	}
+}
+
+// isTerminal returns true for the document level result that ends the document,
+// a SuccessAndReboot result is followed by the resumed execution after the reboot
+func isTerminal(res contracts.DocumentResult) bool {
+	return res.LastPlugin == "" && res.Status != contracts.ResultStatusSuccessAndReboot
+}
+
+// allow returns whether the result can be sent for the document, and records it if it is the terminal status
+func (g *terminalStatusGuard) allow(documentID string, res contracts.DocumentResult) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if _, reported := g.reported[documentID]; reported {
+		return false
+	}
+	if isTerminal(res) {
+		g.reported[documentID] = res.Status
+	}
+	return true
+}
+
+// terminalStatus returns the terminal status reported for the document, if any
+func (g *terminalStatusGuard) terminalStatus(documentID string) (status contracts.ResultStatus, reported bool) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	status, reported = g.reported[documentID]
+	return
+}
+
+// release forgets the document once it is done processing
+func (g *terminalStatusGuard) release(documentID string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	delete(g.reported, documentID)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerminalStatusGuard_AllowsSingleTerminalStatus(t *testing.T) {
+	guard := newTerminalStatusGuard()
+
+	assert.True(t, guard.allow("document1", contracts.DocumentResult{LastPlugin: "plugin1", Status: contracts.ResultStatusInProgress}))
+	assert.True(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusFailed}))
+	assert.False(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusSuccess}))
+	assert.False(t, guard.allow("document1", contracts.DocumentResult{LastPlugin: "plugin2", Status: contracts.ResultStatusSuccess}))
+	status, reported := guard.terminalStatus("document1")
+	assert.True(t, reported)
+	assert.Equal(t, contracts.ResultStatusFailed, status)
+
+	// documents are guarded independently
+	assert.True(t, guard.allow("document2", contracts.DocumentResult{Status: contracts.ResultStatusSuccess}))
+}
+
+func TestTerminalStatusGuard_RebootIsNotTerminal(t *testing.T) {
+	guard := newTerminalStatusGuard()
+
+	assert.True(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusSuccessAndReboot}))
+	assert.True(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusSuccess}))
+	assert.False(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusSuccess}))
+}
+
+func TestTerminalStatusGuard_Release(t *testing.T) {
+	guard := newTerminalStatusGuard()
+	assert.True(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusSuccess}))
+
+	guard.release("document1")
+
+	_, reported := guard.terminalStatus("document1")
+	assert.False(t, reported)
+	assert.True(t, guard.allow("document1", contracts.DocumentResult{Status: contracts.ResultStatusSuccess}))
+}
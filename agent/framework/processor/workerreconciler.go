@@ -0,0 +1,110 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/common/channel/utils"
+	"github.com/aws/amazon-ssm-agent/common/message"
+	"github.com/aws/amazon-ssm-agent/core/executor"
+)
+
+var (
+	// reconcileWorkersOnce makes the first processor initialized after the agent start reconcile the workers
+	reconcileWorkersOnce sync.Once
+	newProcessExecutor   = executor.NewProcessExecutor
+	getAgentPid          = os.Getpid
+)
+
+// coreAgentChannels are the channels of the channel directory which are not owned by a document
+var coreAgentChannels = map[string]bool{
+	path.Base(message.GetWorkerHealthChannel):   true,
+	path.Base(message.TerminationWorkerChannel): true,
+}
+
+// reconcileWorkers cleans up the channels and the document workers left behind by a previous agent process.
+// The channels and the workers of the documents persisted as pending or in progress are kept, those documents are resumed.
+func (p *EngineProcessor) reconcileWorkers() {
+	log := p.context.Log()
+	channelDir, err := utils.GetDefaultChannelPath(p.context.Identity(), "")
+	if err != nil {
+		log.Warnf("Failed to get the channel directory, skipping the worker reconciliation: %v", err)
+		return
+	}
+	// the channels are listed before the documents, a channel created in between belongs to a document which is already persisted
+	channels, err := fileutil.ReadDir(channelDir)
+	if err != nil {
+		log.Debugf("No channel to reconcile in %v: %v", channelDir, err)
+	}
+
+	ownedChannels := make(map[string]bool)
+	ownedWorkers := make(map[int]bool)
+	for _, f := range p.getDocStateFiles(log, appconfig.DefaultLocationOfPending) {
+		ownedChannels[f.Name()] = true
+	}
+	for _, f := range p.getDocStateFiles(log, appconfig.DefaultLocationOfCurrent) {
+		ownedChannels[f.Name()] = true
+		docState := p.documentMgr.GetDocumentState(f.Name(), appconfig.DefaultLocationOfCurrent)
+		if pid := docState.DocumentInformation.ProcInfo.Pid; pid != 0 {
+			ownedWorkers[pid] = true
+		}
+	}
+
+	killOrphanedWorkers(log, newProcessExecutor(log), ownedWorkers)
+	removeOrphanedChannels(log, channelDir, channels, ownedChannels)
+}
+
+// killOrphanedWorkers kills the document and session workers which are neither owned by a document nor started by this agent process
+func killOrphanedWorkers(log log.T, processExecutor executor.IExecutor, ownedWorkers map[int]bool) {
+	processes, err := processExecutor.Processes()
+	if err != nil {
+		log.Warnf("Failed to list the processes, skipping the orphaned worker cleanup: %v", err)
+		return
+	}
+	agentPid := getAgentPid()
+	for _, process := range processes {
+		executableName := strings.ToLower(process.Executable)
+		if !strings.Contains(executableName, appconfig.SSMDocumentWorkerName) && !strings.Contains(executableName, appconfig.SSMSessionWorkerName) {
+			continue
+		}
+		if process.State == "Z" || process.PPid == agentPid || ownedWorkers[process.Pid] {
+			continue
+		}
+		log.Infof("Killing orphaned worker %v with pid %v", process.Executable, process.Pid)
+		if err = processExecutor.Kill(process.Pid); err != nil {
+			log.Warnf("Failed to kill orphaned worker with pid %v: %v", process.Pid, err)
+		}
+	}
+}
+
+// removeOrphanedChannels removes the document channels of channelDir which are not owned by a document
+func removeOrphanedChannels(log log.T, channelDir string, channels []os.FileInfo, ownedChannels map[string]bool) {
+	for _, channel := range channels {
+		if !channel.IsDir() || coreAgentChannels[channel.Name()] || ownedChannels[channel.Name()] {
+			continue
+		}
+		log.Infof("Removing orphaned channel %v", channel.Name())
+		if err := os.RemoveAll(filepath.Join(channelDir, channel.Name())); err != nil {
+			log.Warnf("Failed to remove orphaned channel %v: %v", channel.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/common/message"
+	"github.com/aws/amazon-ssm-agent/core/executor"
+	executormocks "github.com/aws/amazon-ssm-agent/core/executor/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveOrphanedChannels_RemovesStaleChannelAndKeepsLiveOne(t *testing.T) {
+	channelDir := t.TempDir()
+	healthChannel := path.Base(message.GetWorkerHealthChannel)
+	for _, channel := range []string{"stale-document", "live-document", healthChannel} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(channelDir, channel, "tmp"), os.ModePerm))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(channelDir, "stale-document", "worker-1"), []byte("{}"), 0600))
+	channels, err := os.ReadDir(channelDir)
+	assert.NoError(t, err)
+	var channelInfos []os.FileInfo
+	for _, channel := range channels {
+		info, _ := channel.Info()
+		channelInfos = append(channelInfos, info)
+	}
+
+	removeOrphanedChannels(logmocks.NewMockLog(), channelDir, channelInfos, map[string]bool{"live-document": true})
+
+	assert.NoDirExists(t, filepath.Join(channelDir, "stale-document"))
+	assert.DirExists(t, filepath.Join(channelDir, "live-document"))
+	assert.DirExists(t, filepath.Join(channelDir, healthChannel))
+}
+
+func TestKillOrphanedWorkers(t *testing.T) {
+	getAgentPid = func() int { return 100 }
+	defer func() { getAgentPid = os.Getpid }()
+
+	processExecutor := &executormocks.IExecutor{}
+	processExecutor.On("Processes").Return([]executor.OsProcess{
+		{Pid: 200, PPid: 1, Executable: "/usr/bin/ssm-document-worker"},
+		{Pid: 201, PPid: 1, Executable: "/usr/bin/ssm-session-worker"},
+		{Pid: 202, PPid: 1, Executable: "/usr/bin/ssm-document-worker"},
+		{Pid: 203, PPid: 100, Executable: "/usr/bin/ssm-document-worker"},
+		{Pid: 204, PPid: 1, Executable: "/usr/bin/ssm-document-worker", State: "Z"},
+		{Pid: 205, PPid: 1, Executable: "/usr/bin/amazon-ssm-agent"},
+	}, nil)
+	processExecutor.On("Kill", 200).Return(nil)
+	processExecutor.On("Kill", 201).Return(fmt.Errorf("operation not permitted"))
+
+	killOrphanedWorkers(logmocks.NewMockLog(), processExecutor, map[int]bool{202: true})
+
+	processExecutor.AssertExpectations(t)
+	// owned workers, workers started by this agent, exited workers and other processes are left alone
+	processExecutor.AssertNumberOfCalls(t, "Kill", 2)
+}
+
+func TestKillOrphanedWorkers_ProcessListFailure(t *testing.T) {
+	processExecutor := &executormocks.IExecutor{}
+	processExecutor.On("Processes").Return(nil, fmt.Errorf("ps not found"))
+
+	killOrphanedWorkers(logmocks.NewMockLog(), processExecutor, map[int]bool{})
+
+	processExecutor.AssertNotCalled(t, "Kill")
+}
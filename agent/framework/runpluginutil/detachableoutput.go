@@ -0,0 +1,254 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"io"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/iomodule"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
+)
+
+// detachableOutput is the IOHandler given to a plugin running with a step timeout. The calls of the plugin are
+// serialized and forwarded to the IOHandler of the step until the plugin is abandoned, then they are dropped,
+// so a plugin still running after its step completed cannot write to the closed output of the step.
+type detachableOutput struct {
+	lock      sync.Mutex
+	output    iohandler.IOHandler
+	detached  bool
+	processID int
+	stdout    *detachableWriter
+	stderr    *detachableWriter
+}
+
+// detachableWriter forwards the writes of a plugin to a writer of the step until the plugin is abandoned
+type detachableWriter struct {
+	owner  *detachableOutput
+	writer multiwriter.DocumentIOMultiWriter
+}
+
+// newDetachableOutput returns an IOHandler forwarding to the output of the step
+func newDetachableOutput(output iohandler.IOHandler) *detachableOutput {
+	detachable := &detachableOutput{output: output}
+	if writer := output.GetStdoutWriter(); writer != nil {
+		detachable.stdout = &detachableWriter{owner: detachable, writer: writer}
+	}
+	if writer := output.GetStderrWriter(); writer != nil {
+		detachable.stderr = &detachableWriter{owner: detachable, writer: writer}
+	}
+	return detachable
+}
+
+// detach stops forwarding the calls of the plugin to the output of the step, it waits for the call in progress
+func (d *detachableOutput) detach() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.detached = true
+}
+
+// getProcessID returns the ID of the last process started by the plugin, 0 if no process was started
+func (d *detachableOutput) getProcessID() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.processID
+}
+
+// forward runs call on the output of the step unless the plugin is abandoned
+func (d *detachableOutput) forward(call func(output iohandler.IOHandler)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.detached {
+		call(d.output)
+	}
+}
+
+func (d *detachableOutput) Init(filePath ...string) {
+	d.forward(func(output iohandler.IOHandler) { output.Init(filePath...) })
+}
+
+func (d *detachableOutput) RegisterOutputSource(multiWriter multiwriter.DocumentIOMultiWriter, IOModules ...iomodule.IOModule) {
+	d.forward(func(output iohandler.IOHandler) { output.RegisterOutputSource(multiWriter, IOModules...) })
+}
+
+// Close is a no-op, the output of the step is closed once the plugin returns or is abandoned
+func (d *detachableOutput) Close() {}
+
+func (d *detachableOutput) String() (response string) {
+	d.forward(func(output iohandler.IOHandler) { response = output.String() })
+	return
+}
+
+func (d *detachableOutput) MarkAsFailed(err error) {
+	d.forward(func(output iohandler.IOHandler) { output.MarkAsFailed(err) })
+}
+
+func (d *detachableOutput) MarkAsSucceeded() {
+	d.forward(func(output iohandler.IOHandler) { output.MarkAsSucceeded() })
+}
+
+func (d *detachableOutput) MarkAsInProgress() {
+	d.forward(func(output iohandler.IOHandler) { output.MarkAsInProgress() })
+}
+
+func (d *detachableOutput) MarkAsSuccessWithReboot() {
+	d.forward(func(output iohandler.IOHandler) { output.MarkAsSuccessWithReboot() })
+}
+
+func (d *detachableOutput) MarkAsCancelled() {
+	d.forward(func(output iohandler.IOHandler) { output.MarkAsCancelled() })
+}
+
+func (d *detachableOutput) MarkAsShutdown() {
+	d.forward(func(output iohandler.IOHandler) { output.MarkAsShutdown() })
+}
+
+func (d *detachableOutput) AppendInfo(message string) {
+	d.forward(func(output iohandler.IOHandler) { output.AppendInfo(message) })
+}
+
+func (d *detachableOutput) AppendInfof(format string, params ...interface{}) {
+	d.forward(func(output iohandler.IOHandler) { output.AppendInfof(format, params...) })
+}
+
+func (d *detachableOutput) AppendError(message string) {
+	d.forward(func(output iohandler.IOHandler) { output.AppendError(message) })
+}
+
+func (d *detachableOutput) AppendErrorf(format string, params ...interface{}) {
+	d.forward(func(output iohandler.IOHandler) { output.AppendErrorf(format, params...) })
+}
+
+func (d *detachableOutput) GetStatus() (status contracts.ResultStatus) {
+	d.forward(func(output iohandler.IOHandler) { status = output.GetStatus() })
+	return
+}
+
+func (d *detachableOutput) GetStdout() (stdout string) {
+	d.forward(func(output iohandler.IOHandler) { stdout = output.GetStdout() })
+	return
+}
+
+func (d *detachableOutput) GetStderr() (stderr string) {
+	d.forward(func(output iohandler.IOHandler) { stderr = output.GetStderr() })
+	return
+}
+
+func (d *detachableOutput) GetExitCode() (exitCode int) {
+	d.forward(func(output iohandler.IOHandler) { exitCode = output.GetExitCode() })
+	return
+}
+
+func (d *detachableOutput) GetStdoutWriter() multiwriter.DocumentIOMultiWriter {
+	if d.stdout == nil {
+		return nil
+	}
+	return d.stdout
+}
+
+func (d *detachableOutput) GetStderrWriter() multiwriter.DocumentIOMultiWriter {
+	if d.stderr == nil {
+		return nil
+	}
+	return d.stderr
+}
+
+func (d *detachableOutput) GetIOConfig() (ioConfig contracts.IOConfiguration) {
+	d.forward(func(output iohandler.IOHandler) { ioConfig = output.GetIOConfig() })
+	return
+}
+
+func (d *detachableOutput) SetStatus(status contracts.ResultStatus) {
+	d.forward(func(output iohandler.IOHandler) { output.SetStatus(status) })
+}
+
+func (d *detachableOutput) SetExitCode(exitCode int) {
+	d.forward(func(output iohandler.IOHandler) { output.SetExitCode(exitCode) })
+}
+
+func (d *detachableOutput) SetOutput(out interface{}) {
+	d.forward(func(output iohandler.IOHandler) { output.SetOutput(out) })
+}
+
+func (d *detachableOutput) SetStdout(stdout string) {
+	d.forward(func(output iohandler.IOHandler) { output.SetStdout(stdout) })
+}
+
+func (d *detachableOutput) SetStderr(stderr string) {
+	d.forward(func(output iohandler.IOHandler) { output.SetStderr(stderr) })
+}
+
+func (d *detachableOutput) AddResourceUsage(usage contracts.ResourceUsage) {
+	d.forward(func(output iohandler.IOHandler) { output.AddResourceUsage(usage) })
+}
+
+func (d *detachableOutput) GetResourceUsage() (usage *contracts.ResourceUsage) {
+	d.forward(func(output iohandler.IOHandler) { usage = output.GetResourceUsage() })
+	return
+}
+
+func (d *detachableOutput) SetResolvedInput(resolvedInput string) {
+	d.forward(func(output iohandler.IOHandler) { output.SetResolvedInput(resolvedInput) })
+}
+
+func (d *detachableOutput) GetResolvedInput() (resolvedInput string) {
+	d.forward(func(output iohandler.IOHandler) { resolvedInput = output.GetResolvedInput() })
+	return
+}
+
+// SetProcessID records the process so it can be killed if the plugin does not return once its step timed out
+func (d *detachableOutput) SetProcessID(pid int) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.detached {
+		d.processID = pid
+		d.output.SetProcessID(pid)
+	}
+}
+
+func (d *detachableOutput) GetProcessID() int {
+	return d.getProcessID()
+}
+
+func (w *detachableWriter) AddWriter(writer *io.PipeWriter) {
+	w.owner.forward(func(iohandler.IOHandler) { w.writer.AddWriter(writer) })
+}
+
+func (w *detachableWriter) GetWaitGroup() (wg *sync.WaitGroup) {
+	w.owner.forward(func(iohandler.IOHandler) { wg = w.writer.GetWaitGroup() })
+	if wg == nil {
+		wg = new(sync.WaitGroup)
+	}
+	return
+}
+
+// Write drops the bytes once the plugin is abandoned, they are reported as written so the copy from the process does not fail
+func (w *detachableWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	w.owner.forward(func(iohandler.IOHandler) { n, err = w.writer.Write(p) })
+	return
+}
+
+func (w *detachableWriter) WriteString(message string) (n int, err error) {
+	n = len(message)
+	w.owner.forward(func(iohandler.IOHandler) { n, err = w.writer.WriteString(message) })
+	return
+}
+
+// Close is a no-op, the writers of the step are closed with its output
+func (w *detachableWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// DryRunPlugins evaluates the same plugin support, precondition, plugin policy, abort, control flow and cancel
+// checks as RunPlugins without executing any plugin. Steps that would be executed are reported with the WouldRun
+// status, the other ones are reported as skipped, cancelled or failed along with the evaluated reason, indexed by
+// pluginId like the outputs of a real run.
+// Exit codes of the steps that would run are not known ahead of time, so the abort and control flow modifiers only
+// take into account the steps reported as failed by the dry run itself.
+func DryRunPlugins(
+	context context.T,
+	plugins []contracts.PluginState,
+	ioConfig contracts.IOConfiguration,
+	registry PluginRegistry,
+	cancelFlag task.CancelFlag,
+) (pluginOutputs map[string]*contracts.PluginResult) {
+
+	pluginOutputs = make(map[string]*contracts.PluginResult)
+
+	for pluginIndex, pluginState := range plugins {
+		stepContext := context.With(stepLogContext(pluginState, pluginIndex))
+		log := stepContext.Log()
+		pluginID := pluginState.Id
+		pluginName := pluginState.Name
+		configuration := pluginState.Configuration
+		pluginOutputs[pluginID] = &contracts.PluginResult{
+			PluginID:      pluginID,
+			PluginName:    pluginName,
+			StartDateTime: time.Now(),
+		}
+
+		_, pluginHandlerFound := registry[pluginName]
+		isKnown, isSupported, supportMessage := isSupportedPlugin(log, pluginName)
+		shouldSkipStepDueToPriorFailedStep := getShouldPluginSkipBasedOnControlFlow(
+			stepContext,
+			plugins,
+			pluginIndex,
+			pluginOutputs,
+		)
+		operation, logMessage, skipReason := getStepExecutionOperation(
+			log,
+			pluginName,
+			pluginID,
+			isKnown,
+			isSupported,
+			supportMessage,
+			pluginHandlerFound,
+			configuration.IsPreconditionEnabled,
+			getPreconditionClauses(configuration),
+			shouldSkipStepDueToPriorFailedStep)
+		operation, logMessage, skipReason = applyPluginPolicy(context.AppConfig().Ssm, pluginName, pluginID, operation, logMessage, skipReason)
+		if failedStepID := getAbortingFailedStep(ioConfig, plugins, pluginIndex, pluginOutputs); failedStepID != "" {
+			operation, logMessage, skipReason = skipStep, fmt.Sprintf(
+				"Plugin with name %s and id %s skipped because step %s failed and the document aborts on failure",
+				pluginName,
+				pluginID,
+				failedStepID), contracts.SkipReasonPriorStepFailed
+		}
+		if cancelFlag != nil && cancelFlag.Canceled() {
+			operation, logMessage, skipReason = cancelStep, fmt.Sprintf(
+				"Plugin with name %s and id %s not run because the document was cancelled",
+				pluginName,
+				pluginID), ""
+		}
+
+		switch operation {
+		case executeStep:
+			log.Infof("Dry run: plugin %s %s would run", pluginName, pluginID)
+			pluginOutputs[pluginID].Status = contracts.ResultStatusWouldRun
+			pluginOutputs[pluginID].Output = fmt.Sprintf("Step would run. Step name: %s", pluginID)
+		case skipStep:
+			log.Infof("Dry run: %s", logMessage)
+			pluginOutputs[pluginID].Status = contracts.ResultStatusSkipped
+			pluginOutputs[pluginID].Output = logMessage
+			pluginOutputs[pluginID].SkipReason = skipReason
+		case cancelStep:
+			log.Infof("Dry run: %s", logMessage)
+			pluginOutputs[pluginID].Status = contracts.ResultStatusCancelled
+			pluginOutputs[pluginID].Output = logMessage
+		case failStep:
+			log.Infof("Dry run: %s", logMessage)
+			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
+			pluginOutputs[pluginID].Error = logMessage
+			pluginOutputs[pluginID].SkipReason = skipReason
+		default:
+			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
+			pluginOutputs[pluginID].Error = fmt.Sprintf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
+		}
+		pluginOutputs[pluginID].EndDateTime = time.Now()
+	}
+	return
+}
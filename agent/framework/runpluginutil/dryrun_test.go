@@ -0,0 +1,170 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package runpluginutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDryRunPlugins(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+
+	// "StringEquals": ["{{ param }}", "bar"] with param resolved to foo
+	unsatisfiedPreconditions := map[string][]contracts.PreconditionArgument{
+		"StringEquals": {
+			{InitialArgumentValue: "{{ param }}", ResolvedArgumentValue: "foo"},
+			{InitialArgumentValue: "bar", ResolvedArgumentValue: "bar"},
+		},
+	}
+	steps := []struct {
+		id                    string
+		name                  string
+		isPreconditionEnabled bool
+		preconditions         map[string][]contracts.PreconditionArgument
+	}{
+		{id: "supported", name: testPlugin1, isPreconditionEnabled: true},
+		{id: "unsupported", name: testUnsupportedPlugin, isPreconditionEnabled: true},
+		{id: "preconditionFailed", name: testPlugin2, isPreconditionEnabled: true, preconditions: unsatisfiedPreconditions},
+		{id: "unknown", name: testUnknownPlugin},
+	}
+
+	pluginFactory := new(PluginFactoryMock)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory, testPlugin2: pluginFactory}
+	plugins := make([]contracts.PluginState, len(steps))
+	for i, step := range steps {
+		plugins[i] = contracts.PluginState{
+			Id:   step.id,
+			Name: step.name,
+			Configuration: contracts.Configuration{
+				PluginID:              step.id,
+				PluginName:            step.name,
+				IsPreconditionEnabled: step.isPreconditionEnabled,
+				Preconditions:         step.preconditions,
+			},
+		}
+	}
+
+	outputs := DryRunPlugins(ctx, plugins, contracts.IOConfiguration{}, pluginRegistry, nil)
+
+	pluginFactory.AssertNotCalled(t, "Create", mock.Anything)
+	assert.Len(t, outputs, len(steps))
+
+	assert.Equal(t, contracts.ResultStatusWouldRun, outputs["supported"].Status)
+	assert.Equal(t, "supported", outputs["supported"].PluginID)
+	assert.Equal(t, testPlugin1, outputs["supported"].PluginName)
+
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["unsupported"].Status)
+	assert.Equal(t, contracts.SkipReasonUnsupportedPlugin, outputs["unsupported"].SkipReason)
+	assert.Contains(t, outputs["unsupported"].Output, "unsupported plugin")
+
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["preconditionFailed"].Status)
+	assert.Equal(t, contracts.SkipReasonPreconditionFailed, outputs["preconditionFailed"].SkipReason)
+
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["unknown"].Status)
+	assert.Equal(t, contracts.SkipReasonAgentTooOld, outputs["unknown"].SkipReason)
+	assert.Contains(t, outputs["unknown"].Error, "an agent update may be required")
+}
+
+// newDryRunSteps returns the plugin states of steps with the given plugin names, identified by their index
+func newDryRunSteps(names ...string) []contracts.PluginState {
+	plugins := make([]contracts.PluginState, len(names))
+	for i, name := range names {
+		id := fmt.Sprintf("step%d", i)
+		plugins[i] = contracts.PluginState{
+			Id:            id,
+			Name:          name,
+			Configuration: contracts.Configuration{PluginID: id, PluginName: name},
+		}
+	}
+	return plugins
+}
+
+func TestDryRunPluginsAppliesPluginPolicy(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock), testPlugin2: new(PluginFactoryMock)}
+
+	for _, action := range []string{appconfig.DisallowedPluginActionFail, appconfig.DisallowedPluginActionSkip} {
+		config := appconfig.DefaultConfig()
+		config.Ssm.AllowedPlugins = []string{testPlugin1}
+		config.Ssm.DisallowedPluginAction = action
+
+		outputs := DryRunPlugins(contextmocks.NewMockDefaultWithConfig(config), newDryRunSteps(testPlugin1, testPlugin2),
+			contracts.IOConfiguration{}, pluginRegistry, nil)
+
+		assert.Equal(t, contracts.ResultStatusWouldRun, outputs["step0"].Status)
+		if action == appconfig.DisallowedPluginActionSkip {
+			assert.Equal(t, contracts.ResultStatusSkipped, outputs["step1"].Status)
+			assert.Equal(t, contracts.SkipReasonPluginNotPermitted, outputs["step1"].SkipReason)
+			assert.Contains(t, outputs["step1"].Output, "not permitted by policy")
+		} else {
+			assert.Equal(t, contracts.ResultStatusFailed, outputs["step1"].Status)
+			assert.Contains(t, outputs["step1"].Error, "not permitted by policy")
+		}
+	}
+}
+
+func TestDryRunPluginsSkipsStepsAfterAbortingFailure(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock)}
+	ioConfig := contracts.IOConfiguration{OnFailure: contracts.ModifierValueAbort}
+
+	outputs := DryRunPlugins(contextmocks.NewMockDefault(), newDryRunSteps(testPlugin1, testUnknownPlugin, testPlugin1),
+		ioConfig, pluginRegistry, nil)
+
+	assert.Equal(t, contracts.ResultStatusWouldRun, outputs["step0"].Status)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["step1"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["step2"].Status)
+	assert.Equal(t, contracts.SkipReasonPriorStepFailed, outputs["step2"].SkipReason)
+}
+
+func TestDryRunPluginsSkipsStepsAfterExitOnFailure(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock)}
+	plugins := newDryRunSteps(testUnknownPlugin, testPlugin1)
+	plugins[0].Configuration.Properties = map[string]interface{}{contracts.OnFailureModifier: contracts.ModifierValueExit}
+
+	outputs := DryRunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{}, pluginRegistry, nil)
+
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["step0"].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs["step1"].Status)
+}
+
+func TestDryRunPluginsReportsCancelledDocument(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	pluginRegistry := PluginRegistry{testPlugin1: new(PluginFactoryMock)}
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+
+	outputs := DryRunPlugins(contextmocks.NewMockDefault(), newDryRunSteps(testPlugin1), contracts.IOConfiguration{}, pluginRegistry, cancelFlag)
+
+	assert.Equal(t, contracts.ResultStatusCancelled, outputs["step0"].Status)
+	assert.Contains(t, outputs["step0"].Output, "document was cancelled")
+}
@@ -0,0 +1,241 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// InputType is the type a plugin expects for one of its inputs
+type InputType string
+
+const (
+	// InputTypeString accepts a string
+	InputTypeString InputType = "String"
+	// InputTypeStringList accepts a list of strings
+	InputTypeStringList InputType = "StringList"
+	// InputTypeInteger accepts a whole number, or a string holding one or nothing as produced by parameter substitution
+	InputTypeInteger InputType = "Integer"
+	// InputTypeBoolean accepts a boolean, or the strings true and false
+	InputTypeBoolean InputType = "Boolean"
+	// InputTypeMap accepts a JSON object
+	InputTypeMap InputType = "Map"
+)
+
+// InputDefinition declares one input of a plugin
+type InputDefinition struct {
+	Type     InputType
+	Required bool
+}
+
+// InputSchema declares the inputs of a plugin, indexed by input name.
+// Input names are matched case-insensitively, the way the plugins decode their inputs.
+// Inputs which are not declared are passed to the plugin without validation.
+type InputSchema map[string]InputDefinition
+
+// runScriptInputSchema declares the inputs shared by aws:runShellScript and aws:runPowerShellScript
+var runScriptInputSchema = InputSchema{
+	"runCommand":       {Type: InputTypeStringList},
+	"workingDirectory": {Type: InputTypeString},
+	"timeoutSeconds":   {Type: InputTypeInteger},
+	"environment":      {Type: InputTypeMap},
+	"runAsUser":        {Type: InputTypeString},
+	"loginShell":       {Type: InputTypeBoolean},
+}
+
+var (
+	// inputSchemas holds the schemas of the built-in plugins, plus the ones registered with RegisterInputSchema
+	inputSchemas = map[string]InputSchema{
+		appconfig.PluginNameAwsRunShellScript:      runScriptInputSchema,
+		appconfig.PluginNameAwsRunPowerShellScript: runScriptInputSchema,
+		appconfig.PluginDownloadContent: {
+			"sourceType":      {Type: InputTypeString, Required: true},
+			"destinationPath": {Type: InputTypeString},
+		},
+		appconfig.PluginRunDocument: {
+			"documentType": {Type: InputTypeString},
+			"documentPath": {Type: InputTypeString, Required: true},
+		},
+	}
+	inputSchemasLock sync.RWMutex
+)
+
+// RegisterInputSchema declares the inputs of a plugin so that the document parser and RunPlugins reject a step
+// with invalid inputs before the plugin is created. Registering a nil schema removes the schema of the plugin.
+func RegisterInputSchema(pluginName string, schema InputSchema) {
+	inputSchemasLock.Lock()
+	defer inputSchemasLock.Unlock()
+	if schema == nil {
+		delete(inputSchemas, pluginName)
+		return
+	}
+	inputSchemas[pluginName] = schema
+}
+
+func getInputSchema(pluginName string) (schema InputSchema, found bool) {
+	inputSchemasLock.RLock()
+	defer inputSchemasLock.RUnlock()
+	schema, found = inputSchemas[pluginName]
+	return
+}
+
+// ValidatePluginInputs checks the inputs of a step against the schema registered for its plugin, if any.
+// Steps of schema 1.x documents may hold a list of input sets, each of which is validated.
+func ValidatePluginInputs(pluginName string, properties interface{}) error {
+	schema, found := getInputSchema(pluginName)
+	if !found {
+		return nil
+	}
+
+	var problems []string
+	switch inputs := properties.(type) {
+	case []interface{}:
+		for index, inputSet := range inputs {
+			for _, problem := range schema.validate(inputSet) {
+				problems = append(problems, fmt.Sprintf("input set %d: %v", index, problem))
+			}
+		}
+	default:
+		problems = schema.validate(inputs)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid inputs for plugin %v: %v", pluginName, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validate returns the problems found in a set of inputs, ordered by input name
+func (schema InputSchema) validate(properties interface{}) (problems []string) {
+	var inputs map[string]interface{}
+	switch typed := properties.(type) {
+	case nil:
+	case map[string]interface{}:
+		inputs = typed
+	default:
+		return []string{fmt.Sprintf("inputs must be a map, got %v", describeInputValue(properties))}
+	}
+
+	inputNames := make([]string, 0, len(schema))
+	for inputName := range schema {
+		inputNames = append(inputNames, inputName)
+	}
+	sort.Strings(inputNames)
+	for _, inputName := range inputNames {
+		definition := schema[inputName]
+		value, supplied := lookupInput(inputs, inputName)
+		if !supplied || value == nil {
+			if definition.Required {
+				problems = append(problems, fmt.Sprintf("required input %q is missing", inputName))
+			}
+			continue
+		}
+		if !definition.Type.accepts(value) {
+			problems = append(problems, fmt.Sprintf("input %q must be %v, got %v", inputName, definition.Type, describeInputValue(value)))
+		}
+	}
+	return problems
+}
+
+// lookupInput returns the input named inputName, preferring an exact match over a case-insensitive one
+func lookupInput(inputs map[string]interface{}, inputName string) (value interface{}, found bool) {
+	if value, found = inputs[inputName]; found {
+		return
+	}
+	for name, value := range inputs {
+		if strings.EqualFold(name, inputName) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// accepts returns whether the value, as decoded from the document, has the input type
+func (inputType InputType) accepts(value interface{}) bool {
+	switch inputType {
+	case InputTypeString:
+		_, ok := value.(string)
+		return ok
+	case InputTypeStringList:
+		switch list := value.(type) {
+		case []string:
+			return true
+		case []interface{}:
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	case InputTypeInteger:
+		switch number := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return number == math.Trunc(number) && !math.IsInf(number, 0)
+		case json.Number:
+			_, err := number.Int64()
+			return err == nil
+		case string:
+			// an empty string, as substituted for a parameter defaulting to "", leaves the input unset
+			if strings.TrimSpace(number) == "" {
+				return true
+			}
+			_, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			return err == nil
+		}
+		return false
+	case InputTypeBoolean:
+		switch boolean := value.(type) {
+		case bool:
+			return true
+		case string:
+			return strings.EqualFold(boolean, "true") || strings.EqualFold(boolean, "false")
+		}
+		return false
+	case InputTypeMap:
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// describeInputValue names the type of a decoded document value, the value itself is left out as it may be sensitive
+func describeInputValue(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64, float64, json.Number:
+		return "number"
+	case []string, []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const schemaTestPlugin = "aws:schemaTestPlugin"
+
+var schemaTestInputs = InputSchema{
+	"commands":       {Type: InputTypeStringList, Required: true},
+	"timeoutSeconds": {Type: InputTypeInteger},
+	"enabled":        {Type: InputTypeBoolean},
+	"name":           {Type: InputTypeString},
+	"environment":    {Type: InputTypeMap},
+}
+
+func registerSchemaTestPlugin(t *testing.T) {
+	RegisterInputSchema(schemaTestPlugin, schemaTestInputs)
+	t.Cleanup(func() { RegisterInputSchema(schemaTestPlugin, nil) })
+}
+
+func TestValidatePluginInputsWithoutSchema(t *testing.T) {
+	assert.Nil(t, ValidatePluginInputs("aws:noSchemaPlugin", "anything"))
+}
+
+func TestValidatePluginInputsValid(t *testing.T) {
+	registerSchemaTestPlugin(t)
+
+	var properties interface{}
+	err := json.Unmarshal([]byte(`{
+		"commands": ["echo hello"],
+		"timeoutSeconds": 60,
+		"enabled": true,
+		"name": "step",
+		"environment": {"KEY": "value"},
+		"undeclared": 1
+	}`), &properties)
+	assert.Nil(t, err)
+	assert.Nil(t, ValidatePluginInputs(schemaTestPlugin, properties))
+
+	// values substituted from document parameters arrive as strings
+	assert.Nil(t, ValidatePluginInputs(schemaTestPlugin, map[string]interface{}{
+		"commands":       []string{"echo hello"},
+		"timeoutSeconds": "60",
+		"enabled":        "False",
+	}))
+}
+
+func TestValidatePluginInputsMissingRequired(t *testing.T) {
+	registerSchemaTestPlugin(t)
+
+	err := ValidatePluginInputs(schemaTestPlugin, map[string]interface{}{"name": "step"})
+	assert.EqualError(t, err, `invalid inputs for plugin aws:schemaTestPlugin: required input "commands" is missing`)
+
+	err = ValidatePluginInputs(schemaTestPlugin, nil)
+	assert.EqualError(t, err, `invalid inputs for plugin aws:schemaTestPlugin: required input "commands" is missing`)
+}
+
+func TestValidatePluginInputsWrongTypes(t *testing.T) {
+	registerSchemaTestPlugin(t)
+
+	err := ValidatePluginInputs(schemaTestPlugin, map[string]interface{}{
+		"commands":       []interface{}{"echo hello", 1.0},
+		"timeoutSeconds": 1.5,
+		"enabled":        "yes",
+		"name":           false,
+		"environment":    []interface{}{},
+	})
+	assert.EqualError(t, err, "invalid inputs for plugin aws:schemaTestPlugin: "+
+		`input "commands" must be StringList, got list; `+
+		`input "enabled" must be Boolean, got string; `+
+		`input "environment" must be Map, got list; `+
+		`input "name" must be String, got boolean; `+
+		`input "timeoutSeconds" must be Integer, got number`)
+}
+
+func TestValidatePluginInputsList(t *testing.T) {
+	registerSchemaTestPlugin(t)
+
+	err := ValidatePluginInputs(schemaTestPlugin, []interface{}{
+		map[string]interface{}{"commands": []interface{}{"echo hello"}},
+		map[string]interface{}{"timeoutSeconds": "soon"},
+		"not a map",
+	})
+	assert.EqualError(t, err, "invalid inputs for plugin aws:schemaTestPlugin: "+
+		`input set 1: required input "commands" is missing; `+
+		`input set 1: input "timeoutSeconds" must be Integer, got string; `+
+		`input set 2: inputs must be a map, got string`)
+}
+
+func TestValidatePluginInputsMatchesNamesCaseInsensitively(t *testing.T) {
+	registerSchemaTestPlugin(t)
+
+	err := ValidatePluginInputs(schemaTestPlugin, map[string]interface{}{
+		"Commands":       []interface{}{"echo hello"},
+		"TimeoutSeconds": "soon",
+	})
+	assert.EqualError(t, err, `invalid inputs for plugin aws:schemaTestPlugin: input "timeoutSeconds" must be Integer, got string`)
+}
+
+func TestValidatePluginInputsBuiltInPlugins(t *testing.T) {
+	assert.Nil(t, ValidatePluginInputs(appconfig.PluginNameAwsRunShellScript, map[string]interface{}{
+		"runCommand":       []interface{}{"uptime"},
+		"workingDirectory": "/tmp",
+		"timeoutSeconds":   "600",
+	}))
+	assert.Nil(t, ValidatePluginInputs(appconfig.PluginNameAwsRunPowerShellScript, map[string]interface{}{
+		"runCommand":     []interface{}{"Get-Date"},
+		"timeoutSeconds": "",
+	}))
+	assert.EqualError(t, ValidatePluginInputs(appconfig.PluginNameAwsRunPowerShellScript, map[string]interface{}{
+		"runCommand": "Get-Date",
+	}), `invalid inputs for plugin aws:runPowerShellScript: input "runCommand" must be StringList, got string`)
+	assert.EqualError(t, ValidatePluginInputs(appconfig.PluginRunDocument, map[string]interface{}{
+		"documentType": "LocalPath",
+	}), `invalid inputs for plugin aws:runDocument: required input "documentPath" is missing`)
+}
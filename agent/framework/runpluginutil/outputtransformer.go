@@ -0,0 +1,87 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"runtime/debug"
+
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// withheldOutput replaces the output of a step when the output transformer fails, so untransformed output is never persisted
+const withheldOutput = "Output withheld: the output transformer failed"
+
+// OutputTransformer rewrites the standard output and standard error of every step run by RunPlugins, for instance to
+// redact secrets. Transform is called with each line, without its line break, before the line is written to the
+// orchestration directory, uploaded to S3 and CloudWatch, and captured in the step result.
+type OutputTransformer interface {
+	Transform(pluginName string, output string) string
+}
+
+// OutputTransformerFunc adapts a function to the OutputTransformer interface
+type OutputTransformerFunc func(pluginName string, output string) string
+
+// Transform calls f(pluginName, output)
+func (f OutputTransformerFunc) Transform(pluginName string, output string) string {
+	return f(pluginName, output)
+}
+
+// ChainOutputTransformers returns a transformer applying the given transformers in order
+func ChainOutputTransformers(transformers ...OutputTransformer) OutputTransformer {
+	return OutputTransformerFunc(func(pluginName string, output string) string {
+		for _, transformer := range transformers {
+			output = transformer.Transform(pluginName, output)
+		}
+		return output
+	})
+}
+
+// SSMOutputTransformer is the transformer RunPlugins applies to the step outputs. Like SSMMetricsSink, it is set when the
+// process executing the documents starts, such as the document worker.
+var SSMOutputTransformer OutputTransformer = identityOutputTransformer{}
+
+// identityOutputTransformer returns the output unchanged, it is the default transformer
+type identityOutputTransformer struct{}
+
+func (identityOutputTransformer) Transform(_ string, output string) string {
+	return output
+}
+
+// outputTransformerFilter returns the line filter applying the registered transformer to the output of a step,
+// nil when no transformer other than the default one is registered
+func outputTransformerFilter(log log.T, pluginName string) multiwriter.LineFilter {
+	transformer := SSMOutputTransformer
+	if _, isIdentity := transformer.(identityOutputTransformer); transformer == nil || isIdentity {
+		return nil
+	}
+	return func(line string) (string, bool) {
+		return transformOutput(log, transformer, pluginName, line), true
+	}
+}
+
+// transformOutput applies the transformer to a line of output of a step, the line is withheld if the transformer panics
+func transformOutput(log log.T, transformer OutputTransformer, pluginName string, output string) (transformed string) {
+	if output == "" {
+		return output
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Output transformer panic: %v", r)
+			log.Errorf("Stacktrace:\n%s", debug.Stack())
+			transformed = withheldOutput
+		}
+	}()
+	return transformer.Transform(pluginName, output)
+}
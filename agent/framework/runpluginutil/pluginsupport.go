@@ -0,0 +1,66 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// PluginSupport describes whether a plugin can run documents on this agent
+type PluginSupport struct {
+	Name string
+	// Registered is true when the registry holds a factory for the plugin
+	Registered bool
+	// Supported is true when the plugin is known to this agent version and supported on the current platform
+	Supported bool
+	// Reason explains why the plugin cannot run, it is empty for registered and supported plugins
+	Reason string
+}
+
+// DescribePluginSupport reports the support status of every plugin in the registry and of the
+// additional plugin names, sorted by name. It applies the same checks RunPlugins applies to a step.
+func DescribePluginSupport(log log.T, registry PluginRegistry, pluginNames ...string) []PluginSupport {
+	names := make(map[string]struct{}, len(registry)+len(pluginNames))
+	for name := range registry {
+		names[name] = struct{}{}
+	}
+	for _, name := range pluginNames {
+		names[name] = struct{}{}
+	}
+
+	statuses := make([]PluginSupport, 0, len(names))
+	for name := range names {
+		_, registered := registry[name]
+		isKnown, isSupported, _ := isSupportedPlugin(log, name)
+		status := PluginSupport{
+			Name:       name,
+			Registered: registered,
+			Supported:  isKnown && isSupported,
+		}
+		switch {
+		case !isKnown:
+			status.Reason = agentTooOldMessage(name)
+		case !isSupported:
+			status.Reason = fmt.Sprintf("plugin %s is not supported on the current platform", name)
+		case !registered:
+			status.Reason = fmt.Sprintf("plugin %s is not registered with the agent", name)
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
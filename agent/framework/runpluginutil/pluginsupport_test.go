@@ -0,0 +1,43 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package runpluginutil
+
+import (
+	"testing"
+
+	mocklog "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribePluginSupport(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	registry := PluginRegistry{
+		testPlugin0:           new(PluginFactoryMock),
+		testUnsupportedPlugin: new(PluginFactoryMock),
+	}
+
+	statuses := DescribePluginSupport(mocklog.NewMockLog(), registry, testPlugin1, testUnknownPlugin, testPlugin0)
+
+	assert.Equal(t, []PluginSupport{
+		{Name: testPlugin0, Registered: true, Supported: true},
+		{Name: testPlugin1, Registered: false, Supported: true, Reason: "plugin plugin1 is not registered with the agent"},
+		{Name: testUnknownPlugin, Registered: false, Supported: false, Reason: agentTooOldMessage(testUnknownPlugin)},
+		{Name: testUnsupportedPlugin, Registered: true, Supported: false, Reason: "plugin plugin4 is not supported on the current platform"},
+	}, statuses)
+}
@@ -16,7 +16,9 @@ package runpluginutil
 
 import (
 	"fmt"
+	"path/filepath"
 	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -24,22 +26,44 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/localsecret"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/parameters"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/stepoutput"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/platform"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/version"
 )
 
 const (
 	executeStep string = "execute"
 	skipStep    string = "skip"
 	failStep    string = "fail"
+	cancelStep  string = "cancel"
 )
 
+// workingDirectoryInput is the step input that overrides the default working directory of the document
+const workingDirectoryInput = "workingDirectory"
+
+// resolvedInputMask replaces the secret values in the resolved input of a step
+const resolvedInputMask = "*****"
+
+// stepTimeoutGracePeriod is how long a step which timed out is given to return once its cancel flag is set
+var stepTimeoutGracePeriod = 30 * time.Second
+
+// documentCancelPollInterval is how often a step with a timeout checks the document cancel flag
+var documentCancelPollInterval = time.Second
+
+// sensitiveOutputPlaceholder replaces the output of a step marked with sensitiveOutput in the step result
+const sensitiveOutputPlaceholder = "Output omitted because the step output is sensitive"
+
 // TODO: rename to RCPlugin, this represents RCPlugin interface.
 type T interface {
 	Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler)
@@ -52,6 +76,30 @@ type PluginFactory interface {
 // PluginRegistry stores a set of plugins (both worker and long running plugins), indexed by ID.
 type PluginRegistry map[string]PluginFactory
 
+// Register adds the factory of a plugin to the registry, it returns an error and keeps the
+// registered factory when a plugin is already registered with the same name
+func (registry PluginRegistry) Register(name string, factory PluginFactory) error {
+	if registry.IsRegistered(name) {
+		return fmt.Errorf("plugin %v is already registered", name)
+	}
+	registry[name] = factory
+	return nil
+}
+
+// MustRegister adds the factory of a plugin to the registry like Register, and panics when a plugin is already
+// registered with the same name. It is meant for the registration of the plugins built into the agent.
+func (registry PluginRegistry) MustRegister(name string, factory PluginFactory) {
+	if err := registry.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+// IsRegistered returns whether a plugin is registered with the given name
+func (registry PluginRegistry) IsRegistered(name string) bool {
+	_, registered := registry[name]
+	return registered
+}
+
 var (
 	SSMPluginRegistry PluginRegistry
 
@@ -62,22 +110,25 @@ var (
 // This allows us to differentiate between the case where a document asks for a plugin that exists but isn't supported on this platform
 // and the case where a plugin name isn't known at all to this version of the agent (and the user should probably upgrade their agent)
 var allPlugins = map[string]struct{}{
-	appconfig.PluginNameAwsAgentUpdate:         {},
-	appconfig.PluginNameAwsApplications:        {},
-	appconfig.PluginNameAwsConfigureDaemon:     {},
-	appconfig.PluginNameAwsConfigurePackage:    {},
-	appconfig.PluginNameAwsPowerShellModule:    {},
-	appconfig.PluginNameAwsRunPowerShellScript: {},
-	appconfig.PluginNameAwsRunShellScript:      {},
-	appconfig.PluginNameAwsSoftwareInventory:   {},
-	appconfig.PluginNameCloudWatch:             {},
-	appconfig.PluginNameConfigureDocker:        {},
-	appconfig.PluginNameDockerContainer:        {},
-	appconfig.PluginNameDomainJoin:             {},
-	appconfig.PluginEC2ConfigUpdate:            {},
-	appconfig.PluginNameRefreshAssociation:     {},
-	appconfig.PluginDownloadContent:            {},
-	appconfig.PluginRunDocument:                {},
+	appconfig.PluginNameAwsAgentUpdate:            {},
+	appconfig.PluginNameAwsApplications:           {},
+	appconfig.PluginNameAwsCheckCertificateExpiry: {},
+	appconfig.PluginNameAwsConfigureDaemon:        {},
+	appconfig.PluginNameAwsConfigureKernelModules: {},
+	appconfig.PluginNameAwsConfigureRegistry:      {},
+	appconfig.PluginNameAwsConfigurePackage:       {},
+	appconfig.PluginNameAwsPowerShellModule:       {},
+	appconfig.PluginNameAwsRunPowerShellScript:    {},
+	appconfig.PluginNameAwsRunShellScript:         {},
+	appconfig.PluginNameAwsSoftwareInventory:      {},
+	appconfig.PluginNameCloudWatch:                {},
+	appconfig.PluginNameConfigureDocker:           {},
+	appconfig.PluginNameDockerContainer:           {},
+	appconfig.PluginNameDomainJoin:                {},
+	appconfig.PluginEC2ConfigUpdate:               {},
+	appconfig.PluginNameRefreshAssociation:        {},
+	appconfig.PluginDownloadContent:               {},
+	appconfig.PluginRunDocument:                   {},
 }
 
 // allSessionPlugins is the list of all known session plugins.
@@ -88,6 +139,12 @@ var allSessionPlugins = map[string]struct{}{
 	appconfig.PluginNameNonInteractiveCommands: {},
 }
 
+// IsKnownPlugin returns whether pluginName is a document plugin known to this version of the agent, on any platform
+func IsKnownPlugin(pluginName string) bool {
+	_, known := allPlugins[pluginName]
+	return known
+}
+
 // Assign method to global variables to allow unittest to override
 var isSupportedPlugin = IsPluginSupportedForCurrentPlatform
 
@@ -119,6 +176,9 @@ func RunPlugins(
 	}()
 
 	for pluginIndex, pluginState := range plugins {
+		// every line logged for the step, including by the plugin, carries the document, plugin and step it belongs to
+		stepContext := context.With(stepLogContext(pluginState, pluginIndex))
+		log := stepContext.Log()
 		pluginID := pluginState.Id     // the identifier of the plugin
 		pluginName := pluginState.Name // the name of the plugin
 		pluginOutput := pluginState.Result
@@ -157,11 +217,17 @@ func RunPlugins(
 
 		log.Debugf("Executing plugin - %v", pluginName)
 
+		// a paused document starts its next step once it is resumed, cancelled or shut down
+		if cancelFlag != nil && cancelFlag.State() == task.Paused {
+			log.Infof("Document is paused, plugin %s %s starts once the document is resumed", pluginName, pluginID)
+			log.Infof("Document left the paused state with state %v", cancelFlag.WaitWhilePaused())
+		}
+
 		// populate plugin start time, status, and upstream service name
 		configuration := pluginState.Configuration
 		configuration.UpstreamServiceName = upstreamServiceName
 
-		if ioConfig.OutputS3BucketName != "" {
+		if ioConfig.OutputS3BucketName != "" && !configuration.SensitiveOutput {
 			pluginOutputs[pluginID].OutputS3BucketName = ioConfig.OutputS3BucketName
 			if ioConfig.OutputS3KeyPrefix != "" {
 				pluginOutputs[pluginID].OutputS3KeyPrefix = fileutil.BuildS3Path(ioConfig.OutputS3KeyPrefix, pluginName)
@@ -174,6 +240,13 @@ func RunPlugins(
 			ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, ":", "-", -1)
 			ioConfig.CloudWatchConfig.LogStreamPrefix = strings.Replace(ioConfig.CloudWatchConfig.LogStreamPrefix, "*", "-", -1)
 		}
+		// the output of a sensitive step is not uploaded to S3 nor streamed to CloudWatch
+		stepIOConfig := ioConfig
+		if configuration.SensitiveOutput {
+			stepIOConfig.OutputS3BucketName = ""
+			stepIOConfig.OutputS3KeyPrefix = ""
+			stepIOConfig.CloudWatchConfig = contracts.CloudWatchConfiguration{}
+		}
 
 		var (
 			r                  contracts.PluginResult
@@ -181,34 +254,80 @@ func RunPlugins(
 			pluginHandlerFound bool
 			isKnown            bool
 			isSupported        bool
+			supportMessage     string
 		)
 
 		pluginFactory, pluginHandlerFound = registry[pluginName]
-		isKnown, isSupported, _ = isSupportedPlugin(log, pluginName)
+		isKnown, isSupported, supportMessage = isSupportedPlugin(log, pluginName)
 		// checking if a prior step returned exit codes 168 or 169 to exit document.
 		// If so we need to skip every other step
 		shouldSkipStepDueToPriorFailedStep := getShouldPluginSkipBasedOnControlFlow(
-			context,
+			stepContext,
 			plugins,
 			pluginIndex,
 			pluginOutputs,
 		)
 
-		operation, logMessage := getStepExecutionOperation(
+		operation, logMessage, skipReason := getStepExecutionOperation(
 			log,
 			pluginName,
 			pluginID,
 			isKnown,
 			isSupported,
+			supportMessage,
 			pluginHandlerFound,
 			configuration.IsPreconditionEnabled,
-			configuration.Preconditions,
+			getPreconditionClauses(configuration),
 			shouldSkipStepDueToPriorFailedStep)
+		operation, logMessage, skipReason = applyPluginPolicy(context.AppConfig().Ssm, pluginName, pluginID, operation, logMessage, skipReason)
+		if failedStepID := getAbortingFailedStep(ioConfig, plugins, pluginIndex, pluginOutputs); failedStepID != "" {
+			operation, logMessage, skipReason = skipStep, fmt.Sprintf(
+				"Plugin with name %s and id %s skipped because step %s failed and the document aborts on failure",
+				pluginName,
+				pluginID,
+				failedStepID), contracts.SkipReasonPriorStepFailed
+		}
+		// a cancel requested by the operator is not a step failure, shutdown is left to the plugins to handle
+		if cancelFlag != nil && cancelFlag.Canceled() {
+			operation, logMessage, skipReason = cancelStep, fmt.Sprintf(
+				"Plugin with name %s and id %s not run because the document was cancelled",
+				pluginName,
+				pluginID), ""
+		}
 
 		switch operation {
 		case executeStep:
 			log.Infof("Running plugin %s %s", pluginName, pluginID)
-			r = runPlugin(context, pluginFactory, pluginName, configuration, cancelFlag, ioConfig)
+			var err error
+			// named outputs are set explicitly by the steps, their references are resolved in every document
+			if configuration.Properties, err = stepoutput.ResolveNamedOutputs(configuration.Properties, getNamedStepOutputs(pluginOutputs)); err != nil {
+				err = fmt.Errorf("failed to resolve step output references: %v", err)
+			} else {
+				configuration.RedactedValues = append(configuration.RedactedValues, getSecureStepOutputValues(pluginOutputs)...)
+			}
+			if err == nil && configuration.ResolveStepOutputReferences {
+				if configuration.Properties, err = stepoutput.Resolve(configuration.Properties, getCompletedStepOutputs(pluginOutputs)); err != nil {
+					err = fmt.Errorf("failed to resolve step output references: %v", err)
+				}
+			}
+			if err == nil {
+				err = ValidatePluginInputs(pluginName, configuration.Properties)
+			}
+			if err == nil {
+				var workingDirectory string
+				if workingDirectory, err = getStepWorkingDirectory(configuration.Properties); workingDirectory != "" {
+					log.Debugf("step %v overrides the default working directory with %v", pluginID, workingDirectory)
+					configuration.DefaultWorkingDirectory = workingDirectory
+				}
+			}
+			if err != nil {
+				r = contracts.PluginResult{Status: contracts.ResultStatusFailed, Code: 1, StartDateTime: time.Now(), EndDateTime: time.Now()}
+				r.Error = err.Error()
+				r.Output = r.Error
+				log.Error(r.Error)
+			} else {
+				r = runPluginWithStepHooks(stepContext, registry, pluginFactory, pluginName, configuration, cancelFlag, stepIOConfig)
+			}
 			pluginOutputs[pluginID].Code = r.Code
 			pluginOutputs[pluginID].Status = r.Status
 			pluginOutputs[pluginID].Error = r.Error
@@ -216,6 +335,13 @@ func RunPlugins(
 			pluginOutputs[pluginID].StandardOutput = r.StandardOutput
 			pluginOutputs[pluginID].Output = r.Output
 			pluginOutputs[pluginID].StepName = r.StepName
+			pluginOutputs[pluginID].ResolvedInput = r.ResolvedInput
+			pluginOutputs[pluginID].ResourceUsage = r.ResourceUsage
+			pluginOutputs[pluginID].ProcessID = r.ProcessID
+			pluginOutputs[pluginID].PreStep = r.PreStep
+			pluginOutputs[pluginID].PostStep = r.PostStep
+			pluginOutputs[pluginID].Outputs = r.Outputs
+			pluginOutputs[pluginID].SecureOutputs = r.SecureOutputs
 
 			onFailureProp := getStringPropByName(pluginState.Configuration.Properties, contracts.OnFailureModifier)
 			hasOnFailureProp := onFailureProp == contracts.ModifierValueExit || onFailureProp == contracts.ModifierValueSuccessAndExit
@@ -230,7 +356,7 @@ func RunPlugins(
 				outputAddition = "\nStep exited with code 169. Therefore, marking step as Failed. Further document steps will be skipped."
 				pluginOutputs[pluginID].StandardError = r.StandardError + outputAddition
 				pluginOutputs[pluginID].StandardOutput = r.StandardOutput + outputAddition
-			} else if pluginOutputs[pluginID].Status == contracts.ResultStatusFailed && hasOnFailureProp {
+			} else if (pluginOutputs[pluginID].Status == contracts.ResultStatusFailed || pluginOutputs[pluginID].Status == contracts.ResultStatusTimedOut) && hasOnFailureProp {
 				outputAddition = "\nStep was found to have onFailure property. Further document steps will be skipped."
 				pluginOutputs[pluginID].StandardError = r.StandardError + outputAddition
 				pluginOutputs[pluginID].StandardOutput = r.StandardOutput + outputAddition
@@ -245,10 +371,17 @@ func RunPlugins(
 			pluginOutputs[pluginID].Status = contracts.ResultStatusSkipped
 			pluginOutputs[pluginID].Code = 0
 			pluginOutputs[pluginID].Output = logMessage
+			pluginOutputs[pluginID].SkipReason = skipReason
+		case cancelStep:
+			log.Info(logMessage)
+			pluginOutputs[pluginID].Status = contracts.ResultStatusCancelled
+			pluginOutputs[pluginID].Code = 1
+			pluginOutputs[pluginID].Output = logMessage
 		case failStep:
 			err := fmt.Errorf(logMessage)
 			pluginOutputs[pluginID].Status = contracts.ResultStatusFailed
 			pluginOutputs[pluginID].Error = err.Error()
+			pluginOutputs[pluginID].SkipReason = skipReason
 			log.Error(err)
 		default:
 			err := fmt.Errorf("Unknown error, Operation: %s, Plugin name: %s", operation, pluginName)
@@ -259,13 +392,18 @@ func RunPlugins(
 
 		// set end time.
 		pluginOutputs[pluginID].EndDateTime = time.Now()
+		recordStepMetrics(log, pluginOutputs[pluginID], operation == executeStep)
 		log.Infof("Sending plugin %v completion message", pluginID)
 
 		// truncate the result and send it back to buffer channel.
 		result := *pluginOutputs[pluginID]
 		pluginConfig := iohandler.DefaultOutputConfig()
-		result.StandardOutput = pluginutil.StringPrefix(result.StandardOutput, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
-		result.StandardError = pluginutil.StringPrefix(result.StandardError, pluginConfig.MaxStdoutLength, pluginConfig.OutputTruncatedSuffix)
+		maxOutputSize := pluginConfig.MaxStdoutLength
+		if configuredSize := context.AppConfig().Ssm.PluginOutputMaxSizeBytes; configuredSize > 0 {
+			maxOutputSize = configuredSize
+		}
+		result.StandardOutput = pluginutil.StringPrefix(result.StandardOutput, maxOutputSize, pluginConfig.OutputTruncatedSuffix)
+		result.StandardError = pluginutil.StringPrefix(result.StandardError, maxOutputSize, pluginConfig.OutputTruncatedSuffix)
 		// send to buffer channel, guaranteed to not block since buffer size is plugin number
 		resChan <- result
 
@@ -280,6 +418,36 @@ func RunPlugins(
 	return
 }
 
+// applyPluginPolicy blocks the steps that would run, or fail to run, a plugin missing from the allowed plugins of the agent
+// configuration, whether or not the plugin is supported. Steps skipped for another reason are left skipped.
+func applyPluginPolicy(
+	config appconfig.SsmCfg,
+	pluginName string,
+	pluginId string,
+	operation string,
+	logMessage string,
+	skipReason contracts.SkipReason,
+) (string, string, contracts.SkipReason) {
+	if len(config.AllowedPlugins) == 0 || (operation != executeStep && operation != failStep) {
+		return operation, logMessage, skipReason
+	}
+	for _, allowedPlugin := range config.AllowedPlugins {
+		if allowedPlugin == pluginName {
+			return operation, logMessage, skipReason
+		}
+	}
+	logMessage = fmt.Sprintf("Plugin with name %s not permitted by policy. Step name: %s", pluginName, pluginId)
+	if config.DisallowedPluginAction == appconfig.DisallowedPluginActionSkip {
+		return skipStep, logMessage, contracts.SkipReasonPluginNotPermitted
+	}
+	return failStep, logMessage, ""
+}
+
+// stepLogContext returns the log context identifying a step by its document, plugin id and index in the document
+func stepLogContext(pluginState contracts.PluginState, stepIndex int) string {
+	return fmt.Sprintf("[documentID=%v pluginID=%v stepIndex=%v]", pluginState.Configuration.BookKeepingFileName, pluginState.Id, stepIndex)
+}
+
 // orchestrationDirCleanup will clean orchestration folder for the successful and failed document executions. Cleaned only when the agent is configured to do so
 func orchestrationDirCleanup(context context.T, pluginsCount int, pluginOutputs map[string]*contracts.PluginResult, orchestrationDir string) {
 	log := context.Log()
@@ -344,7 +512,48 @@ var runPlugin = func(
 	res.StartDateTime = time.Now()
 	defer func() { res.EndDateTime = time.Now() }()
 
-	output := iohandler.NewDefaultIOHandler(context, ioConfig)
+	// local secrets are resolved right before execution so that only the references are persisted
+	var secretValues []string
+	if config.Properties, secretValues, err = localsecret.Resolve(log, context.AppConfig().Ssm.LocalSecretsDirectory, config.Properties); err != nil {
+		res.Status = contracts.ResultStatusFailed
+		res.Code = 1
+		res.Error = fmt.Errorf("failed to resolve local secrets: %v", err).Error()
+		res.Output = res.Error
+		log.Error(res.Error)
+		return
+	}
+	// the parameter-resolution functions bound at parse time are decoded here for the same reason
+	var decodedProperties, decodedSettings []string
+	if config.Properties, decodedProperties, err = parameters.ResolveDecodedParameters(config.Properties); err == nil {
+		config.Settings, decodedSettings, err = parameters.ResolveDecodedParameters(config.Settings)
+	}
+	if err != nil {
+		res.Status = contracts.ResultStatusFailed
+		res.Code = 1
+		res.Error = fmt.Errorf("failed to decode parameters: %v", err).Error()
+		res.Output = res.Error
+		log.Error(res.Error)
+		return
+	}
+	secretValues = append(secretValues, decodedProperties...)
+	secretValues = append(secretValues, decodedSettings...)
+	// the values of the secure step outputs referenced by the step are masked as well
+	secretValues = append(secretValues, config.RedactedValues...)
+	// the plugin masks the secret values in what it persists outside of its output, such as script files
+	config.RedactedValues = secretValues
+
+	directives := stepoutput.NewDirectiveCollector()
+	// the success criteria are matched line by line on the output as it is written, once the secret values are masked
+	// and the output is transformed
+	criteria, criteriaErr := newSuccessCriteriaMatcher(config.SuccessCriteria)
+	var outputFilters []multiwriter.LineFilter
+	if transformer := outputTransformerFilter(log, pluginName); transformer != nil {
+		outputFilters = append(outputFilters, transformer)
+	}
+	if criteria != nil {
+		outputFilters = append(outputFilters, criteria.Filter)
+	}
+	output := newStepIOHandler(context, ioConfig, secretValues, directives, outputFilters...)
 	//check if properties is a list. If true, then unroll
 	switch config.Properties.(type) {
 	case []interface{}:
@@ -355,7 +564,7 @@ var runPlugin = func(
 		}
 		for _, prop := range properties {
 			config.Properties = prop
-			propOutput := iohandler.NewDefaultIOHandler(context, ioConfig)
+			propOutput := newStepIOHandler(context, ioConfig, secretValues, directives, outputFilters...)
 			stepName, err = getStepName(pluginName, config)
 			if err != nil {
 				errorString := fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err)
@@ -376,6 +585,7 @@ var runPlugin = func(
 			executePlugin(plugin, pluginName, stepName, config, cancelFlag, output)
 		}
 	}
+	applySuccessCriteria(log, criteria, criteriaErr, output)
 
 	if ioConfig.OutputS3BucketName != "" {
 		if stepName != "" {
@@ -388,12 +598,68 @@ var runPlugin = func(
 	res.Code = output.GetExitCode()
 	res.Status = output.GetStatus()
 	res.Output = output.GetOutput()
-	res.StandardOutput = output.GetStdout()
-	res.StandardError = output.GetStderr()
+	res.ResourceUsage = output.GetResourceUsage()
+	res.ProcessID = output.GetProcessID()
+	outputs, secureOutputs := directives.Outputs()
+	res.Outputs = redactOutputs(outputs, secretValues)
+	res.SecureOutputs = secureOutputs
+	res.StandardOutput = localsecret.Redact(output.GetStdout(), secretValues)
+	res.StandardError = localsecret.Redact(output.GetStderr(), secretValues)
+	res.ResolvedInput = localsecret.RedactWith(output.GetResolvedInput(), secretValues, resolvedInputMask)
+	if outputText, ok := res.Output.(string); ok {
+		res.Output = localsecret.Redact(outputText, secretValues)
+	}
+	if config.SensitiveOutput {
+		omitSensitiveOutput(&res)
+	}
 
 	return
 }
 
+// newStepIOHandler returns the IOHandler of a step, removing the output directives from stdout, masking the secret
+// values and applying the other output filters before the output is written to the output files and uploaded to S3
+// and CloudWatch
+func newStepIOHandler(
+	context context.T,
+	ioConfig contracts.IOConfiguration,
+	secretValues []string,
+	directives *stepoutput.DirectiveCollector,
+	outputFilters ...multiwriter.LineFilter) *iohandler.DefaultIOHandler {
+
+	output := iohandler.NewDefaultIOHandler(context, ioConfig)
+	output.AddStdoutFilter(directives.Filter)
+	if len(secretValues) > 0 {
+		output.AddOutputFilter(func(line string) (string, bool) {
+			return localsecret.Redact(line, secretValues), true
+		})
+	}
+	for _, filter := range outputFilters {
+		output.AddOutputFilter(filter)
+	}
+	return output
+}
+
+// omitSensitiveOutput replaces the output of a step in its result, the status, exit code and error are kept.
+// The named outputs of the step are kept secure.
+func omitSensitiveOutput(res *contracts.PluginResult) {
+	for key, value := range res.Outputs {
+		if res.SecureOutputs == nil {
+			res.SecureOutputs = make(map[string]string)
+		}
+		res.SecureOutputs[key] = value
+	}
+	res.Outputs = nil
+	if res.StandardOutput != "" {
+		res.StandardOutput = sensitiveOutputPlaceholder
+	}
+	if res.StandardError != "" {
+		res.StandardError = sensitiveOutputPlaceholder
+	}
+	if outputText, ok := res.Output.(string); res.Output != nil && (!ok || outputText != "") {
+		res.Output = sensitiveOutputPlaceholder
+	}
+}
+
 // executePlugin executes the plugin that's passed in and initializes the necessary writers
 func executePlugin(
 	plugin T,
@@ -406,7 +672,88 @@ func executePlugin(
 	// Create the output object and execute the plugin
 	defer output.Close()
 	output.Init(pluginName, stepName)
-	plugin.Execute(config, cancelFlag, output)
+	if config.TimeoutSeconds <= 0 {
+		plugin.Execute(config, cancelFlag, output)
+		return
+	}
+
+	if executePluginWithTimeout(plugin, config, cancelFlag, output) {
+		output.AppendErrorf("Step timed out after %v seconds", config.TimeoutSeconds)
+		output.SetExitCode(appconfig.CommandStoppedPreemptivelyExitCode)
+		output.SetStatus(contracts.ResultStatusTimedOut)
+	}
+}
+
+// executePluginWithTimeout executes the plugin with a step level cancel flag that mirrors the document cancel flag
+// and is set to Canceled once the step timeout elapses, so the plugin can clean up. The process tree of a plugin still
+// running stepTimeoutGracePeriod after its cancel flag was set is killed, and a plugin still running
+// stepTimeoutGracePeriod after that is abandoned, detached from the output of the step. Returns true if the step timed out.
+func executePluginWithTimeout(
+	plugin T,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	output iohandler.IOHandler) bool {
+
+	stepCancelFlag := task.NewChanneledCancelFlag()
+	pluginOutput := newDetachableOutput(output)
+	done := make(chan struct{})
+	var pluginPanic interface{}
+	go func() {
+		defer close(done)
+		// the panic is raised again by the caller, where runPlugin recovers from it
+		defer func() { pluginPanic = recover() }()
+		plugin.Execute(config, stepCancelFlag, pluginOutput)
+	}()
+
+	timeout := time.NewTimer(time.Duration(config.TimeoutSeconds) * time.Second)
+	defer timeout.Stop()
+	poll := time.NewTicker(documentCancelPollInterval)
+	defer poll.Stop()
+	for {
+		select {
+		case <-done:
+			if pluginPanic != nil {
+				panic(pluginPanic)
+			}
+			if !stepCancelFlag.Canceled() && !stepCancelFlag.ShutDown() {
+				// wake up any routine of the plugin still waiting on the step cancel flag
+				stepCancelFlag.Set(task.Completed)
+			}
+			return false
+		case <-poll.C:
+			if state := cancelFlag.State(); state == task.Canceled || state == task.ShutDown {
+				stepCancelFlag.Set(state)
+			}
+		case <-timeout.C:
+			stepCancelFlag.Set(task.Canceled)
+			if !waitForPlugin(done, stepTimeoutGracePeriod) {
+				if pid := pluginOutput.getProcessID(); pid != 0 {
+					if err := executers.KillProcessTree(pid); err != nil {
+						pluginOutput.AppendErrorf("Failed to kill the process %v of the step: %v", pid, err)
+					}
+				}
+				if !waitForPlugin(done, stepTimeoutGracePeriod) {
+					pluginOutput.detach()
+					output.AppendErrorf("Step did not stop within %v of its timeout and was abandoned", 2*stepTimeoutGracePeriod)
+					return true
+				}
+			}
+			if pluginPanic != nil {
+				panic(pluginPanic)
+			}
+			return true
+		}
+	}
+}
+
+// waitForPlugin returns true if the plugin returns within the wait
+func waitForPlugin(done chan struct{}, wait time.Duration) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(wait):
+		return false
+	}
 }
 
 // GetPropertyName returns the ID field of property in a v1.2 SSM Document
@@ -417,18 +764,21 @@ func GetPropertyName(rawPluginInput interface{}) (propertyName string, err error
 	return
 }
 
-// Checks plugin compatibility and step precondition and returns if it should be executed, skipped or failed
+// Checks plugin compatibility and step precondition and returns if it should be executed, skipped or failed,
+// along with the reason when the step is skipped or fails without running. supportMessage describes why
+// an unknown plugin cannot run, as returned by IsPluginSupportedForCurrentPlatform.
 func getStepExecutionOperation(
 	log log.T,
 	pluginName string,
 	pluginId string,
 	isKnown bool,
 	isSupported bool,
+	supportMessage string,
 	isPluginHandlerFound bool,
 	isPreconditionEnabled bool,
-	preconditions map[string][]contracts.PreconditionArgument,
+	preconditions []contracts.PreconditionClause,
 	shouldSkipStepDueToPriorFailedStep bool,
-) (string, string) {
+) (string, string, contracts.SkipReason) {
 	log.Debugf("isSupported flag = %t", isSupported)
 	log.Debugf("isPluginHandlerFound flag = %t", isPluginHandlerFound)
 	log.Debugf("isPreconditionEnabled flag = %t", isPreconditionEnabled)
@@ -437,33 +787,33 @@ func getStepExecutionOperation(
 		return skipStep, fmt.Sprintf(
 			"Plugin with name %s and id %s skipped due to prior step with an exit condition",
 			pluginName,
-			pluginId)
+			pluginId), contracts.SkipReasonPriorStepExit
 	}
 
 	if !isPreconditionEnabled {
 		// 1.x or 2.0 document
 		if !isKnown {
 			return failStep, fmt.Sprintf(
-				"Plugin with name %s is not supported by this version of ssm agent, please update to latest version. Step name: %s",
-				pluginName,
-				pluginId)
+				"Step execution failed because %s. Step name: %s",
+				supportMessage,
+				pluginId), contracts.SkipReasonAgentTooOld
 		} else if !isSupported {
 			return failStep, fmt.Sprintf(
 				"Plugin with name %s is not supported in current platform. Step name: %s",
 				pluginName,
-				pluginId)
+				pluginId), ""
 		} else if len(preconditions) > 0 {
 			// if 1.x or 2.0 document contains precondition or plugin not found, failStep
 			return failStep, fmt.Sprintf(
 				"Precondition is not supported for document schema version prior to 2.2. Step name: %s",
-				pluginId)
+				pluginId), ""
 		} else if !isPluginHandlerFound {
 			return failStep, fmt.Sprintf(
 				"Plugin with name %s not found. Step name: %s",
 				pluginName,
-				pluginId)
+				pluginId), ""
 		} else {
-			return executeStep, ""
+			return executeStep, "", ""
 		}
 	} else {
 		// 2.2 or higher (cross-platform) document
@@ -473,120 +823,194 @@ func getStepExecutionOperation(
 			// precondition is not present - if pluginFound executeStep, else skipStep
 			if !isKnown {
 				return failStep, fmt.Sprintf(
-					"Plugin with name %s is not supported by this version of ssm agent, please update to latest version. Step name: %s",
-					pluginName,
-					pluginId)
+					"Step execution failed because %s. Step name: %s",
+					supportMessage,
+					pluginId), contracts.SkipReasonAgentTooOld
 			} else if isSupported && isPluginHandlerFound {
-				return executeStep, ""
+				return executeStep, "", ""
 			} else {
 				return skipStep, fmt.Sprintf(
 					"Step execution skipped due to unsupported plugin: %s. Step name: %s",
 					pluginName,
-					pluginId)
+					pluginId), contracts.SkipReasonUnsupportedPlugin
 			}
 		} else {
 			log.Debugf("Cross-platform Precondition is present, precondition = %v", preconditions)
 
-			isAllowed, unrecognizedPreconditionList := evaluatePreconditions(log, preconditions)
+			evaluation := evaluatePreconditions(log, preconditions)
+			isAllowed := len(evaluation.unsatisfied) == 0
 
 			if isAllowed && !isKnown {
 				return failStep, fmt.Sprintf(
-					"Plugin with name %s is not supported by this version of ssm agent, please update to latest version. Step name: %s",
-					pluginName,
-					pluginId)
+					"Step execution failed because %s. Step name: %s",
+					supportMessage,
+					pluginId), contracts.SkipReasonAgentTooOld
+			} else if !isKnown {
+				return skipStep, fmt.Sprintf(
+					"Step execution skipped because %s. Step name: %s",
+					supportMessage,
+					pluginId), contracts.SkipReasonAgentTooOld
 			} else if !isSupported || !isPluginHandlerFound {
 				return skipStep, fmt.Sprintf(
 					"Step execution skipped due to unsupported plugin: %s. Step name: %s",
 					pluginName,
-					pluginId)
+					pluginId), contracts.SkipReasonUnsupportedPlugin
+			} else if evaluation.hasUnknownOperator {
+				// the step may target a newer agent, fail even if another precondition already rules it out
+				return failStep, fmt.Sprintf(
+					"Unrecognized precondition(s): '%s', please update agent to latest version. Step name: %s",
+					strings.Join(evaluation.unrecognized, ", "),
+					pluginId), ""
 			} else if !isAllowed {
 				return skipStep, fmt.Sprintf(
 					"Step execution skipped due to unsatisfied preconditions: '%s'. Step name: %s",
-					strings.Join(unrecognizedPreconditionList, ", "),
-					pluginId)
-			} else if len(unrecognizedPreconditionList) > 0 {
+					strings.Join(evaluation.unsatisfied, ", "),
+					pluginId), contracts.SkipReasonPreconditionFailed
+			} else if len(evaluation.unrecognized) > 0 {
 				return failStep, fmt.Sprintf(
 					"Unrecognized precondition(s): '%s', please update agent to latest version. Step name: %s",
-					strings.Join(unrecognizedPreconditionList, ", "),
-					pluginId)
+					strings.Join(evaluation.unrecognized, ", "),
+					pluginId), ""
 			} else {
-				return executeStep, ""
+				return executeStep, "", ""
 			}
 		}
 	}
 }
 
-// Evaluate precondition and return precondition result and unrecognized preconditions (if any)
+// agentTooOldMessage describes a plugin that this agent does not know about, which usually means
+// the document targets a newer agent release rather than a different platform.
+func agentTooOldMessage(pluginName string) string {
+	return fmt.Sprintf(
+		"plugin %s is not known to SSM Agent version %s, an agent update may be required",
+		pluginName,
+		version.Version)
+}
+
+// preconditionVariables resolves the platform variables preconditions can compare with constant values
+var preconditionVariables = map[string]func(log.T) (string, error){
+	"platformType":    platform.PlatformType,
+	"platformVersion": platform.PlatformVersion,
+}
+
+// preconditionEvaluation is the outcome of evaluating the preconditions of a step.
+// All the clauses of a step are combined with AND, the step runs only when none of them is unsatisfied.
+type preconditionEvaluation struct {
+	// unsatisfied lists the recognized preconditions that do not hold on this instance
+	unsatisfied []string
+	// unrecognized lists the preconditions this agent can not evaluate
+	unrecognized []string
+	// hasUnknownOperator is true when one of the unrecognized preconditions uses an operator this agent does not know
+	hasUnknownOperator bool
+}
+
+// getPreconditionClauses lists the precondition clauses of a step: the operators of its precondition,
+// in a stable order so that the step output does not change between runs, followed by its allOf group
+func getPreconditionClauses(configuration contracts.Configuration) []contracts.PreconditionClause {
+	operators := make([]string, 0, len(configuration.Preconditions))
+	for operator := range configuration.Preconditions {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+
+	clauses := make([]contracts.PreconditionClause, 0, len(operators)+len(configuration.PreconditionsAllOf))
+	for _, operator := range operators {
+		clauses = append(clauses, contracts.PreconditionClause{Operator: operator, Arguments: configuration.Preconditions[operator]})
+	}
+	return append(clauses, configuration.PreconditionsAllOf...)
+}
+
+// Evaluate precondition and return which preconditions are unsatisfied or unrecognized (if any)
 func evaluatePreconditions(
 	log log.T,
-	preconditions map[string][]contracts.PreconditionArgument,
-) (bool, []string) {
-
-	var isAllowed = true
-	var unrecognizedPreconditionList []string
+	preconditions []contracts.PreconditionClause,
+) (evaluation preconditionEvaluation) {
 
-	// For current release, we only support "StringEquals" operator and "platformType"
-	// operand, so explicitly checking for those and number of operands must be 2
-	for key, value := range preconditions {
+	// For current release, we only support "StringEquals", "StringNotEquals" and "Contains" operators
+	// with platform variable or document parameter operands, so explicitly checking for those and number of operands must be 2
+	for _, clause := range preconditions {
+		key, value := clause.Operator, clause.Arguments
 		switch key {
-		case "StringEquals":
+		case "StringEquals", "StringNotEquals", "Contains":
 			if len(value) != 2 {
-				unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator accepts exactly 2 arguments", key))
+				evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": operator accepts exactly 2 arguments", key))
 			} else {
 				if strings.Compare(value[0].InitialArgumentValue, value[1].InitialArgumentValue) == 0 {
-					// StringEquals preconditions with identical arguments are not allowed
-					if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 {
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
+					// preconditions with identical arguments are not allowed
+					if _, isVariable := preconditionVariables[value[0].InitialArgumentValue]; isVariable {
+						evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": [%v %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
 					} else {
 						// hide customer's parameters and constants
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't be identical", key))
+						evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": operator's arguments can't be identical", key))
 					}
 				} else if ssmparameterresolver.TextContainsSsmParameters(value[0].InitialArgumentValue) || ssmparameterresolver.TextContainsSsmParameters(value[1].InitialArgumentValue) {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't contain SSM parameters", key))
+					evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": operator's arguments can't contain SSM parameters", key))
 				} else if ssmparameterresolver.TextContainsSecureSsmParameters(value[0].InitialArgumentValue) || ssmparameterresolver.TextContainsSecureSsmParameters(value[1].InitialArgumentValue) {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": operator's arguments can't contain secure SSM parameters", key))
-				} else if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 || strings.Compare(value[1].InitialArgumentValue, "platformType") == 0 {
-					// keep original logic for platformType variable
-					// Platform type of OS on the instance
-					instancePlatformType, _ := platform.PlatformType(log)
-					log.Debugf("OS platform type of this instance = %s", instancePlatformType)
+					evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": operator's arguments can't contain secure SSM parameters", key))
+				} else if variable, resolveVariable, found := getPreconditionVariable(value); found {
+					// keep original logic for platform variables
+					instanceValue, _ := resolveVariable(log)
+					log.Debugf("%s of this instance = %s", variable, instanceValue)
 
 					// Variable and value can be in any order, i.e. both "StringEquals": ["platformType", "Windows"]
 					// and "StringEquals": ["Windows", "platformType"] are valid
-					var initialPlatformTypeValue string
-					var resolvedPlatformTypeValue string
-					if strings.Compare(value[0].InitialArgumentValue, "platformType") == 0 {
-						initialPlatformTypeValue = value[1].InitialArgumentValue
-						resolvedPlatformTypeValue = value[1].ResolvedArgumentValue
+					var initialVariableValue string
+					var resolvedVariableValue string
+					if strings.Compare(value[0].InitialArgumentValue, variable) == 0 {
+						initialVariableValue = value[1].InitialArgumentValue
+						resolvedVariableValue = value[1].ResolvedArgumentValue
 					} else {
-						initialPlatformTypeValue = value[0].InitialArgumentValue
-						resolvedPlatformTypeValue = value[0].ResolvedArgumentValue
+						initialVariableValue = value[0].InitialArgumentValue
+						resolvedVariableValue = value[0].ResolvedArgumentValue
 					}
 
-					if strings.Compare(strings.ToLower(initialPlatformTypeValue), strings.ToLower(resolvedPlatformTypeValue)) != 0 {
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": the second argument for the platformType variable can't contain document parameters", key))
-					} else if strings.Compare(instancePlatformType, strings.ToLower(initialPlatformTypeValue)) != 0 {
-						// if precondition doesn't match for platformType, mark step for skip
-						isAllowed = false
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
+					if strings.Compare(strings.ToLower(initialVariableValue), strings.ToLower(resolvedVariableValue)) != 0 {
+						evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": the second argument for the %s variable can't contain document parameters", key, variable))
+					} else if !isPreconditionOperatorSatisfied(key, strings.ToLower(instanceValue), strings.ToLower(initialVariableValue)) {
+						// if precondition doesn't match for the platform variable, mark step for skip
+						evaluation.unsatisfied = append(evaluation.unsatisfied, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
 					}
 				} else if strings.Compare(value[0].InitialArgumentValue, value[0].ResolvedArgumentValue) == 0 && strings.Compare(value[1].InitialArgumentValue, value[1].ResolvedArgumentValue) == 0 {
-					unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": at least one of operator's arguments must contain a valid document parameter", key))
+					evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("\"%s\": at least one of operator's arguments must contain a valid document parameter", key))
 				} else {
-					if strings.Compare(value[0].ResolvedArgumentValue, value[1].ResolvedArgumentValue) != 0 {
-						// if arbitrary StringEquals precondition is not satisfied, mark step for skip
-						isAllowed = false
-						unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
+					if !isPreconditionOperatorSatisfied(key, value[0].ResolvedArgumentValue, value[1].ResolvedArgumentValue) {
+						// if arbitrary precondition is not satisfied, mark step for skip
+						evaluation.unsatisfied = append(evaluation.unsatisfied, fmt.Sprintf("\"%s\": [%v, %v]", key, value[0].InitialArgumentValue, value[1].InitialArgumentValue))
 					}
 				}
 			}
 		default:
 			// mark for unrecognizedPrecondition (which is a form of failure)
-			unrecognizedPreconditionList = append(unrecognizedPreconditionList, fmt.Sprintf("unrecognized operator: \"%s\"", key))
+			evaluation.hasUnknownOperator = true
+			evaluation.unrecognized = append(evaluation.unrecognized, fmt.Sprintf("unrecognized operator: \"%s\"", key))
 		}
 	}
 
-	return isAllowed, unrecognizedPreconditionList
+	return evaluation
+}
+
+// getPreconditionVariable returns the platform variable used by one of the precondition arguments and its resolver
+func getPreconditionVariable(arguments []contracts.PreconditionArgument) (variable string, resolve func(log.T) (string, error), found bool) {
+	for _, argument := range arguments {
+		if resolve, found = preconditionVariables[argument.InitialArgumentValue]; found {
+			return argument.InitialArgumentValue, resolve, true
+		}
+	}
+	return "", nil, false
+}
+
+// isPreconditionOperatorSatisfied compares the precondition arguments with the given operator
+func isPreconditionOperatorSatisfied(operator string, first string, second string) bool {
+	switch operator {
+	case "StringEquals":
+		return strings.Compare(first, second) == 0
+	case "StringNotEquals":
+		return strings.Compare(first, second) != 0
+	case "Contains":
+		return strings.Contains(first, second)
+	default:
+		return false
+	}
 }
 
 // Returns the Property's ID field from v1.2 documents or the Name field of a Step in v2.x documents.
@@ -630,6 +1054,76 @@ func getStringPropByName(pluginProperties interface{}, propName string) string {
 	return propValueStr
 }
 
+// getStepWorkingDirectory returns the absolute path set by the workingDirectory input of a step, after checking that it
+// is an existing directory. Relative paths are left to the plugin, which resolves them against the document downloads.
+func getStepWorkingDirectory(pluginProperties interface{}) (string, error) {
+	workingDirectory := getStringPropByName(pluginProperties, workingDirectoryInput)
+	if workingDirectory == "" || !filepath.IsAbs(workingDirectory) {
+		return "", nil
+	}
+	if !fileutil.Exists(workingDirectory) {
+		return "", fmt.Errorf("working directory %v does not exist", workingDirectory)
+	}
+	if !fileutil.IsDirectory(workingDirectory) {
+		return "", fmt.Errorf("working directory %v is not a directory", workingDirectory)
+	}
+	return workingDirectory, nil
+}
+
+// getCompletedStepOutputs returns the standard output of every step that ran successfully, keyed by step name
+func getCompletedStepOutputs(pluginOutputs map[string]*contracts.PluginResult) map[string]string {
+	outputs := make(map[string]string)
+	for pluginID, result := range pluginOutputs {
+		if result.Status == contracts.ResultStatusSuccess {
+			outputs[pluginID] = result.StandardOutput
+		}
+	}
+	return outputs
+}
+
+// getNamedStepOutputs returns the named outputs of every step of the document, keyed by step name.
+// The outputs of the steps which did not run successfully are nil.
+func getNamedStepOutputs(pluginOutputs map[string]*contracts.PluginResult) map[string]map[string]string {
+	outputs := make(map[string]map[string]string)
+	for pluginID, result := range pluginOutputs {
+		outputs[pluginID] = nil
+		if result.Status != contracts.ResultStatusSuccess {
+			continue
+		}
+		stepOutputs := make(map[string]string, len(result.Outputs)+len(result.SecureOutputs))
+		for key, value := range result.Outputs {
+			stepOutputs[key] = value
+		}
+		for key, value := range result.SecureOutputs {
+			stepOutputs[key] = value
+		}
+		outputs[pluginID] = stepOutputs
+	}
+	return outputs
+}
+
+// getSecureStepOutputValues returns the values of the secure named outputs set by the steps which ran successfully
+func getSecureStepOutputValues(pluginOutputs map[string]*contracts.PluginResult) []string {
+	var values []string
+	for _, result := range pluginOutputs {
+		if result.Status != contracts.ResultStatusSuccess {
+			continue
+		}
+		for _, value := range result.SecureOutputs {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// redactOutputs masks the secret values in the named outputs reported by a step
+func redactOutputs(outputs map[string]string, secretValues []string) map[string]string {
+	for key, value := range outputs {
+		outputs[key] = localsecret.Redact(value, secretValues)
+	}
+	return outputs
+}
+
 // This function handles deciding whether the current plugin should be skipped due to a prior plugin with onFailure
 // or onSuccess modifiers. It also handles the finally modifier.
 func getShouldPluginSkipBasedOnControlFlow(
@@ -659,7 +1153,8 @@ func getShouldPluginSkipBasedOnControlFlow(
 		prevPluginId := plugins[prvPluginStateIdx].Id
 		prvPluginResultCode := pluginOutputs[prevPluginId].Code
 		onFailureProp := getStringPropByName(plugins[prvPluginStateIdx].Configuration.Properties, contracts.OnFailureModifier)
-		isFailedStep := pluginOutputs[prevPluginId].Status == contracts.ResultStatusFailed
+		isFailedStep := pluginOutputs[prevPluginId].Status == contracts.ResultStatusFailed ||
+			pluginOutputs[prevPluginId].Status == contracts.ResultStatusTimedOut
 		isFailedAndExitStep := isFailedStep && onFailureProp == contracts.ModifierValueExit
 		onSuccessProp := getStringPropByName(plugins[prvPluginStateIdx].Configuration.Properties, contracts.OnSuccessModifier)
 		isSuccessStep := pluginOutputs[prevPluginId].Status == contracts.ResultStatusSuccess
@@ -673,3 +1168,28 @@ func getShouldPluginSkipBasedOnControlFlow(
 	}
 	return false
 }
+
+// getAbortingFailedStep returns the id of the prior step whose failure stops a document configured with onFailure: abort,
+// or an empty string if the current step may run. Like with the onFailure step modifier, a finally step still runs.
+func getAbortingFailedStep(
+	ioConfig contracts.IOConfiguration,
+	plugins []contracts.PluginState,
+	pluginIndex int,
+	pluginOutputs map[string]*contracts.PluginResult,
+) string {
+	if ioConfig.OnFailure != contracts.ModifierValueAbort {
+		return ""
+	}
+	finallyProp := getStringPropByName(plugins[pluginIndex].Configuration.Properties, contracts.FinallyStepModifier)
+	if finallyProp == contracts.ModifierValueTrue && pluginIndex == len(plugins)-1 {
+		return ""
+	}
+	for prvPluginStateIdx := 0; prvPluginStateIdx < pluginIndex; prvPluginStateIdx++ {
+		prevPluginId := plugins[prvPluginStateIdx].Id
+		if pluginOutputs[prevPluginId].Status == contracts.ResultStatusFailed ||
+			pluginOutputs[prevPluginId].Status == contracts.ResultStatusTimedOut {
+			return prevPluginId
+		}
+	}
+	return ""
+}
@@ -26,12 +26,13 @@ import (
 )
 
 var supportedPlugins = map[string]struct{}{
-	appconfig.PluginNameAwsAgentUpdate:         {},
-	appconfig.PluginNameAwsRunPowerShellScript: {},
-	appconfig.PluginNameAwsRunShellScript:      {},
-	appconfig.PluginNameAwsSoftwareInventory:   {},
-	appconfig.PluginNameRefreshAssociation:     {},
-	appconfig.PluginNameAwsConfigurePackage:    {},
+	appconfig.PluginNameAwsAgentUpdate:            {},
+	appconfig.PluginNameAwsRunPowerShellScript:    {},
+	appconfig.PluginNameAwsRunShellScript:         {},
+	appconfig.PluginNameAwsSoftwareInventory:      {},
+	appconfig.PluginNameRefreshAssociation:        {},
+	appconfig.PluginNameAwsConfigurePackage:       {},
+	appconfig.PluginNameAwsCheckCertificateExpiry: {},
 }
 
 // IsPluginSupportedForCurrentPlatform always returns true for plugins that exist for linux because currently there
@@ -45,6 +46,9 @@ func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown
 	}
 	_, known := allPlugins[pluginName]
 	_, supported := supportedPlugins[pluginName]
+	if !known {
+		return known, supported, agentTooOldMessage(pluginName)
+	}
 
 	return known, supported, fmt.Sprintf("%s v%s", platformName, platformVersion)
 }
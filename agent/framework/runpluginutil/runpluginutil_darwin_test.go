@@ -22,6 +22,7 @@ import (
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/version"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -42,7 +43,8 @@ func TestKnownUnsupported(t *testing.T) {
 */
 
 func TestUnknown(t *testing.T) {
-	isKnown, isSupported, _ := IsPluginSupportedForCurrentPlatform(mockLog, "FOO")
+	isKnown, isSupported, message := IsPluginSupportedForCurrentPlatform(mockLog, "FOO")
 	assert.False(t, isKnown)
 	assert.False(t, isSupported)
+	assert.Equal(t, "plugin FOO is not known to SSM Agent version "+version.Version+", an agent update may be required", message)
 }
@@ -19,15 +19,23 @@ package runpluginutil
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	mocklog "github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/agent/version"
+	identityMocks "github.com/aws/amazon-ssm-agent/common/identity/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -47,7 +55,7 @@ func setIsSupportedMock() {
 	isSupportedPlugin = func(log log.T, pluginName string) (isKnown bool, isSupported bool, message string) {
 		switch pluginName {
 		case testUnknownPlugin:
-			return false, true, ""
+			return false, true, agentTooOldMessage(pluginName)
 		case testUnsupportedPlugin:
 			return true, false, ""
 		default:
@@ -74,7 +82,7 @@ func TestRunPluginsWithNewDocument(t *testing.T) {
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	for index, name := range pluginNames {
 
@@ -165,7 +173,7 @@ func TestRunPluginsWithMissingPluginHandler(t *testing.T) {
 	defaultTime := time.Now()
 	defaultOutput := ""
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	for index, name := range pluginNames {
 
@@ -239,7 +247,7 @@ func TestRunPluginsWithMissingPluginHandler(t *testing.T) {
 
 }
 
-// TODO cancelFlag should not fail subsequent plugins
+// TestRunPluginsWithCancelFlagShutdown tests that a shutdown is left to the plugins and does not cancel subsequent plugins
 func TestRunPluginsWithCancelFlagShutdown(t *testing.T) {
 	setIsSupportedMock()
 	defer restoreIsSupported()
@@ -252,7 +260,7 @@ func TestRunPluginsWithCancelFlagShutdown(t *testing.T) {
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	for index, name := range pluginNames {
 		plugins[name] = new(PluginMock)
@@ -309,6 +317,52 @@ func TestRunPluginsWithCancelFlagShutdown(t *testing.T) {
 	assert.Equal(t, pluginResults[testPlugin2], outputs[testPlugin2])
 }
 
+func TestRunPluginsWithCancelFlagCanceled(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	pluginNames := []string{testPlugin1, testPlugin2, testPlugin0}
+	pluginStates := make([]contracts.PluginState, len(pluginNames))
+	plugins := make(map[string]*PluginMock)
+	pluginRegistry := PluginRegistry{}
+	cancelFlag := task.NewChanneledCancelFlag()
+	ctx := contextmocks.NewMockDefault()
+
+	for index, name := range pluginNames {
+		plugins[name] = new(PluginMock)
+		pluginStates[index] = contracts.PluginState{
+			Name: name,
+			Id:   name,
+			Configuration: contracts.Configuration{
+				PluginID:   name,
+				PluginName: name,
+			},
+		}
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(plugins[name], nil).Maybe()
+		pluginRegistry[name] = pluginFactory
+	}
+	plugins[testPlugin1].On("Execute", mock.Anything, cancelFlag, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(task.CancelFlag).Set(task.Canceled)
+		args.Get(2).(iohandler.IOHandler).MarkAsCancelled()
+	}).Return()
+
+	ch := make(chan contracts.PluginResult, len(pluginNames))
+	outputs := RunPlugins(ctx, pluginStates, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, cancelFlag)
+	close(ch)
+
+	plugins[testPlugin1].AssertExpectations(t)
+	for _, name := range []string{testPlugin2, testPlugin0} {
+		plugins[name].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+		assert.Equal(t, contracts.ResultStatusCancelled, outputs[name].Status)
+		assert.Contains(t, outputs[name].Output, "not run because the document was cancelled")
+	}
+	assert.Equal(t, contracts.ResultStatusCancelled, outputs[testPlugin1].Status)
+	assert.Len(t, ch, len(pluginNames))
+
+	documentStatus, _, _, _ := contracts.DocumentResultAggregator(ctx.Log(), "", outputs)
+	assert.Equal(t, contracts.ResultStatusCancelled, documentStatus)
+}
+
 func TestRunPluginsWithInProgressDocuments(t *testing.T) {
 	setIsSupportedMock()
 	defer restoreIsSupported()
@@ -318,7 +372,7 @@ func TestRunPluginsWithInProgressDocuments(t *testing.T) {
 	plugins := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
 	ioConfig := contracts.IOConfiguration{
-		OrchestrationDirectory: "test",
+		OrchestrationDirectory: t.TempDir(),
 	}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
@@ -428,7 +482,7 @@ func TestRunPluginsWithDuplicatePluginType(t *testing.T) {
 	// create an instance of our test object
 	plugin := new(PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag
 	ctx := contextmocks.NewMockDefault()
@@ -514,7 +568,7 @@ func TestRunPluginsWithCompatiblePlatformPrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -617,7 +671,7 @@ func TestRunPluginsWithCompatiblePlatformPreconditionWithValueFirst(t *testing.T
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -720,7 +774,7 @@ func TestRunPluginsWithIncompatiblePlatformPrecondition(t *testing.T) {
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
 	ioConfig := contracts.IOConfiguration{
-		OrchestrationDirectory: "test",
+		OrchestrationDirectory: t.TempDir(),
 	}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
@@ -776,6 +830,7 @@ func TestRunPluginsWithIncompatiblePlatformPrecondition(t *testing.T) {
 			StandardOutput: defaultOutput,
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusSkipped,
+			SkipReason:     contracts.SkipReasonPreconditionFailed,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -837,7 +892,7 @@ func TestRunPluginsWithFuturePlatformPrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -889,6 +944,7 @@ func TestRunPluginsWithFuturePlatformPrecondition(t *testing.T) {
 			StandardOutput: defaultOutput,
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusSkipped,
+			SkipReason:     contracts.SkipReasonPreconditionFailed,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -939,7 +995,7 @@ func TestRunPluginsWithCompatiblePreconditionButMissingPluginHandler(t *testing.
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -993,6 +1049,7 @@ func TestRunPluginsWithCompatiblePreconditionButMissingPluginHandler(t *testing.
 				StandardOutput: defaultOutput,
 				StandardError:  defaultOutput,
 				Status:         contracts.ResultStatusSkipped,
+				SkipReason:     contracts.SkipReasonUnsupportedPlugin,
 			}
 		} else {
 			pluginResults[name] = &contracts.PluginResult{
@@ -1042,7 +1099,7 @@ func TestRunPluginsWithCompatiblePreconditionButMissingPluginHandler(t *testing.
 	assert.Equal(t, pluginResults, outputs)
 }
 
-// Crossplatform document with more than 1 precondition, steps must fail
+// Crossplatform document with an unknown precondition operator next to a known one, steps must fail
 func TestRunPluginsWithMoreThanOnePrecondition(t *testing.T) {
 	setIsSupportedMock()
 	defer restoreIsSupported()
@@ -1052,7 +1109,7 @@ func TestRunPluginsWithMoreThanOnePrecondition(t *testing.T) {
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
 	ioConfig := contracts.IOConfiguration{
-		OrchestrationDirectory: "test",
+		OrchestrationDirectory: t.TempDir(),
 	}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
@@ -1182,7 +1239,7 @@ func TestRunPluginsWithUnrecognizedPreconditionOperator(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1292,7 +1349,7 @@ func TestRunPluginsWithUnrecognizedPreconditionDuplicateVariable(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1403,7 +1460,7 @@ func TestRunPluginsWithUnrecognizedPreconditionDuplicateParameter(t *testing.T)
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1514,7 +1571,7 @@ func TestRunPluginsWithUnrecognizedPreconditionDuplicateConstant(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1625,7 +1682,7 @@ func TestRunPluginsWithUnrecognizedPreconditionSSMParameter(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1736,7 +1793,7 @@ func TestRunPluginsWithUnrecognizedPreconditionSecureSSMParameter(t *testing.T)
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1847,7 +1904,7 @@ func TestRunPluginsWithUnrecognizedPreconditionNoDocumentParameters(t *testing.T
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -1958,7 +2015,7 @@ func TestRunPluginsWithUnrecognizedPreconditionUnrecognizedParameter(t *testing.
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2069,7 +2126,7 @@ func TestRunPluginsPlatformPreconditionWithDocumentParameters(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2180,7 +2237,7 @@ func TestRunPluginsWithCompatibleParamParamPrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2284,7 +2341,7 @@ func TestRunPluginsWithIncompatibleParamParamPrecondition(t *testing.T) {
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
 	ioConfig := contracts.IOConfiguration{
-		OrchestrationDirectory: "test",
+		OrchestrationDirectory: t.TempDir(),
 	}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
@@ -2339,6 +2396,7 @@ func TestRunPluginsWithIncompatibleParamParamPrecondition(t *testing.T) {
 			StandardOutput: defaultOutput,
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusSkipped,
+			SkipReason:     contracts.SkipReasonPreconditionFailed,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -2397,7 +2455,7 @@ func TestRunPluginsWithCompatibleParamValuePrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2500,7 +2558,7 @@ func TestRunPluginsWithCompatibleValueParamPrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2603,7 +2661,7 @@ func TestRunPluginsWithIncompatibleParamValuePrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2655,6 +2713,7 @@ func TestRunPluginsWithIncompatibleParamValuePrecondition(t *testing.T) {
 			StandardOutput: defaultOutput,
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusSkipped,
+			SkipReason:     contracts.SkipReasonPreconditionFailed,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -2706,7 +2765,7 @@ func TestRunPluginsWithCompatibleMixedPrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2809,7 +2868,7 @@ func TestRunPluginsWithIncompatibleMixedPrecondition(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -2861,6 +2920,7 @@ func TestRunPluginsWithIncompatibleMixedPrecondition(t *testing.T) {
 			StandardOutput: defaultOutput,
 			StandardError:  defaultOutput,
 			Status:         contracts.ResultStatusSkipped,
+			SkipReason:     contracts.SkipReasonPreconditionFailed,
 		}
 		pluginFactory := new(PluginFactoryMock)
 		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
@@ -2911,7 +2971,7 @@ func TestRunPluginsWithMoreThanTwoPreconditionOperands(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -3025,7 +3085,7 @@ func TestRunPluginsWithUnknownPlugin(t *testing.T) {
 	pluginResults := make(map[string]*contracts.PluginResult)
 	pluginInstances := make(map[string]*PluginMock)
 	pluginRegistry := PluginRegistry{}
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	var cancelFlag task.CancelFlag = task.NewChanneledCancelFlag()
 
@@ -3073,8 +3133,9 @@ func TestRunPluginsWithUnknownPlugin(t *testing.T) {
 
 		if name == testUnknownPlugin {
 			pluginError := fmt.Sprintf(
-				"Plugin with name %s is not supported by this version of ssm agent, please update to latest version. Step name: %s",
+				"Step execution failed because plugin %s is not known to SSM Agent version %s, an agent update may be required. Step name: %s",
 				name,
+				version.Version,
 				name)
 
 			pluginResults[name] = &contracts.PluginResult{
@@ -3086,6 +3147,7 @@ func TestRunPluginsWithUnknownPlugin(t *testing.T) {
 				StandardError:  defaultOutput,
 				Status:         contracts.ResultStatusFailed,
 				Error:          pluginError,
+				SkipReason:     contracts.SkipReasonAgentTooOld,
 			}
 		} else {
 			pluginResults[name] = &contracts.PluginResult{
@@ -3149,7 +3211,7 @@ func TestRunPluginSuccessWithNonTruncatedResult(t *testing.T) {
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	for index, name := range pluginNames {
 
@@ -3225,7 +3287,7 @@ func TestRunPluginSetsUpstreamServiceNameInEachPlugin(t *testing.T) {
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 
 	for index, name := range pluginNames {
 
@@ -3275,6 +3337,37 @@ func TestRunPluginSetsUpstreamServiceNameInEachPlugin(t *testing.T) {
 	}
 }
 
+func TestRunPluginsReportsResourceUsageAndProcessID(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	usage := contracts.ResourceUsage{PeakMemoryBytes: 4096, UserCPUTimeMillis: 20, SystemCPUTimeMillis: 10}
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.AddResourceUsage(usage)
+		output.SetProcessID(4242)
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	plugins := []contracts.PluginState{{
+		Name:          testPlugin1,
+		Id:            testPlugin1,
+		Configuration: contracts.Configuration{PluginID: testPlugin1, PluginName: testPlugin1},
+	}}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, &usage, outputs[testPlugin1].ResourceUsage)
+	assert.Equal(t, 4242, outputs[testPlugin1].ProcessID)
+	result := <-ch
+	assert.Equal(t, &usage, result.ResourceUsage)
+	assert.Equal(t, 4242, result.ProcessID)
+}
+
 func TestGetStepNameV1Documents(t *testing.T) {
 	inputPluginName := "testPluginName1"
 	testProperties := make(map[string]string)
@@ -3457,7 +3550,7 @@ func TestRunPluginWithOnFailureProperty168(t *testing.T) {
 	var cancelFlag task.CancelFlag
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
 
 	for index, name := range pluginNames {
@@ -3553,7 +3646,7 @@ func TestRunPluginWithOnFailureProperty169(t *testing.T) {
 	var cancelFlag task.CancelFlag
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
 
 	for index, name := range pluginNames {
@@ -3649,7 +3742,7 @@ func TestRunPluginWithOnFailureProperty1(t *testing.T) {
 	var cancelFlag task.CancelFlag
 	ctx := contextmocks.NewMockDefault()
 	defaultTime := time.Now()
-	ioConfig := contracts.IOConfiguration{}
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
 	pluginConfigs2 := make([]contracts.PluginState, len(pluginNames))
 
 	for index, name := range pluginNames {
@@ -3737,3 +3830,1470 @@ func TestRunPluginWithOnFailureProperty1(t *testing.T) {
 	ctx.AssertCalled(t, "Log")
 	assert.Equal(t, pluginResults[testPlugin1], outputs[testPlugin1])
 }
+
+// newTimeoutTestPlugins creates a blocking first step with a timeout followed by a second step
+func newTimeoutTestPlugins(firstStepProperties interface{}) ([]contracts.PluginState, PluginRegistry, map[string]*PluginMock) {
+	pluginNames := []string{testPlugin1, testPlugin2}
+	plugins := make([]contracts.PluginState, len(pluginNames))
+	pluginInstances := make(map[string]*PluginMock)
+	pluginRegistry := PluginRegistry{}
+
+	for index, name := range pluginNames {
+		pluginInstances[name] = new(PluginMock)
+		config := contracts.Configuration{
+			PluginID:            name,
+			PluginName:          name,
+			UpstreamServiceName: contracts.MessageGatewayService,
+		}
+		pluginFactory := new(PluginFactoryMock)
+		if index == 0 {
+			config.TimeoutSeconds = 1
+			config.Properties = firstStepProperties
+			pluginInstances[name].On("Execute", config, mock.Anything, mock.Anything).Return()
+			// the first step blocks until its cancel flag is set, the cancel flag is not passed to the mock
+			// because the mock reads its arguments while the step timeout sets it
+			pluginMock := pluginInstances[name]
+			pluginFactory.On("Create", mock.Anything).Return(pluginFunc(func(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+				pluginMock.Execute(config, nil, output)
+				cancelFlag.Wait()
+			}), nil)
+		} else {
+			pluginInstances[name].On("Execute", config, mock.Anything, mock.Anything).Return()
+			pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
+		}
+		pluginRegistry[name] = pluginFactory
+
+		plugins[index] = contracts.PluginState{
+			Name:          name,
+			Id:            name,
+			Configuration: config,
+		}
+	}
+	return plugins, pluginRegistry, pluginInstances
+}
+
+func TestRunPluginsWithStepTimeout(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	cancelFlag := task.NewChanneledCancelFlag()
+	plugins, pluginRegistry, pluginInstances := newTimeoutTestPlugins(nil)
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, cancelFlag)
+	close(ch)
+
+	for _, mockPlugin := range pluginInstances {
+		mockPlugin.AssertExpectations(t)
+	}
+	// the document cancel flag must not be affected by the step timeout
+	assert.False(t, cancelFlag.Canceled())
+	assert.Equal(t, contracts.ResultStatusTimedOut, outputs[testPlugin1].Status)
+	assert.Equal(t, appconfig.CommandStoppedPreemptivelyExitCode, outputs[testPlugin1].Code)
+	assert.Contains(t, outputs[testPlugin1].StandardError, "Step timed out after 1 seconds")
+	assert.NotEqual(t, contracts.ResultStatusTimedOut, outputs[testPlugin2].Status)
+
+	documentStatus, _, _, _ := contracts.DocumentResultAggregator(ctx.Log(), "", outputs)
+	assert.Equal(t, contracts.ResultStatusTimedOut, documentStatus)
+}
+
+func TestRunPluginsWithStepTimeoutAndOnFailureExit(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	cancelFlag := task.NewChanneledCancelFlag()
+	plugins, pluginRegistry, pluginInstances := newTimeoutTestPlugins(map[string]interface{}{
+		contracts.OnFailureModifier: contracts.ModifierValueExit,
+	})
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, cancelFlag)
+	close(ch)
+
+	pluginInstances[testPlugin1].AssertExpectations(t)
+	pluginInstances[testPlugin2].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusTimedOut, outputs[testPlugin1].Status)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin2].Status)
+	assert.Equal(t, contracts.SkipReasonPriorStepExit, outputs[testPlugin2].SkipReason)
+}
+
+func TestRunPluginsAbandonsStepIgnoringItsTimeout(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	defer func(gracePeriod time.Duration) { stepTimeoutGracePeriod = gracePeriod }(stepTimeoutGracePeriod)
+	stepTimeoutGracePeriod = 100 * time.Millisecond
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, _ := newTimeoutTestPlugins(nil)
+	unblock := make(chan struct{})
+	stopped := make(chan struct{})
+	// the first step ignores its cancel flag and keeps writing its output
+	ignoringFactory := new(PluginFactoryMock)
+	ignoringFactory.On("Create", mock.Anything).Return(pluginFunc(func(_ contracts.Configuration, _ task.CancelFlag, output iohandler.IOHandler) {
+		defer close(stopped)
+		for {
+			select {
+			case <-unblock:
+				return
+			case <-time.After(time.Millisecond):
+				output.AppendInfo("still running")
+				output.GetStdoutWriter().WriteString("still running\n")
+			}
+		}
+	}), nil)
+	pluginRegistry[testPlugin1] = ignoringFactory
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	start := time.Now()
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	// the abandoned step writes after its output is closed
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	<-stopped
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, contracts.ResultStatusTimedOut, outputs[testPlugin1].Status)
+	assert.Contains(t, outputs[testPlugin1].StandardError, "was abandoned")
+}
+
+func TestRunPluginsKillsProcessOfStepIgnoringItsTimeout(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	defer func(gracePeriod time.Duration) { stepTimeoutGracePeriod = gracePeriod }(stepTimeoutGracePeriod)
+	stepTimeoutGracePeriod = 100 * time.Millisecond
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, _ := newTimeoutTestPlugins(nil)
+	var exitCode int
+	// the first step runs a process without forwarding its cancel flag to the executer
+	ignoringFactory := new(PluginFactoryMock)
+	ignoringFactory.On("Create", mock.Anything).Return(pluginFunc(func(_ contracts.Configuration, _ task.CancelFlag, output iohandler.IOHandler) {
+		executer := executers.WithProcessIDRecorder(executers.ShellCommandExecuter{}, output)
+		exitCode, _ = executer.NewExecute(contextmocks.NewMockDefault(), "", output.GetStdoutWriter(), output.GetStderrWriter(),
+			task.NewChanneledCancelFlag(), 60, "sleep", []string{"60"}, nil)
+	}), nil)
+	pluginRegistry[testPlugin1] = ignoringFactory
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	start := time.Now()
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.NotEqual(t, 0, exitCode)
+	assert.Equal(t, contracts.ResultStatusTimedOut, outputs[testPlugin1].Status)
+	assert.NotContains(t, outputs[testPlugin1].StandardError, "was abandoned")
+	assert.NotZero(t, outputs[testPlugin1].ProcessID)
+}
+
+func TestExecutePluginWithTimeoutRaisesPluginPanicAfterTimeout(t *testing.T) {
+	plugin := pluginFunc(func(_ contracts.Configuration, stepCancelFlag task.CancelFlag, _ iohandler.IOHandler) {
+		stepCancelFlag.Wait()
+		panic("plugin panic")
+	})
+	config := contracts.Configuration{TimeoutSeconds: 1}
+
+	assert.PanicsWithValue(t, "plugin panic", func() {
+		executePluginWithTimeout(plugin, config, task.NewChanneledCancelFlag(), iohandler.NewDefaultIOHandler(contextmocks.NewMockDefault(), contracts.IOConfiguration{}))
+	})
+}
+
+// pluginFunc runs a function as a plugin, unlike PluginMock it does not inspect the cancel flag it is given
+type pluginFunc func(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler)
+
+func (f pluginFunc) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	f(config, cancelFlag, output)
+}
+
+func TestExecutePluginWithTimeoutForwardsDocumentCancel(t *testing.T) {
+	defer func(interval time.Duration) { documentCancelPollInterval = interval }(documentCancelPollInterval)
+	documentCancelPollInterval = 10 * time.Millisecond
+	cancelFlag := task.NewChanneledCancelFlag()
+	var stepState task.State
+	plugin := pluginFunc(func(_ contracts.Configuration, stepCancelFlag task.CancelFlag, _ iohandler.IOHandler) {
+		stepState = stepCancelFlag.Wait()
+	})
+	config := contracts.Configuration{TimeoutSeconds: 60}
+
+	go cancelFlag.Set(task.Canceled)
+	timedOut := executePluginWithTimeout(plugin, config, cancelFlag, iohandler.NewDefaultIOHandler(contextmocks.NewMockDefault(), contracts.IOConfiguration{}))
+
+	assert.False(t, timedOut)
+	assert.Equal(t, task.Canceled, stepState)
+}
+
+func TestExecutePluginWithTimeoutRaisesPluginPanic(t *testing.T) {
+	plugin := pluginFunc(func(contracts.Configuration, task.CancelFlag, iohandler.IOHandler) {
+		panic("plugin panic")
+	})
+	config := contracts.Configuration{TimeoutSeconds: 60}
+
+	assert.PanicsWithValue(t, "plugin panic", func() {
+		executePluginWithTimeout(plugin, config, task.NewChanneledCancelFlag(), iohandler.NewDefaultIOHandler(contextmocks.NewMockDefault(), contracts.IOConfiguration{}))
+	})
+}
+
+func TestRunPluginsWithMissingLocalSecret(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	pluginInstance := new(PluginMock)
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+	plugins := []contracts.PluginState{
+		{
+			Name: testPlugin1,
+			Id:   testPlugin1,
+			Configuration: contracts.Configuration{
+				PluginID:   testPlugin1,
+				PluginName: testPlugin1,
+				Properties: map[string]interface{}{"commands": "login {{ localsecret:missing }}"},
+			},
+		},
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstance.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin1].Status)
+	assert.Contains(t, outputs[testPlugin1].Error, "failed to resolve local secrets")
+	assert.Equal(t, "login {{ localsecret:missing }}", plugins[0].Configuration.Properties.(map[string]interface{})["commands"])
+}
+
+func TestRunPluginsRedactsDecodedParameterValues(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	orchestrationDir := t.TempDir()
+	var executedProperties interface{}
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		executedProperties = args.Get(0).(contracts.Configuration).Properties
+		output := args.Get(2).(iohandler.IOHandler)
+		output.AppendInfo("password is decoded-password")
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+	plugins := []contracts.PluginState{
+		{
+			Name: testPlugin1,
+			Id:   testPlugin1,
+			Configuration: contracts.Configuration{
+				PluginID:   testPlugin1,
+				PluginName: testPlugin1,
+				// "decoded-password" encoded in base64
+				Properties: map[string]interface{}{"commands": "login {{ base64decode=ZGVjb2RlZC1wYXNzd29yZA== }}"},
+			},
+		},
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	assert.Equal(t, map[string]interface{}{"commands": "login decoded-password"}, executedProperties)
+	assert.Equal(t, "password is ****", outputs[testPlugin1].StandardOutput)
+	assert.NotContains(t, outputs[testPlugin1].Output, "decoded-password")
+	// the output files uploaded to S3 and CloudWatch are masked as well
+	stdout, err := os.ReadFile(filepath.Join(orchestrationDir, testPlugin1, "stdout"))
+	assert.NoError(t, err)
+	assert.Equal(t, "password is ****", string(stdout))
+}
+
+func TestRunPluginsWithMalformedDecodedParameter(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	pluginInstance := new(PluginMock)
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+	plugins := []contracts.PluginState{
+		{
+			Name: testPlugin1,
+			Id:   testPlugin1,
+			Configuration: contracts.Configuration{
+				PluginID:   testPlugin1,
+				PluginName: testPlugin1,
+				Properties: map[string]interface{}{"commands": "login {{ base64decode=Z }}"},
+			},
+		},
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstance.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin1].Status)
+	assert.Contains(t, outputs[testPlugin1].Error, "failed to decode parameters")
+}
+
+// newAbortOnFailureTestPlugins creates three steps where the first one fails, the last one may be a finally step
+func newAbortOnFailureTestPlugins(lastStepProperties interface{}) ([]contracts.PluginState, PluginRegistry, map[string]*PluginMock) {
+	pluginNames := []string{testPlugin0, testPlugin1, testPlugin2}
+	plugins := make([]contracts.PluginState, len(pluginNames))
+	pluginInstances := make(map[string]*PluginMock)
+	pluginRegistry := PluginRegistry{}
+
+	for index, name := range pluginNames {
+		pluginInstances[name] = new(PluginMock)
+		config := contracts.Configuration{PluginID: name, PluginName: name}
+		if index == len(pluginNames)-1 {
+			config.Properties = lastStepProperties
+		}
+		failed := index == 0
+		pluginInstances[name].On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			output := args.Get(2).(iohandler.IOHandler)
+			if failed {
+				output.MarkAsFailed(fmt.Errorf("step failed"))
+			} else {
+				output.MarkAsSucceeded()
+			}
+		}).Return()
+
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
+		pluginRegistry[name] = pluginFactory
+
+		plugins[index] = contracts.PluginState{
+			Name:          name,
+			Id:            name,
+			Configuration: config,
+		}
+	}
+	return plugins, pluginRegistry, pluginInstances
+}
+
+func TestRunPluginsWithOnFailureAbort(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newAbortOnFailureTestPlugins(nil)
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir(), OnFailure: contracts.ModifierValueAbort}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstances[testPlugin0].AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin0].Status)
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		pluginInstances[name].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+		assert.Equal(t, contracts.ResultStatusSkipped, outputs[name].Status)
+		assert.Equal(t, contracts.SkipReasonPriorStepFailed, outputs[name].SkipReason)
+		assert.Contains(t, outputs[name].Output, "step "+testPlugin0+" failed")
+	}
+}
+
+func TestRunPluginsWithOnFailureAbortRunsFinallyStep(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newAbortOnFailureTestPlugins(map[string]interface{}{
+		contracts.FinallyStepModifier: contracts.ModifierValueTrue,
+	})
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir(), OnFailure: contracts.ModifierValueAbort}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin1].Status)
+	pluginInstances[testPlugin2].AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin2].Status)
+}
+
+func TestRunPluginsWithoutOnFailureContinuesAfterFailedStep(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newAbortOnFailureTestPlugins(nil)
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	for _, mockPlugin := range pluginInstances {
+		mockPlugin.AssertExpectations(t)
+	}
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin0].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin2].Status)
+}
+
+// newStepOutputTestPlugins creates two sub-document steps where the second one references the output of the first
+func newStepOutputTestPlugins(firstStepOutput string, firstStepFails bool) ([]contracts.PluginState, PluginRegistry, map[string]*PluginMock) {
+	pluginInstances := map[string]*PluginMock{testPlugin1: new(PluginMock), testPlugin2: new(PluginMock)}
+	pluginInstances[testPlugin1].On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.AppendInfo(firstStepOutput)
+		if firstStepFails {
+			output.MarkAsFailed(fmt.Errorf("step failed"))
+		} else {
+			output.MarkAsSucceeded()
+		}
+	}).Return()
+	pluginRegistry := PluginRegistry{}
+	properties := map[string]interface{}{
+		testPlugin1: map[string]interface{}{"commands": "hostname"},
+		testPlugin2: map[string]interface{}{"commands": []interface{}{"ping {{ " + testPlugin1 + ".output }}"}},
+	}
+	plugins := make([]contracts.PluginState, 0, len(properties))
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
+		pluginRegistry[name] = pluginFactory
+		plugins = append(plugins, contracts.PluginState{
+			Name: name,
+			Id:   name,
+			Configuration: contracts.Configuration{
+				PluginID:                    name,
+				PluginName:                  name,
+				Properties:                  properties[name],
+				ResolveStepOutputReferences: true,
+			},
+		})
+	}
+	return plugins, pluginRegistry, pluginInstances
+}
+
+func TestRunPluginsResolvesStepOutputReferences(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newStepOutputTestPlugins("host-1\n", false)
+	pluginInstances[testPlugin2].On("Execute", mock.MatchedBy(func(config contracts.Configuration) bool {
+		return assert.ObjectsAreEqual(map[string]interface{}{"commands": []interface{}{"ping host-1"}}, config.Properties)
+	}), mock.Anything, mock.Anything).Return()
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	for _, mockPlugin := range pluginInstances {
+		mockPlugin.AssertExpectations(t)
+	}
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	assert.Empty(t, outputs[testPlugin2].Error)
+}
+
+func TestRunPluginsWithStepOutputReferenceToFailedStep(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newStepOutputTestPlugins("host-1", true)
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstances[testPlugin2].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin2].Status)
+	assert.Contains(t, outputs[testPlugin2].Error, "failed to resolve step output references")
+}
+
+// newNamedOutputTestPlugins creates two steps where the second one references the named outputs set by the first
+func newNamedOutputTestPlugins(firstStepOutput string, secondStepCommand string) ([]contracts.PluginState, PluginRegistry, map[string]*PluginMock) {
+	pluginInstances := map[string]*PluginMock{testPlugin1: new(PluginMock), testPlugin2: new(PluginMock)}
+	pluginInstances[testPlugin1].On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.AppendInfo(firstStepOutput)
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginRegistry := PluginRegistry{}
+	properties := map[string]interface{}{
+		testPlugin1: map[string]interface{}{"commands": "hostname"},
+		testPlugin2: map[string]interface{}{"commands": []interface{}{secondStepCommand}},
+	}
+	plugins := make([]contracts.PluginState, 0, len(properties))
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
+		pluginRegistry[name] = pluginFactory
+		plugins = append(plugins, contracts.PluginState{
+			Name: name,
+			Id:   name,
+			Configuration: contracts.Configuration{
+				PluginID:   name,
+				PluginName: name,
+				Properties: properties[name],
+			},
+		})
+	}
+	return plugins, pluginRegistry, pluginInstances
+}
+
+func TestRunPluginsResolvesNamedOutputReferences(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newNamedOutputTestPlugins(
+		"resolving host\n::set-output name=host::host-1\n::set-secure-output name=token::s3cr3t\n",
+		"ping {{ "+testPlugin1+".outputs.host }} --token {{ "+testPlugin1+".outputs.token }}")
+	pluginInstances[testPlugin2].On("Execute", mock.MatchedBy(func(config contracts.Configuration) bool {
+		return assert.ObjectsAreEqual(map[string]interface{}{"commands": []interface{}{"ping host-1 --token s3cr3t"}}, config.Properties)
+	}), mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.AppendInfo("token is s3cr3t")
+		output.MarkAsSucceeded()
+	}).Return()
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	for _, mockPlugin := range pluginInstances {
+		mockPlugin.AssertExpectations(t)
+	}
+	first := outputs[testPlugin1]
+	assert.Equal(t, map[string]string{"host": "host-1"}, first.Outputs)
+	assert.Equal(t, map[string]string{"token": "s3cr3t"}, first.SecureOutputs)
+	assert.NotContains(t, first.StandardOutput, "::set-")
+	assert.NotContains(t, first.StandardOutput, "s3cr3t")
+	// the secure output is masked in the output of the step referencing it
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin2].Status)
+	assert.NotContains(t, outputs[testPlugin2].StandardOutput, "s3cr3t")
+	for result := range ch {
+		assert.NotContains(t, fmt.Sprint(result.Output), "s3cr3t")
+	}
+}
+
+func TestRunPluginsRemovesOutputDirectivesFromOutputFiles(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	config := appconfig.DefaultConfig()
+	config.Ssm.PluginOutputMaxSizeBytes = appconfig.DefaultPluginOutputMaxSizeBytesMin
+	ctx := contextmocks.NewMockDefaultWithConfig(config)
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
+	// the directives are printed after the captured output limit
+	stdout := strings.Repeat("resolving host\n", 500) + "::set-output name=host::host-1\n::set-secure-output name=token::s3cr3t\n"
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.GetStdoutWriter().WriteString(stdout)
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	plugins := []contracts.PluginState{{
+		Name:          testPlugin1,
+		Id:            testPlugin1,
+		Configuration: contracts.Configuration{PluginID: testPlugin1, PluginName: testPlugin1},
+	}}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	result := outputs[testPlugin1]
+	assert.Equal(t, map[string]string{"host": "host-1"}, result.Outputs)
+	assert.Equal(t, map[string]string{"token": "s3cr3t"}, result.SecureOutputs)
+	stdoutFile, err := os.ReadFile(filepath.Join(ioConfig.OrchestrationDirectory, testPlugin1, "stdout"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("resolving host\n", 500), string(stdoutFile))
+}
+
+func TestRunPluginsWithMissingNamedOutput(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstances := newNamedOutputTestPlugins("host-1\n", "ping {{ "+testPlugin1+".outputs.host }}")
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstances[testPlugin2].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin2].Status)
+	assert.Contains(t, outputs[testPlugin2].Error, "has no output host")
+}
+
+// runPluginWithSuccessCriteria runs a step that prints stepOutput and exits with 0 under the given success criteria
+func runPluginWithSuccessCriteria(t *testing.T, stepOutput string, criteria contracts.SuccessCriteria) *contracts.PluginResult {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.AppendInfo(stepOutput)
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	plugins := []contracts.PluginState{{
+		Name: testPlugin1,
+		Id:   testPlugin1,
+		Configuration: contracts.Configuration{
+			PluginID:        testPlugin1,
+			PluginName:      testPlugin1,
+			SuccessCriteria: criteria,
+		},
+	}}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstance.AssertExpectations(t)
+	return outputs[testPlugin1]
+}
+
+func TestRunPluginsWithOutputMatchingFailurePattern(t *testing.T) {
+	result := runPluginWithSuccessCriteria(t, "Installing...\nERROR: disk full", contracts.SuccessCriteria{FailurePattern: "ERROR: .*"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Equal(t, 1, result.Code)
+	assert.Contains(t, result.StandardError, `Step output matched the failure pattern "ERROR: .*": "ERROR: disk full"`)
+	assert.Contains(t, result.Output, "ERROR: disk full")
+}
+
+func TestRunPluginsWithOutputMissingSuccessPattern(t *testing.T) {
+	result := runPluginWithSuccessCriteria(t, "Installing...", contracts.SuccessCriteria{SuccessPattern: "Installed \\d+ packages"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Contains(t, result.StandardError, "did not match the success pattern")
+}
+
+func TestRunPluginsWithOutputMeetingSuccessCriteria(t *testing.T) {
+	result := runPluginWithSuccessCriteria(t, "Installed 3 packages", contracts.SuccessCriteria{FailurePattern: "ERROR", SuccessPattern: "Installed \\d+ packages"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+	assert.Equal(t, 0, result.Code)
+	assert.Empty(t, result.StandardError)
+}
+
+func TestRunPluginsMatchesSuccessCriteriaPastCapturedOutputLimit(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	config := appconfig.DefaultConfig()
+	config.Ssm.PluginOutputMaxSizeBytes = appconfig.DefaultPluginOutputMaxSizeBytesMin
+	ctx := contextmocks.NewMockDefaultWithConfig(config)
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		output.GetStdoutWriter().WriteString(strings.Repeat("Installing...\n", 500) + "ERROR: disk full\n")
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	plugins := []contracts.PluginState{{
+		Name: testPlugin1,
+		Id:   testPlugin1,
+		Configuration: contracts.Configuration{
+			PluginID:        testPlugin1,
+			PluginName:      testPlugin1,
+			SuccessCriteria: contracts.SuccessCriteria{FailurePattern: "ERROR: .*"},
+		},
+	}}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	result := outputs[testPlugin1]
+	assert.NotContains(t, result.StandardOutput, "ERROR: disk full")
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Contains(t, result.StandardError, `Step output matched the failure pattern "ERROR: .*": "ERROR: disk full"`)
+}
+
+func TestRunPluginsWithInvalidSuccessCriteria(t *testing.T) {
+	result := runPluginWithSuccessCriteria(t, "done", contracts.SuccessCriteria{FailurePattern: "("})
+
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Contains(t, result.StandardError, "Invalid success criteria")
+}
+
+// runPluginsWithPreconditions runs two steps sharing the given preconditions and returns their results
+func runPluginsWithPreconditions(t *testing.T, preconditions map[string][]contracts.PreconditionArgument, expectExecution bool) map[string]*contracts.PluginResult {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	pluginNames := []string{testPlugin1, testPlugin2}
+	plugins := make([]contracts.PluginState, len(pluginNames))
+	pluginInstances := make(map[string]*PluginMock)
+	pluginRegistry := PluginRegistry{}
+	cancelFlag := task.NewChanneledCancelFlag()
+	ctx := contextmocks.NewMockDefault()
+
+	for index, name := range pluginNames {
+		pluginInstances[name] = new(PluginMock)
+		config := contracts.Configuration{
+			PluginID:              name,
+			PluginName:            name,
+			IsPreconditionEnabled: true,
+			Preconditions:         preconditions,
+			UpstreamServiceName:   contracts.MessageGatewayService,
+		}
+		if expectExecution {
+			pluginInstances[name].On("Execute", config, mock.Anything, mock.Anything).Return()
+		}
+
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
+		pluginRegistry[name] = pluginFactory
+		plugins[index] = contracts.PluginState{
+			Name:          name,
+			Id:            name,
+			Configuration: config,
+		}
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, cancelFlag)
+	close(ch)
+
+	for _, mockPlugin := range pluginInstances {
+		if expectExecution {
+			mockPlugin.AssertExpectations(t)
+		} else {
+			mockPlugin.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+		}
+	}
+	return outputs
+}
+
+func newPreconditionArguments(arguments ...string) []contracts.PreconditionArgument {
+	var preconditionArguments []contracts.PreconditionArgument
+	for i := 0; i+1 < len(arguments); i += 2 {
+		preconditionArguments = append(preconditionArguments, contracts.PreconditionArgument{
+			InitialArgumentValue:  arguments[i],
+			ResolvedArgumentValue: arguments[i+1],
+		})
+	}
+	return preconditionArguments
+}
+
+// Precondition = "StringNotEquals": ["platformType", "Windows"]
+func TestRunPluginsWithCompatibleStringNotEqualsPlatformPrecondition(t *testing.T) {
+	outputs := runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"StringNotEquals": newPreconditionArguments("platformType", "platformType", "Windows", "Windows"),
+	}, true)
+
+	assert.Equal(t, contracts.ResultStatus(""), outputs[testPlugin1].Status)
+	assert.Equal(t, contracts.ResultStatus(""), outputs[testPlugin2].Status)
+}
+
+// Precondition = "StringNotEquals": ["Linux", "platformType"]
+func TestRunPluginsWithIncompatibleStringNotEqualsPlatformPrecondition(t *testing.T) {
+	outputs := runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"StringNotEquals": newPreconditionArguments("Linux", "Linux", "platformType", "platformType"),
+	}, false)
+
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		assert.Equal(t, contracts.ResultStatusSkipped, outputs[name].Status)
+		assert.Equal(t, contracts.SkipReasonPreconditionFailed, outputs[name].SkipReason)
+		assert.Equal(t, "Step execution skipped due to unsatisfied preconditions: '\"StringNotEquals\": [Linux, platformType]'. Step name: "+name, outputs[name].Output)
+	}
+}
+
+// Precondition = "Contains": ["platformType", "Lin"]
+func TestRunPluginsWithCompatibleContainsPlatformPrecondition(t *testing.T) {
+	outputs := runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"Contains": newPreconditionArguments("platformType", "platformType", "Lin", "Lin"),
+	}, true)
+
+	assert.Equal(t, contracts.ResultStatus(""), outputs[testPlugin1].Status)
+	assert.Equal(t, contracts.ResultStatus(""), outputs[testPlugin2].Status)
+}
+
+// Precondition = "Contains": ["platformType", "Win"]
+func TestRunPluginsWithIncompatibleContainsPlatformPrecondition(t *testing.T) {
+	outputs := runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"Contains": newPreconditionArguments("platformType", "platformType", "Win", "Win"),
+	}, false)
+
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		assert.Equal(t, contracts.ResultStatusSkipped, outputs[name].Status)
+		assert.Equal(t, contracts.SkipReasonPreconditionFailed, outputs[name].SkipReason)
+	}
+}
+
+// Precondition = "StringNotEquals": ["{{ param1 }}", "{{ param2 }}"]
+func TestRunPluginsWithStringNotEqualsParamParamPrecondition(t *testing.T) {
+	outputs := runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"StringNotEquals": newPreconditionArguments("{{ param1 }}", "foo", "{{ param2 }}", "bar"),
+	}, true)
+	assert.Equal(t, contracts.ResultStatus(""), outputs[testPlugin1].Status)
+
+	outputs = runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"StringNotEquals": newPreconditionArguments("{{ param1 }}", "foo", "{{ param2 }}", "foo"),
+	}, false)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin1].Status)
+	assert.Equal(t, contracts.SkipReasonPreconditionFailed, outputs[testPlugin1].SkipReason)
+}
+
+// Precondition = "Contains": ["{{ param1 }}", "value"]
+func TestRunPluginsWithContainsParamValuePrecondition(t *testing.T) {
+	outputs := runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"Contains": newPreconditionArguments("{{ param1 }}", "production-eu", "production", "production"),
+	}, true)
+	assert.Equal(t, contracts.ResultStatus(""), outputs[testPlugin1].Status)
+
+	outputs = runPluginsWithPreconditions(t, map[string][]contracts.PreconditionArgument{
+		"Contains": newPreconditionArguments("{{ param1 }}", "staging-eu", "production", "production"),
+	}, false)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin1].Status)
+	assert.Equal(t, contracts.SkipReasonPreconditionFailed, outputs[testPlugin1].SkipReason)
+}
+
+func TestRunPluginsWithInvalidStringNotEqualsAndContainsArguments(t *testing.T) {
+	testCases := map[string]struct {
+		preconditions map[string][]contracts.PreconditionArgument
+		expectedError string
+	}{
+		"identical arguments": {
+			preconditions: map[string][]contracts.PreconditionArgument{
+				"StringNotEquals": newPreconditionArguments("{{ param1 }}", "foo", "{{ param1 }}", "foo"),
+			},
+			expectedError: "\"StringNotEquals\": operator's arguments can't be identical",
+		},
+		"ssm parameter": {
+			preconditions: map[string][]contracts.PreconditionArgument{
+				"Contains": newPreconditionArguments("{{ ssm:param }}", "foo", "{{ param1 }}", "bar"),
+			},
+			expectedError: "\"Contains\": operator's arguments can't contain SSM parameters",
+		},
+		"no document parameters": {
+			preconditions: map[string][]contracts.PreconditionArgument{
+				"Contains": newPreconditionArguments("foo", "foo", "bar", "bar"),
+			},
+			expectedError: "\"Contains\": at least one of operator's arguments must contain a valid document parameter",
+		},
+		"wrong number of arguments": {
+			preconditions: map[string][]contracts.PreconditionArgument{
+				"StringNotEquals": newPreconditionArguments("platformType", "platformType"),
+			},
+			expectedError: "\"StringNotEquals\": operator accepts exactly 2 arguments",
+		},
+	}
+
+	for name, testCase := range testCases {
+		outputs := runPluginsWithPreconditions(t, testCase.preconditions, false)
+		assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin1].Status, name)
+		assert.Contains(t, outputs[testPlugin1].Error, testCase.expectedError, name)
+	}
+}
+
+func TestGetStepExecutionOperationWithPluginUnknownToAgent(t *testing.T) {
+	preconditions := map[string][]contracts.PreconditionArgument{
+		"StringEquals": newPreconditionArguments("platformType", "platformType", "Windows", "Windows"),
+	}
+
+	operation, message, skipReason := getStepExecutionOperation(
+		mocklog.NewMockLog(), testUnknownPlugin, testUnknownPlugin, false, true, agentTooOldMessage(testUnknownPlugin), false, true, getPreconditionClauses(contracts.Configuration{Preconditions: preconditions}), false)
+
+	assert.Equal(t, skipStep, operation)
+	assert.Equal(t, contracts.SkipReasonAgentTooOld, skipReason)
+	assert.Equal(t, fmt.Sprintf(
+		"Step execution skipped because plugin %s is not known to SSM Agent version %s, an agent update may be required. Step name: %s",
+		testUnknownPlugin, version.Version, testUnknownPlugin), message)
+}
+
+func TestGetStepExecutionOperationFailsUnknownPluginAsAgentTooOld(t *testing.T) {
+	satisfiedPreconditions := map[string][]contracts.PreconditionArgument{
+		"StringEquals": newPreconditionArguments("platformType", "platformType", "Linux", "Linux"),
+	}
+	testCases := map[string]struct {
+		isPreconditionEnabled bool
+		preconditions         map[string][]contracts.PreconditionArgument
+	}{
+		"schema prior to 2.2":    {isPreconditionEnabled: false},
+		"no precondition":        {isPreconditionEnabled: true},
+		"precondition satisfied": {isPreconditionEnabled: true, preconditions: satisfiedPreconditions},
+	}
+
+	for name, testCase := range testCases {
+		operation, message, skipReason := getStepExecutionOperation(
+			mocklog.NewMockLog(), testUnknownPlugin, testUnknownPlugin, false, true, agentTooOldMessage(testUnknownPlugin),
+			false, testCase.isPreconditionEnabled, getPreconditionClauses(contracts.Configuration{Preconditions: testCase.preconditions}), false)
+
+		assert.Equal(t, failStep, operation, name)
+		assert.Equal(t, contracts.SkipReasonAgentTooOld, skipReason, name)
+		assert.Equal(t, fmt.Sprintf(
+			"Step execution failed because plugin %s is not known to SSM Agent version %s, an agent update may be required. Step name: %s",
+			testUnknownPlugin, version.Version, testUnknownPlugin), message, name)
+	}
+}
+
+func TestGetStepExecutionOperationWithPluginUnsupportedOnPlatform(t *testing.T) {
+	preconditions := map[string][]contracts.PreconditionArgument{
+		"StringEquals": newPreconditionArguments("platformType", "platformType", "Windows", "Windows"),
+	}
+
+	operation, message, skipReason := getStepExecutionOperation(
+		mocklog.NewMockLog(), testUnsupportedPlugin, testUnsupportedPlugin, true, false, "", false, true, getPreconditionClauses(contracts.Configuration{Preconditions: preconditions}), false)
+
+	assert.Equal(t, skipStep, operation)
+	assert.Equal(t, contracts.SkipReasonUnsupportedPlugin, skipReason)
+	assert.NotContains(t, message, version.Version)
+}
+
+func TestGetStepExecutionOperationWithAllPreconditionsSatisfied(t *testing.T) {
+	preconditions := map[string][]contracts.PreconditionArgument{
+		"StringEquals":    newPreconditionArguments("{{ environment }}", "prod", "prod", "prod"),
+		"StringNotEquals": newPreconditionArguments("{{ region }}", "us-east-1", "us-west-2", "us-west-2"),
+		"Contains":        newPreconditionArguments("{{ role }}", "web-server", "web", "web"),
+	}
+
+	operation, message, skipReason := getStepExecutionOperation(
+		mocklog.NewMockLog(), testPlugin1, testPlugin1, true, true, "", true, true, getPreconditionClauses(contracts.Configuration{Preconditions: preconditions}), false)
+
+	assert.Equal(t, executeStep, operation)
+	assert.Empty(t, message)
+	assert.Empty(t, skipReason)
+}
+
+func TestGetStepExecutionOperationWithOnePreconditionUnsatisfied(t *testing.T) {
+	preconditions := map[string][]contracts.PreconditionArgument{
+		"StringEquals":    newPreconditionArguments("{{ environment }}", "prod", "prod", "prod"),
+		"StringNotEquals": newPreconditionArguments("{{ region }}", "us-east-1", "us-west-2", "us-west-2"),
+		"Contains":        newPreconditionArguments("{{ role }}", "web-server", "db", "db"),
+	}
+
+	operation, message, skipReason := getStepExecutionOperation(
+		mocklog.NewMockLog(), testPlugin1, testPlugin1, true, true, "", true, true, getPreconditionClauses(contracts.Configuration{Preconditions: preconditions}), false)
+
+	assert.Equal(t, skipStep, operation)
+	assert.Equal(t, contracts.SkipReasonPreconditionFailed, skipReason)
+	assert.Equal(t, "Step execution skipped due to unsatisfied preconditions: '\"Contains\": [{{ role }}, db]'. Step name: "+testPlugin1, message)
+}
+
+func TestGetStepExecutionOperationWithUnknownOperatorAmongKnownOperators(t *testing.T) {
+	testCases := map[string]map[string][]contracts.PreconditionArgument{
+		"known preconditions satisfied": {
+			"StringEquals": newPreconditionArguments("{{ environment }}", "prod", "prod", "prod"),
+			"foo":          newPreconditionArguments("{{ environment }}", "prod", "prod", "prod"),
+		},
+		"known precondition unsatisfied": {
+			"StringEquals": newPreconditionArguments("{{ environment }}", "prod", "test", "test"),
+			"foo":          newPreconditionArguments("{{ environment }}", "prod", "prod", "prod"),
+		},
+	}
+
+	for name, preconditions := range testCases {
+		operation, message, skipReason := getStepExecutionOperation(
+			mocklog.NewMockLog(), testPlugin1, testPlugin1, true, true, "", true, true, getPreconditionClauses(contracts.Configuration{Preconditions: preconditions}), false)
+
+		assert.Equal(t, failStep, operation, name)
+		assert.Empty(t, skipReason, name)
+		assert.Equal(t, "Unrecognized precondition(s): 'unrecognized operator: \"foo\"', please update agent to latest version. Step name: "+testPlugin1, message, name)
+	}
+}
+
+func TestGetStepExecutionOperationWithPreconditionGroup(t *testing.T) {
+	testCases := map[string]struct {
+		allOf             []contracts.PreconditionClause
+		expectedOperation string
+		expectedMessage   string
+	}{
+		"all clauses satisfied": {
+			allOf: []contracts.PreconditionClause{
+				{Operator: "StringEquals", Arguments: newPreconditionArguments("{{ environment }}", "prod", "prod", "prod")},
+				{Operator: "StringEquals", Arguments: newPreconditionArguments("{{ role }}", "web", "web", "web")},
+			},
+			expectedOperation: executeStep,
+		},
+		"one clause of an operator unsatisfied": {
+			allOf: []contracts.PreconditionClause{
+				{Operator: "StringEquals", Arguments: newPreconditionArguments("{{ environment }}", "prod", "prod", "prod")},
+				{Operator: "StringEquals", Arguments: newPreconditionArguments("{{ role }}", "db", "web", "web")},
+			},
+			expectedOperation: skipStep,
+			expectedMessage:   "Step execution skipped due to unsatisfied preconditions: '\"StringEquals\": [{{ role }}, web]'. Step name: " + testPlugin1,
+		},
+	}
+
+	for name, testCase := range testCases {
+		configuration := contracts.Configuration{
+			Preconditions: map[string][]contracts.PreconditionArgument{
+				"StringNotEquals": newPreconditionArguments("{{ region }}", "us-east-1", "us-west-2", "us-west-2"),
+			},
+			PreconditionsAllOf: testCase.allOf,
+		}
+
+		operation, message, _ := getStepExecutionOperation(
+			mocklog.NewMockLog(), testPlugin1, testPlugin1, true, true, "", true, true, getPreconditionClauses(configuration), false)
+
+		assert.Equal(t, testCase.expectedOperation, operation, name)
+		assert.Equal(t, testCase.expectedMessage, message, name)
+	}
+}
+
+func TestGetPreconditionClauses(t *testing.T) {
+	groupClause := contracts.PreconditionClause{Operator: "StringEquals", Arguments: newPreconditionArguments("{{ role }}", "web", "web", "web")}
+	configuration := contracts.Configuration{
+		Preconditions: map[string][]contracts.PreconditionArgument{
+			"StringNotEquals": newPreconditionArguments("{{ region }}", "us-east-1", "us-west-2", "us-west-2"),
+			"Contains":        newPreconditionArguments("{{ role }}", "web-server", "web", "web"),
+		},
+		PreconditionsAllOf: []contracts.PreconditionClause{groupClause},
+	}
+
+	clauses := getPreconditionClauses(configuration)
+
+	assert.Equal(t, []contracts.PreconditionClause{
+		{Operator: "Contains", Arguments: configuration.Preconditions["Contains"]},
+		{Operator: "StringNotEquals", Arguments: configuration.Preconditions["StringNotEquals"]},
+		groupClause,
+	}, clauses)
+	assert.Empty(t, getPreconditionClauses(contracts.Configuration{}))
+}
+
+// runPluginWithInputs runs one step with the given inputs and returns its result and the configuration it executed with
+func runPluginWithInputs(t *testing.T, inputs map[string]interface{}) (*contracts.PluginResult, *contracts.Configuration) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	var executedConfig *contracts.Configuration
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		config := args.Get(0).(contracts.Configuration)
+		executedConfig = &config
+		args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return().Maybe()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil).Maybe()
+	plugins := []contracts.PluginState{{
+		Name: testPlugin1,
+		Id:   testPlugin1,
+		Configuration: contracts.Configuration{
+			PluginID:                testPlugin1,
+			PluginName:              testPlugin1,
+			Properties:              inputs,
+			DefaultWorkingDirectory: "/document/default",
+		},
+	}}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	return outputs[testPlugin1], executedConfig
+}
+
+func TestRunPluginsWithWorkingDirectoryOverride(t *testing.T) {
+	workingDirectory := t.TempDir()
+	result, config := runPluginWithInputs(t, map[string]interface{}{"workingDirectory": workingDirectory})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+	assert.Equal(t, workingDirectory, config.DefaultWorkingDirectory)
+}
+
+func TestRunPluginsWithMissingWorkingDirectory(t *testing.T) {
+	workingDirectory := filepath.Join(t.TempDir(), "missing")
+	result, config := runPluginWithInputs(t, map[string]interface{}{"workingDirectory": workingDirectory})
+
+	assert.Nil(t, config, "plugin should not run")
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Equal(t, fmt.Sprintf("working directory %v does not exist", workingDirectory), result.Error)
+}
+
+func TestRunPluginsWithWorkingDirectoryThatIsAFile(t *testing.T) {
+	workingDirectory := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(workingDirectory, []byte("content"), 0600))
+	result, config := runPluginWithInputs(t, map[string]interface{}{"workingDirectory": workingDirectory})
+
+	assert.Nil(t, config, "plugin should not run")
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Equal(t, fmt.Sprintf("working directory %v is not a directory", workingDirectory), result.Error)
+}
+
+func TestRunPluginsWithoutWorkingDirectory(t *testing.T) {
+	result, config := runPluginWithInputs(t, map[string]interface{}{"runCommand": []interface{}{"ls"}})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+	assert.Equal(t, "/document/default", config.DefaultWorkingDirectory)
+
+	// relative paths are resolved by the plugin against the document downloads
+	result, config = runPluginWithInputs(t, map[string]interface{}{"workingDirectory": "scripts"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+	assert.Equal(t, "/document/default", config.DefaultWorkingDirectory)
+}
+
+func TestRunPluginsRejectsInputsNotMatchingSchema(t *testing.T) {
+	RegisterInputSchema(testPlugin1, InputSchema{"commands": {Type: InputTypeStringList, Required: true}})
+	defer RegisterInputSchema(testPlugin1, nil)
+
+	result, config := runPluginWithInputs(t, map[string]interface{}{"commands": "echo hello"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Equal(t, `invalid inputs for plugin `+testPlugin1+`: input "commands" must be StringList, got string`, result.Error)
+	assert.Nil(t, config)
+
+	result, config = runPluginWithInputs(t, map[string]interface{}{})
+
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Contains(t, result.Error, `required input "commands" is missing`)
+	assert.Nil(t, config)
+}
+
+func TestRunPluginsAcceptsInputsMatchingSchema(t *testing.T) {
+	RegisterInputSchema(testPlugin1, InputSchema{"commands": {Type: InputTypeStringList, Required: true}})
+	defer RegisterInputSchema(testPlugin1, nil)
+
+	result, config := runPluginWithInputs(t, map[string]interface{}{"commands": []interface{}{"echo hello"}})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+	assert.NotNil(t, config)
+}
+
+func TestRunPluginsLogsStepWithCorrelationContext(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	stepContext := "[documentID=document-1 pluginID=" + testPlugin1 + " stepIndex=0]"
+	// the step lines are logged through the logger carrying the step context, everything else through the document logger
+	stepLog := mocklog.NewMockLog()
+	documentLog := mocklog.NewEmptyLogMock()
+	documentLog.On("WithContext", mock.MatchedBy(func(logContext []string) bool {
+		return len(logContext) > 0 && logContext[len(logContext)-1] == stepContext
+	})).Return(stepLog)
+	documentLog.On("WithContext", mock.Anything).Return(documentLog)
+	for _, method := range []string{"Trace", "Debug", "Info", "Warn", "Error"} {
+		documentLog.On(method, mock.Anything).Return().Maybe()
+		documentLog.On(method+"f", mock.Anything, mock.Anything).Return().Maybe()
+	}
+	ctx := context.Default(documentLog, appconfig.SsmagentConfig{}, identityMocks.NewDefaultMockAgentIdentity())
+
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	plugins := []contracts.PluginState{{
+		Name: testPlugin1,
+		Id:   testPlugin1,
+		Configuration: contracts.Configuration{
+			PluginID:            testPlugin1,
+			PluginName:          testPlugin1,
+			BookKeepingFileName: "document-1",
+		},
+	}}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	stepLog.AssertCalled(t, "Infof", "Running plugin %s %s", []interface{}{testPlugin1, testPlugin1})
+	stepLog.AssertCalled(t, "Infof", "Sending plugin %v completion message", []interface{}{testPlugin1})
+	documentLog.AssertNotCalled(t, "Infof", "Running plugin %s %s", mock.Anything)
+	documentLog.AssertNotCalled(t, "Infof", "Sending plugin %v completion message", mock.Anything)
+}
+
+// runPluginsWithAllowedPlugins runs a step of testPlugin1, which is allowed, and a step of testPlugin2, which is not
+func runPluginsWithAllowedPlugins(t *testing.T, disallowedPluginAction string) (map[string]*contracts.PluginResult, map[string]*PluginMock) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	config := appconfig.DefaultConfig()
+	config.Ssm.AllowedPlugins = []string{testPlugin1}
+	config.Ssm.DisallowedPluginAction = disallowedPluginAction
+	ctx := contextmocks.NewMockDefaultWithConfig(config)
+	pluginInstances := map[string]*PluginMock{testPlugin1: new(PluginMock), testPlugin2: new(PluginMock)}
+	pluginInstances[testPlugin1].On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return()
+	pluginRegistry := PluginRegistry{}
+	var plugins []contracts.PluginState
+	for _, name := range []string{testPlugin1, testPlugin2} {
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(pluginInstances[name], nil)
+		pluginRegistry[name] = pluginFactory
+		plugins = append(plugins, contracts.PluginState{
+			Name:          name,
+			Id:            name,
+			Configuration: contracts.Configuration{PluginID: name, PluginName: name},
+		})
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	return outputs, pluginInstances
+}
+
+func TestRunPluginsFailsPluginNotPermittedByPolicy(t *testing.T) {
+	outputs, pluginInstances := runPluginsWithAllowedPlugins(t, appconfig.DisallowedPluginActionFail)
+
+	pluginInstances[testPlugin1].AssertExpectations(t)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	pluginInstances[testPlugin2].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin2].Status)
+	assert.Contains(t, outputs[testPlugin2].Error, "not permitted by policy")
+}
+
+func TestRunPluginsSkipsPluginNotPermittedByPolicy(t *testing.T) {
+	outputs, pluginInstances := runPluginsWithAllowedPlugins(t, appconfig.DisallowedPluginActionSkip)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	pluginInstances[testPlugin2].AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin2].Status)
+	assert.Equal(t, contracts.SkipReasonPluginNotPermitted, outputs[testPlugin2].SkipReason)
+	assert.Contains(t, outputs[testPlugin2].Output, "not permitted by policy")
+}
+
+func TestApplyPluginPolicy(t *testing.T) {
+	config := appconfig.SsmCfg{AllowedPlugins: []string{"aws:runShellScript"}, DisallowedPluginAction: appconfig.DisallowedPluginActionFail}
+
+	// the policy applies whether or not the plugin is supported
+	operation, logMessage, _ := applyPluginPolicy(config, "aws:unknownPlugin", "step", failStep, "Plugin with name aws:unknownPlugin is not supported", "")
+	assert.Equal(t, failStep, operation)
+	assert.Contains(t, logMessage, "not permitted by policy")
+
+	// steps skipped for another reason keep their reason
+	operation, _, skipReason := applyPluginPolicy(config, "aws:runPowerShellScript", "step", skipStep, "skipped", contracts.SkipReasonPreconditionFailed)
+	assert.Equal(t, skipStep, operation)
+	assert.Equal(t, contracts.SkipReasonPreconditionFailed, skipReason)
+
+	// all plugins are allowed without allowed plugins
+	operation, _, _ = applyPluginPolicy(appconfig.SsmCfg{}, "aws:runPowerShellScript", "step", executeStep, "", "")
+	assert.Equal(t, executeStep, operation)
+}
+
+func setPlatformVersion(t *testing.T, version string) {
+	original := preconditionVariables["platformVersion"]
+	t.Cleanup(func() { preconditionVariables["platformVersion"] = original })
+	preconditionVariables["platformVersion"] = func(log.T) (string, error) { return version, nil }
+}
+
+func newPlatformVersionPreconditionPlugin(operator string, expectedVersion string) ([]contracts.PluginState, PluginRegistry, *PluginMock) {
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+
+	plugins := []contracts.PluginState{{
+		Name: testPlugin1,
+		Id:   testPlugin1,
+		Configuration: contracts.Configuration{
+			PluginID:              testPlugin1,
+			PluginName:            testPlugin1,
+			IsPreconditionEnabled: true,
+			Preconditions: map[string][]contracts.PreconditionArgument{
+				operator: {
+					{InitialArgumentValue: "platformVersion", ResolvedArgumentValue: "platformVersion"},
+					{InitialArgumentValue: expectedVersion, ResolvedArgumentValue: expectedVersion},
+				},
+			},
+		},
+	}}
+	return plugins, PluginRegistry{testPlugin1: pluginFactory}, pluginInstance
+}
+
+func TestRunPluginsWithPlatformVersionPrecondition(t *testing.T) {
+	testCases := []struct {
+		name            string
+		operator        string
+		expectedVersion string
+		executed        bool
+	}{
+		{"StringEqualsMatch", "StringEquals", "2023", true},
+		{"StringEqualsMismatch", "StringEquals", "2", false},
+		{"StringNotEqualsMatch", "StringNotEquals", "2", true},
+		{"StringNotEqualsMismatch", "StringNotEquals", "2023", false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			setIsSupportedMock()
+			defer restoreIsSupported()
+			setPlatformVersion(t, "2023")
+			ctx := contextmocks.NewMockDefault()
+			plugins, pluginRegistry, pluginInstance := newPlatformVersionPreconditionPlugin(testCase.operator, testCase.expectedVersion)
+			ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
+
+			ch := make(chan contracts.PluginResult, len(plugins))
+			outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+			close(ch)
+
+			if testCase.executed {
+				pluginInstance.AssertExpectations(t)
+				assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+			} else {
+				pluginInstance.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+				assert.Equal(t, contracts.ResultStatusSkipped, outputs[testPlugin1].Status)
+				assert.Contains(t, outputs[testPlugin1].Output, fmt.Sprintf("\"%s\": [platformVersion, %s]", testCase.operator, testCase.expectedVersion))
+			}
+		})
+	}
+}
+
+func TestRunPluginsWithPlatformVersionPreconditionRejectsDocumentParameters(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	setPlatformVersion(t, "2023")
+	ctx := contextmocks.NewMockDefault()
+	plugins, pluginRegistry, pluginInstance := newPlatformVersionPreconditionPlugin("StringEquals", "{{ version }}")
+	plugins[0].Configuration.Preconditions["StringEquals"][1].ResolvedArgumentValue = "2023"
+	ioConfig := contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	pluginInstance.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs[testPlugin1].Status)
+	assert.Contains(t, outputs[testPlugin1].Error, "the second argument for the platformVersion variable can't contain document parameters")
+}
+
+func TestRunPluginsMasksSecretValuesInResolvedInput(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		config := args.Get(0).(contracts.Configuration)
+		output := args.Get(2).(iohandler.IOHandler)
+		output.SetResolvedInput(config.Properties.(map[string]interface{})["commands"].(string))
+		output.MarkAsSucceeded()
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	pluginRegistry := PluginRegistry{testPlugin1: pluginFactory}
+	plugins := []contracts.PluginState{
+		{
+			Name: testPlugin1,
+			Id:   testPlugin1,
+			Configuration: contracts.Configuration{
+				PluginID:   testPlugin1,
+				PluginName: testPlugin1,
+				// the document parameters {{ user }} and {{ password }} were substituted at parse time
+				Properties:     map[string]interface{}{"commands": "login --user plain-user --password decoded-password"},
+				RedactedValues: []string{"decoded-password"},
+			},
+		},
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+	assert.Equal(t, "login --user plain-user --password *****", outputs[testPlugin1].ResolvedInput)
+}
+
+func TestRunPluginsOmitsSensitiveOutput(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	var uploadConfig contracts.IOConfiguration
+	pluginInstance := new(PluginMock)
+	pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		output := args.Get(2).(iohandler.IOHandler)
+		uploadConfig = output.GetIOConfig()
+		output.AppendInfo("secret value")
+		output.AppendError("secret error")
+		output.MarkAsFailed(fmt.Errorf("step failed"))
+	}).Return()
+	pluginFactory := new(PluginFactoryMock)
+	pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+	plugins := []contracts.PluginState{{
+		Name:          testPlugin1,
+		Id:            testPlugin1,
+		Configuration: contracts.Configuration{PluginID: testPlugin1, PluginName: testPlugin1, SensitiveOutput: true},
+	}}
+	ioConfig := contracts.IOConfiguration{
+		OrchestrationDirectory: t.TempDir(),
+		OutputS3BucketName:     "bucket",
+		OutputS3KeyPrefix:      "prefix",
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(ctx, plugins, ioConfig, contracts.MessageGatewayService, PluginRegistry{testPlugin1: pluginFactory}, ch, task.NewChanneledCancelFlag())
+	close(ch)
+
+	// the output writers of the step have no bucket to upload to
+	assert.Empty(t, uploadConfig.OutputS3BucketName)
+	assert.Empty(t, uploadConfig.OutputS3KeyPrefix)
+	result := outputs[testPlugin1]
+	assert.Empty(t, result.OutputS3BucketName)
+	assert.Empty(t, result.StepName)
+	assert.Equal(t, contracts.ResultStatusFailed, result.Status)
+	assert.Equal(t, 1, result.Code)
+	assert.Equal(t, sensitiveOutputPlaceholder, result.StandardOutput)
+	assert.Equal(t, sensitiveOutputPlaceholder, result.StandardError)
+	assert.Equal(t, sensitiveOutputPlaceholder, result.Output)
+	assert.NotContains(t, fmt.Sprint(<-ch), "secret")
+}
+
+func TestRunPluginsWaitsWhileDocumentIsPaused(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	ctx := contextmocks.NewMockDefault()
+	cancelFlag := task.NewChanneledCancelFlag()
+	pluginRegistry := PluginRegistry{}
+	stepStarted := make(chan string, 2)
+	var plugins []contracts.PluginState
+	for _, name := range []string{testPlugin0, testPlugin1} {
+		pluginName := name
+		pluginInstance := new(PluginMock)
+		pluginInstance.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stepStarted <- pluginName
+			// the maintenance window closes while the first step runs
+			if pluginName == testPlugin0 {
+				cancelFlag.Set(task.Paused)
+			}
+			args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+		}).Return()
+		pluginFactory := new(PluginFactoryMock)
+		pluginFactory.On("Create", mock.Anything).Return(pluginInstance, nil)
+		pluginRegistry[pluginName] = pluginFactory
+		plugins = append(plugins, contracts.PluginState{
+			Name:          pluginName,
+			Id:            pluginName,
+			Configuration: contracts.Configuration{PluginID: pluginName, PluginName: pluginName},
+		})
+	}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	done := make(chan map[string]*contracts.PluginResult, 1)
+	go func() {
+		done <- RunPlugins(ctx, plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, pluginRegistry, ch, cancelFlag)
+	}()
+
+	assert.Equal(t, testPlugin0, <-stepStarted)
+	select {
+	case name := <-stepStarted:
+		assert.Fail(t, "step started while the document is paused", name)
+	case <-done:
+		assert.Fail(t, "document completed while it is paused")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancelFlag.Set(task.Running)
+	assert.Equal(t, testPlugin1, <-stepStarted)
+	outputs := <-done
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin0].Status)
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs[testPlugin1].Status)
+}
+
+func TestPluginRegistryRegister(t *testing.T) {
+	registry := PluginRegistry{}
+	firstFactory, secondFactory := new(PluginFactoryMock), new(PluginFactoryMock)
+
+	assert.False(t, registry.IsRegistered(testPlugin1))
+	assert.NoError(t, registry.Register(testPlugin1, firstFactory))
+	assert.True(t, registry.IsRegistered(testPlugin1))
+	assert.NoError(t, registry.Register(testPlugin2, secondFactory))
+
+	assert.EqualError(t, registry.Register(testPlugin1, secondFactory), "plugin plugin1 is already registered")
+	assert.Same(t, firstFactory, registry[testPlugin1])
+	assert.Len(t, registry, 2)
+}
+
+func TestPluginRegistryMustRegister(t *testing.T) {
+	registry := PluginRegistry{}
+	factory := new(PluginFactoryMock)
+
+	assert.NotPanics(t, func() { registry.MustRegister(testPlugin1, factory) })
+	assert.PanicsWithError(t, "plugin plugin1 is already registered", func() { registry.MustRegister(testPlugin1, new(PluginFactoryMock)) })
+	assert.Same(t, factory, registry[testPlugin1])
+}
@@ -34,5 +34,8 @@ func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown
 		return known, true, fmt.Sprintf("%s v%s", platformName, platformVersion)
 	}
 	_, known := allPlugins[pluginName]
+	if !known {
+		return known, true, agentTooOldMessage(pluginName)
+	}
 	return known, true, fmt.Sprintf("%s v%s", platformName, platformVersion)
 }
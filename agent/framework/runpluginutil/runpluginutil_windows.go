@@ -40,6 +40,9 @@ func IsPluginSupportedForCurrentPlatform(log log.T, pluginName string) (isKnown
 	}
 
 	_, known := allPlugins[pluginName]
+	if !known {
+		return known, true, agentTooOldMessage(pluginName)
+	}
 	if isPlatformNanoServer, err := platform.IsPlatformNanoServer(log); err == nil && isPlatformNanoServer {
 		//if the current OS is Nano server, SSM Agent doesn't support the following plugins.
 		if pluginName == appconfig.PluginNameDomainJoin ||
@@ -0,0 +1,143 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	preStepHook  = "preStep"
+	postStepHook = "postStep"
+)
+
+// stepHookPluginNames are the shell plugins running the step hooks, the first one registered on the platform is used
+var stepHookPluginNames = []string{appconfig.PluginNameAwsRunShellScript, appconfig.PluginNameAwsRunPowerShellScript}
+
+// runPluginWithStepHooks runs the plugin between the preStep and postStep commands of the document, if any.
+// A failed hook command only fails the step when the document sets onStepHookFailure to fail,
+// a failed preStep command then prevents the step from running.
+func runPluginWithStepHooks(
+	context context.T,
+	registry PluginRegistry,
+	factory PluginFactory,
+	pluginName string,
+	config contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration) (res contracts.PluginResult) {
+
+	hooks := ioConfig.StepHooks
+	if hooks == nil {
+		return runPlugin(context, factory, pluginName, config, cancelFlag, ioConfig)
+	}
+	log := context.Log()
+	failOnHookFailure := hooks.OnFailure == contracts.StepHookFailureFail
+
+	var preStep *contracts.StepHookResult
+	if hooks.PreStep != "" {
+		preStep = runStepHook(context, registry, preStepHook, hooks.PreStep, config, cancelFlag, ioConfig)
+		if preStep.Status != contracts.ResultStatusSuccess {
+			if failOnHookFailure {
+				res = contracts.PluginResult{Status: contracts.ResultStatusFailed, Code: 1, StartDateTime: time.Now(), EndDateTime: time.Now()}
+				res.Error = fmt.Sprintf("%v command failed with exit code %v, the step was not run", preStepHook, preStep.Code)
+				res.Output = res.Error
+				res.PreStep = preStep
+				log.Error(res.Error)
+				return
+			}
+			log.Warnf("%v command failed with exit code %v, running the step anyway", preStepHook, preStep.Code)
+		}
+	}
+
+	res = runPlugin(context, factory, pluginName, config, cancelFlag, ioConfig)
+	res.PreStep = preStep
+
+	if hooks.PostStep != "" {
+		res.PostStep = runStepHook(context, registry, postStepHook, hooks.PostStep, config, cancelFlag, ioConfig)
+		if res.PostStep.Status != contracts.ResultStatusSuccess {
+			if failOnHookFailure && res.Status == contracts.ResultStatusSuccess {
+				res.Status = contracts.ResultStatusFailed
+				res.Code = 1
+				res.Error = fmt.Sprintf("%v command failed with exit code %v", postStepHook, res.PostStep.Code)
+				res.StandardError += res.Error
+				log.Error(res.Error)
+			} else {
+				log.Warnf("%v command failed with exit code %v", postStepHook, res.PostStep.Code)
+			}
+		}
+	}
+	return
+}
+
+// runStepHook runs a hook command of the step with the shell plugin, in a directory of its own under the step orchestration directory.
+// The hook output is not uploaded with the step output.
+func runStepHook(
+	context context.T,
+	registry PluginRegistry,
+	hookName string,
+	command string,
+	stepConfig contracts.Configuration,
+	cancelFlag task.CancelFlag,
+	ioConfig contracts.IOConfiguration) *contracts.StepHookResult {
+
+	var (
+		shellPluginName string
+		factory         PluginFactory
+	)
+	for _, name := range stepHookPluginNames {
+		if factory = registry[name]; factory != nil {
+			shellPluginName = name
+			break
+		}
+	}
+	if factory == nil {
+		return &contracts.StepHookResult{
+			Status: contracts.ResultStatusFailed,
+			Code:   1,
+			Error:  fmt.Sprintf("%v command not run, no shell plugin is available", hookName),
+		}
+	}
+
+	hookID := stepConfig.PluginID + "." + hookName
+	hookConfig := contracts.Configuration{
+		Properties:              map[string]interface{}{"id": hookID, "runCommand": []interface{}{command}},
+		OrchestrationDirectory:  fileutil.BuildPath(stepConfig.OrchestrationDirectory, hookName),
+		MessageId:               stepConfig.MessageId,
+		BookKeepingFileName:     stepConfig.BookKeepingFileName,
+		PluginName:              shellPluginName,
+		PluginID:                hookID,
+		DefaultWorkingDirectory: stepConfig.DefaultWorkingDirectory,
+		UpstreamServiceName:     stepConfig.UpstreamServiceName,
+		TimeoutSeconds:          stepConfig.TimeoutSeconds,
+		RedactedValues:          stepConfig.RedactedValues,
+	}
+	hookIOConfig := contracts.IOConfiguration{OrchestrationDirectory: ioConfig.OrchestrationDirectory}
+
+	context.Log().Infof("Running %v command of step %v", hookName, stepConfig.PluginID)
+	r := runPlugin(context, factory, shellPluginName, hookConfig, cancelFlag, hookIOConfig)
+	return &contracts.StepHookResult{
+		Status:         r.Status,
+		Code:           r.Code,
+		Error:          r.Error,
+		StandardOutput: r.StandardOutput,
+		StandardError:  r.StandardError,
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package runpluginutil
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// stepHookRecorder records the steps and hook commands run by the test plugins, in order
+type stepHookRecorder struct {
+	lock sync.Mutex
+	runs []string
+}
+
+func (recorder *stepHookRecorder) record(run string) {
+	recorder.lock.Lock()
+	defer recorder.lock.Unlock()
+	recorder.runs = append(recorder.runs, run)
+}
+
+// runPluginsWithStepHooks runs the given steps with a shell plugin that fails the "fail" command
+func runPluginsWithStepHooks(t *testing.T, hooks *contracts.StepHooks, stepIDs ...string) (*stepHookRecorder, map[string]*contracts.PluginResult) {
+	recorder := &stepHookRecorder{}
+	stepPlugin := new(PluginMock)
+	stepPlugin.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		config := args.Get(0).(contracts.Configuration)
+		recorder.record(config.PluginID)
+		args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return()
+	shellPlugin := new(PluginMock)
+	shellPlugin.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		config := args.Get(0).(contracts.Configuration)
+		output := args.Get(2).(iohandler.IOHandler)
+		command := config.Properties.(map[string]interface{})["runCommand"].([]interface{})[0].(string)
+		recorder.record(command)
+		if command == "fail" {
+			output.MarkAsFailed(fmt.Errorf("command failed"))
+			return
+		}
+		output.AppendInfo(command + " output")
+		output.MarkAsSucceeded()
+	}).Return()
+	stepFactory, shellFactory := new(PluginFactoryMock), new(PluginFactoryMock)
+	stepFactory.On("Create", mock.Anything).Return(stepPlugin, nil)
+	shellFactory.On("Create", mock.Anything).Return(shellPlugin, nil)
+	registry := PluginRegistry{testPlugin1: stepFactory, appconfig.PluginNameAwsRunShellScript: shellFactory}
+
+	orchestrationDir := t.TempDir()
+	var plugins []contracts.PluginState
+	for _, stepID := range stepIDs {
+		plugins = append(plugins, contracts.PluginState{
+			Name: testPlugin1,
+			Id:   stepID,
+			Configuration: contracts.Configuration{
+				PluginID:               stepID,
+				PluginName:             testPlugin1,
+				OrchestrationDirectory: orchestrationDir,
+			},
+		})
+	}
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir, StepHooks: hooks}, contracts.MessageGatewayService, registry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	return recorder, outputs
+}
+
+func TestRunPluginsRunsStepHooksAroundEachStep(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	recorder, outputs := runPluginsWithStepHooks(t, &contracts.StepHooks{PreStep: "pre", PostStep: "post"}, "step1", "step2")
+
+	assert.Equal(t, []string{"pre", "step1", "post", "pre", "step2", "post"}, recorder.runs)
+	for _, stepID := range []string{"step1", "step2"} {
+		result := outputs[stepID]
+		assert.Equal(t, contracts.ResultStatusSuccess, result.Status)
+		assert.Equal(t, contracts.ResultStatusSuccess, result.PreStep.Status)
+		assert.Equal(t, contracts.ResultStatusSuccess, result.PostStep.Status)
+		// the hook output is kept apart from the step output
+		assert.Contains(t, result.PreStep.StandardOutput, "pre output")
+		assert.Contains(t, result.PostStep.StandardOutput, "post output")
+		assert.NotContains(t, result.StandardOutput, "pre output")
+	}
+}
+
+func TestRunPluginsWithoutStepHooks(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	recorder, outputs := runPluginsWithStepHooks(t, nil, "step1")
+
+	assert.Equal(t, []string{"step1"}, recorder.runs)
+	assert.Nil(t, outputs["step1"].PreStep)
+	assert.Nil(t, outputs["step1"].PostStep)
+}
+
+func TestRunPluginsStepHookFailurePolicy(t *testing.T) {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+
+	testCases := []struct {
+		name           string
+		hooks          contracts.StepHooks
+		expectedRuns   []string
+		expectedStatus contracts.ResultStatus
+		expectedError  string
+	}{
+		{
+			name:           "PreStepFailureIgnored",
+			hooks:          contracts.StepHooks{PreStep: "fail", PostStep: "post"},
+			expectedRuns:   []string{"fail", "step1", "post"},
+			expectedStatus: contracts.ResultStatusSuccess,
+		},
+		{
+			name:           "PostStepFailureIgnored",
+			hooks:          contracts.StepHooks{PostStep: "fail", OnFailure: contracts.StepHookFailureIgnore},
+			expectedRuns:   []string{"step1", "fail"},
+			expectedStatus: contracts.ResultStatusSuccess,
+		},
+		{
+			name:           "PreStepFailureFailsStep",
+			hooks:          contracts.StepHooks{PreStep: "fail", PostStep: "post", OnFailure: contracts.StepHookFailureFail},
+			expectedRuns:   []string{"fail"},
+			expectedStatus: contracts.ResultStatusFailed,
+			expectedError:  "preStep command failed with exit code 1, the step was not run",
+		},
+		{
+			name:           "PostStepFailureFailsStep",
+			hooks:          contracts.StepHooks{PostStep: "fail", OnFailure: contracts.StepHookFailureFail},
+			expectedRuns:   []string{"step1", "fail"},
+			expectedStatus: contracts.ResultStatusFailed,
+			expectedError:  "postStep command failed with exit code 1",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			hooks := testCase.hooks
+			recorder, outputs := runPluginsWithStepHooks(t, &hooks, "step1")
+
+			assert.Equal(t, testCase.expectedRuns, recorder.runs)
+			assert.Equal(t, testCase.expectedStatus, outputs["step1"].Status)
+			assert.Equal(t, testCase.expectedError, outputs["step1"].Error)
+		})
+	}
+}
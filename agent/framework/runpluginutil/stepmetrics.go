@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"runtime/debug"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// StepMetrics holds the execution metrics of one step processed by RunPlugins
+type StepMetrics struct {
+	PluginName string
+	PluginID   string
+	Status     contracts.ResultStatus
+	// Duration is the time between the start and the end of the step
+	Duration time.Duration
+	// Executed is false for the steps that were skipped, cancelled or failed before the plugin ran
+	Executed bool
+	// ExitCode is the exit code of the plugin, only meaningful when Executed is true
+	ExitCode int
+}
+
+// MetricsSink receives the metrics of every step processed by RunPlugins, for instance to forward them to a
+// telemetry pipeline. RecordStep is called on the document execution path and should not block.
+type MetricsSink interface {
+	RecordStep(metrics StepMetrics)
+}
+
+// SSMMetricsSink is the sink RunPlugins reports the step metrics to. Like SSMPluginRegistry, it is set when the
+// process executing the documents starts, such as the document worker.
+var SSMMetricsSink MetricsSink = noOpMetricsSink{}
+
+// noOpMetricsSink drops every record, it is the default sink
+type noOpMetricsSink struct{}
+
+func (noOpMetricsSink) RecordStep(StepMetrics) {}
+
+// recordStepMetrics reports the result of a step to the registered sink, a failing sink does not fail the document
+func recordStepMetrics(log log.T, result *contracts.PluginResult, executed bool) {
+	sink := SSMMetricsSink
+	if sink == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Metrics sink panic: %v", r)
+			log.Errorf("Stacktrace:\n%s", debug.Stack())
+		}
+	}()
+
+	metrics := StepMetrics{
+		PluginName: result.PluginName,
+		PluginID:   result.PluginID,
+		Status:     result.Status,
+		Duration:   result.EndDateTime.Sub(result.StartDateTime),
+		Executed:   executed,
+	}
+	if executed {
+		metrics.ExitCode = result.Code
+	}
+	sink.RecordStep(metrics)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build freebsd || linux || netbsd || openbsd
+// +build freebsd linux netbsd openbsd
+
+package runpluginutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type capturingMetricsSink struct {
+	records []StepMetrics
+}
+
+func (s *capturingMetricsSink) RecordStep(metrics StepMetrics) {
+	s.records = append(s.records, metrics)
+}
+
+type panickingMetricsSink struct{}
+
+func (panickingMetricsSink) RecordStep(StepMetrics) {
+	panic("sink failure")
+}
+
+// runPluginsWithMetricsSink runs a succeeding, a failing and an unsupported step with the given sink registered
+func runPluginsWithMetricsSink(t *testing.T, sink MetricsSink) map[string]*contracts.PluginResult {
+	setIsSupportedMock()
+	defer restoreIsSupported()
+	defer func(original MetricsSink) { SSMMetricsSink = original }(SSMMetricsSink)
+	SSMMetricsSink = sink
+
+	succeedingPlugin := new(PluginMock)
+	succeedingPlugin.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		time.Sleep(20 * time.Millisecond)
+		args.Get(2).(iohandler.IOHandler).MarkAsSucceeded()
+	}).Return()
+	failingPlugin := new(PluginMock)
+	failingPlugin.On("Execute", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(2).(iohandler.IOHandler).SetExitCode(3)
+		args.Get(2).(iohandler.IOHandler).MarkAsFailed(nil)
+	}).Return()
+	succeedingFactory := new(PluginFactoryMock)
+	succeedingFactory.On("Create", mock.Anything).Return(succeedingPlugin, nil)
+	failingFactory := new(PluginFactoryMock)
+	failingFactory.On("Create", mock.Anything).Return(failingPlugin, nil)
+
+	plugins := []contracts.PluginState{
+		{Name: testPlugin1, Id: "succeeding", Configuration: contracts.Configuration{PluginID: "succeeding", PluginName: testPlugin1}},
+		{Name: testPlugin2, Id: "failing", Configuration: contracts.Configuration{PluginID: "failing", PluginName: testPlugin2}},
+		{Name: testUnsupportedPlugin, Id: "unsupported", Configuration: contracts.Configuration{PluginID: "unsupported", PluginName: testUnsupportedPlugin}},
+	}
+	registry := PluginRegistry{testPlugin1: succeedingFactory, testPlugin2: failingFactory}
+
+	ch := make(chan contracts.PluginResult, len(plugins))
+	outputs := RunPlugins(contextmocks.NewMockDefault(), plugins, contracts.IOConfiguration{OrchestrationDirectory: t.TempDir()}, contracts.MessageGatewayService, registry, ch, task.NewChanneledCancelFlag())
+	close(ch)
+	return outputs
+}
+
+func TestRunPluginsReportsStepMetrics(t *testing.T) {
+	sink := &capturingMetricsSink{}
+	outputs := runPluginsWithMetricsSink(t, sink)
+
+	assert.Len(t, sink.records, 3)
+	for _, record := range sink.records {
+		output := outputs[record.PluginID]
+		assert.Equal(t, output.PluginName, record.PluginName)
+		assert.Equal(t, output.Status, record.Status)
+		assert.Equal(t, output.EndDateTime.Sub(output.StartDateTime), record.Duration)
+	}
+
+	succeeding, failing, unsupported := sink.records[0], sink.records[1], sink.records[2]
+	assert.Equal(t, StepMetrics{
+		PluginName: testPlugin1, PluginID: "succeeding", Status: contracts.ResultStatusSuccess,
+		Duration: succeeding.Duration, Executed: true, ExitCode: 0,
+	}, succeeding)
+	assert.GreaterOrEqual(t, succeeding.Duration, 20*time.Millisecond)
+	assert.Equal(t, StepMetrics{
+		PluginName: testPlugin2, PluginID: "failing", Status: contracts.ResultStatusFailed,
+		Duration: failing.Duration, Executed: true, ExitCode: 3,
+	}, failing)
+	assert.Equal(t, StepMetrics{
+		PluginName: testUnsupportedPlugin, PluginID: "unsupported", Status: contracts.ResultStatusFailed,
+		Duration: unsupported.Duration,
+	}, unsupported)
+}
+
+func TestRunPluginsWithPanickingMetricsSink(t *testing.T) {
+	outputs := runPluginsWithMetricsSink(t, panickingMetricsSink{})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, outputs["succeeding"].Status)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["failing"].Status)
+	assert.Equal(t, contracts.ResultStatusFailed, outputs["unsupported"].Status)
+}
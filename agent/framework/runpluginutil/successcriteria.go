@@ -0,0 +1,115 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runpluginutil
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// successCriteriaMatcher matches the success criteria of a step against each line of its output as it is written,
+// so the lines past the captured output limit are matched as well
+type successCriteriaMatcher struct {
+	criteria       contracts.SuccessCriteria
+	failurePattern *regexp.Regexp
+	successPattern *regexp.Regexp
+
+	lock           sync.Mutex
+	failureMatch   string
+	failureMatched bool
+	successMatched bool
+}
+
+// ValidateSuccessCriteria checks that the patterns of the success criteria are valid regular expressions
+func ValidateSuccessCriteria(criteria contracts.SuccessCriteria) error {
+	_, err := newSuccessCriteriaMatcher(criteria)
+	return err
+}
+
+// newSuccessCriteriaMatcher compiles the patterns of the success criteria, it returns nil when the criteria are empty
+func newSuccessCriteriaMatcher(criteria contracts.SuccessCriteria) (matcher *successCriteriaMatcher, err error) {
+	if criteria == (contracts.SuccessCriteria{}) {
+		return nil, nil
+	}
+	matcher = &successCriteriaMatcher{criteria: criteria}
+	if criteria.FailurePattern != "" {
+		if matcher.failurePattern, err = regexp.Compile(criteria.FailurePattern); err != nil {
+			return nil, fmt.Errorf("failurePattern %q: %v", criteria.FailurePattern, err)
+		}
+	}
+	if criteria.SuccessPattern != "" {
+		if matcher.successPattern, err = regexp.Compile(criteria.SuccessPattern); err != nil {
+			return nil, fmt.Errorf("successPattern %q: %v", criteria.SuccessPattern, err)
+		}
+	}
+	return matcher, nil
+}
+
+// Filter records whether the line matches the patterns, the line is kept unchanged
+func (matcher *successCriteriaMatcher) Filter(line string) (string, bool) {
+	matcher.lock.Lock()
+	defer matcher.lock.Unlock()
+	if matcher.failurePattern != nil && !matcher.failureMatched {
+		if loc := matcher.failurePattern.FindStringIndex(line); loc != nil {
+			matcher.failureMatched = true
+			matcher.failureMatch = line[loc[0]:loc[1]]
+		}
+	}
+	if matcher.successPattern != nil && !matcher.successMatched {
+		matcher.successMatched = matcher.successPattern.MatchString(line)
+	}
+	return line, true
+}
+
+// reason returns why the lines matched so far do not meet the criteria, or an empty string if they do
+func (matcher *successCriteriaMatcher) reason() string {
+	matcher.lock.Lock()
+	defer matcher.lock.Unlock()
+	if matcher.failureMatched {
+		return fmt.Sprintf("Step output matched the failure pattern %q: %q", matcher.criteria.FailurePattern, matcher.failureMatch)
+	}
+	if matcher.successPattern != nil && !matcher.successMatched {
+		return fmt.Sprintf("Step output did not match the success pattern %q", matcher.criteria.SuccessPattern)
+	}
+	return ""
+}
+
+// applySuccessCriteria marks a successful step as Failed when its output does not meet the success criteria,
+// or when the criteria could not be compiled. The output writers are already closed, so the reason is added to stderr directly.
+func applySuccessCriteria(log log.T, matcher *successCriteriaMatcher, criteriaErr error, output iohandler.IOHandler) {
+	if output.GetStatus() != contracts.ResultStatusSuccess || (matcher == nil && criteriaErr == nil) {
+		return
+	}
+
+	var reason string
+	if criteriaErr != nil {
+		reason = fmt.Sprintf("Invalid success criteria: %v", criteriaErr)
+	} else if reason = matcher.reason(); reason == "" {
+		return
+	}
+
+	log.Infof("Marking step as failed: %v", reason)
+	stderr := output.GetStderr()
+	if stderr != "" {
+		stderr += "\n"
+	}
+	output.SetStderr(stderr + reason)
+	output.SetExitCode(1)
+	output.SetStatus(contracts.ResultStatusFailed)
+}
@@ -41,11 +41,11 @@ func LoadLog(defaultLogDir string, logFile string, debugStatus string) []byte {
         <console formatid="fmtinfo"/>
 
         `
-	logConfig += `<rollingfile type="size" filename="` + logFilePath + `" maxsize="30000000" maxrolls="5"/>`
+	logConfig += `<custom name="` + RotatingFileReceiverName + `" data-filename="` + logFilePath + `" data-maxsize="30000000"/>`
 	logConfig += `
 		<filter levels="error,critical" formatid="fmterror">
 		`
-	logConfig += `<rollingfile type="size" filename="` + errorFilePath + `" maxsize="10000000" maxrolls="5"/>`
+	logConfig += `<custom name="` + RotatingFileReceiverName + `" data-filename="` + errorFilePath + `" data-maxsize="10000000"/>`
 	logConfig += `
         </filter>
     </outputs>
@@ -0,0 +1,284 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/cihub/seelog"
+)
+
+// RotatingFileReceiverName is the name of the seelog custom receiver writing to a log file shared by the agent processes
+const RotatingFileReceiverName = "ssm_rotatingfile"
+
+const (
+	compressedLogExtension = ".gz"
+	lockFileExtension      = ".lock"
+	logFilePermissions     = 0666
+	logDirPermissions      = 0755
+)
+
+// loadRotationConfig returns how many rotated files are kept and whether they are compressed
+var loadRotationConfig = func() (retentionCount int, compress bool, err error) {
+	config, err := appconfig.Config(false)
+	if err != nil {
+		return 0, false, err
+	}
+	return config.Agent.RotatedLogRetentionCount, config.Agent.CompressRotatedLogs, nil
+}
+
+func init() {
+	seelog.RegisterReceiver(RotatingFileReceiverName, &RotatingFileReceiver{})
+}
+
+// RotatingFileReceiver implements seelog.CustomReceiver. It appends to a log file which is rotated once it
+// reaches its maximum size, the retention and compression of the rotated files come from the agent configuration.
+//
+// The agent, document worker and session worker processes write to the same files. A writer holds a shared lock
+// on the lock file beside the log file while it writes, and the process rotating the file holds an exclusive lock,
+// so a rotated file is never written to once it has been renamed. Writers reopen the log file when it was rotated.
+type RotatingFileReceiver struct {
+	fileName       string
+	maxSize        int64
+	retentionCount int
+	compress       bool
+
+	mutex    sync.Mutex
+	lockFile *os.File
+	file     *os.File
+}
+
+// AfterParse reads the filename and maxsize attributes of the receiver and opens its lock file
+func (receiver *RotatingFileReceiver) AfterParse(initArgs seelog.CustomReceiverInitArgs) (err error) {
+	receiver.fileName = initArgs.XmlCustomAttrs["filename"]
+	if receiver.fileName == "" {
+		return fmt.Errorf("%s receiver requires a filename", RotatingFileReceiverName)
+	}
+	if receiver.maxSize, err = strconv.ParseInt(initArgs.XmlCustomAttrs["maxsize"], 10, 64); err != nil || receiver.maxSize <= 0 {
+		return fmt.Errorf("%s receiver requires a positive maxsize", RotatingFileReceiverName)
+	}
+	if receiver.retentionCount, receiver.compress, err = loadRotationConfig(); err != nil {
+		return fmt.Errorf("%s receiver could not load the app config - %v", RotatingFileReceiverName, err)
+	}
+	if receiver.retentionCount < appconfig.DefaultRotatedLogRetentionCountMin {
+		receiver.retentionCount = appconfig.DefaultRotatedLogRetentionCount
+	}
+
+	if err = os.MkdirAll(filepath.Dir(receiver.fileName), logDirPermissions); err != nil {
+		return err
+	}
+	receiver.lockFile, err = os.OpenFile(receiver.fileName+lockFileExtension, os.O_RDWR|os.O_CREATE, logFilePermissions)
+	return err
+}
+
+// ReceiveMessage appends the message to the log file and rotates the file when it reached its maximum size
+func (receiver *RotatingFileReceiver) ReceiveMessage(message string, level seelog.LogLevel, context seelog.LogContextInterface) error {
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+
+	size, err := receiver.write(message)
+	if err != nil {
+		return err
+	}
+	if size >= receiver.maxSize {
+		return receiver.rotate()
+	}
+	return nil
+}
+
+// Flush does nothing, messages are written to the file as they are received
+func (receiver *RotatingFileReceiver) Flush() {
+}
+
+// Close closes the log file and the lock file
+func (receiver *RotatingFileReceiver) Close() error {
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+
+	if receiver.file != nil {
+		receiver.file.Close()
+		receiver.file = nil
+	}
+	if receiver.lockFile != nil {
+		receiver.lockFile.Close()
+		receiver.lockFile = nil
+	}
+	return nil
+}
+
+// write appends the message to the log file and returns the size of the file
+func (receiver *RotatingFileReceiver) write(message string) (size int64, err error) {
+	if err = lockFile(receiver.lockFile, false); err != nil {
+		return 0, err
+	}
+	defer unlockFile(receiver.lockFile)
+
+	if err = receiver.reopenIfRotated(); err != nil {
+		return 0, err
+	}
+	if _, err = receiver.file.WriteString(message); err != nil {
+		return 0, err
+	}
+	info, err := receiver.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// reopenIfRotated opens the log file unless the file already open is still the one at the log file path
+func (receiver *RotatingFileReceiver) reopenIfRotated() error {
+	if receiver.file != nil {
+		current, currentErr := os.Stat(receiver.fileName)
+		opened, openedErr := receiver.file.Stat()
+		if currentErr == nil && openedErr == nil && os.SameFile(current, opened) {
+			return nil
+		}
+		receiver.file.Close()
+		receiver.file = nil
+	}
+
+	file, err := openLogFile(receiver.fileName)
+	if err != nil {
+		return err
+	}
+	receiver.file = file
+	return nil
+}
+
+// rotate renames the log file to the first rotated file, shifting and pruning the older rotated files.
+// Nothing is done when another process rotated the file first.
+func (receiver *RotatingFileReceiver) rotate() error {
+	if err := lockFile(receiver.lockFile, true); err != nil {
+		return err
+	}
+	defer unlockFile(receiver.lockFile)
+
+	if info, err := os.Stat(receiver.fileName); err != nil || info.Size() < receiver.maxSize {
+		return nil
+	}
+	receiver.file.Close()
+	receiver.file = nil
+
+	rotatedFiles, err := receiver.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for _, rotated := range rotatedFiles {
+		if rotated.index >= receiver.retentionCount {
+			err = os.Remove(rotated.path)
+		} else {
+			err = os.Rename(rotated.path, receiver.rotatedFileName(rotated.index+1, rotated.extension))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	firstRotated := receiver.rotatedFileName(1, "")
+	if err = os.Rename(receiver.fileName, firstRotated); err != nil {
+		return err
+	}
+	if receiver.compress {
+		return compressFile(firstRotated, receiver.rotatedFileName(1, compressedLogExtension))
+	}
+	return nil
+}
+
+type rotatedFile struct {
+	path      string
+	index     int
+	extension string
+}
+
+// rotatedFiles lists the rotated files of the log file, compressed or not, from the oldest to the newest
+func (receiver *RotatingFileReceiver) rotatedFiles() ([]rotatedFile, error) {
+	entries, err := os.ReadDir(filepath.Dir(receiver.fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(receiver.fileName) + "."
+	var rotatedFiles []rotatedFile
+	for _, entry := range entries {
+		suffix := strings.TrimPrefix(entry.Name(), prefix)
+		if suffix == entry.Name() || entry.IsDir() {
+			continue
+		}
+		extension := ""
+		if strings.HasSuffix(suffix, compressedLogExtension) {
+			extension = compressedLogExtension
+			suffix = strings.TrimSuffix(suffix, compressedLogExtension)
+		}
+		if index, err := strconv.Atoi(suffix); err == nil && index > 0 {
+			rotatedFiles = append(rotatedFiles, rotatedFile{
+				path:      filepath.Join(filepath.Dir(receiver.fileName), entry.Name()),
+				index:     index,
+				extension: extension,
+			})
+		}
+	}
+	sort.Slice(rotatedFiles, func(i, j int) bool { return rotatedFiles[i].index > rotatedFiles[j].index })
+	return rotatedFiles, nil
+}
+
+func (receiver *RotatingFileReceiver) rotatedFileName(index int, extension string) string {
+	return fmt.Sprintf("%s.%d%s", receiver.fileName, index, extension)
+}
+
+// compressFile replaces the source file with its gzip compressed copy at the destination
+func compressFile(source string, destination string) error {
+	temporary := destination + ".tmp"
+	if err := writeCompressedFile(source, temporary); err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("failed to compress rotated log file %s: %v", source, err)
+	}
+	if err := os.Rename(temporary, destination); err != nil {
+		return err
+	}
+	return os.Remove(source)
+}
+
+func writeCompressedFile(source string, destination string) (err error) {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, logFilePermissions)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := destinationFile.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := gzip.NewWriter(destinationFile)
+	if _, err = io.Copy(writer, sourceFile); err != nil {
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cihub/seelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setRotationConfig(t *testing.T, retentionCount int, compress bool) {
+	original := loadRotationConfig
+	t.Cleanup(func() { loadRotationConfig = original })
+	loadRotationConfig = func() (int, bool, error) { return retentionCount, compress, nil }
+}
+
+func newRotatingFileLogger(t *testing.T, fileName string, maxSize int) seelog.LoggerInterface {
+	config := fmt.Sprintf(`
+<seelog type="sync">
+    <outputs formatid="message">
+        <custom name="%s" data-filename="%s" data-maxsize="%d"/>
+    </outputs>
+    <formats>
+        <format id="message" format="%%Msg%%n"/>
+    </formats>
+</seelog>`, RotatingFileReceiverName, fileName, maxSize)
+	logger, err := seelog.LoggerFromConfigAsString(config)
+	require.NoError(t, err)
+	return logger
+}
+
+func TestRotatingFileReceiverFailsWhenConfigCannotBeLoaded(t *testing.T) {
+	original := loadRotationConfig
+	t.Cleanup(func() { loadRotationConfig = original })
+	loadRotationConfig = func() (int, bool, error) { return 0, false, fmt.Errorf("invalid config override") }
+
+	receiver := &RotatingFileReceiver{}
+	err := receiver.AfterParse(seelog.CustomReceiverInitArgs{
+		XmlCustomAttrs: map[string]string{"filename": filepath.Join(t.TempDir(), "agent.log"), "maxsize": "100"},
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid config override")
+}
+
+// readLogLines returns the lines of a log file, decompressing rotated files
+func readLogLines(t *testing.T, path string) []string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, compressedLogExtension) {
+		gzipReader, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		reader = gzipReader
+	}
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func listLogFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileReceiverCompressesAndPrunesRotatedFiles(t *testing.T) {
+	setRotationConfig(t, 2, true)
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "agent.log")
+	// left behind by a configuration keeping more rotated files
+	require.NoError(t, os.WriteFile(fileName+".3", []byte("stale\n"), 0600))
+	require.NoError(t, os.WriteFile(fileName+".4.gz", []byte("stale"), 0600))
+
+	logger := newRotatingFileLogger(t, fileName, 100)
+	for i := 0; i < 13; i++ {
+		logger.Infof("message %02d %s", i, strings.Repeat("x", 30))
+	}
+	logger.Close()
+
+	assert.Equal(t, []string{"agent.log", "agent.log.1.gz", "agent.log.2.gz", "agent.log.lock"}, listLogFiles(t, dir))
+	// the 42 bytes messages rotate the file every third message, the older rotations were pruned
+	assert.Equal(t, []string{"message 12 " + strings.Repeat("x", 30)}, readLogLines(t, fileName))
+	assert.Equal(t, []string{"message 09 " + strings.Repeat("x", 30), "message 10 " + strings.Repeat("x", 30), "message 11 " + strings.Repeat("x", 30)}, readLogLines(t, fileName+".1.gz"))
+	assert.Equal(t, "message 06 "+strings.Repeat("x", 30), readLogLines(t, fileName+".2.gz")[0])
+}
+
+func TestRotatingFileReceiverWithoutCompression(t *testing.T) {
+	setRotationConfig(t, 3, false)
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "errors.log")
+
+	logger := newRotatingFileLogger(t, fileName, 40)
+	for i := 0; i < 4; i++ {
+		logger.Errorf("error %d %s", i, strings.Repeat("y", 40))
+	}
+	logger.Close()
+
+	assert.Equal(t, []string{"errors.log.1", "errors.log.2", "errors.log.3", "errors.log.lock"}, listLogFiles(t, dir))
+	assert.Equal(t, []string{"error 3 " + strings.Repeat("y", 40)}, readLogLines(t, fileName+".1"))
+}
+
+func TestRotatingFileReceiverRejectsMissingAttributes(t *testing.T) {
+	_, err := seelog.LoggerFromConfigAsString(`<seelog><outputs><custom name="` + RotatingFileReceiverName + `" data-maxsize="10"/></outputs></seelog>`)
+	assert.Error(t, err)
+
+	_, err = seelog.LoggerFromConfigAsString(`<seelog><outputs><custom name="` + RotatingFileReceiverName + `" data-filename="` + filepath.Join(t.TempDir(), "agent.log") + `"/></outputs></seelog>`)
+	assert.Error(t, err)
+}
+
+// TestRotatingFileReceiverConcurrentWriters has two receivers, each with its own file handles as separate agent
+// processes would, write to the same log file while it rotates. Every message must be found exactly once and intact.
+func TestRotatingFileReceiverConcurrentWriters(t *testing.T) {
+	setRotationConfig(t, 100, true)
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "agent.log")
+	const writers, messagesPerWriter = 2, 300
+
+	var wait sync.WaitGroup
+	for writer := 0; writer < writers; writer++ {
+		logger := newRotatingFileLogger(t, fileName, 2000)
+		wait.Add(1)
+		go func(writer int, logger seelog.LoggerInterface) {
+			defer wait.Done()
+			defer logger.Close()
+			for i := 0; i < messagesPerWriter; i++ {
+				logger.Infof("writer %d message %03d %s", writer, i, strings.Repeat("z", 20))
+			}
+		}(writer, logger)
+	}
+	wait.Wait()
+
+	seen := make(map[string]int)
+	for _, name := range listLogFiles(t, dir) {
+		if strings.HasSuffix(name, lockFileExtension) {
+			continue
+		}
+		assert.True(t, name == "agent.log" || strings.HasSuffix(name, compressedLogExtension), "unexpected file %s", name)
+		for _, line := range readLogLines(t, filepath.Join(dir, name)) {
+			seen[line]++
+		}
+	}
+	assert.Len(t, seen, writers*messagesPerWriter)
+	for writer := 0; writer < writers; writer++ {
+		for i := 0; i < messagesPerWriter; i++ {
+			assert.Equal(t, 1, seen[fmt.Sprintf("writer %d message %03d %s", writer, i, strings.Repeat("z", 20))])
+		}
+	}
+}
@@ -240,6 +240,11 @@ func (mds *MDSInteractor) listenReply() {
 	}()
 	for result := range mds.replyChan {
 		log.Debugf("start processing reply: %v", result.MessageID)
+		if result.Status == contracts.ResultStatusQueued {
+			mds.sendDocLevelResponse(result.MessageID, result.Status, "")
+			log.Debugf("ended processing reply: %v", result.MessageID)
+			continue
+		}
 		pluginID := result.LastPlugin
 		payloadDoc := messageContracts.SendReplyPayload{}
 
@@ -515,6 +520,7 @@ func (mds *MDSInteractor) processMessage(msg *ssmmds.Message) {
 		return
 	}
 
+	log.Infof("Received document %v with correlation ID %v", docState.DocumentInformation.DocumentID, sdkutil.CommandCorrelationID(*msg.MessageId))
 	errorCode := mds.messageHandler.Submit(docState)
 
 	// showLog is used to minimize warn log during ProcessorBufferFull error
@@ -18,6 +18,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/utils"
+	messageutils "github.com/aws/amazon-ssm-agent/agent/messageservice/utils"
 	"github.com/aws/amazon-ssm-agent/agent/runcommand"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/twinj/uuid"
@@ -108,6 +109,9 @@ func (ad *AgentRunCommandReplyType) constructMessage(result *contracts.DocumentR
 		OsVersion: appConfig.Os.Version,
 	}
 	replyPayload := runcommand.FormatPayload(log, result.LastPlugin, agentInfo, result.PluginResults)
+	if result.Status == contracts.ResultStatusQueued {
+		replyPayload = messageutils.PrepareReplyPayloadToUpdateDocumentStatus(agentInfo, result.Status, "", nil)
+	}
 	commandTopic := utils.GetTopicFromDocResult(result.ResultType, result.RelatedDocumentType)
 	return utils.GenerateAgentJobReplyPayload(log, ad.replyId, result.MessageID, replyPayload, commandTopic)
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	mgsUtils "github.com/aws/amazon-ssm-agent/agent/messageservice/interactor/mgsinteractor/utils"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -78,6 +79,22 @@ func (suite *AgentRunCommandReplyTestSuite) TestAgentRunCommandReply_AgentMessag
 	assert.Equal(suite.T(), outputMsgId, replyContent.JobId)
 }
 
+func (suite *AgentRunCommandReplyTestSuite) TestAgentRunCommandReply_QueuedDocumentStatus() {
+	ctx := context.NewMockDefault()
+	docResult := contracts.DocumentResult{MessageID: "messageId", ResultType: contracts.RunCommandResult, Status: contracts.ResultStatusQueued}
+	agentComplete := NewAgentRunCommandReplyType(ctx, docResult, uuid.NewV4(), 0)
+	agentMessage, err := agentComplete.ConvertToAgentMessage()
+	assert.Nil(suite.T(), err)
+	replyContent := mgsContracts.AgentJobReplyContent{}
+	err = json.Unmarshal(agentMessage.Payload, &replyContent)
+	assert.Nil(suite.T(), err)
+	payload := messageContracts.SendReplyPayload{}
+	err = json.Unmarshal([]byte(replyContent.Content), &payload)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), contracts.ResultStatusQueued, payload.DocumentStatus)
+	assert.Empty(suite.T(), payload.RuntimeStatus)
+}
+
 func (suite *AgentRunCommandReplyTestSuite) TestAgentCommandReply_HugePayloadGreaterThan120000_Fail() {
 	ctx := context.NewMockDefault()
 	pluginResult := make(map[string]*contracts.PluginResult)
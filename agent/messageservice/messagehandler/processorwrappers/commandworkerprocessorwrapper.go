@@ -186,7 +186,9 @@ externalLabel:
 				cpw.handleSpecialPlugin(res.LastPlugin, res.PluginResults, res.MessageID)
 			}
 
-			if res.LastPlugin != "" {
+			if res.Status == contracts.ResultStatusQueued {
+				log.Infof("command: %s queued", res.MessageID)
+			} else if res.LastPlugin != "" {
 				log.Infof("received plugin: %s result from Processor", res.LastPlugin)
 			} else {
 				log.Infof("command: %s complete", res.MessageID)
@@ -198,6 +200,10 @@ externalLabel:
 					cpw.context.AppConfig().Agent.OrchestrationRootDir,
 					cpw.context.AppConfig().Ssm.RunCommandLogsRetentionDurationHours,
 					cpw.context.AppConfig().Ssm.AssociationLogsRetentionDurationHours)
+				go docmanager.DeleteExpiredOrchestrationDirectories(log,
+					shortInstanceID,
+					cpw.context.AppConfig().Agent.OrchestrationRootDir,
+					cpw.context.AppConfig().Ssm.OrchestrationDirectoryRetentionDays)
 			}
 			res.ResultType = contracts.RunCommandResult
 
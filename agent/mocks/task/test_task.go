@@ -120,6 +120,16 @@ func (flag *MockCancelFlag) Wait() (state task.State) {
 	return flag.Called().Get(0).(task.State)
 }
 
+// WaitWhilePaused mocks the method with the same name.
+func (flag *MockCancelFlag) WaitWhilePaused() (state task.State) {
+	return flag.Called().Get(0).(task.State)
+}
+
+// WaitWhileRunning mocks the method with the same name.
+func (flag *MockCancelFlag) WaitWhileRunning() (state task.State) {
+	return flag.Called().Get(0).(task.State)
+}
+
 func (flag *MockCancelFlag) Set(state task.State) {
 	flag.Called(state)
 }
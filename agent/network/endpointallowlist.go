@@ -0,0 +1,112 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+)
+
+// defaultAllowedEndpoints are the endpoints the agent needs to operate, always allowed once an allowlist is configured
+var defaultAllowedEndpoints = []string{
+	// ssm, ssmmessages and ec2messages, including the fips endpoints
+	"ssm*.amazonaws.com",
+	"ssm*.amazonaws.com.cn",
+	"ec2messages*.amazonaws.com",
+	"ec2messages*.amazonaws.com.cn",
+	// path style and virtual hosted style s3 endpoints
+	"s3*.amazonaws.com",
+	"s3*.amazonaws.com.cn",
+	"*.s3*.amazonaws.com",
+	"*.s3*.amazonaws.com.cn",
+	// instance metadata service
+	"169.254.169.254",
+	"fd00:ec2::254",
+}
+
+// EndpointNotAllowedError is returned for requests to a host outside of the endpoint allowlist
+type EndpointNotAllowedError struct {
+	Host string
+}
+
+func (e *EndpointNotAllowedError) Error() string {
+	return fmt.Sprintf("request to %v blocked by policy: the host is not in the AllowedEndpoints of the agent configuration", e.Host)
+}
+
+// endpointAllowlist holds lower case host names and path.Match patterns of the allowed hosts
+type endpointAllowlist []string
+
+// newEndpointAllowlist returns the allowlist built from the agent configuration, nil when every host is allowed
+func newEndpointAllowlist(appConfig appconfig.SsmagentConfig) endpointAllowlist {
+	if len(appConfig.Agent.AllowedEndpoints) == 0 {
+		return nil
+	}
+
+	allowlist := endpointAllowlist{}
+	endpoints := append([]string{}, appConfig.Agent.AllowedEndpoints...)
+	endpoints = append(endpoints, defaultAllowedEndpoints...)
+	endpoints = append(endpoints, appConfig.Mds.Endpoint, appConfig.Ssm.Endpoint, appConfig.Mgs.Endpoint, appConfig.S3.Endpoint, appConfig.Kms.Endpoint)
+	for _, endpoint := range endpoints {
+		if host := endpointHost(endpoint); host != "" {
+			allowlist = append(allowlist, host)
+		}
+	}
+	return allowlist
+}
+
+// endpointHost returns the lower case host of an endpoint given as a host name or url
+func endpointHost(endpoint string) string {
+	endpoint = strings.TrimSpace(endpoint)
+	if strings.Contains(endpoint, "://") {
+		if endpointUrl, err := url.Parse(endpoint); err == nil {
+			endpoint = endpointUrl.Hostname()
+		}
+	} else if host, _, err := net.SplitHostPort(endpoint); err == nil {
+		endpoint = host
+	}
+	return strings.ToLower(strings.Trim(endpoint, "[]"))
+}
+
+// allows returns true if the host matches one of the allowed hosts or patterns
+func (a endpointAllowlist) allows(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range a {
+		if matched, err := path.Match(pattern, host); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictToAllowedEndpoints makes the transport fail requests to hosts outside of the allowlist.
+// The check is done when resolving the proxy of the request since it is the only hook of the transport that sees
+// the requested host whether or not a proxy is used, and it still applies when callers replace DialContext.
+func restrictToAllowedEndpoints(transport *http.Transport, allowlist endpointAllowlist) {
+	proxy := transport.Proxy
+	transport.Proxy = func(request *http.Request) (*url.URL, error) {
+		if !allowlist.allows(request.URL.Hostname()) {
+			return nil, &EndpointNotAllowedError{Host: request.URL.Hostname()}
+		}
+		if proxy == nil {
+			return nil, nil
+		}
+		return proxy(request)
+	}
+}
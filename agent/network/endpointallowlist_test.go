@@ -0,0 +1,96 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func getWithDefaultTransport(t *testing.T, allowedEndpoints []string) error {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	appConfig := appconfig.SsmagentConfig{}
+	appConfig.Agent.AllowedEndpoints = allowedEndpoints
+	client := &http.Client{Transport: GetDefaultTransport(log.NewMockLog(), appConfig)}
+	resp, err := client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func TestGetDefaultTransport_AllowsEveryHostWithoutAllowlist(t *testing.T) {
+	assert.NoError(t, getWithDefaultTransport(t, nil))
+}
+
+func TestGetDefaultTransport_AllowsAllowedEndpoint(t *testing.T) {
+	assert.NoError(t, getWithDefaultTransport(t, []string{"example.com", "127.0.0.1"}))
+}
+
+func TestGetDefaultTransport_BlocksEndpointNotAllowed(t *testing.T) {
+	err := getWithDefaultTransport(t, []string{"example.com"})
+
+	var policyErr *EndpointNotAllowedError
+	assert.True(t, errors.As(err, &policyErr))
+	assert.Equal(t, "127.0.0.1", policyErr.Host)
+	assert.Contains(t, err.Error(), "blocked by policy")
+}
+
+func TestEndpointAllowlist_DefaultAndConfiguredEndpoints(t *testing.T) {
+	appConfig := appconfig.SsmagentConfig{}
+	appConfig.Agent.AllowedEndpoints = []string{"*.Example.com", "proxy.internal:3128"}
+	appConfig.Ssm.Endpoint = "https://vpce-123.ssm.us-east-1.vpce.amazonaws.com"
+	appConfig.Kms.Endpoint = "kms.internal"
+	allowlist := newEndpointAllowlist(appConfig)
+
+	for _, host := range []string{
+		"ssm.us-east-1.amazonaws.com",
+		"ssmmessages.us-east-1.amazonaws.com",
+		"ec2messages.cn-north-1.amazonaws.com.cn",
+		"ssm-fips.us-gov-west-1.amazonaws.com",
+		"s3.amazonaws.com",
+		"s3.eu-west-1.amazonaws.com",
+		"my-bucket.s3.eu-west-1.amazonaws.com",
+		"169.254.169.254",
+		"fd00:ec2::254",
+		"vpce-123.ssm.us-east-1.vpce.amazonaws.com",
+		"kms.internal",
+		"repo.example.com",
+		"proxy.internal",
+	} {
+		assert.True(t, allowlist.allows(host), host)
+	}
+	for _, host := range []string{
+		"example.com",
+		"ec2.us-east-1.amazonaws.com",
+		"ssm.us-east-1.amazonaws.com.attacker.com",
+		"169.254.169.253",
+	} {
+		assert.False(t, allowlist.allows(host), host)
+	}
+}
+
+func TestEndpointAllowlist_NilWithoutConfiguration(t *testing.T) {
+	assert.Nil(t, newEndpointAllowlist(appconfig.SsmagentConfig{}))
+}
@@ -42,6 +42,8 @@ func GetDefaultTLSConfig(log log.T, appConfig appconfig.SsmagentConfig) *tls.Con
 		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
 	}
 	tlsConfigCopy := tlsConfig.Clone()
+	tlsConfigCopy.MinVersion = getMinimumTLSVersion(appConfig)
+	tlsConfigCopy.CipherSuites = getCipherSuites(appConfig)
 
 	retryCount := 0
 	for retryCount < maxRetryCount {
@@ -82,3 +84,30 @@ func GetDefaultTLSConfig(log log.T, appConfig appconfig.SsmagentConfig) *tls.Con
 
 	return tlsConfigCopy
 }
+
+// getMinimumTLSVersion returns the configured minimum TLS version, never below TLS 1.2
+func getMinimumTLSVersion(appConfig appconfig.SsmagentConfig) uint16 {
+	if appConfig.Agent.MinimumTLSVersion == appconfig.MinimumTLSVersion13 {
+		return tls.VersionTLS13
+	}
+	return tls.VersionTLS12
+}
+
+// getCipherSuites returns the ids of the configured secure cipher suites, nil when none is configured to use the Go defaults
+func getCipherSuites(appConfig appconfig.SsmagentConfig) []uint16 {
+	if len(appConfig.Agent.TLSCipherSuites) == 0 {
+		return nil
+	}
+	cipherSuiteIDs := make(map[string]uint16)
+	for _, cipherSuite := range tls.CipherSuites() {
+		cipherSuiteIDs[cipherSuite.Name] = cipherSuite.ID
+	}
+
+	var cipherSuites []uint16
+	for _, name := range appConfig.Agent.TLSCipherSuites {
+		if id, ok := cipherSuiteIDs[name]; ok {
+			cipherSuites = append(cipherSuites, id)
+		}
+	}
+	return cipherSuites
+}
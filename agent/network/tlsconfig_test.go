@@ -0,0 +1,57 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package network
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDefaultTransport_DefaultTLSSettings(t *testing.T) {
+	transport := GetDefaultTransport(log.NewMockLog(), appconfig.DefaultConfig())
+
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	assert.Nil(t, transport.TLSClientConfig.CipherSuites)
+}
+
+func TestGetDefaultTransport_ConfiguredTLSSettings(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Agent.MinimumTLSVersion = appconfig.MinimumTLSVersion13
+	appConfig.Agent.TLSCipherSuites = []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}
+
+	transport := GetDefaultTransport(log.NewMockLog(), appConfig)
+
+	assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, transport.TLSClientConfig.CipherSuites)
+
+	// the settings of a client do not leak into the transports built afterwards
+	transport = GetDefaultTransport(log.NewMockLog(), appconfig.DefaultConfig())
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	assert.Nil(t, transport.TLSClientConfig.CipherSuites)
+}
+
+func TestGetDefaultTLSConfig_IgnoresUnknownCipherSuites(t *testing.T) {
+	appConfig := appconfig.DefaultConfig()
+	appConfig.Agent.MinimumTLSVersion = "1.0"
+	appConfig.Agent.TLSCipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}
+
+	tlsConfig := GetDefaultTLSConfig(log.NewMockLog(), appConfig)
+
+	assert.Equal(t, uint16(tls.VersionTLS12), tlsConfig.MinVersion)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+}
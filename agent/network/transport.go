@@ -10,5 +10,8 @@ import (
 func GetDefaultTransport(log log.T, appConfig appconfig.SsmagentConfig) *http.Transport {
 	result := http.DefaultTransport.(*http.Transport).Clone()
 	result.TLSClientConfig = GetDefaultTLSConfig(log, appConfig)
+	if allowlist := newEndpointAllowlist(appConfig); allowlist != nil {
+		restrictToAllowedEndpoints(result, allowlist)
+	}
 	return result
 }
@@ -163,7 +163,7 @@ func (p *Plugin) runCommands(pluginID string, pluginInput ApplicationPluginInput
 	}
 
 	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(p.context, defaultWorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments, make(map[string]string))
+	exitCode, err := executers.WithResourceUsageRecorder(p.CommandExecuter, output).NewExecute(p.context, defaultWorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, defaultApplicationExecutionTimeoutInSeconds, commandName, commandArguments, make(map[string]string))
 
 	// Set output status
 	output.SetExitCode(exitCode)
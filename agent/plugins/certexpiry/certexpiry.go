@@ -0,0 +1,262 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package certexpiry implements the aws:checkCertificateExpiry plugin.
+package certexpiry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	defaultThresholdDays      = 30
+	defaultDialTimeoutSeconds = 10
+	targetTypeFile            = "File"
+	targetTypeEndpoint        = "Endpoint"
+	certificateStatusValid    = "Valid"
+	certificateStatusExpiring = "Expiring"
+	certificateStatusExpired  = "Expired"
+	certificateStatusError    = "Error"
+	hoursPerDay               = 24
+)
+
+var timeNow = time.Now
+
+// Plugin is the type for the aws:checkCertificateExpiry plugin.
+type Plugin struct {
+	context context.T
+}
+
+// CertificateExpiryPluginInput represents the certificates checked by the plugin.
+type CertificateExpiryPluginInput struct {
+	contracts.PluginInput
+	ID string
+	// CertificateFiles are paths to PEM encoded certificates, the first certificate of each file is checked
+	CertificateFiles []string
+	// Endpoints are host:port addresses serving TLS
+	Endpoints []string
+	// ThresholdDays is the minimum number of days a certificate must remain valid
+	ThresholdDays interface{}
+	// TimeoutSeconds bounds the connection to each endpoint
+	TimeoutSeconds interface{}
+}
+
+// CertificateStatus represents the expiry check result of a single target.
+type CertificateStatus struct {
+	Target          string
+	Type            string
+	Subject         string `json:",omitempty"`
+	NotAfter        string `json:",omitempty"`
+	DaysUntilExpiry int
+	Status          string
+	Error           string `json:",omitempty"`
+}
+
+// CertificateExpiryOutput represents the structured output of the plugin.
+type CertificateExpiryOutput struct {
+	ThresholdDays int
+	Certificates  []CertificateStatus
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin(context context.T) (*Plugin, error) {
+	return &Plugin{
+		context: context,
+	}, nil
+}
+
+// Name returns the name of the plugin
+func Name() string {
+	return appconfig.PluginNameAwsCheckCertificateExpiry
+}
+
+// Execute checks the expiry of the declared certificates and fails when any of them expires within the threshold.
+func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := p.context.Log()
+	log.Infof("%v started with configuration %v", Name(), config)
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	var pluginInput CertificateExpiryPluginInput
+	if err := jsonutil.Remarshal(config.Properties, &pluginInput); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err))
+		return
+	}
+	if len(pluginInput.CertificateFiles) == 0 && len(pluginInput.Endpoints) == 0 {
+		output.MarkAsFailed(fmt.Errorf("at least one of CertificateFiles or Endpoints must be provided"))
+		return
+	}
+	thresholdDays, err := parsePositiveInt(pluginInput.ThresholdDays, defaultThresholdDays)
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("invalid ThresholdDays: %v", err))
+		return
+	}
+	timeoutSeconds, err := parsePositiveInt(pluginInput.TimeoutSeconds, defaultDialTimeoutSeconds)
+	if err != nil {
+		output.MarkAsFailed(fmt.Errorf("invalid TimeoutSeconds: %v", err))
+		return
+	}
+
+	result := CertificateExpiryOutput{ThresholdDays: thresholdDays}
+	for _, file := range pluginInput.CertificateFiles {
+		cert, err := readCertificateFile(file)
+		result.Certificates = append(result.Certificates, newCertificateStatus(file, targetTypeFile, cert, err, thresholdDays))
+	}
+	for _, endpoint := range pluginInput.Endpoints {
+		if cancelFlag.Canceled() {
+			output.MarkAsCancelled()
+			return
+		}
+		cert, err := fetchEndpointCertificate(endpoint, time.Duration(timeoutSeconds)*time.Second)
+		result.Certificates = append(result.Certificates, newCertificateStatus(endpoint, targetTypeEndpoint, cert, err, thresholdDays))
+	}
+
+	if resultJson, err := jsonutil.MarshalIndent(result); err == nil {
+		output.AppendInfo(resultJson)
+	}
+	output.SetOutput(result)
+
+	failed := 0
+	for _, status := range result.Certificates {
+		switch status.Status {
+		case certificateStatusValid:
+		case certificateStatusError:
+			failed++
+			output.AppendErrorf("%v %v: %v", status.Type, status.Target, status.Error)
+		default:
+			failed++
+			output.AppendErrorf("%v %v: certificate %v expires in %v day(s), below the threshold of %v day(s)",
+				status.Type, status.Target, status.Subject, status.DaysUntilExpiry, thresholdDays)
+		}
+	}
+	if failed > 0 {
+		output.MarkAsFailed(fmt.Errorf("%v of %v certificate check(s) failed", failed, len(result.Certificates)))
+		return
+	}
+	output.MarkAsSucceeded()
+}
+
+// newCertificateStatus builds the result for a target from its certificate or retrieval error
+func newCertificateStatus(target string, targetType string, cert *x509.Certificate, err error, thresholdDays int) CertificateStatus {
+	status := CertificateStatus{
+		Target: target,
+		Type:   targetType,
+	}
+	if err != nil {
+		status.Status = certificateStatusError
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Subject = cert.Subject.String()
+	status.NotAfter = cert.NotAfter.UTC().Format(time.RFC3339)
+	status.DaysUntilExpiry = int(math.Floor(cert.NotAfter.Sub(timeNow()).Hours() / hoursPerDay))
+	switch {
+	case !timeNow().Before(cert.NotAfter):
+		status.Status = certificateStatusExpired
+	case status.DaysUntilExpiry < thresholdDays:
+		status.Status = certificateStatusExpiring
+	default:
+		status.Status = certificateStatusValid
+	}
+	return status
+}
+
+// readCertificateFile parses the first PEM encoded certificate of the file
+func readCertificateFile(path string) (*x509.Certificate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %v", err)
+	}
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("malformed certificate: %v", err)
+		}
+		return cert, nil
+	}
+	return nil, fmt.Errorf("malformed certificate: no PEM encoded certificate found")
+}
+
+// fetchEndpointCertificate returns the leaf certificate presented by the endpoint.
+// The chain is not verified since only the expiry date is reported.
+func fetchEndpointCertificate(endpoint string, timeout time.Duration) (*x509.Certificate, error) {
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint, expected host:port: %v", err)
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", endpoint, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("endpoint unreachable: %v", err)
+	}
+	defer conn.Close()
+
+	peerCertificates := conn.ConnectionState().PeerCertificates
+	if len(peerCertificates) == 0 {
+		return nil, fmt.Errorf("endpoint did not present a certificate")
+	}
+	return peerCertificates[0], nil
+}
+
+// parsePositiveInt parses a document value that may be a number or a string, returning defaultValue when unset
+func parsePositiveInt(value interface{}, defaultValue int) (int, error) {
+	var parsed int
+	switch v := value.(type) {
+	case nil:
+		return defaultValue, nil
+	case float64:
+		parsed = int(v)
+	case string:
+		if v == "" {
+			return defaultValue, nil
+		}
+		var err error
+		if parsed, err = strconv.Atoi(v); err != nil {
+			return 0, fmt.Errorf("%v is not a number", v)
+		}
+	default:
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+	if parsed <= 0 {
+		return 0, fmt.Errorf("%v must be greater than 0", parsed)
+	}
+	return parsed, nil
+}
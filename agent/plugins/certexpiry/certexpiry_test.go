@@ -0,0 +1,172 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package certexpiry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self signed certificate expiring after validFor and returns its path
+func writeCertificate(t *testing.T, name string, validFor time.Duration) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), name+".pem")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return path
+}
+
+func executePlugin(t *testing.T, properties map[string]interface{}) (iohandler.IOHandler, CertificateExpiryOutput) {
+	p, err := NewPlugin(context.NewMockDefault())
+	assert.NoError(t, err)
+	output := iohandler.NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{})
+
+	p.Execute(contracts.Configuration{Properties: properties}, task.NewChanneledCancelFlag(), output)
+
+	result, _ := output.GetOutput().(CertificateExpiryOutput)
+	return output, result
+}
+
+func TestExecute_ValidCertificate(t *testing.T) {
+	path := writeCertificate(t, "valid", 365*24*time.Hour)
+
+	output, result := executePlugin(t, map[string]interface{}{
+		"certificateFiles": []interface{}{path},
+		"thresholdDays":    "30",
+	})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, 30, result.ThresholdDays)
+	assert.Len(t, result.Certificates, 1)
+	assert.Equal(t, certificateStatusValid, result.Certificates[0].Status)
+	assert.Equal(t, "CN=valid", result.Certificates[0].Subject)
+	assert.InDelta(t, 364, result.Certificates[0].DaysUntilExpiry, 1)
+	assert.Contains(t, output.GetStdout(), "\"DaysUntilExpiry\"")
+}
+
+func TestExecute_NearExpiryCertificate(t *testing.T) {
+	validPath := writeCertificate(t, "valid", 365*24*time.Hour)
+	expiringPath := writeCertificate(t, "expiring", 5*24*time.Hour)
+
+	output, result := executePlugin(t, map[string]interface{}{
+		"certificateFiles": []interface{}{validPath, expiringPath},
+		"thresholdDays":    float64(14),
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Len(t, result.Certificates, 2)
+	assert.Equal(t, certificateStatusValid, result.Certificates[0].Status)
+	assert.Equal(t, certificateStatusExpiring, result.Certificates[1].Status)
+	assert.InDelta(t, 4, result.Certificates[1].DaysUntilExpiry, 1)
+	assert.Contains(t, output.GetStderr(), "below the threshold of 14 day(s)")
+}
+
+func TestExecute_ExpiredCertificate(t *testing.T) {
+	path := writeCertificate(t, "expired", -24*time.Hour)
+
+	output, result := executePlugin(t, map[string]interface{}{
+		"certificateFiles": []interface{}{path},
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, certificateStatusExpired, result.Certificates[0].Status)
+}
+
+func TestExecute_MalformedCertificate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "malformed.pem")
+	assert.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0600))
+
+	output, result := executePlugin(t, map[string]interface{}{
+		"certificateFiles": []interface{}{path},
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, certificateStatusError, result.Certificates[0].Status)
+	assert.Contains(t, result.Certificates[0].Error, "malformed certificate")
+}
+
+func TestExecute_ReachableEndpoint(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	output, result := executePlugin(t, map[string]interface{}{
+		"endpoints": []interface{}{serverURL.Host},
+	})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, certificateStatusValid, result.Certificates[0].Status)
+	assert.Equal(t, server.Certificate().NotAfter.UTC().Format(time.RFC3339), result.Certificates[0].NotAfter)
+}
+
+func TestExecute_UnreachableEndpoint(t *testing.T) {
+	// reserve a local port and close it so that nothing listens on it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	endpoint := listener.Addr().String()
+	listener.Close()
+
+	output, result := executePlugin(t, map[string]interface{}{
+		"endpoints":      []interface{}{endpoint},
+		"timeoutSeconds": "2",
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Len(t, result.Certificates, 1)
+	assert.Equal(t, targetTypeEndpoint, result.Certificates[0].Type)
+	assert.Equal(t, certificateStatusError, result.Certificates[0].Status)
+	assert.Contains(t, result.Certificates[0].Error, "endpoint unreachable")
+}
+
+func TestExecute_InvalidInput(t *testing.T) {
+	output, _ := executePlugin(t, map[string]interface{}{})
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "at least one of CertificateFiles or Endpoints must be provided")
+
+	output, _ = executePlugin(t, map[string]interface{}{
+		"certificateFiles": []interface{}{"cert.pem"},
+		"thresholdDays":    "-1",
+	})
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "invalid ThresholdDays")
+}
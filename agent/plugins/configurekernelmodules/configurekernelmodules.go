@@ -0,0 +1,220 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package configurekernelmodules implements the aws:configureKernelModules plugin.
+package configurekernelmodules
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	ensureLoaded   = "Loaded"
+	ensureUnloaded = "Unloaded"
+
+	actionLoaded      = "Loaded"
+	actionUnloaded    = "Unloaded"
+	actionPersisted   = "Persisted"
+	actionUnpersisted = "Unpersisted"
+	actionUnchanged   = "Unchanged"
+	actionFailed      = "Failed"
+)
+
+// moduleNamePattern matches the names modprobe accepts, bounded by the kernel module name length.
+// A name cannot start with a dash, so it is never taken for a modprobe option.
+var moduleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]{0,54}$`)
+
+// Plugin is the type for the aws:configureKernelModules plugin.
+type Plugin struct {
+	context context.T
+	manager moduleManager
+}
+
+// ConfigureKernelModulesPluginInput represents the kernel modules declared by the document.
+type ConfigureKernelModulesPluginInput struct {
+	contracts.PluginInput
+	ID      string
+	Modules []KernelModuleInput
+}
+
+// KernelModuleInput declares the desired state of a single kernel module.
+type KernelModuleInput struct {
+	Name string
+	// Ensure is Loaded (default) or Unloaded
+	Ensure string
+	// Persist adds the module to a modules-load.d file owned by the plugin when loaded, or removes that file
+	// when unloaded. Files written by administrators are left untouched.
+	Persist bool
+}
+
+// KernelModuleState reports the state of a single kernel module after the plugin ran.
+type KernelModuleState struct {
+	Name string
+	// Action is Loaded or Unloaded when the module state changed, Persisted or Unpersisted when only
+	// modules-load.d changed, Unchanged or Failed
+	Action string
+	Loaded bool
+	// Persisted reports whether the modules-load.d file of the plugin loads the module at boot
+	Persisted bool
+	Error     string `json:",omitempty"`
+}
+
+// moduleManager loads, unloads and persists kernel modules
+type moduleManager interface {
+	IsLoaded(name string) (bool, error)
+	Load(name string) error
+	Unload(name string) error
+	IsPersisted(name string) (bool, error)
+	Persist(name string) error
+	RemovePersisted(name string) error
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin(context context.T) (*Plugin, error) {
+	return &Plugin{
+		context: context,
+		manager: newModuleManager(),
+	}, nil
+}
+
+// Name returns the name of the plugin
+func Name() string {
+	return appconfig.PluginNameAwsConfigureKernelModules
+}
+
+// Execute brings the declared kernel modules to their desired state. Modules already in the desired state are
+// left untouched, and a failure on one module does not prevent the others from being configured.
+func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := p.context.Log()
+	log.Infof("%v started with configuration %v", Name(), config)
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	var pluginInput ConfigureKernelModulesPluginInput
+	if err := jsonutil.Remarshal(config.Properties, &pluginInput); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err))
+		return
+	}
+	if err := validate(pluginInput.Modules); err != nil {
+		output.MarkAsFailed(err)
+		return
+	}
+
+	states, failed := configureModules(log, p.manager, pluginInput.Modules, cancelFlag)
+	var err error
+	if failed > 0 {
+		err = fmt.Errorf("failed to configure %v of %v kernel modules", failed, len(pluginInput.Modules))
+	}
+	pluginutil.ReportConfigurationResult(output, states, err, cancelFlag)
+}
+
+// validate checks the names and desired states of the declared modules
+func validate(modules []KernelModuleInput) error {
+	if len(modules) == 0 {
+		return fmt.Errorf("at least one kernel module must be provided")
+	}
+	for _, module := range modules {
+		if !moduleNamePattern.MatchString(module.Name) {
+			return fmt.Errorf("invalid kernel module name %q", module.Name)
+		}
+		if module.Ensure != "" && module.Ensure != ensureLoaded && module.Ensure != ensureUnloaded {
+			return fmt.Errorf("Ensure of kernel module %v must be %v or %v", module.Name, ensureLoaded, ensureUnloaded)
+		}
+	}
+	return nil
+}
+
+// configureModules configures every module in order and returns their states and the number of failures
+func configureModules(log log.T, manager moduleManager, modules []KernelModuleInput, cancelFlag task.CancelFlag) (states []KernelModuleState, failed int) {
+	states = make([]KernelModuleState, 0, len(modules))
+	for _, module := range modules {
+		if cancelFlag.Canceled() {
+			log.Info("Kernel module configuration cancelled")
+			break
+		}
+		state, err := configureModule(manager, module)
+		if err != nil {
+			log.Errorf("Failed to configure kernel module %v: %v", module.Name, err)
+			state.Action = actionFailed
+			state.Error = err.Error()
+			failed++
+		}
+		states = append(states, state)
+	}
+	return states, failed
+}
+
+// configureModule brings a single module to its desired state
+func configureModule(manager moduleManager, module KernelModuleInput) (state KernelModuleState, err error) {
+	state = KernelModuleState{Name: module.Name, Action: actionUnchanged}
+	if state.Loaded, err = manager.IsLoaded(module.Name); err != nil {
+		return state, err
+	}
+	if state.Persisted, err = manager.IsPersisted(module.Name); err != nil {
+		return state, err
+	}
+
+	if module.Ensure == ensureUnloaded {
+		if state.Loaded {
+			if err = manager.Unload(module.Name); err != nil {
+				return state, fmt.Errorf("unload failed: %v", err)
+			}
+			state.Loaded = false
+			state.Action = actionUnloaded
+		}
+		if module.Persist && state.Persisted {
+			if err = manager.RemovePersisted(module.Name); err != nil {
+				return state, fmt.Errorf("failed to remove the module from modules-load.d: %v", err)
+			}
+			state.Persisted = false
+			if state.Action == actionUnchanged {
+				state.Action = actionUnpersisted
+			}
+		}
+		return state, nil
+	}
+
+	if !state.Loaded {
+		if err = manager.Load(module.Name); err != nil {
+			return state, fmt.Errorf("load failed: %v", err)
+		}
+		state.Loaded = true
+		state.Action = actionLoaded
+	}
+	if module.Persist && !state.Persisted {
+		if err = manager.Persist(module.Name); err != nil {
+			return state, fmt.Errorf("failed to add the module to modules-load.d: %v", err)
+		}
+		state.Persisted = true
+		if state.Action == actionUnchanged {
+			state.Action = actionPersisted
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build linux
+// +build linux
+
+package configurekernelmodules
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+)
+
+const (
+	sysModuleDir      = "/sys/module"
+	modprobeCommand   = "modprobe"
+	modulesLoadSuffix = ".conf"
+	// modulesLoadPrefix marks the modules-load.d files written by the plugin, the only ones it removes
+	modulesLoadPrefix = "amazon-ssm-agent-"
+)
+
+// modulesLoadDir is a variable so tests can use a temporary directory
+var modulesLoadDir = "/etc/modules-load.d"
+
+// linuxModuleManager manages modules with modprobe and persists them in modules-load.d
+type linuxModuleManager struct{}
+
+func newModuleManager() moduleManager {
+	return linuxModuleManager{}
+}
+
+// IsLoaded returns true if the module is loaded or built into the kernel, which reports module names with underscores
+func (linuxModuleManager) IsLoaded(name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(sysModuleDir, strings.ReplaceAll(name, "-", "_")))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (linuxModuleManager) Load(name string) error {
+	return modprobe(name)
+}
+
+func (linuxModuleManager) Unload(name string) error {
+	return modprobe("-r", name)
+}
+
+func (linuxModuleManager) IsPersisted(name string) (bool, error) {
+	return fileutil.Exists(persistedModulePath(name)), nil
+}
+
+func (linuxModuleManager) Persist(name string) error {
+	if err := fileutil.MakeDirs(modulesLoadDir); err != nil {
+		return err
+	}
+	return os.WriteFile(persistedModulePath(name), []byte(name+"\n"), appconfig.ReadWriteAccess)
+}
+
+func (linuxModuleManager) RemovePersisted(name string) error {
+	return fileutil.DeleteFile(persistedModulePath(name))
+}
+
+// persistedModulePath returns the modules-load.d file the plugin writes to load the module at boot
+func persistedModulePath(name string) string {
+	return filepath.Join(modulesLoadDir, modulesLoadPrefix+name+modulesLoadSuffix)
+}
+
+// modprobe runs modprobe and reports its output on failure
+func modprobe(args ...string) error {
+	if output, err := exec.Command(modprobeCommand, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v %v: %v %v", modprobeCommand, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package configurekernelmodules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinuxModuleManagerLeavesAdministratorFiles(t *testing.T) {
+	defaultModulesLoadDir := modulesLoadDir
+	modulesLoadDir = t.TempDir()
+	defer func() { modulesLoadDir = defaultModulesLoadDir }()
+	adminFile := filepath.Join(modulesLoadDir, "overlay.conf")
+	assert.NoError(t, os.WriteFile(adminFile, []byte("overlay\n"), 0644))
+	manager := linuxModuleManager{}
+
+	persisted, err := manager.IsPersisted("overlay")
+	assert.NoError(t, err)
+	assert.False(t, persisted)
+
+	assert.NoError(t, manager.Persist("overlay"))
+	persisted, _ = manager.IsPersisted("overlay")
+	assert.True(t, persisted)
+	content, err := os.ReadFile(filepath.Join(modulesLoadDir, "amazon-ssm-agent-overlay.conf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "overlay\n", string(content))
+
+	assert.NoError(t, manager.RemovePersisted("overlay"))
+	persisted, _ = manager.IsPersisted("overlay")
+	assert.False(t, persisted)
+	assert.FileExists(t, adminFile)
+}
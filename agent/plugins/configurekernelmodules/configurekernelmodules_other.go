@@ -0,0 +1,54 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+//
+//go:build !linux
+// +build !linux
+
+package configurekernelmodules
+
+import (
+	"fmt"
+)
+
+var errKernelModulesNotSupported = fmt.Errorf("kernel modules can only be configured on Linux")
+
+// unsupportedModuleManager fails every operation since modprobe is only available on Linux
+type unsupportedModuleManager struct{}
+
+func newModuleManager() moduleManager {
+	return unsupportedModuleManager{}
+}
+
+func (unsupportedModuleManager) IsLoaded(name string) (bool, error) {
+	return false, errKernelModulesNotSupported
+}
+
+func (unsupportedModuleManager) Load(name string) error {
+	return errKernelModulesNotSupported
+}
+
+func (unsupportedModuleManager) Unload(name string) error {
+	return errKernelModulesNotSupported
+}
+
+func (unsupportedModuleManager) IsPersisted(name string) (bool, error) {
+	return false, errKernelModulesNotSupported
+}
+
+func (unsupportedModuleManager) Persist(name string) error {
+	return errKernelModulesNotSupported
+}
+
+func (unsupportedModuleManager) RemovePersisted(name string) error {
+	return errKernelModulesNotSupported
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package configurekernelmodules
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeModuleManager keeps the loaded and persisted modules in memory and fails loading the modules in loadErrors
+type fakeModuleManager struct {
+	loaded     map[string]bool
+	persisted  map[string]bool
+	loadErrors map[string]error
+	calls      []string
+}
+
+func newFakeModuleManager() *fakeModuleManager {
+	return &fakeModuleManager{loaded: map[string]bool{}, persisted: map[string]bool{}, loadErrors: map[string]error{}}
+}
+
+func (m *fakeModuleManager) IsLoaded(name string) (bool, error) {
+	return m.loaded[name], nil
+}
+
+func (m *fakeModuleManager) Load(name string) error {
+	m.calls = append(m.calls, "load "+name)
+	if err := m.loadErrors[name]; err != nil {
+		return err
+	}
+	m.loaded[name] = true
+	return nil
+}
+
+func (m *fakeModuleManager) Unload(name string) error {
+	m.calls = append(m.calls, "unload "+name)
+	delete(m.loaded, name)
+	return nil
+}
+
+func (m *fakeModuleManager) IsPersisted(name string) (bool, error) {
+	return m.persisted[name], nil
+}
+
+func (m *fakeModuleManager) Persist(name string) error {
+	m.calls = append(m.calls, "persist "+name)
+	m.persisted[name] = true
+	return nil
+}
+
+func (m *fakeModuleManager) RemovePersisted(name string) error {
+	m.calls = append(m.calls, "unpersist "+name)
+	delete(m.persisted, name)
+	return nil
+}
+
+func executePlugin(manager moduleManager, modules ...map[string]interface{}) (iohandler.IOHandler, []KernelModuleState) {
+	p := &Plugin{context: context.NewMockDefault(), manager: manager}
+	output := iohandler.NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{})
+	properties := map[string]interface{}{"modules": modules}
+
+	p.Execute(contracts.Configuration{Properties: properties}, task.NewChanneledCancelFlag(), output)
+
+	states, _ := output.GetOutput().([]KernelModuleState)
+	return output, states
+}
+
+func TestExecute_Load(t *testing.T) {
+	manager := newFakeModuleManager()
+
+	output, states := executePlugin(manager, map[string]interface{}{"name": "br_netfilter"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, []KernelModuleState{{Name: "br_netfilter", Action: actionLoaded, Loaded: true}}, states)
+	assert.Equal(t, []string{"load br_netfilter"}, manager.calls)
+}
+
+func TestExecute_AlreadyInDesiredState(t *testing.T) {
+	manager := newFakeModuleManager()
+	manager.loaded["overlay"] = true
+	manager.persisted["overlay"] = true
+
+	output, states := executePlugin(manager,
+		map[string]interface{}{"name": "overlay", "persist": true},
+		map[string]interface{}{"name": "floppy", "ensure": "Unloaded"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, []KernelModuleState{
+		{Name: "overlay", Action: actionUnchanged, Loaded: true, Persisted: true},
+		{Name: "floppy", Action: actionUnchanged},
+	}, states)
+	assert.Empty(t, manager.calls)
+}
+
+func TestExecute_Unload(t *testing.T) {
+	manager := newFakeModuleManager()
+	manager.loaded["floppy"] = true
+	manager.persisted["floppy"] = true
+
+	output, states := executePlugin(manager, map[string]interface{}{"name": "floppy", "ensure": "Unloaded", "persist": true})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, []KernelModuleState{{Name: "floppy", Action: actionUnloaded}}, states)
+	assert.Equal(t, []string{"unload floppy", "unpersist floppy"}, manager.calls)
+}
+
+func TestExecute_Persist(t *testing.T) {
+	manager := newFakeModuleManager()
+	manager.loaded["overlay"] = true
+
+	output, states := executePlugin(manager,
+		map[string]interface{}{"name": "overlay", "persist": true},
+		map[string]interface{}{"name": "br_netfilter", "ensure": "Loaded", "persist": true})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, []KernelModuleState{
+		{Name: "overlay", Action: actionPersisted, Loaded: true, Persisted: true},
+		{Name: "br_netfilter", Action: actionLoaded, Loaded: true, Persisted: true},
+	}, states)
+	assert.True(t, manager.persisted["overlay"])
+	assert.True(t, manager.persisted["br_netfilter"])
+}
+
+func TestExecute_LoadError(t *testing.T) {
+	manager := newFakeModuleManager()
+	manager.loadErrors["missing"] = errors.New("Module missing not found")
+
+	output, states := executePlugin(manager,
+		map[string]interface{}{"name": "missing", "persist": true},
+		map[string]interface{}{"name": "overlay"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, actionFailed, states[0].Action)
+	assert.Contains(t, states[0].Error, "Module missing not found")
+	assert.False(t, manager.persisted["missing"])
+	assert.Equal(t, KernelModuleState{Name: "overlay", Action: actionLoaded, Loaded: true}, states[1])
+	assert.Contains(t, output.GetStderr(), "failed to configure 1 of 2 kernel modules")
+}
+
+func TestExecute_InvalidInput(t *testing.T) {
+	inputs := []map[string]interface{}{
+		{"name": "../etc/passwd"},
+		{"name": "overlay; reboot"},
+		{"name": "-r"},
+		{"name": "--first-time"},
+		{"name": ""},
+		{"name": "overlay", "ensure": "Removed"},
+	}
+	for _, input := range inputs {
+		manager := newFakeModuleManager()
+
+		output, _ := executePlugin(manager, input)
+
+		assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus(), "input %v", input)
+		assert.Empty(t, manager.calls)
+	}
+
+	output, _ := executePlugin(newFakeModuleManager())
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+}
@@ -49,6 +49,7 @@ func (m *execDepImp) ParseDocument(context context.T, documentRaw []byte, orches
 	}
 
 	var docContent docparser.DocContent
+	documentRaw, _ = docparser.NormalizeDocumentRaw(documentRaw)
 	err = json.Unmarshal(documentRaw, &docContent)
 	if err != nil {
 		return
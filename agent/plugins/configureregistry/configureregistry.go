@@ -0,0 +1,393 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package configureregistry implements the aws:configureRegistry plugin.
+package configureregistry
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	ensurePresent = "Present"
+	ensureAbsent  = "Absent"
+
+	typeString       = "String"
+	typeExpandString = "ExpandString"
+	typeMultiString  = "MultiString"
+	typeDWord        = "DWord"
+	typeQWord        = "QWord"
+	typeBinary       = "Binary"
+
+	actionCreated        = "Created"
+	actionUpdated        = "Updated"
+	actionDeleted        = "Deleted"
+	actionUnchanged      = "Unchanged"
+	actionRolledBack     = "RolledBack"
+	actionRollbackFailed = "RollbackFailed"
+)
+
+// hives maps the accepted hive names to their canonical name
+var hives = map[string]string{
+	"HKLM":                "HKEY_LOCAL_MACHINE",
+	"HKEY_LOCAL_MACHINE":  "HKEY_LOCAL_MACHINE",
+	"HKCU":                "HKEY_CURRENT_USER",
+	"HKEY_CURRENT_USER":   "HKEY_CURRENT_USER",
+	"HKU":                 "HKEY_USERS",
+	"HKEY_USERS":          "HKEY_USERS",
+	"HKCR":                "HKEY_CLASSES_ROOT",
+	"HKEY_CLASSES_ROOT":   "HKEY_CLASSES_ROOT",
+	"HKCC":                "HKEY_CURRENT_CONFIG",
+	"HKEY_CURRENT_CONFIG": "HKEY_CURRENT_CONFIG",
+}
+
+// Plugin is the type for the aws:configureRegistry plugin.
+type Plugin struct {
+	context context.T
+	editor  registryEditor
+}
+
+// ConfigureRegistryPluginInput represents the registry values declared by the document.
+type ConfigureRegistryPluginInput struct {
+	contracts.PluginInput
+	ID     string
+	Values []RegistryValueInput
+}
+
+// RegistryValueInput declares the desired state of a single registry value.
+type RegistryValueInput struct {
+	// Path is the key path including the hive, e.g. HKLM\SOFTWARE\Example
+	Path string
+	// Name is the value name, empty for the default value of the key
+	Name string
+	// Type is one of String, ExpandString, MultiString, DWord, QWord or Binary (hex encoded)
+	Type string
+	// Value is the desired data, a list of strings for MultiString
+	Value interface{}
+	// Ensure is Present (default) to create or update the value, or Absent to delete it
+	Ensure string
+}
+
+// RegistryChange reports what was done for a single registry value.
+type RegistryChange struct {
+	Path          string
+	Name          string
+	Action        string
+	PreviousValue interface{} `json:",omitempty"`
+	Value         interface{} `json:",omitempty"`
+	Error         string      `json:",omitempty"`
+}
+
+// registryValue is the typed data of a registry value
+type registryValue struct {
+	Type    string
+	String  string
+	Strings []string
+	Integer uint64
+	Binary  []byte
+}
+
+// registryEditor reads and writes registry values, hive being a canonical hive name
+type registryEditor interface {
+	GetValue(hive string, key string, name string) (value registryValue, exists bool, err error)
+	// SetValue writes a value, creating the missing keys of its path, and returns the topmost key it created
+	SetValue(hive string, key string, name string, value registryValue) (createdKey string, err error)
+	DeleteValue(hive string, key string, name string) error
+	// DeleteKey deletes a key with its subkeys and values
+	DeleteKey(hive string, key string) error
+}
+
+// desiredValue is a validated RegistryValueInput
+type desiredValue struct {
+	input  RegistryValueInput
+	hive   string
+	key    string
+	absent bool
+	value  registryValue
+}
+
+// appliedChange keeps the prior state of a value so that the change can be rolled back
+type appliedChange struct {
+	desired    desiredValue
+	existed    bool
+	previous   registryValue
+	createdKey string
+	// index is the position of the change in the reported changes
+	index int
+}
+
+// NewPlugin returns a new instance of the plugin.
+func NewPlugin(context context.T) (*Plugin, error) {
+	return &Plugin{
+		context: context,
+		editor:  newRegistryEditor(),
+	}, nil
+}
+
+// Name returns the name of the plugin
+func Name() string {
+	return appconfig.PluginNameAwsConfigureRegistry
+}
+
+// Execute applies the declared registry values. Values already in the desired state are left untouched and
+// all changes, including the keys they created, are rolled back if any of them fails.
+func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
+	log := p.context.Log()
+	log.Infof("%v started with configuration %v", Name(), config)
+
+	if cancelFlag.ShutDown() {
+		output.MarkAsShutdown()
+		return
+	} else if cancelFlag.Canceled() {
+		output.MarkAsCancelled()
+		return
+	}
+
+	var pluginInput ConfigureRegistryPluginInput
+	if err := jsonutil.Remarshal(config.Properties, &pluginInput); err != nil {
+		output.MarkAsFailed(fmt.Errorf("Invalid format in plugin properties %v;\nerror %v", config.Properties, err))
+		return
+	}
+	if len(pluginInput.Values) == 0 {
+		output.MarkAsFailed(fmt.Errorf("at least one registry value must be provided"))
+		return
+	}
+	desiredValues := make([]desiredValue, 0, len(pluginInput.Values))
+	for _, input := range pluginInput.Values {
+		desired, err := validate(input)
+		if err != nil {
+			output.MarkAsFailed(fmt.Errorf("invalid registry value %v\\%v: %v", input.Path, input.Name, err))
+			return
+		}
+		desiredValues = append(desiredValues, desired)
+	}
+
+	changes, err := apply(log, p.editor, desiredValues, cancelFlag)
+	pluginutil.ReportConfigurationResult(output, changes, err, cancelFlag)
+}
+
+// apply brings every value to its desired state, rolling back the applied changes on failure or cancellation
+func apply(log log.T, editor registryEditor, desiredValues []desiredValue, cancelFlag task.CancelFlag) ([]RegistryChange, error) {
+	var applied []appliedChange
+	changes := make([]RegistryChange, 0, len(desiredValues))
+	for _, desired := range desiredValues {
+		if cancelFlag.Canceled() {
+			log.Info("Registry configuration cancelled, rolling back")
+			return changes, rollback(log, editor, applied, changes)
+		}
+
+		change, err := applyValue(editor, desired, len(changes), &applied)
+		if err != nil {
+			err = fmt.Errorf("failed to configure registry value %v\\%v: %v", desired.input.Path, desired.input.Name, err)
+			log.Errorf("%v, rolling back", err)
+			if rollbackErr := rollback(log, editor, applied, changes); rollbackErr != nil {
+				err = fmt.Errorf("%v; %v", err, rollbackErr)
+			}
+			return changes, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// applyValue brings a single value to its desired state and records the prior state in applied, index being
+// the position of the change in the reported changes
+func applyValue(editor registryEditor, desired desiredValue, index int, applied *[]appliedChange) (RegistryChange, error) {
+	change := RegistryChange{Path: desired.input.Path, Name: desired.input.Name}
+	current, exists, err := editor.GetValue(desired.hive, desired.key, desired.input.Name)
+	if err != nil {
+		return change, err
+	}
+	if exists {
+		change.PreviousValue = current.data()
+	}
+
+	var createdKey string
+	switch {
+	case desired.absent && !exists, !desired.absent && exists && current.equal(desired.value):
+		change.Action = actionUnchanged
+		return change, nil
+	case desired.absent:
+		err = editor.DeleteValue(desired.hive, desired.key, desired.input.Name)
+		change.Action = actionDeleted
+	default:
+		createdKey, err = editor.SetValue(desired.hive, desired.key, desired.input.Name, desired.value)
+		change.Value = desired.value.data()
+		change.Action = actionCreated
+		if exists {
+			change.Action = actionUpdated
+		}
+	}
+	if err != nil {
+		return change, err
+	}
+	*applied = append(*applied, appliedChange{desired: desired, existed: exists, previous: current, createdKey: createdKey, index: index})
+	return change, nil
+}
+
+// rollback restores the prior state of the applied changes in reverse order, deleting the keys they created, and
+// returns an error when some of them could not be restored
+func rollback(log log.T, editor registryEditor, applied []appliedChange, changes []RegistryChange) error {
+	failed := 0
+	for i := len(applied) - 1; i >= 0; i-- {
+		change := applied[i]
+		var err error
+		switch {
+		case change.createdKey != "":
+			err = editor.DeleteKey(change.desired.hive, change.createdKey)
+		case change.existed:
+			_, err = editor.SetValue(change.desired.hive, change.desired.key, change.desired.input.Name, change.previous)
+		default:
+			err = editor.DeleteValue(change.desired.hive, change.desired.key, change.desired.input.Name)
+		}
+		if err != nil {
+			log.Errorf("Failed to roll back registry value %v\\%v: %v", change.desired.input.Path, change.desired.input.Name, err)
+			changes[change.index].Action = actionRollbackFailed
+			changes[change.index].Error = err.Error()
+			failed++
+			continue
+		}
+		changes[change.index].Action = actionRolledBack
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to roll back %v of %v registry changes", failed, len(applied))
+	}
+	return nil
+}
+
+// validate checks the hive, key and type of a declared value and converts its data
+func validate(input RegistryValueInput) (desired desiredValue, err error) {
+	desired.input = input
+	path := strings.Trim(input.Path, "\\")
+	hiveName, key, _ := strings.Cut(path, "\\")
+	var ok bool
+	if desired.hive, ok = hives[strings.ToUpper(hiveName)]; !ok {
+		return desired, fmt.Errorf("unsupported hive %v", hiveName)
+	}
+	if key == "" || strings.Contains(key, "\\\\") {
+		return desired, fmt.Errorf("invalid key path %v", input.Path)
+	}
+	desired.key = key
+
+	switch input.Ensure {
+	case "", ensurePresent:
+	case ensureAbsent:
+		desired.absent = true
+		return desired, nil
+	default:
+		return desired, fmt.Errorf("Ensure must be %v or %v", ensurePresent, ensureAbsent)
+	}
+
+	desired.value, err = parseValue(input.Type, input.Value)
+	return desired, err
+}
+
+// parseValue converts the document data to a registry value of the given type
+func parseValue(valueType string, data interface{}) (value registryValue, err error) {
+	value.Type = valueType
+	switch valueType {
+	case typeString, typeExpandString:
+		if value.String, err = toString(data); err != nil {
+			return value, err
+		}
+	case typeMultiString:
+		items, ok := data.([]interface{})
+		if !ok {
+			return value, fmt.Errorf("%v value must be a list of strings", valueType)
+		}
+		value.Strings = make([]string, 0, len(items))
+		for _, item := range items {
+			text, ok := item.(string)
+			if !ok {
+				return value, fmt.Errorf("%v value must be a list of strings", valueType)
+			}
+			value.Strings = append(value.Strings, text)
+		}
+	case typeDWord, typeQWord:
+		bitSize := 64
+		if valueType == typeDWord {
+			bitSize = 32
+		}
+		text, err := toString(data)
+		if err != nil {
+			return value, err
+		}
+		if value.Integer, err = strconv.ParseUint(text, 0, bitSize); err != nil {
+			return value, fmt.Errorf("%v is not a valid %v", text, valueType)
+		}
+	case typeBinary:
+		text, err := toString(data)
+		if err != nil {
+			return value, err
+		}
+		if value.Binary, err = hex.DecodeString(text); err != nil {
+			return value, fmt.Errorf("%v value must be hex encoded", valueType)
+		}
+	default:
+		return value, fmt.Errorf("unsupported type %v", valueType)
+	}
+	return value, nil
+}
+
+// toString returns the document data as a string, formatting numbers without exponent
+func toString(data interface{}) (string, error) {
+	switch v := data.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%v is not a valid value", data)
+	}
+}
+
+// equal returns true if both values have the same type and data
+func (value registryValue) equal(other registryValue) bool {
+	if value.Type != other.Type || value.String != other.String || value.Integer != other.Integer ||
+		len(value.Strings) != len(other.Strings) || !bytes.Equal(value.Binary, other.Binary) {
+		return false
+	}
+	for i := range value.Strings {
+		if value.Strings[i] != other.Strings[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// data returns the value in the representation used by the document
+func (value registryValue) data() interface{} {
+	switch value.Type {
+	case typeMultiString:
+		return value.Strings
+	case typeDWord, typeQWord:
+		return value.Integer
+	case typeBinary:
+		return hex.EncodeToString(value.Binary)
+	default:
+		return value.String
+	}
+}
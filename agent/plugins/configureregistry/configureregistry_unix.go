@@ -0,0 +1,46 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package configureregistry
+
+import (
+	"fmt"
+)
+
+var errRegistryNotSupported = fmt.Errorf("the registry is only available on Windows")
+
+// unsupportedRegistryEditor fails every operation since the registry does not exist on this platform
+type unsupportedRegistryEditor struct{}
+
+func newRegistryEditor() registryEditor {
+	return unsupportedRegistryEditor{}
+}
+
+func (unsupportedRegistryEditor) GetValue(hive string, key string, name string) (registryValue, bool, error) {
+	return registryValue{}, false, errRegistryNotSupported
+}
+
+func (unsupportedRegistryEditor) SetValue(hive string, key string, name string, value registryValue) (string, error) {
+	return "", errRegistryNotSupported
+}
+
+func (unsupportedRegistryEditor) DeleteValue(hive string, key string, name string) error {
+	return errRegistryNotSupported
+}
+
+func (unsupportedRegistryEditor) DeleteKey(hive string, key string) error {
+	return errRegistryNotSupported
+}
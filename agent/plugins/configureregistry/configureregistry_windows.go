@@ -0,0 +1,173 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package configureregistry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+var hiveKeys = map[string]registry.Key{
+	"HKEY_LOCAL_MACHINE":  registry.LOCAL_MACHINE,
+	"HKEY_CURRENT_USER":   registry.CURRENT_USER,
+	"HKEY_USERS":          registry.USERS,
+	"HKEY_CLASSES_ROOT":   registry.CLASSES_ROOT,
+	"HKEY_CURRENT_CONFIG": registry.CURRENT_CONFIG,
+}
+
+// windowsRegistryEditor edits the registry of the instance
+type windowsRegistryEditor struct{}
+
+func newRegistryEditor() registryEditor {
+	return windowsRegistryEditor{}
+}
+
+// GetValue reads a value, reporting it as missing when either the key or the value does not exist
+func (windowsRegistryEditor) GetValue(hive string, key string, name string) (value registryValue, exists bool, err error) {
+	k, err := registry.OpenKey(hiveKeys[hive], key, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return value, false, nil
+	} else if err != nil {
+		return value, false, err
+	}
+	defer k.Close()
+
+	_, valueType, err := k.GetValue(name, nil)
+	if errors.Is(err, registry.ErrNotExist) {
+		return value, false, nil
+	} else if err != nil {
+		return value, false, err
+	}
+	switch valueType {
+	case registry.SZ:
+		value.Type = typeString
+		value.String, _, err = k.GetStringValue(name)
+	case registry.EXPAND_SZ:
+		value.Type = typeExpandString
+		value.String, _, err = k.GetStringValue(name)
+	case registry.MULTI_SZ:
+		value.Type = typeMultiString
+		value.Strings, _, err = k.GetStringsValue(name)
+	case registry.DWORD:
+		value.Type = typeDWord
+		value.Integer, _, err = k.GetIntegerValue(name)
+	case registry.QWORD:
+		value.Type = typeQWord
+		value.Integer, _, err = k.GetIntegerValue(name)
+	case registry.BINARY:
+		value.Type = typeBinary
+		value.Binary, _, err = k.GetBinaryValue(name)
+	default:
+		err = fmt.Errorf("existing value has unsupported registry type %v", valueType)
+	}
+	return value, err == nil, err
+}
+
+// SetValue writes a value, creating the key if needed, and returns the topmost key it created. The created keys
+// are deleted again when the value cannot be written.
+func (e windowsRegistryEditor) SetValue(hive string, key string, name string, value registryValue) (createdKey string, err error) {
+	if createdKey, err = firstMissingKey(hiveKeys[hive], key); err != nil {
+		return "", err
+	}
+	k, _, err := registry.CreateKey(hiveKeys[hive], key, registry.SET_VALUE)
+	if err != nil {
+		return "", err
+	}
+	err = setValue(k, name, value)
+	k.Close()
+	if err != nil && createdKey != "" {
+		e.DeleteKey(hive, createdKey)
+		return "", err
+	}
+	return createdKey, err
+}
+
+// setValue writes the value with the registry type matching its type
+func setValue(k registry.Key, name string, value registryValue) error {
+	switch value.Type {
+	case typeString:
+		return k.SetStringValue(name, value.String)
+	case typeExpandString:
+		return k.SetExpandStringValue(name, value.String)
+	case typeMultiString:
+		return k.SetStringsValue(name, value.Strings)
+	case typeDWord:
+		return k.SetDWordValue(name, uint32(value.Integer))
+	case typeQWord:
+		return k.SetQWordValue(name, value.Integer)
+	case typeBinary:
+		return k.SetBinaryValue(name, value.Binary)
+	default:
+		return fmt.Errorf("unsupported type %v", value.Type)
+	}
+}
+
+// firstMissingKey returns the shortest path prefix of key that does not exist, or an empty string if key exists
+func firstMissingKey(hive registry.Key, key string) (string, error) {
+	parts := strings.Split(key, "\\")
+	for i := range parts {
+		prefix := strings.Join(parts[:i+1], "\\")
+		k, err := registry.OpenKey(hive, prefix, registry.QUERY_VALUE)
+		if errors.Is(err, registry.ErrNotExist) {
+			return prefix, nil
+		} else if err != nil {
+			return "", err
+		}
+		k.Close()
+	}
+	return "", nil
+}
+
+// DeleteValue deletes a value, ignoring values that do not exist
+func (windowsRegistryEditor) DeleteValue(hive string, key string, name string) error {
+	k, err := registry.OpenKey(hiveKeys[hive], key, registry.SET_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer k.Close()
+
+	if err = k.DeleteValue(name); errors.Is(err, registry.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// DeleteKey deletes a key with its subkeys and values, ignoring keys that do not exist
+func (e windowsRegistryEditor) DeleteKey(hive string, key string) error {
+	k, err := registry.OpenKey(hiveKeys[hive], key, registry.ENUMERATE_SUB_KEYS)
+	if errors.Is(err, registry.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	subKeys, err := k.ReadSubKeyNames(-1)
+	k.Close()
+	if err != nil {
+		return err
+	}
+	for _, subKey := range subKeys {
+		if err = e.DeleteKey(hive, key+"\\"+subKey); err != nil {
+			return err
+		}
+	}
+	return registry.DeleteKey(hiveKeys[hive], key)
+}
@@ -0,0 +1,210 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package configureregistry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/windows/registry"
+)
+
+// failingRegistryEditor delegates to the registry but fails the write of the given value name and the deletion
+// of the given undeletable value name
+type failingRegistryEditor struct {
+	windowsRegistryEditor
+	failingName     string
+	undeletableName string
+}
+
+func (e failingRegistryEditor) SetValue(hive string, key string, name string, value registryValue) (string, error) {
+	if name == e.failingName {
+		return "", errors.New("access denied")
+	}
+	return e.windowsRegistryEditor.SetValue(hive, key, name, value)
+}
+
+func (e failingRegistryEditor) DeleteValue(hive string, key string, name string) error {
+	if name == e.undeletableName {
+		return errors.New("value is locked")
+	}
+	return e.windowsRegistryEditor.DeleteValue(hive, key, name)
+}
+
+// newTestKey creates a key under HKCU that is deleted at the end of the test and returns its path
+func newTestKey(t *testing.T) (string, registry.Key) {
+	key := fmt.Sprintf("Software\\AmazonSSMAgentTest\\%v", time.Now().UnixNano())
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, key, registry.ALL_ACCESS)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		k.Close()
+		registry.DeleteKey(registry.CURRENT_USER, key)
+	})
+	return "HKCU\\" + key, k
+}
+
+func executePlugin(t *testing.T, editor registryEditor, values ...map[string]interface{}) (iohandler.IOHandler, []RegistryChange) {
+	p := &Plugin{context: context.NewMockDefault(), editor: editor}
+	output := iohandler.NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{})
+	properties := map[string]interface{}{"values": values}
+
+	p.Execute(contracts.Configuration{Properties: properties}, task.NewChanneledCancelFlag(), output)
+
+	changes, _ := output.GetOutput().([]RegistryChange)
+	return output, changes
+}
+
+func TestExecute_CreateValues(t *testing.T) {
+	path, k := newTestKey(t)
+
+	output, changes := executePlugin(t, windowsRegistryEditor{},
+		map[string]interface{}{"path": path + "\\Sub", "name": "Text", "type": "String", "value": "hello"},
+		map[string]interface{}{"path": path, "name": "Count", "type": "DWord", "value": 42},
+		map[string]interface{}{"path": path, "name": "List", "type": "MultiString", "value": []interface{}{"a", "b"}})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Len(t, changes, 3)
+	for _, change := range changes {
+		assert.Equal(t, actionCreated, change.Action)
+	}
+	count, valueType, err := k.GetIntegerValue("Count")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), count)
+	assert.Equal(t, uint32(registry.DWORD), valueType)
+	list, _, err := k.GetStringsValue("List")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, list)
+}
+
+func TestExecute_UpdateValue(t *testing.T) {
+	path, k := newTestKey(t)
+	assert.NoError(t, k.SetStringValue("Text", "old"))
+
+	output, changes := executePlugin(t, windowsRegistryEditor{},
+		map[string]interface{}{"path": path, "name": "Text", "type": "String", "value": "new"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, actionUpdated, changes[0].Action)
+	assert.Equal(t, "old", changes[0].PreviousValue)
+	text, _, _ := k.GetStringValue("Text")
+	assert.Equal(t, "new", text)
+}
+
+func TestExecute_NoOp(t *testing.T) {
+	path, k := newTestKey(t)
+	assert.NoError(t, k.SetQWordValue("Big", 1<<40))
+
+	output, changes := executePlugin(t, windowsRegistryEditor{},
+		map[string]interface{}{"path": path, "name": "Big", "type": "QWord", "value": "0x10000000000"},
+		map[string]interface{}{"path": path, "name": "Missing", "ensure": "Absent"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, actionUnchanged, changes[0].Action)
+	assert.Equal(t, actionUnchanged, changes[1].Action)
+}
+
+func TestExecute_DeleteValue(t *testing.T) {
+	path, k := newTestKey(t)
+	assert.NoError(t, k.SetBinaryValue("Blob", []byte{0xca, 0xfe}))
+
+	output, changes := executePlugin(t, windowsRegistryEditor{},
+		map[string]interface{}{"path": path, "name": "Blob", "ensure": "Absent"})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, actionDeleted, changes[0].Action)
+	assert.Equal(t, "cafe", changes[0].PreviousValue)
+	_, _, err := k.GetBinaryValue("Blob")
+	assert.ErrorIs(t, err, registry.ErrNotExist)
+}
+
+func TestExecute_RollbackOnFailure(t *testing.T) {
+	path, k := newTestKey(t)
+	assert.NoError(t, k.SetStringValue("Existing", "before"))
+	assert.NoError(t, k.SetDWordValue("Removed", 7))
+
+	output, changes := executePlugin(t, failingRegistryEditor{failingName: "Failing"},
+		map[string]interface{}{"path": path, "name": "Existing", "type": "String", "value": "after"},
+		map[string]interface{}{"path": path, "name": "Created", "type": "ExpandString", "value": "%TEMP%"},
+		map[string]interface{}{"path": path, "name": "Removed", "ensure": "Absent"},
+		map[string]interface{}{"path": path, "name": "Failing", "type": "String", "value": "x"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "access denied")
+	assert.Len(t, changes, 3)
+	for _, change := range changes {
+		assert.Equal(t, actionRolledBack, change.Action)
+	}
+	existing, _, _ := k.GetStringValue("Existing")
+	assert.Equal(t, "before", existing)
+	_, _, err := k.GetStringValue("Created")
+	assert.ErrorIs(t, err, registry.ErrNotExist)
+	removed, _, _ := k.GetIntegerValue("Removed")
+	assert.Equal(t, uint64(7), removed)
+}
+
+func TestExecute_RollbackDeletesCreatedKeys(t *testing.T) {
+	path, k := newTestKey(t)
+
+	output, changes := executePlugin(t, failingRegistryEditor{failingName: "Failing"},
+		map[string]interface{}{"path": path + "\\Created\\Nested", "name": "Text", "type": "String", "value": "x"},
+		map[string]interface{}{"path": path, "name": "Failing", "type": "String", "value": "x"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Equal(t, actionRolledBack, changes[0].Action)
+	subKeys, err := k.ReadSubKeyNames(-1)
+	assert.NoError(t, err)
+	assert.Empty(t, subKeys)
+}
+
+func TestExecute_ReportsRollbackFailure(t *testing.T) {
+	path, k := newTestKey(t)
+	assert.NoError(t, k.SetStringValue("Existing", "before"))
+
+	output, changes := executePlugin(t, failingRegistryEditor{failingName: "Failing", undeletableName: "Created"},
+		map[string]interface{}{"path": path, "name": "Existing", "type": "String", "value": "after"},
+		map[string]interface{}{"path": path, "name": "Created", "type": "String", "value": "x"},
+		map[string]interface{}{"path": path, "name": "Failing", "type": "String", "value": "x"})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "failed to roll back 1 of 2 registry changes")
+	assert.Equal(t, actionRolledBack, changes[0].Action)
+	assert.Equal(t, actionRollbackFailed, changes[1].Action)
+	assert.Equal(t, "value is locked", changes[1].Error)
+	existing, _, _ := k.GetStringValue("Existing")
+	assert.Equal(t, "before", existing)
+}
+
+func TestExecute_InvalidInput(t *testing.T) {
+	path, _ := newTestKey(t)
+	for _, value := range []map[string]interface{}{
+		{"path": "HKXX\\Software\\Example", "name": "Text", "type": "String", "value": "x"},
+		{"path": "HKLM", "name": "Text", "type": "String", "value": "x"},
+		{"path": path, "name": "Text", "type": "None", "value": "x"},
+		{"path": path, "name": "Count", "type": "DWord", "value": "0x100000000"},
+		{"path": path, "name": "Text", "ensure": "Maybe"},
+	} {
+		output, _ := executePlugin(t, windowsRegistryEditor{}, value)
+		assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus(), "%v", value)
+	}
+}
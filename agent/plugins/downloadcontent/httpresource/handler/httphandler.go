@@ -22,8 +22,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strings"
 
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/httpresource/handler/auth/digest"
@@ -98,26 +100,44 @@ func (handler *httpHandler) Download(log log.T, fileSystem filemanager.FileSyste
 		return "", fmt.Errorf("Failed to prepare the request: %s", err.Error())
 	}
 
-	out, err := fileSystem.CreateFile(downloadPath)
-	if err != nil {
-		return "", fmt.Errorf("Cannot create destinaton file: %s", err.Error())
-	}
-	defer out.Close()
-
 	contentReader, err := handler.requestContent(request)
 	if err != nil {
 		return "", fmt.Errorf("Failed to download file: %s", err.Error())
 	}
 	defer contentReader.Close()
 
+	// the content is written to a partial file that is only moved to the download path once complete,
+	// so that an interrupted transfer never leaves a truncated file at the download path
+	partialPath := downloadPath + artifact.PartialDownloadSuffix
+	out, err := fileSystem.CreateFile(partialPath)
+	if err != nil {
+		return "", fmt.Errorf("Cannot create destinaton file: %s", err.Error())
+	}
+
 	_, err = ioCopy(out, contentReader)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		deletePartialDownload(log, fileSystem, partialPath)
 		return "", fmt.Errorf("An error occurred during data transfer: %s", err.Error())
 	}
 
+	if _, err = fileSystem.MoveAndRenameFile(filepath.Dir(partialPath), filepath.Base(partialPath), filepath.Dir(downloadPath), filepath.Base(downloadPath)); err != nil {
+		deletePartialDownload(log, fileSystem, partialPath)
+		return "", fmt.Errorf("Cannot move downloaded file to destination: %s", err.Error())
+	}
+
 	return downloadPath, nil
 }
 
+// deletePartialDownload removes the partial file left by an interrupted download
+func deletePartialDownload(log log.T, fileSystem filemanager.FileSystem, partialPath string) {
+	if err := fileSystem.DeleteFile(partialPath); err != nil {
+		log.Warnf("Failed to delete partial download %s: %v", partialPath, err)
+	}
+}
+
 // Validate validates handler's attributes values
 func (handler *httpHandler) Validate() (bool, error) {
 	if strings.ToUpper(handler.url.Scheme) != "HTTP" && !handler.isUsingSecureProtocol() {
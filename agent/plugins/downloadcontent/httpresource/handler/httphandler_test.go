@@ -26,6 +26,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/artifact"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -401,13 +403,20 @@ func TestHttpHandlerImpl_Download(t *testing.T) {
 
 	ioCopy = copyStub
 
-	destPath := os.TempDir()
+	destPath := t.TempDir()
 	fileName := "testFile"
 	destinationFile := filepath.Join(destPath, fileName)
+	partialFile := destinationFile + artifact.PartialDownloadSuffix
 	fileSystemMock := filemock.FileSystemMock{}
-	fileSystemMock.On("CreateFile", destinationFile).Return(&os.File{}, nil)
+	fileSystemMock.On("MoveAndRenameFile", destPath, fileName+artifact.PartialDownloadSuffix, destPath, fileName).Return(true, nil)
 
 	for _, test := range tests {
+		if test.err == nil {
+			out, err := os.Create(partialFile)
+			assert.NoError(t, err)
+			fileSystemMock.On("CreateFile", partialFile).Return(out, nil).Once()
+		}
+
 		var testServer *httptest.Server
 		if test.secureServer {
 			testServer = httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
@@ -443,3 +452,42 @@ func TestHttpHandlerImpl_Download(t *testing.T) {
 	ioCopy = io.Copy
 	fileSystemMock.AssertExpectations(t)
 }
+
+func TestHttpHandlerImpl_DownloadMovesCompleteFileToDestination(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("file content"))
+	}))
+	defer testServer.Close()
+	testURL, _ := url.Parse(testServer.URL)
+	destinationFile := filepath.Join(t.TempDir(), "testFile")
+
+	handler := getHttpHandler(*testServer.Client(), *testURL, false, "", "", "")
+	downloadedFile, err := handler.Download(logMock, filemanager.FileSystemImpl{}, destinationFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, destinationFile, downloadedFile)
+	content, err := os.ReadFile(destinationFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "file content", string(content))
+	assert.NoFileExists(t, destinationFile+artifact.PartialDownloadSuffix)
+}
+
+func TestHttpHandlerImpl_DownloadInterruptedLeavesNoPartialFile(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// announce more content than is sent so that the transfer is cut short
+		res.Header().Set("Content-Length", "1024")
+		res.Write([]byte("partial content"))
+	}))
+	defer testServer.Close()
+	testURL, _ := url.Parse(testServer.URL)
+	destinationFile := filepath.Join(t.TempDir(), "testFile")
+
+	handler := getHttpHandler(*testServer.Client(), *testURL, false, "", "", "")
+	downloadedFile, err := handler.Download(logMock, filemanager.FileSystemImpl{}, destinationFile)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "An error occurred during data transfer")
+	assert.Equal(t, "", downloadedFile)
+	assert.NoFileExists(t, destinationFile)
+	assert.NoFileExists(t, destinationFile+artifact.PartialDownloadSuffix)
+}
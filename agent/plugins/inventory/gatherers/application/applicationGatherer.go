@@ -15,6 +15,8 @@
 package application
 
 import (
+	gocontext "context"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -29,10 +31,14 @@ const (
 )
 
 // T represents application gatherer which implements all contracts for gatherers.
-type T struct{}
+type T struct {
+	stopLock sync.Mutex
+	// stopCollection aborts the package queries of the collection in progress, nil when none is running
+	stopCollection gocontext.CancelFunc
+}
 
 // decoupling for easy testability
-var collectData = CollectApplicationData
+var collectData = CollectApplicationDataWithContext
 
 // Gatherer returns new application gatherer
 func Gatherer(context context.T) *T {
@@ -48,15 +54,37 @@ func (t *T) Name() string {
 func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
 
 	var result model.Item
+	var data []model.ApplicationData
+	var filter *NameFilter
+
+	// the patterns are validated with the plugin input, an invalid pattern only gets here through a corrupted policy
+	if filter, err = NewNameFilter(configuration.IncludeApplications, configuration.ExcludeApplications); err != nil {
+		return nil, err
+	}
 
 	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
 	currentTime := time.Now().UTC()
 	captureTime := currentTime.Format(time.RFC3339)
 
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+	t.setStopCollection(cancel)
+	defer t.setStopCollection(nil)
+
+	// partial application data would replace the complete inventory reported before, so nothing is returned
+	if data, err = collectData(context, ctx); err != nil {
+		return nil, err
+	}
+	// the filter applies to the parsed entries, so that a malformed entry cannot hide the others
+	data = filter.Filter(data)
+	if !configuration.IncludeCollectionDetails {
+		data = WithoutCollectionDetails(data)
+	}
+
 	result = model.Item{
 		Name:          t.Name(),
 		SchemaVersion: SchemaVersionOfApplication,
-		Content:       collectData(context),
+		Content:       data,
 		CaptureTime:   captureTime,
 	}
 
@@ -66,5 +94,16 @@ func (t *T) Run(context context.T, configuration model.Config) (items []model.It
 
 // RequestStop stops the execution of application gatherer.
 func (t *T) RequestStop() error {
+	t.stopLock.Lock()
+	defer t.stopLock.Unlock()
+	if t.stopCollection != nil {
+		t.stopCollection()
+	}
 	return nil
 }
+
+func (t *T) setStopCollection(stopCollection gocontext.CancelFunc) {
+	t.stopLock.Lock()
+	defer t.stopLock.Unlock()
+	t.stopCollection = stopCollection
+}
@@ -15,6 +15,8 @@
 package application
 
 import (
+	gocontext "context"
+	"encoding/json"
 	"testing"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -23,7 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func DataGenerator(context context.T) (appData []model.ApplicationData) {
+func DataGenerator(context context.T, ctx gocontext.Context) (appData []model.ApplicationData, err error) {
 	return []model.ApplicationData{
 		{
 			ApplicationType: "System Environment/Libraries",
@@ -43,7 +45,7 @@ func DataGenerator(context context.T) (appData []model.ApplicationData) {
 			URL:             "(none)",
 			Name:            "basesystem",
 		},
-	}
+	}, nil
 }
 
 func TestGatherer(t *testing.T) {
@@ -56,6 +58,58 @@ func TestGatherer(t *testing.T) {
 	item := items[0]
 	assert.Equal(t, GathererName, item.Name)
 	assert.Equal(t, SchemaVersionOfApplication, item.SchemaVersion)
-	assert.Equal(t, collectData(c), item.Content)
+	expectedData, _ := collectData(c, gocontext.Background())
+	assert.Equal(t, expectedData, item.Content)
 	assert.NotNil(t, item.CaptureTime)
 }
+
+func TestGathererCollectionDetails(t *testing.T) {
+	c := contextmocks.NewMockDefault()
+	g := Gatherer(c)
+	collectData = func(context context.T, ctx gocontext.Context) ([]model.ApplicationData, error) {
+		return []model.ApplicationData{
+			{Name: "nss-softokn", Version: "3.16.2.3", CaptureTime: "2016-07-30T18:15:37Z", Source: "rpm"},
+			{Name: "basesystem", Version: "10.0", CaptureTime: "2016-07-30T18:15:37Z", Source: "rpm"},
+		}, nil
+	}
+	defer func() { collectData = CollectApplicationDataWithContext }()
+
+	items, err := g.Run(c, model.Config{IncludeCollectionDetails: true})
+	assert.NoError(t, err)
+	for _, app := range items[0].Content.([]model.ApplicationData) {
+		assert.Equal(t, "2016-07-30T18:15:37Z", app.CaptureTime)
+		assert.Equal(t, "rpm", app.Source)
+	}
+
+	// the collection details are left out unless enabled, as the AWS:Application schema does not expect them
+	items, err = g.Run(c, model.Config{})
+	assert.NoError(t, err)
+	for _, app := range items[0].Content.([]model.ApplicationData) {
+		assert.Empty(t, app.CaptureTime)
+		assert.Empty(t, app.Source)
+	}
+	content, _ := json.Marshal(items[0].Content)
+	assert.NotContains(t, string(content), "CaptureTime")
+	assert.NotContains(t, string(content), "Source")
+}
+
+func TestGathererRequestStopAbortsCollection(t *testing.T) {
+	c := contextmocks.NewMockDefault()
+	g := Gatherer(c)
+	collectionStarted := make(chan struct{})
+	collectData = func(context context.T, ctx gocontext.Context) ([]model.ApplicationData, error) {
+		close(collectionStarted)
+		<-ctx.Done()
+		return nil, collectorAbortedError("package query", ctx.Err())
+	}
+	defer func() { collectData = CollectApplicationDataWithContext }()
+
+	go func() {
+		<-collectionStarted
+		assert.NoError(t, g.RequestStop())
+	}()
+	items, err := g.Run(c, model.Config{})
+
+	assert.True(t, model.IsCollectionAborted(err))
+	assert.Empty(t, items)
+}
@@ -1,8 +1,10 @@
 package application
 
 import (
+	gocontext "context"
 	"errors"
 	"strings"
+	"time"
 
 	"fmt"
 
@@ -27,6 +29,9 @@ const (
 var selectAwsApps map[string]string
 var ApplicationData []model.ApplicationData
 
+// collectorTimeout bounds how long a single package query may run before it is aborted
+var collectorTimeout = 15 * time.Minute
+
 func init() {
 	//NOTE:
 	// For V1 - to filter out aws components from aws applications - we are using a list of all aws components that
@@ -58,16 +63,65 @@ func componentType(applicationName string) model.ComponentType {
 
 // CollectApplicationData collects all application data from the system using platform specific queries and merges in applications installed via configurePackage
 func CollectApplicationData(context context.T) (appData []model.ApplicationData) {
+	appData, _ = CollectApplicationDataWithContext(context, gocontext.Background())
+	return
+}
+
+// CollectApplicationDataWithContext collects all application data like CollectApplicationData, aborting the package
+// queries once ctx is done. The error lists the queries that were aborted, the data of the others is still returned
+// but it is not cached since it is incomplete.
+func CollectApplicationDataWithContext(context context.T, ctx gocontext.Context) (appData []model.ApplicationData, err error) {
 	if len(ApplicationData) > 0 {
-		return ApplicationData
+		return ApplicationData, nil
+	}
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	captureTime := time.Now().UTC().Format(time.RFC3339)
+	appData, err = collectPlatformDependentApplicationData(context, ctx)
+	for i := range appData {
+		appData[i].CaptureTime = captureTime
+	}
+	if err != nil {
+		return appData, err
+	}
+	ApplicationData = appData
+	return ApplicationData, nil
+}
+
+// WithoutCollectionDetails returns a copy of the application data without the CaptureTime and Source of the entries,
+// which the AWS:Application schema only accepts when the collection details are enabled
+func WithoutCollectionDetails(appData []model.ApplicationData) []model.ApplicationData {
+	if appData == nil {
+		return nil
+	}
+	data := make([]model.ApplicationData, len(appData))
+	for i, app := range appData {
+		app.CaptureTime = ""
+		app.Source = ""
+		data[i] = app
+	}
+	return data
+}
+
+// setSource records the package manager or query that found the applications
+func setSource(appData []model.ApplicationData, source string) {
+	for i := range appData {
+		appData[i].Source = source
+	}
+}
+
+// collectorAbortedError describes a package query that was stopped before it completed.
+// The context error is wrapped so that model.IsCollectionAborted recognizes it.
+func collectorAbortedError(command string, ctxErr error) error {
+	if errors.Is(ctxErr, gocontext.DeadlineExceeded) {
+		return fmt.Errorf("%v did not complete within %v and was aborted: %w", command, collectorTimeout, ctxErr)
 	}
-	ApplicationData = collectPlatformDependentApplicationData(context)
-	return ApplicationData
+	return fmt.Errorf("%v was aborted because the inventory collection was stopped: %w", command, ctxErr)
 }
 
 // cleanupJSONField converts a text to a json friendly text as follows:
 // - converts multi-line fields to single line by removing all but the first line
 // - escapes special characters
+// - removes the remaining control characters, such as stray carriage returns or NUL bytes
 // - truncates remaining line to length no more than maxSummaryLength
 func cleanupJSONField(field string) string {
 	res := field
@@ -78,7 +132,7 @@ func cleanupJSONField(field string) string {
 	res = strings.Replace(res, `\`, `\\`, -1)
 	res = strings.Replace(res, `"`, `\"`, -1)
 	res = strings.Replace(res, "\t", `\t`, -1)
-	res = strings.Replace(res, "\x0D", "", -1)
+	res = stripCtlFromUTF8(res)
 	if len(res) > maxSummaryLength {
 		res = res[0:maxSummaryLength]
 	}
@@ -18,7 +18,9 @@
 package application
 
 import (
+	gocontext "context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strconv"
@@ -52,11 +54,19 @@ var (
 	packageInsTimeKey = "install-time"
 )
 
+// sources reported for the collected applications
+const (
+	systemProfilerSource = "system_profiler"
+	pkgutilSource        = "pkgutil"
+)
+
 // decoupling exec.Command for easy testability
 var cmdExecutor = executeCommand
 
-func executeCommand(command string, args ...string) ([]byte, error) {
-	return exec.Command(command, args...).CombinedOutput()
+func executeCommand(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.WaitDelay = time.Second
+	return cmd.CombinedOutput()
 }
 
 func platformInfoProvider(log log.T) (name string, err error) {
@@ -64,20 +74,30 @@ func platformInfoProvider(log log.T) (name string, err error) {
 }
 
 // collectPlatformDependentApplicationData collects all application data from the system using system_profiler command.
-func collectPlatformDependentApplicationData(context context.T) (appData []model.ApplicationData) {
+func collectPlatformDependentApplicationData(context context.T, ctx gocontext.Context) (appData []model.ApplicationData, collectErr error) {
 
 	var err error
+	var abortErrs []error
 	log := context.Log()
 
 	cmd := systemProfilerCmd
 	args := []string{xmlFormatArg, applicationDataArg}
 
-	if appData, err = getApplicationData(context, cmd, args); err != nil {
+	if appData, err = getApplicationData(context, ctx, cmd, args); err != nil {
 		log.Info("system_profiler command failed!")
+		if model.IsCollectionAborted(err) {
+			abortErrs = append(abortErrs, err)
+		}
 	}
+	setSource(appData, systemProfilerSource)
 
-	pkgData, err := getInstalledPackages(context, pkgutilCmd)
+	pkgData, err := getInstalledPackages(context, ctx, pkgutilCmd)
+	if model.IsCollectionAborted(err) {
+		abortErrs = append(abortErrs, err)
+	}
+	collectErr = errors.Join(abortErrs...)
 	if err == nil {
+		setSource(pkgData, pkgutilSource)
 		var i int
 		for i = 0; i < len(pkgData); i++ {
 			appData = append(appData, pkgData[i])
@@ -88,11 +108,15 @@ func collectPlatformDependentApplicationData(context context.T) (appData []model
 	return
 }
 
-func getInstalledPackages(context context.T, command string) (data []model.ApplicationData, err error) {
+func getInstalledPackages(context context.T, ctx gocontext.Context, command string) (data []model.ApplicationData, err error) {
 	log := context.Log()
 	var output []byte
 	log.Debugf("Executing command: %v", command)
-	if output, err = cmdExecutor("bash", "-c", command); err != nil {
+	collectorCtx, cancel := gocontext.WithTimeout(ctx, collectorTimeout)
+	defer cancel()
+	if output, err = cmdExecutor(collectorCtx, "bash", "-c", command); err != nil && collectorCtx.Err() != nil {
+		err = collectorAbortedError(command, collectorCtx.Err())
+	} else if err != nil {
 		log.Errorf("Failed to execute command : %v with error - %v",
 			command,
 			err.Error())
@@ -163,13 +187,17 @@ func convertToApplicationDataFromInstalledPkg(input string) (data []model.Applic
 }
 
 // getApplicationData runs a terminal command and gets information about all packages/applications
-func getApplicationData(context context.T, command string, args []string) (data []model.ApplicationData, err error) {
+func getApplicationData(context context.T, ctx gocontext.Context, command string, args []string) (data []model.ApplicationData, err error) {
 	var output []byte
 	log := context.Log()
 
 	log.Debugf("Executing command: %v %v", command, args)
 
-	if output, err = cmdExecutor(command, args...); err != nil {
+	collectorCtx, cancel := gocontext.WithTimeout(ctx, collectorTimeout)
+	defer cancel()
+	if output, err = cmdExecutor(collectorCtx, command, args...); err != nil && collectorCtx.Err() != nil {
+		err = collectorAbortedError(command, collectorCtx.Err())
+	} else if err != nil {
 		log.Errorf("Failed to execute command : %v %v with error - %v",
 			command,
 			args,
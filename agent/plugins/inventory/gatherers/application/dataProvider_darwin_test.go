@@ -18,6 +18,7 @@
 package application
 
 import (
+	gocontext "context"
 	"fmt"
 	"testing"
 
@@ -250,12 +251,12 @@ var sampleDataPackagesParsed = []model.ApplicationData{
 
 var unexpectedSampleDataParsed = []model.ApplicationData{}
 
-func MockTestExecutorWithError(command string, args ...string) ([]byte, error) {
+func MockTestExecutorWithError(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
 	var result []byte
 	return result, fmt.Errorf("random error")
 }
 
-func MockTestExecutorWithoutError(command string, args ...string) ([]byte, error) {
+func MockTestExecutorWithoutError(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
 	return []byte(sampleData), nil
 }
 
@@ -285,17 +286,17 @@ func TestGetApplicationData(t *testing.T) {
 	//testing with error
 	cmdExecutor = MockTestExecutorWithError
 
-	data, err = getApplicationData(mockContext, mockCommand, mockArgs)
+	data, err = getApplicationData(mockContext, gocontext.Background(), mockCommand, mockArgs)
 
 	assert.NotNil(t, err, "Error must be thrown when command execution fails")
 	assert.Equal(t, 0, len(data), "When command execution fails - application dataset must be empty")
 
 	//testing without error
-	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+	cmdExecutor = func(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
 		return []byte(applicationSampleDataWrapper), nil
 	}
 
-	data, err = getApplicationData(mockContext, mockCommand, mockArgs)
+	data, err = getApplicationData(mockContext, gocontext.Background(), mockCommand, mockArgs)
 
 	assert.Nil(t, err, "Error must not be thrown with MockTestExecutorWithoutError")
 	assertEqual(t, sampleDataParsed, data)
@@ -305,16 +306,16 @@ func TestCollectApplicationData(t *testing.T) {
 	mockContext := context.NewMockDefault()
 
 	// sysctl return result without error
-	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+	cmdExecutor = func(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
 		return []byte(applicationSampleDataWrapper), nil
 	}
 
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assertEqual(t, sampleDataParsed, data)
 
 	// sysctl return errors
 	cmdExecutor = MockTestExecutorWithError
-	data = collectPlatformDependentApplicationData(mockContext)
+	data, _ = collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assert.Equal(t, 0, len(data), "When command execution fails - application dataset must be empty")
 }
 
@@ -340,7 +341,7 @@ func TestGetInstalledPackages(t *testing.T) {
 	//testing with error
 	cmdExecutor = MockTestExecutorWithError
 
-	data, err = getInstalledPackages(mockContext, mockCommand)
+	data, err = getInstalledPackages(mockContext, gocontext.Background(), mockCommand)
 
 	assert.NotNil(t, err, "Error must be thrown when command execution fails")
 	assert.Equal(t, 0, len(data), "When command execution fails - application dataset must be empty")
@@ -348,7 +349,7 @@ func TestGetInstalledPackages(t *testing.T) {
 	//testing without error
 	cmdExecutor = MockTestExecutorWithoutError
 
-	data, err = getInstalledPackages(mockContext, mockCommand)
+	data, err = getInstalledPackages(mockContext, gocontext.Background(), mockCommand)
 
 	assert.Nil(t, err, "Error must not be thrown with MockTestExecutorWithoutError")
 	assertEqual(t, sampleDataPackagesParsed, data)
@@ -59,6 +59,8 @@ func TestCleanupJSONField(t *testing.T) {
 		{`\"b` + "\n", `\\\"b`},
 		{"description\non\nmulti\nline", `description`},
 		{"a simple text", `a simple text`},
+		{"java-jdk\r", `java-jdk`},
+		{"1.8.0\x00_292\r\nsecond line", `1.8.0_292`},
 	}
 	for _, test := range inOut {
 		input, output := test[0], test[1]
@@ -18,7 +18,9 @@
 package application
 
 import (
+	gocontext "context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -34,6 +36,14 @@ import (
 	"github.com/twinj/uuid"
 )
 
+// sources reported for the collected applications
+const (
+	dpkgSource          = "dpkg"
+	rpmSource           = "rpm"
+	snapSource          = "snap"
+	inventoryFileSource = "inventory file"
+)
+
 type InventoryApplicationFile struct {
 	Content []model.ApplicationData
 }
@@ -80,8 +90,11 @@ func mark(s string) string {
 var cmdExecutor = executeCommand
 var checkCommandExists = commandExists
 
-func executeCommand(command string, args ...string) ([]byte, error) {
-	return exec.Command(command, args...).CombinedOutput()
+func executeCommand(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	// children of a killed package manager may keep the output open, do not wait for them
+	cmd.WaitDelay = time.Second
+	return cmd.CombinedOutput()
 }
 
 // returns true if the command is available on the instance
@@ -101,11 +114,13 @@ func platformInfoProvider(log log.T) (name string, err error) {
 }
 
 // collectPlatformDependentApplicationData collects all application data from the system using rpm or dpkg query.
-func collectPlatformDependentApplicationData(context context.T) (appData []model.ApplicationData) {
+// Queries that are aborted are reported in the returned error, the data of the other queries is still returned.
+func collectPlatformDependentApplicationData(context context.T, ctx gocontext.Context) (appData []model.ApplicationData, collectErr error) {
 
 	var err error
 	var cmd string
 	var args []string
+	var abortErrs []error
 
 	log := context.Log()
 
@@ -124,6 +139,7 @@ func collectPlatformDependentApplicationData(context context.T) (appData []model
 				log.Errorf("Failed to gather inventory data from inventory file %v: %v", GathererName, err)
 				return
 			}
+			setSource(appData, inventoryFileSource)
 			log.Infof("Used file to gather application")
 			return
 
@@ -138,10 +154,14 @@ func collectPlatformDependentApplicationData(context context.T) (appData []model
 		args = []string{dpkgArgsToGetAllApplications, dpkgQueryFormat}
 		var dpkgAppData []model.ApplicationData
 		log.Infof("Using '%s' to gather application information", cmd)
-		if dpkgAppData, err = getApplicationData(context, cmd, args); err != nil {
+		if dpkgAppData, err = getApplicationData(context, ctx, cmd, args); err != nil {
 			log.Errorf("Failed to gather inventory data for %v: %v", GathererName, err)
+			if model.IsCollectionAborted(err) {
+				abortErrs = append(abortErrs, err)
+			}
 		} else {
 			log.Infof("Found %v dpkg packages", len(dpkgAppData))
+			setSource(dpkgAppData, dpkgSource)
 			appData = append(appData, dpkgAppData...)
 		}
 	}
@@ -152,10 +172,14 @@ func collectPlatformDependentApplicationData(context context.T) (appData []model
 		args = []string{rpmCmdArgToGetAllApplications, rpmQueryFormat, rpmQueryFormatArgs}
 		var rpmAppData []model.ApplicationData
 		log.Infof("Using '%s' to gather application information", cmd)
-		if rpmAppData, err = getApplicationData(context, cmd, args); err != nil {
+		if rpmAppData, err = getApplicationData(context, ctx, cmd, args); err != nil {
 			log.Errorf("Failed to gather inventory data for %v: %v", GathererName, err)
+			if model.IsCollectionAborted(err) {
+				abortErrs = append(abortErrs, err)
+			}
 		} else {
 			log.Infof("Found %v rpm packages", len(rpmAppData))
+			setSource(rpmAppData, rpmSource)
 			appData = append(appData, rpmAppData...)
 		}
 	}
@@ -165,16 +189,21 @@ func collectPlatformDependentApplicationData(context context.T) (appData []model
 		cmd = snapCmd
 		args = []string{snapArgsToGetAllInstalledSnaps}
 		var snapAppData []model.ApplicationData
-		if snapAppData, err = getApplicationData(context, cmd, args); err != nil {
+		if snapAppData, err = getApplicationData(context, ctx, cmd, args); err != nil {
 			log.Errorf("Failed to gather inventory data for %v: %v", GathererName, err)
+			if model.IsCollectionAborted(err) {
+				abortErrs = append(abortErrs, err)
+			}
 		} else {
 			log.Infof("Appending application information found using snap to application data.")
 			log.Infof("Found %v snap packages", len(snapAppData))
+			setSource(snapAppData, snapSource)
 			appData = append(appData, snapAppData...)
 		}
 	}
 
 	log.Infof("Found %v packages in total", len(appData))
+	collectErr = errors.Join(abortErrs...)
 
 	if noPackageManagerFound {
 		log.Errorf("Unable to detect package manager - hence no inventory data for %v", GathererName)
@@ -237,8 +266,9 @@ func getInventoryApplicationFileData(inventoryApplicationFileBytes []byte) (data
 	return
 }
 
-// getApplicationData runs a shell command and gets information about all packages/applications
-func getApplicationData(context context.T, command string, args []string) (data []model.ApplicationData, err error) {
+// getApplicationData runs a shell command and gets information about all packages/applications.
+// The command is killed once ctx is done or collectorTimeout passes.
+func getApplicationData(context context.T, ctx gocontext.Context, command string, args []string) (data []model.ApplicationData, err error) {
 
 	/*
 					Note: Following are samples of how rpm & dpkg stores package information.
@@ -348,7 +378,11 @@ func getApplicationData(context context.T, command string, args []string) (data
 
 	log.Debugf("Executing command: %v %v", command, args)
 
-	if output, err = cmdExecutor(command, args...); err != nil {
+	collectorCtx, cancel := gocontext.WithTimeout(ctx, collectorTimeout)
+	defer cancel()
+	if output, err = cmdExecutor(collectorCtx, command, args...); err != nil && collectorCtx.Err() != nil {
+		err = collectorAbortedError(command, collectorCtx.Err())
+	} else if err != nil {
 		log.Errorf("Failed to execute command : %v %v with error - %v",
 			command,
 			args,
@@ -363,7 +397,7 @@ func getApplicationData(context context.T, command string, args []string) (data
 		}
 		log.Debugf("Command output: %v", cmdOutput)
 
-		if data, err = convertToApplicationData(cmdOutput); err != nil {
+		if data, err = convertToApplicationData(log, cmdOutput); err != nil {
 			err = fmt.Errorf("Unable to convert query output to ApplicationData - %v", err.Error())
 			log.Errorf(err.Error())
 		} else {
@@ -374,8 +408,9 @@ func getApplicationData(context context.T, command string, args []string) (data
 	return
 }
 
-// convertToApplicationData converts query output into json string so that it can be deserialized easily
-func convertToApplicationData(input string) (data []model.ApplicationData, err error) {
+// convertToApplicationData converts query output into json string so that it can be deserialized easily.
+// If the output cannot be deserialized as a whole, the entries are deserialized one by one and the malformed ones are dropped.
+func convertToApplicationData(log log.T, input string) (data []model.ApplicationData, err error) {
 
 	//This implementation is closely tied to the kind of rpm/dpkg query. A change in query MUST be accompanied
 	//with a change in transform logic or else json formatting will be impacted.
@@ -399,13 +434,16 @@ func convertToApplicationData(input string) (data []model.ApplicationData, err e
 
 	str := convertEntriesToJsonArray(input)
 	// keep single line out of multi-line fields and escape special characters
-	str, err = replaceMarkedFields(str, startMarker, endMarker, cleanupJSONField)
+	if str, err = replaceMarkedFields(str, startMarker, endMarker, cleanupJSONField); err == nil {
+		//unmarshal json string accordingly.
+		err = json.Unmarshal([]byte(str), &data)
+	}
 	if err != nil {
-		return
+		log.Warnf("Unable to parse application data as a whole, parsing each application separately - %v", err)
+		data, err = convertEntriesToApplicationData(log, input)
 	}
 
-	//unmarshal json string accordingly.
-	if err = json.Unmarshal([]byte(str), &data); err == nil {
+	if err == nil {
 
 		//transform the date & architecture - by iterating over all elements
 		for i, item := range data {
@@ -450,3 +488,43 @@ func convertToApplicationData(input string) (data []model.ApplicationData, err e
 
 	return
 }
+
+// convertEntriesToApplicationData deserializes each application entry of the query output separately,
+// so that a single malformed entry does not prevent the other applications from being reported
+func convertEntriesToApplicationData(log log.T, input string) (data []model.ApplicationData, err error) {
+	entries := splitApplicationEntries(input)
+	for _, entry := range entries {
+		var str string
+		if str, err = replaceMarkedFields(entry, startMarker, endMarker, cleanupJSONField); err != nil {
+			log.Warnf("Dropping malformed application entry %q - %v", entry, err)
+			continue
+		}
+		var item model.ApplicationData
+		if err = json.Unmarshal([]byte(str), &item); err != nil {
+			log.Warnf("Dropping malformed application entry %q - %v", str, err)
+			continue
+		}
+		data = append(data, item)
+	}
+
+	err = nil
+	if len(entries) > 0 && len(data) == 0 {
+		err = fmt.Errorf("none of the %v application entries could be parsed", len(entries))
+	}
+	return
+}
+
+// splitApplicationEntries splits the query output into one json object per application.
+// Every entry starts with the marked Name field, the random start marker makes the split safe
+// even if other fields contain braces or commas.
+func splitApplicationEntries(input string) (entries []string) {
+	entryPrefix := `{"Name":"` + startMarker
+	for _, entry := range strings.Split(input, entryPrefix) {
+		entry = strings.TrimSuffix(strings.TrimSpace(entry), ",")
+		if entry == "" {
+			continue
+		}
+		entries = append(entries, entryPrefix+entry)
+	}
+	return
+}
@@ -18,8 +18,10 @@
 package application
 
 import (
+	gocontext "context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
@@ -129,24 +131,64 @@ var snapSampleDataParsed = `{"Name":"` + mark(`core`) + `","Publisher":"` + mark
 	`","ApplicationType":"` + mark(`admin`) + `","Architecture":"` + mark(``) + `","Url":"` + mark(``) + `",` +
 	`"Summary":"` + mark(``) + `","PackageId":"` + mark(`pkg:snap/canonical*/core@16-2.43.3`) + `"}`
 
-func MockTestExecutorWithError(command string, args ...string) ([]byte, error) {
+func MockTestExecutorWithError(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
 	var result []byte
 	return result, fmt.Errorf("random error")
 }
 
-func MockTestExecutorWithoutError(command string, args ...string) ([]byte, error) {
+func MockTestExecutorWithoutError(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
 	return []byte(sampleData), nil
 }
 
 var i = 0
 
 func TestConvertToApplicationData(t *testing.T) {
-	data, err := convertToApplicationData(sampleData)
+	data, err := convertToApplicationData(context.NewMockDefault().Log(), sampleData)
 
 	assert.Nil(t, err, "Check conversion logic - since sample data in unit test is tied to implementation")
 	assertEqual(t, sampleDataParsed, data)
 }
 
+func TestConvertToApplicationData_StripsControlCharacters(t *testing.T) {
+	input := `{"Name":"` + mark("java-jdk\r") + `","Version":"` + mark("1.8.0\x00_292\r") + `","Publisher":"` + mark("Amazon.com") +
+		`","Architecture":"` + mark("x86_64") + `","PackageId":"` + mark("java-jdk-1.8.0_292.src.rpm\r") + `"},` +
+		`{"Name":"` + mark("vim-filesystem") + `","Version":"` + mark("8.0.0503") + `","Architecture":"` + mark("x86_64") + `"},`
+
+	data, err := convertToApplicationData(context.NewMockDefault().Log(), input)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, "java-jdk", data[0].Name)
+	assert.Equal(t, "1.8.0_292", data[0].Version)
+	assert.Equal(t, "java-jdk-1.8.0_292.src.rpm", data[0].PackageId)
+	assert.Equal(t, "vim-filesystem", data[1].Name)
+}
+
+func TestConvertToApplicationData_DropsMalformedEntries(t *testing.T) {
+	// the second entry is truncated and cannot be deserialized
+	input := `{"Name":"` + mark("amazon-ssm-agent") + `","Version":"` + mark("3.0.0\r") + `","Architecture":"` + mark("x86_64") + `"},` +
+		`{"Name":"` + mark("java-jdk") + `","Version":"` + mark("1.8.0") + `",` +
+		`{"Name":"` + mark("vim-filesystem") + `","Version":"` + mark("8.0.0503") + `","Architecture":"` + mark("x86_64") + `"},`
+
+	data, err := convertToApplicationData(context.NewMockDefault().Log(), input)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, "amazon-ssm-agent", data[0].Name)
+	assert.Equal(t, "3.0.0", data[0].Version)
+	assert.Equal(t, model.AWSComponent, data[0].CompType)
+	assert.Equal(t, "vim-filesystem", data[1].Name)
+}
+
+func TestConvertToApplicationData_FailsWhenAllEntriesAreMalformed(t *testing.T) {
+	input := `{"Name":"` + mark("java-jdk") + `","Version":"` + mark("1.8.0") + `",`
+
+	data, err := convertToApplicationData(context.NewMockDefault().Log(), input)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(data))
+}
+
 func TestGetApplicationData(t *testing.T) {
 
 	var data []model.ApplicationData
@@ -163,7 +205,7 @@ func TestGetApplicationData(t *testing.T) {
 	//testing with error
 	cmdExecutor = MockTestExecutorWithError
 
-	data, err = getApplicationData(mockContext, mockCommand, mockArgs)
+	data, err = getApplicationData(mockContext, gocontext.Background(), mockCommand, mockArgs)
 
 	assert.NotNil(t, err, "Error must be thrown when command execution fails")
 	assert.Equal(t, 0, len(data), "When command execution fails - application dataset must be empty")
@@ -171,7 +213,7 @@ func TestGetApplicationData(t *testing.T) {
 	//testing without error
 	cmdExecutor = MockTestExecutorWithoutError
 
-	data, err = getApplicationData(mockContext, mockCommand, mockArgs)
+	data, err = getApplicationData(mockContext, gocontext.Background(), mockCommand, mockArgs)
 
 	assert.Nil(t, err, "Error must not be thrown with MockTestExecutorWithoutError")
 	assertEqual(t, sampleDataParsed, data)
@@ -198,10 +240,37 @@ func TestCollectApplicationData_CollectsBothRpmAndDpkgPackages(t *testing.T) {
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithoutError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assertEqual(t, append(sampleDataParsed, sampleDataParsed...), data)
 }
 
+func TestCollectApplicationData_SetsSourceAndCaptureTimeOncePerCollection(t *testing.T) {
+	mockContext := context.NewMockDefault()
+	oldCheckCmd, oldCmdExecutor, oldApplicationData := checkCommandExists, cmdExecutor, ApplicationData
+	defer func() {
+		checkCommandExists, cmdExecutor, ApplicationData = oldCheckCmd, oldCmdExecutor, oldApplicationData
+	}()
+
+	ApplicationData = nil
+	checkCommandExists = func(cmd string) bool { return cmd == dpkgCmd || cmd == rpmCmd }
+	cmdExecutor = MockTestExecutorWithoutError
+	data, err := CollectApplicationDataWithContext(mockContext, gocontext.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2*len(sampleDataParsed), len(data))
+	_, err = time.Parse(time.RFC3339, data[0].CaptureTime)
+	assert.NoError(t, err)
+	for i, app := range data {
+		// every entry of a collection pass has the same capture time
+		assert.Equal(t, data[0].CaptureTime, app.CaptureTime)
+		if i < len(sampleDataParsed) {
+			assert.Equal(t, dpkgSource, app.Source)
+		} else {
+			assert.Equal(t, rpmSource, app.Source)
+		}
+	}
+}
+
 func TestCollectApplicationData_CollectsOnlyDpkgPackages(t *testing.T) {
 	mockContext := context.NewMockDefault()
 	oldCheckCmd := checkCommandExists
@@ -214,7 +283,7 @@ func TestCollectApplicationData_CollectsOnlyDpkgPackages(t *testing.T) {
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithoutError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assertEqual(t, sampleDataParsed, data)
 }
 
@@ -230,7 +299,7 @@ func TestCollectApplicationData_CollectsOnlyRpmPackages(t *testing.T) {
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithoutError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assertEqual(t, sampleDataParsed, data)
 }
 
@@ -246,7 +315,7 @@ func TestCollectApplicationData_CollectsNeitherRpmNorDpkgPackages(t *testing.T)
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithoutError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assert.Equal(t, 0, len(data), "when no package managers are found - application dataset must be empty")
 }
 
@@ -262,7 +331,7 @@ func TestCollectApplicationData_FindsRpmButEncountersExecutorError(t *testing.T)
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assert.Equal(t, 0, len(data), "When command execution fails - application dataset must be empty")
 }
 
@@ -284,7 +353,7 @@ func TestCollectApplicationData_FindsDpkgAndRpmButEncountersExecutorErrorWithDpk
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithoutError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assertEqual(t, sampleDataParsed, data)
 }
 
@@ -306,7 +375,7 @@ func TestCollectApplicationData_FindsDpkgAndRpmButEncountersExecutorErrorWithRpm
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithoutError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assertEqual(t, sampleDataParsed, data)
 }
 
@@ -323,6 +392,75 @@ func TestCollectApplicationData_FindsDpkgAndRpmButEncountersExecutorErrorWithAll
 		return retVal
 	}
 	cmdExecutor = MockTestExecutorWithError
-	data := collectPlatformDependentApplicationData(mockContext)
+	data, _ := collectPlatformDependentApplicationData(mockContext, gocontext.Background())
 	assert.Equal(t, 0, len(data), "When command execution fails - application dataset must be empty")
 }
+
+func blockingExecutor(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("signal: killed")
+}
+
+func TestGetApplicationData_AbortsHungCommandAfterTimeout(t *testing.T) {
+	oldTimeout := collectorTimeout
+	defer func() { collectorTimeout = oldTimeout }()
+	collectorTimeout = 50 * time.Millisecond
+	cmdExecutor = blockingExecutor
+
+	start := time.Now()
+	data, err := getApplicationData(context.NewMockDefault(), gocontext.Background(), dpkgCmd, []string{dpkgArgsToGetAllApplications})
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.True(t, model.IsCollectionAborted(err))
+	assert.Contains(t, err.Error(), "dpkg-query did not complete within 50ms and was aborted")
+	assert.Empty(t, data)
+}
+
+func TestGetApplicationData_AbortsCommandWhenCollectionIsStopped(t *testing.T) {
+	cmdExecutor = blockingExecutor
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+
+	_, err := getApplicationData(context.NewMockDefault(), ctx, rpmCmd, []string{rpmCmdArgToGetAllApplications})
+
+	assert.True(t, model.IsCollectionAborted(err))
+	assert.Contains(t, err.Error(), "rpm was aborted because the inventory collection was stopped")
+}
+
+func TestCollectApplicationData_ReportsAbortedQueryAndKeepsCompletedOnes(t *testing.T) {
+	oldCheckCmd := checkCommandExists
+	oldTimeout := collectorTimeout
+	defer func() {
+		checkCommandExists = oldCheckCmd
+		collectorTimeout = oldTimeout
+	}()
+	checkCommandExists = func(string) bool { return true }
+	collectorTimeout = 50 * time.Millisecond
+	// dpkg-query hangs, rpm completes
+	cmdExecutor = func(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
+		if command == dpkgCmd {
+			return blockingExecutor(ctx, command, args...)
+		}
+		return MockTestExecutorWithoutError(ctx, command, args...)
+	}
+
+	data, err := collectPlatformDependentApplicationData(context.NewMockDefault(), gocontext.Background())
+
+	assert.True(t, model.IsCollectionAborted(err))
+	assert.Contains(t, err.Error(), "dpkg-query did not complete")
+	assertEqual(t, sampleDataParsed, data)
+}
+
+func TestExecuteCommand_KillsCommandWhenContextIsDone(t *testing.T) {
+	if !commandExists("sleep") {
+		t.Skip("sleep is not available")
+	}
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := executeCommand(ctx, "sleep", "30")
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
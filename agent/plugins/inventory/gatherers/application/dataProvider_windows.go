@@ -19,13 +19,16 @@
 package application
 
 import (
+	gocontext "context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
@@ -114,15 +117,20 @@ var SysnativePowershellCmd = filepath.Join(os.Getenv("SystemRoot"), "sysnative",
 var ArgsToReadRegistryFromWindowsCurrentVersionUninstall = fmt.Sprintf(ArgsToReadRegistryApplications, RegistryPathCurrentVersionUninstall)
 var ArgsToReadRegistryFromWow6432Node = fmt.Sprintf(ArgsToReadRegistryApplications, RegistryPathWow6432NodeCurrentVersionUninstall)
 
+// registrySource is the source reported for the applications found in the uninstall keys of the registry
+const registrySource = "registry"
+
 // decoupling exec.Command for easy testability
 var cmdExecutor = executeCommand
 
-func executeCommand(command string, args ...string) ([]byte, error) {
-	return exec.Command(command, args...).CombinedOutput()
+func executeCommand(ctx gocontext.Context, command string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.WaitDelay = time.Second
+	return cmd.CombinedOutput()
 }
 
 // collectPlatformDependentApplicationData collects application data for windows platform
-func collectPlatformDependentApplicationData(context context.T) []model.ApplicationData {
+func collectPlatformDependentApplicationData(context context.T, ctx gocontext.Context) ([]model.ApplicationData, error) {
 	/*
 		Note:
 
@@ -174,17 +182,30 @@ func collectPlatformDependentApplicationData(context context.T) []model.Applicat
 
 	//it will enable us to run other complicated queries too.
 
-	var data, apps []model.ApplicationData
+	var data []model.ApplicationData
 	var cmd string
+	var abortErrs []error
 
 	log := context.Log()
 
+	collect := func(command, args, arch string) {
+		apps, err := executePowershellCommands(context, ctx, command, args, arch)
+		if model.IsCollectionAborted(err) {
+			abortErrs = append(abortErrs, err)
+		}
+		setSource(apps, registrySource)
+		data = append(data, apps...)
+	}
+
 	//detecting process architecture
 	exeArch := runtime.GOARCH
 	log.Infof("Exe architecture as detected by golang runtime - %v", exeArch)
 
 	//detecting OS architecture
-	osArch := detectOSArch(context, PowershellCmd, ArgsForDetectingOSArch)
+	osArch, err := detectOSArch(context, ctx, PowershellCmd, ArgsForDetectingOSArch)
+	if model.IsCollectionAborted(err) {
+		return nil, err
+	}
 	log.Infof("Detected OS architecture as - %v", osArch)
 
 	if strings.Contains(osArch, KeywordFor32BitArchitectureReportedByPowershell) {
@@ -193,8 +214,7 @@ func collectPlatformDependentApplicationData(context context.T) []model.Applicat
 			//exe architecture is also 32 bit
 			//since both exe & os are 32 bit - we need to detect only 32 bit apps
 			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWindowsCurrentVersionUninstall
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch32Bit)
-			data = append(data, apps...)
+			collect(PowershellCmd, cmd, model.Arch32Bit)
 		} else {
 			log.Error("Detected an unsupported scenario of 64 bit amazon ssm agent running on 32 bit windows OS - nothing to report")
 		}
@@ -205,46 +225,46 @@ func collectPlatformDependentApplicationData(context context.T) []model.Applicat
 
 			//detecting 32 bit apps by querying Wow6432Node path in registry
 			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWow6432Node
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch32Bit)
-			data = append(data, apps...)
+			collect(PowershellCmd, cmd, model.Arch32Bit)
 
 			//detecting 64 bit apps by querying normal registry path
 			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWindowsCurrentVersionUninstall
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch64Bit)
-			data = append(data, apps...)
+			collect(PowershellCmd, cmd, model.Arch64Bit)
 		} else {
 			//exe architecture is 32 bit - all queries to registry path will be redirected to wow6432 so need to use sysnative
 			//reference: https://blogs.msdn.microsoft.com/david.wang/2006/03/27/howto-detect-process-bitness/
 
 			//detecting 32 bit apps by querying Wow632 registry node
 			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWow6432Node
-			apps = executePowershellCommands(context, PowershellCmd, cmd, model.Arch32Bit)
-			data = append(data, apps...)
+			collect(PowershellCmd, cmd, model.Arch32Bit)
 
 			//detecting 64 bit apps by using sysnative for reading registry to avoid path redirection
 			cmd = ConvertGuidToCompressedGuidCmd + ArgsToReadRegistryFromProducts + ArgsToReadRegistryFromWindowsCurrentVersionUninstall
-			apps = executePowershellCommands(context, SysnativePowershellCmd, cmd, model.Arch64Bit)
-			data = append(data, apps...)
+			collect(SysnativePowershellCmd, cmd, model.Arch64Bit)
 		}
 	} else {
 		log.Error("Can't find application data because unable to detect OS architecture - nothing to report")
 	}
 
-	return data
+	return data, errors.Join(abortErrs...)
 }
 
 // detectOSArch detects OS architecture; decouple for unit test
 var detectOSArch = detectOSArchFun
 
-func detectOSArchFun(context context.T, command, args string) (osArch string) {
+func detectOSArchFun(context context.T, ctx gocontext.Context, command, args string) (osArch string, err error) {
 	var output []byte
-	var err error
 	log := context.Log()
 
 	log.Infof("Getting OS architecture")
 	log.Infof("Executing command: %v %v", command, args)
 
-	if output, err = cmdExecutor(command, args); err != nil {
+	collectorCtx, cancel := gocontext.WithTimeout(ctx, collectorTimeout)
+	defer cancel()
+	if output, err = cmdExecutor(collectorCtx, command, args); err != nil && collectorCtx.Err() != nil {
+		err = collectorAbortedError(command, collectorCtx.Err())
+		log.Error(err.Error())
+	} else if err != nil {
 		log.Debugf("Failed to execute command : %v %v with error - %v",
 			command,
 			args,
@@ -264,16 +284,20 @@ func detectOSArchFun(context context.T, command, args string) (osArch string) {
 }
 
 // executePowershellCommands executes commands in powershell to get all windows applications installed.
-func executePowershellCommands(context context.T, command, args, arch string) (data []model.ApplicationData) {
+func executePowershellCommands(context context.T, ctx gocontext.Context, command, args, arch string) (data []model.ApplicationData, err error) {
 
 	var output []byte
-	var err error
 	log := context.Log()
 
 	log.Infof("Getting all %v windows applications", arch)
 	log.Infof("Executing command: %v %v", command, args)
 
-	if output, err = cmdExecutor(command, args); err != nil {
+	collectorCtx, cancel := gocontext.WithTimeout(ctx, collectorTimeout)
+	defer cancel()
+	if output, err = cmdExecutor(collectorCtx, command, args); err != nil && collectorCtx.Err() != nil {
+		err = collectorAbortedError(command, collectorCtx.Err())
+		log.Error(err.Error())
+	} else if err != nil {
 		log.Debugf("Failed to execute command : %v %v with error - %v",
 			command,
 			args,
@@ -0,0 +1,140 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// regexPatternPrefix marks an application name pattern as a regular expression, the other patterns are globs
+const regexPatternPrefix = "regex:"
+
+// NameFilter selects the applications reported by the gatherer by their name
+type NameFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewNameFilter parses lists of name patterns, either comma separated or, for the patterns containing commas, as a
+// JSON array of strings. A pattern is a case-insensitive glob where * matches any sequence of characters and ? a
+// single character, or a regular expression when it starts with "regex:". Both must match the whole name.
+func NewNameFilter(includePatterns, excludePatterns string) (*NameFilter, error) {
+	include, err := compileNamePatterns(includePatterns)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileNamePatterns(excludePatterns)
+	if err != nil {
+		return nil, err
+	}
+	return &NameFilter{include: include, exclude: exclude}, nil
+}
+
+// IsEmpty returns true when the filter keeps every application
+func (f *NameFilter) IsEmpty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// Matches returns true if the name matches one of the include patterns, or there are none, and no exclude pattern
+func (f *NameFilter) Matches(name string) bool {
+	if len(f.include) > 0 && !matchesAny(f.include, name) {
+		return false
+	}
+	return !matchesAny(f.exclude, name)
+}
+
+// Filter returns the applications whose name matches the filter, the given slice is left untouched
+func (f *NameFilter) Filter(appData []model.ApplicationData) []model.ApplicationData {
+	if f.IsEmpty() {
+		return appData
+	}
+	filtered := make([]model.ApplicationData, 0, len(appData))
+	for _, app := range appData {
+		if f.Matches(app.Name) {
+			filtered = append(filtered, app)
+		}
+	}
+	return filtered
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitNamePatterns returns the patterns of a JSON array, or of a comma separated list otherwise
+func splitNamePatterns(patterns string) ([]string, error) {
+	patterns = strings.TrimSpace(patterns)
+	if !strings.HasPrefix(patterns, "[") {
+		return strings.Split(patterns, ","), nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(patterns), &list); err != nil {
+		return nil, fmt.Errorf("invalid application name pattern list %q: %v", patterns, err)
+	}
+	return list, nil
+}
+
+func compileNamePatterns(patterns string) (compiled []*regexp.Regexp, err error) {
+	list, err := splitNamePatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range list {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		var expression string
+		if strings.HasPrefix(pattern, regexPatternPrefix) {
+			// anchored like the globs, the group keeps the anchors around the alternatives
+			expression = "^(?:" + strings.TrimPrefix(pattern, regexPatternPrefix) + ")$"
+		} else {
+			expression = globToRegexp(pattern)
+		}
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid application name pattern %q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// globToRegexp converts a glob to an anchored case-insensitive regular expression
+func globToRegexp(glob string) string {
+	var expression strings.Builder
+	expression.WriteString("(?i)^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expression.WriteString(".*")
+		case '?':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expression.WriteString("$")
+	return expression.String()
+}
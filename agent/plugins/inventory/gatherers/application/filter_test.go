@@ -0,0 +1,98 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package application
+
+import (
+	gocontext "context"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var filterTestApplications = []model.ApplicationData{
+	{Name: "python3", Version: "3.9.16"},
+	{Name: "python3-devel", Version: "3.9.16"},
+	{Name: "Python-Launcher", Version: "3.11.0"},
+	{Name: "libcurl", Version: "8.0.1"},
+	{Name: "libcurl-devel", Version: "8.0.1"},
+	{Name: "openssl", Version: "3.0.8"},
+}
+
+func applicationNames(appData []model.ApplicationData) []string {
+	names := make([]string, len(appData))
+	for i, app := range appData {
+		names[i] = app.Name
+	}
+	return names
+}
+
+func TestNameFilter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		include  string
+		exclude  string
+		expected []string
+	}{
+		{"no patterns", "", "", []string{"python3", "python3-devel", "Python-Launcher", "libcurl", "libcurl-devel", "openssl"}},
+		{"glob include is case-insensitive", "python*", "", []string{"python3", "python3-devel", "Python-Launcher"}},
+		{"glob single character", "python?", "", []string{"python3"}},
+		{"exclude only", "", "*-devel", []string{"python3", "Python-Launcher", "libcurl", "openssl"}},
+		{"include and exclude", "python*, lib*", "*-devel", []string{"python3", "Python-Launcher", "libcurl"}},
+		{"regex include", "regex:^lib.*l$", "", []string{"libcurl", "libcurl-devel"}},
+		{"regex is case-sensitive", "regex:Python.*", "", []string{"Python-Launcher"}},
+		{"glob is anchored", "ssl", "", []string{}},
+		{"regex is anchored", "regex:ssl", "", []string{}},
+		{"regex alternatives are anchored", "regex:python3|openssl", "", []string{"python3", "openssl"}},
+		{"json list", `["python*", "lib*"]`, `["*-devel"]`, []string{"python3", "Python-Launcher", "libcurl"}},
+		{"json list keeps commas in regex", `["regex:python\\d{1,2}"]`, "", []string{"python3"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			filter, err := NewNameFilter(testCase.include, testCase.exclude)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, applicationNames(filter.Filter(filterTestApplications)))
+		})
+	}
+}
+
+func TestNameFilterInvalidPattern(t *testing.T) {
+	_, err := NewNameFilter("python*", "regex:[a-")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "regex:[a-")
+}
+
+func TestNameFilterInvalidPatternList(t *testing.T) {
+	_, err := NewNameFilter(`["python*"`, "")
+	assert.Error(t, err)
+}
+
+func TestGathererFiltersApplications(t *testing.T) {
+	c := contextmocks.NewMockDefault()
+	g := Gatherer(c)
+	collectData = func(context context.T, ctx gocontext.Context) ([]model.ApplicationData, error) {
+		return filterTestApplications, nil
+	}
+	defer func() { collectData = CollectApplicationDataWithContext }()
+
+	items, err := g.Run(c, model.Config{IncludeApplications: "python*", ExcludeApplications: "*-devel"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"python3", "Python-Launcher"}, applicationNames(items[0].Content.([]model.ApplicationData)))
+	// the collected data is cached and shared with other gatherers, it must not be modified by the filter
+	assert.Len(t, filterTestApplications, 6)
+	assert.Equal(t, "python3-devel", filterTestApplications[1].Name)
+}
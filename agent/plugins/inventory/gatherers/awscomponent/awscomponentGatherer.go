@@ -108,7 +108,8 @@ func CollectAWSComponentData(context context.T) (data []model.ApplicationData) {
 	log.Infof("Number of applications detected by %v - %v", application.GathererName, len(data))
 	log.Debugf("Filtering out awscomponents from list of all applications")
 
-	data = FilterAWSComponent(data)
+	// the AWS:AWSComponent schema has no collection details
+	data = application.WithoutCollectionDetails(FilterAWSComponent(data))
 
 	log.Infof("Number of applications detected by %v - %v", GathererName, len(data))
 	log.Debugf("Applications detected by AWSComponents:\n%v", data)
@@ -0,0 +1,82 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package container contains a container gatherer which reports running containers and local images.
+package container
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of container gatherer, the containers are reported as a custom inventory type
+	// because the service only accepts its predefined AWS: types
+	GathererName = "Custom:Container"
+	// ImageInventoryName captures name of the custom inventory type reporting local container images
+	ImageInventoryName = "Custom:ContainerImage"
+	// SchemaVersionOfContainerGatherer represents schema version of container gatherer
+	SchemaVersionOfContainerGatherer = "1.0"
+)
+
+type T struct{}
+
+// Gatherer returns new container gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectContainerData
+
+// Name returns name of container gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes container gatherer and returns list of inventory.Item comprising of container and image data.
+// No item is returned when no container runtime is present, and an item is left out when its data could not be collected.
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	var data *containerInventory
+	if data, err = collectData(context); err != nil || data == nil {
+		return
+	}
+
+	if data.Containers != nil {
+		items = append(items, model.Item{
+			Name:          t.Name(),
+			SchemaVersion: SchemaVersionOfContainerGatherer,
+			Content:       data.Containers,
+			CaptureTime:   captureTime,
+		})
+	}
+	if data.Images != nil {
+		items = append(items, model.Item{
+			Name:          ImageInventoryName,
+			SchemaVersion: SchemaVersionOfContainerGatherer,
+			Content:       data.Images,
+			CaptureTime:   captureTime,
+		})
+	}
+	return
+}
+
+// RequestStop stops the execution of container gatherer.
+func (t *T) RequestStop() error {
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testContainers = []model.ContainerData{
+	{
+		ContainerId: "4a1c2b3d",
+		Name:        "web",
+		Image:       "nginx:latest",
+		Status:      "running",
+		CreatedTime: "2024-01-02T03:04:05Z",
+		Runtime:     dockerRuntimeName,
+	},
+}
+
+var testImages = []model.ContainerImageData{
+	{
+		Repository: "nginx",
+		Tag:        "latest",
+		Digest:     "sha256:abc",
+		Runtime:    dockerRuntimeName,
+	},
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T) (*containerInventory, error) {
+		return &containerInventory{Containers: testContainers, Images: testImages}, nil
+	}
+	defer func() { collectData = collectContainerData }()
+
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "Custom:Container", items[0].Name)
+	assert.Equal(t, SchemaVersionOfContainerGatherer, items[0].SchemaVersion)
+	assert.Equal(t, testContainers, items[0].Content)
+	assert.Equal(t, "Custom:ContainerImage", items[1].Name)
+	assert.Equal(t, testImages, items[1].Content)
+}
+
+func TestGathererWithoutRuntime(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T) (*containerInventory, error) {
+		return nil, nil
+	}
+	defer func() { collectData = collectContainerData }()
+
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Empty(t, items)
+}
+
+func TestGathererReportsOnlyCollectedData(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T) (*containerInventory, error) {
+		return &containerInventory{Images: []model.ContainerImageData{}}, nil
+	}
+	defer func() { collectData = collectContainerData }()
+
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, ImageInventoryName, items[0].Name)
+	assert.Equal(t, []model.ContainerImageData{}, items[0].Content)
+}
+
+func TestGathererError(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T) (*containerInventory, error) {
+		return nil, errors.New("runtime unavailable")
+	}
+	defer func() { collectData = collectContainerData }()
+
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.NotNil(t, err)
+	assert.Empty(t, items)
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// maxFieldLength caps the length of any single reported value
+	maxFieldLength = 1024
+	// noneValue is reported for image repositories and tags which are not set, matching the docker CLI
+	noneValue = "<none>"
+)
+
+// containerRuntime is implemented by every container runtime the gatherer can query
+type containerRuntime interface {
+	Name() string
+	// Containers returns the containers which are currently running
+	Containers(log log.T) ([]model.ContainerData, error)
+	// Images returns the images stored locally
+	Images(log log.T) ([]model.ContainerImageData, error)
+}
+
+// containerInventory holds the collected data, a nil list means that list could not be collected
+type containerInventory struct {
+	Containers []model.ContainerData
+	Images     []model.ContainerImageData
+}
+
+var detectRuntime = detectContainerRuntime
+
+// detectContainerRuntime returns the first container runtime found on the instance, or nil if there is none
+func detectContainerRuntime(log log.T) containerRuntime {
+	if socket, found := findDockerSocket(); found {
+		log.Debugf("Found docker socket at %v", socket)
+		return newDockerRuntime(socket)
+	}
+	if address, found := findContainerdSocket(); found {
+		log.Debugf("Found containerd socket at %v", address)
+		return newContainerdRuntime(address)
+	}
+	return nil
+}
+
+// collectContainerData returns the containers and images of the detected runtime, or nil if no runtime is present.
+// Failing to list either containers or images is tolerated as long as the other one succeeds.
+func collectContainerData(context context.T) (data *containerInventory, err error) {
+	log := context.Log()
+	runtime := detectRuntime(log)
+	if runtime == nil {
+		log.Info("No container runtime found, skipping container inventory")
+		return nil, nil
+	}
+	log.Infof("Collecting container inventory from %v", runtime.Name())
+
+	data = &containerInventory{}
+	containers, containerErr := runtime.Containers(log)
+	if containerErr != nil {
+		log.Warnf("Failed to list %v containers: %v", runtime.Name(), containerErr)
+	} else {
+		data.Containers = sanitizeContainers(log, runtime.Name(), containers)
+	}
+
+	images, imageErr := runtime.Images(log)
+	if imageErr != nil {
+		log.Warnf("Failed to list %v images: %v", runtime.Name(), imageErr)
+	} else {
+		data.Images = sanitizeImages(log, runtime.Name(), images)
+	}
+
+	if containerErr != nil && imageErr != nil {
+		return nil, fmt.Errorf("unable to collect container inventory from %v: %v; %v", runtime.Name(), containerErr, imageErr)
+	}
+	return data, nil
+}
+
+// sanitizeContainers cleans up every field and drops containers without an id.
+// The returned list is never nil so that an instance without running containers reports an empty list.
+func sanitizeContainers(log log.T, runtimeName string, containers []model.ContainerData) []model.ContainerData {
+	result := []model.ContainerData{}
+	for _, container := range containers {
+		container.ContainerId = sanitizeField(container.ContainerId)
+		if container.ContainerId == "" {
+			log.Warnf("Skipping %v container without id, image: %q", runtimeName, container.Image)
+			continue
+		}
+		container.Name = sanitizeField(container.Name)
+		container.Image = sanitizeField(container.Image)
+		container.ImageId = sanitizeField(container.ImageId)
+		container.Status = sanitizeField(container.Status)
+		container.CreatedTime = sanitizeField(container.CreatedTime)
+		container.Runtime = runtimeName
+		result = append(result, container)
+	}
+	return result
+}
+
+// sanitizeImages cleans up every field and drops images which can be identified neither by repository nor by id.
+// The returned list is never nil so that an instance without images reports an empty list.
+func sanitizeImages(log log.T, runtimeName string, images []model.ContainerImageData) []model.ContainerImageData {
+	result := []model.ContainerImageData{}
+	for _, image := range images {
+		image.Repository = sanitizeField(image.Repository)
+		image.ImageId = sanitizeField(image.ImageId)
+		if (image.Repository == "" || image.Repository == noneValue) && image.ImageId == "" {
+			log.Warnf("Skipping %v image without repository or id, digest: %q", runtimeName, image.Digest)
+			continue
+		}
+		if image.Repository == "" {
+			image.Repository = noneValue
+		}
+		image.Tag = sanitizeField(image.Tag)
+		if image.Tag == "" {
+			image.Tag = noneValue
+		}
+		image.Digest = sanitizeField(image.Digest)
+		image.CreatedTime = sanitizeField(image.CreatedTime)
+		image.Runtime = runtimeName
+		result = append(result, image)
+	}
+	return result
+}
+
+// sanitizeField removes control characters and surrounding spaces and truncates overly long values
+func sanitizeField(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 32 || r == 127 {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.TrimSpace(strings.ToValidUTF8(value, ""))
+	if runes := []rune(value); len(runes) > maxFieldLength {
+		value = string(runes[:maxFieldLength])
+	}
+	return value
+}
+
+// splitImageReference splits an image reference such as registry:5000/repo:tag@sha256:abc into repository, tag and digest
+func splitImageReference(reference string) (repository, tag, digest string) {
+	repository = reference
+	if index := strings.Index(repository, "@"); index >= 0 {
+		digest = repository[index+1:]
+		repository = repository[:index]
+	}
+	// a colon before the last slash belongs to the registry port
+	if index := strings.LastIndex(repository, ":"); index > strings.LastIndex(repository, "/") {
+		tag = repository[index+1:]
+		repository = repository[:index]
+	}
+	return
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	containerdRuntimeName   = "containerd"
+	defaultContainerdSocket = "/run/containerd/containerd.sock"
+	// ctrCmd is the containerd CLI, containerd only exposes a grpc API
+	ctrCmd            = "ctr"
+	ctrCommandTimeout = 30 * time.Second
+	ctrRunningStatus  = "RUNNING"
+)
+
+// containerdContainerInfo is the subset of `ctr containers info` the gatherer reports
+type containerdContainerInfo struct {
+	ID        string
+	Image     string
+	CreatedAt time.Time
+}
+
+// containerdRuntime queries containerd through its CLI across all namespaces
+type containerdRuntime struct {
+	address string
+}
+
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ctrCommandTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, command, args...).Output()
+}
+
+// findContainerdSocket returns the containerd socket and whether it exists along with the ctr CLI
+func findContainerdSocket() (string, bool) {
+	if _, err := os.Stat(defaultContainerdSocket); err != nil {
+		return defaultContainerdSocket, false
+	}
+	_, err := exec.LookPath(ctrCmd)
+	return defaultContainerdSocket, err == nil
+}
+
+func newContainerdRuntime(address string) *containerdRuntime {
+	return &containerdRuntime{address: address}
+}
+
+// Name returns the name of the runtime
+func (c *containerdRuntime) Name() string {
+	return containerdRuntimeName
+}
+
+// Containers returns the containers with a running task in any namespace
+func (c *containerdRuntime) Containers(log log.T) (containers []model.ContainerData, err error) {
+	err = c.forEachNamespace(log, func(namespace string) error {
+		output, err := c.ctr(namespace, "tasks", "ls")
+		if err != nil {
+			return err
+		}
+		for _, fields := range parseTable(output) {
+			if len(fields) < 3 || fields[2] != ctrRunningStatus {
+				continue
+			}
+			info, infoErr := c.containerInfo(namespace, fields[0])
+			if infoErr != nil {
+				// a container can go away between listing and inspecting it, report the others
+				log.Warnf("Failed to inspect containerd container %v in namespace %v: %v", fields[0], namespace, infoErr)
+				continue
+			}
+			container := model.ContainerData{
+				ContainerId: info.ID,
+				Name:        namespace + "/" + info.ID,
+				Image:       info.Image,
+				Status:      strings.ToLower(fields[2]),
+			}
+			if !info.CreatedAt.IsZero() {
+				container.CreatedTime = info.CreatedAt.UTC().Format(time.RFC3339)
+			}
+			containers = append(containers, container)
+		}
+		return nil
+	})
+	return
+}
+
+// Images returns the images stored in any namespace
+func (c *containerdRuntime) Images(log log.T) (images []model.ContainerImageData, err error) {
+	err = c.forEachNamespace(log, func(namespace string) error {
+		output, err := c.ctr(namespace, "images", "ls")
+		if err != nil {
+			return err
+		}
+		// columns are REF TYPE DIGEST SIZE PLATFORMS LABELS
+		for _, fields := range parseTable(output) {
+			if len(fields) < 3 {
+				continue
+			}
+			repository, tag, _ := splitImageReference(fields[0])
+			images = append(images, model.ContainerImageData{
+				Repository: repository,
+				Tag:        tag,
+				Digest:     fields[2],
+				ImageId:    fields[2],
+			})
+		}
+		return nil
+	})
+	return
+}
+
+func (c *containerdRuntime) containerInfo(namespace, id string) (info containerdContainerInfo, err error) {
+	var output []byte
+	if output, err = c.ctr(namespace, "containers", "info", id); err != nil {
+		return
+	}
+	err = json.Unmarshal(output, &info)
+	return
+}
+
+func (c *containerdRuntime) ctr(namespace string, args ...string) ([]byte, error) {
+	ctrArgs := []string{"--address", c.address}
+	if namespace != "" {
+		ctrArgs = append(ctrArgs, "--namespace", namespace)
+	}
+	output, err := cmdExecutor(ctrCmd, append(ctrArgs, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("%v %v failed: %v", ctrCmd, strings.Join(args, " "), err)
+	}
+	return output, nil
+}
+
+// forEachNamespace calls collect for every containerd namespace, failing only if no namespace could be collected
+func (c *containerdRuntime) forEachNamespace(log log.T, collect func(namespace string) error) error {
+	output, err := c.ctr("", "namespaces", "ls", "-q")
+	if err != nil {
+		return err
+	}
+	var namespaceErr error
+	var succeeded bool
+	for _, namespace := range strings.Fields(string(output)) {
+		if err = collect(namespace); err != nil {
+			log.Warnf("Failed to collect containerd inventory in namespace %v: %v", namespace, err)
+			namespaceErr = err
+			continue
+		}
+		succeeded = true
+	}
+	if !succeeded {
+		return namespaceErr
+	}
+	return nil
+}
+
+// parseTable splits the rows of a ctr table into fields, skipping the header
+func parseTable(output []byte) (rows [][]string) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for _, line := range lines[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows = append(rows, fields)
+		}
+	}
+	return
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockCtr returns the canned output of the ctr command whose arguments, after the address, match the key
+func mockCtr(outputs map[string]string) func() {
+	cmdExecutor = func(command string, args ...string) ([]byte, error) {
+		key := strings.Join(args[2:], " ")
+		if output, found := outputs[key]; found {
+			return []byte(output), nil
+		}
+		return nil, errors.New("exit status 1")
+	}
+	return func() { cmdExecutor = executeCommand }
+}
+
+func TestContainerdContainers(t *testing.T) {
+	defer mockCtr(map[string]string{
+		"namespaces ls -q": "default\nk8s.io\n",
+		"--namespace default tasks ls": "TASK     PID     STATUS\n" +
+			"redis    1234    RUNNING\n" +
+			"batch    0       STOPPED\n" +
+			"gone     4321    RUNNING\n",
+		"--namespace default containers info redis": `{"ID": "redis", "Image": "docker.io/library/redis:7", "CreatedAt": "2024-01-02T03:04:05.123456Z"}`,
+		"--namespace k8s.io tasks ls":               "TASK    PID    STATUS\n",
+	})()
+
+	containers, err := newContainerdRuntime(defaultContainerdSocket).Containers(logmocks.NewMockLog())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ContainerData{
+		{ContainerId: "redis", Name: "default/redis", Image: "docker.io/library/redis:7", Status: "running", CreatedTime: "2024-01-02T03:04:05Z"},
+	}, containers)
+}
+
+func TestContainerdImages(t *testing.T) {
+	defer mockCtr(map[string]string{
+		"namespaces ls -q": "default\nbroken\n",
+		"--namespace default images ls": "REF                        TYPE                                                 DIGEST          SIZE      PLATFORMS   LABELS\n" +
+			"docker.io/library/redis:7  application/vnd.oci.image.index.v1+json              sha256:5f2b3d   40.1 MiB  linux/amd64 -\n" +
+			"registry:5000/app@sha256:9a8b  application/vnd.oci.image.manifest.v1+json   sha256:9a8b     2.0 KiB   linux/amd64 -\n",
+	})()
+
+	images, err := newContainerdRuntime(defaultContainerdSocket).Images(logmocks.NewMockLog())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ContainerImageData{
+		{Repository: "docker.io/library/redis", Tag: "7", Digest: "sha256:5f2b3d", ImageId: "sha256:5f2b3d"},
+		{Repository: "registry:5000/app", Digest: "sha256:9a8b", ImageId: "sha256:9a8b"},
+	}, images)
+}
+
+func TestContainerdFailsWhenNoNamespaceCanBeListed(t *testing.T) {
+	defer mockCtr(map[string]string{
+		"namespaces ls -q": "default\n",
+	})()
+
+	images, err := newContainerdRuntime(defaultContainerdSocket).Images(logmocks.NewMockLog())
+	assert.Error(t, err)
+	assert.Empty(t, images)
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	dockerRuntimeName   = "docker"
+	defaultDockerSocket = "/var/run/docker.sock"
+	dockerHostEnv       = "DOCKER_HOST"
+	// dockerAPIHost is only used to build request urls, requests always go through the socket
+	dockerAPIHost    = "http://docker"
+	dockerAPITimeout = 30 * time.Second
+	// maxDockerResponseSize protects the agent from reading unbounded responses
+	maxDockerResponseSize = 64 * 1024 * 1024
+)
+
+// dockerContainer is the subset of the docker engine /containers/json response the gatherer reports
+type dockerContainer struct {
+	Id      string
+	Names   []string
+	Image   string
+	ImageID string
+	Created int64
+	State   string
+	Status  string
+}
+
+// dockerImage is the subset of the docker engine /images/json response the gatherer reports
+type dockerImage struct {
+	Id          string
+	RepoTags    []string
+	RepoDigests []string
+	Created     int64
+}
+
+// dockerRuntime queries the docker engine API, which is also served by podman, over its unix socket
+type dockerRuntime struct {
+	client *http.Client
+}
+
+// findDockerSocket returns the docker socket set in DOCKER_HOST or the default one, and whether it exists
+func findDockerSocket() (string, bool) {
+	socket := defaultDockerSocket
+	if host := os.Getenv(dockerHostEnv); strings.HasPrefix(host, "unix://") {
+		socket = strings.TrimPrefix(host, "unix://")
+	}
+	_, err := os.Stat(socket)
+	return socket, err == nil
+}
+
+func newDockerRuntime(socket string) *dockerRuntime {
+	return &dockerRuntime{
+		client: &http.Client{
+			Timeout: dockerAPITimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// Name returns the name of the runtime
+func (d *dockerRuntime) Name() string {
+	return dockerRuntimeName
+}
+
+// Containers returns the running docker containers
+func (d *dockerRuntime) Containers(log log.T) (containers []model.ContainerData, err error) {
+	var response []dockerContainer
+	if err = d.get("/containers/json", &response); err != nil {
+		return
+	}
+	for _, container := range response {
+		status := container.State
+		if status == "" {
+			status = container.Status
+		}
+		var name string
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		containers = append(containers, model.ContainerData{
+			ContainerId: container.Id,
+			Name:        name,
+			Image:       container.Image,
+			ImageId:     container.ImageID,
+			Status:      status,
+			CreatedTime: formatUnixTime(container.Created),
+		})
+	}
+	return
+}
+
+// Images returns the local docker images, one entry per repository tag
+func (d *dockerRuntime) Images(log log.T) (images []model.ContainerImageData, err error) {
+	var response []dockerImage
+	if err = d.get("/images/json", &response); err != nil {
+		return
+	}
+	for _, image := range response {
+		digests := make(map[string]string)
+		for _, repoDigest := range image.RepoDigests {
+			repository, _, digest := splitImageReference(repoDigest)
+			digests[repository] = digest
+		}
+
+		entry := model.ContainerImageData{
+			ImageId:     image.Id,
+			CreatedTime: formatUnixTime(image.Created),
+		}
+		var tagged bool
+		for _, repoTag := range image.RepoTags {
+			repository, tag, _ := splitImageReference(repoTag)
+			if repository == noneValue {
+				continue
+			}
+			tagged = true
+			entry.Repository, entry.Tag, entry.Digest = repository, tag, digests[repository]
+			images = append(images, entry)
+		}
+		if tagged {
+			continue
+		}
+		// untagged images are reported once, with the repository they were pulled from if known
+		entry.Repository, entry.Tag = noneValue, noneValue
+		if len(image.RepoDigests) > 0 {
+			entry.Repository, _, entry.Digest = splitImageReference(image.RepoDigests[0])
+		}
+		images = append(images, entry)
+	}
+	return
+}
+
+func (d *dockerRuntime) get(path string, response interface{}) error {
+	resp, err := d.client.Get(dockerAPIHost + path)
+	if err != nil {
+		return fmt.Errorf("docker API request %v failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker API request %v returned status %v", path, resp.Status)
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxDockerResponseSize)).Decode(response); err != nil {
+		return fmt.Errorf("unable to parse docker API response for %v: %v", path, err)
+	}
+	return nil
+}
+
+func formatUnixTime(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDockerContainersResponse = `[
+		{"Id": "8dfafdbc3a40", "Names": ["/web\u0007server"], "Image": "nginx:latest", "ImageID": "sha256:605c77e624dd", "Created": 1704164645, "State": "running", "Status": "Up 2 hours"},
+		{"Id": "9cd87474be90", "Names": [], "Image": "busybox", "ImageID": "sha256:beae173ccac6", "Created": 0, "Status": "Up 1 second"}
+	]`
+	testDockerImagesResponse = `[
+		{"Id": "sha256:605c77e624dd", "RepoTags": ["nginx:latest", "registry:5000/nginx:1.25"], "RepoDigests": ["nginx@sha256:0d17b565"], "Created": 1704164645},
+		{"Id": "sha256:beae173ccac6", "RepoTags": ["<none>:<none>"], "RepoDigests": ["busybox@sha256:5acba83a"], "Created": 1704164645},
+		{"Id": "sha256:0123456789ab", "RepoTags": null, "RepoDigests": null, "Created": 1704164645}
+	]`
+)
+
+// startDockerServer serves the given handler on a unix socket and returns a docker runtime using it
+func startDockerServer(t *testing.T, handler http.HandlerFunc) *dockerRuntime {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return newDockerRuntime(socket)
+}
+
+func TestDockerContainers(t *testing.T) {
+	runtime := startDockerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/containers/json", r.URL.Path)
+		w.Write([]byte(testDockerContainersResponse))
+	})
+
+	containers, err := runtime.Containers(logmocks.NewMockLog())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ContainerData{
+		{ContainerId: "8dfafdbc3a40", Name: "web\u0007server", Image: "nginx:latest", ImageId: "sha256:605c77e624dd", Status: "running", CreatedTime: "2024-01-02T03:04:05Z"},
+		{ContainerId: "9cd87474be90", Image: "busybox", ImageId: "sha256:beae173ccac6", Status: "Up 1 second"},
+	}, containers)
+}
+
+func TestDockerImages(t *testing.T) {
+	runtime := startDockerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/images/json", r.URL.Path)
+		w.Write([]byte(testDockerImagesResponse))
+	})
+
+	images, err := runtime.Images(logmocks.NewMockLog())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ContainerImageData{
+		{Repository: "nginx", Tag: "latest", Digest: "sha256:0d17b565", ImageId: "sha256:605c77e624dd", CreatedTime: "2024-01-02T03:04:05Z"},
+		{Repository: "registry:5000/nginx", Tag: "1.25", ImageId: "sha256:605c77e624dd", CreatedTime: "2024-01-02T03:04:05Z"},
+		{Repository: "busybox", Tag: noneValue, Digest: "sha256:5acba83a", ImageId: "sha256:beae173ccac6", CreatedTime: "2024-01-02T03:04:05Z"},
+		{Repository: noneValue, Tag: noneValue, ImageId: "sha256:0123456789ab", CreatedTime: "2024-01-02T03:04:05Z"},
+	}, images)
+}
+
+func TestDockerAPIError(t *testing.T) {
+	runtime := startDockerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	})
+
+	containers, err := runtime.Containers(logmocks.NewMockLog())
+	assert.Error(t, err)
+	assert.Empty(t, containers)
+}
+
+func TestDockerMalformedResponse(t *testing.T) {
+	runtime := startDockerServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id": "8dfafdbc3a40", "Names": [`))
+	})
+
+	images, err := runtime.Images(logmocks.NewMockLog())
+	assert.Error(t, err)
+	assert.Empty(t, images)
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package container
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRuntime is a container runtime returning canned data
+type mockRuntime struct {
+	containers    []model.ContainerData
+	containersErr error
+	images        []model.ContainerImageData
+	imagesErr     error
+}
+
+func (m *mockRuntime) Name() string {
+	return "mock"
+}
+
+func (m *mockRuntime) Containers(log log.T) ([]model.ContainerData, error) {
+	return m.containers, m.containersErr
+}
+
+func (m *mockRuntime) Images(log log.T) ([]model.ContainerImageData, error) {
+	return m.images, m.imagesErr
+}
+
+func setRuntime(runtime containerRuntime) func() {
+	detectRuntime = func(log log.T) containerRuntime {
+		return runtime
+	}
+	return func() { detectRuntime = detectContainerRuntime }
+}
+
+func TestCollectContainerDataWithoutRuntime(t *testing.T) {
+	defer setRuntime(nil)()
+
+	data, err := collectContainerData(contextmocks.NewMockDefault())
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestCollectContainerDataSanitizesEntries(t *testing.T) {
+	defer setRuntime(&mockRuntime{
+		containers: []model.ContainerData{
+			{ContainerId: "abc\x00123", Name: " web\t\n", Image: "repo/\x1b[31mimage\x1b[0m:1.0", Status: "running\r", CreatedTime: "2024-01-02T03:04:05Z"},
+			{ContainerId: "déjà-vu-日本", Name: `quote"d\name`, Image: "img\u007f", Status: "running"},
+			{ContainerId: "\x01\x02", Image: "dropped"},
+		},
+		images: []model.ContainerImageData{
+			{Repository: "repo\x07name", Tag: "v1\x00", Digest: "sha256:abc\n"},
+			{Repository: "", Tag: "", ImageId: "sha256:untagged"},
+			{Repository: noneValue, Tag: noneValue},
+			{Repository: strings.Repeat("r", maxFieldLength+10), Tag: "latest"},
+		},
+	})()
+
+	data, err := collectContainerData(contextmocks.NewMockDefault())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ContainerData{
+		{ContainerId: "abc123", Name: "web", Image: "repo/[31mimage[0m:1.0", Status: "running", CreatedTime: "2024-01-02T03:04:05Z", Runtime: "mock"},
+		{ContainerId: "déjà-vu-日本", Name: `quote"d\name`, Image: "img", Status: "running", Runtime: "mock"},
+	}, data.Containers)
+	assert.Equal(t, 3, len(data.Images))
+	assert.Equal(t, model.ContainerImageData{Repository: "reponame", Tag: "v1", Digest: "sha256:abc", Runtime: "mock"}, data.Images[0])
+	assert.Equal(t, model.ContainerImageData{Repository: noneValue, Tag: noneValue, ImageId: "sha256:untagged", Runtime: "mock"}, data.Images[1])
+	assert.Equal(t, maxFieldLength, len(data.Images[2].Repository))
+}
+
+func TestCollectContainerDataToleratesPartialFailure(t *testing.T) {
+	defer setRuntime(&mockRuntime{
+		containersErr: errors.New("permission denied"),
+		images:        []model.ContainerImageData{{Repository: "nginx", Tag: "latest"}},
+	})()
+
+	data, err := collectContainerData(contextmocks.NewMockDefault())
+	assert.NoError(t, err)
+	assert.Nil(t, data.Containers)
+	assert.Equal(t, 1, len(data.Images))
+}
+
+func TestCollectContainerDataReportsEmptyLists(t *testing.T) {
+	defer setRuntime(&mockRuntime{})()
+
+	data, err := collectContainerData(contextmocks.NewMockDefault())
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ContainerData{}, data.Containers)
+	assert.Equal(t, []model.ContainerImageData{}, data.Images)
+}
+
+func TestCollectContainerDataFailsWhenNothingCollected(t *testing.T) {
+	defer setRuntime(&mockRuntime{
+		containersErr: errors.New("permission denied"),
+		imagesErr:     errors.New("permission denied"),
+	})()
+
+	data, err := collectContainerData(contextmocks.NewMockDefault())
+	assert.Error(t, err)
+	assert.Nil(t, data)
+}
+
+func TestSplitImageReference(t *testing.T) {
+	testCases := []struct {
+		reference, repository, tag, digest string
+	}{
+		{"nginx", "nginx", "", ""},
+		{"nginx:1.25", "nginx", "1.25", ""},
+		{"registry:5000/team/app", "registry:5000/team/app", "", ""},
+		{"registry:5000/team/app:v2", "registry:5000/team/app", "v2", ""},
+		{"docker.io/library/redis@sha256:abc", "docker.io/library/redis", "", "sha256:abc"},
+		{"redis:7@sha256:abc", "redis", "7", "sha256:abc"},
+	}
+	for _, testCase := range testCases {
+		repository, tag, digest := splitImageReference(testCase.reference)
+		assert.Equal(t, testCase.repository, repository, testCase.reference)
+		assert.Equal(t, testCase.tag, tag, testCase.reference)
+		assert.Equal(t, testCase.digest, digest, testCase.reference)
+	}
+}
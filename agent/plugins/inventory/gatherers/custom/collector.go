@@ -0,0 +1,166 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package custom
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// collectorSchemaVersion is the schema version of the items produced by registered collectors
+const collectorSchemaVersion = "1.0"
+
+// Collector gathers the entries of a custom inventory type from within the agent, for data that
+// is not exposed as a custom inventory file.
+type Collector interface {
+	// Collect returns the entries of the inventory type, each entry mapping attribute names to values
+	Collect(context context.T) ([]map[string]string, error)
+}
+
+var (
+	collectorsLock       sync.RWMutex
+	registeredCollectors = map[string]Collector{}
+)
+
+// RegisterCollector registers a collector whose entries are gathered along with the custom inventory files.
+// The typeName must follow the custom inventory naming rules, e.g. Custom:InternalSoftware.
+func RegisterCollector(typeName string, collector Collector) error {
+	if err := checkTypeName(typeName); err != nil {
+		return err
+	}
+	if typeName == CustomInventoryTypeNamePrefix {
+		return fmt.Errorf("Custom inventory TypeName (%v) needs a name after the %v prefix", typeName, CustomInventoryTypeNamePrefix)
+	}
+	if collector == nil {
+		return fmt.Errorf("Custom inventory collector for %v is nil", typeName)
+	}
+
+	collectorsLock.Lock()
+	defer collectorsLock.Unlock()
+	if _, ok := registeredCollectors[typeName]; ok {
+		return fmt.Errorf("Custom inventory collector for %v is already registered", typeName)
+	}
+	if len(registeredCollectors) >= CustomInventoryCountLimit {
+		return fmt.Errorf("Total custom inventory collector count exceeds limit (%v)", CustomInventoryCountLimit)
+	}
+	registeredCollectors[typeName] = collector
+	return nil
+}
+
+// collectRegisteredItems runs the registered collectors in type name order and returns an item per collector,
+// skipping the types already gathered from files, the collectors that fail or produce invalid entries and the
+// items that would take the combined custom inventory beyond its count or size limits
+func collectRegisteredItems(context context.T, gatheredItems []model.Item) (items []model.Item) {
+	log := context.Log()
+	collectorsLock.RLock()
+	typeNames := make([]string, 0, len(registeredCollectors))
+	collectors := make(map[string]Collector, len(registeredCollectors))
+	for typeName, collector := range registeredCollectors {
+		typeNames = append(typeNames, typeName)
+		collectors[typeName] = collector
+	}
+	collectorsLock.RUnlock()
+	sort.Strings(typeNames)
+
+	gatheredTypeNames := make(map[string]bool, len(gatheredItems))
+	for _, item := range gatheredItems {
+		gatheredTypeNames[item.Name] = true
+	}
+	count := len(gatheredItems)
+	totalSize := itemSize(gatheredItems)
+	for _, typeName := range typeNames {
+		if gatheredTypeNames[typeName] {
+			LogError(log, fmt.Errorf("Custom inventory typeName (%v) of a registered collector is also provided by a file, ignoring the collector", typeName))
+			continue
+		}
+		if count >= CustomInventoryCountLimit {
+			LogError(log, fmt.Errorf("Total custom inventory count exceeds limit (%v), ignoring the collector of %v", CustomInventoryCountLimit, typeName))
+			continue
+		}
+		item, err := collectItem(context, log, typeName, collectors[typeName])
+		if err != nil {
+			LogError(log, fmt.Errorf("Failed to collect custom inventory %v, error %v. continue...", typeName, err))
+			continue
+		}
+		size := itemSize(item)
+		if size > model.SizeLimitKBPerInventoryType*1024 || totalSize+size > model.TotalSizeLimitKB*1024 {
+			LogError(log, fmt.Errorf("Custom inventory %v of %v bytes exceeds the size limit, ignoring the collector", typeName, size))
+			continue
+		}
+		count++
+		totalSize += size
+		items = append(items, item)
+	}
+	return items
+}
+
+// itemSize returns the size in bytes of the inventory data as sent to the service
+func itemSize(data interface{}) int {
+	content, _ := json.Marshal(data)
+	return len(content)
+}
+
+// collectItem runs a collector and validates its entries with the same limits as the custom inventory files
+func collectItem(context context.T, log log.T, typeName string, collector Collector) (item model.Item, err error) {
+	entries, err := collector.Collect(context)
+	if err != nil {
+		return item, err
+	}
+
+	content := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		attributes := make(map[string]interface{}, len(entry))
+		for name, value := range entry {
+			attributes[name] = value
+		}
+		if err = validateContentEntryAttributes(log, attributes); err != nil {
+			return item, err
+		}
+		content = append(content, attributes)
+	}
+
+	return model.Item{
+		Name:          typeName,
+		SchemaVersion: collectorSchemaVersion,
+		Content:       content,
+		// CaptureTime must be in UTC so that formatting to RFC3339
+		CaptureTime: time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// checkTypeName checks the custom inventory naming rules of the service
+func checkTypeName(typeName string) error {
+	if len(typeName) == 0 {
+		return fmt.Errorf("Custom inventory item has missed or empty TypeName")
+	}
+	if len(typeName) > TypeNameLengthLimit {
+		return fmt.Errorf("Custom inventory item TypeName (%v)'s length %v exceeded the limit: %v",
+			typeName,
+			len(typeName),
+			TypeNameLengthLimit)
+	}
+	if !strings.HasPrefix(typeName, CustomInventoryTypeNamePrefix) {
+		return fmt.Errorf("Custom inventory item's TypeName (%v) has to start with %v",
+			typeName, CustomInventoryTypeNamePrefix)
+	}
+	return nil
+}
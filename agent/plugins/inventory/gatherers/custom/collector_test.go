@@ -0,0 +1,159 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package custom
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCollector struct {
+	entries []map[string]string
+	err     error
+}
+
+func (c fakeCollector) Collect(context context.T) ([]map[string]string, error) {
+	return c.entries, c.err
+}
+
+// resetCollectors removes the collectors registered by a test once it completes
+func resetCollectors(t *testing.T) {
+	t.Cleanup(func() {
+		collectorsLock.Lock()
+		defer collectorsLock.Unlock()
+		registeredCollectors = map[string]Collector{}
+	})
+}
+
+func findItem(items []model.Item, name string) *model.Item {
+	for i := range items {
+		if items[i].Name == name {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+func TestGathererIncludesRegisteredCollector(t *testing.T) {
+	resetCollectors(t)
+	c := contextmocks.NewMockDefault()
+	readFileFunc = MockReadFile
+	readDirFunc = MockReadDir
+	entries := []map[string]string{
+		{"Name": "billing-daemon", "Version": "4.2"},
+		{"Name": "license-server", "Version": "1.0"},
+	}
+	assert.NoError(t, RegisterCollector("Custom:InternalSoftware", fakeCollector{entries: entries}))
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.NotNil(t, findItem(items, "Custom:MyFile"))
+	item := findItem(items, "Custom:InternalSoftware")
+	if assert.NotNil(t, item) {
+		assert.Equal(t, collectorSchemaVersion, item.SchemaVersion)
+		assert.NotEmpty(t, item.CaptureTime)
+		assert.Equal(t, []map[string]interface{}{
+			{"Name": "billing-daemon", "Version": "4.2"},
+			{"Name": "license-server", "Version": "1.0"},
+		}, item.Content)
+	}
+}
+
+func TestGathererSkipsFailingAndInvalidCollectors(t *testing.T) {
+	resetCollectors(t)
+	c := contextmocks.NewMockDefault()
+	readFileFunc = MockReadFile
+	readDirFunc = MockReadDir
+	assert.NoError(t, RegisterCollector("Custom:Failing", fakeCollector{err: errors.New("daemon not running")}))
+	assert.NoError(t, RegisterCollector("Custom:Invalid", fakeCollector{entries: []map[string]string{{"": "empty name"}}}))
+	assert.NoError(t, RegisterCollector("Custom:MyFile", fakeCollector{entries: []map[string]string{{"Name": "shadowed"}}}))
+	assert.NoError(t, RegisterCollector("Custom:Valid", fakeCollector{entries: []map[string]string{{"Name": "valid"}}}))
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(items))
+	assert.NotNil(t, findItem(items, "Custom:Valid"))
+	fileItem := findItem(items, "Custom:MyFile")
+	if assert.NotNil(t, fileItem) {
+		assert.Equal(t, MockCustomInventoryItem().SchemaVersion, fileItem.SchemaVersion)
+		assert.NotContains(t, fileItem.Content, map[string]interface{}{"Name": "shadowed"})
+	}
+}
+
+func TestGathererLimitsCombinedCount(t *testing.T) {
+	resetCollectors(t)
+	c := contextmocks.NewMockDefault()
+	readFileFunc = MockReadFile
+	readDirFunc = MockReadDir
+	for i := 0; i < CustomInventoryCountLimit; i++ {
+		assert.NoError(t, RegisterCollector(fmt.Sprintf("Custom:Collected%02d", i), fakeCollector{entries: []map[string]string{{"Name": "value"}}}))
+	}
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, CustomInventoryCountLimit, len(items))
+	assert.NotNil(t, findItem(items, "Custom:MyFile"))
+	assert.Nil(t, findItem(items, fmt.Sprintf("Custom:Collected%02d", CustomInventoryCountLimit-1)))
+}
+
+func TestGathererLimitsCollectedSize(t *testing.T) {
+	resetCollectors(t)
+	c := contextmocks.NewMockDefault()
+	readFileFunc = MockReadFile
+	readDirFunc = MockReadDir
+	entry := map[string]string{}
+	for i := 0; i < AttributeCountLimit; i++ {
+		entry[fmt.Sprintf("Attribute%02d", i)] = strings.Repeat("a", AttributeValueLengthLimit)
+	}
+	// each entry is about 200KB, so that the type exceeds the size limit of a single inventory type
+	entries := make([]map[string]string, model.SizeLimitKBPerInventoryType/200+1)
+	for i := range entries {
+		entries[i] = entry
+	}
+	assert.NoError(t, RegisterCollector("Custom:Large", fakeCollector{entries: entries}))
+	assert.NoError(t, RegisterCollector("Custom:Small", fakeCollector{entries: []map[string]string{{"Name": "value"}}}))
+
+	items, err := Gatherer(c).Run(c, model.Config{})
+
+	assert.NoError(t, err)
+	assert.Nil(t, findItem(items, "Custom:Large"))
+	assert.NotNil(t, findItem(items, "Custom:Small"))
+}
+
+func TestRegisterCollectorValidatesTypeName(t *testing.T) {
+	resetCollectors(t)
+	collector := fakeCollector{}
+
+	assert.Error(t, RegisterCollector("", collector))
+	assert.Error(t, RegisterCollector("Custom:", collector))
+	assert.Error(t, RegisterCollector("AWS:Application", collector))
+	assert.Error(t, RegisterCollector("Custom:"+strings.Repeat("a", TypeNameLengthLimit), collector))
+	assert.Error(t, RegisterCollector("Custom:NilCollector", nil))
+
+	assert.NoError(t, RegisterCollector("Custom:Software", collector))
+	err := RegisterCollector("Custom:Software", collector)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered")
+}
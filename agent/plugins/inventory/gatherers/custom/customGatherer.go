@@ -23,7 +23,6 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -140,6 +139,8 @@ func (t *T) Run(context context.T, configuration model.Config) (items []model.It
 		}
 	}
 
+	items = append(items, collectRegisteredItems(context, items)...)
+
 	count := len(items)
 	log.Debugf("Count of custom inventory items : %v.", count)
 	if count == 0 {
@@ -212,25 +213,7 @@ func convertToItem(log log.T, content []byte) (item model.Item, err error) {
 
 // validateTypeName validates custom inventory item TypeName
 func validateTypeName(log log.T, customInventoryItem model.CustomInventoryItem) (err error) {
-	typeName := customInventoryItem.TypeName
-	typeNameLength := len(typeName)
-	if typeNameLength == 0 {
-		err = errors.New("Custom inventory item has missed or empty TypeName")
-		LogError(log, err)
-		return
-	} else if typeNameLength > TypeNameLengthLimit {
-		err = fmt.Errorf("Custom inventory item TypeName (%v)'s length %v exceeded the limit: %v",
-			typeName,
-			typeNameLength,
-			TypeNameLengthLimit)
-		LogError(log, err)
-		return
-	}
-
-	// validate TypeName prefix
-	if !strings.HasPrefix(customInventoryItem.TypeName, CustomInventoryTypeNamePrefix) {
-		err = fmt.Errorf("Custom inventory item's TypeName (%v) has to start with %v",
-			customInventoryItem.TypeName, CustomInventoryTypeNamePrefix)
+	if err = checkTypeName(customInventoryItem.TypeName); err != nil {
 		LogError(log, err)
 	}
 	return
@@ -0,0 +1,250 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+// maxFieldLength caps the length of any single reported value
+const maxFieldLength = 1024
+
+// mountEntry is a mounted file system as listed by the operating system
+type mountEntry struct {
+	Device         string
+	MountPoint     string
+	FileSystemType string
+}
+
+// fileSystemUsage holds the capacity of a file system in bytes
+type fileSystemUsage struct {
+	Size      uint64
+	Used      uint64
+	Available uint64
+}
+
+// decoupling the platform specific queries for easy testability
+var (
+	listMounts = listMountedFileSystems
+	getUsage   = getFileSystemUsage
+)
+
+// pseudoFileSystemTypes are kernel, memory and other virtual file systems which do not hold data on a device
+var pseudoFileSystemTypes = map[string]struct{}{
+	"autofs":          {},
+	"binfmt_misc":     {},
+	"bpf":             {},
+	"cgroup":          {},
+	"cgroup2":         {},
+	"configfs":        {},
+	"debugfs":         {},
+	"devfs":           {},
+	"devpts":          {},
+	"devtmpfs":        {},
+	"efivarfs":        {},
+	"fdescfs":         {},
+	"fusectl":         {},
+	"fuse.gvfsd-fuse": {},
+	"fuse.lxcfs":      {},
+	"hugetlbfs":       {},
+	"kernfs":          {},
+	"linprocfs":       {},
+	"linsysfs":        {},
+	"mqueue":          {},
+	"nsfs":            {},
+	"proc":            {},
+	"procfs":          {},
+	"pstore":          {},
+	"ptyfs":           {},
+	"ramfs":           {},
+	"rpc_pipefs":      {},
+	"securityfs":      {},
+	"selinuxfs":       {},
+	"sysfs":           {},
+	"tmpfs":           {},
+	"tracefs":         {},
+}
+
+// collectFileSystemData returns the mounted file systems with their usage. A file system whose usage cannot be read
+// is still reported without sizes, and entries which cannot be identified are skipped.
+func collectFileSystemData(context context.T, includePseudo bool) ([]model.FileSystemData, error) {
+	log := context.Log()
+	mounts, err := listMounts(log)
+	if err != nil {
+		log.Errorf("Unable to list mounted file systems: %v", err)
+		return nil, fmt.Errorf("unable to list mounted file systems: %v", err)
+	}
+
+	// a file system mounted over another one hides it, so only the last mount of a mount point is reported
+	data := []model.FileSystemData{}
+	indexByMountPoint := make(map[string]int)
+	for _, mount := range mounts {
+		entry := model.FileSystemData{
+			Device:         sanitizeField(mount.Device),
+			MountPoint:     sanitizeField(mount.MountPoint),
+			FileSystemType: sanitizeField(mount.FileSystemType),
+		}
+		if entry.MountPoint == "" {
+			log.Warnf("Skipping file system without mount point, device: %q", entry.Device)
+			continue
+		}
+		if !includePseudo && isPseudoFileSystem(entry.FileSystemType) {
+			log.Debugf("Skipping pseudo file system %v mounted on %v", entry.FileSystemType, entry.MountPoint)
+			continue
+		}
+
+		if usage, usageErr := getUsage(mount.MountPoint); usageErr != nil {
+			log.Warnf("Unable to read usage of file system mounted on %v: %v", entry.MountPoint, usageErr)
+		} else {
+			entry.Size = strconv.FormatUint(usage.Size, 10)
+			entry.Used = strconv.FormatUint(usage.Used, 10)
+			entry.Available = strconv.FormatUint(usage.Available, 10)
+		}
+
+		if index, found := indexByMountPoint[entry.MountPoint]; found {
+			data[index] = entry
+			continue
+		}
+		indexByMountPoint[entry.MountPoint] = len(data)
+		data = append(data, entry)
+	}
+	log.Infof("Number of file systems detected by %v - %v", GathererName, len(data))
+	return data, nil
+}
+
+// isPseudoFileSystem reports whether the file system type does not store data on a device
+func isPseudoFileSystem(fileSystemType string) bool {
+	_, found := pseudoFileSystemTypes[strings.ToLower(fileSystemType)]
+	return found
+}
+
+// sanitizeField removes control characters and surrounding spaces and truncates overly long values
+func sanitizeField(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 32 || r == 127 {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(value, ""))
+	value = strings.TrimSpace(value)
+	if runes := []rune(value); len(runes) > maxFieldLength {
+		value = string(runes[:maxFieldLength])
+	}
+	return value
+}
+
+// parseProcMounts parses the /proc/mounts format, where each line holds the device, mount point, type, options,
+// dump and pass fields separated by spaces and where spaces, tabs, newlines and backslashes in a field are octal escaped
+func parseProcMounts(log log.T, reader io.Reader) ([]mountEntry, error) {
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			if strings.TrimSpace(line) != "" {
+				log.Warnf("Skipping malformed mount entry %q", line)
+			}
+			continue
+		}
+		mounts = append(mounts, mountEntry{
+			Device:         unescapeMountField(fields[0]),
+			MountPoint:     unescapeMountField(fields[1]),
+			FileSystemType: unescapeMountField(fields[2]),
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountField replaces the three digit octal escapes used in /proc/mounts with the characters they stand for
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var builder strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if code, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				builder.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(field[i])
+	}
+	return builder.String()
+}
+
+// mountOutputPatterns match a line of the mount command on macOS and FreeBSD, "device on /path (type, options)",
+// and on NetBSD and OpenBSD, "device on /path type type (options)"
+var mountOutputPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(.+?) on (.+) type (\S+) \(.*\)$`),
+	regexp.MustCompile(`^(.+?) on (.+) \(([^,()]+)(?:,[^()]*)?\)$`),
+}
+
+// parseMountOutput parses the output of the mount command on BSD based systems
+func parseMountOutput(log log.T, output string) []mountEntry {
+	var mounts []mountEntry
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parsed := false
+		for _, pattern := range mountOutputPatterns {
+			if match := pattern.FindStringSubmatch(line); match != nil {
+				mounts = append(mounts, mountEntry{Device: match[1], MountPoint: match[2], FileSystemType: strings.TrimSpace(match[3])})
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			log.Warnf("Skipping malformed mount entry %q", line)
+		}
+	}
+	return mounts
+}
+
+// parseDfOutput reads the usage of the file system mounted on mountPoint from the output of df -k -P.
+// The columns are counted from the end of the line because the device name may contain spaces.
+func parseDfOutput(output string, mountPoint string) (usage fileSystemUsage, err error) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasSuffix(line, " "+mountPoint) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimSuffix(line, " "+mountPoint))
+		if len(fields) < 5 {
+			break
+		}
+		// fields end with 1024-blocks, used, available and capacity
+		values := make([]uint64, 3)
+		for i := range values {
+			if values[i], err = strconv.ParseUint(fields[len(fields)-4+i], 10, 64); err != nil {
+				return usage, fmt.Errorf("unexpected df output %q", line)
+			}
+		}
+		return fileSystemUsage{Size: values[0] * 1024, Used: values[1] * 1024, Available: values[2] * 1024}, nil
+	}
+	return usage, fmt.Errorf("mount point %v not found in df output", mountPoint)
+}
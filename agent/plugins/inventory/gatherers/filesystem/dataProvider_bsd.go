@@ -0,0 +1,48 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || netbsd || openbsd
+// +build darwin freebsd netbsd openbsd
+
+package filesystem
+
+import (
+	"os/exec"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// decoupling exec.Command for easy testability
+var cmdExecutor = executeCommand
+
+func executeCommand(command string, args ...string) ([]byte, error) {
+	return exec.Command(command, args...).Output()
+}
+
+// listMountedFileSystems parses the mount table printed by the mount command
+func listMountedFileSystems(log log.T) ([]mountEntry, error) {
+	output, err := cmdExecutor("mount")
+	if err != nil {
+		return nil, err
+	}
+	return parseMountOutput(log, string(output)), nil
+}
+
+// getFileSystemUsage reads the capacity of the file system mounted on mountPoint with the POSIX output of df
+func getFileSystemUsage(mountPoint string) (usage fileSystemUsage, err error) {
+	output, err := cmdExecutor("df", "-k", "-P", mountPoint)
+	if err != nil {
+		return
+	}
+	return parseDfOutput(string(output), mountPoint)
+}
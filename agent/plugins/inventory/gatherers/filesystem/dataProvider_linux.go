@@ -0,0 +1,53 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package filesystem
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+const procMountsPath = "/proc/self/mounts"
+
+// listMountedFileSystems reads the mount table of the agent's mount namespace
+func listMountedFileSystems(log log.T) ([]mountEntry, error) {
+	file, err := os.Open(procMountsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseProcMounts(log, file)
+}
+
+// getFileSystemUsage reads the capacity of the file system mounted on mountPoint, the way df computes it
+func getFileSystemUsage(mountPoint string) (usage fileSystemUsage, err error) {
+	var stat syscall.Statfs_t
+	if err = syscall.Statfs(mountPoint, &stat); err != nil {
+		return
+	}
+	blockSize := uint64(stat.Frsize)
+	if blockSize == 0 {
+		blockSize = uint64(stat.Bsize)
+	}
+	return fileSystemUsage{
+		Size:      stat.Blocks * blockSize,
+		Used:      (stat.Blocks - stat.Bfree) * blockSize,
+		Available: stat.Bavail * blockSize,
+	}, nil
+}
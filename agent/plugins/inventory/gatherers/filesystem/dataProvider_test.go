@@ -0,0 +1,180 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filesystem
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+const sampleProcMounts = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/nvme0n1p1 / xfs rw,noatime,attr2,inode64,noquota 0 0
+/dev/nvme1n1 /mnt/my\040disk ext4 rw,relatime 0 0
+/dev/nvme2n1 /mnt/back\134slash\011tab ext4 rw,relatime 0 0
+malformed
+
+`
+
+func TestParseProcMounts(t *testing.T) {
+	mounts, err := parseProcMounts(logmocks.NewMockLog(), strings.NewReader(sampleProcMounts))
+	assert.Nil(t, err)
+	assert.Equal(t, []mountEntry{
+		{Device: "sysfs", MountPoint: "/sys", FileSystemType: "sysfs"},
+		{Device: "proc", MountPoint: "/proc", FileSystemType: "proc"},
+		{Device: "/dev/nvme0n1p1", MountPoint: "/", FileSystemType: "xfs"},
+		{Device: "/dev/nvme1n1", MountPoint: "/mnt/my disk", FileSystemType: "ext4"},
+		{Device: "/dev/nvme2n1", MountPoint: "/mnt/back\\slash\ttab", FileSystemType: "ext4"},
+	}, mounts)
+}
+
+func TestUnescapeMountFieldKeepsInvalidEscapes(t *testing.T) {
+	assert.Equal(t, `/mnt/a\9zz`, unescapeMountField(`/mnt/a\9zz`))
+	assert.Equal(t, `/mnt/end\04`, unescapeMountField(`/mnt/end\04`))
+}
+
+func TestParseMountOutput(t *testing.T) {
+	output := `/dev/disk1s1 on / (apfs, local, journaled)
+devfs on /dev (devfs, local, nobrowse)
+map auto_home on /System/Volumes/Data/home (autofs, automounted, nobrowse)
+/dev/disk3s2 on /Volumes/My Disk (hfs, local, nodev, nosuid)
+/dev/sd0a on /home type ffs (local, nodev, nosuid)
+/dev/ada0p2 on /usr (ufs)
+not a mount line
+`
+	mounts := parseMountOutput(logmocks.NewMockLog(), output)
+	assert.Equal(t, []mountEntry{
+		{Device: "/dev/disk1s1", MountPoint: "/", FileSystemType: "apfs"},
+		{Device: "devfs", MountPoint: "/dev", FileSystemType: "devfs"},
+		{Device: "map auto_home", MountPoint: "/System/Volumes/Data/home", FileSystemType: "autofs"},
+		{Device: "/dev/disk3s2", MountPoint: "/Volumes/My Disk", FileSystemType: "hfs"},
+		{Device: "/dev/sd0a", MountPoint: "/home", FileSystemType: "ffs"},
+		{Device: "/dev/ada0p2", MountPoint: "/usr", FileSystemType: "ufs"},
+	}, mounts)
+}
+
+func TestParseDfOutput(t *testing.T) {
+	output := `Filesystem     1024-blocks      Used Available Capacity  Mounted on
+//user@server/my share   1000000    250000    750000    25%    /Volumes/share
+`
+	usage, err := parseDfOutput(output, "/Volumes/share")
+	assert.Nil(t, err)
+	assert.Equal(t, fileSystemUsage{Size: 1024000000, Used: 256000000, Available: 768000000}, usage)
+
+	_, err = parseDfOutput(output, "/missing")
+	assert.NotNil(t, err)
+
+	_, err = parseDfOutput("/dev/disk1 abc 1 2 3% /", "/")
+	assert.NotNil(t, err)
+}
+
+func stubPlatform(mounts []mountEntry, listErr error, usageByMountPoint map[string]fileSystemUsage) func() {
+	listMounts = func(log log.T) ([]mountEntry, error) {
+		return mounts, listErr
+	}
+	getUsage = func(mountPoint string) (fileSystemUsage, error) {
+		if usage, found := usageByMountPoint[mountPoint]; found {
+			return usage, nil
+		}
+		return fileSystemUsage{}, errors.New("permission denied")
+	}
+	return func() {
+		listMounts = listMountedFileSystems
+		getUsage = getFileSystemUsage
+	}
+}
+
+func TestCollectFileSystemData(t *testing.T) {
+	restore := stubPlatform([]mountEntry{
+		{Device: "proc", MountPoint: "/proc", FileSystemType: "proc"},
+		{Device: "/dev/nvme0n1p1", MountPoint: "/", FileSystemType: "xfs"},
+		{Device: "tmpfs", MountPoint: "/run", FileSystemType: "tmpfs"},
+		{Device: "server:/export", MountPoint: "/mnt/nfs", FileSystemType: "nfs4"},
+	}, nil, map[string]fileSystemUsage{
+		"/":    {Size: 1000, Used: 400, Available: 600},
+		"/run": {Size: 10, Used: 1, Available: 9},
+	})
+	defer restore()
+
+	data, err := collectFileSystemData(contextmocks.NewMockDefault(), false)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.FileSystemData{
+		{Device: "/dev/nvme0n1p1", MountPoint: "/", FileSystemType: "xfs", Size: "1000", Used: "400", Available: "600"},
+		{Device: "server:/export", MountPoint: "/mnt/nfs", FileSystemType: "nfs4"},
+	}, data)
+}
+
+func TestCollectFileSystemDataIncludesPseudoFileSystems(t *testing.T) {
+	restore := stubPlatform([]mountEntry{
+		{Device: "proc", MountPoint: "/proc", FileSystemType: "proc"},
+		{Device: "tmpfs", MountPoint: "/run", FileSystemType: "tmpfs"},
+	}, nil, map[string]fileSystemUsage{
+		"/run": {Size: 10, Used: 1, Available: 9},
+	})
+	defer restore()
+
+	data, err := collectFileSystemData(contextmocks.NewMockDefault(), true)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.FileSystemData{
+		{Device: "proc", MountPoint: "/proc", FileSystemType: "proc"},
+		{Device: "tmpfs", MountPoint: "/run", FileSystemType: "tmpfs", Size: "10", Used: "1", Available: "9"},
+	}, data)
+}
+
+func TestCollectFileSystemDataReportsLastMountOfMountPoint(t *testing.T) {
+	restore := stubPlatform([]mountEntry{
+		{Device: "/dev/nvme1n1", MountPoint: "/data", FileSystemType: "ext4"},
+		{Device: "/dev/nvme0n1p1", MountPoint: "/", FileSystemType: "xfs"},
+		{Device: "/dev/nvme2n1", MountPoint: "/data", FileSystemType: "xfs"},
+	}, nil, map[string]fileSystemUsage{})
+	defer restore()
+
+	data, err := collectFileSystemData(contextmocks.NewMockDefault(), false)
+	assert.Nil(t, err)
+	assert.Equal(t, []model.FileSystemData{
+		{Device: "/dev/nvme2n1", MountPoint: "/data", FileSystemType: "xfs"},
+		{Device: "/dev/nvme0n1p1", MountPoint: "/", FileSystemType: "xfs"},
+	}, data)
+}
+
+func TestCollectFileSystemDataSanitizesEntries(t *testing.T) {
+	restore := stubPlatform([]mountEntry{
+		{Device: "/dev/sdb\x1b[31m", MountPoint: "/mnt/odd\nname", FileSystemType: " ext4 "},
+		{Device: "/dev/sdc", MountPoint: "\t", FileSystemType: "ext4"},
+		{Device: strings.Repeat("d", maxFieldLength+10), MountPoint: "/mnt/long", FileSystemType: "ext4"},
+	}, nil, map[string]fileSystemUsage{})
+	defer restore()
+
+	data, err := collectFileSystemData(contextmocks.NewMockDefault(), false)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(data))
+	assert.Equal(t, model.FileSystemData{Device: "/dev/sdb[31m", MountPoint: "/mnt/oddname", FileSystemType: "ext4"}, data[0])
+	assert.Equal(t, maxFieldLength, len(data[1].Device))
+}
+
+func TestCollectFileSystemDataListError(t *testing.T) {
+	restore := stubPlatform(nil, errors.New("mount table unavailable"), nil)
+	defer restore()
+
+	data, err := collectFileSystemData(contextmocks.NewMockDefault(), false)
+	assert.NotNil(t, err)
+	assert.Nil(t, data)
+}
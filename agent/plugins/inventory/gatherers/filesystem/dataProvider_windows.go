@@ -0,0 +1,96 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package filesystem
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"golang.org/x/sys/windows"
+)
+
+// listMountedFileSystems returns the volumes mounted on a drive letter, drives without media are left out
+func listMountedFileSystems(log log.T) ([]mountEntry, error) {
+	buffer := make([]uint16, 254)
+	length, err := windows.GetLogicalDriveStrings(uint32(len(buffer)), &buffer[0])
+	if err != nil {
+		return nil, err
+	}
+	if int(length) > len(buffer) {
+		return nil, fmt.Errorf("drive list does not fit %v characters", len(buffer))
+	}
+
+	var mounts []mountEntry
+	// the drive list is a sequence of null terminated root paths such as C:\
+	for _, root := range splitNullTerminated(buffer[:length]) {
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			continue
+		}
+		driveType := windows.GetDriveType(rootPtr)
+		if driveType == windows.DRIVE_NO_ROOT_DIR || driveType == windows.DRIVE_UNKNOWN {
+			continue
+		}
+
+		fileSystemName := make([]uint16, windows.MAX_PATH+1)
+		if err := windows.GetVolumeInformation(rootPtr, nil, 0, nil, nil, nil, &fileSystemName[0], uint32(len(fileSystemName))); err != nil {
+			log.Debugf("Skipping drive %v, its volume information is not available: %v", root, err)
+			continue
+		}
+
+		device := root
+		volumeName := make([]uint16, windows.MAX_PATH+1)
+		if err := windows.GetVolumeNameForVolumeMountPoint(rootPtr, &volumeName[0], uint32(len(volumeName))); err == nil {
+			device = windows.UTF16ToString(volumeName)
+		}
+
+		mounts = append(mounts, mountEntry{
+			Device:         device,
+			MountPoint:     root,
+			FileSystemType: windows.UTF16ToString(fileSystemName),
+		})
+	}
+	return mounts, nil
+}
+
+// getFileSystemUsage reads the capacity of the volume mounted on mountPoint
+func getFileSystemUsage(mountPoint string) (usage fileSystemUsage, err error) {
+	mountPointPtr, err := windows.UTF16PtrFromString(mountPoint)
+	if err != nil {
+		return
+	}
+	var availableToCaller, total, free uint64
+	if err = windows.GetDiskFreeSpaceEx(mountPointPtr, &availableToCaller, &total, &free); err != nil {
+		return
+	}
+	return fileSystemUsage{Size: total, Used: total - free, Available: availableToCaller}, nil
+}
+
+// splitNullTerminated splits a buffer holding consecutive null terminated strings
+func splitNullTerminated(buffer []uint16) (values []string) {
+	start := 0
+	for i, char := range buffer {
+		if char != 0 {
+			continue
+		}
+		if i > start {
+			values = append(values, windows.UTF16ToString(buffer[start:i]))
+		}
+		start = i + 1
+	}
+	return values
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package filesystem contains a file system gatherer which reports mounted file systems and their usage.
+package filesystem
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+)
+
+const (
+	// GathererName captures name of file system gatherer, the file systems are reported as a custom inventory type
+	// because the service only accepts its predefined AWS: types
+	GathererName = "Custom:FileSystem"
+	// SchemaVersionOfFileSystemGatherer represents schema version of file system gatherer
+	SchemaVersionOfFileSystemGatherer = "1.0"
+)
+
+type T struct{}
+
+// Gatherer returns new file system gatherer
+func Gatherer(context context.T) *T {
+	return new(T)
+}
+
+var collectData = collectFileSystemData
+
+// Name returns name of file system gatherer
+func (t *T) Name() string {
+	return GathererName
+}
+
+// Run executes file system gatherer and returns list of inventory.Item comprising of file system data.
+// Pseudo file systems are only reported when the configuration asks for them.
+func (t *T) Run(context context.T, configuration model.Config) (items []model.Item, err error) {
+	//CaptureTime must comply with format: 2016-07-30T18:15:37Z to comply with regex at SSM.
+	currentTime := time.Now().UTC()
+	captureTime := currentTime.Format(time.RFC3339)
+
+	var data []model.FileSystemData
+	if data, err = collectData(context, configuration.IncludePseudoFileSystems); err != nil {
+		return
+	}
+
+	items = append(items, model.Item{
+		Name:          t.Name(),
+		SchemaVersion: SchemaVersionOfFileSystemGatherer,
+		Content:       data,
+		CaptureTime:   captureTime,
+	})
+	return
+}
+
+// RequestStop stops the execution of file system gatherer.
+func (t *T) RequestStop() error {
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filesystem
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFileSystems = []model.FileSystemData{
+	{
+		Device:         "/dev/nvme0n1p1",
+		MountPoint:     "/",
+		FileSystemType: "xfs",
+		Size:           "8577331200",
+		Used:           "2147483648",
+		Available:      "6429847552",
+	},
+}
+
+func TestGatherer(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T, includePseudo bool) ([]model.FileSystemData, error) {
+		assert.False(t, includePseudo)
+		return testFileSystems, nil
+	}
+	defer func() { collectData = collectFileSystemData }()
+
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "Custom:FileSystem", items[0].Name)
+	assert.Equal(t, SchemaVersionOfFileSystemGatherer, items[0].SchemaVersion)
+	assert.Equal(t, testFileSystems, items[0].Content)
+}
+
+func TestGathererIncludesPseudoFileSystemsOnRequest(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	var requested bool
+	collectData = func(context context.T, includePseudo bool) ([]model.FileSystemData, error) {
+		requested = includePseudo
+		return testFileSystems, nil
+	}
+	defer func() { collectData = collectFileSystemData }()
+
+	_, err := gatherer.Run(contextMock, model.Config{Collection: "Enabled", IncludePseudoFileSystems: true})
+	assert.Nil(t, err)
+	assert.True(t, requested)
+}
+
+func TestGathererError(t *testing.T) {
+	contextMock := contextmocks.NewMockDefault()
+	gatherer := Gatherer(contextMock)
+	collectData = func(context context.T, includePseudo bool) ([]model.FileSystemData, error) {
+		return nil, errors.New("mount table unavailable")
+	}
+	defer func() { collectData = collectFileSystemData }()
+
+	items, err := gatherer.Run(contextMock, model.Config{})
+	assert.NotNil(t, err)
+	assert.Empty(t, items)
+}
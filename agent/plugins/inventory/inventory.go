@@ -16,6 +16,7 @@ package inventory
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -33,8 +34,10 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/awscomponent"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/billinginfo"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/container"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/custom"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/file"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/filesystem"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/instancedetailedinformation"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/network"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/registry"
@@ -60,6 +63,7 @@ const (
 	errorMsgForInabilityToSendDataToSSM       = "inventory data could not be uploaded to Systems Manager. Additional troubleshooting information - %v"
 	errorMsgForInabilityToSendFileDataToSSM   = "File inventory data could not be uploaded to Systems Manager. Additional troubleshooting information - %v"
 	msgWhenNoDataToReturnForInventoryPlugin   = "Inventory policy has been successfully applied but there is no inventory data to upload to SSM"
+	msgWhenSomeGatherersWereAborted           = "Inventory data of the types that were collected has been uploaded to SSM"
 	successfulMsgForInventoryPlugin           = "Inventory policy has been successfully applied and collected inventory data has been uploaded to SSM"
 	largeSizeItem                             = 1024 * 1024 //1MB
 	fileInventoryItemName                     = "AWS:File"
@@ -78,8 +82,17 @@ type PluginInput struct {
 	WindowsRegistry             string
 	WindowsUpdates              string
 	InstanceDetailedInformation string
-	CustomInventory             string
-	CustomInventoryDirectory    string
+	Containers                  string
+	FileSystems                 string
+	IncludePseudoFileSystems    string
+	// IncludeApplicationCollectionDetails set to "true" reports the capture time and source of every application
+	IncludeApplicationCollectionDetails string
+	CustomInventory                     string
+	CustomInventoryDirectory            string
+	// IncludeApplications and ExcludeApplications are comma separated lists, or JSON arrays, of globs or regular
+	// expressions prefixed with "regex:", restricting the applications reported to the ones whose whole name matches
+	IncludeApplications string
+	ExcludeApplications string
 }
 
 // Plugin encapsulates the logic of configuring, starting and stopping inventory plugin
@@ -153,20 +166,26 @@ func (p *Plugin) ApplyInventoryPolicy(inventoryInput PluginInput, output iohandl
 		return
 	}
 
-	//execute all eligible gatherers with their respective config
-	if items, err = p.RunGatherers(gatherers); err != nil {
-		log.Info(err.Error())
+	//execute all eligible gatherers with their respective config, when some of them were aborted
+	//the inventory types collected by the others are still uploaded but the execution fails
+	var gatherErr error
+	if items, gatherErr = p.RunGatherers(gatherers); gatherErr != nil {
+		log.Info(gatherErr.Error())
 		output.SetExitCode(1)
-		output.AppendError(err.Error())
-		return
+		output.AppendError(gatherErr.Error())
+		if !model.IsCollectionAborted(gatherErr) {
+			return
+		}
 	}
 
 	//check if there is data to send to SSM
 	if len(items) == 0 {
 		//no data to send to ssm - no need to call PutInventory API
 		log.Info(msgWhenNoDataToReturnForInventoryPlugin)
-		output.SetExitCode(0)
-		output.AppendInfo(msgWhenNoDataToReturnForInventoryPlugin)
+		if gatherErr == nil {
+			output.SetExitCode(0)
+			output.AppendInfo(msgWhenNoDataToReturnForInventoryPlugin)
+		}
 		return
 	}
 
@@ -193,6 +212,10 @@ func (p *Plugin) ApplyInventoryPolicy(inventoryInput PluginInput, output iohandl
 	}
 
 	log.Infof("%v uploaded inventory data to SSM", Name())
+	if gatherErr != nil {
+		output.AppendInfo(msgWhenSomeGatherersWereAborted)
+		return
+	}
 	output.SetExitCode(0)
 	output.AppendInfo(successfulMsgForInventoryPlugin)
 
@@ -487,6 +510,28 @@ func (p *Plugin) validateCustomGatherer(context context.T, collectionPolicy, loc
 	return
 }
 
+// validateFileSystemGatherer enables the file system gatherer, which leaves out pseudo file systems unless includePseudo is true
+func (p *Plugin) validateFileSystemGatherer(context context.T, collectionPolicy, includePseudo string) (status bool, gatherer gatherers.T, policy model.Config, err error) {
+	if status, gatherer, policy, err = p.validatePredefinedGatherer(context, collectionPolicy, filesystem.GathererName); status {
+		policy.IncludePseudoFileSystems = strings.EqualFold(includePseudo, "true")
+	}
+	return
+}
+
+// validateApplicationGatherer enables the application gatherer, which reports the collection details of the applications
+// when includeCollectionDetails is true and only the applications whose name matches the include and exclude patterns
+func (p *Plugin) validateApplicationGatherer(context context.T, collectionPolicy, includeCollectionDetails, includeApplications, excludeApplications string) (status bool, gatherer gatherers.T, policy model.Config, err error) {
+	if status, gatherer, policy, err = p.validatePredefinedGatherer(context, collectionPolicy, application.GathererName); status {
+		if _, err = application.NewNameFilter(includeApplications, excludeApplications); err != nil {
+			return false, nil, model.Config{}, err
+		}
+		policy.IncludeCollectionDetails = strings.EqualFold(includeCollectionDetails, "true")
+		policy.IncludeApplications = includeApplications
+		policy.ExcludeApplications = excludeApplications
+	}
+	return
+}
+
 // ValidateInventoryInput validates inventory input and returns a map of eligible gatherers & their corresponding config.
 // It throws an error if gatherer is not recognized/installed.
 func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (configuredGatherers map[gatherers.T]model.Config, err error) {
@@ -501,7 +546,6 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 	log.Debugf("Validating gatherers from inventory input - \n%v", jsonutil.Indent(string(dataB)))
 
 	predefinedGatherers := map[string]string{
-		application.GathererName:                 input.Applications,
 		awscomponent.GathererName:                input.AWSComponents,
 		role.GathererName:                        input.WindowsRoles,
 		service.GathererName:                     input.Services,
@@ -509,6 +553,7 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		billinginfo.GathererName:                 input.BillingInfo,
 		windowsUpdate.GathererName:               input.WindowsUpdates,
 		instancedetailedinformation.GathererName: input.InstanceDetailedInformation,
+		container.GathererName:                   input.Containers,
 	}
 
 	predefinedGatherersWithFilters := map[string]string{
@@ -538,6 +583,22 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 		}
 	}
 
+	//checking application gatherer
+	if canGathererRun, gatherer, cfg, err = p.validateApplicationGatherer(context, input.Applications, input.IncludeApplicationCollectionDetails, input.IncludeApplications, input.ExcludeApplications); err != nil {
+		log.Errorf("Error while validating gatherer %v", err.Error())
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
+	//checking file system gatherer
+	if canGathererRun, gatherer, cfg, err = p.validateFileSystemGatherer(context, input.FileSystems, input.IncludePseudoFileSystems); err != nil {
+		log.Errorf("Error while validating gatherer %v", err.Error())
+		return
+	} else if canGathererRun {
+		configuredGatherers[gatherer] = cfg
+	}
+
 	//checking custom gatherer
 	if canGathererRun, gatherer, cfg, err = p.validateCustomGatherer(context, input.CustomInventory, input.CustomInventoryDirectory); err != nil {
 		log.Errorf("Error while validating gatherer %v", err.Error())
@@ -550,7 +611,8 @@ func (p *Plugin) ValidateInventoryInput(context context.T, input PluginInput) (c
 }
 
 // RunGatherers execute given array of gatherers and accordingly returns. It returns error if gatherer is not
-// registered or if at any stage the data returned breaches size limit
+// registered or if at any stage the data returned breaches size limit. Gatherers that are aborted are left out
+// of the items, the other gatherers still run and the returned error lists the aborted ones.
 func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []model.Item, err error) {
 
 	//NOTE: Currently all gatherers will be invoked in synchronous & sequential fashion.
@@ -558,6 +620,7 @@ func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []m
 	//mainly for custom inventory gatherer to send data independently of associate.
 
 	var gItems []model.Item
+	var abortErrs []error
 
 	log := p.context.Log()
 
@@ -567,7 +630,13 @@ func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []m
 		start := time.Now()
 
 		if gItems, err = gatherer.Run(p.context, config); err != nil {
-			err = fmt.Errorf("Encountered error while executing %v. Error - %v", name, err.Error())
+			err = fmt.Errorf("Encountered error while executing %v. Error - %w", name, err)
+			if model.IsCollectionAborted(err) {
+				log.Warn(err.Error())
+				abortErrs = append(abortErrs, err)
+				err = nil
+				continue
+			}
 			break
 
 		} else {
@@ -589,6 +658,9 @@ func (p *Plugin) RunGatherers(gatherers map[gatherers.T]model.Config) (items []m
 		}
 	}
 
+	if err == nil {
+		err = errors.Join(abortErrs...)
+	}
 	return
 }
 
@@ -677,6 +749,22 @@ func (p *Plugin) ParseAssociationIdFromFileName(input string) string {
 	return strings.Split(input, ".")[0]
 }
 
+// stopGatherersOnCancel asks the gatherers to stop once the document is cancelled, so that a gatherer
+// waiting on a hung command does not keep the association in progress
+func (p *Plugin) stopGatherersOnCancel(cancelFlag task.CancelFlag) {
+	log := p.context.Log()
+	cancelFlag.Wait()
+	if !cancelFlag.Canceled() && !cancelFlag.ShutDown() {
+		return
+	}
+	log.Infof("%v was cancelled, stopping the running gatherers", Name())
+	for name, gatherer := range p.supportedGatherers {
+		if err := gatherer.RequestStop(); err != nil {
+			log.Debugf("Unable to stop gatherer %v - %v", name, err)
+		}
+	}
+}
+
 // WorkerConfig plugin implementation
 
 // Execute runs the inventory plugin
@@ -693,7 +781,7 @@ func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelF
 	dataB, _ = json.Marshal(config)
 	log.Debugf("Starting %v with configuration \n%v", pluginName, jsonutil.Indent(string(dataB)))
 
-	//TODO: take care of cancel flag (SSM-INV-233)
+	go p.stopGatherersOnCancel(cancelFlag)
 
 	associationID = p.ParseAssociationIdFromFileName(config.BookKeepingFileName)
 
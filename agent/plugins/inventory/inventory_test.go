@@ -16,6 +16,7 @@ package inventory
 
 import (
 	"bytes"
+	gocontext "context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -23,9 +24,13 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/application"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/gatherers/filesystem"
 	gatherers2 "github.com/aws/amazon-ssm-agent/agent/plugins/inventory/mocks/gatherers"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/inventory/model"
+	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/stretchr/testify/assert"
@@ -246,6 +251,55 @@ func TestRunGatherers(t *testing.T) {
 	assert.NotNil(t, err, "%v should throw errors", errorProneGatherer)
 }
 
+func TestRunGatherersContinuesAfterAbortedGatherer(t *testing.T) {
+	p, _ := MockInventoryPlugin([]string{}, []string{})
+	config := model.Config{Collection: "Enabled"}
+	data := MockInventoryItems()
+
+	completedGatherer := gatherers2.NewMockDefault()
+	completedGatherer.On("Name").Return("Completed-1")
+	completedGatherer.On("Run", p.context, config).Return(data, nil)
+	abortedGatherer := gatherers2.NewMockDefault()
+	abortedGatherer.On("Name").Return("Aborted-1")
+	abortedGatherer.On("Run", p.context, config).Return([]model.Item{}, fmt.Errorf("rpm did not complete: %w", gocontext.DeadlineExceeded))
+
+	items, err := p.RunGatherers(map[gatherers.T]model.Config{
+		completedGatherer: config,
+		abortedGatherer:   config,
+	})
+
+	assert.True(t, model.IsCollectionAborted(err))
+	assert.Contains(t, err.Error(), "Encountered error while executing Aborted-1")
+	assert.Equal(t, data, items)
+	completedGatherer.AssertExpectations(t)
+}
+
+func TestStopGatherersOnCancel(t *testing.T) {
+	p, _ := MockInventoryPlugin([]string{"Gatherer-1"}, []string{"Gatherer-1"})
+	gatherer := p.supportedGatherers["Gatherer-1"].(*gatherers2.Mock)
+	gatherer.On("RequestStop").Return(nil)
+	cancelFlag := new(taskmocks.MockCancelFlag)
+	cancelFlag.On("Wait").Return(task.Canceled)
+	cancelFlag.On("Canceled").Return(true)
+
+	p.stopGatherersOnCancel(cancelFlag)
+
+	gatherer.AssertExpectations(t)
+}
+
+func TestStopGatherersOnCancel_Completed(t *testing.T) {
+	p, _ := MockInventoryPlugin([]string{"Gatherer-1"}, []string{"Gatherer-1"})
+	gatherer := p.supportedGatherers["Gatherer-1"].(*gatherers2.Mock)
+	cancelFlag := new(taskmocks.MockCancelFlag)
+	cancelFlag.On("Wait").Return(task.Completed)
+	cancelFlag.On("Canceled").Return(false)
+	cancelFlag.On("ShutDown").Return(false)
+
+	p.stopGatherersOnCancel(cancelFlag)
+
+	gatherer.AssertNotCalled(t, "RequestStop")
+}
+
 func TestVerifyInventoryDataSize(t *testing.T) {
 	var smallItem, largeItem model.Item
 	var items []model.Item
@@ -451,3 +505,49 @@ func TestShouldRetryWithNonOptimizedData(t *testing.T) {
 		assert.Equal(t, testCase.shouldRetry, shouldRetryWithNonOptimizedData(testCase.err, log))
 	}
 }
+
+func TestValidateFileSystemGatherer(t *testing.T) {
+	p, _ := MockInventoryPlugin([]string{filesystem.GathererName}, []string{filesystem.GathererName})
+
+	status, _, policy, err := p.validateFileSystemGatherer(p.context, model.Enabled, "True")
+	assert.Nil(t, err)
+	assert.True(t, status)
+	assert.True(t, policy.IncludePseudoFileSystems)
+
+	status, _, policy, err = p.validateFileSystemGatherer(p.context, model.Enabled, "")
+	assert.Nil(t, err)
+	assert.True(t, status)
+	assert.False(t, policy.IncludePseudoFileSystems)
+
+	status, _, _, err = p.validateFileSystemGatherer(p.context, "Disabled", "true")
+	assert.Nil(t, err)
+	assert.False(t, status)
+}
+
+func TestValidateApplicationGatherer(t *testing.T) {
+	p, _ := MockInventoryPlugin([]string{application.GathererName}, []string{application.GathererName})
+
+	status, _, policy, err := p.validateApplicationGatherer(p.context, model.Enabled, "true", "", "")
+	assert.Nil(t, err)
+	assert.True(t, status)
+	assert.True(t, policy.IncludeCollectionDetails)
+
+	status, _, policy, err = p.validateApplicationGatherer(p.context, model.Enabled, "", "", "")
+	assert.Nil(t, err)
+	assert.True(t, status)
+	assert.False(t, policy.IncludeCollectionDetails)
+
+	status, _, _, err = p.validateApplicationGatherer(p.context, "Disabled", "true", "", "")
+	assert.Nil(t, err)
+	assert.False(t, status)
+
+	status, _, policy, err = p.validateApplicationGatherer(p.context, model.Enabled, "", "python*, regex:^libc", "*-devel")
+	assert.Nil(t, err)
+	assert.True(t, status)
+	assert.Equal(t, "python*, regex:^libc", policy.IncludeApplications)
+	assert.Equal(t, "*-devel", policy.ExcludeApplications)
+
+	status, _, _, err = p.validateApplicationGatherer(p.context, model.Enabled, "", "regex:lib(", "")
+	assert.Error(t, err)
+	assert.False(t, status)
+}
@@ -15,6 +15,8 @@
 package model
 
 import (
+	"context"
+	"errors"
 	"sort"
 	"strings"
 
@@ -88,6 +90,11 @@ type ApplicationData struct {
 	Summary         string        `json:",omitempty"`
 	PackageId       string        `json:",omitempty"`
 	CompType        ComponentType `json:"-"`
+	// CaptureTime is when the package query that found the application ran, it is set once per collection pass.
+	// CaptureTime and Source are only reported when the collection details are enabled for the gatherer.
+	CaptureTime string `json:",omitempty"`
+	// Source is the package manager or query that found the application, e.g. rpm or dpkg
+	Source string `json:",omitempty"`
 }
 
 // FileData captures all attributes present in AWS:File inventory type
@@ -175,6 +182,38 @@ type InstanceDetailedInformation struct {
 	KernelVersion         string
 }
 
+// ContainerData captures all attributes present in Custom:Container inventory type
+type ContainerData struct {
+	ContainerId string
+	Name        string `json:",omitempty"`
+	Image       string
+	ImageId     string `json:",omitempty"`
+	Status      string
+	CreatedTime string
+	Runtime     string
+}
+
+// ContainerImageData captures all attributes present in Custom:ContainerImage inventory type
+type ContainerImageData struct {
+	Repository  string
+	Tag         string
+	Digest      string `json:",omitempty"`
+	ImageId     string `json:",omitempty"`
+	CreatedTime string `json:",omitempty"`
+	Runtime     string
+}
+
+// FileSystemData captures all attributes present in Custom:FileSystem inventory type.
+// Sizes are in bytes and are left out when the usage of the file system could not be read.
+type FileSystemData struct {
+	Device         string
+	MountPoint     string
+	FileSystemType string
+	Size           string `json:",omitempty"`
+	Used           string `json:",omitempty"`
+	Available      string `json:",omitempty"`
+}
+
 // Config captures all various properties (including optional) that can be supplied to a gatherer.
 // NOTE: Not all properties will be applicable to all gatherers.
 // E.g: Applications gatherer uses Collection, Files use Filters, Custom uses Collection & Location.
@@ -182,6 +221,13 @@ type Config struct {
 	Collection string `json:"Collection"`
 	Filters    string `json:"Filters"`
 	Location   string `json:"Location"`
+	// IncludePseudoFileSystems makes the file system gatherer also report pseudo file systems such as proc or tmpfs
+	IncludePseudoFileSystems bool `json:"IncludePseudoFileSystems"`
+	// IncludeCollectionDetails makes the application gatherer report when and from which source each application was collected
+	IncludeCollectionDetails bool `json:"IncludeCollectionDetails"`
+	// IncludeApplications and ExcludeApplications are comma separated lists, or JSON arrays, of name patterns restricting the applications reported
+	IncludeApplications string `json:"IncludeApplications"`
+	ExcludeApplications string `json:"ExcludeApplications"`
 }
 
 // Policy defines how an inventory policy document looks like
@@ -208,6 +254,12 @@ func FormatArchitecture(arch string) string {
 	return arch
 }
 
+// IsCollectionAborted returns true if err reports a collection that was aborted because it timed out or was asked
+// to stop, rather than one that failed
+func IsCollectionAborted(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
 // ByNamePublisherVersion implements sorting ApplicationData elements by name (case insensitive) then by publisher (case insensitive) then version (by component)
 type ByNamePublisherVersion []ApplicationData
 
@@ -31,6 +31,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
 )
 
 const (
@@ -298,3 +299,21 @@ func ValidatePluginId(id string) bool {
 	}
 	return true
 }
+
+// ReportConfigurationResult sets the per item results of a configuration plugin as the plugin output and
+// marks the plugin as failed when err is set, cancelled when the document was cancelled or succeeded otherwise
+func ReportConfigurationResult(output iohandler.IOHandler, results interface{}, err error, cancelFlag task.CancelFlag) {
+	if resultsJson, jsonErr := jsonutil.MarshalIndent(results); jsonErr == nil {
+		output.AppendInfo(resultsJson)
+	}
+	output.SetOutput(results)
+
+	switch {
+	case err != nil:
+		output.MarkAsFailed(err)
+	case cancelFlag.Canceled():
+		output.MarkAsCancelled()
+	default:
+		output.MarkAsSucceeded()
+	}
+}
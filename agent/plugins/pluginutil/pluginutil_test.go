@@ -16,10 +16,15 @@ package pluginutil
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -217,3 +222,30 @@ func TestValidateIdInput(t *testing.T) {
 	validity3 := ValidatePluginId(idValue)
 	assert.False(t, validity3)
 }
+
+func TestReportConfigurationResult(t *testing.T) {
+	results := []string{"configured"}
+	testCases := []struct {
+		err            error
+		cancel         bool
+		expectedStatus contracts.ResultStatus
+	}{
+		{expectedStatus: contracts.ResultStatusSuccess},
+		{err: errors.New("failed to configure 1 of 1 items"), expectedStatus: contracts.ResultStatusFailed},
+		{cancel: true, expectedStatus: contracts.ResultStatusCancelled},
+	}
+
+	for _, testCase := range testCases {
+		output := iohandler.NewDefaultIOHandler(context.NewMockDefault(), contracts.IOConfiguration{})
+		cancelFlag := task.NewChanneledCancelFlag()
+		if testCase.cancel {
+			cancelFlag.Set(task.Canceled)
+		}
+
+		ReportConfigurationResult(output, results, testCase.err, cancelFlag)
+
+		assert.Equal(t, testCase.expectedStatus, output.GetStatus())
+		assert.Equal(t, results, output.GetOutput())
+		assert.Contains(t, output.GetStdout(), "configured")
+	}
+}
@@ -171,7 +171,7 @@ func (p *Plugin) runCommands(pluginID string, pluginInput PSModulePluginInput, o
 	commandArguments := append(pluginutil.GetShellArguments(), scriptPath)
 
 	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(p.context, pluginInput.WorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, make(map[string]string))
+	exitCode, err := executers.WithResourceUsageRecorder(p.CommandExecuter, output).NewExecute(p.context, pluginInput.WorkingDirectory, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, make(map[string]string))
 
 	// Set output status
 	output.SetExitCode(exitCode)
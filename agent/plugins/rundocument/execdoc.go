@@ -16,7 +16,6 @@
 package rundocument
 
 import (
-	"encoding/json"
 	"path/filepath"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
@@ -25,35 +24,35 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/task"
-	"gopkg.in/yaml.v2"
 )
 
 type ExecDocument interface {
 	ParseDocument(context context.T, documentRaw []byte, orchestrationDir string,
 		s3Bucket string, s3KeyPrefix string, messageID string, documentID string, defaultWorkingDirectory string,
-		params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error)
-	ExecuteDocument(config contracts.Configuration, context context.T, pluginInput []contracts.PluginState, documentID string,
-		documentCreatedDate string) (chan contracts.DocumentResult, error)
+		executionDepth int, params map[string]interface{}) (pluginsInfo []contracts.PluginState, ioConfig contracts.IOConfiguration, err error)
+	ExecuteDocument(config contracts.Configuration, context context.T, pluginInput []contracts.PluginState, ioConfig contracts.IOConfiguration,
+		documentID string, documentCreatedDate string) (chan contracts.DocumentResult, error)
 }
 
 type ExecDocumentImpl struct {
 	DocExecutor executer.Executer
 }
 
-// ParseDocument parses the remote document obtained to a format that the executor can use.
+// ParseDocument parses the remote document obtained to a format that the executor can use, along with its document-level settings.
 // This function is also responsible for all the validation of document and replacement of parameters
 func (exec ExecDocumentImpl) ParseDocument(context context.T, documentRaw []byte, orchestrationDir string,
 	s3Bucket string, s3KeyPrefix string, messageID string, documentID string, defaultWorkingDirectory string,
-	params map[string]interface{}) (pluginsInfo []contracts.PluginState, err error) {
+	executionDepth int, params map[string]interface{}) (pluginsInfo []contracts.PluginState, ioConfig contracts.IOConfiguration, err error) {
 	log := context.Log()
 	docContent := docparser.DocContent{
 		InvokedPlugin: appconfig.PluginRunDocument,
 	}
-	if err := json.Unmarshal(documentRaw, &docContent); err != nil {
-		if err := yaml.Unmarshal(documentRaw, &docContent); err != nil {
-			log.Error("Unmarshaling remote resource document failed. Please make sure the document is in the correct JSON or YAML formal")
-			return pluginsInfo, err
-		}
+	if _, normalized := docparser.NormalizeDocumentRaw(documentRaw); normalized {
+		log.Info("Removed the byte order mark or trailing whitespace of the document before parsing it")
+	}
+	if err := docparser.UnmarshalDocumentContent(documentRaw, &docContent); err != nil {
+		log.Errorf("Unmarshaling remote resource document failed. Please make sure the document is in the correct JSON or YAML format: %v", err)
+		return pluginsInfo, ioConfig, err
 	}
 	parserInfo := docparser.DocumentParserInfo{
 		OrchestrationDir:  orchestrationDir,
@@ -62,34 +61,35 @@ func (exec ExecDocumentImpl) ParseDocument(context context.T, documentRaw []byte
 		MessageId:         messageID,
 		DocumentId:        documentID,
 		DefaultWorkingDir: defaultWorkingDirectory,
+		ExecutionDepth:    executionDepth,
 	}
 
 	pluginsInfo, err = docContent.ParseDocument(context, contracts.DocumentInfo{}, parserInfo, params)
+	ioConfig = docContent.GetIOConfiguration(parserInfo)
 	log.Debug("Parsed document - ", docContent)
 	log.Debug("Plugins Info - ", pluginsInfo)
 	return
 }
 
-// ExecuteDocument is responsible to execute the sub-documents that are created or downloaded by the executeCommand plugin
-func (exec ExecDocumentImpl) ExecuteDocument(config contracts.Configuration, context context.T, pluginInput []contracts.PluginState, documentID string,
-	documentCreatedDate string) (resultChannels chan contracts.DocumentResult, err error) {
+// ExecuteDocument is responsible to execute the sub-documents that are created or downloaded by the executeCommand plugin.
+// The sub-document keeps its document-level settings such as onFailure, its output is part of the output of the step running it.
+func (exec ExecDocumentImpl) ExecuteDocument(config contracts.Configuration, context context.T, pluginInput []contracts.PluginState, ioConfig contracts.IOConfiguration,
+	documentID string, documentCreatedDate string) (resultChannels chan contracts.DocumentResult, err error) {
 	log := context.Log()
 	log.Info("Running sub-document")
 
 	// The full path of orchestrationDir should look like:
 	// Linux: /var/lib/amazon/ssm/instance-id/document/orchestration/command-id/plugin-id
 	// Windows: %PROGRAMDATA%\Amazon\SSM\InstanceData\instance-id\document\orchestration\command-id\plugin-id
-	orchestrationDir := filepath.Join(config.OrchestrationDirectory, config.PluginID)
+	ioConfig.OrchestrationDirectory = filepath.Join(config.OrchestrationDirectory, config.PluginID)
+	ioConfig.OutputS3BucketName = ""
+	ioConfig.OutputS3KeyPrefix = ""
 
 	docState := contracts.DocumentState{
 		DocumentInformation: contracts.DocumentInfo{
 			DocumentID: documentID,
 		},
-		IOConfig: contracts.IOConfiguration{
-			OrchestrationDirectory: orchestrationDir,
-			OutputS3BucketName:     "",
-			OutputS3KeyPrefix:      "",
-		},
+		IOConfig:                   ioConfig,
 		InstancePluginsInformation: pluginInput,
 		UpstreamServiceName:        config.UpstreamServiceName,
 	}
@@ -21,18 +21,27 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/basicexecuter"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/network"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/downloadcontent/httpresource/handler"
 	ssmsvc "github.com/aws/amazon-ssm-agent/agent/ssm"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/agent/times"
 	"github.com/aws/aws-sdk-go/service/ssm"
 
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"strings"
@@ -42,12 +51,16 @@ import (
 )
 
 const (
-	executeCommandMaxDepth = 3 //Maximum depth of document execution
-	jsonExtension          = ".json"
-	yamlExtension          = ".yaml"
+	jsonExtension = ".json"
+	yamlExtension = ".yaml"
 
 	SSMDocumentType = "SSMDocument"
 	LocalPathType   = "LocalPath"
+	HTTPSType       = "HTTPS"
+
+	defaultHTTPSDocumentName = "document"
+	// httpsDownloadTimeout bounds the whole download of an HTTPS document, redirects included
+	httpsDownloadTimeout = 2 * time.Minute
 
 	downloadsDir = "downloads" //Directory under the orchestration directory where the downloaded resource resides
 
@@ -66,23 +79,26 @@ func NewPlugin(context context.T) (*Plugin, error) {
 
 // Plugin is the type for the aws:copyContent plugin.
 type Plugin struct {
-	context context.T
-	filesys filemanager.FileSystem
-	ssmSvc  ssmsvc.Service
-	execDoc ExecDocument
+	context    context.T
+	filesys    filemanager.FileSystem
+	ssmSvc     ssmsvc.Service
+	execDoc    ExecDocument
+	httpClient http.Client
 }
 
+// sha256Checksum matches the hex encoded SHA-256 checksum accepted in the checksum input
+var sha256Checksum = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
 // RunDocumentPluginInput is a struct that holds the parameters sent through send command
 type RunDocumentPluginInput struct {
 	contracts.PluginInput
 	DocumentType       string      `json:"documentType"`
 	DocumentPath       string      `json:"documentPath"`
 	DocumentParameters interface{} `json:"documentParameters"`
-}
-
-// ExecutePluginDepth is the struct that is sent through to the sub-documents to maintain the depth of execution
-type ExecutePluginDepth struct {
-	executeCommandDepth int
+	Checksum           string      `json:"checksum"`
+	// Signature is the base64 encoded detached signature of the document, verified with the trusted key KeyID
+	Signature string `json:"signature"`
+	KeyID     string `json:"keyId"`
 }
 
 // Execute runs multiple sets of commands and returns their outputs.
@@ -90,6 +106,7 @@ type ExecutePluginDepth struct {
 func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
 	p.filesys = filemanager.FileSystemImpl{}
 	p.ssmSvc = ssmsvc.NewService(p.context)
+	p.httpClient = newHTTPSDocumentClient(network.GetDefaultTransport(p.context.Log(), p.context.AppConfig()))
 	exec := basicexecuter.NewBasicExecuter(p.context)
 	p.execDoc = ExecDocumentImpl{
 		DocExecutor: exec,
@@ -120,23 +137,18 @@ func (p *Plugin) runDocument(input *RunDocumentPluginInput, config contracts.Con
 	log.Debug("Inside aws:runDocument function")
 	var documentPath string
 	var pluginsInfo []contracts.PluginState
+	var ioConfig contracts.IOConfiguration
 	var err error
-	//Set the depth of execution to be 1 for the first level execution
-	execDepth := 1
-	// Getting the current depth of execution and checking against maximum depth
-	if config.Settings != nil {
-		if settings, ok := config.Settings.(*ExecutePluginDepth); !ok {
-			log.Error("Plugin setting is not of the right type")
-			output.MarkAsFailed(errors.New("There was an error obtaining the depth of execution"))
-			return
-		} else {
-			execDepth = settings.executeCommandDepth + 1
-			if execDepth > executeCommandMaxDepth {
-				output.MarkAsFailed(fmt.Errorf("Maximum depth for document execution exceeded. "+
-					"Maximum depth permitted - %v and current depth - %v", executeCommandMaxDepth, execDepth))
-				return
-			}
-		}
+	// The sub-document runs one level below the document this step belongs to, which is 0 for the top level
+	execDepth := config.ExecutionDepth + 1
+	maxDepth := p.context.AppConfig().Ssm.RunDocumentMaxDepth
+	if maxDepth <= 0 {
+		maxDepth = appconfig.DefaultRunDocumentMaxDepth
+	}
+	if execDepth > maxDepth {
+		output.MarkAsFailed(fmt.Errorf("Maximum depth for document execution exceeded. "+
+			"Maximum depth permitted - %v and current depth - %v", maxDepth, execDepth))
+		return
 	}
 	log.Info("Depth of execution - ", execDepth)
 
@@ -149,6 +161,11 @@ func (p *Plugin) runDocument(input *RunDocumentPluginInput, config contracts.Con
 		if documentPath, err = p.downloadDocumentFromSSM(log, config, input); err != nil {
 			output.MarkAsFailed(err)
 		}
+	} else if input.DocumentType == HTTPSType {
+		if documentPath, err = p.downloadDocumentFromHTTPS(log, config, input); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
 	} else {
 		if filepath.IsAbs(input.DocumentPath) {
 			documentPath = input.DocumentPath
@@ -158,26 +175,25 @@ func (p *Plugin) runDocument(input *RunDocumentPluginInput, config contracts.Con
 			documentPath = filepath.Join(orchestrationDir, downloadsDir, input.DocumentPath)
 		}
 	}
-	if pluginsInfo, err = p.prepareDocumentForExecution(log, documentPath, config, input.DocumentParameters); err != nil {
-		output.MarkAsFailed(fmt.Errorf("There was an error while preparing documents - %v", err.Error()))
+	if pluginsInfo, ioConfig, err = p.prepareDocumentForExecution(log, documentPath, input, config, execDepth); err != nil {
+		output.MarkAsFailed(fmt.Errorf("There was an error while preparing documents - %w", err))
 		return
 	}
-	// Sending execution depth in Configuration.Settings to the sub-documents
+	// The steps of the sub-documents may also reference the output of the steps that ran before them
 	for i, plugins := range pluginsInfo {
-		plugins.Configuration.Settings = &ExecutePluginDepth{executeCommandDepth: execDepth}
+		plugins.Configuration.ResolveStepOutputReferences = true
 		pluginsInfo[i] = plugins
 	}
 
 	var resultsChannel chan contracts.DocumentResult
 	var pluginOutput map[string]*contracts.PluginResult
-	if resultsChannel, err = p.execDoc.ExecuteDocument(config, p.context, pluginsInfo, config.BookKeepingFileName, times.ToIso8601UTC(time.Now())); err != nil {
+	if resultsChannel, err = p.execDoc.ExecuteDocument(config, p.context, pluginsInfo, ioConfig, config.BookKeepingFileName, times.ToIso8601UTC(time.Now())); err != nil {
 		output.MarkAsFailed(fmt.Errorf("There was an error while running documents - %v", err.Error()))
 	}
-	for res := range resultsChannel {
-		if res.LastPlugin == "" {
-			pluginOutput = res.PluginResults
-			break
-		}
+	if finalResult, _, drainErr := executer.DrainResults(resultsChannel); drainErr != nil {
+		log.Errorf("Sub-document did not complete: %v", drainErr)
+	} else {
+		pluginOutput = finalResult.PluginResults
 	}
 	if pluginOutput == nil {
 		output.MarkAsFailed(errors.New("No output obtained from executing document"))
@@ -198,6 +214,79 @@ func (p *Plugin) runDocument(input *RunDocumentPluginInput, config contracts.Con
 	}
 }
 
+// downloadDocumentFromHTTPS downloads the document at the https URL given as document path into the downloads folder
+func (p *Plugin) downloadDocumentFromHTTPS(log log.T, config contracts.Configuration, input *RunDocumentPluginInput) (string, error) {
+	documentURL, err := parseHTTPSDocumentPath(input.DocumentPath)
+	if err != nil {
+		return "", err
+	}
+
+	destination := filepath.Join(config.OrchestrationDirectory, downloadsDir)
+	if err = p.filesys.MakeDirs(destination); err != nil {
+		log.Error("failed to create directory for the document - ", err)
+		return "", err
+	}
+
+	documentName := filepath.Base(path.Base(documentURL.Path))
+	if documentName == "." || documentName == "/" || documentName == string(filepath.Separator) {
+		documentName = defaultHTTPSDocumentName
+	}
+	pathToFile := filepath.Join(destination, documentName)
+
+	log.Debugf("Downloading document from %v to %v", documentURL.Redacted(), pathToFile)
+	httpHandler := handler.NewHTTPHandler(p.httpClient, *documentURL, false, handler.HTTPAuthConfig{AuthMethod: handler.NONE}, nil)
+	if pathToFile, err = httpHandler.Download(log, p.filesys, pathToFile); err != nil {
+		log.Errorf("Unable to download document from %v. %v", documentURL.Redacted(), err)
+		return "", fmt.Errorf("unable to download document from %v: %v", documentURL.Redacted(), err)
+	}
+	return pathToFile, nil
+}
+
+// newHTTPSDocumentClient returns the client downloading HTTPS documents, which times out and never follows a redirect to http
+func newHTTPSDocumentClient(transport http.RoundTripper) http.Client {
+	return http.Client{
+		Transport:     transport,
+		Timeout:       httpsDownloadTimeout,
+		CheckRedirect: rejectInsecureRedirect,
+	}
+}
+
+// rejectInsecureRedirect stops the download when it is redirected away from https
+func rejectInsecureRedirect(req *http.Request, via []*http.Request) error {
+	//Go's http.DefaultClient allows 10 redirects before returning an error.
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if !strings.EqualFold(req.URL.Scheme, "https") {
+		return fmt.Errorf("redirected from secure URL %v to insecure URL %v", via[len(via)-1].URL.Redacted(), req.URL.Redacted())
+	}
+	return nil
+}
+
+// parseHTTPSDocumentPath parses the document path of an HTTPS document, which has to use TLS
+func parseHTTPSDocumentPath(documentPath string) (*url.URL, error) {
+	documentURL, err := url.Parse(strings.TrimSpace(documentPath))
+	if err != nil {
+		return nil, fmt.Errorf("Document path is not a valid URL: %v", err)
+	}
+	if !strings.EqualFold(documentURL.Scheme, "https") || documentURL.Host == "" {
+		return nil, errors.New("Document path must be an https:// URL when the document type is HTTPS")
+	}
+	return documentURL, nil
+}
+
+// verifyChecksum compares the SHA-256 checksum of the document with the expected one, when one is given
+func verifyChecksum(rawDocument []byte, expectedChecksum string) error {
+	if expectedChecksum == "" {
+		return nil
+	}
+	digest := sha256.Sum256(rawDocument)
+	if actualChecksum := hex.EncodeToString(digest[:]); !strings.EqualFold(actualChecksum, expectedChecksum) {
+		return fmt.Errorf("Document checksum mismatch: expected sha256 %v but the document has %v", strings.ToLower(expectedChecksum), actualChecksum)
+	}
+	return nil
+}
+
 func (p *Plugin) downloadDocumentFromSSM(log log.T, config contracts.Configuration, input *RunDocumentPluginInput) (string, error) {
 	var err error
 	// Downloads folder for download path
@@ -227,8 +316,11 @@ func (p *Plugin) downloadDocumentFromSSM(log log.T, config contracts.Configurati
 
 }
 
-// PrepareDocumentForExecution parses the raw content of the document, validates it and returns a PluginState that can be executed.
-func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, config contracts.Configuration, params interface{}) (pluginsInfo []contracts.PluginState, err error) {
+// PrepareDocumentForExecution parses the raw content of the document, validates it and returns a PluginState that can be executed
+// along with the document-level settings of the document.
+// A document which does not match the checksum or the signature of the input is rejected before it is parsed.
+func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, input *RunDocumentPluginInput, config contracts.Configuration, executionDepth int) (pluginsInfo []contracts.PluginState, ioConfig contracts.IOConfiguration, err error) {
+	params := input.DocumentParameters
 	parameters := make(map[string]interface{})
 	if params != nil {
 		switch params := params.(type) {
@@ -238,7 +330,7 @@ func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, confi
 				if erryaml := yaml.Unmarshal([]byte(params), &parameters); erryaml != nil {
 					errs := fmt.Errorf("Unmarshalling document parameters failed. Please make sure the parameters are specified in the right format"+
 						"JSON format error - %v, YAML format error - %v.", err, erryaml)
-					return pluginsInfo, errs
+					return pluginsInfo, ioConfig, errs
 				}
 			}
 		case map[string]interface{}:
@@ -247,7 +339,7 @@ func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, confi
 				parameters[k] = v
 			}
 		default:
-			return pluginsInfo, errors.New("parameter type specified to run document is unknown")
+			return pluginsInfo, ioConfig, errors.New("parameter type specified to run document is unknown")
 
 		}
 		log.Info("Parameters passed in are ", parameters)
@@ -263,11 +355,20 @@ func (p *Plugin) prepareDocumentForExecution(log log.T, pathToFile string, confi
 	var rawDocument []byte
 	if rawDocument, err = readFileContents(log, p.filesys, pathToFile); err != nil {
 		log.Error("Could not read document from remote resource - ", err)
-		return nil, err
+		return nil, ioConfig, err
+	}
+	if err = verifyChecksum(rawDocument, input.Checksum); err != nil {
+		log.Error(err)
+		return nil, ioConfig, err
+	}
+	appConfig := p.context.AppConfig()
+	if err = verifySignature(rawDocument, input.Signature, input.KeyID, appConfig.Ssm.RunDocumentSigningKeysDirectory, appConfig.Ssm.RunDocumentRequireSignature); err != nil {
+		log.Error(err)
+		return nil, ioConfig, err
 	}
 	log.Infof("Sending the document received for parsing - %v", string(rawDocument))
 
-	return p.execDoc.ParseDocument(p.context, rawDocument, config.OrchestrationDirectory, config.OutputS3BucketName, config.OutputS3KeyPrefix, config.MessageId, config.PluginID, config.DefaultWorkingDirectory, parameters)
+	return p.execDoc.ParseDocument(p.context, rawDocument, config.OrchestrationDirectory, config.OutputS3BucketName, config.OutputS3KeyPrefix, config.MessageId, config.PluginID, config.DefaultWorkingDirectory, executionDepth, parameters)
 }
 
 // Name returns the plugin name
@@ -294,14 +395,28 @@ func parseAndValidateInput(rawPluginInput interface{}) (*RunDocumentPluginInput,
 func validateInput(input *RunDocumentPluginInput) (valid bool, err error) {
 	// ensure non-empty location type
 	if input.DocumentType == "" {
-		return false, errors.New("Document Type must be specified to either by SSMDocument or LocalPath.")
+		return false, errors.New("Document Type must be specified to either by SSMDocument or LocalPath or HTTPS.")
 	}
-	if input.DocumentType != SSMDocumentType && input.DocumentType != LocalPathType {
+	if input.DocumentType != SSMDocumentType && input.DocumentType != LocalPathType && input.DocumentType != HTTPSType {
 		return false, errors.New("Document type specified in invalid")
 	}
 	if input.DocumentPath == "" {
 		return false, errors.New("Document Path must be provided")
 	}
+	if input.DocumentType == HTTPSType {
+		if _, err = parseHTTPSDocumentPath(input.DocumentPath); err != nil {
+			return false, err
+		}
+	}
+	if input.Checksum != "" && !sha256Checksum.MatchString(input.Checksum) {
+		return false, errors.New("Checksum must be the hex encoded SHA-256 checksum of the document")
+	}
+	if (input.Signature == "") != (input.KeyID == "") {
+		return false, errors.New("Signature and KeyId must be provided together")
+	}
+	if input.KeyID != "" && !signingKeyID.MatchString(input.KeyID) {
+		return false, errors.New("KeyId may only contain letters, digits, '.', '_' and '-'")
+	}
 	return true, nil
 }
 
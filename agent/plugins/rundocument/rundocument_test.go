@@ -16,13 +16,21 @@
 package rundocument
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/rundocument/mocks/rundocument"
@@ -31,7 +39,11 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager"
 	filemock "github.com/aws/amazon-ssm-agent/agent/fileutil/filemanager/mock"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	executermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/mock"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -49,7 +61,7 @@ func (m *InstanceMock) InstanceID() (string, error) {
 }
 
 var logMock = log.NewMockLog()
-var contextMock = context.NewMockDefault()
+var contextMock = contextmocks.NewMockDefault()
 var plugin = contracts.PluginState{}
 
 func TestReadFileContents(t *testing.T) {
@@ -93,7 +105,7 @@ func TestExecDocumentImpl_ExecuteDocumentFailure(t *testing.T) {
 		DocExecutor: execMock,
 	}
 	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
-	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, documentId, "time")
+	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, contracts.IOConfiguration{}, documentId, "time")
 
 	assert.NoError(t, err)
 }
@@ -119,11 +131,33 @@ func TestExecDocumentImpl_ExecuteDocumentSuccess(t *testing.T) {
 		DocExecutor: execMock,
 	}
 	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
-	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, documentId, "time")
+	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, contracts.IOConfiguration{}, documentId, "time")
 
 	assert.NoError(t, err)
 }
 
+func TestExecDocumentImpl_ExecuteDocumentKeepsOnFailure(t *testing.T) {
+	var pluginInput []contracts.PluginState
+	pluginInput = append(pluginInput, plugin)
+	execMock := executermocks.NewMockExecuter()
+	execMock.On("Run", mock.AnythingOfType("*task.ChanneledCancelFlag"), mock.MatchedBy(func(docStore *executer.DocumentFileStore) bool {
+		ioConfig := docStore.Load().IOConfig
+		return ioConfig.OnFailure == contracts.ModifierValueAbort &&
+			ioConfig.OrchestrationDirectory == filepath.Join("orch", "aws:runDocument") &&
+			ioConfig.OutputS3BucketName == ""
+	})).Return(make(chan contracts.DocumentResult))
+	exec := ExecDocumentImpl{
+		DocExecutor: execMock,
+	}
+	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
+	ioConfig := contracts.IOConfiguration{OnFailure: contracts.ModifierValueAbort, OutputS3BucketName: "bucket"}
+
+	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, ioConfig, "documentId", "time")
+
+	assert.NoError(t, err)
+	execMock.AssertExpectations(t)
+}
+
 func TestExecDocumentImpl_ExecuteDocumentWithMultiplePlugin(t *testing.T) {
 
 	documentId := "documentId"
@@ -145,7 +179,7 @@ func TestExecDocumentImpl_ExecuteDocumentWithMultiplePlugin(t *testing.T) {
 	exec := ExecDocumentImpl{
 		DocExecutor: execMock,
 	}
-	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, documentId, "time")
+	_, err := exec.ExecuteDocument(conf, contextMock, pluginInput, contracts.IOConfiguration{}, documentId, "time")
 
 	assert.NoError(t, err)
 }
@@ -165,7 +199,7 @@ func TestExecutePlugin_PrepareDocumentForExecution(t *testing.T) {
 		"key" : "value"
 	}`
 	fileMock.On("ReadFile", "document/name.json").Return(content, nil)
-	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
+	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, parameters).Return(plugins, contracts.IOConfiguration{}, nil)
 
 	p := Plugin{
 		context: contextMock,
@@ -173,7 +207,7 @@ func TestExecutePlugin_PrepareDocumentForExecution(t *testing.T) {
 		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/name.json", conf, "")
+	_, _, err := p.prepareDocumentForExecution(logMock, "document/name.json", &RunDocumentPluginInput{}, conf, 1)
 
 	assert.NoError(t, err)
 	fileMock.AssertExpectations(t)
@@ -195,7 +229,7 @@ func TestExecutePlugin_PrepareDocumentForExecutionFail(t *testing.T) {
 		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/name.json", conf, "")
+	_, _, err := p.prepareDocumentForExecution(logMock, "document/name.json", &RunDocumentPluginInput{}, conf, 1)
 
 	assert.Error(t, err)
 	assert.Equal(t, fmt.Errorf("File is empty!"), err)
@@ -220,7 +254,7 @@ param2: world`
 	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
 
 	fileMock.On("ReadFile", "document/doc-name.json").Return("content", nil)
-	execMock.On("ParseDocument", contextMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
+	execMock.On("ParseDocument", contextMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, parameters).Return(plugins, contracts.IOConfiguration{}, nil)
 
 	p := Plugin{
 		context: contextMock,
@@ -228,7 +262,7 @@ param2: world`
 		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.json", conf, params)
+	_, _, err := p.prepareDocumentForExecution(logMock, "document/doc-name.json", &RunDocumentPluginInput{DocumentParameters: params}, conf, 1)
 
 	assert.NoError(t, err)
 	fileMock.AssertExpectations(t)
@@ -252,7 +286,7 @@ func TestExecuteImpl_PrepareDocumentForExecutionParametersJSON(t *testing.T) {
 	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
 
 	fileMock.On("ReadFile", "document/doc-name.yaml").Return("content", nil)
-	execMock.On("ParseDocument", contextMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
+	execMock.On("ParseDocument", contextMock, []byte("content"), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, parameters).Return(plugins, contracts.IOConfiguration{}, nil)
 
 	p := Plugin{
 		context: contextMock,
@@ -260,7 +294,7 @@ func TestExecuteImpl_PrepareDocumentForExecutionParametersJSON(t *testing.T) {
 		execDoc: &execMock,
 	}
 
-	_, err := p.prepareDocumentForExecution(logMock, "document/doc-name.yaml", conf, params)
+	_, _, err := p.prepareDocumentForExecution(logMock, "document/doc-name.yaml", &RunDocumentPluginInput{DocumentParameters: params}, conf, 1)
 
 	assert.NoError(t, err)
 	fileMock.AssertExpectations(t)
@@ -281,11 +315,9 @@ func TestPlugin_RunDocumentMaxDepthExceeded(t *testing.T) {
 	input.DocumentType = "LocalPath"
 	input.DocumentPath = filepath.Join("var", "tmp", "docLocation", "docname.json")
 	conf.Properties = &input
-	var executionDepth interface{}
-	executionDepth = createStubExecutionDepth(4)
-	conf.Settings = executionDepth
+	conf.ExecutionDepth = 5
 
-	mockIOHandler.On("MarkAsFailed", fmt.Errorf("Maximum depth for document execution exceeded. Maximum depth permitted - 3 and current depth - 5")).Return()
+	mockIOHandler.On("MarkAsFailed", fmt.Errorf("Maximum depth for document execution exceeded. Maximum depth permitted - 5 and current depth - 6")).Return()
 
 	p := Plugin{
 		context: contextMock,
@@ -300,6 +332,78 @@ func TestPlugin_RunDocumentMaxDepthExceeded(t *testing.T) {
 	mockIOHandler.AssertExpectations(t)
 }
 
+func TestPlugin_RunDocumentMutuallyReferencingDocumentsStopAtMaxDepth(t *testing.T) {
+	fileMock := filemock.FileSystemMock{}
+	conf := createStubConfiguration("orch", "bucket", "prefix", "1234-1234-1234", "directory")
+	conf.Properties = &RunDocumentPluginInput{DocumentType: LocalPathType, DocumentPath: "docA.json"}
+
+	// docA runs docB, which runs docA again
+	fileMock.On("ReadFile", filepath.Join("orch", "downloads", "docA.json")).Return("docA", nil)
+	fileMock.On("ReadFile", filepath.Join("orch", "downloads", "docB.json")).Return("docB", nil)
+
+	p := Plugin{
+		context: contextMock,
+		filesys: &fileMock,
+	}
+	execDoc := &mutuallyReferencingExecDoc{
+		plugin:     &p,
+		references: map[string]string{"docA": "docB.json", "docB": "docA.json"},
+		conf:       conf,
+	}
+	p.execDoc = execDoc
+
+	output := iohandler.NewDefaultIOHandler(contextMock, contracts.IOConfiguration{})
+	p.execute(conf, createMockCancelFlag(), output)
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "Maximum depth for document execution exceeded. Maximum depth permitted - 5 and current depth - 6")
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, execDoc.executedDepths)
+}
+
+// mutuallyReferencingExecDoc runs the aws:runDocument step of each sub-document in place, the same way the executer would
+type mutuallyReferencingExecDoc struct {
+	plugin         *Plugin
+	references     map[string]string
+	conf           contracts.Configuration
+	executedDepths []int
+}
+
+func (e *mutuallyReferencingExecDoc) ParseDocument(context context.T, documentRaw []byte, orchestrationDir string,
+	s3Bucket string, s3KeyPrefix string, messageID string, documentID string, defaultWorkingDirectory string,
+	executionDepth int, params map[string]interface{}) ([]contracts.PluginState, contracts.IOConfiguration, error) {
+	pluginConf := e.conf
+	pluginConf.Properties = &RunDocumentPluginInput{DocumentType: LocalPathType, DocumentPath: e.references[string(documentRaw)]}
+	pluginConf.ExecutionDepth = executionDepth
+	return []contracts.PluginState{{Id: "aws:runDocument", Name: "aws:runDocument", Configuration: pluginConf}}, contracts.IOConfiguration{}, nil
+}
+
+func (e *mutuallyReferencingExecDoc) ExecuteDocument(config contracts.Configuration, context context.T, pluginInput []contracts.PluginState, ioConfig contracts.IOConfiguration,
+	documentID string, documentCreatedDate string) (chan contracts.DocumentResult, error) {
+	// the sub-document is handed to the executer as json, the depth must survive the round trip
+	var docState contracts.DocumentState
+	docStateJSON, _ := json.Marshal(contracts.DocumentState{InstancePluginsInformation: pluginInput})
+	if err := json.Unmarshal(docStateJSON, &docState); err != nil {
+		return nil, err
+	}
+
+	pluginResults := make(map[string]*contracts.PluginResult)
+	for _, pluginState := range docState.InstancePluginsInformation {
+		e.executedDepths = append(e.executedDepths, pluginState.Configuration.ExecutionDepth)
+		output := iohandler.NewDefaultIOHandler(context, contracts.IOConfiguration{})
+		e.plugin.execute(pluginState.Configuration, createMockCancelFlag(), output)
+		pluginResults[pluginState.Id] = &contracts.PluginResult{
+			PluginID:      pluginState.Id,
+			Status:        output.GetStatus(),
+			StandardError: output.GetStderr(),
+		}
+	}
+
+	resChan := make(chan contracts.DocumentResult, 1)
+	resChan <- contracts.DocumentResult{Status: contracts.ResultStatusSuccess, PluginResults: pluginResults}
+	close(resChan)
+	return resChan, nil
+}
+
 func TestPlugin_RunDocument(t *testing.T) {
 
 	execMock := rundocument.NewExecMock()
@@ -341,8 +445,10 @@ func TestPlugin_RunDocument(t *testing.T) {
 	plugins := []contracts.PluginState{plugin}
 
 	fileMock.On("ReadFile", filepath.Join("orch", "downloads", "var", "tmp", "docLocation", "docname.json")).Return(content, nil)
-	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
-	execMock.On("ExecuteDocument", contextMock, plugins, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
+	// the document-level settings of the sub-document are kept when it runs
+	subDocumentIOConfig := contracts.IOConfiguration{OnFailure: contracts.ModifierValueAbort}
+	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, parameters).Return(plugins, subDocumentIOConfig, nil)
+	execMock.On("ExecuteDocument", contextMock, plugins, subDocumentIOConfig, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
 	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
 	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
 
@@ -358,6 +464,8 @@ func TestPlugin_RunDocument(t *testing.T) {
 	mockplugin.AssertExpectations(t)
 	fileMock.AssertExpectations(t)
 	mockIOHandler.AssertExpectations(t)
+	// steps of the sub-document may reference the output of the steps before them
+	assert.True(t, plugins[0].Configuration.ResolveStepOutputReferences)
 }
 
 func TestPlugin_RunDocumentFromSSMDocument(t *testing.T) {
@@ -402,8 +510,8 @@ func TestPlugin_RunDocumentFromSSMDocument(t *testing.T) {
 	fileMock.On("MakeDirs", filepath.Join("orch", "downloads")).Return(nil)
 	fileMock.On("WriteFile", filepath.Join("orch", "downloads", "RunShellScript.json"), content).Return(nil)
 	fileMock.On("ReadFile", filepath.Join("orch", "downloads", "RunShellScript.json")).Return(content, nil)
-	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
-	execMock.On("ExecuteDocument", contextMock, plugins, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
+	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, parameters).Return(plugins, contracts.IOConfiguration{}, nil)
+	execMock.On("ExecuteDocument", contextMock, plugins, contracts.IOConfiguration{}, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
 	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
 	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
 
@@ -461,8 +569,8 @@ func TestPlugin_RunDocumentFromAbsLocalPath(t *testing.T) {
 	parameters := make(map[string]interface{})
 
 	fileMock.On("ReadFile", filepath.Join(rootAbsPath, "tmp", "document", "docName.json")).Return(content, nil)
-	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, parameters).Return(plugins, nil)
-	execMock.On("ExecuteDocument", contextMock, plugins, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
+	execMock.On("ParseDocument", contextMock, []byte(content), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, parameters).Return(plugins, contracts.IOConfiguration{}, nil)
+	execMock.On("ExecuteDocument", contextMock, plugins, contracts.IOConfiguration{}, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
 	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
 	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
 
@@ -495,13 +603,13 @@ func TestExecDocumentImpl_ParseDocumentYAML(t *testing.T) {
 		OutputS3BucketName:      "bucket",
 		OutputS3KeyPrefix:       "prefix",
 		MessageId:               "1234-1234-1234",
-		PluginID:                "aws:runScript",
+		PluginID:                "aws:runPowerShellScript",
 		DefaultWorkingDirectory: "directory",
-		PluginName:              "aws:runScript",
+		PluginName:              "aws:runPowerShellScript",
 	}
 	var exec ExecDocumentImpl
 	var params map[string]interface{}
-	pluginsInfo, err := exec.ParseDocument(contextMock, []byte(yamlDoc), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, params)
+	pluginsInfo, _, err := exec.ParseDocument(contextMock, []byte(yamlDoc), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, params)
 
 	assert.NoError(t, err)
 	for _, plugin := range pluginsInfo {
@@ -512,6 +620,20 @@ func TestExecDocumentImpl_ParseDocumentYAML(t *testing.T) {
 	}
 }
 
+func TestExecDocumentImpl_ParseDocumentWithOnFailure(t *testing.T) {
+	document := `{
+		"schemaVersion": "2.2",
+		"onFailure": "abort",
+		"mainSteps": [{"action": "aws:runShellScript", "name": "run", "inputs": {"runCommand": ["date"]}}]
+	}`
+	var exec ExecDocumentImpl
+
+	_, ioConfig, err := exec.ParseDocument(contextMock, []byte(document), "orch", "bucket", "prefix", "1234-1234-1234", "aws:runDocument", "directory", 1, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, contracts.ModifierValueAbort, ioConfig.OnFailure)
+}
+
 func TestExecDocumentImpl_ParseDocumentJSON(t *testing.T) {
 	jsonDoc := loadFile(t, "testdata/jsondoc.json")
 	conf := contracts.Configuration{
@@ -519,13 +641,13 @@ func TestExecDocumentImpl_ParseDocumentJSON(t *testing.T) {
 		OutputS3BucketName:      "bucket",
 		OutputS3KeyPrefix:       "prefix",
 		MessageId:               "1234-1234-1234",
-		PluginID:                "aws:runScript",
+		PluginID:                "aws:runPowerShellScript",
 		DefaultWorkingDirectory: "directory",
-		PluginName:              "aws:runScript",
+		PluginName:              "aws:runPowerShellScript",
 	}
 	var exec ExecDocumentImpl
 	var params map[string]interface{}
-	pluginsInfo, err := exec.ParseDocument(contextMock, []byte(jsonDoc), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, params)
+	pluginsInfo, _, err := exec.ParseDocument(contextMock, []byte(jsonDoc), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, params)
 
 	assert.NoError(t, err)
 	for _, plugin := range pluginsInfo {
@@ -536,6 +658,66 @@ func TestExecDocumentImpl_ParseDocumentJSON(t *testing.T) {
 	}
 }
 
+func TestExecDocumentImpl_ParseDocumentWithByteOrderMark(t *testing.T) {
+	for _, file := range []string{"testdata/yamldoc.yaml", "testdata/jsondoc.json"} {
+		document := "\xEF\xBB\xBF" + string(loadFile(t, file)) + " \t\r\n\n"
+		var exec ExecDocumentImpl
+
+		pluginsInfo, _, err := exec.ParseDocument(contextMock, []byte(document), "orch", "bucket", "prefix", "1234-1234-1234", "aws:runPowerShellScript", "directory", 1, nil)
+
+		assert.NoError(t, err, file)
+		assert.NotEmpty(t, pluginsInfo, file)
+		for _, plugin := range pluginsInfo {
+			assert.Equal(t, "aws:runPowerShellScript", plugin.Name, file)
+			assert.NotNil(t, plugin.Configuration.Properties, file)
+		}
+	}
+}
+
+func TestExecDocumentImpl_ParseDocumentErrorKind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		document string
+		params   map[string]interface{}
+		kind     docparser.ParseErrorKind
+	}{
+		{
+			name:     "MalformedJSON",
+			document: `{"schemaVersion": "2.2", "mainSteps": [`,
+			kind:     docparser.SyntaxError,
+		},
+		{
+			name:     "UnsupportedSchemaVersion",
+			document: `{"schemaVersion": "9999.0", "mainSteps": [{"action": "aws:runShellScript", "name": "step", "inputs": {"runCommand": ["echo hello"]}}]}`,
+			kind:     docparser.SchemaError,
+		},
+		{
+			name:     "NoSteps",
+			document: `{"schemaVersion": "2.2", "mainSteps": []}`,
+			kind:     docparser.SchemaError,
+		},
+		{
+			name: "MissingRequiredParameter",
+			document: `{"schemaVersion": "2.2", "parameters": {"message": {"type": "String"}},
+				"mainSteps": [{"action": "aws:runShellScript", "name": "step", "inputs": {"runCommand": ["echo {{ message }}"]}}]}`,
+			kind: docparser.ParameterError,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var exec ExecDocumentImpl
+
+			_, _, err := exec.ParseDocument(contextMock, []byte(testCase.document), "orch", "bucket", "prefix", "1234-1234-1234", "aws:runDocument", "directory", 1, testCase.params)
+
+			var parseError *docparser.ParseError
+			if assert.True(t, errors.As(err, &parseError), "unexpected error %v", err) {
+				assert.Equal(t, testCase.kind, parseError.Kind)
+				assert.Equal(t, parseError.Err.Error(), err.Error())
+			}
+		})
+	}
+}
+
 func TestValidateInput_NoDocumentType(t *testing.T) {
 	input := RunDocumentPluginInput{}
 
@@ -543,7 +725,7 @@ func TestValidateInput_NoDocumentType(t *testing.T) {
 
 	assert.False(t, result)
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "Document Type must be specified to either by SSMDocument or LocalPath.")
+	assert.Contains(t, err.Error(), "Document Type must be specified to either by SSMDocument or LocalPath or HTTPS.")
 
 }
 func TestValidateInput_UnknownDocumentType(t *testing.T) {
@@ -613,13 +795,6 @@ func TestDownloadDocumentFromSSM_ARNName(t *testing.T) {
 
 }
 
-func createStubExecutionDepth(depth int) *ExecutePluginDepth {
-	currentDepth := ExecutePluginDepth{}
-	currentDepth.executeCommandDepth = depth
-
-	return &currentDepth
-}
-
 func createStubConfiguration(orch, bucket, prefix, message, dir string) contracts.Configuration {
 	return contracts.Configuration{
 		OrchestrationDirectory:  orch,
@@ -653,3 +828,172 @@ func loadFile(t *testing.T, fileName string) (result []byte) {
 	}
 	return
 }
+
+func TestExecDocumentImpl_ParseDocumentYAMLWithAnchors(t *testing.T) {
+	yamlDoc := loadFile(t, "testdata/yamldocanchors.yaml")
+	var exec ExecDocumentImpl
+	var params map[string]interface{}
+	pluginsInfo, _, err := exec.ParseDocument(contextMock, []byte(yamlDoc), "orch", "bucket", "prefix", "1234-1234-1234", "aws:runDocument", "directory", 1, params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pluginsInfo))
+	assert.Equal(t, map[string]interface{}{
+		"timeoutSeconds":   "600",
+		"workingDirectory": "/tmp",
+		"runCommand":       []interface{}{"echo first"},
+	}, pluginsInfo[0].Configuration.Properties)
+	assert.Equal(t, map[string]interface{}{
+		"timeoutSeconds":   "600",
+		"workingDirectory": "/tmp",
+		"runCommand":       []interface{}{"echo second"},
+	}, pluginsInfo[1].Configuration.Properties)
+}
+
+const httpsDocument = `{"schemaVersion": "2.2", "mainSteps": []}`
+
+// httpsDocumentPlugin returns a plugin downloading its documents from a local test HTTPS server
+func httpsDocumentPlugin(t *testing.T, execMock *rundocument.ExecMock) (*Plugin, *httptest.Server) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/documents/shared.json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, httpsDocument)
+	}))
+	t.Cleanup(server.Close)
+
+	return &Plugin{
+		context:    contextMock,
+		filesys:    filemanager.FileSystemImpl{},
+		execDoc:    execMock,
+		httpClient: *server.Client(),
+	}, server
+}
+
+func TestPlugin_RunDocumentFromHTTPS(t *testing.T) {
+	execMock := rundocument.NewExecMock()
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p, server := httpsDocumentPlugin(t, &execMock)
+	conf := createStubConfiguration(t.TempDir(), "bucket", "prefix", "1234-1234-1234", "directory")
+
+	plugins := []contracts.PluginState{{}}
+	resChan := make(chan contracts.DocumentResult, 1)
+	resChan <- contracts.DocumentResult{
+		Status:        contracts.ResultStatusSuccess,
+		PluginResults: map[string]*contracts.PluginResult{"step": {Status: contracts.ResultStatusSuccess}},
+	}
+	close(resChan)
+
+	execMock.On("ParseDocument", contextMock, []byte(httpsDocument), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, map[string]interface{}{}).Return(plugins, contracts.IOConfiguration{}, nil)
+	execMock.On("ExecuteDocument", contextMock, plugins, contracts.IOConfiguration{}, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil)
+	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess)
+	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return()
+
+	checksum := sha256.Sum256([]byte(httpsDocument))
+	input := RunDocumentPluginInput{
+		DocumentType: HTTPSType,
+		DocumentPath: server.URL + "/documents/shared.json",
+		Checksum:     strings.ToUpper(hex.EncodeToString(checksum[:])),
+	}
+	p.runDocument(&input, conf, mockIOHandler)
+
+	execMock.AssertExpectations(t)
+	mockIOHandler.AssertExpectations(t)
+	assert.FileExists(t, filepath.Join(conf.OrchestrationDirectory, "downloads", "shared.json"))
+}
+
+func TestPlugin_RunDocumentFromHTTPSChecksumMismatch(t *testing.T) {
+	execMock := rundocument.NewExecMock()
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p, server := httpsDocumentPlugin(t, &execMock)
+	conf := createStubConfiguration(t.TempDir(), "bucket", "prefix", "1234-1234-1234", "directory")
+
+	var failure error
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Run(func(args mock.Arguments) {
+		failure = args.Get(0).(error)
+	}).Return().Once()
+
+	checksum := sha256.Sum256([]byte("another document"))
+	input := RunDocumentPluginInput{
+		DocumentType: HTTPSType,
+		DocumentPath: server.URL + "/documents/shared.json",
+		Checksum:     hex.EncodeToString(checksum[:]),
+	}
+	p.runDocument(&input, conf, mockIOHandler)
+
+	// the document is neither parsed nor executed
+	execMock.AssertNotCalled(t, "ParseDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	execMock.AssertNotCalled(t, "ExecuteDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockIOHandler.AssertExpectations(t)
+	assert.Contains(t, failure.Error(), "Document checksum mismatch: expected sha256 "+hex.EncodeToString(checksum[:]))
+}
+
+func TestPlugin_RunDocumentFromHTTPSNotFound(t *testing.T) {
+	execMock := rundocument.NewExecMock()
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p, server := httpsDocumentPlugin(t, &execMock)
+	conf := createStubConfiguration(t.TempDir(), "bucket", "prefix", "1234-1234-1234", "directory")
+
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Return().Once()
+
+	input := RunDocumentPluginInput{DocumentType: HTTPSType, DocumentPath: server.URL + "/documents/missing.json"}
+	p.runDocument(&input, conf, mockIOHandler)
+
+	execMock.AssertNotCalled(t, "ParseDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockIOHandler.AssertExpectations(t)
+}
+
+func TestPlugin_RunDocumentFromHTTPSRejectsInsecureRedirect(t *testing.T) {
+	insecureServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, httpsDocument)
+	}))
+	defer insecureServer.Close()
+	server := httptest.NewTLSServer(http.RedirectHandler(insecureServer.URL+"/documents/shared.json", http.StatusFound))
+	defer server.Close()
+
+	execMock := rundocument.NewExecMock()
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p := &Plugin{
+		context:    contextMock,
+		filesys:    filemanager.FileSystemImpl{},
+		execDoc:    &execMock,
+		httpClient: newHTTPSDocumentClient(server.Client().Transport),
+	}
+	conf := createStubConfiguration(t.TempDir(), "bucket", "prefix", "1234-1234-1234", "directory")
+
+	var failure error
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Run(func(args mock.Arguments) {
+		failure = args.Get(0).(error)
+	}).Return().Once()
+
+	input := RunDocumentPluginInput{DocumentType: HTTPSType, DocumentPath: server.URL + "/documents/shared.json"}
+	p.runDocument(&input, conf, mockIOHandler)
+
+	execMock.AssertNotCalled(t, "ParseDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockIOHandler.AssertExpectations(t)
+	assert.Contains(t, failure.Error(), "to insecure URL "+insecureServer.URL)
+}
+
+func TestNewHTTPSDocumentClient(t *testing.T) {
+	client := newHTTPSDocumentClient(http.DefaultTransport)
+	assert.Equal(t, httpsDownloadTimeout, client.Timeout)
+	assert.NotNil(t, client.CheckRedirect)
+}
+
+func TestValidateInput_HTTPSDocumentPath(t *testing.T) {
+	input := RunDocumentPluginInput{DocumentType: HTTPSType, DocumentPath: "https://artifacts.example.com/documents/shared.yaml"}
+	valid, err := validateInput(&input)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	input.DocumentPath = "http://artifacts.example.com/documents/shared.yaml"
+	valid, err = validateInput(&input)
+	assert.False(t, valid)
+	assert.Contains(t, err.Error(), "must be an https:// URL")
+
+	input.DocumentPath = "https://artifacts.example.com/documents/shared.yaml"
+	input.Checksum = "abc"
+	valid, err = validateInput(&input)
+	assert.False(t, valid)
+	assert.Contains(t, err.Error(), "SHA-256")
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rundocument
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// signingKeyFileExtension is the extension of the public key files in the signing keys directory
+const signingKeyFileExtension = ".pem"
+
+// signingKeyID matches the key ids accepted in the keyId input, which name files of the signing keys directory
+var signingKeyID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// errSignatureVerification is wrapped by every error rejecting a document because of its signature
+var errSignatureVerification = errors.New("Document signature verification failed")
+
+// verifySignature verifies the detached signature of the document with the trusted public key keyID of keysDir.
+// Unsigned documents are accepted unless requireSignature is set.
+func verifySignature(rawDocument []byte, signature string, keyID string, keysDir string, requireSignature bool) error {
+	if signature == "" {
+		if requireSignature {
+			return fmt.Errorf("%w: the document is not signed and a signature is required", errSignatureVerification)
+		}
+		return nil
+	}
+
+	publicKey, err := readSigningKey(keysDir, keyID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errSignatureVerification, err)
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: the signature is not base64 encoded: %v", errSignatureVerification, err)
+	}
+
+	digest := sha256.Sum256(rawDocument)
+	valid := false
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], rawSignature) == nil
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(key, digest[:], rawSignature)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(key, rawDocument, rawSignature)
+	default:
+		return fmt.Errorf("%w: key %v has an unsupported type %T", errSignatureVerification, keyID, publicKey)
+	}
+	if !valid {
+		return fmt.Errorf("%w: the document does not match its signature with key %v", errSignatureVerification, keyID)
+	}
+	return nil
+}
+
+// readSigningKey reads the PEM encoded public key keyID from the signing keys directory
+func readSigningKey(keysDir string, keyID string) (crypto.PublicKey, error) {
+	if keysDir == "" {
+		return nil, errors.New("no signing keys directory is configured")
+	}
+	keyPath := filepath.Join(keysDir, keyID+signingKeyFileExtension)
+	content, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %v: %v", keyID, err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("key %v is not PEM encoded", keyID)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %v: %v", keyID, err)
+	}
+	return publicKey, nil
+}
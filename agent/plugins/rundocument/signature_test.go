@@ -0,0 +1,173 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rundocument
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
+	contextmocks "github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/plugins/rundocument/mocks/rundocument"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSigningKey stores the public key in the keys directory under keyID
+func writeSigningKey(t *testing.T, keysDir string, keyID string, publicKey crypto.PublicKey) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	content := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, keyID+signingKeyFileExtension), content, 0600))
+}
+
+// runSignedHTTPSDocument runs the HTTPS test document with the given signature and returns the failure reported, if any
+func runSignedHTTPSDocument(t *testing.T, keysDir string, requireSignature bool, signature string, keyID string) error {
+	execMock := rundocument.NewExecMock()
+	mockIOHandler := new(iohandlermocks.MockIOHandler)
+	p, server := httpsDocumentPlugin(t, &execMock)
+	config := appconfig.SsmagentConfig{}
+	config.Ssm.RunDocumentSigningKeysDirectory = keysDir
+	config.Ssm.RunDocumentRequireSignature = requireSignature
+	p.context = contextmocks.NewMockDefaultWithConfig(config)
+	conf := createStubConfiguration(t.TempDir(), "bucket", "prefix", "1234-1234-1234", "directory")
+
+	plugins := []contracts.PluginState{{}}
+	resChan := make(chan contracts.DocumentResult, 1)
+	resChan <- contracts.DocumentResult{
+		Status:        contracts.ResultStatusSuccess,
+		PluginResults: map[string]*contracts.PluginResult{"step": {Status: contracts.ResultStatusSuccess}},
+	}
+	close(resChan)
+	execMock.On("ParseDocument", p.context, []byte(httpsDocument), conf.OrchestrationDirectory, conf.OutputS3BucketName, conf.OutputS3KeyPrefix, conf.MessageId, conf.PluginID, conf.DefaultWorkingDirectory, 1, map[string]interface{}{}).Return(plugins, contracts.IOConfiguration{}, nil).Maybe()
+	execMock.On("ExecuteDocument", p.context, plugins, contracts.IOConfiguration{}, conf.BookKeepingFileName, mock.Anything).Return(resChan, nil).Maybe()
+	mockIOHandler.On("GetStatus").Return(contracts.ResultStatusSuccess).Maybe()
+	mockIOHandler.On("SetStatus", contracts.ResultStatusSuccess).Return().Maybe()
+	var failure error
+	mockIOHandler.On("MarkAsFailed", mock.Anything).Run(func(args mock.Arguments) {
+		failure = args.Get(0).(error)
+	}).Return().Maybe()
+
+	input := RunDocumentPluginInput{
+		DocumentType: HTTPSType,
+		DocumentPath: server.URL + "/documents/shared.json",
+		Signature:    signature,
+		KeyID:        keyID,
+	}
+	p.runDocument(&input, conf, mockIOHandler)
+
+	if failure != nil {
+		// a rejected document is neither parsed nor executed
+		execMock.AssertNotCalled(t, "ParseDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	} else {
+		execMock.AssertExpectations(t)
+	}
+	return failure
+}
+
+func TestPlugin_RunDocumentWithValidSignature(t *testing.T) {
+	keysDir := t.TempDir()
+	digest := sha256.Sum256([]byte(httpsDocument))
+
+	ed25519PublicKey, ed25519PrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeSigningKey(t, keysDir, "ed25519-key", ed25519PublicKey)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writeSigningKey(t, keysDir, "ecdsa-key", &ecdsaKey.PublicKey)
+	ecdsaSignature, err := ecdsa.SignASN1(rand.Reader, ecdsaKey, digest[:])
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeSigningKey(t, keysDir, "rsa-key", &rsaKey.PublicKey)
+	rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	signatures := map[string][]byte{
+		"ed25519-key": ed25519.Sign(ed25519PrivateKey, []byte(httpsDocument)),
+		"ecdsa-key":   ecdsaSignature,
+		"rsa-key":     rsaSignature,
+	}
+	for keyID, signature := range signatures {
+		err := runSignedHTTPSDocument(t, keysDir, true, base64.StdEncoding.EncodeToString(signature), keyID)
+		assert.NoError(t, err, keyID)
+	}
+}
+
+func TestPlugin_RunDocumentWithInvalidSignature(t *testing.T) {
+	keysDir := t.TempDir()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeSigningKey(t, keysDir, "trusted", publicKey)
+
+	signature := ed25519.Sign(privateKey, []byte("another document"))
+	err = runSignedHTTPSDocument(t, keysDir, false, base64.StdEncoding.EncodeToString(signature), "trusted")
+
+	assert.True(t, errors.Is(err, errSignatureVerification), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "Document signature verification failed: the document does not match its signature with key trusted")
+}
+
+func TestPlugin_RunDocumentWithUnknownSigningKey(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	signature := ed25519.Sign(privateKey, []byte(httpsDocument))
+	err = runSignedHTTPSDocument(t, t.TempDir(), false, base64.StdEncoding.EncodeToString(signature), "untrusted")
+
+	assert.True(t, errors.Is(err, errSignatureVerification), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "failed to read key untrusted")
+}
+
+func TestPlugin_RunDocumentWithoutSignature(t *testing.T) {
+	// unsigned documents run unless a signature is required
+	assert.NoError(t, runSignedHTTPSDocument(t, t.TempDir(), false, "", ""))
+
+	err := runSignedHTTPSDocument(t, t.TempDir(), true, "", "")
+	assert.True(t, errors.Is(err, errSignatureVerification), "unexpected error %v", err)
+	assert.Contains(t, err.Error(), "the document is not signed and a signature is required")
+}
+
+func TestValidateInput_Signature(t *testing.T) {
+	input := RunDocumentPluginInput{DocumentType: LocalPathType, DocumentPath: "document.json", Signature: "c2lnbmF0dXJl", KeyID: "release-2024"}
+	valid, err := validateInput(&input)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	input.KeyID = ""
+	valid, err = validateInput(&input)
+	assert.False(t, valid)
+	assert.Contains(t, err.Error(), "Signature and KeyId must be provided together")
+
+	input.KeyID = "../release-2024"
+	valid, err = validateInput(&input)
+	assert.False(t, valid)
+	assert.Contains(t, err.Error(), "KeyId may only contain")
+}
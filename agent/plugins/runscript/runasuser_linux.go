@@ -0,0 +1,152 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package runscript
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+)
+
+var (
+	lookupUser     = user.Lookup
+	lookupGroupIds = func(runAsUser *user.User) ([]string, error) { return runAsUser.GroupIds() }
+	getEuid        = os.Geteuid
+)
+
+// runAs is the user the commands of a step run as
+type runAs struct {
+	userName string
+	homeDir  string
+	// credential is nil when the user is the agent user and no switch is needed
+	credential *executers.Credential
+}
+
+// resolveRunAsUser looks up the user and checks the agent is allowed to start processes as that user
+func resolveRunAsUser(userName string) (*runAs, error) {
+	runAsUser, err := lookupUser(userName)
+	if err != nil {
+		return nil, fmt.Errorf("runAsUser %v does not exist: %v", userName, err)
+	}
+	uid, err := strconv.ParseUint(runAsUser.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid %v of runAsUser %v: %v", runAsUser.Uid, userName, err)
+	}
+	gid, err := strconv.ParseUint(runAsUser.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid %v of runAsUser %v: %v", runAsUser.Gid, userName, err)
+	}
+
+	result := &runAs{userName: runAsUser.Username, homeDir: runAsUser.HomeDir}
+	if euid := getEuid(); euid != 0 {
+		if uint64(euid) != uid {
+			return nil, fmt.Errorf("the agent must run as root to run commands as %v", userName)
+		}
+		return result, nil
+	}
+
+	groupIds, err := lookupGroupIds(runAsUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the groups of runAsUser %v: %v", userName, err)
+	}
+	credential := &executers.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	for _, groupId := range groupIds {
+		group, err := strconv.ParseUint(groupId, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group id %v of runAsUser %v: %v", groupId, userName, err)
+		}
+		credential.Groups = append(credential.Groups, uint32(group))
+	}
+	result.credential = credential
+	return result, nil
+}
+
+// checkWorkingDirectoryAccess checks the user can change into the working directory,
+// which requires the search permission on the directory and all its parents
+func (r *runAs) checkWorkingDirectoryAccess(workingDir string) error {
+	if r.credential == nil || r.credential.Uid == 0 || workingDir == "" {
+		return nil
+	}
+	dir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return err
+	}
+	for {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("failed to access working directory %v: %v", workingDir, err)
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok && !r.canSearch(info.Mode().Perm(), stat.Uid, stat.Gid) {
+			return fmt.Errorf("runAsUser %v is not allowed to access working directory %v", r.userName, workingDir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+// canSearch applies the owner, group or other permission bits matching the credential, as the kernel does
+func (r *runAs) canSearch(perm os.FileMode, ownerUid uint32, ownerGid uint32) bool {
+	if ownerUid == r.credential.Uid {
+		return perm&0100 != 0
+	}
+	if ownerGid == r.credential.Gid {
+		return perm&0010 != 0
+	}
+	for _, group := range r.credential.Groups {
+		if ownerGid == group {
+			return perm&0010 != 0
+		}
+	}
+	return perm&0001 != 0
+}
+
+// stageScript copies the script into a temporary directory owned by the user, the orchestration directory is only accessible to the agent.
+// The returned cleanup function removes the copy.
+func (r *runAs) stageScript(scriptPath string) (stagedPath string, cleanup func(), err error) {
+	if r.credential == nil {
+		return scriptPath, func() {}, nil
+	}
+	content, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return "", nil, err
+	}
+	stagingDir, err := os.MkdirTemp("", "ssm-runas-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(stagingDir) }
+	stagedPath = filepath.Join(stagingDir, filepath.Base(scriptPath))
+	if err = os.WriteFile(stagedPath, content, appconfig.ReadWriteExecuteAccess); err == nil {
+		if err = os.Chown(stagingDir, int(r.credential.Uid), int(r.credential.Gid)); err == nil {
+			err = os.Chown(stagedPath, int(r.credential.Uid), int(r.credential.Gid))
+		}
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return stagedPath, cleanup, nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build linux
+// +build linux
+
+package runscript
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	executersmock "github.com/aws/amazon-ssm-agent/agent/mocks/executers"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+const nobodyId = 65534
+
+// mockRunAsUserLookup makes the user lookup only know the nobody user, and the agent run with the given effective uid
+func mockRunAsUserLookup(t *testing.T, euid int) {
+	oldLookupUser, oldLookupGroupIds, oldGetEuid := lookupUser, lookupGroupIds, getEuid
+	t.Cleanup(func() {
+		lookupUser, lookupGroupIds, getEuid = oldLookupUser, oldLookupGroupIds, oldGetEuid
+	})
+	lookupUser = func(userName string) (*user.User, error) {
+		if userName != "nobody" {
+			return nil, user.UnknownUserError(userName)
+		}
+		id := strconv.Itoa(nobodyId)
+		return &user.User{Username: "nobody", Uid: id, Gid: id, HomeDir: "/nonexistent"}, nil
+	}
+	lookupGroupIds = func(*user.User) ([]string, error) {
+		return []string{strconv.Itoa(nobodyId)}, nil
+	}
+	getEuid = func() int { return euid }
+}
+
+func newRunAsUserTestPlugin() *Plugin {
+	return &Plugin{
+		Context:         context.NewMockDefault(),
+		CommandExecuter: executers.ShellCommandExecuter{},
+		Name:            "aws:runShellScript",
+		ScriptName:      shellScriptName,
+		ShellCommand:    shellCommand,
+		ShellArguments:  shellArgs,
+		ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+	}
+}
+
+func runAsUserTestCommands(t *testing.T, p *Plugin, pluginInput RunScriptPluginInput) iohandler.IOHandler {
+	orchestrationDir := t.TempDir()
+	output := iohandler.NewDefaultIOHandler(p.Context, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.Init(pluginID)
+	pluginInput.Environment = make(map[string]string)
+	p.runCommands(pluginID, pluginInput, orchestrationDir, "/", task.NewChanneledCancelFlag(), output)
+	output.Close()
+	return output
+}
+
+func TestResolveRunAsUser(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+
+	runAsUser, err := resolveRunAsUser("nobody")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/nonexistent", runAsUser.homeDir)
+	assert.Equal(t, &executers.Credential{Uid: nobodyId, Gid: nobodyId, Groups: []uint32{nobodyId}}, runAsUser.credential)
+}
+
+func TestResolveRunAsUser_NonexistentUser(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+
+	_, err := resolveRunAsUser("missing")
+
+	assert.ErrorContains(t, err, "runAsUser missing does not exist")
+}
+
+func TestResolveRunAsUser_AgentNotRoot(t *testing.T) {
+	mockRunAsUserLookup(t, 1000)
+
+	_, err := resolveRunAsUser("nobody")
+	assert.ErrorContains(t, err, "the agent must run as root")
+
+	// running as the agent user itself needs no switch
+	getEuid = func() int { return nobodyId }
+	runAsUser, err := resolveRunAsUser("nobody")
+	assert.NoError(t, err)
+	assert.Nil(t, runAsUser.credential)
+}
+
+func TestCheckWorkingDirectoryAccess(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+	runAsUser, _ := resolveRunAsUser("nobody")
+	if os.Geteuid() != 0 {
+		t.Skip("changing the owner of the working directory requires root")
+	}
+	workingDir := t.TempDir()
+	// the temporary directories of the test are only accessible to their owner
+	assert.NoError(t, os.Chmod(filepath.Dir(workingDir), 0755))
+	assert.NoError(t, os.Chmod(workingDir, 0700))
+	assert.NoError(t, os.Chown(workingDir, 0, 0))
+
+	assert.ErrorContains(t, runAsUser.checkWorkingDirectoryAccess(workingDir), "not allowed to access working directory")
+
+	assert.NoError(t, os.Chown(workingDir, nobodyId, nobodyId))
+	assert.NoError(t, runAsUser.checkWorkingDirectoryAccess(workingDir))
+}
+
+func TestRunCommandsAsRunAsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+	mockRunAsUserLookup(t, 0)
+
+	output := runAsUserTestCommands(t, newRunAsUserTestPlugin(), RunScriptPluginInput{
+		RunCommand: []string{"id -u", "id -g", `echo "$USER $HOME"`},
+		RunAsUser:  "nobody",
+	})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus(), output.GetStderr())
+	assert.Equal(t, fmt.Sprintf("%v\n%v\nnobody /nonexistent", nobodyId, nobodyId), strings.TrimSpace(output.GetStdout()))
+}
+
+func TestRunCommandsWithNonexistentRunAsUser(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+
+	output := runAsUserTestCommands(t, newRunAsUserTestPlugin(), RunScriptPluginInput{
+		RunCommand: []string{"id -u"},
+		RunAsUser:  "missing",
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "runAsUser missing does not exist")
+}
+
+func TestRunCommandsAsRunAsUserWithUnsupportedExecuter(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+	commandExecuter := &executersmock.MockCommandExecuter{}
+	p := newRunAsUserTestPlugin()
+	p.CommandExecuter = commandExecuter
+
+	output := runAsUserTestCommands(t, p, RunScriptPluginInput{
+		RunCommand: []string{"id -u"},
+		RunAsUser:  "nobody",
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "failed to run the commands as user nobody")
+	commandExecuter.AssertNotCalled(t, "NewExecute")
+}
+
+func TestRunCommandsAsRunAsUserWithStdinSource(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+	source := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(source, []byte("secret"), 0600))
+	commandExecuter := &executersmock.MockCommandExecuter{}
+	p := newRunAsUserTestPlugin()
+	p.CommandExecuter = commandExecuter
+
+	output := runAsUserTestCommands(t, p, RunScriptPluginInput{
+		RunCommand:  []string{"cat"},
+		RunAsUser:   "nobody",
+		StdinSource: source,
+	})
+
+	assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+	assert.Contains(t, output.GetStderr(), "stdinSource is not supported when the commands run as user nobody")
+	commandExecuter.AssertNotCalled(t, "NewExecute")
+}
+
+func TestRunCommandsWithoutRunAsUserRunsAsAgentUser(t *testing.T) {
+	mockRunAsUserLookup(t, 0)
+	lookupUser = func(userName string) (*user.User, error) {
+		assert.Fail(t, "the user must not be looked up without runAsUser")
+		return nil, user.UnknownUserError(userName)
+	}
+
+	output := runAsUserTestCommands(t, newRunAsUserTestPlugin(), RunScriptPluginInput{
+		RunCommand: []string{"id -u"},
+	})
+
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus(), output.GetStderr())
+	assert.Equal(t, strconv.Itoa(os.Geteuid()), strings.TrimSpace(output.GetStdout()))
+}
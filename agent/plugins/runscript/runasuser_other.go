@@ -0,0 +1,42 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package runscript
+
+import (
+	"errors"
+
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+)
+
+// runAs is the user the commands of a step run as
+type runAs struct {
+	homeDir    string
+	credential *executers.Credential
+}
+
+// resolveRunAsUser fails as running commands as another user is only supported on linux
+func resolveRunAsUser(userName string) (*runAs, error) {
+	return nil, errors.New("runAsUser is only supported on Linux")
+}
+
+func (r *runAs) checkWorkingDirectoryAccess(workingDir string) error {
+	return nil
+}
+
+func (r *runAs) stageScript(scriptPath string) (string, func(), error) {
+	return scriptPath, func() {}, nil
+}
@@ -16,17 +16,25 @@ package runscript
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/executers"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser/localsecret"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/plugins/pluginutil"
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/session/logging/ansifilter"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/common/identity/identity"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
@@ -38,6 +46,17 @@ const (
 
 var getRemoteProvider = identity.GetRemoteProvider
 
+var newParameterResolverBridge = func(context context.T) ssmparameterresolver.ISsmParameterResolverBridge {
+	return ssmparameterresolver.NewSsmParameterResolverBridge(ssmparameterresolver.NewService(context))
+}
+
+var (
+	// environmentVariableNamePattern is the format of the names of the environment variables set by documents
+	environmentVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// secureParameterValuePattern matches an environment variable value made of a single secure string parameter reference
+	secureParameterValuePattern = regexp.MustCompile(`^\s*{{\s*ssm-secure:[\w-./]+\s*}}\s*$`)
+)
+
 // Plugin is the type for the runscript plugin.
 type Plugin struct {
 	Context context.T
@@ -50,6 +69,15 @@ type Plugin struct {
 	ShellArguments        []string
 	ByteOrderMark         fileutil.ByteOrderMark
 	IdentityRuntimeClient runtimeconfig.IIdentityRuntimeConfigClient
+	// StripAnsi removes ANSI escape sequences from the captured output, the process output is left untouched
+	StripAnsi bool
+	// LoginShellArguments are prepended to ShellArguments to run the commands in a login shell,
+	// the plugin does not support the loginShell input when empty
+	LoginShellArguments []string
+	// SupportsInterpreter is true for the plugins running their script with the interpreter input when it is set
+	SupportsInterpreter bool
+	// RedactedValues are secret values masked in the logged commands and in the script file once the commands complete
+	RedactedValues []string
 }
 
 // RunScriptPluginInput represents one set of commands executed by the RunScript plugin.
@@ -60,13 +88,29 @@ type RunScriptPluginInput struct {
 	ID               string
 	WorkingDirectory string
 	TimeoutSeconds   interface{}
+	// RunAsUser is the user the commands run as, the commands run as the agent user when empty
+	RunAsUser string
+	// StdinSource is a file or named pipe whose content is streamed to the standard input of the commands.
+	// The agent opens it, so it cannot be combined with a RunAsUser other than the agent user
+	StdinSource string
+	// LoginShell runs the commands in a login shell, which sources /etc/profile and the profile of the user first.
+	// Sourcing the profiles slows down every invocation, and whatever they do, such as printing a banner,
+	// changing the working directory or prompting for input, affects the commands and their output.
+	LoginShell bool
+	// Interpreter is the executable running the script, followed by its arguments, the script path is passed last.
+	// The executable is looked up in the PATH when it is not a path. The plugin shell runs the script when empty.
+	Interpreter []string
+	// CombineOutput sends the standard error of the commands to their standard output, interleaved in the order it is written.
+	// The standard error of the step is then left empty.
+	CombineOutput bool
 }
 
 // Execute runs multiple sets of commands and returns their outputs.
 // res.Output will contain a slice of RunScriptPluginOutput.
 func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelFlag, output iohandler.IOHandler) {
 	log := p.Context.Log()
-	log.Infof("%v started with configuration %v", p.Name, config)
+	p.RedactedValues = config.RedactedValues
+	log.Infof("%v started with configuration %v", p.Name, p.redact(fmt.Sprint(config)))
 	log.Debugf("DefaultWorkingDirectory %v", config.DefaultWorkingDirectory)
 
 	runCommandID, err := messageContracts.GetCommandID(config.MessageId)
@@ -80,7 +124,13 @@ func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelF
 		output.MarkAsShutdown()
 	} else if cancelFlag.Canceled() {
 		output.MarkAsCancelled()
+	} else if err = executers.ValidateProcessPriority(config.ProcessPriority); err != nil {
+		output.MarkAsFailed(err)
 	} else {
+		p.CommandExecuter = executers.WithPriority(p.CommandExecuter, config.ProcessPriority)
+		p.CommandExecuter = executers.WithResourceUsageRecorder(p.CommandExecuter, output)
+		p.CommandExecuter = executers.WithProcessIDRecorder(p.CommandExecuter, output)
+		p.StripAnsi = config.StripAnsi
 		p.runCommandsRawInput(config.PluginID, config.Properties, config.OrchestrationDirectory, config.DefaultWorkingDirectory, cancelFlag, output, runCommandID)
 	}
 }
@@ -112,6 +162,33 @@ func (p *Plugin) setShareCredsEnvironment(pluginInput RunScriptPluginInput) {
 	}
 }
 
+// resolveEnvironment validates the names of the environment variables set by the document, and replaces the values
+// referencing a secure string parameter with the parameter value so that the secret is only given to the script process
+func (p *Plugin) resolveEnvironment(environment map[string]string) error {
+	var bridge ssmparameterresolver.ISsmParameterResolverBridge
+	for _, name := range environmentVariableNames(environment) {
+		if !environmentVariableNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q, names must start with a letter or an underscore followed by letters, digits or underscores", name)
+		}
+		value := environment[name]
+		if !ssmparameterresolver.TextContainsSecureSsmParameters(value) {
+			continue
+		}
+		if !secureParameterValuePattern.MatchString(value) {
+			return fmt.Errorf("the value of environment variable %v must be a single secure string parameter reference", name)
+		}
+		if bridge == nil {
+			bridge = newParameterResolverBridge(p.Context)
+		}
+		resolved, err := bridge.GetParameterFromSsmParameterStore(p.Context.Log(), value)
+		if err != nil {
+			return fmt.Errorf("failed to resolve the value of environment variable %v: %v", name, err)
+		}
+		environment[name] = resolved
+	}
+	return nil
+}
+
 func (p *Plugin) setCommandIdEnvironment(pluginInput RunScriptPluginInput, runCommandID string) {
 	if runCommandID != "" {
 		// Check if "SSM_COMMAND_ID" exists already in the env. If so, log that it will be overwritten
@@ -135,6 +212,9 @@ func (p *Plugin) runCommandsRawInput(pluginID string, rawPluginInput interface{}
 
 	if pluginInput.Environment == nil {
 		pluginInput.Environment = make(map[string]string)
+	} else if err = p.resolveEnvironment(pluginInput.Environment); err != nil {
+		output.MarkAsFailed(err)
+		return
 	}
 
 	p.setCommandIdEnvironment(pluginInput, runCommandID)
@@ -164,9 +244,39 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 		}
 	}
 
+	if pluginInput.LoginShell && len(p.LoginShellArguments) == 0 {
+		output.MarkAsFailed(fmt.Errorf("loginShell is not supported by %v", p.Name))
+		return
+	}
+
+	commandName, commandArguments := p.ShellCommand, p.shellArguments(pluginInput.LoginShell)
+	if len(pluginInput.Interpreter) > 0 {
+		if commandName, err = p.resolveInterpreter(pluginInput); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+		commandArguments = append([]string{}, pluginInput.Interpreter[1:]...)
+	}
+
+	var runAsUser *runAs
+	if pluginInput.RunAsUser != "" {
+		if runAsUser, err = resolveRunAsUser(pluginInput.RunAsUser); err == nil {
+			err = runAsUser.checkWorkingDirectoryAccess(workingDir)
+		}
+		// the agent opens the stdin source, which would let the commands read a file the user has no access to
+		if err == nil && runAsUser.credential != nil && pluginInput.StdinSource != "" {
+			err = fmt.Errorf("stdinSource is not supported when the commands run as user %v", pluginInput.RunAsUser)
+		}
+		if err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+	}
+
 	// TODO:MF: This subdirectory is only needed because we could be running multiple sets of properties for the same plugin - otherwise the orchestration directory would already be unique
 	orchestrationDir := fileutil.BuildPath(orchestrationDirectory, pluginInput.ID)
-	log.Debugf("Running commands %v with environment variables %v in workingDirectory %v; orchestrationDir %v ", pluginInput.RunCommand, pluginInput.Environment, workingDir, orchestrationDir)
+	// the values of the environment variables may be secrets, only their names are logged
+	log.Debugf("Running commands %v with environment variables %v in workingDirectory %v; orchestrationDir %v ", p.redact(fmt.Sprint(pluginInput.RunCommand)), environmentVariableNames(pluginInput.Environment), workingDir, orchestrationDir)
 
 	// create orchestration dir if needed
 	if err = fileutil.MakeDirsWithExecuteAccess(orchestrationDir); err != nil {
@@ -174,25 +284,78 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 		return
 	}
 
+	output.SetResolvedInput(strings.Join(pluginInput.RunCommand, "\n"))
+
 	// Create script file path
 	scriptPath := filepath.Join(orchestrationDir, p.ScriptName)
-	log.Debugf("Writing commands %v to file %v", pluginInput, scriptPath)
+	log.Debugf("Writing commands %v to file %v", p.redact(fmt.Sprint(pluginInput.RunCommand)), scriptPath)
 
 	// Create script file
 	if err = pluginutil.CreateScriptFile(log, scriptPath, pluginInput.RunCommand, p.ByteOrderMark); err != nil {
 		output.MarkAsFailed(fmt.Errorf("failed to create script file. %v", err))
 		return
 	}
+	if len(p.RedactedValues) > 0 {
+		defer p.redactScriptFile(scriptPath, pluginInput.RunCommand)
+	}
+
+	commandExecuter := p.CommandExecuter
+	if runAsUser != nil {
+		// without a credential the user is the agent user, no switch is needed
+		if runAsUser.credential != nil {
+			if commandExecuter, err = executers.WithCredential(commandExecuter, runAsUser.credential); err != nil {
+				output.MarkAsFailed(fmt.Errorf("failed to run the commands as user %v. %v", pluginInput.RunAsUser, err))
+				return
+			}
+		}
+		stagedScriptPath, cleanup, err := runAsUser.stageScript(scriptPath)
+		if err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to create script file for user %v. %v", pluginInput.RunAsUser, err))
+			return
+		}
+		defer cleanup()
+		scriptPath = stagedScriptPath
+		setRunAsUserEnvironment(pluginInput.Environment, pluginInput.RunAsUser, runAsUser.homeDir)
+	}
 
 	// Set execution time
 	executionTimeout := pluginutil.ValidateExecutionTimeout(log, pluginInput.TimeoutSeconds)
 
-	// Construct Command Name and Arguments
-	commandName := p.ShellCommand
-	commandArguments := append(p.ShellArguments, scriptPath)
+	if pluginInput.StdinSource != "" {
+		// the wait for a writer of a named pipe counts against the execution timeout
+		var stdinSource *os.File
+		if stdinSource, executionTimeout, err = openStdinSource(pluginInput.StdinSource, cancelFlag, executionTimeout); err != nil {
+			output.MarkAsFailed(err)
+			return
+		}
+		// the commands read the file directly, they see the end of the source as the end of their standard input
+		defer stdinSource.Close()
+		if commandExecuter, err = executers.WithStdin(commandExecuter, stdinSource); err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to stream %v to the standard input of the commands. %v", pluginInput.StdinSource, err))
+			return
+		}
+	}
+
+	if pluginInput.CombineOutput {
+		if commandExecuter, err = executers.WithCombinedOutput(commandExecuter); err != nil {
+			output.MarkAsFailed(fmt.Errorf("failed to combine the output of the commands. %v", err))
+			return
+		}
+	}
+
+	// The script path is the last argument of the shell or interpreter
+	commandArguments = append(commandArguments, scriptPath)
+
+	var stdoutWriter, stderrWriter io.Writer = output.GetStdoutWriter(), output.GetStderrWriter()
+	if p.StripAnsi {
+		stdoutFilter, stderrFilter := ansifilter.NewWriter(stdoutWriter), ansifilter.NewWriter(stderrWriter)
+		defer stdoutFilter.Flush()
+		defer stderrFilter.Flush()
+		stdoutWriter, stderrWriter = stdoutFilter, stderrFilter
+	}
 
 	// Execute Command
-	exitCode, err := p.CommandExecuter.NewExecute(p.Context, workingDir, output.GetStdoutWriter(), output.GetStderrWriter(), cancelFlag, executionTimeout, commandName, commandArguments, pluginInput.Environment)
+	exitCode, err := commandExecuter.NewExecute(p.Context, workingDir, stdoutWriter, stderrWriter, cancelFlag, executionTimeout, commandName, commandArguments, pluginInput.Environment)
 
 	// Set output status
 	output.SetExitCode(exitCode)
@@ -207,3 +370,63 @@ func (p *Plugin) runCommands(pluginID string, pluginInput RunScriptPluginInput,
 		}
 	}
 }
+
+// redact masks the secret values of the step in text
+func (p *Plugin) redact(text string) string {
+	return localsecret.Redact(text, p.RedactedValues)
+}
+
+// redactScriptFile rewrites the script file left in the orchestration directory with the secret values masked
+func (p *Plugin) redactScriptFile(scriptPath string, runCommand []string) {
+	redactedCommand := make([]string, len(runCommand))
+	for i, command := range runCommand {
+		redactedCommand[i] = p.redact(command)
+	}
+	if err := pluginutil.CreateScriptFile(p.Context.Log(), scriptPath, redactedCommand, p.ByteOrderMark); err != nil {
+		p.Context.Log().Warnf("failed to mask the secret values in script file %v", scriptPath)
+	}
+}
+
+// shellArguments returns the arguments of the shell running the script, in a login shell when loginShell is true
+func (p *Plugin) shellArguments(loginShell bool) []string {
+	var arguments []string
+	if loginShell {
+		arguments = append(arguments, p.LoginShellArguments...)
+	}
+	return append(arguments, p.ShellArguments...)
+}
+
+// resolveInterpreter returns the path of the interpreter executable set by the document, after checking it is executable
+func (p *Plugin) resolveInterpreter(pluginInput RunScriptPluginInput) (string, error) {
+	if !p.SupportsInterpreter {
+		return "", fmt.Errorf("interpreter is not supported by %v", p.Name)
+	}
+	if pluginInput.LoginShell {
+		return "", fmt.Errorf("interpreter cannot be combined with loginShell")
+	}
+	interpreterPath, err := exec.LookPath(pluginInput.Interpreter[0])
+	if err != nil {
+		return "", fmt.Errorf("interpreter %v is not an executable file: %v", pluginInput.Interpreter[0], err)
+	}
+	return interpreterPath, nil
+}
+
+// setRunAsUserEnvironment points HOME and USER to the user the commands run as, unless the document sets them
+func setRunAsUserEnvironment(environment map[string]string, userName string, homeDir string) {
+	if _, ok := environment["USER"]; !ok {
+		environment["USER"] = userName
+	}
+	if _, ok := environment["HOME"]; !ok && homeDir != "" {
+		environment["HOME"] = homeDir
+	}
+}
+
+// environmentVariableNames returns the sorted names of the environment variables
+func environmentVariableNames(environment map[string]string) []string {
+	names := make([]string, 0, len(environment))
+	for name := range environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -15,8 +15,10 @@ package runscript
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	agentcontext "github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/executers"
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
@@ -29,6 +31,8 @@ import (
 	iohandlermocks "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/mock"
 	multiwritermock "github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler/multiwriter/mock"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+	ssmparameterresolvermock "github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/mock"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/amazon-ssm-agent/common/identity"
 	"github.com/aws/amazon-ssm-agent/common/runtimeconfig"
@@ -50,7 +54,6 @@ type TestCase struct {
 type CommandTester func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler)
 
 const (
-	orchestrationDirectory  = "OrchesDir"
 	defaultWorkingDirectory = ""
 	s3BucketName            = "bucket"
 	s3KeyPrefix             = "key"
@@ -158,9 +161,9 @@ func testRunScripts(t *testing.T, testCase TestCase, rawInput bool) {
 			err := jsonutil.Remarshal(testCase.Input, &rawPluginInput)
 			assert.Nil(t, err)
 
-			p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler, runCommandID)
+			p.runCommandsRawInput(pluginID, rawPluginInput, t.TempDir(), defaultWorkingDirectory, mockCancelFlag, mockIOHandler, runCommandID)
 		} else {
-			p.runCommands(pluginID, testCase.Input, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+			p.runCommands(pluginID, testCase.Input, t.TempDir(), defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
 		}
 	}
 
@@ -257,6 +260,95 @@ func TestSetCommandIdEnvironment(t *testing.T) {
 	assert.Len(t, pluginInput.Environment, 1)
 }
 
+func TestResolveEnvironment(t *testing.T) {
+	oldFunc := newParameterResolverBridge
+	defer func() { newParameterResolverBridge = oldFunc }()
+	bridgeCreated := false
+	newParameterResolverBridge = func(agentcontext.T) ssmparameterresolver.ISsmParameterResolverBridge {
+		bridgeCreated = true
+		return ssmparameterresolvermock.GetSsmParamResolverBridge(map[string]string{"{{ ssm-secure:apiToken }}": "s3cr3t"})
+	}
+	p := &Plugin{Context: context.NewMockDefault()}
+
+	environment := map[string]string{"GREETING": "hello", "_PATH_2": "/tmp"}
+	assert.NoError(t, p.resolveEnvironment(environment))
+	assert.Equal(t, map[string]string{"GREETING": "hello", "_PATH_2": "/tmp"}, environment)
+	assert.False(t, bridgeCreated)
+
+	environment = map[string]string{"GREETING": "hello", "API_TOKEN": "{{ ssm-secure:apiToken }}"}
+	assert.NoError(t, p.resolveEnvironment(environment))
+	assert.Equal(t, map[string]string{"GREETING": "hello", "API_TOKEN": "s3cr3t"}, environment)
+	assert.True(t, bridgeCreated)
+
+	environment = map[string]string{"API_TOKEN": "{{ ssm-secure:missing }}"}
+	assert.EqualError(t, p.resolveEnvironment(environment), "failed to resolve the value of environment variable API_TOKEN: parameter does not exist")
+
+	environment = map[string]string{"AUTHORIZATION": "Bearer {{ ssm-secure:apiToken }}"}
+	assert.EqualError(t, p.resolveEnvironment(environment), "the value of environment variable AUTHORIZATION must be a single secure string parameter reference")
+}
+
+// TestRunCommandsRawInputWithInvalidEnvironmentVariableName tests that the commands are not run
+// when the document sets an environment variable with an invalid name
+func TestRunCommandsRawInputWithInvalidEnvironmentVariableName(t *testing.T) {
+	for _, name := range []string{"", "1VAR", "MY-VAR", "MY VAR", "VAR=1", "ÉTÉ"} {
+		executeTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+			mockIOHandler.On("MarkAsFailed", mock.MatchedBy(func(err error) bool {
+				return strings.HasPrefix(err.Error(), fmt.Sprintf("invalid environment variable name %q", name))
+			})).Return()
+
+			testCase := generateTestCaseOk("0", map[string]string{name: "value"})
+			p.runCommandsRawInput(pluginID, singleValuePropertyBuilder(t, testCase), t.TempDir(), defaultWorkingDirectory, mockCancelFlag, mockIOHandler, "")
+
+			mockExecuter.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+
+		testExecution(t, executeTester)
+	}
+}
+
+// TestShellArguments tests that the login shell arguments are only passed to the shell when loginShell is set
+func TestShellArguments(t *testing.T) {
+	p := &Plugin{ShellCommand: shellCommand, ShellArguments: shellArgs, LoginShellArguments: loginShellArgs}
+
+	assert.Equal(t, []string{"-c"}, p.shellArguments(false))
+	assert.Equal(t, []string{"-l", "-c"}, p.shellArguments(true))
+	assert.Equal(t, []string{"-c"}, p.ShellArguments)
+}
+
+// TestRunCommandsRawInputLoginShellNotSupported tests that the commands are not run when the document
+// asks for a login shell the plugin does not support
+func TestRunCommandsRawInputLoginShellNotSupported(t *testing.T) {
+	executeTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockIOHandler.On("MarkAsFailed", fmt.Errorf("loginShell is not supported by aws:runShellScript")).Return()
+
+		testCase := generateTestCaseOk("0", nil)
+		rawPluginInput := singleValuePropertyBuilder(t, testCase).(map[string]interface{})
+		rawPluginInput["loginShell"] = true
+		p.runCommandsRawInput(pluginID, rawPluginInput, t.TempDir(), defaultWorkingDirectory, mockCancelFlag, mockIOHandler, "")
+
+		mockExecuter.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+
+	testExecution(t, executeTester)
+}
+
+// TestRunCommandsRawInputInterpreterNotSupported tests that the commands are not run when the document
+// sets an interpreter the plugin does not support
+func TestRunCommandsRawInputInterpreterNotSupported(t *testing.T) {
+	executeTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockIOHandler.On("MarkAsFailed", fmt.Errorf("interpreter is not supported by aws:runShellScript")).Return()
+
+		testCase := generateTestCaseOk("0", nil)
+		rawPluginInput := singleValuePropertyBuilder(t, testCase).(map[string]interface{})
+		rawPluginInput["interpreter"] = []interface{}{"/usr/bin/python3"}
+		p.runCommandsRawInput(pluginID, rawPluginInput, t.TempDir(), defaultWorkingDirectory, mockCancelFlag, mockIOHandler, "")
+
+		mockExecuter.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+
+	testExecution(t, executeTester)
+}
+
 // TestBucketsInDifferentRegions tests runScripts when S3Buckets are present in IAD and PDX region.
 func TestBucketsInDifferentRegions(t *testing.T) {
 	for _, testCase := range TestCases {
@@ -275,7 +367,7 @@ func testBucketsInDifferentRegions(t *testing.T, testCase TestCase, testingBucke
 		setIOHandlerExpectations(mockIOHandler, testCase)
 
 		// call method under test
-		p.runCommands(pluginID, testCase.Input, orchestrationDirectory, defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
+		p.runCommands(pluginID, testCase.Input, t.TempDir(), defaultWorkingDirectory, mockCancelFlag, mockIOHandler)
 	}
 
 	testExecution(t, runScriptTester)
@@ -296,6 +388,25 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+// TestExecuteWithInvalidProcessPriority tests that the step fails without running commands when the priority is invalid.
+func TestExecuteWithInvalidProcessPriority(t *testing.T) {
+	executeTester := func(p *Plugin, mockCancelFlag *taskmocks.MockCancelFlag, mockExecuter *executers.MockCommandExecuter, mockIOHandler *iohandlermocks.MockIOHandler) {
+		mockCancelFlag.On("ShutDown").Return(false)
+		mockCancelFlag.On("Canceled").Return(false)
+		mockIOHandler.On("MarkAsFailed", mock.Anything).Return()
+
+		p.Execute(contracts.Configuration{
+			PluginID:        pluginID,
+			Properties:      singleValuePropertyBuilder(t, TestCases[0]),
+			ProcessPriority: contracts.ProcessPriority{Nice: 25},
+		}, mockCancelFlag, mockIOHandler)
+
+		mockExecuter.AssertNotCalled(t, "NewExecute", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	}
+
+	testExecution(t, executeTester)
+}
+
 func arrayPropertyBuilder(t *testing.T, testCases []TestCase) interface{} {
 	var pluginProperties []interface{}
 
@@ -362,7 +473,7 @@ func testExecuteMultiInput(t *testing.T, testCases []TestCase) {
 				Properties:             pluginProperties,
 				OutputS3BucketName:     s3BucketName,
 				OutputS3KeyPrefix:      s3KeyPrefix,
-				OrchestrationDirectory: orchestrationDirectory,
+				OrchestrationDirectory: t.TempDir(),
 				BookKeepingFileName:    commandID,
 				PluginID:               pluginID,
 				MessageId:              "aws.ssm.21cd24ac-4aca-4d53-bd65-c06d64b7b343.i-03b9244a8137e5bac",
@@ -394,7 +505,7 @@ func testExecute(t *testing.T, testCase TestCase) {
 				Properties:             pluginProperties,
 				OutputS3BucketName:     s3BucketName,
 				OutputS3KeyPrefix:      s3KeyPrefix,
-				OrchestrationDirectory: orchestrationDirectory,
+				OrchestrationDirectory: t.TempDir(),
 				BookKeepingFileName:    commandID,
 				PluginID:               pluginID,
 				MessageId:              "aws.ssm.21cd24ac-4aca-4d53-bd65-c06d64b7b343.i-03b9244a8137e5bac",
@@ -426,7 +537,7 @@ func testExecuteWithEnvironment(t *testing.T, testCase TestCase) {
 				Properties:             pluginProperties,
 				OutputS3BucketName:     s3BucketName,
 				OutputS3KeyPrefix:      s3KeyPrefix,
-				OrchestrationDirectory: orchestrationDirectory,
+				OrchestrationDirectory: t.TempDir(),
 				BookKeepingFileName:    commandID,
 				PluginID:               pluginID,
 				MessageId:              "aws.ssm.21cd24ac-4aca-4d53-bd65-c06d64b7b343.i-03b9244a8137e5bac",
@@ -474,6 +585,7 @@ func setIOHandlerExpectations(mockIOHandler *iohandlermocks.MockIOHandler, t Tes
 	mockIOHandler.On("GetStderrWriter").Return(t.Output.StderrWriter)
 	mockIOHandler.On("SetExitCode", t.Output.ExitCode).Return()
 	mockIOHandler.On("SetStatus", t.Output.Status).Return()
+	mockIOHandler.On("SetResolvedInput", strings.Join(t.Input.RunCommand, "\n")).Return()
 	if t.ExecuterError != nil {
 		mockIOHandler.On("GetStatus").Return(t.Output.Status)
 		mockIOHandler.On("MarkAsFailed", fmt.Errorf("failed to run commands: %v", t.ExecuterError)).Return()
@@ -3,6 +3,499 @@
 
 package runscript
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	agentcontext "github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/executers"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver"
+	ssmparameterresolvermock "github.com/aws/amazon-ssm-agent/agent/ssm/ssmparameterresolver/mock"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/aws/amazon-ssm-agent/common/identity/credentialproviders"
+	"github.com/stretchr/testify/assert"
+)
+
 const (
 	rootAbsPath = "/"
 )
+
+// TestRunCommandsRawInputEnvironmentVisibleToScript runs a shell script which only succeeds
+// when it sees the environment variables set by the document
+func TestRunCommandsRawInputEnvironmentVisibleToScript(t *testing.T) {
+	oldGetRemoteProvider, oldNewParameterResolverBridge := getRemoteProvider, newParameterResolverBridge
+	defer func() {
+		getRemoteProvider, newParameterResolverBridge = oldGetRemoteProvider, oldNewParameterResolverBridge
+	}()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+	newParameterResolverBridge = func(agentcontext.T) ssmparameterresolver.ISsmParameterResolverBridge {
+		return ssmparameterresolvermock.GetSsmParamResolverBridge(map[string]string{"{{ssm-secure:apiToken}}": "s3cr3t"})
+	}
+
+	ctx := context.NewMockDefault()
+	p := &Plugin{
+		Context:         ctx,
+		CommandExecuter: executers.ShellCommandExecuter{},
+		Name:            "aws:runShellScript",
+		ScriptName:      shellScriptName,
+		ShellCommand:    shellCommand,
+		ShellArguments:  shellArgs,
+		ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+	}
+	orchestrationDir := t.TempDir()
+	output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.Init(pluginID)
+	rawPluginInput := map[string]interface{}{
+		"runCommand": []interface{}{`[ "$API_TOKEN" = "s3cr3t" ] && echo "greeting: $GREETING"`},
+		"environment": map[string]interface{}{
+			"GREETING":  "hello",
+			"API_TOKEN": "{{ssm-secure:apiToken}}",
+		},
+	}
+
+	p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDir, orchestrationDir, task.NewChanneledCancelFlag(), output, "")
+	output.Close()
+
+	assert.Equal(t, 0, output.GetExitCode())
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Contains(t, output.GetStdout(), "greeting: hello")
+	assert.NotContains(t, output.GetStdout(), "s3cr3t")
+}
+
+// TestRunCommandsStripAnsi runs a shell script printing colored output, with an escape sequence split across two writes
+func TestRunCommandsStripAnsi(t *testing.T) {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	script := `printf '\033[1;32mok\033[0m\n'; printf '\033[3' >&2; sleep 0.2; printf '1merror\033[0m\n' >&2`
+	testCases := []struct {
+		name           string
+		stripAnsi      bool
+		expectedStdout string
+		expectedStderr string
+	}{
+		{"StripAnsi", true, "ok\n", "error\n"},
+		{"KeepAnsi", false, "\033[1;32mok\033[0m\n", "\033[31merror\033[0m\n"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.NewMockDefault()
+			p := &Plugin{
+				Context:         ctx,
+				CommandExecuter: executers.ShellCommandExecuter{},
+				Name:            "aws:runShellScript",
+				ScriptName:      shellScriptName,
+				ShellCommand:    shellCommand,
+				ShellArguments:  shellArgs,
+				ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+				StripAnsi:       testCase.stripAnsi,
+			}
+			orchestrationDir := t.TempDir()
+			output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+			output.Init(pluginID)
+			rawPluginInput := map[string]interface{}{
+				"runCommand": []interface{}{script},
+			}
+
+			p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDir, orchestrationDir, task.NewChanneledCancelFlag(), output, "")
+			output.Close()
+
+			assert.Equal(t, 0, output.GetExitCode())
+			assert.Equal(t, testCase.expectedStdout, output.GetStdout())
+			assert.Equal(t, testCase.expectedStderr, output.GetStderr())
+		})
+	}
+}
+
+// TestRunCommandsLoginShell runs a shell script which prints a variable exported by the profile of the user,
+// the profile is only sourced when the commands run in a login shell
+func TestRunCommandsLoginShell(t *testing.T) {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	homeDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(homeDir, ".profile"), []byte("export PROFILE_SOURCED=yes\n"), 0600))
+	for _, loginShell := range []bool{false, true} {
+		ctx := context.NewMockDefault()
+		p := &Plugin{
+			Context:             ctx,
+			CommandExecuter:     executers.ShellCommandExecuter{},
+			Name:                "aws:runShellScript",
+			ScriptName:          shellScriptName,
+			ShellCommand:        shellCommand,
+			ShellArguments:      shellArgs,
+			ByteOrderMark:       fileutil.ByteOrderMarkSkip,
+			LoginShellArguments: loginShellArgs,
+		}
+		orchestrationDir := t.TempDir()
+		output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+		output.Init(pluginID)
+		rawPluginInput := map[string]interface{}{
+			"runCommand":  []interface{}{`echo "profile sourced: ${PROFILE_SOURCED:-no}"`},
+			"environment": map[string]interface{}{"HOME": homeDir},
+			"loginShell":  loginShell,
+		}
+
+		p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDir, orchestrationDir, task.NewChanneledCancelFlag(), output, "")
+		output.Close()
+
+		assert.Equal(t, 0, output.GetExitCode())
+		if loginShell {
+			assert.Contains(t, output.GetStdout(), "profile sourced: yes")
+		} else {
+			assert.Equal(t, "profile sourced: no\n", output.GetStdout())
+		}
+	}
+}
+
+// TestRunCommandsInterpreter runs the commands with the interpreter set by the document
+func TestRunCommandsInterpreter(t *testing.T) {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	notExecutable := filepath.Join(t.TempDir(), "interpreter")
+	assert.NoError(t, os.WriteFile(notExecutable, []byte("#!/bin/sh\n"), 0600))
+	testCases := []struct {
+		name           string
+		interpreter    []interface{}
+		runCommand     []interface{}
+		expectedStdout string
+		expectedError  string
+	}{
+		{"Default", nil, []interface{}{"false", "echo hello"}, "hello\n", ""},
+		{"CustomPath", []interface{}{"/bin/cat"}, []interface{}{"echo hello"}, "echo hello\n", ""},
+		// errexit stops the script before it prints anything
+		{"CustomPathWithArguments", []interface{}{"/bin/sh", "-e"}, []interface{}{"false", "echo hello"}, "", "failed to run commands"},
+		{"NonexistentPath", []interface{}{"/nonexistent/interpreter"}, []interface{}{"echo hello"}, "", "interpreter /nonexistent/interpreter is not an executable file"},
+		{"NotExecutable", []interface{}{notExecutable}, []interface{}{"echo hello"}, "", fmt.Sprintf("interpreter %v is not an executable file", notExecutable)},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.NewMockDefault()
+			p := &Plugin{
+				Context:             ctx,
+				CommandExecuter:     executers.ShellCommandExecuter{},
+				Name:                "aws:runShellScript",
+				ScriptName:          shellScriptName,
+				ShellCommand:        shellCommand,
+				ShellArguments:      shellArgs,
+				ByteOrderMark:       fileutil.ByteOrderMarkSkip,
+				SupportsInterpreter: true,
+			}
+			orchestrationDir := t.TempDir()
+			output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+			output.Init(pluginID)
+			rawPluginInput := map[string]interface{}{
+				"runCommand": testCase.runCommand,
+			}
+			if testCase.interpreter != nil {
+				rawPluginInput["interpreter"] = testCase.interpreter
+			}
+
+			p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDir, orchestrationDir, task.NewChanneledCancelFlag(), output, "")
+			output.Close()
+
+			if testCase.expectedError != "" {
+				assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+				assert.Contains(t, output.GetStderr(), testCase.expectedError)
+				assert.Empty(t, output.GetStdout())
+				return
+			}
+			assert.Equal(t, 0, output.GetExitCode())
+			assert.Equal(t, testCase.expectedStdout, output.GetStdout())
+		})
+	}
+}
+
+// TestRunCommandsCombineOutput runs a script writing to both streams, with and without combining them
+func TestRunCommandsCombineOutput(t *testing.T) {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	runCommand := []interface{}{"echo out1", "echo err1 >&2", "echo out2", "echo err2 >&2"}
+	testCases := []struct {
+		name           string
+		combineOutput  bool
+		expectedStdout string
+		expectedStderr string
+	}{
+		{"Separate", false, "out1\nout2\n", "err1\nerr2\n"},
+		{"Combined", true, "out1\nerr1\nout2\nerr2\n", ""},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.NewMockDefault()
+			p := &Plugin{
+				Context:         ctx,
+				CommandExecuter: executers.ShellCommandExecuter{},
+				Name:            "aws:runShellScript",
+				ScriptName:      shellScriptName,
+				ShellCommand:    shellCommand,
+				ShellArguments:  shellArgs,
+				ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+			}
+			orchestrationDir := t.TempDir()
+			output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+			output.Init(pluginID)
+			rawPluginInput := map[string]interface{}{
+				"runCommand":    runCommand,
+				"combineOutput": testCase.combineOutput,
+			}
+
+			p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDir, orchestrationDir, task.NewChanneledCancelFlag(), output, "")
+			output.Close()
+
+			assert.Equal(t, 0, output.GetExitCode())
+			assert.Equal(t, testCase.expectedStdout, output.GetStdout())
+			assert.Equal(t, testCase.expectedStderr, output.GetStderr())
+		})
+	}
+}
+
+// TestExecuteRecordsProcessID runs a short-lived shell script and checks the ID of the spawned shell is recorded
+func TestExecuteRecordsProcessID(t *testing.T) {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	ctx := context.NewMockDefault()
+	p := &Plugin{
+		Context:         ctx,
+		CommandExecuter: executers.ShellCommandExecuter{},
+		Name:            "aws:runShellScript",
+		ScriptName:      shellScriptName,
+		ShellCommand:    shellCommand,
+		ShellArguments:  shellArgs,
+		ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+	}
+	orchestrationDir := t.TempDir()
+	output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.Init(pluginID)
+	config := contracts.Configuration{
+		PluginID:                pluginID,
+		OrchestrationDirectory:  orchestrationDir,
+		DefaultWorkingDirectory: orchestrationDir,
+		Properties:              map[string]interface{}{"runCommand": []interface{}{"exit 0"}},
+	}
+
+	p.Execute(config, task.NewChanneledCancelFlag(), output)
+	output.Close()
+
+	assert.Equal(t, 0, output.GetExitCode())
+	assert.NotZero(t, output.GetProcessID())
+}
+
+// TestExecuteRedactsScriptFile runs a script using a secret value, which must be masked in the script file once it completes
+func TestExecuteRedactsScriptFile(t *testing.T) {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	ctx := context.NewMockDefault()
+	p := &Plugin{
+		Context:         ctx,
+		CommandExecuter: executers.ShellCommandExecuter{},
+		Name:            "aws:runShellScript",
+		ScriptName:      shellScriptName,
+		ShellCommand:    shellCommand,
+		ShellArguments:  shellArgs,
+		ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+	}
+	orchestrationDir := t.TempDir()
+	output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.Init(pluginID)
+	config := contracts.Configuration{
+		PluginID:                pluginID,
+		OrchestrationDirectory:  orchestrationDir,
+		DefaultWorkingDirectory: orchestrationDir,
+		Properties:              map[string]interface{}{"runCommand": []interface{}{`[ "s3cr3t" = "s3cr3t" ]`}},
+		RedactedValues:          []string{"s3cr3t"},
+	}
+
+	p.Execute(config, task.NewChanneledCancelFlag(), output)
+	output.Close()
+
+	assert.Equal(t, 0, output.GetExitCode())
+	script, err := os.ReadFile(filepath.Join(orchestrationDir, shellScriptName))
+	assert.NoError(t, err)
+	assert.Equal(t, "[ \"****\" = \"****\" ]\n", string(script))
+}
+
+// runShellScriptWithStdin runs a shell script reading its standard input from the stdin source
+func runShellScriptWithStdin(t *testing.T, script string, stdinSource string) iohandler.IOHandler {
+	oldGetRemoteProvider := getRemoteProvider
+	defer func() { getRemoteProvider = oldGetRemoteProvider }()
+	getRemoteProvider = func(agentIdentity identity.IAgentIdentity) (credentialproviders.IRemoteProvider, bool) {
+		return nil, false
+	}
+
+	ctx := context.NewMockDefault()
+	p := &Plugin{
+		Context:         ctx,
+		CommandExecuter: executers.ShellCommandExecuter{},
+		Name:            "aws:runShellScript",
+		ScriptName:      shellScriptName,
+		ShellCommand:    shellCommand,
+		ShellArguments:  shellArgs,
+		ByteOrderMark:   fileutil.ByteOrderMarkSkip,
+	}
+	orchestrationDir := t.TempDir()
+	output := iohandler.NewDefaultIOHandler(ctx, contracts.IOConfiguration{OrchestrationDirectory: orchestrationDir})
+	output.Init(pluginID)
+	rawPluginInput := map[string]interface{}{
+		"runCommand":     []interface{}{script},
+		"stdinSource":    stdinSource,
+		"timeoutSeconds": "10",
+	}
+
+	p.runCommandsRawInput(pluginID, rawPluginInput, orchestrationDir, orchestrationDir, task.NewChanneledCancelFlag(), output, "")
+	output.Close()
+	return output
+}
+
+func TestRunCommandsStdinSourceFile(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "input.txt")
+	assert.NoError(t, os.WriteFile(source, []byte("first line\nsecond line\n"), 0600))
+
+	output := runShellScriptWithStdin(t, "cat -n", source)
+
+	assert.Equal(t, 0, output.GetExitCode())
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Contains(t, output.GetStdout(), "1\tfirst line")
+	assert.Contains(t, output.GetStdout(), "2\tsecond line")
+}
+
+// TestRunCommandsStdinSourceNamedPipe streams several writes through a named pipe, the script
+// only completes once the writer closes the pipe
+func TestRunCommandsStdinSourceNamedPipe(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "input.fifo")
+	assert.NoError(t, syscall.Mkfifo(source, 0600))
+
+	writerDone := make(chan error, 1)
+	go func() {
+		writer, err := os.OpenFile(source, os.O_WRONLY, 0)
+		if err != nil {
+			writerDone <- err
+			return
+		}
+		defer writer.Close()
+		for i := 1; i <= 3; i++ {
+			if _, err = fmt.Fprintf(writer, "chunk %d\n", i); err != nil {
+				writerDone <- err
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		writerDone <- nil
+	}()
+
+	output := runShellScriptWithStdin(t, `while read line; do echo "got $line"; done; echo eof`, source)
+
+	assert.NoError(t, <-writerDone)
+	assert.Equal(t, 0, output.GetExitCode())
+	assert.Equal(t, contracts.ResultStatusSuccess, output.GetStatus())
+	assert.Equal(t, "got chunk 1\ngot chunk 2\ngot chunk 3\neof\n", output.GetStdout())
+}
+
+func TestRunCommandsStdinSourceInvalid(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name          string
+		source        string
+		expectedError string
+	}{
+		{"Missing", filepath.Join(dir, "missing.txt"), "does not exist"},
+		{"Directory", dir, "is not a file or a named pipe"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			output := runShellScriptWithStdin(t, "echo should not run", testCase.source)
+
+			assert.Equal(t, contracts.ResultStatusFailed, output.GetStatus())
+			assert.Contains(t, output.GetStderr(), testCase.expectedError)
+			assert.NotContains(t, output.GetStdout(), "should not run")
+		})
+	}
+}
+
+func TestOpenStdinSourceNamedPipeWithoutWriterCancelled(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "input.fifo")
+	assert.NoError(t, syscall.Mkfifo(source, 0600))
+	cancelFlag := task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.Canceled)
+
+	file, _, err := openStdinSource(source, cancelFlag, 10)
+
+	assert.Nil(t, file)
+	assert.ErrorContains(t, err, "cancelled while waiting for a writer")
+	assertNoPendingStdinSourceOpen(t, source)
+}
+
+func TestOpenStdinSourceNamedPipeWithoutWriterTimesOut(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "input.fifo")
+	assert.NoError(t, syscall.Mkfifo(source, 0600))
+
+	file, _, err := openStdinSource(source, task.NewChanneledCancelFlag(), 1)
+
+	assert.Nil(t, file)
+	assert.ErrorContains(t, err, "timed out waiting for a writer")
+	assertNoPendingStdinSourceOpen(t, source)
+}
+
+func TestOpenStdinSourceNamedPipeCountsWaitAgainstTimeout(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "input.fifo")
+	assert.NoError(t, syscall.Mkfifo(source, 0600))
+	go func() {
+		time.Sleep(2 * time.Second)
+		if writer, err := os.OpenFile(source, os.O_WRONLY, 0); err == nil {
+			writer.Close()
+		}
+	}()
+
+	file, remainingTimeout, err := openStdinSource(source, task.NewChanneledCancelFlag(), 10)
+
+	assert.NoError(t, err)
+	file.Close()
+	assert.LessOrEqual(t, remainingTimeout, 8)
+	assert.Greater(t, remainingTimeout, 0)
+}
+
+// assertNoPendingStdinSourceOpen checks the abandoned open of the named pipe no longer waits for a writer,
+// opening the write end without blocking fails when there is no reader
+func assertNoPendingStdinSourceOpen(t *testing.T, source string) {
+	assert.Eventually(t, func() bool {
+		writer, err := os.OpenFile(source, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+		if err == nil {
+			writer.Close()
+		}
+		return errors.Is(err, syscall.ENXIO)
+	}, 5*time.Second, 50*time.Millisecond)
+}
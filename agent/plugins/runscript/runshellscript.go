@@ -32,6 +32,7 @@ type runShellPlugin struct {
 var shellScriptName = "_script.sh"
 var shellCommand = "sh"
 var shellArgs = []string{"-c"}
+var loginShellArgs = []string{"-l"}
 
 // NewRunShellPlugin returns a new instance of the SHPlugin.
 func NewRunShellPlugin(context context.T) (*runShellPlugin, error) {
@@ -43,6 +44,8 @@ func NewRunShellPlugin(context context.T) (*runShellPlugin, error) {
 			ScriptName:            shellScriptName,
 			ShellCommand:          shellCommand,
 			ShellArguments:        shellArgs,
+			LoginShellArguments:   loginShellArgs,
+			SupportsInterpreter:   true,
 			ByteOrderMark:         fileutil.ByteOrderMarkSkip,
 			CommandExecuter:       executers.ShellCommandExecuter{},
 			IdentityRuntimeClient: runtimeconfig.NewIdentityRuntimeConfigClient(),
@@ -0,0 +1,103 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package runscript
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+// stdinSourceOpenPollInterval is how often a pending open of the stdin source checks for cancellation and timeout
+var stdinSourceOpenPollInterval = 100 * time.Millisecond
+
+type openResult struct {
+	file *os.File
+	err  error
+}
+
+// validateStdinSource checks that the stdin source exists and is a regular file or a named pipe
+func validateStdinSource(source string) (isNamedPipe bool, err error) {
+	info, err := os.Stat(source)
+	if os.IsNotExist(err) {
+		return false, fmt.Errorf("stdinSource %v does not exist", source)
+	} else if err != nil {
+		return false, fmt.Errorf("stdinSource %v is not accessible: %v", source, err)
+	}
+	isNamedPipe = info.Mode()&os.ModeNamedPipe != 0
+	if !info.Mode().IsRegular() && !isNamedPipe {
+		return false, fmt.Errorf("stdinSource %v is not a file or a named pipe", source)
+	}
+	return isNamedPipe, nil
+}
+
+// openStdinSource validates and opens the stdin source for reading, and returns the execution timeout left for the commands.
+// Opening a named pipe blocks until a writer opens it, so the open is abandoned when the
+// step is cancelled or when the execution timeout expires before a writer shows up.
+func openStdinSource(source string, cancelFlag task.CancelFlag, executionTimeout int) (file *os.File, remainingTimeout int, err error) {
+	isNamedPipe, err := validateStdinSource(source)
+	if err != nil {
+		return nil, executionTimeout, err
+	}
+
+	opened := make(chan openResult, 1)
+	go func() {
+		file, err := os.Open(source)
+		opened <- openResult{file: file, err: err}
+	}()
+
+	deadline := time.Now().Add(time.Duration(executionTimeout) * time.Second)
+	for {
+		select {
+		case result := <-opened:
+			if result.err != nil {
+				return nil, executionTimeout, fmt.Errorf("stdinSource %v is not readable: %v", source, result.err)
+			}
+			// the commands get at least a second, the deadline has not passed yet
+			if remainingTimeout = int(time.Until(deadline) / time.Second); remainingTimeout < 1 {
+				remainingTimeout = 1
+			}
+			return result.file, remainingTimeout, nil
+		case <-time.After(stdinSourceOpenPollInterval):
+		}
+
+		if cancelFlag.Canceled() || cancelFlag.ShutDown() || time.Now().After(deadline) {
+			go abandonStdinSourceOpen(source, isNamedPipe, opened)
+			if time.Now().After(deadline) {
+				return nil, 0, fmt.Errorf("timed out waiting for a writer to open stdinSource %v", source)
+			}
+			return nil, executionTimeout, fmt.Errorf("cancelled while waiting for a writer to open stdinSource %v", source)
+		}
+	}
+}
+
+// abandonStdinSourceOpen waits for the pending open of the stdin source to complete and closes the file.
+// The open of a named pipe only completes once a writer shows up, so the write end is opened in place of the writer.
+func abandonStdinSourceOpen(source string, isNamedPipe bool, opened <-chan openResult) {
+	for {
+		if isNamedPipe {
+			releasePendingOpen(source)
+		}
+		select {
+		case result := <-opened:
+			if result.file != nil {
+				result.file.Close()
+			}
+			return
+		case <-time.After(stdinSourceOpenPollInterval):
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
+
+package runscript
+
+import (
+	"os"
+	"syscall"
+)
+
+// releasePendingOpen completes an open of the named pipe waiting for a writer by opening and closing the write end.
+// The non-blocking open fails when no reader is waiting yet.
+func releasePendingOpen(source string) {
+	if writer, err := os.OpenFile(source, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+		writer.Close()
+	}
+}
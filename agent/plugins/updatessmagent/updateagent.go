@@ -56,6 +56,7 @@ type UpdatePluginInput struct {
 	AllowDowngrade string `json:"allowDowngrade"`
 	TargetVersion  string `json:"targetVersion"`
 	Source         string `json:"source"`
+	Force          string `json:"force"`
 	UpdaterName    string `json:"-"`
 }
 
@@ -123,6 +124,7 @@ func runUpdateAgent(
 		&pluginInput,
 		updaterVersion,
 		config.MessageId,
+		config.BookKeepingFileName,
 		config.UpstreamServiceName,
 		pluginConfig.StdoutFileName,
 		pluginConfig.StderrFileName,
@@ -233,6 +235,11 @@ func (p *Plugin) Execute(config contracts.Configuration, cancelFlag task.CancelF
 		output.MarkAsShutdown()
 	} else if cancelFlag.Canceled() {
 		output.MarkAsCancelled()
+	} else if frozen, window := updateutil.IsUpdateFrozen(log, p.Context.AppConfig().Agent, time.Now()); frozen {
+		// updates are blocked during a maintenance freeze, other documents are not affected
+		log.Infof("%v %v", updateutil.UpdateDeferredByFreezeMessage, window)
+		output.AppendInfof("%v %v", updateutil.UpdateDeferredByFreezeMessage, window)
+		output.SetStatus(contracts.ResultStatusSkipped)
 	} else {
 		// create update directory before creating locks
 		var downloadFolder string
@@ -308,6 +315,7 @@ func generateUpdateCmd(
 	pluginInput *UpdatePluginInput,
 	updaterVersion string,
 	messageID string,
+	documentID string,
 	upstreamServiceName contracts.UpstreamServiceName,
 	stdout string,
 	stderr string,
@@ -329,6 +337,17 @@ func generateUpdateCmd(
 		cmd = append(cmd, "-"+updateconstants.DisableDowngradeCmd)
 	}
 
+	// Tell the updater to restart the agent without waiting for the documents in progress
+	if pluginInput.Force != "" {
+		force, err := strconv.ParseBool(pluginInput.Force)
+		if err != nil {
+			return cmd, err
+		}
+		if force {
+			cmd = append(cmd, "-"+updateconstants.ForceCmd)
+		}
+	}
+
 	cmd = append(cmd, "-"+updateconstants.SourceVersionCmd, currentAgentVersion)
 	cmd = append(cmd, "-"+updateconstants.TargetVersionCmd, pluginInput.TargetVersion)
 
@@ -345,5 +364,8 @@ func generateUpdateCmd(
 
 	cmd = append(cmd, "-"+updateconstants.UpstreamServiceName, string(upstreamServiceName))
 
+	// Tell the updater which document to exclude when it waits for the documents in progress
+	cmd = append(cmd, "-"+updateconstants.DocumentIDCmd, documentID)
+
 	return
 }
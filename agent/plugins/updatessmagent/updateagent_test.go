@@ -20,6 +20,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/framework/processor/executer/iohandler"
@@ -47,10 +48,10 @@ func TestGenerateUpdateCmd(t *testing.T) {
 	pluginInput := createStubPluginInput()
 
 	result, err := generateUpdateCmd(pluginInput,
-		"3.0.0.0", "messageID with space", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
+		"3.0.0.0", "messageID with space", "documentID", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
 
 	assert.NoError(t, err)
-	assert.EqualValues(t, 22, len(result))
+	assert.EqualValues(t, 24, len(result))
 	assert.Contains(t, result[0], "3.0.0.0")
 	assert.EqualValues(t, "messageID with space", result[9])
 	assert.EqualValues(t, "stdout", result[11])
@@ -59,6 +60,8 @@ func TestGenerateUpdateCmd(t *testing.T) {
 	assert.EqualValues(t, "bucket", result[17])
 	assert.EqualValues(t, "testSource", result[19])
 	assert.EqualValues(t, "MessageGatewayService", result[21])
+	assert.EqualValues(t, "-"+updateconstants.DocumentIDCmd, result[22])
+	assert.EqualValues(t, "documentID", result[23])
 }
 
 func TestGenerateUpdateCmdNoDowngrade(t *testing.T) {
@@ -66,9 +69,9 @@ func TestGenerateUpdateCmdNoDowngrade(t *testing.T) {
 	pluginInput.AllowDowngrade = "false"
 
 	result, err := generateUpdateCmd(pluginInput,
-		"3.0.0.0", "messageID with space", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
+		"3.0.0.0", "messageID with space", "documentID", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
 	assert.NoError(t, err)
-	assert.EqualValues(t, 23, len(result))
+	assert.EqualValues(t, 25, len(result))
 	assert.Contains(t, result[0], "3.0.0.0")
 	assert.EqualValues(t, "messageID with space", result[10])
 	assert.EqualValues(t, "stdout", result[12])
@@ -85,7 +88,29 @@ func TestGenerateUpdateCmdInvalidDowngrade(t *testing.T) {
 	pluginInput.AllowDowngrade = "somerandomstring"
 
 	_, err := generateUpdateCmd(pluginInput,
-		"3.0.0.0", "messageID", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
+		"3.0.0.0", "messageID", "documentID", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
+
+	assert.Error(t, err)
+}
+
+func TestGenerateUpdateCmdForce(t *testing.T) {
+	pluginInput := createStubPluginInput()
+	pluginInput.Force = "true"
+
+	result, err := generateUpdateCmd(pluginInput,
+		"3.0.0.0", "messageID", "documentID", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 25, len(result))
+	assert.EqualValues(t, "-"+updateconstants.ForceCmd, result[2])
+}
+
+func TestGenerateUpdateCmdInvalidForce(t *testing.T) {
+	pluginInput := createStubPluginInput()
+	pluginInput.Force = "somerandomstring"
+
+	_, err := generateUpdateCmd(pluginInput,
+		"3.0.0.0", "messageID", "documentID", contracts.MessageGatewayService, "stdout", "stderr", "prefix", "bucket")
 
 	assert.Error(t, err)
 }
@@ -555,6 +580,70 @@ func TestExecuteFailureDuringUpdate(t *testing.T) {
 	}
 }
 
+func TestExecuteDeferredDuringUpdateFreeze(t *testing.T) {
+	pluginInput := createStubPluginInput()
+	config := contracts.Configuration{}
+	p := make([]interface{}, 1)
+	p[0] = pluginInput
+	config.Properties = p
+	appConfig := appconfig.SsmagentConfig{}
+	appConfig.Agent.UpdateFreeze = true
+	appConfig.Agent.UpdateFreezeEndTime = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	plugin := &Plugin{
+		Context: contextmocks.NewMockDefaultWithConfig(appConfig),
+	}
+	mockCancelFlag := new(task.MockCancelFlag)
+	mockLockfile := lockmocks.MockLockfile{}
+	mockIOHandler := iohandler.DefaultIOHandler{}
+	methodCalled := false
+
+	// Create stub
+	updateAgent = func(
+		config contracts.Configuration,
+		context context.T,
+		util updateutil.T,
+		s3util updates3util.T,
+		manifest updatemanifest.T,
+		rawPluginInput interface{},
+		output iohandler.IOHandler,
+		startTime time.Time,
+		exec executor.IExecutor,
+		downloadFolder string) int {
+		methodCalled = true
+		output.MarkAsInProgress()
+		return 1
+	}
+
+	getLockObj = func(pth string) (lockfile.Lockfile, error) {
+		return &mockLockfile, nil
+	}
+	// Setup mocks
+	mockCancelFlag.On("Canceled").Return(false)
+	mockCancelFlag.On("ShutDown").Return(false)
+
+	updateUtilRef = &fakeUtility{
+		downloadErr: false,
+	}
+	plugin.Execute(config, mockCancelFlag, &mockIOHandler)
+
+	assert.False(t, methodCalled)
+	assert.Equal(t, contracts.ResultStatusSkipped, mockIOHandler.GetStatus())
+	assert.Contains(t, mockIOHandler.GetStdout(), updateutil.UpdateDeferredByFreezeMessage)
+	mockLockfile.AssertNotCalled(t, "TryLockExpireWithRetry", int64(60))
+
+	// once the freeze window ends the update runs again
+	appConfig.Agent.UpdateFreezeEndTime = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	plugin.Context = contextmocks.NewMockDefaultWithConfig(appConfig)
+	mockIOHandler = iohandler.DefaultIOHandler{}
+	mockLockfile.On("TryLockExpireWithRetry", int64(60)).Return(nil)
+	mockLockfile.On("ShouldRetry", nil).Return(false)
+	mockLockfile.On("ChangeOwner", 1).Return(nil)
+	plugin.Execute(config, mockCancelFlag, &mockIOHandler)
+
+	assert.True(t, methodCalled)
+	assert.Equal(t, contracts.ResultStatusInProgress, mockIOHandler.GetStatus())
+}
+
 func createStubPluginInput() *UpdatePluginInput {
 	return &UpdatePluginInput{
 		TargetVersion:  "9000.0.0.0",
@@ -105,6 +105,11 @@ func (s *RunCommandService) listenReply(resultChan chan contracts.DocumentResult
 				}
 			}()
 
+			if res.Status == contracts.ResultStatusQueued {
+				log.Infof("command: %v queued", res.MessageID)
+				s.sendDocLevelResponse(res.MessageID, res.Status, "")
+				return
+			}
 			if res.LastPlugin != "" {
 				log.Infof("received plugin: %v result from Processor", res.LastPlugin)
 			} else {
@@ -117,6 +122,10 @@ func (s *RunCommandService) listenReply(resultChan chan contracts.DocumentResult
 					s.context.AppConfig().Agent.OrchestrationRootDir,
 					s.context.AppConfig().Ssm.RunCommandLogsRetentionDurationHours,
 					s.context.AppConfig().Ssm.AssociationLogsRetentionDurationHours)
+				go docmanager.DeleteExpiredOrchestrationDirectories(log,
+					shortInstanceId,
+					s.context.AppConfig().Agent.OrchestrationRootDir,
+					s.context.AppConfig().Ssm.OrchestrationDirectoryRetentionDays)
 			}
 			s.sendResponse(res.MessageID, res)
 		}()
@@ -159,6 +168,7 @@ func (s *RunCommandService) processMessage(msg *ssmmds.Message) {
 		}
 		return
 	}
+	log.Infof("Received document %v with correlation ID %v", docState.DocumentInformation.DocumentID, sdkutil.CommandCorrelationID(*msg.MessageId))
 	if err = s.service.AcknowledgeMessage(log, *msg.MessageId); err != nil {
 		sdkutil.HandleAwsError(log, err, s.processorStopPolicy)
 		return
@@ -15,6 +15,7 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
@@ -24,6 +25,7 @@ import (
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/framework/docparser"
 	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	messageContracts "github.com/aws/amazon-ssm-agent/agent/runcommand/contracts"
@@ -82,9 +84,9 @@ func (ols *offlineService) GetMessages(log log.T, instanceID string) (messages *
 		commandID := uuid.NewV4().String()
 		messageID := fmt.Sprintf("aws.ssm.%v.%v", commandID, instanceID)
 
-		// Parse file
+		// Parse file, documents authored with Windows tools may start with a byte order mark
 		var content contracts.DocumentContent
-		if errContent := jsonutil.UnmarshalFile(docPath, &content); errContent != nil {
+		if errContent := unmarshalCommandDocument(docPath, &content); errContent != nil {
 			log.Errorf("Error parsing command document %v:\n%v", docName, errContent)
 			if errMove := moveCommandDocument(ols.newCommandDir, ols.invalidCommandDir, docName, commandID); errMove != nil {
 				log.Errorf("Command %v was invalid but failed to move to invalid folder: %v", commandID, errMove.Error())
@@ -191,3 +193,13 @@ func (ols *offlineService) GetFailedReply(log log.T, replyId string) (*ssmmds.Se
 func (ols *offlineService) SendReplyWithInput(log log.T, sendReply *ssmmds.SendReplyInput) error {
 	return nil
 }
+
+// unmarshalCommandDocument reads a local command document, it is normalized with docparser.NormalizeDocumentRaw before decoding
+func unmarshalCommandDocument(docPath string, content *contracts.DocumentContent) error {
+	documentRaw, err := fileutil.ReadAllText(docPath)
+	if err != nil {
+		return err
+	}
+	normalized, _ := docparser.NormalizeDocumentRaw([]byte(documentRaw))
+	return json.Unmarshal(normalized, content)
+}
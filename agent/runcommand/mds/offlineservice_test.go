@@ -25,104 +25,94 @@ import (
 
 var logger = log.NewMockLog()
 
-const (
-	newCommands       = "testdata/new"
-	submittedCommands = "testdata/new/submitted"
-	invalidCommands   = "testdata/new/invalid"
-	completeDir       = "testdata/new/completed"
-)
-
 func TestValid(t *testing.T) {
-	service := GetTestService()
+	service := GetTestService(t)
+
+	err := SubmitTestDoc(service, "validcommand20.json")
+	assert.Nil(t, err)
+
+	messages, err := service.GetMessages(logger, "i-bar")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(messages.Messages))
+	assert.Equal(t, 0, FileCount(service.newCommandDir))
+	assert.Equal(t, 1, FileCount(service.submittedCommandDir))
+}
 
-	defer CleanTestDirs()
-	err := SubmitTestDoc("validcommand20.json")
+func TestValidWithByteOrderMark(t *testing.T) {
+	service := GetTestService(t)
+
+	doc, err := fileutil.ReadAllText(filepath.Join("testdata", "validcommand20.json"))
+	assert.Nil(t, err)
+	err = fileutil.WriteAllText(filepath.Join(service.newCommandDir, "validcommand20.json"), "\xEF\xBB\xBF"+doc)
 	assert.Nil(t, err)
 
 	messages, err := service.GetMessages(logger, "i-bar")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(messages.Messages))
-	assert.Equal(t, 0, FileCount(newCommands))
-	assert.Equal(t, 1, FileCount(submittedCommands))
+	assert.Equal(t, 0, FileCount(service.invalidCommandDir))
+	assert.Equal(t, 1, FileCount(service.submittedCommandDir))
 }
 
 func TestInvalid(t *testing.T) {
-	service := GetTestService()
+	service := GetTestService(t)
 
-	defer CleanTestDirs()
-	err := SubmitTestDoc("invalidcommand.json")
+	err := SubmitTestDoc(service, "invalidcommand.json")
 	assert.Nil(t, err)
 
 	messages, err := service.GetMessages(logger, "i-bar")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(messages.Messages))
-	assert.Equal(t, 0, FileCount(newCommands))
-	assert.Equal(t, 1, FileCount(invalidCommands))
+	assert.Equal(t, 0, FileCount(service.newCommandDir))
+	assert.Equal(t, 1, FileCount(service.invalidCommandDir))
 }
 
 func TestBothVersions(t *testing.T) {
-	service := GetTestService()
+	service := GetTestService(t)
 
-	defer CleanTestDirs()
 	var err error
-	err = SubmitTestDoc("validcommand20.json")
+	err = SubmitTestDoc(service, "validcommand20.json")
 	assert.Nil(t, err)
-	err = SubmitTestDoc("validcommand12.json")
+	err = SubmitTestDoc(service, "validcommand12.json")
 	assert.Nil(t, err)
 
 	messages, err := service.GetMessages(logger, "i-bar")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(messages.Messages))
-	assert.Equal(t, 0, FileCount(newCommands))
-	assert.Equal(t, 2, FileCount(submittedCommands))
+	assert.Equal(t, 0, FileCount(service.newCommandDir))
+	assert.Equal(t, 2, FileCount(service.submittedCommandDir))
 }
 
 func TestOfflineService_SendReply(t *testing.T) {
-	service := GetTestService()
-	defer CleanTestDirs()
+	service := GetTestService(t)
 	service.SendReply(logger, "aws.ssm.testCommandID.testInstanceID", "payload")
-	assert.Equal(t, 1, FileCount(completeDir))
+	assert.Equal(t, 1, FileCount(service.commandResultDir))
 }
 
-func GetTestService() Service {
-	CleanTestDirs()
-	return &offlineService{
+// GetTestService returns an offline service reading and writing the command documents in a temporary directory
+func GetTestService(t *testing.T) *offlineService {
+	newCommands := t.TempDir()
+	service := &offlineService{
 		TopicPrefix:         "foo",
 		newCommandDir:       newCommands,
-		submittedCommandDir: submittedCommands,
-		invalidCommandDir:   invalidCommands,
-		commandResultDir:    completeDir,
+		submittedCommandDir: filepath.Join(newCommands, "submitted"),
+		invalidCommandDir:   filepath.Join(newCommands, "invalid"),
+		commandResultDir:    filepath.Join(newCommands, "completed"),
+	}
+	for _, dir := range []string{service.submittedCommandDir, service.invalidCommandDir, service.commandResultDir} {
+		assert.NoError(t, fileutil.MakeDirs(dir))
 	}
+	return service
 }
 
-func SubmitTestDoc(name string) error {
+func SubmitTestDoc(service *offlineService, name string) error {
 	if doc, err := fileutil.ReadAllText(filepath.Join("testdata", name)); err != nil {
 		return err
 	} else {
-		return fileutil.WriteAllText(filepath.Join(newCommands, name), doc)
-	}
-}
-
-func CleanTestDirs() {
-	var files []string
-	files, _ = fileutil.GetFileNames(submittedCommands)
-	for _, file := range files {
-		fileutil.DeleteFile(filepath.Join(submittedCommands, file))
-	}
-	files, _ = fileutil.GetFileNames(invalidCommands)
-	for _, file := range files {
-		fileutil.DeleteFile(filepath.Join(invalidCommands, file))
-	}
-	files, _ = fileutil.GetFileNames(newCommands)
-	for _, file := range files {
-		fileutil.DeleteFile(filepath.Join(newCommands, file))
-	}
-	files, _ = fileutil.GetFileNames(completeDir)
-	for _, file := range files {
-		fileutil.DeleteFile(filepath.Join(completeDir, file))
+		return fileutil.WriteAllText(filepath.Join(service.newCommandDir, name), doc)
 	}
 }
 
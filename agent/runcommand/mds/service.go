@@ -114,6 +114,7 @@ func NewService(context context.T, connectionTimeout time.Duration) Service {
 
 	sess := session.New(config)
 	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentHandler(agentConfig.Agent.Name, agentConfig.Agent.Version))
+	sess.Handlers.Build.PushBackNamed(sdkutil.CorrelationIDHandler)
 
 	msgSvc := ssmmds.New(sess)
 
@@ -220,6 +221,7 @@ func (mds *sdkService) AcknowledgeMessage(log log.T, messageID string) (err erro
 	}
 	log.Debug("Calling AcknowledgeMessage with params", params)
 	req, resp := mds.sdk.AcknowledgeMessageRequest(params)
+	setCorrelationID(req, messageID)
 	if err = mds.sendRequest(req); err != nil {
 		err = fmt.Errorf("AcknowledgeMessage Error: %v", err)
 		log.Debug(err)
@@ -233,6 +235,7 @@ func (mds *sdkService) AcknowledgeMessage(log log.T, messageID string) (err erro
 func (mds *sdkService) SendReplyWithInput(log log.T, sendReply *ssmmds.SendReplyInput) (err error) {
 	log.Debug("Calling SendReply with params", sendReply)
 	req, resp := mds.sdk.SendReplyRequest(sendReply)
+	setCorrelationID(req, aws.StringValue(sendReply.MessageId))
 	if err = mds.sendRequest(req); err != nil {
 		err = fmt.Errorf("SendReply Error: %v", err)
 		log.Debug(err)
@@ -266,6 +269,7 @@ func (mds *sdkService) FailMessage(log log.T, messageID string, failureType Fail
 	}
 	log.Debug("Calling FailMessage with params", params)
 	req, resp := mds.sdk.FailMessageRequest(params)
+	setCorrelationID(req, messageID)
 	if err = mds.sendRequest(req); err != nil {
 		err = fmt.Errorf("FailMessage Error: %v", err)
 		log.Debug(err)
@@ -282,6 +286,7 @@ func (mds *sdkService) DeleteMessage(log log.T, messageID string) (err error) {
 	}
 	log.Debug("Calling DeleteMessage with params", params)
 	req, resp := mds.sdk.DeleteMessageRequest(params)
+	setCorrelationID(req, messageID)
 	if err = mds.sendRequest(req); err != nil {
 		err = fmt.Errorf("DeleteMessage Error: %v", err)
 		log.Debug(err)
@@ -377,6 +382,11 @@ func (mds *sdkService) Stop() {
 	mds.context.Log().Infof("Stopped Mds service")
 }
 
+// setCorrelationID makes the request carry the correlation ID of the command with the given message ID
+func setCorrelationID(req *request.Request, messageID string) {
+	req.SetContext(sdkutil.WithCorrelationID(req.Context(), sdkutil.CommandCorrelationID(messageID)))
+}
+
 // sendRequest wraps req.Send() so that it can keep track of the executing request
 func (mds *sdkService) sendRequest(req *request.Request) error {
 	mds.storeRequest(req)
@@ -392,7 +402,7 @@ func (mds *sdkService) storeRequest(req *request.Request) {
 		return
 	}
 	var cancel reqContext.CancelFunc
-	ctx, cancel := reqContext.WithCancel(reqContext.Background())
+	ctx, cancel := reqContext.WithCancel(req.Context())
 	req.SetContext(ctx)
 	mds.cancelRequest = cancel
 }
@@ -0,0 +1,76 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/sdkutil"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssmmds"
+	"github.com/stretchr/testify/assert"
+)
+
+// newCorrelationTestService returns an MDS service whose requests are built but never sent,
+// and the headers of the requests it built.
+func newCorrelationTestService(t *testing.T) (Service, *[]http.Header) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String("https://ec2messages.us-east-1.amazonaws.com"),
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+	})
+	assert.NoError(t, err)
+	sess.Handlers.Build.PushBackNamed(sdkutil.CorrelationIDHandler)
+
+	var headers []http.Header
+	sendSdkRequest := func(req *request.Request) error {
+		if err := req.Build(); err != nil {
+			return err
+		}
+		headers = append(headers, req.HTTPRequest.Header)
+		return nil
+	}
+	cancelSdkRequest := func(trans *http.Transport, req *request.Request) {}
+	return NewMdsSdkService(context.NewMockDefault(), ssmmds.New(sess), &http.Transport{}, sendSdkRequest, cancelSdkRequest), &headers
+}
+
+func TestMessageCallsCarryCorrelationID(t *testing.T) {
+	messageID := "aws.ssm.2b196342-d7d4-436e-8f09-3883a1116ac3.i-bar"
+	service, headers := newCorrelationTestService(t)
+
+	assert.NoError(t, service.AcknowledgeMessage(logger, messageID))
+	assert.NoError(t, service.SendReply(logger, messageID, "{}"))
+	assert.NoError(t, service.FailMessage(logger, messageID, InternalHandlerException))
+	assert.NoError(t, service.DeleteMessage(logger, messageID))
+
+	assert.Len(t, *headers, 4)
+	for _, header := range *headers {
+		assert.Equal(t, sdkutil.CommandCorrelationID(messageID), header.Get(sdkutil.CorrelationIDHeader))
+	}
+}
+
+func TestGetMessagesDoesNotCarryCorrelationID(t *testing.T) {
+	service, headers := newCorrelationTestService(t)
+
+	_, err := service.GetMessages(logger, "i-1234567890abcdef0")
+
+	assert.NoError(t, err)
+	assert.Len(t, *headers, 1)
+	assert.Empty(t, (*headers)[0].Get(sdkutil.CorrelationIDHeader))
+}
@@ -379,7 +379,7 @@ func getPluginConfigurationsFromMainStep(mainSteps []*contracts.InstancePluginCo
 	commandID, _ := messageContracts.GetCommandID(messageID)
 	for index, instancePluginConfig := range mainSteps {
 		resolvedPreconditions := map[string][]contracts.PreconditionArgument{}
-		for operator, arguments := range instancePluginConfig.Preconditions {
+		for operator, arguments := range instancePluginConfig.Preconditions.Operators {
 			for _, arg := range arguments {
 				resolvedPreconditions[operator] = append(resolvedPreconditions[operator], contracts.PreconditionArgument{
 					InitialArgumentValue:  arg,
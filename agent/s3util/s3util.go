@@ -86,6 +86,12 @@ func NewAmazonS3Util(context context.T, bucketName string) (res *AmazonS3Util, e
 
 // S3Upload uploads a file to s3.
 func (u *AmazonS3Util) S3Upload(log log.T, bucketName string, objectKey string, filePath string) (err error) {
+	return u.S3UploadWithContentEncoding(log, bucketName, objectKey, filePath, "")
+}
+
+// S3UploadWithContentEncoding uploads a file to s3 and sets the Content-Encoding metadata of the object
+// when contentEncoding is not empty.
+func (u *AmazonS3Util) S3UploadWithContentEncoding(log log.T, bucketName string, objectKey string, filePath string, contentEncoding string) (err error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		log.Errorf("Failed to open file %v", err)
@@ -101,6 +107,9 @@ func (u *AmazonS3Util) S3Upload(log log.T, bucketName string, objectKey string,
 		ContentType: aws.String("text/plain"),
 		ACL:         aws.String("bucket-owner-full-control"),
 	}
+	if contentEncoding != "" {
+		params.ContentEncoding = aws.String(contentEncoding)
+	}
 
 	if bucketEncrypted, sseAlgortihm, encryptionKey := getSSEAlgorithm(log, u, bucketName); bucketEncrypted == true {
 		switch sseAlgortihm {
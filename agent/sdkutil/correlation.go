@@ -0,0 +1,62 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sdkutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// CorrelationIDHeader is the header carrying the correlation ID of the command a service call is made for
+const CorrelationIDHeader = "X-Amz-Ssm-Agent-Correlation-Id"
+
+// correlationIDKey is the key of the correlation ID in the context of a request
+type correlationIDKey struct{}
+
+// CorrelationIDHandler sets the correlation ID header on the requests whose context carries a correlation ID
+var CorrelationIDHandler = request.NamedHandler{
+	Name: "ssmagent.CorrelationIDHandler",
+	Fn: func(r *request.Request) {
+		if correlationID, ok := CorrelationIDFromContext(r.Context()); ok {
+			r.HTTPRequest.Header.Set(CorrelationIDHeader, correlationID)
+		}
+	},
+}
+
+// CommandCorrelationID returns the correlation ID of the service calls made for the command with the given message ID.
+// It is derived from the message ID, so every call made for a command carries the same ID, including after an agent restart.
+func CommandCorrelationID(messageID string) string {
+	sum := sha256.Sum256([]byte("ssm-agent-correlation:" + messageID))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID, which CorrelationIDHandler sets on the requests using it
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, correlationIDKey{}, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	correlationID, ok := ctx.Value(correlationIDKey{}).(string)
+	return correlationID, ok && correlationID != ""
+}
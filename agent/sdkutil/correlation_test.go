@@ -0,0 +1,38 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sdkutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandCorrelationID(t *testing.T) {
+	first := CommandCorrelationID("aws.ssm.command-1.i-bar")
+
+	assert.Equal(t, first, CommandCorrelationID("aws.ssm.command-1.i-bar"))
+	assert.NotEqual(t, first, CommandCorrelationID("aws.ssm.command-2.i-bar"))
+	assert.Len(t, first, 36)
+}
+
+func TestCorrelationIDFromContext(t *testing.T) {
+	_, ok := CorrelationIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	correlationID, ok := CorrelationIDFromContext(WithCorrelationID(context.Background(), "abc"))
+	assert.True(t, ok)
+	assert.Equal(t, "abc", correlationID)
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package audit records the start and the end of the sessions run by the session worker.
+//
+// Every session appends a start and an end record to a local audit file, one JSON object per line. The file is
+// independent of the session transcript, it only describes who ran which session when and how it ended.
+// The file is protected by its permissions only, the records are not signed. Once the file reaches
+// maxFileSize it is rotated, and only the maxRotatedFiles most recent rotated files are kept.
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+)
+
+const (
+	auditDirName  = "audit"
+	auditFileName = "session_audit.log"
+	// rotatedFileTimeFormat is the suffix of the rotated audit files, it sorts them by rotation time
+	rotatedFileTimeFormat = "20060102T150405.000000000"
+
+	// EventStart is the event of the record written when a session starts
+	EventStart = "start"
+	// EventEnd is the event of the record written when a session ends
+	EventEnd = "end"
+
+	// ExitReasonCompleted is used when the session plugin returned on its own
+	ExitReasonCompleted = "Completed"
+	// ExitReasonCancelled is used when the session was terminated by the service
+	ExitReasonCancelled = "Cancelled"
+	// ExitReasonShutdown is used when the session worker was asked to stop and the session plugin shut down in time
+	ExitReasonShutdown = "Shutdown"
+	// ExitReasonTerminated is used when the session worker exits before the session plugin returned
+	ExitReasonTerminated = "Terminated"
+)
+
+var (
+	timeNow = time.Now
+	// maxFileSize is the size from which the audit file is rotated before a record is appended
+	maxFileSize int64 = 10 * 1024 * 1024
+	// maxRotatedFiles is the number of rotated audit files kept next to the audit file
+	maxRotatedFiles = 4
+)
+
+// Record is one line of the audit file
+type Record struct {
+	Event        string `json:"event"`
+	SessionId    string `json:"sessionId"`
+	DocumentId   string `json:"documentId"`
+	DocumentName string `json:"documentName,omitempty"`
+	Principal    string `json:"principal,omitempty"`
+	RunAsUser    string `json:"runAsUser,omitempty"`
+	InstanceId   string `json:"instanceId,omitempty"`
+	StartTime    string `json:"startTime"`
+	StopTime     string `json:"stopTime,omitempty"`
+	ExitReason   string `json:"exitReason,omitempty"`
+}
+
+// FilePath returns the path of the audit file under the agent data directory
+func FilePath() string {
+	return filepath.Join(appconfig.DefaultDataStorePath, auditDirName, auditFileName)
+}
+
+// ExitReason returns the reason a session ended based on the state of its cancel flag
+func ExitReason(cancelFlag task.CancelFlag) string {
+	if cancelFlag.ShutDown() {
+		return ExitReasonShutdown
+	}
+	if cancelFlag.Canceled() {
+		return ExitReasonCancelled
+	}
+	return ExitReasonCompleted
+}
+
+// Recorder writes the audit records of a session.
+// Failing to write a record is logged and does not affect the session.
+type Recorder struct {
+	log      log.T
+	filePath string
+	lock     sync.Mutex
+	record   Record
+	// ended is set once the end record is written
+	ended bool
+}
+
+// NewRecorder returns a recorder for the session run by the document, writing to the audit file at filePath
+func NewRecorder(context context.T, filePath string, docState contracts.DocumentState) *Recorder {
+	docInfo := docState.DocumentInformation
+	record := Record{
+		SessionId:    docInfo.DocumentID,
+		DocumentId:   docInfo.DocumentID,
+		DocumentName: docInfo.DocumentName,
+		Principal:    docInfo.SessionOwner,
+		RunAsUser:    docInfo.RunAsUser,
+	}
+	// the session plugin configuration holds the resolved session details
+	for _, pluginState := range docState.InstancePluginsInformation {
+		config := pluginState.Configuration
+		if config.SessionId != "" {
+			record.SessionId = config.SessionId
+		}
+		if config.SessionOwner != "" {
+			record.Principal = config.SessionOwner
+		}
+		if config.RunAsUser != "" {
+			record.RunAsUser = config.RunAsUser
+		}
+	}
+	if instanceId, err := context.Identity().InstanceID(); err == nil {
+		record.InstanceId = instanceId
+	}
+	return &Recorder{
+		log:      context.Log(),
+		filePath: filePath,
+		record:   record,
+	}
+}
+
+// Start writes the start record of the session
+func (r *Recorder) Start() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.record.StartTime = timeNow().UTC().Format(time.RFC3339Nano)
+	start := r.record
+	start.Event = EventStart
+	if err := r.write(start); err != nil {
+		r.log.Errorf("Failed to write the audit start record of session %v: %v", r.record.SessionId, err)
+	}
+}
+
+// End writes the end record of the session with the given exit reason.
+// Only the first call writes a record, so the forced exit path can end a session the plugin is still running.
+func (r *Recorder) End(exitReason string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.ended {
+		return
+	}
+	r.ended = true
+	end := r.record
+	end.Event = EventEnd
+	end.StopTime = timeNow().UTC().Format(time.RFC3339Nano)
+	end.ExitReason = exitReason
+	if err := r.write(end); err != nil {
+		r.log.Errorf("Failed to write the audit end record of session %v: %v", r.record.SessionId, err)
+	}
+}
+
+// write appends the record to the audit file as a single line, rotating the file first when it is full.
+// Each record is written with a single append so the records of concurrent session workers do not interleave.
+func (r *Recorder) write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.filePath), appconfig.ReadWriteExecuteAccess); err != nil {
+		return err
+	}
+	if err = r.rotate(); err != nil {
+		r.log.Warnf("Failed to rotate the audit file %v: %v", r.filePath, err)
+	}
+	file, err := os.OpenFile(r.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, appconfig.ReadWriteAccess)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// rotate renames the audit file once it reaches maxFileSize and deletes the oldest rotated files past maxRotatedFiles.
+// The rotated files are named after the rotation time, so a session worker rotating the file concurrently
+// renames it to another name and no record is overwritten.
+func (r *Recorder) rotate() error {
+	info, err := os.Stat(r.filePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Size() < maxFileSize {
+		return nil
+	}
+	rotatedPath := r.filePath + "." + timeNow().UTC().Format(rotatedFileTimeFormat)
+	if err = os.Rename(r.filePath, rotatedPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(r.filePath))
+	if err != nil {
+		return err
+	}
+	var rotatedFiles []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), filepath.Base(r.filePath)+".") {
+			rotatedFiles = append(rotatedFiles, filepath.Join(filepath.Dir(r.filePath), entry.Name()))
+		}
+	}
+	sort.Strings(rotatedFiles)
+	for len(rotatedFiles) > maxRotatedFiles {
+		if err = os.Remove(rotatedFiles[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		rotatedFiles = rotatedFiles[1:]
+	}
+	return nil
+}
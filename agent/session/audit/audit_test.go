@@ -0,0 +1,157 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/context"
+	"github.com/aws/amazon-ssm-agent/agent/task"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAuditLines(t *testing.T, filePath string) []string {
+	content, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestRecorderWritesStartAndEndRecords(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() { timeNow = oldTimeNow }()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	filePath := filepath.Join(t.TempDir(), auditDirName, auditFileName)
+	docState := contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{DocumentID: "session-id", DocumentName: "SSM-SessionManagerRunShell"},
+		InstancePluginsInformation: []contracts.PluginState{{
+			Configuration: contracts.Configuration{SessionId: "session-id", SessionOwner: "arn:aws:iam::123456789012:user/operator", RunAsUser: "ssm-user"},
+		}},
+	}
+	recorder := NewRecorder(context.NewMockDefault(), filePath, docState)
+
+	recorder.Start()
+	now = now.Add(time.Minute)
+	recorder.End(ExitReasonCompleted)
+	recorder.End(ExitReasonTerminated)
+
+	lines := readAuditLines(t, filePath)
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+	var start, end Record
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &start))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &end))
+	assert.Equal(t, Record{
+		Event:        EventStart,
+		SessionId:    "session-id",
+		DocumentId:   "session-id",
+		DocumentName: "SSM-SessionManagerRunShell",
+		Principal:    "arn:aws:iam::123456789012:user/operator",
+		RunAsUser:    "ssm-user",
+		InstanceId:   start.InstanceId,
+		StartTime:    "2024-05-01T10:00:00Z",
+	}, start)
+	assert.Equal(t, EventEnd, end.Event)
+	assert.Equal(t, "2024-05-01T10:00:00Z", end.StartTime)
+	assert.Equal(t, "2024-05-01T10:01:00Z", end.StopTime)
+	assert.Equal(t, ExitReasonCompleted, end.ExitReason)
+
+	info, err := os.Stat(filePath)
+	assert.NoError(t, err)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
+
+func TestRecorderAppendsToExistingAudit(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), auditFileName)
+	for _, sessionId := range []string{"first", "second"} {
+		recorder := NewRecorder(context.NewMockDefault(), filePath, contracts.DocumentState{
+			DocumentInformation: contracts.DocumentInfo{DocumentID: sessionId},
+		})
+		recorder.Start()
+		recorder.End(ExitReasonCompleted)
+	}
+
+	lines := readAuditLines(t, filePath)
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[2], `"sessionId":"second"`)
+}
+
+func TestRecorderIgnoresWriteFailures(t *testing.T) {
+	// the parent of the audit file is a regular file, so the audit directory cannot be created
+	parent := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(parent, nil, 0600))
+	recorder := NewRecorder(context.NewMockDefault(), filepath.Join(parent, auditFileName), contracts.DocumentState{})
+
+	recorder.Start()
+	recorder.End(ExitReasonCompleted)
+
+	assert.True(t, recorder.ended)
+}
+
+func TestRecorderRotatesFullAuditFile(t *testing.T) {
+	oldTimeNow, oldMaxFileSize, oldMaxRotatedFiles := timeNow, maxFileSize, maxRotatedFiles
+	defer func() { timeNow, maxFileSize, maxRotatedFiles = oldTimeNow, oldMaxFileSize, oldMaxRotatedFiles }()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	maxFileSize, maxRotatedFiles = 1, 2
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, auditFileName)
+
+	// every record fills the audit file, so it is rotated before each following record, at the time of that record
+	for i := 0; i < 3; i++ {
+		recorder := NewRecorder(context.NewMockDefault(), filePath, contracts.DocumentState{
+			DocumentInformation: contracts.DocumentInfo{DocumentID: fmt.Sprintf("session-%d", i)},
+		})
+		recorder.Start()
+		now = now.Add(time.Second)
+		recorder.End(ExitReasonCompleted)
+		now = now.Add(time.Second)
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1+maxRotatedFiles)
+	lines := readAuditLines(t, filePath)
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], `"sessionId":"session-2"`)
+		assert.Contains(t, lines[0], `"event":"end"`)
+	}
+	rotatedLines := readAuditLines(t, filePath+"."+now.Add(-time.Second).UTC().Format(rotatedFileTimeFormat))
+	if assert.Len(t, rotatedLines, 1) {
+		assert.Contains(t, rotatedLines[0], `"sessionId":"session-2"`)
+		assert.Contains(t, rotatedLines[0], `"event":"start"`)
+	}
+}
+
+func TestExitReason(t *testing.T) {
+	cancelFlag := task.NewChanneledCancelFlag()
+	assert.Equal(t, ExitReasonCompleted, ExitReason(cancelFlag))
+
+	cancelFlag.Set(task.Canceled)
+	assert.Equal(t, ExitReasonCancelled, ExitReason(cancelFlag))
+
+	cancelFlag = task.NewChanneledCancelFlag()
+	cancelFlag.Set(task.ShutDown)
+	assert.Equal(t, ExitReasonShutdown, ExitReason(cancelFlag))
+}
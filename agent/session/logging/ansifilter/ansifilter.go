@@ -0,0 +1,147 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package ansifilter removes ANSI (ECMA-48) control functions from a stream of terminal output.
+package ansifilter
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pborman/ansi"
+)
+
+// maxPendingSequenceLength bounds the bytes held back while waiting for the end of an escape sequence.
+// A longer sequence is considered malformed and its escape byte is dropped.
+const maxPendingSequenceLength = 4096
+
+var (
+	escape     = ansi.ESC[0]
+	bell       = ansi.BEL[0]
+	terminator = []byte(ansi.ST)
+)
+
+// Writer strips cursor, color and other control functions from the bytes written to it
+// and forwards the remaining plain text to the underlying writer.
+// Escape sequences split across several writes are held back until they are complete.
+type Writer struct {
+	out     io.Writer
+	pending []byte
+	// oscOnly strips the operating system commands and forwards the other control functions unchanged
+	oscOnly bool
+}
+
+// NewWriter returns a Writer forwarding plain text to out.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// NewOSCWriter returns a Writer which only strips operating system commands, from OSC to ST,
+// such as OSC 8 hyperlinks and window titles. Colors and other control functions are forwarded to out.
+func NewOSCWriter(out io.Writer) *Writer {
+	return &Writer{out: out, oscOnly: true}
+}
+
+// Write strips the control functions from p and writes the remaining text.
+func (w *Writer) Write(p []byte) (int, error) {
+	data := append(w.pending, p...)
+	w.pending = nil
+
+	text := make([]byte, 0, len(data))
+	for len(data) > 0 {
+		index := bytes.IndexByte(data, escape)
+		if index < 0 {
+			text = append(text, data...)
+			break
+		}
+		text = append(text, data[:index]...)
+		data = data[index:]
+
+		if w.oscOnly && len(data) >= 2 && ansi.Name(data[:2]) != ansi.OSC {
+			text = append(text, escape)
+			data = data[1:]
+			continue
+		}
+		length, complete := sequenceLength(data)
+		if !complete {
+			if len(data) <= maxPendingSequenceLength {
+				w.pending = append([]byte(nil), data...)
+				break
+			}
+			length = 1
+		}
+		data = data[length:]
+	}
+
+	if !w.oscOnly {
+		text = bytes.ReplaceAll(text, []byte{bell}, nil)
+	}
+	if _, err := w.out.Write(text); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush discards an escape sequence that is still incomplete at the end of the stream.
+func (w *Writer) Flush() {
+	w.pending = nil
+}
+
+// sequenceLength returns the length of the escape sequence at the start of seq
+// and false if seq ends before the sequence is complete.
+func sequenceLength(seq []byte) (length int, complete bool) {
+	if len(seq) < 2 {
+		return 0, false
+	}
+
+	switch ansi.Name(seq[:2]) {
+	case ansi.CSI:
+		// parameter and intermediate bytes followed by a final byte
+		for i := 2; i < len(seq); i++ {
+			switch {
+			case seq[i] >= 0x20 && seq[i] <= 0x3f:
+				continue
+			case seq[i] >= 0x40 && seq[i] <= 0x7e:
+				return i + 1, true
+			default:
+				// malformed sequence, drop what was parsed and keep the unexpected byte as text
+				return i, true
+			}
+		}
+		return 0, false
+	case ansi.OSC, ansi.DCS, ansi.APC, ansi.PM, ansi.SOS:
+		// control strings end with the string terminator, terminals also accept BEL
+		for i := 2; i < len(seq); i++ {
+			if seq[i] == bell {
+				return i + 1, true
+			}
+			if bytes.HasPrefix(seq[i:], terminator) {
+				return i + len(terminator), true
+			}
+		}
+		return 0, false
+	}
+
+	// other escape sequences are intermediate bytes followed by a final byte, such as character set selection
+	for i := 1; i < len(seq); i++ {
+		switch {
+		case seq[i] >= 0x20 && seq[i] <= 0x2f:
+			continue
+		case seq[i] >= 0x30 && seq[i] <= 0x7e:
+			return i + 1, true
+		default:
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ansifilter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func filter(chunks ...string) string {
+	var out bytes.Buffer
+	writer := NewWriter(&out)
+	for _, chunk := range chunks {
+		writer.Write([]byte(chunk))
+	}
+	writer.Flush()
+	return out.String()
+}
+
+func TestWriterStripsControlFunctions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text", "sh-4.2$ ls -l\r\n", "sh-4.2$ ls -l\r\n"},
+		{"color", "\x1b[01;34mdir\x1b[0m file\r\n", "dir file\r\n"},
+		{"reset color without parameters", "\x1b[mtext", "text"},
+		{"cursor movement and erase", "\x1b[2J\x1b[H\x1b[1A\x1b[Kprompt", "prompt"},
+		{"private mode", "\x1b[?2004hsh-4.2$ \x1b[?2004l", "sh-4.2$ "},
+		{"window title terminated by bell", "\x1b]0;ec2-user@host:~\x07sh-4.2$ ", "sh-4.2$ "},
+		{"window title terminated by string terminator", "\x1b]0;title\x1b\\sh-4.2$ ", "sh-4.2$ "},
+		{"character set selection", "\x1b(Bline", "line"},
+		{"keypad mode", "\x1b=\x1b>line", "line"},
+		{"bell", "done\x07", "done"},
+		{"utf-8 text", "caf\xc3\xa9 \xe2\x9c\x93 \x1b[32mok\x1b[0m", "caf\xc3\xa9 \xe2\x9c\x93 ok"},
+		{"malformed control sequence keeps the text", "\x1b[31\nline", "\nline"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, filter(testCase.input))
+		})
+	}
+}
+
+func TestWriterStripsSequencesSplitAcrossWrites(t *testing.T) {
+	input := "\x1b[01;34mdir\x1b[0m \x1b]0;title\x1b\\file\r\n"
+
+	// write one byte at a time, as the session logger does
+	var chunks []string
+	for i := range input {
+		chunks = append(chunks, input[i:i+1])
+	}
+
+	assert.Equal(t, "dir file\r\n", filter(chunks...))
+	assert.Equal(t, "dir file\r\n", filter("\x1b[01", ";34mdir\x1b", "[0m \x1b]0;ti", "tle\x1b", "\\file\r\n"))
+}
+
+func TestWriterDiscardsIncompleteSequenceOnFlush(t *testing.T) {
+	assert.Equal(t, "text", filter("text\x1b[01;3"))
+	assert.Equal(t, "text", filter("text\x1b"))
+}
+
+func TestWriterDropsUnterminatedControlString(t *testing.T) {
+	input := "\x1b]0;" + string(bytes.Repeat([]byte("a"), maxPendingSequenceLength)) + "\r\nnext line"
+
+	result := filter(input)
+
+	assert.Contains(t, result, "next line")
+	assert.NotContains(t, result, "\x1b")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriterReturnsUnderlyingError(t *testing.T) {
+	writer := NewWriter(failingWriter{})
+
+	n, err := writer.Write([]byte("text"))
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func filterOSC(chunks ...string) string {
+	var out bytes.Buffer
+	writer := NewOSCWriter(&out)
+	for _, chunk := range chunks {
+		writer.Write([]byte(chunk))
+	}
+	writer.Flush()
+	return out.String()
+}
+
+func TestOSCWriterStripsOperatingSystemCommands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"hyperlink terminated by string terminator", "see \x1b]8;;https://example.com\x1b\\the docs\x1b]8;;\x1b\\ now", "see the docs now"},
+		{"hyperlink terminated by bell", "\x1b]8;id=1;file:///tmp/a.txt\x07a.txt\x1b]8;;\x07\r\n", "a.txt\r\n"},
+		{"window title", "\x1b]0;ec2-user@host:~\x07sh-4.2$ ", "sh-4.2$ "},
+		{"colors are kept", "\x1b[01;34m\x1b]8;;file:///home\x1b\\home\x1b]8;;\x1b\\\x1b[0m", "\x1b[01;34mhome\x1b[0m"},
+		{"other escape sequences and bell are kept", "\x1b(B\x1b=done\x07", "\x1b(B\x1b=done\x07"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, filterOSC(testCase.input))
+		})
+	}
+}
+
+func TestOSCWriterStripsHyperlinksSplitAcrossWrites(t *testing.T) {
+	input := "\x1b[32m\x1b]8;;https://example.com/a\x1b\\link\x1b]8;;\x1b\\\x1b[0m\r\n"
+
+	var chunks []string
+	for i := range input {
+		chunks = append(chunks, input[i:i+1])
+	}
+
+	assert.Equal(t, "\x1b[32mlink\x1b[0m\r\n", filterOSC(chunks...))
+	assert.Equal(t, "\x1b[32mlink\x1b[0m\r\n", filterOSC("\x1b[32m\x1b", "]8;;https://exa", "mple.com/a\x1b", "\\link\x1b]8;;\x1b\\\x1b[0m\r\n"))
+}
@@ -16,17 +16,20 @@ package main
 
 import (
 	"bufio"
+	"io"
 	"os"
 	"strconv"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/context"
 	"github.com/aws/amazon-ssm-agent/agent/log/ssmlog"
+	"github.com/aws/amazon-ssm-agent/agent/session/logging/ansifilter"
 	"github.com/aws/amazon-ssm-agent/agent/session/logging/console"
 	"github.com/aws/amazon-ssm-agent/agent/version"
 )
 
 const totalArguments = 2
+const totalArgumentsWithAnsiFilter = 3
 const defaultSessionLoggerContextName = "[ssm-session-logger]"
 
 func main() {
@@ -37,10 +40,11 @@ func main() {
 	log := logger.Log()
 	log.Infof("ssm-session-logger - %v", version.String())
 
-	// We need two arguments here.
+	// We need two arguments here, a third one is optional.
 	// First one is the name of the log file to read from.
 	// Second one tells us whether to enable virtual terminal processing for newer versions of Windows.
-	if argsLen != totalArguments {
+	// Third one tells us whether to strip ANSI control functions like colors and cursor movements from the output.
+	if argsLen != totalArguments && argsLen != totalArgumentsWithAnsiFilter {
 		log.Error("Invalid number of arguments received while initializing session logger.")
 		return
 	}
@@ -69,10 +73,25 @@ func main() {
 		}
 	}
 
+	stripAnsiControlFunctions := false
+	if argsLen == totalArgumentsWithAnsiFilter {
+		if stripAnsiControlFunctions, err = strconv.ParseBool(args[3]); err != nil {
+			log.Errorf("Invalid argument type received while initializing session logger %s", args[3])
+			return
+		}
+	}
+
+	var output io.Writer = os.Stdout
+	if stripAnsiControlFunctions {
+		ansiFilter := ansifilter.NewWriter(os.Stdout)
+		defer ansiFilter.Flush()
+		output = ansiFilter
+	}
+
 	scanner := bufio.NewScanner(file)
 	scanner.Split(bufio.ScanBytes)
 	for scanner.Scan() {
-		os.Stdout.Write(scanner.Bytes())
+		output.Write(scanner.Bytes())
 	}
 }
 
@@ -84,6 +103,8 @@ func initializeLogger() context.T {
 	// initialize appconfig, use default config
 	config := appconfig.DefaultConfig()
 
-	// agentIdentity is nil because session-logger does not use instanceId/Region/Credentials
+	// agentIdentity is nil because session-logger does not use instanceId/Region/Credentials.
+	// It runs as a child of the session shell, so it does not forward to CloudWatch Logs: the session worker
+	// streams the transcript it writes with the agent identity when CloudWatch streaming is enabled.
 	return context.Default(logger, config, nil).With(defaultSessionLoggerContextName)
 }
@@ -0,0 +1,119 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package transcript writes session output as JSON lines, one object per output chunk,
+// and reconstructs the raw output stream from them for replay.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// StreamStdout is the stream type of the output of the shell
+	StreamStdout = "stdout"
+	// StreamStderr is the stream type of the error output when it is separated from stdout
+	StreamStderr = "stderr"
+	// StreamExitCode is the stream type of the exit code sent when the command completes
+	StreamExitCode = "exitCode"
+
+	// maxLineSize bounds the size of a line the decoder accepts
+	maxLineSize = 16 * 1024 * 1024
+)
+
+// Chunk is a piece of session output, Data is encoded as base64 in the JSON object
+type Chunk struct {
+	Timestamp  time.Time `json:"ts"`
+	StreamType string    `json:"streamType"`
+	Data       []byte    `json:"data"`
+}
+
+// Encoder writes chunks of session output as JSON lines
+type Encoder struct {
+	out io.Writer
+	now func() time.Time
+}
+
+// NewEncoder returns an Encoder writing to out.
+func NewEncoder(out io.Writer) *Encoder {
+	return &Encoder{
+		out: out,
+		now: func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WriteChunk writes data received on the given stream as a single line.
+func (e *Encoder) WriteChunk(streamType string, data []byte) error {
+	line, err := json.Marshal(Chunk{
+		Timestamp:  e.now(),
+		StreamType: streamType,
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = e.out.Write(append(line, '\n'))
+	return err
+}
+
+// Decoder reads the chunks written by an Encoder
+type Decoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewDecoder returns a Decoder reading JSON lines from in.
+func NewDecoder(in io.Reader) *Decoder {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, maxLineSize)
+	return &Decoder{scanner: scanner}
+}
+
+// Next returns the next chunk, or io.EOF once all the chunks are read.
+func (d *Decoder) Next() (chunk Chunk, err error) {
+	for d.scanner.Scan() {
+		d.line++
+		if len(d.scanner.Bytes()) == 0 {
+			continue
+		}
+		if err = json.Unmarshal(d.scanner.Bytes(), &chunk); err != nil {
+			return chunk, fmt.Errorf("invalid transcript line %d: %v", d.line, err)
+		}
+		return chunk, nil
+	}
+	if err = d.scanner.Err(); err != nil {
+		return chunk, err
+	}
+	return chunk, io.EOF
+}
+
+// Decode writes the chunks of the JSON lines transcript read from in to out,
+// reconstructing the raw byte stream the session would have persisted in the raw format.
+func Decode(in io.Reader, out io.Writer) error {
+	decoder := NewDecoder(in)
+	for {
+		chunk, err := decoder.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err = out.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package transcript
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	chunks := []Chunk{
+		{StreamType: StreamStdout, Data: []byte("$ ls\r\n")},
+		{StreamType: StreamStdout, Data: []byte("\x1b[01;34mdir\x1b[0m  file\r\n")},
+		{StreamType: StreamStderr, Data: []byte("ls: cannot access 'missing': No such file or directory\n")},
+		{StreamType: StreamStdout, Data: []byte{0xe2, 0x82}},
+		{StreamType: StreamStdout, Data: []byte{0xac, '\n'}},
+		{StreamType: StreamExitCode, Data: []byte("2")},
+	}
+	var raw, encoded bytes.Buffer
+	encoder := NewEncoder(&encoded)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tick := 0
+	encoder.now = func() time.Time {
+		tick++
+		return start.Add(time.Duration(tick) * time.Millisecond)
+	}
+	for _, chunk := range chunks {
+		raw.Write(chunk.Data)
+		assert.NoError(t, encoder.WriteChunk(chunk.StreamType, chunk.Data))
+	}
+
+	// one JSON object per line
+	lines := strings.Split(strings.TrimSuffix(encoded.String(), "\n"), "\n")
+	assert.Len(t, lines, len(chunks))
+	assert.Equal(t, `{"ts":"2024-05-01T10:00:00.001Z","streamType":"stdout","data":"JCBscw0K"}`, lines[0])
+
+	decoder := NewDecoder(bytes.NewReader(encoded.Bytes()))
+	for i, expected := range chunks {
+		chunk, err := decoder.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, expected.StreamType, chunk.StreamType)
+		assert.Equal(t, expected.Data, chunk.Data)
+		assert.Equal(t, start.Add(time.Duration(i+1)*time.Millisecond), chunk.Timestamp)
+	}
+	_, err := decoder.Next()
+	assert.Equal(t, io.EOF, err)
+
+	var decoded bytes.Buffer
+	assert.NoError(t, Decode(bytes.NewReader(encoded.Bytes()), &decoded))
+	assert.Equal(t, raw.Bytes(), decoded.Bytes())
+}
+
+func TestDecodeEmptyTranscript(t *testing.T) {
+	var decoded bytes.Buffer
+	assert.NoError(t, Decode(strings.NewReader(""), &decoded))
+	assert.Empty(t, decoded.Bytes())
+}
+
+func TestDecodeSkipsEmptyLines(t *testing.T) {
+	var decoded bytes.Buffer
+	transcript := `{"ts":"2024-05-01T10:00:00Z","streamType":"stdout","data":"aGVsbG8="}` + "\n\n" +
+		`{"ts":"2024-05-01T10:00:01Z","streamType":"stdout","data":"IHdvcmxk"}` + "\n"
+
+	assert.NoError(t, Decode(strings.NewReader(transcript), &decoded))
+	assert.Equal(t, "hello world", decoded.String())
+}
+
+func TestDecodeInvalidLine(t *testing.T) {
+	var decoded bytes.Buffer
+	transcript := `{"ts":"2024-05-01T10:00:00Z","streamType":"stdout","data":"aGVsbG8="}` + "\nnot json\n"
+
+	err := Decode(strings.NewReader(transcript), &decoded)
+
+	assert.EqualError(t, err, "invalid transcript line 2: invalid character 'o' in literal null (expecting 'u')")
+	assert.Equal(t, "hello", decoded.String())
+}
@@ -222,7 +222,7 @@ func (p *PortPlugin) execute(
 			}
 		}()
 		cancelState := cancelFlag.Wait()
-		if cancelFlag.Canceled() {
+		if cancelFlag.Canceled() || cancelFlag.ShutDown() {
 			p.cancelled <- struct{}{}
 			log.Debug("Cancel flag set to cancelled in session")
 		}
@@ -44,6 +44,8 @@ import (
 	mgsConfig "github.com/aws/amazon-ssm-agent/agent/session/config"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	"github.com/aws/amazon-ssm-agent/agent/session/datachannel"
+	"github.com/aws/amazon-ssm-agent/agent/session/logging/ansifilter"
+	"github.com/aws/amazon-ssm-agent/agent/session/logging/transcript"
 	"github.com/aws/amazon-ssm-agent/agent/session/shell/constants"
 	"github.com/aws/amazon-ssm-agent/agent/session/shell/execcmd"
 	"github.com/aws/amazon-ssm-agent/agent/task"
@@ -77,6 +79,11 @@ type logger struct {
 	cloudWatchStreamingFinished chan bool
 	streamLogsToCloudWatch      bool
 	writeToIpcFile              bool
+	transcriptFileName          string
+	transcriptFilePath          string
+	transcriptFile              *os.File
+	stripTranscriptOSC          bool
+	transcriptFilters           map[mgsContracts.PayloadType]*transcriptFilter
 	s3Util                      s3util.IAmazonS3Util
 	cwl                         cloudwatchlogsinterface.ICloudWatchLogsService
 }
@@ -86,8 +93,25 @@ type IShellPlugin interface {
 	InputStreamMessageHandler(log log.T, streamDataMessage mgsContracts.AgentMessage) error
 }
 
+// transcriptFilter strips the operating system commands from the output of one stream persisted in the transcript,
+// it holds back the commands split across reads until they are complete
+type transcriptFilter struct {
+	filtered bytes.Buffer
+	writer   *ansifilter.Writer
+}
+
+// jsonLinesFileExtension is the extension of the session log file persisted in the JSON lines transcript format
+const jsonLinesFileExtension = ".jsonl"
+
 const separateOutputStreamPrefixRegex = "^[0-9a-zA-Z\r\n_:-]{0,30}$"
 
+// transcriptStreamTypes maps the payload types persisted in the session transcript to their JSON lines stream type
+var transcriptStreamTypes = map[mgsContracts.PayloadType]string{
+	mgsContracts.Output:   transcript.StreamStdout,
+	mgsContracts.StdErr:   transcript.StreamStderr,
+	mgsContracts.ExitCode: transcript.StreamExitCode,
+}
+
 // NewPlugin returns a new instance of the Shell Plugin
 func NewPlugin(context context.T, name string) (*ShellPlugin, error) {
 	var plugin = ShellPlugin{
@@ -285,11 +309,17 @@ func (p *ShellPlugin) execute(config agentContracts.Configuration,
 		if closeErr := ipcFile.Close(); closeErr != nil {
 			log.Warnf("error occurred while closing ipcFile, %v", closeErr)
 		}
+		if p.logger.transcriptFile != nil {
+			if closeErr := p.logger.transcriptFile.Close(); closeErr != nil {
+				log.Warnf("error occurred while closing the session transcript, %v", closeErr)
+			}
+		}
 	}()
 
 	go func() {
 		cancelState := cancelFlag.Wait()
-		if cancelFlag.Canceled() {
+		// a worker shutting down tears the session down the same way as a cancellation
+		if cancelFlag.Canceled() || cancelFlag.ShutDown() {
 			cancelled <- true
 			log.Debug("Cancel flag set to cancelled in session")
 		}
@@ -308,13 +338,20 @@ func (p *ShellPlugin) execute(config agentContracts.Configuration,
 	log.Debug("Shell session execution complete")
 }
 
-// Creates ipc temp file
+// Creates ipc temp file, along with the JSON lines transcript when it is configured
 func (p *ShellPlugin) createIpcFile() (*os.File, error) {
 	if !p.logger.writeToIpcFile {
 		return nil, nil
 	}
 	ipcFile, err := os.Create(p.logger.ipcFilePath)
-	return ipcFile, err
+	if err != nil || p.logger.transcriptFilePath == "" {
+		return ipcFile, err
+	}
+	if p.logger.transcriptFile, err = os.Create(p.logger.transcriptFilePath); err != nil {
+		ipcFile.Close()
+		return nil, err
+	}
+	return ipcFile, nil
 }
 
 // Executes command in pseudo terminal with pty
@@ -332,12 +369,19 @@ func (p *ShellPlugin) executeCommandsWithPty(config agentContracts.Configuration
 
 	// Execute shell profile
 	if appconfig.PluginNameStandardStream == p.name {
-		if err := p.runShellProfile(log, config); err != nil {
+		if err := p.runShellProfile(log, config.ShellProfile); err != nil {
 			errorString := fmt.Errorf("Encountered an error while executing shell profile: %s", err)
 			log.Error(errorString)
 			output.MarkAsFailed(errorString)
 			return
 		}
+		// the initial commands are typed into the shell like the shell profile, once it ran
+		if err := p.runShellProfile(log, config.InitialCommands); err != nil {
+			errorString := fmt.Errorf("Encountered an error while streaming initial commands: %s", err)
+			log.Error(errorString)
+			output.MarkAsFailed(errorString)
+			return
+		}
 	}
 
 	// Start logging activity like streaming to CW
@@ -648,8 +692,25 @@ func (p *ShellPlugin) initializeLogger(log log.T, config agentContracts.Configur
 	p.logger.ipcFilePath = filepath.Join(config.OrchestrationDirectory, mgsConfig.IpcFileName+mgsConfig.LogFileExtension)
 
 	// Generate final log file path
+	p.logger.stripTranscriptOSC = p.context.AppConfig().Ssm.SessionTranscriptStripOSC
+	if p.logger.stripTranscriptOSC {
+		p.logger.transcriptFilters = newTranscriptFilters()
+	}
 	p.logger.logFileName = config.SessionId + mgsConfig.LogFileExtension
 	p.logger.logFilePath = filepath.Join(config.OrchestrationDirectory, p.logger.logFileName)
+	if p.context.AppConfig().Ssm.SessionTranscriptFormat == appconfig.SessionTranscriptFormatJSONLines {
+		// the JSON lines transcript is uploaded to S3 in place of the log file, CloudWatch keeps receiving the raw output
+		p.logger.transcriptFileName = config.SessionId + jsonLinesFileExtension
+		p.logger.transcriptFilePath = filepath.Join(config.OrchestrationDirectory, p.logger.transcriptFileName)
+	}
+}
+
+// s3LogFile returns the name and path of the session log file uploaded to S3
+func (p *ShellPlugin) s3LogFile() (fileName, filePath string) {
+	if p.logger.transcriptFilePath != "" {
+		return p.logger.transcriptFileName, p.logger.transcriptFilePath
+	}
+	return p.logger.logFileName, p.logger.logFilePath
 }
 
 // uploadShellSessionLogsToS3 uploads shell session logs to S3 bucket specified.
@@ -661,7 +722,8 @@ func (p *ShellPlugin) uploadShellSessionLogsToS3(log log.T, s3UploaderUtil s3uti
 
 	log.Debugf("Preparing to upload session logs to S3 bucket %s and prefix %s", config.OutputS3BucketName, s3KeyPrefix)
 
-	if err := s3UploaderUtil.S3Upload(log, config.OutputS3BucketName, s3KeyPrefix, p.logger.logFilePath); err != nil {
+	_, logFilePath := p.s3LogFile()
+	if err := s3UploaderUtil.S3Upload(log, config.OutputS3BucketName, s3KeyPrefix, logFilePath); err != nil {
 		log.Errorf("Failed to upload shell session logs to S3: %s", err)
 	}
 }
@@ -865,7 +927,7 @@ func (p *ShellPlugin) processStdoutData(
 	}
 
 	if p.logger.writeToIpcFile {
-		if _, err := file.Write(processedBuf.Bytes()); err != nil {
+		if err := p.writeToTranscript(file, payloadType, processedBuf.Bytes()); err != nil {
 			return processedBuf, fmt.Errorf("encountered an error while writing to file: %s", err)
 		}
 	}
@@ -878,6 +940,41 @@ func (p *ShellPlugin) processStdoutData(
 	return unprocessedBuf, nil
 }
 
+// writeToTranscript persists session output to the ipc file, and to the JSON lines transcript when it is configured
+func (p *ShellPlugin) writeToTranscript(file *os.File, payloadType mgsContracts.PayloadType, data []byte) (err error) {
+	if p.logger.stripTranscriptOSC {
+		data = p.stripTranscriptOSC(payloadType, data)
+	}
+	if _, err = file.Write(data); err != nil || p.logger.transcriptFile == nil || len(data) == 0 {
+		return
+	}
+	return transcript.NewEncoder(p.logger.transcriptFile).WriteChunk(transcriptStreamTypes[payloadType], data)
+}
+
+// newTranscriptFilters creates one filter per stream persisted in the transcript, the stdout and stderr routines
+// write concurrently so the filters are created up front and only read afterwards
+func newTranscriptFilters() map[mgsContracts.PayloadType]*transcriptFilter {
+	filters := make(map[mgsContracts.PayloadType]*transcriptFilter)
+	for payloadType := range transcriptStreamTypes {
+		filter := &transcriptFilter{}
+		filter.writer = ansifilter.NewOSCWriter(&filter.filtered)
+		filters[payloadType] = filter
+	}
+	return filters
+}
+
+// stripTranscriptOSC removes the operating system commands, such as OSC 8 hyperlinks, from the output of a stream
+func (p *ShellPlugin) stripTranscriptOSC(payloadType mgsContracts.PayloadType, data []byte) []byte {
+	filter, found := p.logger.transcriptFilters[payloadType]
+	if !found {
+		return data
+	}
+	filter.filtered.Reset()
+	// writing to the buffer does not fail
+	filter.writer.Write(data)
+	return filter.filtered.Bytes()
+}
+
 // startStreamingLogs starts streaming of logs to CloudWatch
 func (p *ShellPlugin) startStreamingLogs(
 	ipcFile *os.File,
@@ -950,7 +1047,8 @@ func (p *ShellPlugin) finishLogging(
 
 		if config.OutputS3BucketName != "" {
 			log.Debug("Starting S3 logging")
-			s3KeyPrefix := fileutil.BuildS3Path(config.OutputS3KeyPrefix, p.logger.logFileName)
+			s3LogFileName, _ := p.s3LogFile()
+			s3KeyPrefix := fileutil.BuildS3Path(config.OutputS3KeyPrefix, s3LogFileName)
 			p.uploadShellSessionLogsToS3(log, p.logger.s3Util, config, s3KeyPrefix)
 			sessionPluginResultOutput.S3Bucket = config.OutputS3BucketName
 			sessionPluginResultOutput.S3UrlSuffix = s3KeyPrefix
@@ -20,8 +20,11 @@ package shell
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -36,6 +39,7 @@ import (
 	taskmocks "github.com/aws/amazon-ssm-agent/agent/mocks/task"
 	mgsContracts "github.com/aws/amazon-ssm-agent/agent/session/contracts"
 	dataChannelMock "github.com/aws/amazon-ssm-agent/agent/session/datachannel/mocks"
+	"github.com/aws/amazon-ssm-agent/agent/session/logging/transcript"
 	execcmdMock "github.com/aws/amazon-ssm-agent/agent/session/shell/execcmd/mocks"
 	"github.com/aws/amazon-ssm-agent/agent/task"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
@@ -210,7 +214,7 @@ func (suite *ShellTestSuite) TestSendExitCode() {
 		dataChannel: suite.mockDataChannel,
 		execCmd:     suite.mockCmd,
 	}
-	suite.plugin.logger = logger{ipcFilePath: "test.log"}
+	suite.plugin.logger = logger{ipcFilePath: filepath.Join(suite.T().TempDir(), "test.log")}
 	ipcFile, _ := os.Create(suite.plugin.logger.ipcFilePath)
 
 	// Deleting file
@@ -303,6 +307,7 @@ func (suite *ShellTestSuite) TestExecuteForNonInteractiveCommandsWithSeparateOut
 			CloudWatchStreamingEnabled: false,
 			SessionId:                  sessionId,
 			SessionOwner:               sessionOwner,
+			OrchestrationDirectory:     suite.T().TempDir(),
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -367,6 +372,7 @@ func (suite *ShellTestSuite) TestExecuteForNonInteractiveCommandsWithSeparateOut
 			CloudWatchStreamingEnabled: false,
 			SessionId:                  sessionId,
 			SessionOwner:               sessionOwner,
+			OrchestrationDirectory:     suite.T().TempDir(),
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -827,3 +833,210 @@ func (suite *ShellTestSuite) TestIfIpcFileIsWrittenTo() {
 	stat, _ := ipcFile.Stat()
 	suite.True(stat.Size() == 1)
 }
+
+// Test the JSON lines transcript is written next to the raw ipc file while the data channel receives the raw output
+func (suite *ShellTestSuite) TestTranscriptIsWrittenAsJSONLines() {
+	dir := suite.T().TempDir()
+	ipcFileName := filepath.Join(dir, "ipcTempFile.log")
+	ipcFile, _ := os.Create(ipcFileName)
+	defer ipcFile.Close()
+	transcriptFileName := filepath.Join(dir, sessionId+".jsonl")
+	transcriptFile, _ := os.Create(transcriptFileName)
+	defer transcriptFile.Close()
+	plugin := &ShellPlugin{
+		context:     suite.mockContext,
+		dataChannel: suite.mockDataChannel,
+		logger: logger{
+			writeToIpcFile: true,
+			transcriptFile: transcriptFile,
+		},
+	}
+
+	chunks := []struct {
+		payloadType mgsContracts.PayloadType
+		data        string
+		streamType  string
+	}{
+		{mgsContracts.Output, "$ ls missing\r\n", transcript.StreamStdout},
+		{mgsContracts.StdErr, "ls: missing: No such file or directory\n", transcript.StreamStderr},
+		{mgsContracts.ExitCode, "2", transcript.StreamExitCode},
+	}
+	var unprocessedBuf bytes.Buffer
+	for _, chunk := range chunks {
+		suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, chunk.payloadType, []byte(chunk.data)).Return(nil).Once()
+		_, err := plugin.processStdoutData(suite.mockLog, []byte(chunk.data), len(chunk.data), unprocessedBuf, ipcFile, chunk.payloadType)
+		suite.Nil(err)
+	}
+	suite.mockDataChannel.AssertExpectations(suite.T())
+
+	// the ipc file streamed to CloudWatch keeps the raw output
+	rawContent, _ := os.ReadFile(ipcFileName)
+	suite.Equal("$ ls missing\r\nls: missing: No such file or directory\n2", string(rawContent))
+
+	content, _ := os.ReadFile(transcriptFileName)
+	decoder := transcript.NewDecoder(bytes.NewReader(content))
+	for _, expected := range chunks {
+		chunk, err := decoder.Next()
+		suite.Nil(err)
+		suite.Equal(expected.streamType, chunk.StreamType)
+		suite.Equal(expected.data, string(chunk.Data))
+		suite.False(chunk.Timestamp.IsZero())
+	}
+	var raw bytes.Buffer
+	suite.Nil(transcript.Decode(bytes.NewReader(content), &raw))
+	suite.Equal(string(rawContent), raw.String())
+}
+
+// Test OSC 8 hyperlinks are stripped from the ipc file when configured while the data channel still receives them
+func (suite *ShellTestSuite) TestIpcFileStripsOperatingSystemCommands() {
+	chunks := []string{"see \x1b]8;;https://exam", "ple.com\x1b\\\x1b[1mdocs\x1b[0m\x1b]8;;\x1b", "\\\r\n"}
+	for _, testCase := range []struct {
+		stripOSC bool
+		expected string
+	}{
+		{true, "see \x1b[1mdocs\x1b[0m\r\n"},
+		{false, strings.Join(chunks, "")},
+	} {
+		plugin := &ShellPlugin{
+			context:     suite.mockContext,
+			dataChannel: suite.mockDataChannel,
+			logger: logger{
+				writeToIpcFile:     true,
+				stripTranscriptOSC: testCase.stripOSC,
+			},
+		}
+		if testCase.stripOSC {
+			plugin.logger.transcriptFilters = newTranscriptFilters()
+		}
+		ipcFileName := filepath.Join(suite.T().TempDir(), "ipcTempFile.log")
+		ipcFile, _ := os.Create(ipcFileName)
+
+		var unprocessedBuf bytes.Buffer
+		for _, chunk := range chunks {
+			suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, mgsContracts.Output, []byte(chunk)).Return(nil).Once()
+			_, err := plugin.processStdoutData(suite.mockLog, []byte(chunk), len(chunk), unprocessedBuf, ipcFile, mgsContracts.Output)
+			suite.Nil(err)
+		}
+		ipcFile.Close()
+		suite.mockDataChannel.AssertExpectations(suite.T())
+
+		content, _ := os.ReadFile(ipcFileName)
+		suite.Equal(testCase.expected, string(content))
+	}
+}
+
+// Test the stdout and stderr routines strip operating system commands from the ipc file concurrently
+func (suite *ShellTestSuite) TestIpcFileStripsOperatingSystemCommandsConcurrently() {
+	suite.mockDataChannel.On("SendStreamDataMessage", suite.mockLog, mock.Anything, mock.Anything).Return(nil)
+	plugin := &ShellPlugin{
+		context:     context.NewMockDefaultWithConfig(appconfig.SsmagentConfig{Ssm: appconfig.SsmCfg{SessionTranscriptStripOSC: true}}),
+		dataChannel: suite.mockDataChannel,
+	}
+	plugin.initializeLogger(suite.mockLog, contracts.Configuration{
+		SessionId:              sessionId,
+		OrchestrationDirectory: suite.T().TempDir(),
+	})
+	suite.True(plugin.logger.writeToIpcFile)
+	ipcFileName := filepath.Join(suite.T().TempDir(), "ipcTempFile.log")
+	ipcFile, _ := os.Create(ipcFileName)
+	defer ipcFile.Close()
+
+	chunks := []string{"\x1b]8;;https://exam", "ple.com\x1b\\x", "\x1b]8;;\x1b\\\n"}
+	var wg sync.WaitGroup
+	for _, payloadType := range []mgsContracts.PayloadType{mgsContracts.Output, mgsContracts.StdErr} {
+		wg.Add(1)
+		go func(payloadType mgsContracts.PayloadType) {
+			defer wg.Done()
+			var unprocessedBuf bytes.Buffer
+			for i := 0; i < 100; i++ {
+				for _, chunk := range chunks {
+					_, err := plugin.processStdoutData(suite.mockLog, []byte(chunk), len(chunk), unprocessedBuf, ipcFile, payloadType)
+					suite.Nil(err)
+				}
+			}
+		}(payloadType)
+	}
+	wg.Wait()
+
+	content, _ := os.ReadFile(ipcFileName)
+	// the routines interleave their writes so only the filtered content is compared
+	suite.NotContains(string(content), "\x1b")
+	suite.Equal(200, strings.Count(string(content), "x"))
+	suite.Equal(200, strings.Count(string(content), "\n"))
+}
+
+// Test the JSON lines transcript is uploaded to S3 while the raw log file is kept for CloudWatch
+func (suite *ShellTestSuite) TestInitializeLoggerWithTranscriptFormat() {
+	config := contracts.Configuration{
+		SessionId:              sessionId,
+		OrchestrationDirectory: "orchestrationDir",
+	}
+
+	plugin := &ShellPlugin{context: context.NewMockDefaultWithConfig(appconfig.SsmagentConfig{
+		Ssm: appconfig.SsmCfg{SessionTranscriptFormat: appconfig.SessionTranscriptFormatJSONLines},
+	})}
+	plugin.initializeLogger(suite.mockLog, config)
+	suite.Equal(filepath.Join("orchestrationDir", sessionId+".log"), plugin.logger.logFilePath)
+	s3FileName, s3FilePath := plugin.s3LogFile()
+	suite.Equal(sessionId+".jsonl", s3FileName)
+	suite.Equal(filepath.Join("orchestrationDir", sessionId+".jsonl"), s3FilePath)
+
+	plugin = &ShellPlugin{context: context.NewMockDefaultWithConfig(appconfig.SsmagentConfig{
+		Ssm: appconfig.SsmCfg{SessionTranscriptFormat: appconfig.SessionTranscriptFormatRaw},
+	})}
+	plugin.initializeLogger(suite.mockLog, config)
+	suite.Equal("", plugin.logger.transcriptFilePath)
+	s3FileName, s3FilePath = plugin.s3LogFile()
+	suite.Equal(sessionId+".log", s3FileName)
+	suite.Equal(filepath.Join("orchestrationDir", sessionId+".log"), s3FilePath)
+}
+
+// Test initial commands are streamed into the shell in order, like the shell profile, and recorded in the transcript
+func (suite *ShellTestSuite) TestRunInitialCommands() {
+	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.plugin.logger.writeToIpcFile = true
+	ipcFileName := "shell_initial_commands_test_file"
+	ipcFile, _ := os.Create(ipcFileName)
+	defer func() {
+		ipcFile.Close()
+		os.Remove(ipcFileName)
+	}()
+
+	initialCommands := contracts.ShellProfileConfig{
+		Windows: "echo first\necho second\necho third",
+		Linux:   "echo first\necho second\necho third",
+	}
+	err := suite.plugin.runShellProfile(suite.mockLog, initialCommands)
+	suite.Nil(err)
+	suite.stdin.Close()
+
+	// the test pipe hands the input straight back as terminal output, the way a pty echoes typed commands
+	var unprocessedBuf bytes.Buffer
+	stdoutBytes := make([]byte, 1024)
+	for {
+		stdoutBytesLen, readErr := suite.stdout.Read(stdoutBytes)
+		if readErr != nil {
+			break
+		}
+		unprocessedBuf, err = suite.plugin.processStdoutData(suite.mockLog, stdoutBytes, stdoutBytesLen, unprocessedBuf, ipcFile, mgsContracts.Output)
+		suite.Nil(err)
+	}
+
+	transcript, _ := os.ReadFile(ipcFileName)
+	expected := strings.Join([]string{"echo first", "echo second", "echo third"}, shellProfileNewLineCharacter) + shellProfileNewLineCharacter
+	suite.Equal(expected, string(transcript))
+}
+
+// Test nothing is written to the shell when there are no initial commands
+func (suite *ShellTestSuite) TestRunInitialCommandsWithEmptyScript() {
+	initialCommands := contracts.ShellProfileConfig{
+		Windows: "  ",
+		Linux:   "\n",
+	}
+	err := suite.plugin.runShellProfile(suite.mockLog, initialCommands)
+	suite.Nil(err)
+	suite.stdin.Close()
+
+	data, _ := io.ReadAll(suite.stdout)
+	suite.Empty(data)
+}
@@ -55,6 +55,8 @@ const (
 	catCmd                = "cat"
 	scriptFlag            = "-c"
 	groupsIdentifier      = "groups="
+
+	shellProfileNewLineCharacter = newLineCharacter
 )
 
 // StartCommandExecutor starts command execution in different behaviors based on plugin type.
@@ -320,14 +322,14 @@ func extractGroupId(value string) (int, error) {
 }
 
 // runShellProfile executes the shell profile config
-func (p *ShellPlugin) runShellProfile(log log.T, config agentContracts.Configuration) error {
-	if strings.TrimSpace(config.ShellProfile.Linux) == "" {
+func (p *ShellPlugin) runShellProfile(log log.T, shellProfile agentContracts.ShellProfileConfig) error {
+	if strings.TrimSpace(shellProfile.Linux) == "" {
 		return nil
 	}
 	if p.stdin == nil {
 		return nil
 	}
-	if _, err := p.stdin.Write([]byte(config.ShellProfile.Linux + newLineCharacter)); err != nil {
+	if _, err := p.stdin.Write([]byte(shellProfile.Linux + shellProfileNewLineCharacter)); err != nil {
 		log.Errorf("Unable to write to stdin, err: %v.", err)
 		return err
 	}
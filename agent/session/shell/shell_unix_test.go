@@ -23,6 +23,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"syscall"
 	"time"
 
@@ -44,7 +45,7 @@ func (suite *ShellTestSuite) TestExecuteWhenCancelFlagIsShutDown() {
 	suite.mockIohandler.On("MarkAsShutdown").Return(nil)
 
 	suite.plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -61,7 +62,7 @@ func (suite *ShellTestSuite) TestExecuteWhenCancelFlagIsCancelled() {
 	suite.mockIohandler.On("MarkAsCancelled").Return(nil)
 
 	suite.plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -103,7 +104,7 @@ func (suite *ShellTestSuite) TestExecute() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -147,7 +148,7 @@ func (suite *ShellTestSuite) TestExecuteNonInteractiveCommands() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -185,13 +186,15 @@ func (suite *ShellTestSuite) TestExecuteWithCWLoggingEnabled() {
 	// When CW logging is enabled with streaming disabled then IsFileComplete is expected to be true since log to CW is uploaded once at the end of the session
 	expectedIsFileComplete := true
 	suite.mockCWL.On("IsLogGroupPresent", testCwLogGroupName).Return(true, &testCwlLogGroup)
-	suite.mockCWL.On("StreamData", testCwLogGroupName, sessionId, sessionId+mgsConfig.LogFileExtension, expectedIsFileComplete, false, mock.Anything, false, false).Return(true)
+	orchestrationDir := suite.T().TempDir()
+	suite.mockCWL.On("StreamData", testCwLogGroupName, sessionId, filepath.Join(orchestrationDir, sessionId+mgsConfig.LogFileExtension), expectedIsFileComplete, false, mock.Anything, false, false).Return(true)
 
 	suite.plugin.Execute(
 		contracts.Configuration{
-			CloudWatchLogGroup: testCwLogGroupName,
-			SessionId:          sessionId,
-			SessionOwner:       sessionOwner,
+			CloudWatchLogGroup:     testCwLogGroupName,
+			SessionId:              sessionId,
+			SessionOwner:           sessionOwner,
+			OrchestrationDirectory: orchestrationDir,
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -232,7 +235,8 @@ func (suite *ShellTestSuite) TestExecuteWithCWLogStreamingEnabled() {
 	// When CW log streaming is enabled then IsFileComplete is expected to be false since log to CW will uploaded periodically since the beginning of the session
 	expectedIsFileComplete := false
 	suite.mockCWL.On("IsLogGroupPresent", testCwLogGroupName).Return(true, &testCwlLogGroup)
-	suite.mockCWL.On("StreamData", testCwLogGroupName, sessionId, "ipcTempFile.log", expectedIsFileComplete, false, mock.Anything, true, true).Return(true)
+	orchestrationDir := suite.T().TempDir()
+	suite.mockCWL.On("StreamData", testCwLogGroupName, sessionId, filepath.Join(orchestrationDir, "ipcTempFile.log"), expectedIsFileComplete, false, mock.Anything, true, true).Return(true)
 	suite.mockDataChannel.On("GetRegion").Return("region")
 	suite.mockDataChannel.On("GetInstanceId").Return("instanceId")
 
@@ -248,6 +252,7 @@ func (suite *ShellTestSuite) TestExecuteWithCWLogStreamingEnabled() {
 			CloudWatchStreamingEnabled: true,
 			SessionId:                  sessionId,
 			SessionOwner:               sessionOwner,
+			OrchestrationDirectory:     orchestrationDir,
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -291,6 +296,7 @@ func (suite *ShellTestSuite) TestExecuteWithCWLoggingDisabledButStreamingEnabled
 			CloudWatchStreamingEnabled: true,
 			SessionId:                  sessionId,
 			SessionOwner:               sessionOwner,
+			OrchestrationDirectory:     suite.T().TempDir(),
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -339,7 +345,7 @@ func (suite *ShellTestSuite) TestExecuteWithCancelFlag() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -385,7 +391,7 @@ func (suite *ShellTestSuite) TestExecuteNonInteractiveCommandsWithCancelFlag() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -407,7 +413,7 @@ func (suite *ShellTestSuite) TestWritePump() {
 	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mock.Anything, payload).Return(nil)
 
 	suite.plugin.stdout = stdout
-	suite.plugin.logger = logger{ipcFilePath: "test.log"}
+	suite.plugin.logger = logger{ipcFilePath: filepath.Join(suite.T().TempDir(), "test.log")}
 
 	// Create ipc file
 	ipcFile, _ := os.Create(suite.plugin.logger.ipcFilePath)
@@ -439,7 +445,7 @@ func (suite *ShellTestSuite) TestWritePumpForInvalidUtf8Character() {
 	suite.mockDataChannel.On("SendStreamDataMessage", mock.Anything, mock.Anything, invalidUtf8Payload).Return(nil)
 
 	suite.plugin.stdout = stdout
-	suite.plugin.logger = logger{ipcFilePath: "test.log"}
+	suite.plugin.logger = logger{ipcFilePath: filepath.Join(suite.T().TempDir(), "test.log")}
 
 	// Create ipc file
 	ipcFile, _ := os.Create(suite.plugin.logger.ipcFilePath)
@@ -466,7 +472,7 @@ func (suite *ShellTestSuite) TestWritePumpWhenDatachannelIsPaused() {
 	suite.mockDataChannel.On("IsActive").Return(false)
 
 	suite.plugin.stdout = stdout
-	suite.plugin.logger = logger{ipcFilePath: "test.log"}
+	suite.plugin.logger = logger{ipcFilePath: filepath.Join(suite.T().TempDir(), "test.log")}
 
 	// Create ipc file
 	ipcFile, _ := os.Create(suite.plugin.logger.ipcFilePath)
@@ -991,7 +997,7 @@ func (suite *ShellTestSuite) TestExecuteForNonInteractiveCommandSession() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
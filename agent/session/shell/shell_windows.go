@@ -291,14 +291,14 @@ func (p *ShellPlugin) startPtyAsUser(log log.T, config agentContracts.Configurat
 }
 
 // runShellProfile executes the shell profile config
-func (p *ShellPlugin) runShellProfile(log log.T, config agentContracts.Configuration) error {
-	if strings.TrimSpace(config.ShellProfile.Windows) == "" {
+func (p *ShellPlugin) runShellProfile(log log.T, shellProfile agentContracts.ShellProfileConfig) error {
+	if strings.TrimSpace(shellProfile.Windows) == "" {
 		return nil
 	}
 	if p.stdin == nil {
 		return nil
 	}
-	commands := strings.Split(config.ShellProfile.Windows, "\n")
+	commands := strings.Split(shellProfile.Windows, "\n")
 
 	for _, command := range commands {
 		if _, err := p.stdin.Write([]byte(command + shellProfileNewLineCharacter)); err != nil {
@@ -382,8 +382,9 @@ func (p *ShellPlugin) generateTranscriptFile(
 
 	time.Sleep(5 * time.Second)
 
-	// Start shell logger
-	loggerCmdInput := fmt.Sprintf("%s %s %t%s", appconfig.DefaultSessionLogger, loggerFile, enableVirtualTerminalProcessingForWindows, newLineCharacter)
+	// Start shell logger, color and cursor control functions are stripped from transcripts uploaded to CloudWatch
+	stripAnsiControlFunctions := config.CloudWatchLogGroup != ""
+	loggerCmdInput := fmt.Sprintf("%s %s %t %t%s", appconfig.DefaultSessionLogger, loggerFile, enableVirtualTerminalProcessingForWindows, stripAnsiControlFunctions, newLineCharacter)
 	p.stdin.Write([]byte(loggerCmdInput))
 
 	// Sleep till the logger completes execution
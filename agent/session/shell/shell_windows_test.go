@@ -22,6 +22,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/contracts"
@@ -40,7 +41,7 @@ func (suite *ShellTestSuite) TestExecuteWhenCancelFlagIsShutDown() {
 	suite.mockIohandler.On("MarkAsShutdown").Return(nil)
 
 	suite.plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -57,7 +58,7 @@ func (suite *ShellTestSuite) TestExecuteWhenCancelFlagIsCancelled() {
 	suite.mockIohandler.On("MarkAsCancelled").Return(nil)
 
 	suite.plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -99,7 +100,7 @@ func (suite *ShellTestSuite) TestExecute() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -138,13 +139,15 @@ func (suite *ShellTestSuite) TestExecuteWithCWLoggingEnabled() {
 	// When CW logging is enabled with streaming disabled then IsFileComplete is expected to be true since log to CW is uploaded once at the end of the session
 	expectedIsFileComplete := true
 	suite.mockCWL.On("IsLogGroupPresent", testCwLogGroupName).Return(true, &testCwlLogGroup)
-	suite.mockCWL.On("StreamData", testCwLogGroupName, sessionId, sessionId+mgsConfig.LogFileExtension, expectedIsFileComplete, false, mock.Anything, false, false).Return(true)
+	orchestrationDir := suite.T().TempDir()
+	suite.mockCWL.On("StreamData", testCwLogGroupName, sessionId, filepath.Join(orchestrationDir, sessionId+mgsConfig.LogFileExtension), expectedIsFileComplete, false, mock.Anything, false, false).Return(true)
 
 	suite.plugin.Execute(
 		contracts.Configuration{
-			CloudWatchLogGroup: testCwLogGroupName,
-			SessionId:          sessionId,
-			SessionOwner:       sessionOwner,
+			CloudWatchLogGroup:     testCwLogGroupName,
+			SessionId:              sessionId,
+			SessionOwner:           sessionOwner,
+			OrchestrationDirectory: orchestrationDir,
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -202,6 +205,7 @@ func (suite *ShellTestSuite) TestExecuteWithCWLogStreamingEnabled() {
 			CloudWatchStreamingEnabled: true,
 			SessionId:                  sessionId,
 			SessionOwner:               sessionOwner,
+			OrchestrationDirectory:     suite.T().TempDir(),
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -242,6 +246,7 @@ func (suite *ShellTestSuite) TestExecuteWithCWLoggingDisabledButStreamingEnabled
 			CloudWatchStreamingEnabled: true,
 			SessionId:                  sessionId,
 			SessionOwner:               sessionOwner,
+			OrchestrationDirectory:     suite.T().TempDir(),
 		},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
@@ -290,7 +295,7 @@ func (suite *ShellTestSuite) TestExecuteWithCancelFlag() {
 	}
 
 	plugin.Execute(
-		contracts.Configuration{},
+		contracts.Configuration{OrchestrationDirectory: suite.T().TempDir()},
 		suite.mockCancelFlag,
 		suite.mockIohandler,
 		suite.mockDataChannel,
@@ -314,7 +319,7 @@ func (suite *ShellTestSuite) TestWritePump() {
 	suite.mockDataChannel.On("IsActive").Return(true)
 
 	suite.plugin.stdout = stdout
-	suite.plugin.logger = logger{ipcFilePath: "test.log"}
+	suite.plugin.logger = logger{ipcFilePath: filepath.Join(suite.T().TempDir(), "test.log")}
 
 	// Create ipc file
 	ipcFile, _ := os.Create(suite.plugin.logger.ipcFilePath)
@@ -346,7 +351,7 @@ func (suite *ShellTestSuite) TestWritePumpForInvalidUtf8Character() {
 	suite.mockDataChannel.On("IsActive").Return(true)
 
 	suite.plugin.stdout = stdout
-	suite.plugin.logger = logger{ipcFilePath: "test.log"}
+	suite.plugin.logger = logger{ipcFilePath: filepath.Join(suite.T().TempDir(), "test.log")}
 
 	// Create ipc file
 	ipcFile, _ := os.Create(suite.plugin.logger.ipcFilePath)
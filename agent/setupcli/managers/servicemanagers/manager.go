@@ -16,6 +16,7 @@ package servicemanagers
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/common"
@@ -36,6 +37,8 @@ const (
 
 var serviceManagers = map[ServiceManager]IServiceManager{}
 
+var timeSleep = time.Sleep
+
 func registerServiceManager(managerType ServiceManager, manager IServiceManager) {
 	serviceManagers[managerType] = manager
 }
@@ -95,3 +98,28 @@ func StartAgent(manager IServiceManager, log log.T) error {
 
 	return fmt.Errorf("retries exhausted")
 }
+
+// StartAgentAndVerify starts the agent and, when verifyInterval is greater than zero, waits for the interval
+// and confirms the agent is still running before reporting success
+func StartAgentAndVerify(manager IServiceManager, log log.T, verifyInterval time.Duration) error {
+	if err := StartAgent(manager, log); err != nil {
+		return err
+	}
+
+	if verifyInterval <= 0 {
+		return nil
+	}
+
+	log.Infof("Waiting %v to verify agent is still running after start", verifyInterval)
+	timeSleep(verifyInterval)
+
+	status, err := manager.GetAgentStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get agent status after verification window: %v", err)
+	} else if status != common.Running {
+		return fmt.Errorf("agent status was %v %v after start when expected status was %v", status, verifyInterval, common.Running)
+	}
+
+	log.Info("Verified agent is still running")
+	return nil
+}
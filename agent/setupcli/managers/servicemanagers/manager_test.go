@@ -17,6 +17,7 @@ package servicemanagers_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
 	"github.com/aws/amazon-ssm-agent/agent/setupcli/managers/common"
@@ -96,3 +97,41 @@ func TestStartAgent_ErrorStartAgentOnce_ErrorVerifyStartAgentOnce_Success(t *tes
 	err := servicemanagers.StartAgent(managerMock, logger)
 	assert.NoError(t, err)
 }
+
+func TestStartAgentAndVerify_AgentStaysRunning_Success(t *testing.T) {
+	managerMock := &mocks.IServiceManager{}
+
+	managerMock.On("GetName").Return("MockName").Once()
+	managerMock.On("StartAgent").Return(nil).Once()
+	managerMock.On("GetAgentStatus").Return(common.Running, nil).Twice()
+
+	err := servicemanagers.StartAgentAndVerify(managerMock, logger, time.Millisecond)
+	assert.NoError(t, err)
+	managerMock.AssertExpectations(t)
+}
+
+func TestStartAgentAndVerify_AgentDiesAfterStart_Error(t *testing.T) {
+	managerMock := &mocks.IServiceManager{}
+
+	managerMock.On("GetName").Return("MockName").Once()
+	managerMock.On("StartAgent").Return(nil).Once()
+	managerMock.On("GetAgentStatus").Return(common.Running, nil).Once()
+	managerMock.On("GetAgentStatus").Return(common.Stopped, nil).Once()
+
+	err := servicemanagers.StartAgentAndVerify(managerMock, logger, time.Millisecond)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expected status was Running")
+	managerMock.AssertExpectations(t)
+}
+
+func TestStartAgentAndVerify_NoVerifyInterval_SkipsVerification(t *testing.T) {
+	managerMock := &mocks.IServiceManager{}
+
+	managerMock.On("GetName").Return("MockName").Once()
+	managerMock.On("StartAgent").Return(nil).Once()
+	managerMock.On("GetAgentStatus").Return(common.Running, nil).Once()
+
+	err := servicemanagers.StartAgentAndVerify(managerMock, logger, 0)
+	assert.NoError(t, err)
+	managerMock.AssertExpectations(t)
+}
@@ -68,6 +68,7 @@ var (
 	version                 string
 	downgrade               bool
 	manifestUrl             string
+	verifyStartSeconds      int
 )
 
 var (
@@ -78,7 +79,7 @@ var (
 	getRegistrationInfo     = registration.NewOnpremRegistrationInfo
 	getVerificationManager  = managers.GetVerificationManager
 	getDownloadManager      = managers.GetDownloadManager
-	startAgent              = servicemanagers.StartAgent
+	startAgent              = startAndVerifyAgent
 	hasElevatedPermissions  = utilityCmn.IsRunningElevatedPermissions
 
 	osExecutable         = os.Executable
@@ -666,10 +667,16 @@ func setParams() {
 	flag.BoolVar(&downgrade, "downgrade", false, "")
 
 	flag.BoolVar(&skipSignatureValidation, "skip-signature-validation", false, "")
+	flag.IntVar(&verifyStartSeconds, "verify-start-seconds", 0, "")
 
 	flag.Parse()
 }
 
+// startAndVerifyAgent starts the agent and verifies it stays running for the configured verification window
+func startAndVerifyAgent(manager servicemanagers.IServiceManager, log log.T) error {
+	return servicemanagers.StartAgentAndVerify(manager, log, time.Duration(verifyStartSeconds)*time.Second)
+}
+
 func hasAgentAlreadyInstalled(versionStr string) (bool, error) {
 	val, err := versionutil.VersionCompare(versionStr, agentVersioning.Version)
 	if err != nil {
@@ -714,6 +721,7 @@ func verifyParams(log log.T, additionalVerifier func() string) {
 	log.Infof("manifest-url=%v", manifestUrl)
 	log.Infof("artifactsDir=%v", artifactsDir)
 	log.Infof("skip-signature-validation=%v", skipSignatureValidation)
+	log.Infof("verify-start-seconds=%v", verifyStartSeconds)
 
 	var errMessage string
 	errMessage += additionalVerifier()
@@ -722,6 +730,10 @@ func verifyParams(log log.T, additionalVerifier func() string) {
 		errMessage += "Region required. "
 	}
 
+	if verifyStartSeconds < 0 {
+		errMessage += "Verify start seconds must not be negative. "
+	}
+
 	if errMessage != "" {
 		flagUsage()
 		osExit(1, log, "Invalid parameters - %v", errMessage)
@@ -771,6 +783,7 @@ func flagUsage() {
 	fmt.Fprintln(os.Stderr, "\t-version\tVersion of the ssm agent to download ('stable' or 'latest'). Default set to 'stable' if agent is not already installed \t(OPTIONAL)")
 	fmt.Fprintln(os.Stderr, "\t-downgrade\tSet when the agent needs to be downgraded \t(OPTIONAL but REQUIRED during downgrade)")
 	fmt.Fprintln(os.Stderr, "\t-skip-signature-validation\tSkip signature validation \t(OPTIONAL)")
+	fmt.Fprintln(os.Stderr, "\t-verify-start-seconds\tSeconds to wait after starting the agent to verify it is still running. Default set to 0 (no verification) \t(OPTIONAL)")
 	fmt.Fprintln(os.Stderr, "\t-register      \tRegister ssm agent if unregistered or override is set \t(REQUIRED)")
 	fmt.Fprintln(os.Stderr, "\t\t-activation-code  \tSSM Activation Code for Onprem environment \t(REQUIRED and paired with activation-id)")
 	fmt.Fprintln(os.Stderr, "\t\t-activation-id  \tSSM Activation ID for Onprem environment \t(REQUIRED and paired with Activation code)")
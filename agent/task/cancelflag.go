@@ -22,6 +22,9 @@ import (
 type State int
 
 const (
+	// Running indicates a job which has not been paused, canceled, shut down or completed.
+	Running State = 0
+
 	// Canceled indicates a job for which cancellation has been requested.
 	Canceled State = 1
 
@@ -30,6 +33,11 @@ const (
 
 	// ShutDown indicates a job for which ShutDown has been requested.
 	ShutDown State = 3
+
+	// Paused indicates a job which must not start its next step until it is set back to Running,
+	// or is canceled or shut down. Unlike the other states, pausing does not wake up the callers of Wait,
+	// the callers of WaitWhileRunning observe it.
+	Paused State = 4
 )
 
 // CancelFlag is an object that is passed to any job submitted to a task in order to
@@ -58,6 +66,13 @@ type CancelFlag interface {
 	// In the go routine, once Wait returns, if the return value indicates that a cancel
 	// request has been received, the go routine wakes up the running job.
 	Wait() (state State)
+
+	// WaitWhilePaused blocks the caller while the flag is in Paused state and returns the state it left it for.
+	WaitWhilePaused() (state State)
+
+	// WaitWhileRunning blocks the caller while the flag is in Running state and returns the state it left it for,
+	// so that a pause can be forwarded to a job running in another process.
+	WaitWhileRunning() (state State)
 }
 
 // ChanneledCancelFlag is a default implementation of the task.CancelFlag interface.
@@ -65,12 +80,14 @@ type ChanneledCancelFlag struct {
 	state  State
 	ch     chan struct{}
 	closed bool
-	m      sync.RWMutex
+	// changed is closed and replaced each time the state of the flag changes
+	changed chan struct{}
+	m       sync.RWMutex
 }
 
 // NewChanneledCancelFlag creates a new instance of ChanneledCancelFlag.
 func NewChanneledCancelFlag() *ChanneledCancelFlag {
-	flag := &ChanneledCancelFlag{ch: make(chan struct{})}
+	flag := &ChanneledCancelFlag{ch: make(chan struct{}), changed: make(chan struct{})}
 	return flag
 }
 
@@ -101,11 +118,41 @@ func (t *ChanneledCancelFlag) Wait() (state State) {
 	return t.State()
 }
 
+// WaitWhilePaused blocks while the flag is in Paused state. Returns the state.
+func (t *ChanneledCancelFlag) WaitWhilePaused() (state State) {
+	return t.waitWhile(Paused)
+}
+
+// WaitWhileRunning blocks while the flag is in Running state. Returns the state.
+func (t *ChanneledCancelFlag) WaitWhileRunning() (state State) {
+	return t.waitWhile(Running)
+}
+
+// waitWhile blocks while the flag is in the given state. Returns the state it left it for.
+func (t *ChanneledCancelFlag) waitWhile(waitState State) (state State) {
+	for {
+		t.m.RLock()
+		state, changed := t.state, t.changed
+		t.m.RUnlock()
+		if state != waitState {
+			return state
+		}
+		<-changed
+	}
+}
+
 // Set sets the state of this flag and wakes up waiting callers.
+// A canceled, shut down or completed flag can no longer be paused or set back to Running.
 func (t *ChanneledCancelFlag) Set(state State) {
 	t.m.Lock()
 	defer t.m.Unlock()
-	t.state = state
+	if (state == Paused || state == Running) && t.closed {
+		return
+	}
+	t.setState(state)
+	if state == Paused || state == Running {
+		return
+	}
 
 	// close channel to wake up routines that are waiting
 	if !t.closed {
@@ -114,3 +161,15 @@ func (t *ChanneledCancelFlag) Set(state State) {
 		t.closed = true
 	}
 }
+
+// setState changes the state of the flag and wakes up the callers waiting for it to change
+func (t *ChanneledCancelFlag) setState(state State) {
+	if t.state == state {
+		return
+	}
+	t.state = state
+	if t.changed != nil {
+		close(t.changed)
+	}
+	t.changed = make(chan struct{})
+}
@@ -15,6 +15,7 @@ package task
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +68,62 @@ func testWait(t *testing.T, state State) {
 	assert.Equal(t, state, <-ch)
 	assert.Equal(t, flag.Canceled(), state == Canceled)
 }
+
+// TestWaitWhilePaused tests that WaitWhilePaused blocks the caller until the flag leaves the Paused state,
+// without waking up the callers of Wait
+func TestWaitWhilePaused(t *testing.T) {
+	for _, state := range []State{Running, Canceled, ShutDown} {
+		flag := NewChanneledCancelFlag()
+		assert.Equal(t, Running, flag.WaitWhilePaused())
+
+		flag.Set(Paused)
+		waitReturned := make(chan State, 1)
+		go func() { waitReturned <- flag.Wait() }()
+		pausedReturned := make(chan State, 1)
+		go func() { pausedReturned <- flag.WaitWhilePaused() }()
+
+		select {
+		case <-pausedReturned:
+			assert.Fail(t, "WaitWhilePaused returned while the flag is paused")
+		case <-waitReturned:
+			assert.Fail(t, "Wait returned while the flag is paused")
+		case <-time.After(50 * time.Millisecond):
+		}
+		assert.False(t, flag.Canceled())
+
+		flag.Set(state)
+		assert.Equal(t, state, <-pausedReturned)
+		if state != Running {
+			assert.Equal(t, state, <-waitReturned)
+		}
+	}
+}
+
+// TestWaitWhileRunning tests that WaitWhileRunning returns once the flag is paused, canceled or shut down
+func TestWaitWhileRunning(t *testing.T) {
+	for _, state := range []State{Paused, Canceled, ShutDown} {
+		flag := NewChanneledCancelFlag()
+		runningReturned := make(chan State, 1)
+		go func() { runningReturned <- flag.WaitWhileRunning() }()
+
+		select {
+		case <-runningReturned:
+			assert.Fail(t, "WaitWhileRunning returned while the flag is running")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		flag.Set(state)
+		assert.Equal(t, state, <-runningReturned)
+	}
+}
+
+// TestPauseAfterCancel tests that a canceled flag can no longer be paused or set back to Running
+func TestPauseAfterCancel(t *testing.T) {
+	flag := NewChanneledCancelFlag()
+	flag.Set(Canceled)
+
+	flag.Set(Paused)
+	assert.Equal(t, Canceled, flag.WaitWhilePaused())
+	flag.Set(Running)
+	assert.True(t, flag.Canceled())
+}
@@ -82,25 +82,27 @@ type contextManager struct {
 
 // UpdateDetail Book keeping detail for Agent Update
 type UpdateDetail struct {
-	State               UpdateState
-	Result              contracts.ResultStatus
-	StandardOut         string
-	StandardError       string
-	OutputS3KeyPrefix   string
-	OutputS3BucketName  string
-	StdoutFileName      string
-	StderrFileName      string
-	SourceVersion       string
-	SourceLocation      string
-	SourceHash          string
-	TargetVersion       string
-	TargetResolver      updateconstants.TargetVersionResolver
-	TargetLocation      string
-	TargetHash          string
-	PackageName         string
-	StartDateTime       time.Time
-	EndDateTime         time.Time
-	MessageID           string
+	State              UpdateState
+	Result             contracts.ResultStatus
+	StandardOut        string
+	StandardError      string
+	OutputS3KeyPrefix  string
+	OutputS3BucketName string
+	StdoutFileName     string
+	StderrFileName     string
+	SourceVersion      string
+	SourceLocation     string
+	SourceHash         string
+	TargetVersion      string
+	TargetResolver     updateconstants.TargetVersionResolver
+	TargetLocation     string
+	TargetHash         string
+	PackageName        string
+	StartDateTime      time.Time
+	EndDateTime        time.Time
+	MessageID          string
+	// DocumentID is the id of the document state file of the document that triggered the update
+	DocumentID          string
 	UpdateRoot          string
 	RequiresUninstall   bool
 	ManifestURL         string
@@ -108,6 +110,8 @@ type UpdateDetail struct {
 	SelfUpdate          bool
 	AllowDowngrade      bool
 	UpstreamServiceName string
+	// Force restarts the agent without waiting for the documents in progress to complete
+	Force bool
 }
 
 // HasMessageID represents if update is triggered by run command
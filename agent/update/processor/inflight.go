@@ -0,0 +1,113 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package processor
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/fileutil"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+var (
+	getInFlightDocuments          = listInFlightDocuments
+	inFlightDocumentsPollInterval = 10 * time.Second
+	inFlightDocumentsMaxWait      = 30 * time.Minute
+)
+
+// listInFlightDocuments returns the ids of the documents in progress in the current folder of the agent,
+// except the document that triggered the update
+func listInFlightDocuments(context context.T, updateDetail *UpdateDetail) ([]string, error) {
+	shortInstanceId, err := context.Identity().ShortInstanceID()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get instance id: %v", err)
+	}
+
+	currentDir := filepath.Join(appconfig.DefaultDataStorePath,
+		shortInstanceId,
+		appconfig.DefaultDocumentRootDirName,
+		appconfig.DefaultLocationOfState,
+		appconfig.DefaultLocationOfCurrent)
+	return listInFlightDocumentsInDir(context.Log(), currentDir, updateDocumentID(updateDetail))
+}
+
+// updateDocumentID returns the id of the document state file of the document that triggered the update,
+// updates started by plugins that do not pass the document id fall back to the command id of the message id
+func updateDocumentID(updateDetail *UpdateDetail) string {
+	if updateDetail.DocumentID != "" {
+		return updateDetail.DocumentID
+	}
+	if updateDetail.HasMessageID() {
+		commandID, _ := getCommandID(updateDetail.MessageID)
+		return commandID
+	}
+	return ""
+}
+
+// listInFlightDocumentsInDir returns the ids of the document state files in the directory whose document is in progress,
+// pending documents have not started and are resumed by the agent after the restart
+func listInFlightDocumentsInDir(log log.T, currentDir, updateDocumentID string) ([]string, error) {
+	fileNames, err := fileutil.GetFileNames(currentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []string
+	for _, fileName := range fileNames {
+		if fileName == updateDocumentID {
+			continue
+		}
+		var docState contracts.DocumentState
+		if err := jsonutil.UnmarshalFile(filepath.Join(currentDir, fileName), &docState); err != nil {
+			log.Warnf("Ignoring document state %v that cannot be read: %v", fileName, err)
+			continue
+		}
+		if docState.DocumentInformation.DocumentStatus == contracts.ResultStatusInProgress {
+			documents = append(documents, fileName)
+		}
+	}
+	return documents, nil
+}
+
+// waitForInFlightDocuments waits for the documents executed by the agent to complete before the agent is restarted,
+// and returns an error when documents are still running after inFlightDocumentsMaxWait unless the update is forced
+func waitForInFlightDocuments(mgr *updateManager, log log.T, updateDetail *UpdateDetail) error {
+	if updateDetail.Force {
+		log.Infof("Update is forced, the agent is restarted without waiting for in-flight documents")
+		return nil
+	}
+
+	deadline := time.Now().Add(inFlightDocumentsMaxWait)
+	for {
+		documents, err := getInFlightDocuments(mgr.Context, updateDetail)
+		if err != nil {
+			log.Warnf("Failed to list in-flight documents, proceeding with the update: %v", err)
+			return nil
+		}
+		if len(documents) == 0 {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("documents %v are still in progress after waiting %v, use the force option to update the agent while documents are running", documents, inFlightDocumentsMaxWait)
+		}
+		log.Infof("Waiting for in-flight documents %v to complete before restarting the agent", documents)
+		time.Sleep(inFlightDocumentsPollInterval)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package processor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/context"
+	"github.com/aws/amazon-ssm-agent/agent/contracts"
+	"github.com/aws/amazon-ssm-agent/agent/jsonutil"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/agent/updateutil/updateconstants"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubInFlightDocuments replaces the in-flight document listing and the wait bounds for the duration of a test
+func stubInFlightDocuments(t *testing.T, list func(context.T, *UpdateDetail) ([]string, error), maxWait time.Duration) {
+	originalList, originalInterval, originalMaxWait := getInFlightDocuments, inFlightDocumentsPollInterval, inFlightDocumentsMaxWait
+	getInFlightDocuments, inFlightDocumentsPollInterval, inFlightDocumentsMaxWait = list, time.Millisecond, maxWait
+	t.Cleanup(func() {
+		getInFlightDocuments, inFlightDocumentsPollInterval, inFlightDocumentsMaxWait = originalList, originalInterval, originalMaxWait
+	})
+}
+
+func createInFlightUpdaterStub(isInstallCalled *bool) *Updater {
+	updater := createDefaultUpdaterStub()
+	waitForCloudInit = func(log log.T, timeoutSeconds int) error {
+		return nil
+	}
+	updater.mgr.install = func(mgr *updateManager, log log.T, version string, updateDetail *UpdateDetail) (exitCode updateconstants.UpdateScriptExitCode, err error) {
+		*isInstallCalled = true
+		return exitCode, nil
+	}
+	updater.mgr.verify = func(mgr *updateManager, log log.T, updateDetail *UpdateDetail, isRollback bool) (err error) {
+		return nil
+	}
+	updater.mgr.finalize = func(mgr *updateManager, updateDetail *UpdateDetail, errorCode string) (err error) {
+		return nil
+	}
+	return updater
+}
+
+func TestProceedUpdateWaitsForInFlightDocuments(t *testing.T) {
+	calls := 0
+	stubInFlightDocuments(t, func(context.T, *UpdateDetail) ([]string, error) {
+		calls++
+		if calls < 3 {
+			return []string{"in-flight-command-id"}, nil
+		}
+		return nil, nil
+	}, time.Minute)
+	isInstallCalled := false
+	updater := createInFlightUpdaterStub(&isInstallCalled)
+	updateDetail := createUpdateDetail(Staged)
+
+	err := proceedUpdate(updater.mgr, logmocks.NewMockLog(), updateDetail)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	assert.True(t, isInstallCalled)
+	assert.Equal(t, Installed, updateDetail.State)
+}
+
+func TestProceedUpdateFailsWhenDocumentsStayInFlight(t *testing.T) {
+	stubInFlightDocuments(t, func(context.T, *UpdateDetail) ([]string, error) {
+		return []string{"in-flight-command-id"}, nil
+	}, 10*time.Millisecond)
+	isInstallCalled := false
+	updater := createInFlightUpdaterStub(&isInstallCalled)
+	updateDetail := createUpdateDetail(Staged)
+
+	err := proceedUpdate(updater.mgr, logmocks.NewMockLog(), updateDetail)
+
+	assert.NoError(t, err)
+	assert.False(t, isInstallCalled)
+	assert.Equal(t, Completed, updateDetail.State)
+	assert.Equal(t, contracts.ResultStatusFailed, updateDetail.Result)
+	assert.True(t, strings.Contains(updateDetail.StandardOut, "in-flight-command-id"))
+}
+
+func TestProceedUpdateWithForceDoesNotWaitForInFlightDocuments(t *testing.T) {
+	stubInFlightDocuments(t, func(context.T, *UpdateDetail) ([]string, error) {
+		t.Error("in-flight documents must not be listed when the update is forced")
+		return []string{"in-flight-command-id"}, nil
+	}, time.Minute)
+	isInstallCalled := false
+	updater := createInFlightUpdaterStub(&isInstallCalled)
+	updateDetail := createUpdateDetail(Staged)
+	updateDetail.Force = true
+
+	err := proceedUpdate(updater.mgr, logmocks.NewMockLog(), updateDetail)
+
+	assert.NoError(t, err)
+	assert.True(t, isInstallCalled)
+	assert.Equal(t, Installed, updateDetail.State)
+}
+
+func TestProceedUpdateWhenListingInFlightDocumentsFails(t *testing.T) {
+	stubInFlightDocuments(t, func(context.T, *UpdateDetail) ([]string, error) {
+		return nil, fmt.Errorf("cannot get instance id")
+	}, time.Minute)
+	isInstallCalled := false
+	updater := createInFlightUpdaterStub(&isInstallCalled)
+
+	err := proceedUpdate(updater.mgr, logmocks.NewMockLog(), createUpdateDetail(Staged))
+
+	assert.NoError(t, err)
+	assert.True(t, isInstallCalled)
+}
+
+// writeDocumentState persists a document state file with the given status in the directory
+func writeDocumentState(t *testing.T, dir, documentID string, status contracts.ResultStatus) {
+	content, err := jsonutil.Marshal(contracts.DocumentState{
+		DocumentInformation: contracts.DocumentInfo{DocumentID: documentID, DocumentStatus: status},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, documentID), []byte(content), 0600))
+}
+
+func TestListInFlightDocumentsInDir(t *testing.T) {
+	currentDir := t.TempDir()
+	// the association run that triggered the update is named after the association id and run id, not the message id
+	updateDetail := &UpdateDetail{
+		MessageID:  "aws.ssm.association-id.i-1234567890",
+		DocumentID: "association-id.2024-01-01T00-00-00.000Z",
+	}
+	writeDocumentState(t, currentDir, updateDetail.DocumentID, contracts.ResultStatusInProgress)
+	writeDocumentState(t, currentDir, "in-flight-command-id", contracts.ResultStatusInProgress)
+	writeDocumentState(t, currentDir, "rebooting-command-id", contracts.ResultStatusSuccessAndReboot)
+	assert.NoError(t, os.WriteFile(filepath.Join(currentDir, "corrupt-command-id"), []byte("{"), 0600))
+
+	documents, err := listInFlightDocumentsInDir(logmocks.NewMockLog(), currentDir, updateDocumentID(updateDetail))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"in-flight-command-id"}, documents)
+}
+
+func TestListInFlightDocumentsInDirFallsBackToCommandID(t *testing.T) {
+	currentDir := t.TempDir()
+	updateDetail := &UpdateDetail{MessageID: "aws.ssm.update-command-id.i-1234567890"}
+	writeDocumentState(t, currentDir, "update-command-id", contracts.ResultStatusInProgress)
+
+	documents, err := listInFlightDocumentsInDir(logmocks.NewMockLog(), currentDir, updateDocumentID(updateDetail))
+
+	assert.NoError(t, err)
+	assert.Empty(t, documents)
+}
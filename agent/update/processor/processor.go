@@ -179,6 +179,20 @@ func validateUpdateVersion(log log.T, detail *UpdateDetail, info updateinfo.T) (
 	return nil
 }
 
+// validateTargetVersionInManifest checks the target version is listed in the manifest for the current platform,
+// the returned error code tells a version missing from the manifest apart from one not built for this platform
+func validateTargetVersionInManifest(detail *UpdateDetail) (code updateconstants.ErrorCode, err error) {
+	if detail.Manifest.HasVersion(detail.PackageName, detail.TargetVersion) {
+		return "", nil
+	}
+	if !detail.Manifest.HasVersionForAnyPlatform(detail.PackageName, detail.TargetVersion) {
+		return updateconstants.ErrorTargetVersionNotInManifest,
+			fmt.Errorf("%v target version %v is not found in the manifest %v", detail.PackageName, detail.TargetVersion, detail.ManifestURL)
+	}
+	return updateconstants.ErrorTargetVersionUnsupportedOnPlatform,
+		fmt.Errorf("%v target version %v is not available for the current platform in the manifest %v", detail.PackageName, detail.TargetVersion, detail.ManifestURL)
+}
+
 func validateInactiveVersion(context context.T, info updateinfo.T, detail *UpdateDetail) (err error) {
 	context.Log().Info("Validating inactive version for amazon ssm agent")
 	var isActive bool
@@ -369,8 +383,9 @@ func validateUpdateParam(mgr *updateManager, logger log.T, updateDetail *UpdateD
 		return mgr.failed(updateDetail, logger, updateconstants.ErrorInvalidSourceVersion, fmt.Sprintf("%v source version %v is unsupported on current platform", updateDetail.PackageName, updateDetail.SourceVersion), true)
 	}
 
-	if !updateDetail.Manifest.HasVersion(updateDetail.PackageName, updateDetail.TargetVersion) {
-		return mgr.failed(updateDetail, logger, updateconstants.ErrorInvalidTargetVersion, fmt.Sprintf("%v target version %v is unsupported on current platform", updateDetail.PackageName, updateDetail.TargetVersion), true)
+	// Validate target version can be installed from the manifest before attempting the update
+	if code, err := validateTargetVersionInManifest(updateDetail); err != nil {
+		return mgr.failed(updateDetail, logger, code, err.Error(), true)
 	}
 
 	// Validate target version is supported
@@ -473,6 +488,10 @@ func proceedUpdate(mgr *updateManager, log log.T, updateDetail *UpdateDetail) (e
 		log.Warnf("error waiting for cloud-init: %v", err)
 	}
 
+	if err = waitForInFlightDocuments(mgr, log, updateDetail); err != nil {
+		return mgr.failed(updateDetail, log, updateconstants.ErrorDocumentsInProgress, err.Error(), true)
+	}
+
 	log.Infof(
 		"Attempting to upgrade from %v to %v",
 		updateDetail.SourceVersion,
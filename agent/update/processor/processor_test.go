@@ -982,8 +982,9 @@ func TestValidateUpdateParam_AllowDowngrade_SourceVersionNotExist(t *testing.T)
 	assert.Equal(t, "", updateDetail.StandardError)
 }
 
-func TestValidateUpdateParam_TargetVersionNotExist(t *testing.T) {
-	// setup
+// runValidateUpdateParamWithTargetVersion validates an update whose target version is not listed in the manifest
+// for the current platform, and returns the error code the update failed with
+func runValidateUpdateParamWithTargetVersion(t *testing.T, inAnyPlatform bool) (*UpdateDetail, string) {
 	var logger = logmocks.NewMockLog()
 	updater := createDefaultUpdaterStub()
 
@@ -993,11 +994,14 @@ func TestValidateUpdateParam_TargetVersionNotExist(t *testing.T) {
 	manifest := &updatemanifestmocks.T{}
 	manifest.On("HasVersion", mock.Anything, updateDetail.SourceVersion).Return(true)
 	manifest.On("HasVersion", mock.Anything, updateDetail.TargetVersion).Return(false)
+	manifest.On("HasVersionForAnyPlatform", mock.Anything, updateDetail.TargetVersion).Return(inAnyPlatform)
 	updateDetail.Manifest = manifest
 
+	errorCode := ""
 	finalizeCalled := false
 	updater.mgr.finalize = func(mgr *updateManager, updateDetail *UpdateDetail, code string) (err error) {
 		finalizeCalled = true
+		errorCode = code
 		return nil
 	}
 
@@ -1017,9 +1021,22 @@ func TestValidateUpdateParam_TargetVersionNotExist(t *testing.T) {
 	assert.Equal(t, Completed, updateDetail.State)
 	assert.Equal(t, contracts.ResultStatusFailed, updateDetail.Result)
 	assert.False(t, updateDetail.RequiresUninstall)
-
-	assert.Contains(t, updateDetail.StandardOut, "target version 6.0.0.0 is unsupported on current platform")
 	assert.Equal(t, "", updateDetail.StandardError)
+	return updateDetail, errorCode
+}
+
+func TestValidateUpdateParam_TargetVersionNotExist(t *testing.T) {
+	updateDetail, errorCode := runValidateUpdateParamWithTargetVersion(t, false)
+
+	assert.Equal(t, string(updateconstants.ErrorTargetVersionNotInManifest), errorCode)
+	assert.Contains(t, updateDetail.StandardOut, "target version 6.0.0.0 is not found in the manifest")
+}
+
+func TestValidateUpdateParam_TargetVersionUnsupportedOnPlatform(t *testing.T) {
+	updateDetail, errorCode := runValidateUpdateParamWithTargetVersion(t, true)
+
+	assert.Equal(t, string(updateconstants.ErrorTargetVersionUnsupportedOnPlatform), errorCode)
+	assert.Contains(t, updateDetail.StandardOut, "target version 6.0.0.0 is not available for the current platform")
 }
 
 func TestValidateUpdateParam_FailInvalidVersion(t *testing.T) {
@@ -1030,6 +1047,7 @@ func TestValidateUpdateParam_FailInvalidVersion(t *testing.T) {
 	manifest := &updatemanifestmocks.T{}
 	manifest.On("HasVersion", mock.Anything, updateDetail.SourceVersion).Return(true)
 	manifest.On("HasVersion", mock.Anything, updateDetail.TargetVersion).Return(false)
+	manifest.On("HasVersionForAnyPlatform", mock.Anything, updateDetail.TargetVersion).Return(false)
 	manifest.On("IsVersionActive", mock.Anything, mock.Anything).Return(false, nil)
 	updateDetail.Manifest = manifest
 
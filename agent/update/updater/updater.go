@@ -61,6 +61,7 @@ var (
 	targetHash          *string
 	packageName         *string
 	messageID           *string
+	documentID          *string
 	stdout              *string
 	stderr              *string
 	outputKeyPrefix     *string
@@ -68,6 +69,7 @@ var (
 	manifestURL         *string
 	selfUpdate          *bool
 	disableDowngrade    *bool
+	force               *bool
 	upstreamServiceName *string
 )
 
@@ -88,6 +90,7 @@ func init() {
 
 	packageName = flag.String(updateconstants.PackageNameCmd, "", "target Agent Version")
 	messageID = flag.String(updateconstants.MessageIDCmd, "", "target Agent Version")
+	documentID = flag.String(updateconstants.DocumentIDCmd, "", "id of the document that triggered the update")
 	stdout = flag.String(updateconstants.StdoutFileName, "", "standard output file path")
 	stderr = flag.String(updateconstants.StderrFileName, "", "standard error file path")
 	outputKeyPrefix = flag.String(updateconstants.OutputKeyPrefixCmd, "", "output key prefix")
@@ -99,6 +102,8 @@ func init() {
 
 	disableDowngrade = flag.Bool(updateconstants.DisableDowngradeCmd, false, "defines if updater is allowed to downgrade")
 
+	force = flag.Bool(updateconstants.ForceCmd, false, "defines if updater restarts the agent while documents are in progress")
+
 	upstreamServiceName = flag.String(updateconstants.UpstreamServiceName, string(contracts.MessageDeliveryService), "defines the upstream messaging service")
 
 	// Legacy flags no longer used, need to be defined or we get this error: flag provided but not defined
@@ -196,6 +201,7 @@ func updateAgent() int {
 		OutputS3BucketName:  *outputBucket,
 		PackageName:         *packageName,
 		MessageID:           *messageID,
+		DocumentID:          *documentID,
 		StartDateTime:       time.Now().UTC(),
 		RequiresUninstall:   false,
 		ManifestURL:         *manifestURL,
@@ -203,6 +209,7 @@ func updateAgent() int {
 		SelfUpdate:          *selfUpdate,
 		AllowDowngrade:      !*disableDowngrade,
 		UpstreamServiceName: *upstreamServiceName,
+		Force:               *force,
 	}
 
 	updateDetail.UpdateRoot, err = updateutil.ResolveUpdateRoot(updateDetail.SourceVersion)
@@ -193,6 +193,12 @@ const (
 	// ErrorInvalidTargetVersion represents Target version is not supported
 	ErrorInvalidTargetVersion ErrorCode = "ErrorInvalidTargetVersion"
 
+	// ErrorTargetVersionNotInManifest represents Target version is not listed in the manifest
+	ErrorTargetVersionNotInManifest ErrorCode = "ErrorTargetVersionNotInManifest"
+
+	// ErrorTargetVersionUnsupportedOnPlatform represents Target version is listed in the manifest but not for the current platform
+	ErrorTargetVersionUnsupportedOnPlatform ErrorCode = "ErrorTargetVersionUnsupportedOnPlatform"
+
 	// ErrorIncompatibleTargetVersion represents Target version is incompatible
 	ErrorIncompatibleTargetVersion ErrorCode = "ErrorIncompatibleTargetVersion"
 
@@ -295,6 +301,9 @@ const (
 	// ErrorUnexpected represents Unexpected Error
 	ErrorUnexpected ErrorCode = "ErrorUnexpected"
 
+	// ErrorDocumentsInProgress represents documents still running when the agent must be restarted
+	ErrorDocumentsInProgress ErrorCode = "ErrorDocumentsInProgress"
+
 	// ErrorUpdaterLockBusy represents message when updater lock is acquired by someone else
 	ErrorUpdaterLockBusy ErrorCode = "ErrorUpdaterLockBusy"
 
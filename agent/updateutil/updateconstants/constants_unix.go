@@ -45,6 +45,9 @@ const (
 	// MessageIDCmd represents the command argument for message id
 	MessageIDCmd = "messageid"
 
+	// DocumentIDCmd represents the command argument for the id of the document that triggered the update
+	DocumentIDCmd = "documentid"
+
 	// StdoutFileName represents the command argument for standard output file
 	StdoutFileName = "stdout"
 
@@ -66,6 +69,9 @@ const (
 	// DisableDowngradeCmd represents the command argument for if updater should not downgrade
 	DisableDowngradeCmd = "disable.downgrade"
 
+	// ForceCmd represents the command argument for if updater should not wait for the documents in progress
+	ForceCmd = "force"
+
 	// UpstreamServiceName represents the upstream messaging service the command originated from
 	UpstreamServiceName = "upstream.service.name"
 )
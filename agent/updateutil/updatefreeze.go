@@ -0,0 +1,67 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package updateutil
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/log"
+)
+
+// UpdateDeferredByFreezeMessage is reported when an agent update is not started because of a maintenance freeze
+const UpdateDeferredByFreezeMessage = "Agent update deferred due to maintenance freeze"
+
+// IsUpdateFrozen returns whether agent updates are frozen at the given time together with a description of the freeze window.
+// A freeze without start or end time is open-ended on that side, and a bound which cannot be parsed is ignored
+// so that a typo in the configuration never lets an update through during an intended freeze.
+func IsUpdateFrozen(log log.T, agentInfo appconfig.AgentInfo, now time.Time) (frozen bool, window string) {
+	if !agentInfo.UpdateFreeze {
+		return false, ""
+	}
+
+	start, startSet := parseFreezeBound(log, "UpdateFreezeStartTime", agentInfo.UpdateFreezeStartTime)
+	end, endSet := parseFreezeBound(log, "UpdateFreezeEndTime", agentInfo.UpdateFreezeEndTime)
+	if startSet && now.Before(start) {
+		return false, ""
+	}
+	if endSet && !now.Before(end) {
+		return false, ""
+	}
+
+	switch {
+	case startSet && endSet:
+		window = fmt.Sprintf("from %v until %v", start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+	case endSet:
+		window = fmt.Sprintf("until %v", end.UTC().Format(time.RFC3339))
+	case startSet:
+		window = fmt.Sprintf("since %v until further notice", start.UTC().Format(time.RFC3339))
+	default:
+		window = "until further notice"
+	}
+	return true, window
+}
+
+func parseFreezeBound(log log.T, name, value string) (bound time.Time, set bool) {
+	if value == "" {
+		return
+	}
+	var err error
+	if bound, err = time.Parse(time.RFC3339, value); err != nil {
+		log.Warnf("Ignoring invalid %v '%v', expected RFC3339 format: %v", name, value, err)
+		return time.Time{}, false
+	}
+	return bound, true
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package updateutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/appconfig"
+	"github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsUpdateFrozen(t *testing.T) {
+	now := time.Date(2024, 12, 24, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name           string
+		freeze         bool
+		start, end     string
+		expectedFrozen bool
+		expectedWindow string
+	}{
+		{"freeze disabled", false, "", "", false, ""},
+		{"freeze disabled with window", false, "2024-12-20T00:00:00Z", "2025-01-02T00:00:00Z", false, ""},
+		{"open-ended freeze", true, "", "", true, "until further notice"},
+		{"inside window", true, "2024-12-20T00:00:00Z", "2025-01-02T00:00:00Z", true, "from 2024-12-20T00:00:00Z until 2025-01-02T00:00:00Z"},
+		{"window with offset", true, "2024-12-20T01:00:00+01:00", "", true, "since 2024-12-20T00:00:00Z until further notice"},
+		{"before window", true, "2024-12-25T00:00:00Z", "2025-01-02T00:00:00Z", false, ""},
+		{"after window", true, "2024-12-20T00:00:00Z", "2024-12-24T12:00:00Z", false, ""},
+		{"only end time", true, "", "2024-12-31T00:00:00Z", true, "until 2024-12-31T00:00:00Z"},
+		{"invalid start time is ignored", true, "tomorrow", "2024-12-31T00:00:00Z", true, "until 2024-12-31T00:00:00Z"},
+		{"invalid end time keeps freeze", true, "", "2024-13-45", true, "until further notice"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			agentInfo := appconfig.AgentInfo{
+				UpdateFreeze:          testCase.freeze,
+				UpdateFreezeStartTime: testCase.start,
+				UpdateFreezeEndTime:   testCase.end,
+			}
+			frozen, window := IsUpdateFrozen(log.NewMockLog(), agentInfo, now)
+			assert.Equal(t, testCase.expectedFrozen, frozen)
+			assert.Equal(t, testCase.expectedWindow, window)
+		})
+	}
+}
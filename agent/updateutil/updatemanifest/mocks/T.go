@@ -93,6 +93,20 @@ func (_m *T) HasVersion(packageName string, version string) bool {
 	return r0
 }
 
+// HasVersionForAnyPlatform provides a mock function with given fields: packageName, version
+func (_m *T) HasVersionForAnyPlatform(packageName string, version string) bool {
+	ret := _m.Called(packageName, version)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = rf(packageName, version)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // IsVersionActive provides a mock function with given fields: packageName, version
 func (_m *T) IsVersionActive(packageName string, version string) (bool, error) {
 	ret := _m.Called(packageName, version)
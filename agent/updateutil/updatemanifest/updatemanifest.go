@@ -83,6 +83,23 @@ func (m *manifestImpl) HasVersion(packageName string, version string) bool {
 	return false
 }
 
+// HasVersionForAnyPlatform returns if manifest file has particular version for package on any platform,
+// a version missing from HasVersion but found here is not supported on the current platform
+func (m *manifestImpl) HasVersionForAnyPlatform(packageName string, version string) bool {
+	for _, p := range m.manifest.Packages {
+		if p.Name == packageName {
+			for _, f := range p.Files {
+				for _, v := range f.AvailableVersions {
+					if v.Version == version || version == updateconstants.PipelineTestVersion {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
 // GetLatestVersion returns latest version for specific package
 func (m *manifestImpl) GetLatestVersion(packageName string) (result string, err error) {
 	var version = updateconstants.MinimumVersion
@@ -29,6 +29,7 @@ const (
 type T interface {
 	LoadManifest(manifestPath string) error
 	HasVersion(packageName string, version string) bool
+	HasVersionForAnyPlatform(packageName string, version string) bool
 	GetLatestVersion(packageName string) (string, error)
 	GetLatestActiveVersion(packageName string) (string, error)
 	GetDownloadURLAndHash(packageName string, version string) (string, string, error)
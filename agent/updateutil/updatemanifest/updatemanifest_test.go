@@ -64,6 +64,28 @@ func TestParseSimpleManifest_HasVersion(t *testing.T) {
 	assert.False(t, hasVersion)
 }
 
+func TestParseSimpleManifest_HasVersionForAnyPlatform(t *testing.T) {
+	context := context.NewMockDefault()
+	updateInfo := &updateinfomocks.T{}
+	packageName := "amazon-ssm-agent"
+	updateInfo.On("GenerateCompressedFileName", packageName).Return(packageName + "-linux-386.tar.gz")
+
+	manifest := New(context, updateInfo, "")
+	assert.Nil(t, manifest.LoadManifest(sampleManifests))
+
+	// version listed for the current platform
+	assert.True(t, manifest.HasVersion(packageName, "1.1.0.0"))
+	assert.True(t, manifest.HasVersionForAnyPlatform(packageName, "1.1.0.0"))
+
+	// version listed for other platforms only
+	assert.False(t, manifest.HasVersion(packageName, "1.0.178.0"))
+	assert.True(t, manifest.HasVersionForAnyPlatform(packageName, "1.0.178.0"))
+
+	// version not in the manifest
+	assert.False(t, manifest.HasVersionForAnyPlatform(packageName, "1.1.0.3"))
+	assert.False(t, manifest.HasVersionForAnyPlatform("unknown-package", "1.1.0.0"))
+}
+
 func TestParseSimpleManifest_GetDownloadURLHash(t *testing.T) {
 	context := context.NewMockDefault()
 	updateInfo := &updateinfomocks.T{}
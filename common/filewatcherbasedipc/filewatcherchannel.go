@@ -42,8 +42,28 @@ const (
 
 	consumeAttemptCount                = 5
 	consumeRetryIntervalInMilliseconds = 200
+
+	// how long and how often a message file that is missing or empty is checked again before it is read
+	messageVisibilityTimeout      = 2 * time.Second
+	messageVisibilityPollInterval = 50 * time.Millisecond
 )
 
+// WaitForMessageVisibility makes the channels created afterwards wait for message files that are missing or empty
+// when they are consumed, which happens when the channel directory is on a network file system slow to sync.
+// It is set from the agent configuration by the processes using the channels.
+var WaitForMessageVisibility = false
+
+// messageFileSystem is the file system access used to check the visibility of the message files
+type messageFileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+}
+
+type osMessageFileSystem struct{}
+
+func (osMessageFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
 // TODO add unittest
 type fileWatcherChannel struct {
 	logger        log.T
@@ -61,6 +81,8 @@ type fileWatcherChannel struct {
 	shouldReadRetry          bool
 	isWatcherClosed          bool
 	watcherClosedChan        chan bool
+	waitForMessageVisibility bool
+	fs                       messageFileSystem
 }
 
 //TODO make this constructor private
@@ -77,16 +99,24 @@ func NewFileWatcherChannel(logger log.T, mode Mode, name string, shouldReadRetry
 
 	tmpPath := filepath.Join(name, "tmp")
 	curTime := time.Now()
+	// only remove the channel directory on failure when this call created it, the other end of the
+	// channel may already be using it and channel creation can be retried
+	_, statErr := os.Stat(name)
+	removeIfCreated := func() {
+		if os.IsNotExist(statErr) {
+			os.RemoveAll(name)
+		}
+	}
 	//TODO if client is RunAs, server needs to grant client user R/W access respectively
 	if err := createIfNotExist(name); err != nil {
 		logger.Errorf("failed to create directory: %v", err)
-		os.RemoveAll(name)
+		removeIfCreated()
 		//if err occurs, the channel is not healthy anymore, should return false
 		return nil, err
 	}
 	if err := createIfNotExist(tmpPath); err != nil {
 		logger.Errorf("failed to create directory: %v", err)
-		os.RemoveAll(name)
+		removeIfCreated()
 		//if err occurs, the channel is not healthy anymore, should return false
 		return nil, err
 	}
@@ -98,28 +128,31 @@ func NewFileWatcherChannel(logger log.T, mode Mode, name string, shouldReadRetry
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		logger.Errorf("filewatcher listener encountered error when start watcher: %v", err)
-		os.RemoveAll(name)
+		removeIfCreated()
 		return nil, err
 	}
 
 	if err = watcher.Add(name); err != nil {
 		logger.Errorf("filewatcher listener encountered error when add watch: %v", err)
-		os.RemoveAll(name)
+		watcher.Close()
+		removeIfCreated()
 		return nil, err
 	}
 
 	ch := &fileWatcherChannel{
-		path:              name,
-		tmpPath:           tmpPath,
-		watcher:           watcher,
-		onMessageChan:     onMessageChan,
-		logger:            logger,
-		mode:              mode,
-		counter:           0,
-		recvCounter:       0,
-		shouldReadRetry:   shouldReadRetry,
-		watcherClosedChan: make(chan bool, 1),
-		startTime:         fmt.Sprintf("%04d%02d%02d%02d%02d%02d", curTime.Year(), curTime.Month(), curTime.Day(), curTime.Hour(), curTime.Minute(), curTime.Second()),
+		path:                     name,
+		tmpPath:                  tmpPath,
+		watcher:                  watcher,
+		onMessageChan:            onMessageChan,
+		logger:                   logger,
+		mode:                     mode,
+		counter:                  0,
+		recvCounter:              0,
+		shouldReadRetry:          shouldReadRetry,
+		watcherClosedChan:        make(chan bool, 1),
+		fs:                       osMessageFileSystem{},
+		waitForMessageVisibility: WaitForMessageVisibility,
+		startTime:                fmt.Sprintf("%04d%02d%02d%02d%02d%02d", curTime.Year(), curTime.Month(), curTime.Day(), curTime.Hour(), curTime.Minute(), curTime.Second()),
 	}
 	if ch.mode == ModeRespondent {
 		ch.shouldDeleteAfterConsume = false
@@ -326,6 +359,10 @@ func (ch *fileWatcherChannel) consume(filepath string) {
 	log := ch.logger
 	log.Debugf("consuming message under path: %v", filepath)
 
+	if ch.waitForMessageVisibility {
+		ch.awaitMessageVisibility(filepath)
+	}
+
 	var buf []byte
 	var err error
 	if ch.shouldReadRetry {
@@ -348,6 +385,31 @@ func (ch *fileWatcherChannel) consume(filepath string) {
 	ch.onMessageChan <- string(buf)
 }
 
+// awaitMessageVisibility polls a message file which is missing or empty until it becomes visible with its content
+// or messageVisibilityTimeout expires, the file written by the other end may be seen after its watcher event.
+func (ch *fileWatcherChannel) awaitMessageVisibility(filepath string) {
+	log := ch.logger
+	deadline := time.Now().Add(messageVisibilityTimeout)
+	for attempt := 1; ; attempt++ {
+		info, err := ch.fs.Stat(filepath)
+		if err == nil && info.Size() > 0 {
+			if attempt > 1 {
+				log.Debugf("message %v became visible after %v checks", filepath, attempt)
+			}
+			return
+		}
+		if err != nil && !os.IsNotExist(err) {
+			// other errors are reported when the message is read
+			return
+		}
+		if time.Now().After(deadline) {
+			log.Warnf("message %v is still missing or empty after %v", filepath, messageVisibilityTimeout)
+			return
+		}
+		time.Sleep(messageVisibilityPollInterval)
+	}
+}
+
 func fileRead(logger log.T, filepath string) (buf []byte, err error) {
 	for attempt := 0; attempt < consumeAttemptCount; attempt++ {
 		//On windows rename does not guarantee atomic access: https://github.com/golang/go/issues/8914
@@ -0,0 +1,100 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filewatcherbasedipc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// delayedFileSystem makes a message file visible only after a number of Stat calls, first empty then with its
+// content, the way a write from another host shows up on a slow syncing network file system
+type delayedFileSystem struct {
+	path         string
+	content      string
+	visibleAfter int
+	calls        int
+}
+
+func (fs *delayedFileSystem) Stat(name string) (os.FileInfo, error) {
+	fs.calls++
+	switch {
+	case fs.calls == fs.visibleAfter-1:
+		os.WriteFile(fs.path, []byte{}, 0600)
+	case fs.calls == fs.visibleAfter:
+		os.WriteFile(fs.path, []byte(fs.content), 0600)
+	}
+	return os.Stat(name)
+}
+
+func newTestConsumeChannel(t *testing.T, fs messageFileSystem, waitForMessageVisibility bool) *fileWatcherChannel {
+	return &fileWatcherChannel{
+		logger:                   logmocks.NewMockLog(),
+		path:                     t.TempDir(),
+		onMessageChan:            make(chan string, 1),
+		shouldDeleteAfterConsume: true,
+		fs:                       fs,
+		waitForMessageVisibility: waitForMessageVisibility,
+	}
+}
+
+func TestConsume_WaitsForDelayedMessageVisibility(t *testing.T) {
+	fs := &delayedFileSystem{content: "message", visibleAfter: 4}
+	ch := newTestConsumeChannel(t, fs, true)
+	fs.path = filepath.Join(ch.path, "master-1-0")
+
+	ch.consume(fs.path)
+
+	assert.Equal(t, 4, fs.calls)
+	assert.Len(t, ch.onMessageChan, 1)
+	assert.Equal(t, "message", <-ch.onMessageChan)
+	assert.NoFileExists(t, fs.path)
+}
+
+func TestConsume_DoesNotWaitForMessageVisibilityWhenDisabled(t *testing.T) {
+	fs := &delayedFileSystem{content: "message", visibleAfter: 4}
+	ch := newTestConsumeChannel(t, fs, false)
+	fs.path = filepath.Join(ch.path, "master-1-0")
+
+	ch.consume(fs.path)
+
+	assert.Equal(t, 0, fs.calls)
+	assert.Empty(t, ch.onMessageChan)
+}
+
+func TestAwaitMessageVisibility_ReturnsWhenMessageIsVisible(t *testing.T) {
+	fs := &delayedFileSystem{content: "message", visibleAfter: 1}
+	ch := newTestConsumeChannel(t, fs, true)
+	fs.path = filepath.Join(ch.path, "master-1-0")
+
+	ch.awaitMessageVisibility(fs.path)
+
+	assert.Equal(t, 1, fs.calls)
+}
+
+func TestNewFileWatcherChannel_KeepsExistingPathOnFailure(t *testing.T) {
+	// a regular file in place of the channel directory makes the creation of the tmp directory fail
+	name := filepath.Join(t.TempDir(), "channel")
+	assert.NoError(t, os.WriteFile(name, []byte("in use"), 0600))
+
+	ch, err := NewFileWatcherChannel(logmocks.NewMockLog(), ModeWorker, name, false)
+
+	assert.Error(t, err)
+	assert.Nil(t, ch)
+	assert.FileExists(t, name)
+}
@@ -17,11 +17,14 @@ package filewatcherbasedipc
 import (
 	"os"
 	"path"
+	"time"
 
+	"github.com/aws/amazon-ssm-agent/agent/backoffconfig"
 	"github.com/aws/amazon-ssm-agent/agent/fileutil"
 	"github.com/aws/amazon-ssm-agent/agent/log"
 	"github.com/aws/amazon-ssm-agent/common/channel/utils"
 	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/cenkalti/backoff/v4"
 )
 
 const (
@@ -33,6 +36,15 @@ const (
 
 type Mode string
 
+var (
+	// ChannelCreationAttemptCount is the maximum number of attempts made by CreateFileWatcherChannelWithRetry
+	ChannelCreationAttemptCount = 5
+	// ChannelCreationRetryInterval is the delay before the first retry, later retries back off exponentially
+	ChannelCreationRetryInterval = 200 * time.Millisecond
+
+	createFileWatcherChannel = CreateFileWatcherChannel
+)
+
 // IPCChannel is defined as a persistent interface for raw json datagram transmission, it is designed to adopt both file ad named pipe
 type IPCChannel interface {
 	// Send sends a raw json datagram to the channel, return when send is "complete" -- message is dropped to the persistent layer
@@ -88,6 +100,33 @@ func CreateFileWatcherChannel(log log.T, identity identity.IAgentIdentity, mode
 	return f, err, false
 }
 
+// CreateFileWatcherChannelWithRetry calls CreateFileWatcherChannel, retrying with exponential backoff
+// so that transient filesystem errors on busy hosts do not fail the command
+func CreateFileWatcherChannelWithRetry(log log.T, identity identity.IAgentIdentity, mode Mode, filename string, shouldReadRetry bool) (channel IPCChannel, err error, found bool) {
+	exponentialBackOff, err := backoffconfig.GetExponentialBackoff(ChannelCreationRetryInterval, ChannelCreationAttemptCount)
+	if err != nil {
+		return nil, err, false
+	}
+
+	attempt := 0
+	createChannel := func() (createErr error) {
+		attempt++
+		if channel, createErr, found = createFileWatcherChannel(log, identity, mode, filename, shouldReadRetry); createErr != nil {
+			log.Warnf("%v failed to create channel %v on attempt %v/%v: %v", mode, filename, attempt, ChannelCreationAttemptCount, createErr)
+		}
+		return createErr
+	}
+
+	maxRetries := ChannelCreationAttemptCount - 1
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if err = backoff.Retry(createChannel, backoff.WithMaxRetries(exponentialBackOff, uint64(maxRetries))); err != nil {
+		return nil, err, false
+	}
+	return channel, nil, found
+}
+
 // RemoveFileWatcherChannel removes the channel folder specific to the command
 func RemoveFileWatcherChannel(identity identity.IAgentIdentity, channelName string) error {
 	channelPath, err := utils.GetDefaultChannelPath(identity, channelName)
@@ -0,0 +1,87 @@
+// Copyright 2024 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may not
+// use this file except in compliance with the License. A copy of the
+// License is located at
+//
+// http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+// either express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package filewatcherbasedipc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ssm-agent/agent/log"
+	logmocks "github.com/aws/amazon-ssm-agent/agent/mocks/log"
+	"github.com/aws/amazon-ssm-agent/common/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeChannelFactory struct {
+	failures int
+	found    bool
+	channel  IPCChannel
+	calls    int
+}
+
+func (f *fakeChannelFactory) create(log log.T, identity identity.IAgentIdentity, mode Mode, filename string, shouldReadRetry bool) (IPCChannel, error, bool) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, fmt.Errorf("channel directory not yet visible"), false
+	}
+	return f.channel, nil, f.found
+}
+
+func useChannelFactory(t *testing.T, factory *fakeChannelFactory) {
+	oldCreateFileWatcherChannel := createFileWatcherChannel
+	oldRetryInterval := ChannelCreationRetryInterval
+	t.Cleanup(func() {
+		createFileWatcherChannel = oldCreateFileWatcherChannel
+		ChannelCreationRetryInterval = oldRetryInterval
+	})
+	createFileWatcherChannel = factory.create
+	ChannelCreationRetryInterval = time.Millisecond
+}
+
+func TestCreateFileWatcherChannelWithRetry_WorkerTransientFailure(t *testing.T) {
+	factory := &fakeChannelFactory{failures: 2, channel: &fileWatcherChannel{}}
+	useChannelFactory(t, factory)
+
+	channel, err, found := CreateFileWatcherChannelWithRetry(logmocks.NewMockLog(), nil, ModeWorker, "documentID", true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, factory.channel, channel)
+	assert.False(t, found)
+	assert.Equal(t, 3, factory.calls)
+}
+
+func TestCreateFileWatcherChannelWithRetry_MasterKeepsFoundFlag(t *testing.T) {
+	factory := &fakeChannelFactory{failures: 1, found: true, channel: &fileWatcherChannel{}}
+	useChannelFactory(t, factory)
+
+	channel, err, found := CreateFileWatcherChannelWithRetry(logmocks.NewMockLog(), nil, ModeMaster, "documentID", false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, factory.channel, channel)
+	assert.True(t, found)
+	assert.Equal(t, 2, factory.calls)
+}
+
+func TestCreateFileWatcherChannelWithRetry_AttemptsExhausted(t *testing.T) {
+	factory := &fakeChannelFactory{failures: ChannelCreationAttemptCount + 1, channel: &fileWatcherChannel{}}
+	useChannelFactory(t, factory)
+
+	channel, err, found := CreateFileWatcherChannelWithRetry(logmocks.NewMockLog(), nil, ModeWorker, "documentID", true)
+
+	assert.Error(t, err)
+	assert.Nil(t, channel)
+	assert.False(t, found)
+	assert.Equal(t, ChannelCreationAttemptCount, factory.calls)
+}
@@ -177,6 +177,12 @@ func (u *SelfUpdate) updateFromS3() (err error) {
 	log := u.context.Log()
 	log.Debugf("Start self updater")
 
+	// the next scheduled run picks the update up once the freeze lifts
+	if frozen, window := updateutil.IsUpdateFrozen(log, u.context.AppConfig().Agent, time.Now()); frozen {
+		log.Infof("%v %v", updateutil.UpdateDeferredByFreezeMessage, window)
+		return nil
+	}
+
 	var pid int
 	var instanceId, region string
 
@@ -20,6 +20,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aws/amazon-ssm-agent/agent/appconfig"
 	"github.com/aws/amazon-ssm-agent/agent/log"
@@ -241,6 +242,40 @@ func (suite *SelfUpdateTestSuite) TestLockWithMultipleUpdates() {
 	}
 }
 
+func (suite *SelfUpdateTestSuite) TestUpdateDeferredDuringFreeze() {
+	suite.identityMock.On("InstanceID").Return("i-123", nil).Once()
+	suite.identityMock.On("Region").Return("us-west-2", nil).Once()
+	workingDir, _ := os.Getwd()
+	lockfilePath := filepath.Join(workingDir, "lockDir")
+	lockFileName = filepath.Join(lockfilePath, "test.lock")
+	err := os.MkdirAll(lockfilePath, 0777)
+	defer func() {
+		os.RemoveAll(lockfilePath)
+	}()
+
+	executed := false
+	updateExecuteSelfUpdate = func(log log.T, region string) (pid int, err error) {
+		executed = true
+		return os.Getppid(), nil
+	}
+	mockSelfUpdateObj := SelfUpdate{context: suite.contextMock}
+
+	suite.appconfigMock.Agent.UpdateFreeze = true
+	suite.appconfigMock.Agent.UpdateFreezeEndTime = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	err = mockSelfUpdateObj.updateFromS3()
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), executed)
+	_, statErr := os.Stat(lockFileName)
+	assert.True(suite.T(), os.IsNotExist(statErr), "lock must not be taken during a freeze")
+
+	// the freeze window has ended
+	suite.appconfigMock.Agent.UpdateFreezeEndTime = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	err = mockSelfUpdateObj.updateFromS3()
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), executed)
+	suite.identityMock.AssertExpectations(suite.T())
+}
+
 // Execute the test suite
 func TestSelfUpdateTestSuite(t *testing.T) {
 	suite.Run(t, new(SelfUpdateTestSuite))